@@ -14,6 +14,10 @@ const (
 	natsImage = "nats:2.10"
 	// rabbitmqImage is the image used for RabbitMQ.
 	rabbitmqImage = "rabbitmq:4.0.6"
+	// mosquittoImage is the image used for MQTT.
+	mosquittoImage = "eclipse-mosquitto:2.0.20"
+	// pulsarImage is the image used for Pulsar.
+	pulsarImage = "apachepulsar/pulsar:3.3.2"
 )
 
 func bindBrokers(brokers map[string]*dagger.Service) func(r *dagger.Container) *dagger.Container {
@@ -50,6 +54,12 @@ func brokerServices() map[string]*dagger.Service {
 	// RabbitMQ
 	brokers["rabbitmq"] = brokerRabbitMQ().AsService()
 
+	// MQTT
+	brokers["mosquitto"] = brokerMosquitto().AsService()
+
+	// Pulsar
+	brokers["pulsar"] = brokerPulsar().AsService()
+
 	return brokers
 }
 
@@ -287,3 +297,26 @@ func brokerRabbitMQ() *dagger.Container {
 		// Add exposed ports
 		WithExposedPort(5672)
 }
+
+// brokerMosquitto returns a container for the Mosquitto MQTT broker.
+func brokerMosquitto() *dagger.Container {
+	return dag.Container().
+		// Add base image
+		From(mosquittoImage).
+		// Add exposed ports
+		WithExposedPort(1883).
+		// Start Mosquitto accepting anonymous connections from other containers
+		WithoutEntrypoint().
+		WithExec([]string{"mosquitto", "-c", "/mosquitto-no-auth.conf"})
+}
+
+// brokerPulsar returns a container for the Pulsar broker.
+func brokerPulsar() *dagger.Container {
+	return dag.Container().
+		// Add base image
+		From(pulsarImage).
+		// Add exposed ports
+		WithExposedPort(6650).
+		// Start Pulsar in standalone mode
+		WithExec([]string{"bin/pulsar", "standalone"})
+}
@@ -3,25 +3,55 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/options"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// ErrInvalidGenerate happens when using an invalid generation argument.
 	ErrInvalidGenerate = errors.New("invalid generate argument")
+	// ErrInvalidConfigFile happens when the configuration file is invalid.
+	ErrInvalidConfigFile = errors.New("invalid configuration file")
 )
 
+// DefaultConfigFile is the configuration file used when it exists and no other
+// one is set.
+const DefaultConfigFile = ".asyncapi-codegen.yaml"
+
 // Flags contains all command line flags.
 type Flags struct {
+	// ConfigPath is the path of the configuration file, setting the flags
+	// that are not on the command line
+	ConfigPath string
+
 	// InputPaths are the path of the AsyncAPI specification file and its dependencies
 	InputPaths []string
 
+	// Merge states if the input files should be merged into one specification,
+	// instead of being the specification and its dependencies
+	Merge bool
+
+	// ConvertToV3 states if the AsyncAPI v2 specifications should be converted
+	// to AsyncAPI v3 before the generation
+	ConvertToV3 bool
+
+	// AllowRemoteRefs states if the references to HTTP(S) URLs should be
+	// resolved, in addition to the ones to local files
+	AllowRemoteRefs bool
+
 	// OutputPath is the path of the generated code file
 	OutputPath string
 
+	// DocsOutputPath is the path of the generated documentation files,
+	// without their extension
+	DocsOutputPath string
+
 	// PackageName is the package name of the generated code
 	PackageName string
 
@@ -32,6 +62,14 @@ type Flags struct {
 	// Broker contains the broker name whose code should be generated
 	Broker string
 
+	// Split states if the code should be generated in several files in the
+	// output directory, instead of one file
+	Split bool
+
+	// TemplateDir is the directory of the templates used in place of the
+	// default ones
+	TemplateDir string
+
 	// DisableFormatting states if the formatting should be disabled when
 	// writing the generated code
 	DisableFormatting bool
@@ -44,29 +82,148 @@ type Flags struct {
 	// Supported values: camel, none
 	NamingScheme string
 
+	// Initialisms are the words, like ID or URL, written in upper case in the
+	// generated golang names
+	Initialisms []string
+
+	// OperationPrefix is added before the operations name
+	OperationPrefix string
+
+	// OperationSuffix is added after the operations name
+	OperationSuffix string
+
 	// IgnoreStringFormat states whether the properties' format (date, date-time) should impact the type in types
 	IgnoreStringFormat bool
 
+	// StringFormats are the formats of the strings generated with a specific type.
+	// Supported values: date, date-time, uuid, duration
+	StringFormats []string
+
+	// Codecs are the codecs registered by the generated code, as the import
+	// path of their package with their type name, per content type
+	Codecs map[string]string
+
 	// ForcePointers can be used to force all struct fields to be generated as pointers
 	ForcePointers bool
+
+	// OptionalFields defines how the optional struct fields are generated.
+	// Supported values: pointer, omitempty, option
+	OptionalFields string
+
+	// Watch states if the code should be generated again each time the
+	// specification files change
+	Watch bool
+
+	// Check states if the command should fail when the generated files are
+	// not up to date, instead of writing them
+	Check bool
 }
 
 // SetToCommand adds the flags to a cobra command.
 func (f *Flags) SetToCommand(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.ConfigPath, "config", DefaultConfigFile,
+		"Configuration file, with the flags names as keys (flags on the command line take precedence)")
 	cmd.Flags().StringSliceVarP(
 		&f.InputPaths, "input", "i", []string{"asyncapi.yaml"},
 		"AsyncAPI specification file to use, and its dependencies")
-	cmd.Flags().StringVarP(&f.OutputPath, "output", "o", "asyncapi.gen.go", "Destination file")
+	cmd.Flags().BoolVar(&f.Merge, "merge", false,
+		"Merges the input files into one specification, instead of using the first one with its dependencies\n"+
+			"(AsyncAPI v3 only)")
+	cmd.Flags().BoolVar(&f.ConvertToV3, "convert-to-v3", false,
+		"Converts the AsyncAPI v2 specifications to AsyncAPI v3, and generates the code of AsyncAPI v3")
+	cmd.Flags().BoolVar(&f.AllowRemoteRefs, "allow-remote-refs", false,
+		"Resolves the references to HTTP(S) URLs, in addition to the ones to local files")
+	cmd.Flags().StringVarP(&f.OutputPath, "output", "o", "asyncapi.gen.go", "Destination file, or directory with --split")
+	cmd.Flags().StringVar(&f.DocsOutputPath, "docs-output", "",
+		"Destination of the documentation generated with '-g markdown,html', without the file extension\n"+
+			"(defaults to the output without its extension)")
 	cmd.Flags().StringVarP(&f.PackageName, "package", "p", "asyncapi", "Golang package name")
 	cmd.Flags().StringVarP(&f.Generate, "generate", "g", "user,application,types", "Generation options")
+	cmd.Flags().BoolVar(&f.Split, "split", false,
+		"Generates the code in several files (types.gen.go, channels.gen.go, app.gen.go, user.gen.go)\n"+
+			"in the output directory, instead of one file (AsyncAPI v3 only)")
+	cmd.Flags().StringVar(&f.TemplateDir, "template-dir", "",
+		"Directory of the templates used in place of the default ones, with the same path (like 'controller.tmpl')")
 	cmd.Flags().BoolVarP(&f.DisableFormatting, "disable-formatting", "f", false, "Disables the code generation formatting")
 	cmd.Flags().StringVarP(&f.ConvertKeys, "convert-keys", "c", "none",
 		"Schema property key names conversion strategy.\nSupported values: snake, camel, kebab, none.")
 	cmd.Flags().StringVarP(&f.NamingScheme, "naming-scheme", "n", "none",
 		"Naming scheme for generated golang elements.\nSupported values: camel, none.")
+	cmd.Flags().StringSliceVar(&f.Initialisms, "initialisms", nil,
+		"Words written in upper case in the generated golang names, like ID or URL")
+	cmd.Flags().StringVar(&f.OperationPrefix, "operation-prefix", "",
+		"Prefix of the operations name in the generated methods (AsyncAPI v3 only)")
+	cmd.Flags().StringVar(&f.OperationSuffix, "operation-suffix", "Operation",
+		"Suffix of the operations name in the generated methods (AsyncAPI v3 only)")
 	cmd.Flags().BoolVar(&f.IgnoreStringFormat, "ignore-string-format", false,
 		"Ignores the format (date, date-time) on string properties, generating golang string, instead of dates")
+	cmd.Flags().StringSliceVar(&f.StringFormats, "string-formats", []string{"date", "date-time", "uuid", "duration"},
+		"Formats of the string properties generated with a specific type, instead of golang string.\n"+
+			"Supported values: date, date-time, uuid, duration.")
+	cmd.Flags().StringToStringVar(&f.Codecs, "codecs", nil,
+		"Codecs registered by the generated code for content types, like 'application/yaml=github.com/acme/codecs.YAML'\n"+
+			"(AsyncAPI v3 only)")
 	cmd.Flags().BoolVar(&f.ForcePointers, "force-pointers", false, "Forces all struct fields to be generated as pointers")
+	cmd.Flags().StringVar(&f.OptionalFields, "optional-fields", "pointer",
+		"Generation of the optional struct fields (AsyncAPI v3 only).\nSupported values: pointer, omitempty, option.")
+	cmd.Flags().BoolVar(&f.Check, "check", false,
+		"Fails if the generated files are not up to date, without writing them (to detect drifts in CI)")
+	cmd.Flags().BoolVar(&f.Watch, "watch", false,
+		"Generates the code again each time the specification or the files it references change, until interrupted")
+}
+
+// LoadConfigFile sets the flags that are not on the command line from the
+// configuration file, if it exists or if it is set on the command line.
+func (f *Flags) LoadConfigFile(cmd *cobra.Command) error {
+	data, err := os.ReadFile(f.ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("config") {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+	}
+
+	for _, name := range utils.MapKeysToSortedList(config) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || name == "config" {
+			return fmt.Errorf("%w: unknown option %q", ErrInvalidConfigFile, name)
+		} else if flag.Changed {
+			continue
+		}
+
+		if err := cmd.Flags().Set(name, configValue(config[name])); err != nil {
+			return fmt.Errorf("%w: option %q: %w", ErrInvalidConfigFile, name, err)
+		}
+	}
+
+	return nil
+}
+
+// configValue returns the value of an option of the configuration file as it
+// would be on the command line, with the lists and maps separated by commas.
+func configValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return strings.Join(values, ",")
+	case map[string]any:
+		values := make([]string, 0, len(v))
+		for _, k := range utils.MapKeysToSortedList(v) {
+			values = append(values, fmt.Sprintf("%s=%v", k, v[k]))
+		}
+		return strings.Join(values, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
 }
 
 // ToCodegenOptions processes command line flags structure to code generation tool options.
@@ -74,11 +231,21 @@ func (f Flags) ToCodegenOptions() (options.Options, error) {
 	opt := options.Options{
 		OutputPath:         f.OutputPath,
 		PackageName:        f.PackageName,
+		DocsOutputPath:     f.DocsOutputPath,
+		Split:              f.Split,
+		TemplateDir:        f.TemplateDir,
 		DisableFormatting:  f.DisableFormatting,
+		Check:              f.Check,
 		ConvertKeys:        f.ConvertKeys,
 		NamingScheme:       f.NamingScheme,
+		Initialisms:        f.Initialisms,
+		OperationPrefix:    f.OperationPrefix,
+		OperationSuffix:    f.OperationSuffix,
 		IgnoreStringFormat: f.IgnoreStringFormat,
+		StringFormats:      f.StringFormats,
+		Codecs:             f.Codecs,
 		ForcePointers:      f.ForcePointers,
+		OptionalFields:     f.OptionalFields,
 	}
 
 	if f.Generate != "" {
@@ -91,6 +258,16 @@ func (f Flags) ToCodegenOptions() (options.Options, error) {
 				opt.Generate.User = true
 			case "types":
 				opt.Generate.Types = true
+			case "mocks":
+				opt.Generate.Mocks = true
+			case "fixtures":
+				opt.Generate.Fixtures = true
+			case "fuzz":
+				opt.Generate.Fuzz = true
+			case "markdown":
+				opt.Generate.Markdown = true
+			case "html":
+				opt.Generate.HTML = true
 			default:
 				return opt, fmt.Errorf("%w: %q", ErrInvalidGenerate, v)
 			}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/converter"
+	"github.com/spf13/cobra"
+)
+
+// ConvertFlags are the flags of the convert command.
+type ConvertFlags struct {
+	// InputPath is the path of the AsyncAPI v2 specification file
+	InputPath string
+
+	// OutputPath is the path of the converted AsyncAPI v3 specification file
+	OutputPath string
+}
+
+var convertFlags ConvertFlags
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Converts an AsyncAPI v2 specification to an AsyncAPI v3 specification.",
+	Long: `Converts an AsyncAPI v2 specification to an AsyncAPI v3 specification, in YAML
+or in JSON depending on the output file extension.
+
+The channels keys are the v2 channel addresses changed into valid ids, and the
+operations keys are their operationId or the channel id followed by the v2
+operation ('publish' or 'subscribe').
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return convertFlags.Convert()
+	},
+}
+
+// SetToCommand adds the flags to a cobra command.
+func (f *ConvertFlags) SetToCommand(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.InputPath, "input", "i", "asyncapi.yaml", "AsyncAPI v2 specification file to convert")
+	cmd.Flags().StringVarP(&f.OutputPath, "output", "o", "",
+		"Destination of the AsyncAPI v3 specification file (standard output if not set)")
+}
+
+// Convert converts the input file and writes it to the output.
+func (f ConvertFlags) Convert() error {
+	data, err := os.ReadFile(f.InputPath)
+	if err != nil {
+		return err
+	}
+
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	converted, err := converter.FromV2ToV3(data)
+	if err != nil {
+		return fmt.Errorf("converting %q: %w", f.InputPath, err)
+	}
+
+	return writeDocument(f.OutputPath, converted)
+}
+
+// writeDocument writes the JSON document to the output, in YAML or in JSON
+// depending on its extension, or to the standard output if not set.
+func writeDocument(path string, doc []byte) error {
+	var err error
+	if filepath.Ext(path) == ".json" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, doc, "", "  "); err != nil {
+			return err
+		}
+		doc = append(buf.Bytes(), '\n')
+	} else if doc, err = yaml.JSONToYAML(doc); err != nil {
+		return err
+	}
+
+	if path == "" {
+		_, err := os.Stdout.Write(doc)
+		return err
+	}
+	return os.WriteFile(path, doc, 0644)
+}
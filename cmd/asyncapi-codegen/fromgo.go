@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/fromgo"
+	"github.com/spf13/cobra"
+)
+
+// FromGoFlags are the flags of the from-go command.
+type FromGoFlags struct {
+	// OutputPath is the path of the generated AsyncAPI v3 specification file
+	OutputPath string
+
+	// Title and Version are the title and the version of the specification
+	Title   string
+	Version string
+}
+
+var fromGoFlags FromGoFlags
+
+var fromGoCmd = &cobra.Command{
+	Use:   "from-go [packages]",
+	Short: "Generates an AsyncAPI v3 specification from annotated Go code.",
+	Long: `Generates an AsyncAPI v3 specification from the annotated Go code of the
+packages (./... if not set), in YAML or in JSON depending on the output file
+extension.
+
+The messages are the struct types annotated with '//asyncapi:message', and the
+operations are the methods of the interfaces annotated with '//asyncapi:publisher'
+(sent messages) or '//asyncapi:subscriber' (received messages).
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fromGoFlags.FromGo(args)
+	},
+}
+
+// SetToCommand adds the flags to a cobra command.
+func (f *FromGoFlags) SetToCommand(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.OutputPath, "output", "o", "",
+		"Destination of the AsyncAPI v3 specification file (standard output if not set)")
+	cmd.Flags().StringVar(&f.Title, "title", "AsyncAPI", "Title of the specification")
+	cmd.Flags().StringVar(&f.Version, "version", "1.0.0", "Version of the specification")
+}
+
+// FromGo generates the specification of the packages and writes it to the output.
+func (f FromGoFlags) FromGo(patterns []string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	doc, err := fromgo.Generate(fromgo.Params{
+		Patterns: patterns,
+		Title:    f.Title,
+		Version:  f.Version,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeDocument(f.OutputPath, doc)
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen"
 	"github.com/spf13/cobra"
@@ -19,28 +22,70 @@ Just plug your application to your favorite message broker!
 More info on README: https://github.com/lerenn/asyncapi-codegen
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		cg, err := codegen.FromFile(flags.InputPaths[0], flags.InputPaths[1:]...)
-		if err != nil {
-			return err
-		}
+		// Only print the usage on invalid flags, not on generation errors
+		cmd.SilenceUsage = true
 
-		opt, err := flags.ToCodegenOptions()
-		if err != nil {
+		if err := flags.LoadConfigFile(cmd); err != nil {
 			return err
 		}
 
-		if err := cg.Generate(opt); err != nil {
-			return err
+		if flags.Watch {
+			return watch(cmd.Context(), generate, os.Stderr)
 		}
 
-		return nil
+		_, err = generate()
+		return err
 	},
 }
 
+// generate generates the code from the flags, and returns the files of the
+// specification.
+func generate() ([]string, error) {
+	params := codegen.FromFileParams{
+		Path:                  flags.InputPaths[0],
+		Dependencies:          flags.InputPaths[1:],
+		AllowRemoteReferences: flags.AllowRemoteRefs,
+		ConvertToV3:           flags.ConvertToV3,
+	}
+	if flags.Merge {
+		params.Dependencies, params.Documents = nil, flags.InputPaths[1:]
+	}
+
+	// Watch at least the input files if the specification can't be read
+	files := append([]string{}, flags.InputPaths...)
+
+	cg, err := codegen.FromFileWithParams(params)
+	if err != nil {
+		return files, err
+	}
+	files = append(files, cg.Files()...)
+
+	opt, err := flags.ToCodegenOptions()
+	if err != nil {
+		return files, err
+	}
+
+	return files, cg.Generate(opt)
+}
+
 func main() {
 	flags.SetToCommand(cmd)
 
-	if err := cmd.Execute(); err != nil {
+	convertFlags.SetToCommand(convertCmd)
+	cmd.AddCommand(convertCmd)
+
+	validateFlags.SetToCommand(validateCmd)
+	cmd.AddCommand(validateCmd)
+
+	fromGoFlags.SetToCommand(fromGoCmd)
+	cmd.AddCommand(fromGoCmd)
+
+	// Stop the watch mode when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := cmd.ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/validator"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/spf13/cobra"
+)
+
+// ErrInvalidSpecification is returned when the specification has issues.
+var ErrInvalidSpecification = fmt.Errorf("%w: invalid specification", extensions.ErrAsyncAPI)
+
+// ValidateFlags are the flags of the validate command.
+type ValidateFlags struct {
+	// InputPath is the path of the AsyncAPI v3 specification file
+	InputPath string
+}
+
+var validateFlags ValidateFlags
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates an AsyncAPI v3 specification.",
+	Long: `Validates the structure of an AsyncAPI v3 specification, and checks that its
+references point to existing elements (including in other local files), that
+there is no duplicate keys, that the operations have different generated names,
+and that the bindings are supported.
+
+The issues are written with their position in the files, like:
+
+  asyncapi.yaml:12:7: /channels/user: unknown field "adress"
+`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return validateFlags.Validate(cmd)
+	},
+}
+
+// SetToCommand adds the flags to a cobra command.
+func (f *ValidateFlags) SetToCommand(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.InputPath, "input", "i", "asyncapi.yaml", "AsyncAPI v3 specification file to validate")
+}
+
+// Validate validates the input file and writes its issues.
+func (f ValidateFlags) Validate(cmd *cobra.Command) error {
+	issues, err := validator.ValidateFile(f.InputPath)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		cmd.Println(issue.String())
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%w: %d issue(s) in %q", ErrInvalidSpecification, len(issues), f.InputPath)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	// watchPollInterval is the interval between the checks of the files.
+	watchPollInterval = 250 * time.Millisecond
+	// watchDebounce is the time without change after which the code is
+	// generated again, so the files saved together trigger one generation.
+	watchDebounce = 300 * time.Millisecond
+)
+
+// fileState is the state of a watched file.
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// watch generates the code, then generates it again each time the files it
+// returns change, until the context is done. The generation errors are
+// written to the output instead of stopping the watch.
+func watch(ctx context.Context, generate func() ([]string, error), out io.Writer) error {
+	files := regenerate(generate, out, nil)
+	states := statFiles(files)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Wait for the files to stop changing before generating again
+		if current := statFiles(files); !equalStates(current, states) {
+			states, changedAt = current, time.Now()
+			continue
+		} else if changedAt.IsZero() || time.Since(changedAt) < watchDebounce {
+			continue
+		}
+
+		changedAt = time.Time{}
+		files = regenerate(generate, out, files)
+		states = statFiles(files)
+	}
+}
+
+// regenerate generates the code and returns the files to watch, keeping the
+// previous ones if the generation failed.
+func regenerate(generate func() ([]string, error), out io.Writer, previous []string) []string {
+	files, err := generate()
+	if err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err)
+		return uniqueSorted(append(files, previous...))
+	}
+
+	files = uniqueSorted(files)
+	fmt.Fprintf(out, "Generated at %s, watching %d files\n", time.Now().Format(time.TimeOnly), len(files))
+	return files
+}
+
+func statFiles(files []string) map[string]fileState {
+	states := make(map[string]fileState, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			states[f] = fileState{}
+			continue
+		}
+		states[f] = fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+	}
+	return states
+}
+
+func equalStates(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for f, s := range a {
+		if other, ok := b[f]; !ok || !s.modTime.Equal(other.modTime) || s.exists != other.exists || s.size != other.size {
+			return false
+		}
+	}
+	return true
+}
+
+func uniqueSorted(files []string) []string {
+	set := make(map[string]bool, len(files))
+	res := make([]string, 0, len(files))
+	for _, f := range files {
+		if !set[f] {
+			set[f] = true
+			res = append(res, f)
+		}
+	}
+
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestWatchSuite(t *testing.T) {
+	suite.Run(t, new(WatchSuite))
+}
+
+type WatchSuite struct {
+	suite.Suite
+	spec   string
+	ref    string
+	writes int
+
+	mutex       sync.Mutex
+	generations int
+	err         error
+}
+
+func (suite *WatchSuite) SetupTest() {
+	watchPollInterval, watchDebounce = 10*time.Millisecond, 50*time.Millisecond
+
+	dir := suite.T().TempDir()
+	suite.spec, suite.ref = filepath.Join(dir, "asyncapi.yaml"), filepath.Join(dir, "schemas.yaml")
+	suite.write(suite.spec, "spec")
+	suite.write(suite.ref, "ref")
+
+	suite.writes, suite.generations, suite.err = 0, 0, nil
+}
+
+func (suite *WatchSuite) write(path, content string) {
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+
+	// Change the modification time, as the writes can happen in the same tick
+	suite.writes++
+	at := time.Now().Add(time.Duration(suite.writes) * time.Second)
+	suite.Require().NoError(os.Chtimes(path, at, at))
+}
+
+// generate counts the generations, and returns the specification files with
+// the error set by the test.
+func (suite *WatchSuite) generate() ([]string, error) {
+	suite.mutex.Lock()
+	defer suite.mutex.Unlock()
+
+	suite.generations++
+	if suite.err != nil {
+		// Only the input file is known when the specification can't be read
+		return []string{suite.spec}, suite.err
+	}
+	return []string{suite.spec, suite.ref}, nil
+}
+
+func (suite *WatchSuite) waitGenerations(n int) {
+	suite.Require().Eventually(func() bool {
+		suite.mutex.Lock()
+		defer suite.mutex.Unlock()
+		return suite.generations == n
+	}, time.Second, 5*time.Millisecond)
+}
+
+// watch watches until the returned function is called.
+func (suite *WatchSuite) watch(out *bytes.Buffer) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watch(ctx, suite.generate, out) }()
+
+	return func() {
+		cancel()
+		suite.Require().NoError(<-done)
+	}
+}
+
+func (suite *WatchSuite) TestGenerateOnChange() {
+	var out bytes.Buffer
+	stop := suite.watch(&out)
+	defer stop()
+	suite.waitGenerations(1)
+
+	// A change of a referenced file should generate again
+	suite.write(suite.ref, "ref changed")
+	suite.waitGenerations(2)
+
+	// Several changes in a row should only generate once
+	suite.write(suite.spec, "spec changed")
+	suite.write(suite.ref, "ref changed again")
+	suite.waitGenerations(3)
+	time.Sleep(3 * watchDebounce)
+	suite.waitGenerations(3)
+}
+
+func (suite *WatchSuite) TestKeepWatchingOnError() {
+	var out bytes.Buffer
+	stop := suite.watch(&out)
+	suite.waitGenerations(1)
+
+	// The error should be reported without stopping the watch, and the files
+	// watched before it should still be watched
+	suite.mutex.Lock()
+	suite.err = errors.New("invalid specification")
+	suite.mutex.Unlock()
+	suite.write(suite.spec, "invalid")
+	suite.waitGenerations(2)
+
+	suite.mutex.Lock()
+	suite.err = nil
+	suite.mutex.Unlock()
+	suite.write(suite.ref, "fixed")
+	suite.waitGenerations(3)
+
+	stop()
+	suite.Require().Contains(out.String(), "Error: invalid specification\n")
+}
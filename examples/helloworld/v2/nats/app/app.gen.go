@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
@@ -50,18 +51,13 @@ func (c AppController) wrapMiddlewares(
 	middlewares []extensions.Middleware,
 	callback extensions.NextMiddleware,
 ) func(ctx context.Context, msg *extensions.BrokerMessage) error {
-	var called bool
-
 	// If there is no more middleware
 	if len(middlewares) == 0 {
-		return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-			// Call the callback if it exists and it has not been called already
-			if callback != nil && !called {
-				called = true
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
 				return callback(ctx)
 			}
-
-			// Nil can be returned, as the callback has already been called
 			return nil
 		}
 	}
@@ -69,29 +65,27 @@ func (c AppController) wrapMiddlewares(
 	// Get the next function to call from next middlewares or callback
 	next := c.wrapMiddlewares(middlewares[1:], callback)
 
-	// Wrap middleware into a check function that will call execute the middleware
-	// and call the next wrapped middleware if the returned function has not been
-	// called already
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
 	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-		// Call the middleware and the following if it has not been done already
-		if !called {
-			// Create the next call with the context and the message
-			nextWithArgs := func(ctx context.Context) error {
-				return next(ctx, msg)
-			}
-
-			// Call the middleware and register it as already called
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
 			called = true
-			if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
-				return err
-			}
+			return next(ctx, msg)
+		}
 
-			// If next has already been called in middleware, it should not be executed again
-			return nextWithArgs(ctx)
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
 		}
 
-		// Nil can be returned, as the next middleware has already been called
-		return nil
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
 	}
 }
 
@@ -216,7 +210,7 @@ func (c *AppController) listenToHelloNextMessage(
 		// Process message
 		msg, err := brokerMessageToHelloMessage(acknowledgeableBrokerMessage.BrokerMessage)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
 		}
 
 		// Execute the subscription function
@@ -224,14 +218,16 @@ func (c *AppController) listenToHelloNextMessage(
 			return err
 		}
 
-		acknowledgeableBrokerMessage.Ack()
-
 		return nil
-	}); err != nil {
+	}); err != nil && !errors.Is(err, extensions.ErrSkipMessage) {
 		c.errorHandler(msgCtx, path, &acknowledgeableBrokerMessage, err)
 		// On error execute the acknowledgeableBrokerMessage nack() function and
 		// let the BrokerAcknowledgment decide what is the right nack behavior for the broker
 		acknowledgeableBrokerMessage.Nak()
+	} else {
+		// Acknowledge the message once handled by the middlewares and the
+		// subscription function, or skipped by a middleware
+		acknowledgeableBrokerMessage.Ack()
 	}
 
 	return false, nil
@@ -298,7 +294,8 @@ func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
 	}
 }
 
-// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
 func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
 	return func(controller *controller) {
 		controller.errorHandler = handler
@@ -44,18 +44,13 @@ func (c UserController) wrapMiddlewares(
 	middlewares []extensions.Middleware,
 	callback extensions.NextMiddleware,
 ) func(ctx context.Context, msg *extensions.BrokerMessage) error {
-	var called bool
-
 	// If there is no more middleware
 	if len(middlewares) == 0 {
-		return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-			// Call the callback if it exists and it has not been called already
-			if callback != nil && !called {
-				called = true
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
 				return callback(ctx)
 			}
-
-			// Nil can be returned, as the callback has already been called
 			return nil
 		}
 	}
@@ -63,29 +58,27 @@ func (c UserController) wrapMiddlewares(
 	// Get the next function to call from next middlewares or callback
 	next := c.wrapMiddlewares(middlewares[1:], callback)
 
-	// Wrap middleware into a check function that will call execute the middleware
-	// and call the next wrapped middleware if the returned function has not been
-	// called already
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
 	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-		// Call the middleware and the following if it has not been done already
-		if !called {
-			// Create the next call with the context and the message
-			nextWithArgs := func(ctx context.Context) error {
-				return next(ctx, msg)
-			}
-
-			// Call the middleware and register it as already called
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
 			called = true
-			if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
-				return err
-			}
+			return next(ctx, msg)
+		}
 
-			// If next has already been called in middleware, it should not be executed again
-			return nextWithArgs(ctx)
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
 		}
 
-		// Nil can be returned, as the next middleware has already been called
-		return nil
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
 	}
 }
 
@@ -130,9 +123,60 @@ func (c *UserController) PublishHello(
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
 
 	// Publish the message on event-broker through middlewares
-	return c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
-		return c.broker.Publish(ctx, path, brokerMsg)
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		return extensions.Publish(ctx, c.broker, path, brokerMsg)
 	})
+	if err != nil {
+		c.errorHandler(ctx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// PublishBatchHello will publish several messages at once to 'hello' channel
+//
+// NOTE: the middlewares are executed on each message before the whole batch is published.
+func (c *UserController) PublishBatchHello(
+	ctx context.Context,
+	msgs []HelloMessage,
+) error {
+	// Get channel path
+	path := "hello"
+
+	// Set context
+	ctx = addUserContextValues(ctx, path)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Publish the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, path, brokerMsgs)
+	if err != nil {
+		for _, brokerMsg := range brokerMsgs {
+			c.errorHandler(ctx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
 }
 
 // AsyncAPIVersion is the version of the used AsyncAPI document
@@ -172,7 +216,8 @@ func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
 	}
 }
 
-// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
 func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
 	return func(controller *controller) {
 		controller.errorHandler = handler
@@ -5,7 +5,7 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -54,18 +54,13 @@ func (c UserController) wrapMiddlewares(
 	middlewares []extensions.Middleware,
 	callback extensions.NextMiddleware,
 ) func(ctx context.Context, msg *extensions.BrokerMessage) error {
-	var called bool
-
 	// If there is no more middleware
 	if len(middlewares) == 0 {
-		return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-			// Call the callback if it exists and it has not been called already
-			if callback != nil && !called {
-				called = true
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
 				return callback(ctx)
 			}
-
-			// Nil can be returned, as the callback has already been called
 			return nil
 		}
 	}
@@ -73,29 +68,27 @@ func (c UserController) wrapMiddlewares(
 	// Get the next function to call from next middlewares or callback
 	next := c.wrapMiddlewares(middlewares[1:], callback)
 
-	// Wrap middleware into a check function that will call execute the middleware
-	// and call the next wrapped middleware if the returned function has not been
-	// called already
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
 	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-		// Call the middleware and the following if it has not been done already
-		if !called {
-			// Create the next call with the context and the message
-			nextWithArgs := func(ctx context.Context) error {
-				return next(ctx, msg)
-			}
-
-			// Call the middleware and register it as already called
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
 			called = true
-			if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
-				return err
-			}
+			return next(ctx, msg)
+		}
 
-			// If next has already been called in middleware, it should not be executed again
-			return nextWithArgs(ctx)
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
 		}
 
-		// Nil can be returned, as the next middleware has already been called
-		return nil
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
 	}
 }
 
@@ -220,7 +213,7 @@ func (c *UserController) listenToPongNextMessage(
 		// Process message
 		msg, err := brokerMessageToPongMessage(acknowledgeableBrokerMessage.BrokerMessage)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
 		}
 
 		// Add correlation ID to context if it exists
@@ -233,14 +226,16 @@ func (c *UserController) listenToPongNextMessage(
 			return err
 		}
 
-		acknowledgeableBrokerMessage.Ack()
-
 		return nil
-	}); err != nil {
+	}); err != nil && !errors.Is(err, extensions.ErrSkipMessage) {
 		c.errorHandler(msgCtx, path, &acknowledgeableBrokerMessage, err)
 		// On error execute the acknowledgeableBrokerMessage nack() function and
 		// let the BrokerAcknowledgment decide what is the right nack behavior for the broker
 		acknowledgeableBrokerMessage.Nak()
+	} else {
+		// Acknowledge the message once handled by the middlewares and the
+		// subscription function, or skipped by a middleware
+		acknowledgeableBrokerMessage.Ack()
 	}
 
 	return false, nil
@@ -298,9 +293,66 @@ func (c *UserController) PublishPing(
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
 
 	// Publish the message on event-broker through middlewares
-	return c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
-		return c.broker.Publish(ctx, path, brokerMsg)
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		return extensions.Publish(ctx, c.broker, path, brokerMsg)
 	})
+	if err != nil {
+		c.errorHandler(ctx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// PublishBatchPing will publish several messages at once to 'ping.v2' channel
+//
+// NOTE: the middlewares are executed on each message before the whole batch is published.
+func (c *UserController) PublishBatchPing(
+	ctx context.Context,
+	msgs []PingMessage,
+) error {
+	// Get channel path
+	path := "ping.v2"
+
+	// Set context
+	ctx = addUserContextValues(ctx, path)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Set correlation ID if it does not exist
+		if id := msg.CorrelationID(); id == "" {
+			msg.SetCorrelationID(uuid.New().String())
+		}
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsCorrelationID, msg.CorrelationID())
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Publish the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, path, brokerMsgs)
+	if err != nil {
+		for _, brokerMsg := range brokerMsgs {
+			c.errorHandler(ctx, path, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
 }
 
 // WaitForPong will wait for a specific message by its correlation ID.
@@ -455,7 +507,8 @@ func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
 	}
 }
 
-// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
 func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
 	return func(controller *controller) {
 		controller.errorHandler = handler
@@ -606,8 +659,10 @@ func NewPongMessage() PongMessage {
 func brokerMessageToPongMessage(bMsg extensions.BrokerMessage) (PongMessage, error) {
 	var msg PongMessage
 
-	// Unmarshal payload to expected message payload format
-	err := json.Unmarshal(bMsg.Payload, &msg.Payload)
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
 	if err != nil {
 		return msg, err
 	}
@@ -632,8 +687,8 @@ func brokerMessageToPongMessage(bMsg extensions.BrokerMessage) (PongMessage, err
 func (msg PongMessage) toBrokerMessage() (extensions.BrokerMessage, error) {
 	// TODO: implement checks on message
 
-	// Marshal payload to JSON
-	payload, err := json.Marshal(msg.Payload)
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
 	if err != nil {
 		return extensions.BrokerMessage{}, err
 	}
@@ -5,8 +5,8 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
 
@@ -28,11 +28,19 @@ func NewUserController(bc extensions.BrokerController, options ...ControllerOpti
 
 	// Create default controller
 	controller := controller{
-		broker:        bc,
-		subscriptions: make(map[string]extensions.BrokerChannelSubscription),
-		logger:        extensions.DummyLogger{},
-		middlewares:   make([]extensions.Middleware, 0),
-		errorHandler:  extensions.DefaultErrorHandler(),
+		broker:               bc,
+		subscriptions:        make(map[string]extensions.BrokerChannelSubscription),
+		pauseGates:           make(map[string]*extensions.PauseGate),
+		logger:               extensions.DummyLogger{},
+		middlewares:          make([]extensions.Middleware, 0),
+		operationMiddlewares: make(map[string][]extensions.Middleware),
+		operationConcurrency: make(map[string]int),
+		operationAckPolicies: make(map[string]extensions.AckPolicy),
+		errorHandler:         extensions.DefaultErrorHandler(),
+		inFlight:             extensions.NewInFlight(),
+		health:               extensions.NewHealthTracker(),
+		metrics:              extensions.DummyMetricsCollector{},
+		idGenerator:          extensions.NewUUIDv7,
 	}
 
 	// Apply options
@@ -40,6 +48,22 @@ func NewUserController(bc extensions.BrokerController, options ...ControllerOpti
 		option(&controller)
 	}
 
+	// Isolate the tenants on the broker, if a tenant resolver is set
+	if controller.tenantResolver != nil {
+		controller.broker = extensions.NewTenantBroker(controller.broker, controller.tenantResolver)
+	}
+
+	// Call the reconnection hook on the reconnections of the broker
+	if notifier, ok := bc.(extensions.ReconnectNotifier); ok && controller.hooks.OnReconnected != nil {
+		ctx := context.WithValue(context.Background(), extensions.ContextKeyIsVersion, "1.0.0")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsProvider, "user")
+		onReconnected := controller.hooks.OnReconnected
+		controller.stopReconnectNotify = notifier.NotifyReconnect(func() { onReconnected(ctx) })
+	}
+
+	// Without subscription, the controller is ready right away
+	controller.health.SetReady()
+
 	return &UserController{controller: controller}, nil
 }
 
@@ -47,18 +71,13 @@ func (c UserController) wrapMiddlewares(
 	middlewares []extensions.Middleware,
 	callback extensions.NextMiddleware,
 ) func(ctx context.Context, msg *extensions.BrokerMessage) error {
-	var called bool
-
 	// If there is no more middleware
 	if len(middlewares) == 0 {
-		return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-			// Call the callback if it exists and it has not been called already
-			if callback != nil && !called {
-				called = true
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
 				return callback(ctx)
 			}
-
-			// Nil can be returned, as the callback has already been called
 			return nil
 		}
 	}
@@ -66,35 +85,41 @@ func (c UserController) wrapMiddlewares(
 	// Get the next function to call from next middlewares or callback
 	next := c.wrapMiddlewares(middlewares[1:], callback)
 
-	// Wrap middleware into a check function that will call execute the middleware
-	// and call the next wrapped middleware if the returned function has not been
-	// called already
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
 	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
-		// Call the middleware and the following if it has not been done already
-		if !called {
-			// Create the next call with the context and the message
-			nextWithArgs := func(ctx context.Context) error {
-				return next(ctx, msg)
-			}
-
-			// Call the middleware and register it as already called
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
 			called = true
-			if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
-				return err
-			}
+			return next(ctx, msg)
+		}
 
-			// If next has already been called in middleware, it should not be executed again
-			return nextWithArgs(ctx)
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
 		}
 
-		// Nil can be returned, as the next middleware has already been called
-		return nil
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
 	}
 }
 
 func (c UserController) executeMiddlewares(ctx context.Context, msg *extensions.BrokerMessage, callback extensions.NextMiddleware) error {
+	// Add the middlewares of the operation, after the controller ones
+	middlewares := c.middlewares
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperation, func(operation string) {
+		if opMiddlewares := c.operationMiddlewares[operation]; len(opMiddlewares) > 0 {
+			middlewares = append(middlewares[:len(middlewares):len(middlewares)], opMiddlewares...)
+		}
+	})
+
 	// Wrap middleware to have 'next' function when calling them
-	wrapped := c.wrapMiddlewares(c.middlewares, callback)
+	wrapped := c.wrapMiddlewares(middlewares, callback)
 
 	// Execute wrapped middlewares
 	return wrapped(ctx, msg)
@@ -106,11 +131,55 @@ func addUserContextValues(ctx context.Context, addr string) context.Context {
 	return context.WithValue(ctx, extensions.ContextKeyIsChannel, addr)
 }
 
-// Close will clean up any existing resources on the controller
+// Health returns the health of the controller: the one of its broker, if it
+// implements extensions.HealthChecker, and the one of its subscriptions.
+func (c *UserController) Health(ctx context.Context) extensions.Health {
+	return c.health.Health(ctx, c.broker)
+}
+
+// Healthy returns an error wrapping extensions.ErrUnhealthy if the broker is
+// not reachable or if a subscription has ended without unsubscribing.
+func (c *UserController) Healthy(ctx context.Context) error {
+	return c.Health(ctx).Err()
+}
+
+// Ready returns a channel closed once the controller is ready: when it has
+// subscribed to a channel, or right away if it has no operation to receive.
+func (c *UserController) Ready() <-chan struct{} {
+	return c.health.Ready()
+}
+
+// Close will clean up any existing resources on the controller.
+//
+// If enabled with WithDrainOnClose, it stops handling the new received messages
+// and waits for the ones being handled or published before, as long as the
+// context is not done.
 func (c *UserController) Close(ctx context.Context) {
+	// Wait for the messages being handled or published
+	if c.drainOnClose {
+		if err := c.inFlight.Drain(ctx); err != nil {
+			c.logger.Warning(ctx, "Closing before the end of the messages being handled or published",
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	}
+
+	// Stop calling the reconnection hook
+	if c.stopReconnectNotify != nil {
+		c.stopReconnectNotify()
+	}
+
 	// Unsubscribing remaining channels
 }
 
+// UseForPingRequestOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of PingRequestOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *UserController) UseForPingRequestOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["PingRequestOperation"] = append(c.operationMiddlewares["PingRequestOperation"], middlewares...)
+}
+
 // SendToPingRequestOperation will send a Ping message on Ping channel.
 //
 // NOTE: this won't wait for reply, use the normal version to get the reply or do the catching reply manually.
@@ -120,6 +189,10 @@ func (c *UserController) SendToPingRequestOperation(
 	ctx context.Context,
 	msg PingMessage,
 ) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
 	// Set channel address
 	addr := "ping.v3"
 
@@ -131,21 +204,106 @@ func (c *UserController) SendToPingRequestOperation(
 	// Set context
 	ctx = addUserContextValues(ctx, addr)
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "PingRequestOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForPingMessage)
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsCorrelationID, msg.CorrelationID())
 
+	// Validate message if enabled
+	if err := c.validate(msg); err != nil {
+		return err
+	}
+
 	// Convert to BrokerMessage
 	brokerMsg, err := msg.toBrokerMessage()
 	if err != nil {
 		return err
 	}
 
+	// Stamp the identifier and the publication time of the message
+	ctx = c.stamp(ctx, &brokerMsg)
+
 	// Set broker message to context
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
 
 	// Send the message on event-broker through middlewares
-	return c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
-		return c.broker.Publish(ctx, addr, brokerMsg)
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		err := extensions.Publish(ctx, c.broker, addr, brokerMsg)
+		c.metrics.MessagePublished(extensions.MetricsLabels{Channel: "ping.v3", Operation: "PingRequestOperation"}, err)
+		return err
 	})
+	if err != nil {
+		c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// SendBatchToPingRequestOperation will send several Ping messages at once on Ping channel.
+//
+// NOTE: the middlewares are executed on each message before the whole batch is sent.
+func (c *UserController) SendBatchToPingRequestOperation(
+	ctx context.Context,
+	msgs []PingMessage,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "ping.v3"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "PingRequestOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForPingMessage)
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Set correlation ID if it does not exist
+		if id := msg.CorrelationID(); id == "" {
+			msg.SetCorrelationID(uuid.New().String())
+		}
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsCorrelationID, msg.CorrelationID())
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Stamp the identifier and the publication time of the message
+		msgCtx = c.stamp(msgCtx, &brokerMsg)
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Send the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, addr, brokerMsgs)
+	metricsLabels := extensions.MetricsLabels{Channel: "ping.v3", Operation: "PingRequestOperation"}
+	for _, brokerMsg := range brokerMsgs {
+		c.metrics.MessagePublished(metricsLabels, err)
+		if err != nil {
+			c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
 }
 
 // RequestToPingRequestOperation will send a Ping message on Ping channel
@@ -155,18 +313,43 @@ func (c *UserController) SendToPingRequestOperation(
 // reply with the same correlation ID. Otherwise, it will returns the first
 // message on the reply channel.
 //
-// A timeout can be set in context to avoid blocking operation, if needed.
-
+// A timeout can be set in context, or for all requests with the WithRequestTimeout
+// option, to avoid blocking operation, if needed.
 func (c *UserController) RequestToPingRequestOperation(
 	ctx context.Context,
 	msg PingMessage,
 ) (PongMessage, error) {
+	return c.WaitForReplyToPingRequestOperation(ctx, msg, func(ctx context.Context, msg PingMessage) error {
+		return c.SendToPingRequestOperation(ctx, msg)
+	})
+}
+
+// WaitForReplyToPingRequestOperation will wait for the Pong message
+// replying to a Ping message, from Pong channel.
+//
+// The pub function should send the message: it is called after subscribing to
+// the reply channel, in order not to miss the reply.
+// The correlation ID of the message is generated if it is empty, and the reply
+// with the same correlation ID is returned, ignoring the others.
+//
+// A timeout can be set in context, or for all requests with the WithRequestTimeout
+// option, to avoid blocking operation, if needed.
+func (c *UserController) WaitForReplyToPingRequestOperation(
+	ctx context.Context,
+	msg PingMessage,
+	pub func(ctx context.Context, msg PingMessage) error,
+) (PongMessage, error) {
+	// Set the timeout of the request, if there is one
+	ctx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+
 	// Get receiving channel address
 	addr := "pong.v3"
 
 	// Set context
 	ctx = addUserContextValues(ctx, addr)
 	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "wait-for")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "PingRequestOperation")
 
 	// Subscribe to broker channel
 	sub, err := c.broker.Subscribe(ctx, addr)
@@ -178,8 +361,11 @@ func (c *UserController) RequestToPingRequestOperation(
 
 	// Close receiver on leave
 	defer func() {
-		// Stop the subscription
-		sub.Cancel(ctx)
+		// Stop the subscription, waiting for it to be released for a limited
+		// time even if the request has timed out
+		cancelCtx, cancelTimeout := context.WithTimeout(context.WithoutCancel(ctx), extensions.ReplyCancellationTimeout)
+		sub.Cancel(cancelCtx)
+		cancelTimeout()
 
 		// Logging unsubscribing
 		c.logger.Info(ctx, "Unsubscribed from channel")
@@ -191,7 +377,7 @@ func (c *UserController) RequestToPingRequestOperation(
 	}
 
 	// Send the message
-	if err := c.SendToPingRequestOperation(ctx, msg); err != nil {
+	if err := pub(ctx, msg); err != nil {
 		c.logger.Error(ctx, "error happened when sending message", extensions.LogInfo{Key: "error", Value: err.Error()})
 		return PongMessage{}, fmt.Errorf("error happened when sending message: %w", err)
 	}
@@ -202,6 +388,7 @@ func (c *UserController) RequestToPingRequestOperation(
 		msg, err := c.waitForPingRequestOperationNextResponse(ctx, addr, sub, msg)
 		if err != nil {
 			c.logger.Error(ctx, err.Error())
+			return PongMessage{}, err
 		}
 
 		// Continue if the message hasn't been received
@@ -223,6 +410,8 @@ func (c *UserController) waitForPingRequestOperationNextResponse(
 	msgCtx, cancel := context.WithCancel(context.Background())
 	msgCtx = addUserContextValues(msgCtx, addr)
 	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsDirection, "wait-for")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsOperation, "PingRequestOperation")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForPongMessage)
 	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsCorrelationID, msg.CorrelationID())
 	defer cancel()
 
@@ -267,10 +456,15 @@ func (c *UserController) waitForPingRequestOperationNextResponse(
 			return nil, err
 		}
 
+		// Validate message if enabled
+		if err := c.validate(rmsg); err != nil {
+			return nil, err
+		}
+
 		return &rmsg, nil
 	case <-ctx.Done(): // Set corresponding error if context is done
 		c.logger.Error(msgCtx, "Context done before getting message")
-		return nil, extensions.ErrContextCanceled
+		return nil, fmt.Errorf("%w: %w", extensions.ErrContextCanceled, ctx.Err())
 	}
 }
 
@@ -284,13 +478,52 @@ type controller struct {
 	broker extensions.BrokerController
 	// subscriptions is a map of all subscriptions
 	subscriptions map[string]extensions.BrokerChannelSubscription
+	// pauseGates stop reading the messages of the paused subscriptions
+	pauseGates map[string]*extensions.PauseGate
 	// logger is the logger that will be used² to log operations on controller
 	logger extensions.Logger
 	// middlewares are the middlewares that will be executed when sending or
 	// receiving messages
 	middlewares []extensions.Middleware
+	// operationMiddlewares are the middlewares that will be executed after the
+	// controller ones for the messages of an operation, by operation name
+	operationMiddlewares map[string][]extensions.Middleware
+	// metrics collects the metrics of the sent and received messages
+	metrics extensions.MetricsCollector
 	// handler to handle errors from consumers and middlewares
 	errorHandler extensions.ErrorHandler
+	// validation is true if the messages should be validated against the
+	// constraints of the specification
+	validation bool
+	// requestTimeout is the maximum duration of the requests, if not zero
+	requestTimeout time.Duration
+	// concurrency is the maximum number of received messages handled at the
+	// same time by each subscription
+	concurrency int
+	// operationConcurrency overrides the concurrency for the subscriptions of
+	// an operation, by operation name
+	operationConcurrency map[string]int
+	// ackPolicy is the way the received messages are acknowledged
+	ackPolicy extensions.AckPolicy
+	// operationAckPolicies override the acknowledgment policy for the
+	// messages of an operation, by operation name
+	operationAckPolicies map[string]extensions.AckPolicy
+	// inFlight tracks the messages being handled or published
+	inFlight *extensions.InFlight
+	// drainOnClose is true if the controller should wait for the messages
+	// being handled or published when closing
+	drainOnClose bool
+	// health tracks the health of the subscriptions
+	health *extensions.HealthTracker
+	// hooks are called on the lifecycle events of the subscriptions
+	hooks extensions.SubscriptionHooks
+	// stopReconnectNotify stops the notification of the broker reconnections
+	stopReconnectNotify func()
+	// idGenerator generates the identifiers of the sent messages
+	idGenerator extensions.IDGenerator
+	// tenantResolver resolves the tenant of the publications and subscriptions,
+	// if the tenants are isolated
+	tenantResolver extensions.TenantResolver
 }
 
 // ControllerOption is the type of the options that can be passed
@@ -311,13 +544,192 @@ func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
 	}
 }
 
-// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions
+// WithMetrics attaches a metrics collector, that will record the metrics of
+// the sent and received messages
+func WithMetrics(collector extensions.MetricsCollector) ControllerOption {
+	return func(controller *controller) {
+		controller.metrics = collector
+	}
+}
+
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
 func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
 	return func(controller *controller) {
 		controller.errorHandler = handler
 	}
 }
 
+// WithValidation enables the validation of the messages against the constraints
+// of the specification, before sending them and after receiving them. Invalid
+// messages are rejected with extensions.ValidationErrors.
+func WithValidation() ControllerOption {
+	return func(controller *controller) {
+		controller.validation = true
+	}
+}
+
+// WithRequestTimeout sets the maximum duration of each request waiting for a
+// reply, in addition to the context deadline. The requests that time out return
+// an error wrapping extensions.ErrContextCanceled and context.DeadlineExceeded.
+func WithRequestTimeout(timeout time.Duration) ControllerOption {
+	return func(controller *controller) {
+		controller.requestTimeout = timeout
+	}
+}
+
+// WithConcurrency sets the maximum number of received messages handled
+// concurrently by each subscription, on a pool of workers. By default, the
+// messages are handled one at a time, in the order they are received: with a
+// concurrency above 1, this order is not guaranteed anymore, and the messages
+// can be acknowledged in another order.
+func WithConcurrency(workers int) ControllerOption {
+	return func(controller *controller) {
+		controller.concurrency = workers
+	}
+}
+
+// WithAckPolicy sets the way the received messages are acknowledged once
+// handled. By default, the messages handled without error are acknowledged, and
+// the others are negatively acknowledged.
+func WithAckPolicy(policy extensions.AckPolicy) ControllerOption {
+	return func(controller *controller) {
+		controller.ackPolicy = policy
+	}
+}
+
+// WithDrainOnClose makes the controller drain when closing: it stops handling
+// the new received messages, that are left to the broker, and waits for the
+// messages being handled or published (until the context given to Close is
+// done) before unsubscribing from the channels.
+func WithDrainOnClose() ControllerOption {
+	return func(controller *controller) {
+		controller.drainOnClose = true
+	}
+}
+
+// WithSubscriptionHooks sets the hooks called on the lifecycle events of the
+// subscriptions: subscription, unsubscription, reconnection of the broker (if
+// it implements extensions.ReconnectNotifier) and received messages dropped.
+func WithSubscriptionHooks(hooks extensions.SubscriptionHooks) ControllerOption {
+	return func(controller *controller) {
+		controller.hooks = hooks
+	}
+}
+
+// WithIDGenerator sets the generator of the identifiers of the sent messages,
+// set in their 'messageId' header if it is not already set. By default, the
+// identifiers are UUIDv7.
+func WithIDGenerator(generator extensions.IDGenerator) ControllerOption {
+	return func(controller *controller) {
+		controller.idGenerator = generator
+	}
+}
+
+// WithTenantResolver isolates the tenants given by the resolver: the channels
+// addresses are prefixed by the tenant, the sent messages have it in their
+// 'tenantId' header, and the received messages of another tenant are dropped.
+//
+// The messages sent while handling a received message are for the tenant of
+// the received message, that is also in the context under
+// extensions.ContextKeyIsTenant.
+func WithTenantResolver(resolver extensions.TenantResolver) ControllerOption {
+	return func(controller *controller) {
+		controller.tenantResolver = resolver
+	}
+}
+
+// ackPolicyFor returns the acknowledgment policy of the operation.
+func (c controller) ackPolicyFor(operation string) extensions.AckPolicy {
+	if policy, exists := c.operationAckPolicies[operation]; exists {
+		return policy
+	}
+	return c.ackPolicy
+}
+
+// pause pauses the subscription to the address: its messages are not read
+// anymore, and their delivery by the broker is stopped if it supports it.
+func (c controller) pause(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Stop the delivery by the broker, or buffer the messages if it can't
+	if err := sub.Pause(ctx); err != nil {
+		c.logger.Warning(ctx, "Buffering the messages, as their delivery can't be stopped",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+
+	if c.pauseGates[addr].Pause() {
+		c.health.Paused(addr, true)
+		c.logger.Info(ctx, "Paused subscription")
+	}
+	return nil
+}
+
+// resume resumes the paused subscription to the address.
+func (c controller) resume(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Restart the delivery by the broker
+	if err := sub.Resume(ctx); err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	if c.pauseGates[addr].Resume() {
+		c.health.Paused(addr, false)
+		c.logger.Info(ctx, "Resumed subscription")
+	}
+	return nil
+}
+
+// workerPool returns the worker pool handling the received messages of the
+// operation, or nil if they are handled sequentially.
+func (c controller) workerPool(operation string) *extensions.WorkerPool {
+	workers := c.concurrency
+	if w, exists := c.operationConcurrency[operation]; exists {
+		workers = w
+	}
+	return extensions.NewWorkerPool(workers)
+}
+
+// withRequestTimeout returns the context of a request, with the timeout of the
+// requests if there is one.
+func (c controller) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// stamp sets the 'messageId' and 'sentAt' headers of the sent message if they
+// are not already set, and adds them to the context. The baggage of the
+// context is also set in the headers.
+func (c controller) stamp(ctx context.Context, msg *extensions.BrokerMessage) context.Context {
+	extensions.InjectBaggage(ctx, msg)
+	id, sentAt := extensions.StampMessage(msg, c.idGenerator, time.Now())
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsMessageID, id)
+	return context.WithValue(ctx, extensions.ContextKeyIsSentAt, sentAt)
+}
+
+// validate checks the message against the constraints of the specification,
+// if the validation is enabled.
+func (c controller) validate(msg interface{ Validate() error }) error {
+	if !c.validation {
+		return nil
+	}
+	return msg.Validate()
+}
+
 type MessageWithCorrelationID interface {
 	CorrelationID() string
 	SetCorrelationID(id string)
@@ -348,11 +760,58 @@ type HeadersFromPingMessage struct {
 	CorrelationId *string `json:"correlationId,omitempty"`
 }
 
+// Validate checks that HeadersFromPingMessage respects the constraints of the specification.
+func (t HeadersFromPingMessage) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// MarshalBrokerHeaders converts the headers into the headers of a broker message.
+func (t HeadersFromPingMessage) MarshalBrokerHeaders() (map[string][]byte, error) {
+	headers := make(map[string][]byte, 1)
+
+	// Adding CorrelationId header
+	if h := t.CorrelationId; h != nil {
+		b, err := extensions.MarshalHeader(*h)
+		if err != nil {
+			return nil, fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+		}
+		headers["correlationId"] = b
+	}
+
+	return headers, nil
+}
+
+// UnmarshalBrokerHeaders fills the headers with the headers of a broker message,
+// ignoring the unknown ones.
+func (t *HeadersFromPingMessage) UnmarshalBrokerHeaders(headers map[string][]byte) error {
+	for k, v := range headers {
+		switch k {
+		case "correlationId": // Retrieving CorrelationId header
+			var h string
+			if err := extensions.UnmarshalHeader(v, &h); err != nil {
+				return fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+			}
+			t.CorrelationId = &h
+		}
+	}
+
+	return nil
+}
+
 // PingMessagePayload is a schema from the AsyncAPI specification required in messages
 type PingMessagePayload struct {
 	Event *string `json:"event,omitempty" validate:"omitempty,eq=ping"`
 }
 
+// Validate checks that PingMessagePayload respects the constraints of the specification.
+func (t PingMessagePayload) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
 // PingMessage is the message expected for 'PingMessage' channel.
 type PingMessage struct {
 	// Headers will be used to fill the message headers
@@ -362,6 +821,19 @@ type PingMessage struct {
 	Payload PingMessagePayload
 }
 
+// Validate checks that PingMessage respects the constraints of the specification.
+func (msg PingMessage) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("headers", msg.Headers.Validate())
+
+	errs.AddNested("payload", msg.Payload.Validate())
+
+	return errs.Err()
+}
+
+// jsonSchemaForPingMessage is the JSON Schema of the payload of PingMessage.
+const jsonSchemaForPingMessage = "{\"properties\":{\"event\":{\"const\":\"ping\",\"type\":\"string\"}},\"type\":\"object\"}"
+
 func NewPingMessage() PingMessage {
 	var msg PingMessage
 
@@ -376,21 +848,17 @@ func NewPingMessage() PingMessage {
 func brokerMessageToPingMessage(bMsg extensions.BrokerMessage) (PingMessage, error) {
 	var msg PingMessage
 
-	// Unmarshal payload to expected message payload format
-	err := json.Unmarshal(bMsg.Payload, &msg.Payload)
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
 	if err != nil {
 		return msg, err
 	}
 
-	// Get each headers from broker message
-	for k, v := range bMsg.Headers {
-		switch {
-		case k == "correlationId": // Retrieving CorrelationId header
-			h := string(v)
-			msg.Headers.CorrelationId = &h
-		default:
-			// TODO: log unknown error
-		}
+	// Get the headers from broker message
+	if err := msg.Headers.UnmarshalBrokerHeaders(bMsg.Headers); err != nil {
+		return msg, err
 	}
 
 	// TODO: run checks on msg type
@@ -402,18 +870,16 @@ func brokerMessageToPingMessage(bMsg extensions.BrokerMessage) (PingMessage, err
 func (msg PingMessage) toBrokerMessage() (extensions.BrokerMessage, error) {
 	// TODO: implement checks on message
 
-	// Marshal payload to JSON
-	payload, err := json.Marshal(msg.Payload)
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
 	if err != nil {
 		return extensions.BrokerMessage{}, err
 	}
 
-	// Add each headers to broker message
-	headers := make(map[string][]byte, 1)
-
-	// Adding CorrelationId header
-	if msg.Headers.CorrelationId != nil {
-		headers["correlationId"] = []byte(*msg.Headers.CorrelationId)
+	// Convert the headers to broker message headers
+	headers, err := msg.Headers.MarshalBrokerHeaders()
+	if err != nil {
+		return extensions.BrokerMessage{}, err
 	}
 
 	return extensions.BrokerMessage{
@@ -450,11 +916,58 @@ type HeadersFromPongMessage struct {
 	CorrelationId *string `json:"correlationId,omitempty"`
 }
 
+// Validate checks that HeadersFromPongMessage respects the constraints of the specification.
+func (t HeadersFromPongMessage) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// MarshalBrokerHeaders converts the headers into the headers of a broker message.
+func (t HeadersFromPongMessage) MarshalBrokerHeaders() (map[string][]byte, error) {
+	headers := make(map[string][]byte, 1)
+
+	// Adding CorrelationId header
+	if h := t.CorrelationId; h != nil {
+		b, err := extensions.MarshalHeader(*h)
+		if err != nil {
+			return nil, fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+		}
+		headers["correlationId"] = b
+	}
+
+	return headers, nil
+}
+
+// UnmarshalBrokerHeaders fills the headers with the headers of a broker message,
+// ignoring the unknown ones.
+func (t *HeadersFromPongMessage) UnmarshalBrokerHeaders(headers map[string][]byte) error {
+	for k, v := range headers {
+		switch k {
+		case "correlationId": // Retrieving CorrelationId header
+			var h string
+			if err := extensions.UnmarshalHeader(v, &h); err != nil {
+				return fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+			}
+			t.CorrelationId = &h
+		}
+	}
+
+	return nil
+}
+
 // PongMessagePayload is a schema from the AsyncAPI specification required in messages
 type PongMessagePayload struct {
 	Event *string `json:"event,omitempty" validate:"omitempty,eq=pong"`
 }
 
+// Validate checks that PongMessagePayload respects the constraints of the specification.
+func (t PongMessagePayload) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
 // PongMessage is the message expected for 'PongMessage' channel.
 type PongMessage struct {
 	// Headers will be used to fill the message headers
@@ -464,6 +977,19 @@ type PongMessage struct {
 	Payload PongMessagePayload
 }
 
+// Validate checks that PongMessage respects the constraints of the specification.
+func (msg PongMessage) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("headers", msg.Headers.Validate())
+
+	errs.AddNested("payload", msg.Payload.Validate())
+
+	return errs.Err()
+}
+
+// jsonSchemaForPongMessage is the JSON Schema of the payload of PongMessage.
+const jsonSchemaForPongMessage = "{\"properties\":{\"event\":{\"const\":\"pong\",\"type\":\"string\"}},\"type\":\"object\"}"
+
 func NewPongMessage() PongMessage {
 	var msg PongMessage
 
@@ -478,21 +1004,17 @@ func NewPongMessage() PongMessage {
 func brokerMessageToPongMessage(bMsg extensions.BrokerMessage) (PongMessage, error) {
 	var msg PongMessage
 
-	// Unmarshal payload to expected message payload format
-	err := json.Unmarshal(bMsg.Payload, &msg.Payload)
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
 	if err != nil {
 		return msg, err
 	}
 
-	// Get each headers from broker message
-	for k, v := range bMsg.Headers {
-		switch {
-		case k == "correlationId": // Retrieving CorrelationId header
-			h := string(v)
-			msg.Headers.CorrelationId = &h
-		default:
-			// TODO: log unknown error
-		}
+	// Get the headers from broker message
+	if err := msg.Headers.UnmarshalBrokerHeaders(bMsg.Headers); err != nil {
+		return msg, err
 	}
 
 	// TODO: run checks on msg type
@@ -504,18 +1026,16 @@ func brokerMessageToPongMessage(bMsg extensions.BrokerMessage) (PongMessage, err
 func (msg PongMessage) toBrokerMessage() (extensions.BrokerMessage, error) {
 	// TODO: implement checks on message
 
-	// Marshal payload to JSON
-	payload, err := json.Marshal(msg.Payload)
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
 	if err != nil {
 		return extensions.BrokerMessage{}, err
 	}
 
-	// Add each headers to broker message
-	headers := make(map[string][]byte, 1)
-
-	// Adding CorrelationId header
-	if msg.Headers.CorrelationId != nil {
-		headers["correlationId"] = []byte(*msg.Headers.CorrelationId)
+	// Convert the headers to broker message headers
+	headers, err := msg.Headers.MarshalBrokerHeaders()
+	if err != nil {
+		return extensions.BrokerMessage{}, err
 	}
 
 	return extensions.BrokerMessage{
@@ -0,0 +1,416 @@
+// Package converter converts AsyncAPI documents between major versions.
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+var (
+	// ErrInvalidDocument is returned when the document can't be converted.
+	ErrInvalidDocument = fmt.Errorf("%w: invalid document to convert", extensions.ErrAsyncAPI)
+)
+
+const (
+	// V3Version is the version of the converted documents.
+	V3Version = "3.0.0"
+
+	// defaultSchemaFormatPrefix is the prefix of the schema formats that are
+	// the default one, and don't need a multi format schema in AsyncAPI v3.
+	defaultSchemaFormatPrefix = "application/vnd.aai.asyncapi"
+)
+
+// invalidIDCharacters are the characters that can't be in the AsyncAPI v3 ids.
+var invalidIDCharacters = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+
+// v2Operations are the AsyncAPI v2 operations of a channel, with their
+// AsyncAPI v3 action: 'publish' operations are the messages received by the
+// application, and 'subscribe' operations the messages it sends.
+var v2Operations = []struct {
+	name   string
+	action string
+}{
+	{"publish", "receive"},
+	{"subscribe", "send"},
+}
+
+// FromV2ToV3 converts an AsyncAPI v2 document in JSON to an AsyncAPI v3
+// document in JSON.
+//
+// The channels keys are the v2 channel addresses changed into valid ids, and
+// the operations keys are their operationId or the channel id followed by the
+// v2 operation ('publish' or 'subscribe').
+//
+// NOTE: references to the channels and operations of the v2 document are not
+// converted, as they don't exist in AsyncAPI v3.
+func FromV2ToV3(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if version, _ := doc["asyncapi"].(string); !strings.HasPrefix(version, "2.") {
+		return nil, fmt.Errorf("%w: version %q is not an AsyncAPI v2 version", ErrInvalidDocument, doc["asyncapi"])
+	}
+
+	converted, err := convertDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(converted)
+}
+
+func convertDocument(doc map[string]any) (map[string]any, error) {
+	res := copyFields(doc, "asyncapi", "info", "tags", "externalDocs", "servers", "channels", "components")
+	res["asyncapi"] = V3Version
+
+	// Tags and external documentation are in the info with AsyncAPI v3
+	info := copyFields(object(doc["info"]))
+	for _, key := range []string{"tags", "externalDocs"} {
+		if v, exists := doc[key]; exists {
+			info[key] = v
+		}
+	}
+	res["info"] = info
+
+	if servers := object(doc["servers"]); servers != nil {
+		res["servers"] = convertObjects(servers, convertServer)
+	}
+
+	channels, operations, err := convertChannels(object(doc["channels"]))
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) > 0 {
+		res["channels"] = channels
+	}
+	if len(operations) > 0 {
+		res["operations"] = operations
+	}
+
+	if components := object(doc["components"]); components != nil {
+		res["components"] = convertComponents(components)
+	}
+
+	return res, nil
+}
+
+// convertChannels converts the channels, with their addresses as keys, to
+// channels and operations with ids as keys.
+func convertChannels(channels map[string]any) (map[string]any, map[string]any, error) {
+	resChannels, resOperations := make(map[string]any), make(map[string]any)
+
+	for _, address := range utils.MapKeysToSortedList(channels) {
+		id := toID(address)
+		if _, exists := resChannels[id]; exists {
+			return nil, nil, fmt.Errorf("%w: channel %q has the same id %q as another channel",
+				ErrInvalidDocument, address, id)
+		}
+
+		ch := object(channels[address])
+		resChannels[id] = convertChannel(ch, address)
+
+		// Convert the operations
+		for _, op := range v2Operations {
+			v2Op := object(ch[op.name])
+			if v2Op == nil {
+				continue
+			}
+
+			opID := toID(stringField(v2Op, "operationId"))
+			if opID == "" {
+				opID = id + "_" + op.name
+			}
+			if _, exists := resOperations[opID]; exists {
+				return nil, nil, fmt.Errorf("%w: operation %q is defined several times", ErrInvalidDocument, opID)
+			}
+
+			resOperations[opID] = convertOperation(v2Op, op.action, id, object(resChannels[id]), opID)
+		}
+	}
+
+	return resChannels, resOperations, nil
+}
+
+func convertChannel(ch map[string]any, address string) map[string]any {
+	res := copyFields(ch, "publish", "subscribe", "servers", "parameters")
+	if _, isRef := ch["$ref"]; isRef {
+		return res
+	}
+
+	if address != "" {
+		res["address"] = address
+	}
+
+	// Servers are references to the servers with AsyncAPI v3
+	if servers, ok := ch["servers"].([]any); ok {
+		refs := make([]any, 0, len(servers))
+		for _, srv := range servers {
+			if name, ok := srv.(string); ok {
+				refs = append(refs, reference("#/servers/"+name))
+			}
+		}
+		res["servers"] = refs
+	}
+
+	if params := object(ch["parameters"]); params != nil {
+		res["parameters"] = convertObjects(params, convertParameter)
+	}
+
+	return res
+}
+
+// convertOperation converts the operation, adding its messages to the channel.
+func convertOperation(op map[string]any, action, channelID string, channel map[string]any, opID string) map[string]any {
+	res := copyFields(op, "operationId", "message", "security")
+	res["action"] = action
+	res["channel"] = reference("#/channels/" + channelID)
+
+	if security, ok := op["security"].([]any); ok {
+		res["security"] = convertSecurityRequirements(security)
+	}
+
+	// Get the messages, that can be one of several messages
+	msg := object(op["message"])
+	if msg == nil {
+		return res
+	}
+	msgs := []any{msg}
+	if oneOf, ok := msg["oneOf"].([]any); ok {
+		msgs = oneOf
+	}
+
+	// Add the messages to the channel
+	channelMessages := object(channel["messages"])
+	if channelMessages == nil {
+		channelMessages = make(map[string]any)
+		channel["messages"] = channelMessages
+	}
+
+	refs := make([]any, 0, len(msgs))
+	for i, m := range msgs {
+		converted := convertMessage(object(m))
+		id := channelMessageID(channelMessages, converted, messageID(object(m), opID, i, len(msgs)))
+		channelMessages[id] = converted
+		refs = append(refs, reference("#/channels/"+channelID+"/messages/"+id))
+	}
+	res["messages"] = refs
+
+	return res
+}
+
+// messageID returns the id of the message in the channel, from its reference,
+// its messageId, its name, or the operation id.
+func messageID(msg map[string]any, opID string, index, count int) string {
+	if ref := stringField(msg, "$ref"); strings.HasPrefix(ref, "#/components/messages/") {
+		return toID(strings.TrimPrefix(ref, "#/components/messages/"))
+	}
+
+	for _, key := range []string{"messageId", "name"} {
+		if id := toID(stringField(msg, key)); id != "" {
+			return id
+		}
+	}
+
+	if count > 1 {
+		return opID + "_message_" + strconv.Itoa(index)
+	}
+	return opID + "_message"
+}
+
+// channelMessageID returns the id of the message in the channel messages,
+// changing it if another message with the same id is already there.
+func channelMessageID(messages map[string]any, msg map[string]any, id string) string {
+	for i, candidate := 1, id; ; i++ {
+		existing, exists := messages[candidate]
+		if !exists || reflect.DeepEqual(existing, msg) {
+			return candidate
+		}
+		candidate = id + "_" + strconv.Itoa(i)
+	}
+}
+
+func convertMessage(msg map[string]any) map[string]any {
+	res := copyFields(msg, "messageId", "schemaFormat")
+	if _, isRef := msg["$ref"]; isRef {
+		return res
+	}
+
+	// The payloads in other formats are multi format schemas with AsyncAPI v3
+	format := stringField(msg, "schemaFormat")
+	if payload, exists := msg["payload"]; exists && format != "" && !strings.HasPrefix(format, defaultSchemaFormatPrefix) {
+		res["payload"] = map[string]any{
+			"schemaFormat": format,
+			"schema":       payload,
+		}
+	}
+
+	return res
+}
+
+func convertServer(srv map[string]any) map[string]any {
+	res := copyFields(srv, "url", "security")
+	if _, isRef := srv["$ref"]; isRef {
+		return res
+	}
+
+	// The URL is split into the host and path name with AsyncAPI v3, without
+	// the scheme that is the protocol
+	url := stringField(srv, "url")
+	if _, afterScheme, found := strings.Cut(url, "://"); found {
+		url = afterScheme
+	}
+	host, pathname, found := strings.Cut(url, "/")
+	res["host"] = host
+	if found && pathname != "" {
+		res["pathname"] = "/" + pathname
+	}
+
+	if security, ok := srv["security"].([]any); ok {
+		res["security"] = convertSecurityRequirements(security)
+	}
+
+	return res
+}
+
+// convertSecurityRequirements converts the names of the security schemes to
+// references to the security schemes.
+func convertSecurityRequirements(requirements []any) []any {
+	refs := make([]any, 0, len(requirements))
+	for _, req := range requirements {
+		for _, name := range utils.MapKeysToSortedList(object(req)) {
+			refs = append(refs, reference("#/components/securitySchemes/"+name))
+		}
+	}
+	return refs
+}
+
+// convertParameter converts the parameter, keeping the constraints of its
+// schema that are in AsyncAPI v3 parameters.
+func convertParameter(param map[string]any) map[string]any {
+	res := copyFields(param, "schema")
+	if _, isRef := param["$ref"]; isRef {
+		return res
+	}
+
+	schema := object(param["schema"])
+	if enum, ok := schema["enum"].([]any); ok {
+		values := make([]any, 0, len(enum))
+		for _, v := range enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		res["enum"] = values
+	}
+	if def, exists := schema["default"]; exists {
+		res["default"] = fmt.Sprint(def)
+	}
+	if examples, ok := schema["examples"].([]any); ok {
+		values := make([]any, 0, len(examples))
+		for _, v := range examples {
+			values = append(values, fmt.Sprint(v))
+		}
+		res["examples"] = values
+	}
+
+	return res
+}
+
+func convertSecurityScheme(scheme map[string]any) map[string]any {
+	res := copyFields(scheme, "flows")
+
+	// Scopes of the flows are the available scopes with AsyncAPI v3
+	if flows := object(scheme["flows"]); flows != nil {
+		resFlows := make(map[string]any, len(flows))
+		for name, flow := range flows {
+			resFlow := copyFields(object(flow), "scopes")
+			if scopes, exists := object(flow)["scopes"]; exists {
+				resFlow["availableScopes"] = scopes
+			}
+			resFlows[name] = resFlow
+		}
+		res["flows"] = resFlows
+	}
+
+	return res
+}
+
+func convertOperationTrait(trait map[string]any) map[string]any {
+	res := copyFields(trait, "operationId", "security")
+	if security, ok := trait["security"].([]any); ok {
+		res["security"] = convertSecurityRequirements(security)
+	}
+	return res
+}
+
+func convertComponents(components map[string]any) map[string]any {
+	res := copyFields(components)
+
+	converters := map[string]func(map[string]any) map[string]any{
+		"servers":         convertServer,
+		"parameters":      convertParameter,
+		"messages":        convertMessage,
+		"messageTraits":   convertMessage,
+		"operationTraits": convertOperationTrait,
+		"securitySchemes": convertSecurityScheme,
+		"channels": func(ch map[string]any) map[string]any {
+			return convertChannel(ch, "")
+		},
+	}
+	for key, convert := range converters {
+		if objects := object(components[key]); objects != nil {
+			res[key] = convertObjects(objects, convert)
+		}
+	}
+
+	return res
+}
+
+// convertObjects converts each object of the map.
+func convertObjects(objects map[string]any, convert func(map[string]any) map[string]any) map[string]any {
+	res := make(map[string]any, len(objects))
+	for key, obj := range objects {
+		res[key] = convert(object(obj))
+	}
+	return res
+}
+
+// copyFields returns a copy of the object without the excluded fields.
+func copyFields(obj map[string]any, excluded ...string) map[string]any {
+	res := make(map[string]any, len(obj))
+	for key, value := range obj {
+		res[key] = value
+	}
+	for _, key := range excluded {
+		delete(res, key)
+	}
+	return res
+}
+
+// object returns the value as an object, or nil if it is not one.
+func object(v any) map[string]any {
+	obj, _ := v.(map[string]any)
+	return obj
+}
+
+func stringField(obj map[string]any, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func reference(ref string) map[string]any {
+	return map[string]any{"$ref": ref}
+}
+
+// toID changes the value into a valid AsyncAPI v3 id, replacing the invalid
+// characters by underscores (like 'user/{id}/signup' into 'user_id_signup').
+func toID(s string) string {
+	return strings.Trim(invalidIDCharacters.ReplaceAllString(s, "_"), "_")
+}
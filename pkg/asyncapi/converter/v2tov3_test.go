@@ -0,0 +1,214 @@
+package converter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestV2ToV3Suite(t *testing.T) {
+	suite.Run(t, new(V2ToV3Suite))
+}
+
+type V2ToV3Suite struct {
+	suite.Suite
+}
+
+func (suite *V2ToV3Suite) convert(v2 string) map[string]any {
+	data, err := yaml.YAMLToJSON([]byte(v2))
+	suite.Require().NoError(err)
+
+	data, err = FromV2ToV3(data)
+	suite.Require().NoError(err)
+
+	var doc map[string]any
+	suite.Require().NoError(json.Unmarshal(data, &doc))
+	return doc
+}
+
+func (suite *V2ToV3Suite) convertExpected(v2, v3 string) {
+	expected, err := yaml.YAMLToJSON([]byte(v3))
+	suite.Require().NoError(err)
+
+	converted, err := json.Marshal(suite.convert(v2))
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(string(expected), string(converted))
+}
+
+func (suite *V2ToV3Suite) TestInfoAndServers() {
+	suite.convertExpected(`
+asyncapi: 2.6.0
+info:
+  title: Sample
+  version: 1.0.0
+tags:
+  - name: users
+servers:
+  production:
+    url: mqtt://broker.example.com:1883/v1
+    protocol: mqtt
+    security:
+      - user: []
+  local:
+    url: localhost:{port}
+    protocol: nats
+    variables:
+      port:
+        default: "4222"`, `
+asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+  tags:
+    - name: users
+servers:
+  production:
+    host: broker.example.com:1883
+    pathname: /v1
+    protocol: mqtt
+    security:
+      - $ref: '#/components/securitySchemes/user'
+  local:
+    host: localhost:{port}
+    protocol: nats
+    variables:
+      port:
+        default: "4222"`)
+}
+
+func (suite *V2ToV3Suite) TestChannelsAndOperations() {
+	suite.convertExpected(`
+asyncapi: 2.6.0
+channels:
+  user/{userId}/signedup:
+    description: Users signing up
+    parameters:
+      userId:
+        schema:
+          type: string
+          enum: [a, b]
+    publish:
+      message:
+        $ref: '#/components/messages/userSignedUp'
+    subscribe:
+      operationId: notifyUser
+      message:
+        oneOf:
+          - messageId: welcome
+            payload:
+              type: string
+          - name: reminder
+            payload:
+              type: string`, `
+asyncapi: 3.0.0
+info: {}
+channels:
+  user_userId_signedup:
+    address: user/{userId}/signedup
+    description: Users signing up
+    parameters:
+      userId:
+        enum: [a, b]
+    messages:
+      userSignedUp:
+        $ref: '#/components/messages/userSignedUp'
+      welcome:
+        payload:
+          type: string
+      reminder:
+        name: reminder
+        payload:
+          type: string
+operations:
+  user_userId_signedup_publish:
+    action: receive
+    channel:
+      $ref: '#/channels/user_userId_signedup'
+    messages:
+      - $ref: '#/channels/user_userId_signedup/messages/userSignedUp'
+  notifyUser:
+    action: send
+    channel:
+      $ref: '#/channels/user_userId_signedup'
+    messages:
+      - $ref: '#/channels/user_userId_signedup/messages/welcome'
+      - $ref: '#/channels/user_userId_signedup/messages/reminder'`)
+}
+
+func (suite *V2ToV3Suite) TestComponents() {
+	suite.convertExpected(`
+asyncapi: 2.6.0
+components:
+  schemas:
+    user:
+      type: object
+  messages:
+    avro:
+      messageId: avro
+      schemaFormat: application/vnd.apache.avro;version=1.9.0
+      payload:
+        type: record
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          scopes:
+            read: Read access`, `
+asyncapi: 3.0.0
+info: {}
+components:
+  schemas:
+    user:
+      type: object
+  messages:
+    avro:
+      payload:
+        schemaFormat: application/vnd.apache.avro;version=1.9.0
+        schema:
+          type: record
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          availableScopes:
+            read: Read access`)
+}
+
+func (suite *V2ToV3Suite) TestSameMessageInOperations() {
+	doc := suite.convert(`
+asyncapi: 2.6.0
+channels:
+  ping:
+    publish:
+      message:
+        name: ping
+        payload:
+          type: string
+    subscribe:
+      message:
+        oneOf:
+          - name: ping
+            payload:
+              type: string
+          - name: ping
+            payload:
+              type: integer`)
+
+	// The same message is only once in the channel, and messages with the same
+	// id and a different definition are renamed
+	channel := object(object(doc["channels"])["ping"])
+	suite.Require().Len(object(channel["messages"]), 2)
+	suite.Require().Contains(object(channel["messages"]), "ping")
+	suite.Require().Contains(object(channel["messages"]), "ping_1")
+}
+
+func (suite *V2ToV3Suite) TestInvalidVersion() {
+	_, err := FromV2ToV3([]byte(`{"asyncapi":"3.0.0"}`))
+	suite.Require().ErrorIs(err, ErrInvalidDocument)
+}
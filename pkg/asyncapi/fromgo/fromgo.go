@@ -0,0 +1,704 @@
+// Package fromgo generates AsyncAPI documents from annotated Go code.
+//
+// The messages are the struct types annotated with '//asyncapi:message', and
+// the operations are the methods of the interfaces annotated with
+// '//asyncapi:publisher' (sent messages) or '//asyncapi:subscriber' (received
+// messages), that have one of the messages as parameter:
+//
+//	// UserSignedUp is sent when a user signs up.
+//	//asyncapi:message channel=user.signedup
+//	type UserSignedUp struct {
+//		// Name is the name of the user.
+//		Name  string `json:"name"`
+//		Email string `json:"email,omitempty"`
+//	}
+//
+//	//asyncapi:subscriber
+//	type Subscriber interface {
+//		UserSignedUp(ctx context.Context, msg UserSignedUp) error
+//	}
+package fromgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrInvalidAnnotation is returned when an annotation of the Go code is invalid.
+	ErrInvalidAnnotation = fmt.Errorf("%w: invalid annotation", extensions.ErrAsyncAPI)
+	// ErrUnsupportedType is returned when a Go type can't be described by a schema.
+	ErrUnsupportedType = fmt.Errorf("%w: unsupported type", extensions.ErrAsyncAPI)
+)
+
+const (
+	// Version is the version of the generated documents.
+	Version = "3.0.0"
+
+	// AnnotationPrefix is the prefix of the annotations in the Go comments.
+	AnnotationPrefix = "asyncapi:"
+
+	// MessageAnnotation annotates the struct types that are messages, with the
+	// optional 'name', 'channel' and 'contentType' options.
+	MessageAnnotation = "message"
+	// PublisherAnnotation annotates the interfaces whose methods send messages.
+	PublisherAnnotation = "publisher"
+	// SubscriberAnnotation annotates the interfaces whose methods receive messages.
+	SubscriberAnnotation = "subscriber"
+	// OperationAnnotation annotates the methods of the publishers and
+	// subscribers, with the optional 'name' and 'channel' options.
+	OperationAnnotation = "operation"
+)
+
+var (
+	// invalidIDCharacters are the characters that can't be in the AsyncAPI v3 ids.
+	invalidIDCharacters = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+	// addressParameters are the parameters of the channels addresses.
+	addressParameters = regexp.MustCompile(`{([^}]+)}`)
+)
+
+// Params are the parameters of the document generation.
+type Params struct {
+	// Patterns are the patterns of the scanned packages, like './...'.
+	Patterns []string
+	// Dir is the directory in which the patterns are resolved (the current one
+	// if not set).
+	Dir string
+
+	// Title and Version are the title and the version of the document.
+	Title   string
+	Version string
+}
+
+// annotation is an annotation of the Go code, with its options.
+type annotation struct {
+	kind    string
+	options map[string]string
+}
+
+// message is an annotated message.
+type message struct {
+	id          string
+	channel     string
+	contentType string
+	description string
+}
+
+// generator generates a document from the loaded packages.
+type generator struct {
+	messages map[*types.TypeName]message
+	// docs are the descriptions of the types, fields and methods, by position
+	docs map[token.Pos]string
+	// enums are the values of the constants, by type
+	enums map[*types.TypeName][]any
+
+	channels   map[string]map[string]any
+	operations map[string]any
+	schemas    map[string]any
+	// schemasIDs are the ids of the schemas of the named types
+	schemasIDs map[*types.TypeName]string
+}
+
+// Generate returns the AsyncAPI v3 document, in JSON, of the annotated
+// messages and interfaces of the packages.
+func Generate(params Params) ([]byte, error) {
+	pkgs, err := load(token.NewFileSet(), params.Dir, params.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].path < pkgs[j].path })
+
+	g := generator{
+		messages:   make(map[*types.TypeName]message),
+		docs:       make(map[token.Pos]string),
+		enums:      make(map[*types.TypeName][]any),
+		channels:   make(map[string]map[string]any),
+		operations: make(map[string]any),
+		schemas:    make(map[string]any),
+		schemasIDs: make(map[*types.TypeName]string),
+	}
+
+	interfaces, err := g.scan(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.messages) == 0 {
+		return nil, fmt.Errorf("%w: no type annotated with '//%s%s'", ErrInvalidAnnotation,
+			AnnotationPrefix, MessageAnnotation)
+	}
+
+	doc, err := g.document(interfaces)
+	if err != nil {
+		return nil, err
+	}
+	doc["info"] = map[string]any{"title": params.Title, "version": params.Version}
+
+	return json.Marshal(doc)
+}
+
+// annotatedInterface is an interface annotated as publisher or subscriber.
+type annotatedInterface struct {
+	obj    *types.TypeName
+	action string
+	// methods are the methods of the interface, with their annotations
+	methods []*ast.Field
+	pkg     *loadedPackage
+}
+
+// scan gets the annotated messages, the documentation and the annotated
+// interfaces of the packages.
+func (g *generator) scan(pkgs []*loadedPackage) ([]annotatedInterface, error) {
+	var interfaces []annotatedInterface
+
+	for _, pkg := range pkgs {
+		g.scanEnums(pkg)
+
+		for _, file := range pkg.files {
+			// Get the documentation of the fields and methods
+			ast.Inspect(file, func(n ast.Node) bool {
+				if f, ok := n.(*ast.Field); ok && len(f.Names) > 0 {
+					g.docs[f.Names[0].Pos()] = comment(f.Doc, f.Comment)
+				}
+				return true
+			})
+
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					doc := ts.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+
+					obj, _ := pkg.info.Defs[ts.Name].(*types.TypeName)
+					if obj == nil {
+						continue
+					}
+					g.docs[obj.Pos()] = comment(doc, nil)
+
+					itf, err := g.scanType(pkg, obj, ts, doc)
+					if err != nil {
+						return nil, err
+					} else if itf != nil {
+						interfaces = append(interfaces, *itf)
+					}
+				}
+			}
+		}
+	}
+
+	return interfaces, nil
+}
+
+// scanType gets the message or the interface of an annotated type.
+func (g *generator) scanType(
+	pkg *loadedPackage,
+	obj *types.TypeName,
+	ts *ast.TypeSpec,
+	doc *ast.CommentGroup,
+) (*annotatedInterface, error) {
+	for _, a := range annotations(doc) {
+		switch a.kind {
+		case MessageAnnotation:
+			if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+				return nil, fmt.Errorf("%w: message %s is not a struct", ErrInvalidAnnotation, obj.Name())
+			}
+
+			id := a.options["name"]
+			if id == "" {
+				id = strcase.ToLowerCamel(firstNonEmpty(strings.TrimSuffix(obj.Name(), "Message"), obj.Name()))
+			}
+			g.messages[obj] = message{
+				id:          id,
+				channel:     a.options["channel"],
+				contentType: a.options["contentType"],
+				description: g.docs[obj.Pos()],
+			}
+		case PublisherAnnotation, SubscriberAnnotation:
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s %s is not an interface", ErrInvalidAnnotation, a.kind, obj.Name())
+			}
+
+			action := "send"
+			if a.kind == SubscriberAnnotation {
+				action = "receive"
+			}
+			return &annotatedInterface{obj: obj, action: action, methods: it.Methods.List, pkg: pkg}, nil
+		default:
+			return nil, fmt.Errorf("%w: unknown annotation %q on %s", ErrInvalidAnnotation, a.kind, obj.Name())
+		}
+	}
+
+	return nil, nil
+}
+
+// scanEnums gets the values of the constants of the named types of the package.
+func (g *generator) scanEnums(pkg *loadedPackage) {
+	scope := pkg.types.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok {
+			continue
+		}
+
+		obj := namedObject(c.Type())
+		if obj == nil {
+			continue
+		}
+
+		var value any
+		switch c.Val().Kind() {
+		case constant.String:
+			value = constant.StringVal(c.Val())
+		case constant.Int:
+			value, _ = constant.Int64Val(c.Val())
+		case constant.Float:
+			value, _ = constant.Float64Val(c.Val())
+		case constant.Bool:
+			value = constant.BoolVal(c.Val())
+		default:
+			continue
+		}
+		g.enums[obj] = append(g.enums[obj], value)
+	}
+}
+
+// document returns the document of the scanned messages and interfaces.
+func (g *generator) document(interfaces []annotatedInterface) (map[string]any, error) {
+	// Add the components messages, and the channels set on the messages
+	messages := make(map[string]any, len(g.messages))
+	for _, obj := range g.sortedMessages() {
+		msg := g.messages[obj]
+		if _, exists := messages[msg.id]; exists {
+			return nil, fmt.Errorf("%w: several messages are named %q", ErrInvalidAnnotation, msg.id)
+		}
+
+		payload, err := g.schema(obj.Type())
+		if err != nil {
+			return nil, fmt.Errorf("message %s: %w", obj.Name(), err)
+		}
+
+		m := map[string]any{"payload": payload}
+		setIfNotEmpty(m, "contentType", msg.contentType)
+		setIfNotEmpty(m, "description", msg.description)
+		messages[msg.id] = m
+
+		if msg.channel != "" {
+			g.addChannelMessage(msg.channel, msg.id)
+		}
+	}
+
+	// Add the operations of the interfaces
+	for _, itf := range interfaces {
+		for _, method := range itf.methods {
+			if err := g.addOperation(itf, method); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	doc := map[string]any{"asyncapi": Version}
+	components := map[string]any{"messages": messages}
+	if len(g.schemas) > 0 {
+		components["schemas"] = g.schemas
+	}
+	doc["components"] = components
+	if len(g.channels) > 0 {
+		doc["channels"] = g.channels
+	}
+	if len(g.operations) > 0 {
+		doc["operations"] = g.operations
+	}
+
+	return doc, nil
+}
+
+// sortedMessages returns the messages types, sorted by package and name.
+func (g *generator) sortedMessages() []*types.TypeName {
+	objs := make([]*types.TypeName, 0, len(g.messages))
+	for obj := range g.messages {
+		objs = append(objs, obj)
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].Pkg().Path() != objs[j].Pkg().Path() {
+			return objs[i].Pkg().Path() < objs[j].Pkg().Path()
+		}
+		return objs[i].Name() < objs[j].Name()
+	})
+	return objs
+}
+
+// addOperation adds the operation of a method of a publisher or subscriber.
+func (g *generator) addOperation(itf annotatedInterface, method *ast.Field) error {
+	if len(method.Names) == 0 {
+		// Embedded interfaces are annotated separately
+		return nil
+	}
+	name := method.Names[0].Name
+
+	fn, _ := itf.pkg.info.Defs[method.Names[0]].(*types.Func)
+	if fn == nil {
+		return nil
+	}
+
+	// Get the message of the method
+	var msgObj *types.TypeName
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		if obj := namedObject(params.At(i).Type()); obj != nil {
+			if _, ok := g.messages[obj]; ok {
+				msgObj = obj
+			}
+		}
+	}
+	if msgObj == nil {
+		return fmt.Errorf("%w: method %s of %s has no message as parameter", ErrInvalidAnnotation, name, itf.obj.Name())
+	}
+	msg := g.messages[msgObj]
+
+	op := annotation{options: map[string]string{}}
+	for _, a := range annotations(method.Doc) {
+		if a.kind != OperationAnnotation {
+			return fmt.Errorf("%w: unknown annotation %q on method %s of %s", ErrInvalidAnnotation,
+				a.kind, name, itf.obj.Name())
+		}
+		op = a
+	}
+
+	address := firstNonEmpty(op.options["channel"], msg.channel)
+	if address == "" {
+		return fmt.Errorf("%w: no channel for method %s of %s, nor for message %s", ErrInvalidAnnotation,
+			name, itf.obj.Name(), msgObj.Name())
+	}
+	channelID := g.addChannelMessage(address, msg.id)
+
+	id := firstNonEmpty(op.options["name"], strcase.ToLowerCamel(name))
+	if _, exists := g.operations[id]; exists {
+		return fmt.Errorf("%w: several operations are named %q", ErrInvalidAnnotation, id)
+	}
+
+	operation := map[string]any{
+		"action":   itf.action,
+		"channel":  map[string]any{"$ref": "#/channels/" + channelID},
+		"messages": []any{map[string]any{"$ref": "#/channels/" + channelID + "/messages/" + msg.id}},
+	}
+	setIfNotEmpty(operation, "description", g.docs[method.Names[0].Pos()])
+	g.operations[id] = operation
+
+	return nil
+}
+
+// addChannelMessage adds the message to the channel of the address, and
+// returns the channel id.
+func (g *generator) addChannelMessage(address, msgID string) string {
+	id := strings.Trim(invalidIDCharacters.ReplaceAllString(address, "_"), "_")
+
+	ch, exists := g.channels[id]
+	if !exists {
+		ch = map[string]any{"address": address, "messages": map[string]any{}}
+
+		params := make(map[string]any)
+		for _, match := range addressParameters.FindAllStringSubmatch(address, -1) {
+			params[match[1]] = map[string]any{}
+		}
+		if len(params) > 0 {
+			ch["parameters"] = params
+		}
+
+		g.channels[id] = ch
+	}
+
+	ch["messages"].(map[string]any)[msgID] = map[string]any{"$ref": "#/components/messages/" + msgID}
+	return id
+}
+
+// schema returns the schema of a Go type, as it is marshaled in JSON.
+func (g *generator) schema(t types.Type) (map[string]any, error) {
+	if obj := namedObject(t); obj != nil {
+		switch {
+		case obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time":
+			return map[string]any{"type": "string", "format": "date-time"}, nil
+		case obj.Pkg() != nil && obj.Pkg().Path() == "encoding/json" && obj.Name() == "RawMessage":
+			return map[string]any{}, nil
+		case hasMethod(t, "MarshalText"):
+			return map[string]any{"type": "string"}, nil
+		}
+
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			return g.namedStructSchema(obj)
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		s, err := basicSchema(u)
+		if err != nil {
+			return nil, err
+		}
+		if obj := namedObject(t); obj != nil && len(g.enums[obj]) > 0 {
+			s["enum"] = g.enums[obj]
+		}
+		return s, nil
+	case *types.Pointer:
+		return g.schema(u.Elem())
+	case *types.Slice, *types.Array:
+		elem := u.(interface{ Elem() types.Type }).Elem()
+		if b, ok := elem.(*types.Basic); ok && b.Kind() == types.Byte {
+			return map[string]any{"type": "string", "format": "byte"}, nil
+		}
+
+		items, err := g.schema(elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case *types.Map:
+		if b, ok := u.Key().Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+			return nil, fmt.Errorf("%w: %s (the maps keys should be strings)", ErrUnsupportedType, t)
+		}
+
+		values, err := g.schema(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case *types.Struct:
+		return g.structSchema(u)
+	case *types.Interface:
+		if u.Empty() {
+			return map[string]any{}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
+
+// namedStructSchema returns the reference to the schema of the components of
+// a named struct, adding it if needed.
+func (g *generator) namedStructSchema(obj *types.TypeName) (map[string]any, error) {
+	id, exists := g.schemasIDs[obj]
+	if !exists {
+		id = strcase.ToLowerCamel(obj.Name())
+		for i := 2; g.schemas[id] != nil; i++ {
+			id = fmt.Sprintf("%s%d", strcase.ToLowerCamel(obj.Name()), i)
+		}
+
+		// Register the schema before generating it, for recursive types
+		g.schemasIDs[obj] = id
+		g.schemas[id] = map[string]any{}
+
+		s, err := g.structSchema(obj.Type().Underlying().(*types.Struct))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Name(), err)
+		}
+		setIfNotEmpty(s, "description", g.docs[obj.Pos()])
+		g.schemas[id] = s
+	}
+
+	return map[string]any{"$ref": "#/components/schemas/" + id}, nil
+}
+
+// structSchema returns the object schema of the exported fields of a struct,
+// with the fields of the embedded structs.
+func (g *generator) structSchema(st *types.Struct) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+
+	if err := g.addFields(st, properties, &required); err != nil {
+		return nil, err
+	}
+
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s, nil
+}
+
+func (g *generator) addFields(st *types.Struct, properties map[string]any, required *[]string) error {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		name, omitEmpty, skip := jsonField(f, st.Tag(i))
+		if skip {
+			continue
+		}
+
+		// Flatten the fields of the embedded structs without name
+		if f.Embedded() && name == "" {
+			if embedded, ok := derefType(f.Type()).Underlying().(*types.Struct); ok {
+				if err := g.addFields(embedded, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		name = firstNonEmpty(name, f.Name())
+
+		s, err := g.schema(f.Type())
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+		if _, isRef := s["$ref"]; !isRef {
+			// The siblings of the references are ignored
+			setIfNotEmpty(s, "description", g.docs[f.Pos()])
+		}
+		properties[name] = s
+
+		if _, isPointer := f.Type().(*types.Pointer); !isPointer && !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+
+	return nil
+}
+
+// jsonField returns the JSON name of the field (empty if not set), if it is
+// omitted when empty, and if it is not marshaled.
+func jsonField(f *types.Var, tag string) (name string, omitEmpty, skip bool) {
+	if !f.Exported() && !f.Embedded() {
+		return "", false, true
+	}
+
+	value, ok := lookupTag(tag, "json")
+	if !ok {
+		return "", false, false
+	}
+	if value == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(value, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+func lookupTag(tag, key string) (string, bool) {
+	return reflect.StructTag(tag).Lookup(key)
+}
+
+func basicSchema(b *types.Basic) (map[string]any, error) {
+	switch info := b.Info(); {
+	case info&types.IsBoolean != 0:
+		return map[string]any{"type": "boolean"}, nil
+	case info&types.IsString != 0:
+		return map[string]any{"type": "string"}, nil
+	case info&types.IsInteger != 0:
+		s := map[string]any{"type": "integer"}
+		switch b.Kind() {
+		case types.Int32, types.Uint32:
+			s["format"] = "int32"
+		case types.Int64, types.Uint64:
+			s["format"] = "int64"
+		}
+		return s, nil
+	case info&types.IsFloat != 0:
+		s := map[string]any{"type": "number"}
+		if b.Kind() == types.Float32 {
+			s["format"] = "float"
+		} else {
+			s["format"] = "double"
+		}
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, b)
+}
+
+// annotations returns the annotations of the comment, like
+// '//asyncapi:message channel=user.signedup'.
+func annotations(doc *ast.CommentGroup) []annotation {
+	if doc == nil {
+		return nil
+	}
+
+	var res []annotation
+	for _, c := range doc.List {
+		text, ok := strings.CutPrefix(c.Text, "//"+AnnotationPrefix)
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		a := annotation{kind: fields[0], options: make(map[string]string)}
+		for _, opt := range fields[1:] {
+			k, v, _ := strings.Cut(opt, "=")
+			a.options[k] = v
+		}
+		res = append(res, a)
+	}
+
+	return res
+}
+
+// comment returns the text of the comments, without the annotations.
+func comment(groups ...*ast.CommentGroup) string {
+	for _, g := range groups {
+		if text := strings.TrimSpace(g.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// namedObject returns the object of the named type or alias, if it is one.
+func namedObject(t types.Type) *types.TypeName {
+	if named, ok := derefType(t).(interface{ Obj() *types.TypeName }); ok {
+		return named.Obj()
+	}
+	return nil
+}
+
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// hasMethod returns true if the type, or a pointer to it, has the method.
+func hasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func setIfNotEmpty(m map[string]any, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
@@ -0,0 +1,92 @@
+package fromgo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFromGoSuite(t *testing.T) {
+	suite.Run(t, new(FromGoSuite))
+}
+
+type FromGoSuite struct {
+	suite.Suite
+}
+
+func (suite *FromGoSuite) TestSchemas() {
+	data, err := Generate(Params{Patterns: []string{"./testdata/schemas"}, Title: "Events", Version: "1.0.0"})
+	suite.Require().NoError(err)
+
+	expected, err := yaml.YAMLToJSON([]byte(`
+asyncapi: 3.0.0
+info:
+  title: Events
+  version: 1.0.0
+channels:
+  events:
+    address: events
+    messages:
+      event:
+        $ref: '#/components/messages/event'
+components:
+  messages:
+    event:
+      contentType: application/json
+      payload:
+        $ref: '#/components/schemas/event'
+  schemas:
+    event:
+      type: object
+      required: [Count, data, id, score]
+      properties:
+        id:
+          type: string
+        data:
+          type: string
+          format: byte
+        raw: {}
+        score:
+          type: number
+          format: float
+        children:
+          type: array
+          items:
+            $ref: '#/components/schemas/event'
+        Count:
+          type: integer
+          format: int32
+          description: Count is the count, without JSON tag.`))
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(string(expected), string(data))
+}
+
+func (suite *FromGoSuite) TestErrors() {
+	for pkg, msg := range map[string]string{
+		"./testdata/nochannel": "no channel for method Publish of Publisher, nor for message Event",
+		"./testdata/nomessage": "method Receive of Subscriber has no message as parameter",
+	} {
+		_, err := Generate(Params{Patterns: []string{pkg}})
+		suite.Require().ErrorIs(err, ErrInvalidAnnotation, pkg)
+		suite.Require().ErrorContains(err, msg, pkg)
+	}
+}
+
+func (suite *FromGoSuite) TestOperations() {
+	data, err := Generate(Params{Patterns: []string{"../../../test/v3/features/fromgo/model"}})
+	suite.Require().NoError(err)
+
+	var doc map[string]any
+	suite.Require().NoError(json.Unmarshal(data, &doc))
+
+	// The publishers methods should send messages, and the subscribers ones
+	// should receive them, with the annotated names and channels
+	operations := doc["operations"].(map[string]any)
+	suite.Require().Len(operations, 3)
+	suite.Require().Equal("send", operations["signUp"].(map[string]any)["action"])
+	suite.Require().Equal("receive", operations["welcome"].(map[string]any)["action"])
+	suite.Require().Equal(map[string]any{"$ref": "#/channels/v3_fromgo_user_region_banned"},
+		operations["ban"].(map[string]any)["channel"])
+}
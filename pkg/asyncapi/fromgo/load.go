@@ -0,0 +1,126 @@
+package fromgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// listedPackage is a package as listed by 'go list -json'.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	DepOnly    bool
+	Error      *struct{ Err string }
+}
+
+// loadedPackage is a parsed and type-checked package.
+type loadedPackage struct {
+	path  string
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// packagesImporter imports the loaded packages, and the other ones from
+// their source.
+type packagesImporter struct {
+	loaded map[string]*types.Package
+	source types.ImporterFrom
+}
+
+func (imp packagesImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, "", 0)
+}
+
+func (imp packagesImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := imp.loaded[path]; ok {
+		return pkg, nil
+	}
+	return imp.source.ImportFrom(path, dir, mode)
+}
+
+// load parses and type-checks the packages matching the patterns, in the
+// order of their dependencies.
+func load(fset *token.FileSet, dir string, patterns []string) ([]*loadedPackage, error) {
+	listed, err := list(dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := packagesImporter{
+		loaded: make(map[string]*types.Package),
+		source: importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+	}
+
+	var pkgs []*loadedPackage
+	for _, lp := range listed {
+		if lp.DepOnly {
+			continue
+		} else if lp.Error != nil {
+			return nil, fmt.Errorf("package %s: %s", lp.ImportPath, lp.Error.Err)
+		}
+
+		pkg := &loadedPackage{
+			path: lp.ImportPath,
+			info: &types.Info{
+				Types: make(map[ast.Expr]types.TypeAndValue),
+				Defs:  make(map[*ast.Ident]types.Object),
+				Uses:  make(map[*ast.Ident]types.Object),
+			},
+		}
+		for _, name := range lp.GoFiles {
+			f, err := parser.ParseFile(fset, filepath.Join(lp.Dir, name), nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			pkg.files = append(pkg.files, f)
+		}
+
+		conf := types.Config{Importer: imp}
+		if pkg.types, err = conf.Check(lp.ImportPath, fset, pkg.files, pkg.info); err != nil {
+			return nil, fmt.Errorf("package %s: %w", lp.ImportPath, err)
+		}
+		imp.loaded[lp.ImportPath] = pkg.types
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs, nil
+}
+
+// list lists the packages matching the patterns with their dependencies,
+// that are listed before them.
+func list(dir string, patterns []string) ([]listedPackage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-e", "-deps", "-json"}, patterns...)...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("listing the packages: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listedPackage
+	for dec := json.NewDecoder(&stdout); ; {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs, nil
+}
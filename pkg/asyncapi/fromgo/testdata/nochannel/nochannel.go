@@ -0,0 +1,9 @@
+package nochannel
+
+//asyncapi:message
+type Event struct{}
+
+//asyncapi:publisher
+type Publisher interface {
+	Publish(msg Event) error
+}
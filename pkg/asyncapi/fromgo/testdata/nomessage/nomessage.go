@@ -0,0 +1,9 @@
+package nomessage
+
+//asyncapi:message channel=events
+type Event struct{}
+
+//asyncapi:subscriber
+type Subscriber interface {
+	Receive(name string) error
+}
@@ -0,0 +1,19 @@
+package schemas
+
+import "encoding/json"
+
+// Base contains the fields of all the events.
+type Base struct {
+	ID string `json:"id"`
+}
+
+//asyncapi:message channel=events contentType=application/json
+type Event struct {
+	Base
+	Data     []byte          `json:"data"`
+	Raw      json.RawMessage `json:"raw,omitempty"`
+	Ignored  string          `json:"-"`
+	Score    float32         `json:"score"`
+	Children []*Event        `json:"children,omitempty"`
+	Count    int32           // Count is the count, without JSON tag.
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/converter"
 	asyncapiv2 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v2"
 	asyncapiv3 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
@@ -30,6 +31,8 @@ type FromFileParams struct {
 	// MajorVersion is the major version of the AsyncAPI specification.
 	// If it is 0, it will try to get it from the specification.
 	MajorVersion int
+	// ConvertToV3 converts the AsyncAPI v2 specifications to AsyncAPI v3.
+	ConvertToV3 bool
 }
 
 // FromFile parses the AsyncAPI specification either from a YAML file or a JSON file.
@@ -51,11 +54,13 @@ func FromFile(params FromFileParams) (asyncapi.Specification, error) {
 		return FromYAML(FromYAMLParams{
 			Data:         data,
 			MajorVersion: params.MajorVersion,
+			ConvertToV3:  params.ConvertToV3,
 		})
 	case ".json":
 		return FromJSON(FromJSONParams{
 			Data:         data,
 			MajorVersion: params.MajorVersion,
+			ConvertToV3:  params.ConvertToV3,
 		})
 	default:
 		return nil, fmt.Errorf("%w: %q", ErrInvalidFileFormat, params.MajorVersion)
@@ -69,6 +74,8 @@ type FromYAMLParams struct {
 	// MajorVersion is the major version of the AsyncAPI specification.
 	// If it is 0, it will try to get it from the specification.
 	MajorVersion int
+	// ConvertToV3 converts the AsyncAPI v2 specifications to AsyncAPI v3.
+	ConvertToV3 bool
 }
 
 // FromYAML parses the AsyncAPI specification from a YAML file.
@@ -89,6 +96,7 @@ func FromYAML(params FromYAMLParams) (asyncapi.Specification, error) {
 	return FromJSON(FromJSONParams{
 		Data:         data,
 		MajorVersion: params.MajorVersion,
+		ConvertToV3:  params.ConvertToV3,
 	})
 }
 
@@ -99,6 +107,8 @@ type FromJSONParams struct {
 	// MajorVersion is the major version of the AsyncAPI specification.
 	// If it is 0, it will try to get it from the specification.
 	MajorVersion int
+	// ConvertToV3 converts the AsyncAPI v2 specifications to AsyncAPI v3.
+	ConvertToV3 bool
 }
 
 // FromJSON parses the AsyncAPI specification from a JSON file.
@@ -119,6 +129,15 @@ func FromJSON(params FromJSONParams) (asyncapi.Specification, error) {
 		majorVersion = v
 	}
 
+	// Convert AsyncAPI v2 specification if needed
+	if majorVersion == asyncapiv2.MajorVersion && params.ConvertToV3 {
+		converted, err := converter.FromV2ToV3(params.Data)
+		if err != nil {
+			return nil, err
+		}
+		params.Data, majorVersion = converted, asyncapiv3.MajorVersion
+	}
+
 	// Use a different specification based on the AsyncAPI version
 	// NOTE: version should already be correct at this moment
 	var spec asyncapi.Specification
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrRemoteReference is returned when a reference to an URL can't be resolved.
+	ErrRemoteReference = fmt.Errorf("%w: remote reference", extensions.ErrAsyncAPI)
+
+	// ErrCircularReference is returned when files reference each other.
+	ErrCircularReference = fmt.Errorf("%w: circular reference between files", extensions.ErrAsyncAPI)
+)
+
+// ReferencesResolver resolves the references to other files or URLs (like
+// './schemas.yaml#/components/schemas/user'), by adding the specifications
+// they point to as dependencies. Each file or URL is only loaded once.
+//
+// NOTE: files referencing each other, directly or not, are not supported.
+type ReferencesResolver struct {
+	// AllowRemote allows the references to HTTP(S) URLs.
+	AllowRemote bool
+	// Client is the HTTP client used to get the remote references. The
+	// default one has a 30 seconds timeout.
+	Client *http.Client
+
+	cache     map[string]asyncapi.Specification
+	resolving map[string]bool
+}
+
+// Resolve adds the specifications referenced by the specification located at
+// the path or URL as its dependencies, and resolves their own references.
+func (r *ReferencesResolver) Resolve(spec asyncapi.Specification, location string) error {
+	if r.cache == nil {
+		r.cache = make(map[string]asyncapi.Specification)
+		r.resolving = make(map[string]bool)
+	}
+	if !isRemote(location) {
+		location = filepath.Clean(location)
+	}
+	r.resolving[location] = true
+	defer delete(r.resolving, location)
+
+	data, err := r.load(location)
+	if err != nil {
+		return err
+	}
+
+	refs, err := externalReferences(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", location, err)
+	}
+
+	for _, ref := range refs {
+		dep, err := r.dependency(spec, location, ref)
+		if err != nil {
+			return err
+		}
+
+		if err := spec.AddDependency(ref, dep); err != nil {
+			return err
+		}
+	}
+
+	r.cache[location] = spec
+	return nil
+}
+
+// Files returns the local files that have been loaded, sorted.
+func (r *ReferencesResolver) Files() []string {
+	files := make([]string, 0, len(r.cache))
+	for location := range r.cache {
+		if !isRemote(location) {
+			files = append(files, location)
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+// dependency returns the specification referenced from the location, loading
+// it and resolving its own references if it is not in cache.
+//
+//nolint:ireturn,nolintlint
+func (r *ReferencesResolver) dependency(
+	spec asyncapi.Specification,
+	location, ref string,
+) (asyncapi.Specification, error) {
+	target, err := r.referenceLocation(location, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.resolving[target] {
+		return nil, fmt.Errorf("%w: %q references %q", ErrCircularReference, location, ref)
+	} else if dep, exists := r.cache[target]; exists {
+		return dep, nil
+	}
+
+	data, err := r.load(target)
+	if err != nil {
+		return nil, err
+	}
+
+	dep, err := FromYAML(FromYAMLParams{
+		Data:         data,
+		MajorVersion: spec.MajorVersion(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", target, err)
+	}
+
+	return dep, r.Resolve(dep, target)
+}
+
+// referenceLocation returns the location of the file referenced from the
+// location, which is relative to it if it is not absolute.
+func (r *ReferencesResolver) referenceLocation(location, ref string) (string, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid reference %q: %w", extensions.ErrAsyncAPI, ref, err)
+	}
+
+	// Absolute URLs
+	if refURL.Scheme == "http" || refURL.Scheme == "https" {
+		if !r.AllowRemote {
+			return "", fmt.Errorf("%w: %q is not allowed without the remote references option", ErrRemoteReference, ref)
+		}
+		return ref, nil
+	}
+
+	// Files referenced from an URL
+	if isRemote(location) {
+		base, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid URL %q: %w", ErrRemoteReference, location, err)
+		}
+		base.Path = path.Join(path.Dir(base.Path), ref)
+		return base.String(), nil
+	}
+
+	// Local files
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(location), filepath.FromSlash(ref)), nil
+}
+
+// load returns the content of the file or URL.
+func (r *ReferencesResolver) load(location string) ([]byte, error) {
+	if !isRemote(location) {
+		return os.ReadFile(location)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRemoteReference, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: getting %q returned status %q", ErrRemoteReference, location, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// isRemote returns true if the location is an HTTP(S) URL.
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// externalReferences returns the files or URLs of the references of the YAML
+// or JSON document that are not in the document itself, sorted.
+func externalReferences(data []byte) ([]string, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	addExternalReferences(document, files)
+
+	refs := make([]string, 0, len(files))
+	for f := range files {
+		refs = append(refs, f)
+	}
+	sort.Strings(refs)
+
+	return refs, nil
+}
+
+// addExternalReferences adds the files of the references of the value, and of
+// its children, that are not in the document itself.
+func addExternalReferences(value any, files map[string]bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			if file, _, _ := strings.Cut(ref, "#"); file != "" {
+				files[file] = true
+			}
+		}
+		for _, child := range v {
+			addExternalReferences(child, files)
+		}
+	case []any:
+		for _, child := range v {
+			addExternalReferences(child, files)
+		}
+	}
+}
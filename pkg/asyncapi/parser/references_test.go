@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	asyncapiv3 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestReferencesSuite(t *testing.T) {
+	suite.Run(t, new(ReferencesSuite))
+}
+
+type ReferencesSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (suite *ReferencesSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+func (suite *ReferencesSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.dir, filepath.FromSlash(name))
+	suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0o755))
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func (suite *ReferencesSuite) resolve(path string, resolver ReferencesResolver) (*asyncapiv3.Specification, error) {
+	spec, err := FromFile(FromFileParams{Path: path})
+	suite.Require().NoError(err)
+
+	if err := resolver.Resolve(spec, path); err != nil {
+		return nil, err
+	}
+
+	specV3, ok := spec.(*asyncapiv3.Specification)
+	suite.Require().True(ok)
+	suite.Require().NoError(specV3.Process())
+	return specV3, nil
+}
+
+func (suite *ReferencesSuite) TestLocalFiles() {
+	path := suite.writeFile("asyncapi.yaml", `
+asyncapi: 3.0.0
+components:
+  schemas:
+    user:
+      $ref: './schemas/user.yaml#/components/schemas/user'`)
+	suite.writeFile("schemas/user.yaml", `
+components:
+  schemas:
+    user:
+      type: object
+      properties:
+        address:
+          $ref: './address.yaml#/components/schemas/address'`)
+	suite.writeFile("schemas/address.yaml", `
+components:
+  schemas:
+    address:
+      type: object`)
+
+	spec, err := suite.resolve(path, ReferencesResolver{})
+	suite.Require().NoError(err)
+
+	// References are resolved relatively to the file containing them
+	deps := spec.Dependencies()
+	suite.Require().Len(deps, 2)
+	user := spec.Components.Schemas["user"].Follow()
+	suite.Require().Equal("UserSchema", user.Name)
+	suite.Require().Equal("AddressSchema", user.Properties["address"].Follow().Name)
+}
+
+func (suite *ReferencesSuite) TestCircularFiles() {
+	path := suite.writeFile("asyncapi.yaml", `
+asyncapi: 3.0.0
+components:
+  schemas:
+    user:
+      $ref: './user.yaml#/components/schemas/user'`)
+	suite.writeFile("user.yaml", `
+components:
+  schemas:
+    user:
+      $ref: './asyncapi.yaml#/components/schemas/other'`)
+
+	_, err := suite.resolve(path, ReferencesResolver{})
+	suite.Require().ErrorIs(err, ErrCircularReference)
+}
+
+func (suite *ReferencesSuite) TestRemoteFiles() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/user.yaml":
+			_, _ = w.Write([]byte(`
+components:
+  schemas:
+    user:
+      type: object
+      properties:
+        address:
+          $ref: 'address.yaml#/components/schemas/address'`))
+		case "/schemas/address.yaml":
+			_, _ = w.Write([]byte(`
+components:
+  schemas:
+    address:
+      type: object`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := suite.writeFile("asyncapi.yaml", `
+asyncapi: 3.0.0
+components:
+  schemas:
+    user:
+      $ref: '`+server.URL+`/schemas/user.yaml#/components/schemas/user'`)
+
+	// Remote references are not allowed by default
+	_, err := suite.resolve(path, ReferencesResolver{})
+	suite.Require().ErrorIs(err, ErrRemoteReference)
+
+	// Relative references are resolved relatively to the URL
+	spec, err := suite.resolve(path, ReferencesResolver{AllowRemote: true, Client: server.Client()})
+	suite.Require().NoError(err)
+	user := spec.Components.Schemas["user"].Follow()
+	suite.Require().Equal("AddressSchema", user.Properties["address"].Follow().Name)
+}
+
+func (suite *ReferencesSuite) TestRemoteFileNotFound() {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	path := suite.writeFile("asyncapi.yaml", `
+asyncapi: 3.0.0
+components:
+  schemas:
+    user:
+      $ref: '`+server.URL+`/user.yaml#/components/schemas/user'`)
+
+	_, err := suite.resolve(path, ReferencesResolver{AllowRemote: true})
+	suite.Require().ErrorIs(err, ErrRemoteReference)
+}
@@ -42,6 +42,7 @@ type Message struct {
 	OneOf         []*Message     `json:"oneOf"`
 	Payload       *Schema        `json:"payload"`
 	CorrelationID *CorrelationID `json:"correlationID"`
+	ContentType   string         `json:"contentType"`
 	Reference     string         `json:"$ref"`
 
 	// --- Non AsyncAPI fields -------------------------------------------------
@@ -109,6 +110,11 @@ func (msg *Message) setDependencies(spec Specification) error {
 		return err
 	}
 
+	// Use the default content type if none is set
+	if msg.ContentType == "" {
+		msg.ContentType = spec.DefaultContentType
+	}
+
 	// Set CorrelationID dependencies
 	return msg.setCorrelationIDDependencies(spec)
 }
@@ -43,6 +43,9 @@ type Schema struct {
 	Name        string  `json:"-"`
 	ReferenceTo *Schema `json:"-"`
 
+	// allOfMerged is true when the AllOf schemas have been merged into this one.
+	allOfMerged bool
+
 	// Embedded validation fields
 	asyncapi.Validations[Schema]
 
@@ -262,12 +265,26 @@ func (s *Schema) generateAllOfMetadata() error {
 }
 
 func (s *Schema) setAllOfDependencies(spec Specification) error {
+	// Merge only once, as referenced schemas can be merged before their turn
+	if s.allOfMerged {
+		return nil
+	}
+	s.allOfMerged = true
+
 	for _, v := range s.AllOf {
 		// Set dependencies
 		if err := v.setDependencies(spec); err != nil {
 			return err
 		}
 
+		// Merge the AllOf of the referenced schema first, in order to get all
+		// its fields (this also works for recursive references)
+		if v.ReferenceTo != nil {
+			if err := v.ReferenceTo.setAllOfDependencies(spec); err != nil {
+				return err
+			}
+		}
+
 		// Merge with other fields as one struct (invalidate references)
 		if err := s.MergeWith(spec, *v); err != nil {
 			return err
@@ -0,0 +1,51 @@
+package asyncapiv2
+
+import (
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(SchemaSuite))
+}
+
+type SchemaSuite struct {
+	suite.Suite
+}
+
+func objectWithProperty(name string) *Schema {
+	return &Schema{
+		Type:        SchemaTypeIsObject.String(),
+		Properties:  map[string]*Schema{name: {Type: SchemaTypeIsString.String()}},
+		Validations: asyncapi.Validations[Schema]{Required: []string{name}},
+	}
+}
+
+func allOf(schemas ...*Schema) *Schema {
+	return &Schema{Validations: asyncapi.Validations[Schema]{AllOf: schemas}}
+}
+
+func (suite *SchemaSuite) TestAllOfWithNestedReferences() {
+	orderCreated := allOf(&Schema{Reference: "#/components/schemas/event"}, objectWithProperty("orderId"))
+	event := allOf(&Schema{Reference: "#/components/schemas/traceable"}, objectWithProperty("id"))
+	spec := Specification{Components: Components{Schemas: map[string]*Schema{
+		"orderCreated": orderCreated,
+		"event":        event,
+		"traceable":    objectWithProperty("traceId"),
+	}}}
+	suite.Require().NoError(spec.generateMetadata())
+
+	// Set the dependencies of the schema before the referenced one
+	suite.Require().NoError(orderCreated.setDependencies(spec))
+	suite.Require().NoError(event.setDependencies(spec))
+
+	for _, s := range []*Schema{orderCreated, event} {
+		suite.Require().Equal(SchemaTypeIsObject.String(), s.Type)
+		suite.Require().Contains(s.Properties, "traceId")
+		suite.Require().True(s.Properties["traceId"].IsRequired)
+	}
+	suite.Require().Len(orderCreated.Properties, 3)
+	suite.Require().Len(event.Properties, 2)
+}
@@ -25,10 +25,11 @@ var (
 type Specification struct {
 	// --- AsyncAPI fields -----------------------------------------------------
 
-	Version    string              `json:"asyncapi"`
-	Info       Info                `json:"info"`
-	Channels   map[string]*Channel `json:"channels"`
-	Components Components          `json:"components"`
+	Version            string              `json:"asyncapi"`
+	Info               Info                `json:"info"`
+	DefaultContentType string              `json:"defaultContentType"`
+	Channels           map[string]*Channel `json:"channels"`
+	Components         Components          `json:"components"`
 
 	// --- Non AsyncAPI fields -------------------------------------------------
 
@@ -1,10 +1,68 @@
 package asyncapiv3
 
+import "reflect"
+
 const (
 	// BindingsSuffix is the suffix added to the bindings name.
 	BindingsSuffix = "Bindings"
 )
 
+// mergeBindings sets the protocol-specific information that is not set in the
+// bindings with the one of the other bindings, that can be from a trait. Both
+// are pointers to bindings of the same type.
+func mergeBindings(bindings, other any) {
+	mergeBindingsValues(reflect.ValueOf(bindings).Elem(), reflect.ValueOf(other).Elem())
+}
+
+func mergeBindingsValues(v, other reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// Skip the non AsyncAPI fields and the reference
+			if tag := v.Type().Field(i).Tag.Get("json"); tag == "-" || tag == "$ref" || !v.Field(i).CanSet() {
+				continue
+			}
+			mergeBindingsValues(v.Field(i), other.Field(i))
+		}
+	case reflect.Pointer:
+		if other.IsNil() {
+			return
+		} else if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		mergeBindingsValues(v.Elem(), other.Elem())
+	case reflect.Interface:
+		// The bindings without specific type are decoded as maps
+		m, isMap := v.Interface().(map[string]any)
+		otherMap, otherIsMap := other.Interface().(map[string]any)
+		if isMap && otherIsMap {
+			mergeBindingsMaps(m, otherMap)
+		} else if v.IsNil() {
+			v.Set(other)
+		}
+	default:
+		if v.IsZero() {
+			v.Set(other)
+		}
+	}
+}
+
+func mergeBindingsMaps(m, other map[string]any) {
+	for k, otherValue := range other {
+		value, exists := m[k]
+		if !exists {
+			m[k] = otherValue
+			continue
+		}
+
+		valueMap, isMap := value.(map[string]any)
+		otherValueMap, otherIsMap := otherValue.(map[string]any)
+		if isMap && otherIsMap {
+			mergeBindingsMaps(valueMap, otherValueMap)
+		}
+	}
+}
+
 // HTTPBinding represents protocol-specific information for an HTTP channel.
 type HTTPBinding any
 
@@ -14,24 +72,95 @@ type WsBinding any
 // KafkaBinding represents protocol-specific information for a Kafka channel.
 type KafkaBinding any
 
+// KafkaChannelBinding represents protocol-specific information for a Kafka channel.
+// Source: https://github.com/asyncapi/bindings/tree/master/kafka#channel-binding-object
+type KafkaChannelBinding struct {
+	Topic          string `json:"topic"`
+	Partitions     int    `json:"partitions"`
+	Replicas       int    `json:"replicas"`
+	BindingVersion string `json:"bindingVersion"`
+}
+
 // AnyPointMqBinding represents protocol-specific information for an Anypoint MQ channel.
 type AnyPointMqBinding any
 
 // AMQPBinding represents protocol-specific information for an AMQP 0-9-1 channel.
 type AMQPBinding any
 
+// AMQPChannelBinding represents protocol-specific information for an AMQP 0-9-1 channel.
+// Source: https://github.com/asyncapi/bindings/tree/master/amqp#channel-binding-object
+type AMQPChannelBinding struct {
+	Is             string                      `json:"is"`
+	Exchange       *AMQPChannelBindingExchange `json:"exchange"`
+	Queue          *AMQPChannelBindingQueue    `json:"queue"`
+	BindingVersion string                      `json:"bindingVersion"`
+}
+
+// AMQPChannelBindingExchange represents the exchange of an AMQP 0-9-1 channel binding.
+type AMQPChannelBindingExchange struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"autoDelete"`
+	VHost      string `json:"vhost"`
+}
+
+// AMQPChannelBindingQueue represents the queue of an AMQP 0-9-1 channel binding.
+type AMQPChannelBindingQueue struct {
+	Name       string `json:"name"`
+	Durable    bool   `json:"durable"`
+	Exclusive  bool   `json:"exclusive"`
+	AutoDelete bool   `json:"autoDelete"`
+	VHost      string `json:"vhost"`
+}
+
+// AMQPOperationBinding represents protocol-specific information for an AMQP 0-9-1 operation.
+// Source: https://github.com/asyncapi/bindings/tree/master/amqp#operation-binding-object
+type AMQPOperationBinding struct {
+	Expiration     int      `json:"expiration"`
+	UserID         string   `json:"userId"`
+	CC             []string `json:"cc"`
+	Priority       int      `json:"priority"`
+	DeliveryMode   int      `json:"deliveryMode"`
+	Mandatory      bool     `json:"mandatory"`
+	BCC            []string `json:"bcc"`
+	Timestamp      bool     `json:"timestamp"`
+	Ack            bool     `json:"ack"`
+	BindingVersion string   `json:"bindingVersion"`
+}
+
 // AMQP1Binding represents protocol-specific information for an AMQP 1.0 channel.
 type AMQP1Binding any
 
 // MQTTBinding represents protocol-specific information for an MQTT channel.
 type MQTTBinding any
 
+// MQTTOperationBinding represents protocol-specific information for an MQTT operation.
+// Source: https://github.com/asyncapi/bindings/tree/master/mqtt#operation-binding-object
+type MQTTOperationBinding struct {
+	QoS                   int    `json:"qos"`
+	Retain                bool   `json:"retain"`
+	MessageExpiryInterval int    `json:"messageExpiryInterval"`
+	BindingVersion        string `json:"bindingVersion"`
+}
+
 // MQTT5Binding represents protocol-specific information for an MQTT 5 channel.
 type MQTT5Binding any
 
 // NATSBinding represents protocol-specific information for a NATS channel.
 type NATSBinding any
 
+// NATSOperationBinding represents protocol-specific information for a NATS operation.
+// Source: https://github.com/asyncapi/bindings/tree/master/nats#operation-binding-object
+type NATSOperationBinding struct {
+	Queue          string `json:"queue"`
+	BindingVersion string `json:"bindingVersion"`
+
+	// --- asyncapi-codegen extensions -----------------------------------------
+
+	ExtJetStreamConsumer *JetStreamConsumerExtension `json:"x-jetstream-consumer"`
+}
+
 // JMSBinding represents protocol-specific information for a JMS channel.
 type JMSBinding any
 
@@ -34,6 +34,10 @@ type Channel struct {
 	Bindings     *ChannelBindings       `json:"bindings"`
 	Reference    string                 `json:"$ref"`
 
+	// --- asyncapi-codegen extensions -----------------------------------------
+
+	ExtLastValueCache *LastValueCacheExtension `json:"x-last-value-cache"`
+
 	// --- Non AsyncAPI fields -------------------------------------------------
 
 	Name        string   `json:"-"`
@@ -75,6 +79,11 @@ func (ch *Channel) generateMetadata(parentName, name string) error {
 	// Generate Bindings metadata
 	ch.Bindings.generateMetadata(ch.Name, "")
 
+	// Check extensions
+	if err := ch.ExtLastValueCache.validate(); err != nil {
+		return fmt.Errorf("channel %q: %w", ch.Name, err)
+	}
+
 	return nil
 }
 
@@ -6,26 +6,26 @@ package asyncapiv3
 type ChannelBindings struct {
 	// --- AsyncAPI fields -----------------------------------------------------
 
-	HTTP         HTTPBinding         `json:"http"`
-	WS           WsBinding           `json:"ws"`
-	Kafka        KafkaBinding        `json:"kafka"`
-	AnyPointMQ   AnyPointMqBinding   `json:"anypointmq"`
-	AMQP         AMQPBinding         `json:"amqp"`
-	AMQP1        AMQP1Binding        `json:"amqp1"`
-	MQTT         MQTTBinding         `json:"mqtt"`
-	MQTT5        MQTT5Binding        `json:"mqtt5"`
-	NATS         NATSBinding         `json:"nats"`
-	JMS          JMSBinding          `json:"jms"`
-	SNS          SNSBinding          `json:"sns"`
-	Solace       SolaceBinding       `json:"solace"`
-	SQS          SQSBinding          `json:"sqs"`
-	Stomp        StompBinding        `json:"stomp"`
-	Redis        RedisBinding        `json:"redis"`
-	Mercure      MercureBinding      `json:"mercure"`
-	IBMMQ        IBMMQBinding        `json:"ibmmq"`
-	GooglePubSub GooglePubSubBinding `json:"googlepubsub"`
-	Pulsar       PulsarBinding       `json:"pulsar"`
-	Reference    string              `json:"$ref"`
+	HTTP         HTTPBinding          `json:"http"`
+	WS           WsBinding            `json:"ws"`
+	Kafka        *KafkaChannelBinding `json:"kafka"`
+	AnyPointMQ   AnyPointMqBinding    `json:"anypointmq"`
+	AMQP         *AMQPChannelBinding  `json:"amqp"`
+	AMQP1        AMQP1Binding         `json:"amqp1"`
+	MQTT         MQTTBinding          `json:"mqtt"`
+	MQTT5        MQTT5Binding         `json:"mqtt5"`
+	NATS         NATSBinding          `json:"nats"`
+	JMS          JMSBinding           `json:"jms"`
+	SNS          SNSBinding           `json:"sns"`
+	Solace       SolaceBinding        `json:"solace"`
+	SQS          SQSBinding           `json:"sqs"`
+	Stomp        StompBinding         `json:"stomp"`
+	Redis        RedisBinding         `json:"redis"`
+	Mercure      MercureBinding       `json:"mercure"`
+	IBMMQ        IBMMQBinding         `json:"ibmmq"`
+	GooglePubSub GooglePubSubBinding  `json:"googlepubsub"`
+	Pulsar       PulsarBinding        `json:"pulsar"`
+	Reference    string               `json:"$ref"`
 
 	// --- Non AsyncAPI fields -------------------------------------------------
 
@@ -60,3 +60,11 @@ func (chb *ChannelBindings) setDependencies(spec Specification) error {
 
 	return nil
 }
+
+// Follow returns referenced channel bindings if specified or the actual channel bindings.
+func (chb *ChannelBindings) Follow() *ChannelBindings {
+	if chb.ReferenceTo != nil {
+		return chb.ReferenceTo
+	}
+	return chb
+}
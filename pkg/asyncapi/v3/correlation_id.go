@@ -12,8 +12,8 @@ type CorrelationID struct {
 
 	// --- Non AsyncAPI fields -------------------------------------------------
 
-	Name        string   `json:"-"`
-	ReferenceTo *Channel `json:"-"`
+	Name        string         `json:"-"`
+	ReferenceTo *CorrelationID `json:"-"`
 }
 
 // generateMetadata generates metadata for the CorrelationID.
@@ -36,7 +36,7 @@ func (c *CorrelationID) setDependencies(spec Specification) error {
 
 	// Add pointer to reference if there is one
 	if c.Reference != "" {
-		refTo, err := spec.ReferenceChannel(c.Reference)
+		refTo, err := spec.ReferenceCorrelationID(c.Reference)
 		if err != nil {
 			return err
 		}
@@ -46,7 +46,15 @@ func (c *CorrelationID) setDependencies(spec Specification) error {
 	return nil
 }
 
+// Follow returns referenced CorrelationID if specified or the actual CorrelationID.
+func (c *CorrelationID) Follow() *CorrelationID {
+	if c.ReferenceTo != nil {
+		return c.ReferenceTo
+	}
+	return c
+}
+
 // Exists checks that the correlation exists (and that the location is set).
 func (c *CorrelationID) Exists() bool {
-	return c != nil && c.Location != ""
+	return c != nil && c.Follow().Location != ""
 }
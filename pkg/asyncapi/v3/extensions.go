@@ -1,8 +1,21 @@
 package asyncapiv3
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/token"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+var (
+	// ErrInvalidExtension is the error returned when an asyncapi-codegen extension is invalid.
+	ErrInvalidExtension = fmt.Errorf("%w: invalid extension", extensions.ErrAsyncAPI)
 )
 
 // Extensions holds additional properties defined for asyncapi-codegen
@@ -13,6 +26,290 @@ type Extensions struct {
 
 	// Setting custom import statements for ExtGoType
 	ExtGoTypeImport *GoTypeImportExtension `json:"x-go-type-import"`
+
+	// Setting how the optional fields are generated, for a property or all the
+	// properties of an object
+	ExtGoOptional string `json:"x-go-optional"`
+
+	// Setting the name of the generated type, or of the struct field for a
+	// property
+	ExtGoName string `json:"x-go-name"`
+}
+
+// checkGoName returns an error if the name set with the x-go-name extension is
+// not an exported golang identifier.
+func checkGoName(name string) error {
+	if name != "" && (!token.IsIdentifier(name) || !token.IsExported(name)) {
+		return fmt.Errorf("%w: x-go-name %q should be an exported golang identifier", ErrInvalidExtension, name)
+	}
+	return nil
+}
+
+// Values of the x-go-optional extension, also used as the strategy for the
+// optional fields of the generator.
+const (
+	// GoOptionalPointer generates the optional fields as pointers, nil if absent.
+	GoOptionalPointer = "pointer"
+	// GoOptionalOmitEmpty generates the optional fields as values, omitted if
+	// they have their zero value.
+	GoOptionalOmitEmpty = "omitempty"
+	// GoOptionalOption generates the optional fields as extensions.Option, unset
+	// if absent.
+	GoOptionalOption = "option"
+)
+
+// IsValidGoOptional returns true if the value is a valid x-go-optional value.
+func IsValidGoOptional(value string) bool {
+	switch value {
+	case GoOptionalPointer, GoOptionalOmitEmpty, GoOptionalOption:
+		return true
+	default:
+		return false
+	}
+}
+
+// LastValueCacheExtension specifies that the broker should keep the last
+// messages published on each address of a channel, for the x-last-value-cache
+// extension.
+type LastValueCacheExtension struct {
+	Store   string `json:"store"`   // "kv" (default) or "object"
+	Bucket  string `json:"bucket"`  // Name of the bucket keeping the messages
+	History int    `json:"history"` // Number of messages kept per address
+}
+
+// validate checks that the last value cache is valid.
+func (lvc *LastValueCacheExtension) validate() error {
+	if lvc == nil {
+		return nil
+	}
+
+	if lvc.Bucket == "" {
+		return fmt.Errorf("%w: x-last-value-cache requires a bucket", ErrInvalidExtension)
+	}
+
+	switch lvc.Store {
+	case "", extensions.LastValueCacheStoreKV:
+		if lvc.History < 0 || lvc.History > 64 {
+			return fmt.Errorf("%w: x-last-value-cache history should be between 1 and 64", ErrInvalidExtension)
+		}
+	case extensions.LastValueCacheStoreObject:
+		if lvc.History > 1 {
+			return fmt.Errorf("%w: x-last-value-cache object store only keeps the last message", ErrInvalidExtension)
+		}
+	default:
+		return fmt.Errorf("%w: invalid x-last-value-cache store %q", ErrInvalidExtension, lvc.Store)
+	}
+
+	return nil
+}
+
+// ReplyStreamExtension specifies that several messages can be sent in reply to
+// a request, for the x-reply-stream extension of an operation reply. It can be
+// set to 'true', or to an object to customize the end-of-stream marker.
+type ReplyStreamExtension struct {
+	// EndHeader is the header marking the message ending the stream, which is
+	// not a reply itself ('endOfStream' by default).
+	EndHeader string `json:"endHeader"`
+
+	disabled bool
+}
+
+// UnmarshalJSON unmarshals the extension, that can also be a boolean.
+func (rs *ReplyStreamExtension) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*rs = ReplyStreamExtension{}
+		return nil
+	case "false":
+		*rs = ReplyStreamExtension{disabled: true}
+		return nil
+	}
+
+	type replyStream ReplyStreamExtension
+	return json.Unmarshal(data, (*replyStream)(rs))
+}
+
+// EndHeaderKey returns the header marking the end of the stream.
+func (rs ReplyStreamExtension) EndHeaderKey() string {
+	if rs.EndHeader == "" {
+		return extensions.StandardHeaderEndOfStream
+	}
+	return rs.EndHeader
+}
+
+// JetStreamConsumerExtension overrides the NATS JetStream consumer configuration
+// for an operation, for the x-jetstream-consumer extension of the NATS bindings.
+type JetStreamConsumerExtension struct {
+	Durable       string   `json:"durable"`       // Name of the durable consumer
+	AckWait       string   `json:"ackWait"`       // Duration, e.g. "30s"
+	MaxDeliver    int      `json:"maxDeliver"`    // Maximum number of deliveries
+	DeliverPolicy string   `json:"deliverPolicy"` // "all", "last", "new" or "lastPerSubject"
+	BackOff       []string `json:"backoff"`       // Durations between redeliveries
+
+	// --- Non AsyncAPI fields -------------------------------------------------
+
+	AckWaitDuration  time.Duration   `json:"-"`
+	BackOffDurations []time.Duration `json:"-"`
+}
+
+// validate checks that the JetStream consumer configuration is valid and
+// parses its durations.
+func (jsc *JetStreamConsumerExtension) validate() error {
+	if jsc == nil {
+		return nil
+	}
+
+	if jsc.AckWait != "" {
+		d, err := time.ParseDuration(jsc.AckWait)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%w: invalid x-jetstream-consumer ackWait %q", ErrInvalidExtension, jsc.AckWait)
+		}
+		jsc.AckWaitDuration = d
+	}
+
+	// Back off requires more deliveries than its durations
+	if jsc.MaxDeliver < -1 || (len(jsc.BackOff) > 0 && jsc.MaxDeliver <= len(jsc.BackOff)) {
+		return fmt.Errorf("%w: invalid x-jetstream-consumer maxDeliver %d", ErrInvalidExtension, jsc.MaxDeliver)
+	}
+
+	switch jsc.DeliverPolicy {
+	case "", extensions.JetStreamDeliverAll, extensions.JetStreamDeliverLast,
+		extensions.JetStreamDeliverNew, extensions.JetStreamDeliverLastPerSubject:
+	default:
+		return fmt.Errorf("%w: invalid x-jetstream-consumer deliverPolicy %q", ErrInvalidExtension, jsc.DeliverPolicy)
+	}
+
+	jsc.BackOffDurations = make([]time.Duration, 0, len(jsc.BackOff))
+	for _, b := range jsc.BackOff {
+		d, err := time.ParseDuration(b)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%w: invalid x-jetstream-consumer backoff %q", ErrInvalidExtension, b)
+		}
+		jsc.BackOffDurations = append(jsc.BackOffDurations, d)
+	}
+
+	return nil
+}
+
+// ProtobufExtension specifies that the payload of a message is a Protocol
+// Buffers message generated from a .proto file, for the x-protobuf extension.
+type ProtobufExtension struct {
+	Type   string                 `json:"type"`   // Go type of the message, e.g. "orderspb.OrderCreated"
+	Import *GoTypeImportExtension `json:"import"` // Import of the package of the Go type
+}
+
+// validate checks that the protobuf extension is valid.
+func (pe *ProtobufExtension) validate() error {
+	if pe == nil {
+		return nil
+	}
+
+	if pe.Type == "" || strings.HasPrefix(pe.Type, "*") {
+		return fmt.Errorf("%w: x-protobuf requires the Go type of the message, without pointer", ErrInvalidExtension)
+	}
+
+	return nil
+}
+
+// MessageVersionsExtension specifies the previous versions of the payload of a
+// message, for the x-message-versions extension. The version of the payload is
+// sent in a header, and the received payloads of the previous versions are
+// converted into the current version.
+type MessageVersionsExtension struct {
+	Header   string             `json:"header"`   // Header of the version, 'messageVersion' by default
+	Current  string             `json:"current"`  // Version of the payload of the message
+	Default  string             `json:"default"`  // Version of the payloads without header, the current one by default
+	Previous map[string]*Schema `json:"previous"` // Payloads of the previous versions, by version
+
+	// Versions are the previous versions, sorted by version.
+	Versions []*MessageVersion `json:"-"`
+}
+
+// MessageVersion is a previous version of the payload of a message.
+type MessageVersion struct {
+	Version string
+	// GoName is the suffix of the generated names for this version, like 'V1'.
+	GoName  string
+	Payload *Schema
+}
+
+// HeaderKey returns the header with the version of the payload.
+func (mv MessageVersionsExtension) HeaderKey() string {
+	if mv.Header == "" {
+		return extensions.StandardHeaderMessageVersion
+	}
+	return mv.Header
+}
+
+// DefaultVersion returns the version of the payloads without version header.
+func (mv MessageVersionsExtension) DefaultVersion() string {
+	if mv.Default == "" {
+		return mv.Current
+	}
+	return mv.Default
+}
+
+// generateMetadata checks the versions and generates the metadata of the
+// payloads of the previous versions.
+func (mv *MessageVersionsExtension) generateMetadata(msgName string) error {
+	if mv == nil {
+		return nil
+	}
+
+	if mv.Current == "" {
+		return fmt.Errorf("%w: x-message-versions requires the current version", ErrInvalidExtension)
+	} else if _, exists := mv.Previous[mv.DefaultVersion()]; !exists && mv.DefaultVersion() != mv.Current {
+		return fmt.Errorf("%w: x-message-versions default version %q is unknown", ErrInvalidExtension, mv.Default)
+	}
+
+	mv.Versions = make([]*MessageVersion, 0, len(mv.Previous))
+	names := make(map[string]string, len(mv.Previous))
+	for version, payload := range mv.Previous {
+		goName := template.Namify("V_" + version)
+		switch {
+		case version == mv.Current:
+			return fmt.Errorf("%w: x-message-versions previous version %q is the current one", ErrInvalidExtension, version)
+		case payload == nil:
+			return fmt.Errorf("%w: x-message-versions version %q has no payload", ErrInvalidExtension, version)
+		case names[goName] != "":
+			return fmt.Errorf("%w: x-message-versions versions %q and %q have the same name",
+				ErrInvalidExtension, names[goName], version)
+		}
+		names[goName] = version
+
+		if err := payload.generateMetadata("", msgName+"_"+MessagePayloadSuffix+"_"+goName, nil, false); err != nil {
+			return err
+		}
+		mv.Versions = append(mv.Versions, &MessageVersion{Version: version, GoName: goName, Payload: payload})
+	}
+
+	sort.Slice(mv.Versions, func(i, j int) bool { return mv.Versions[i].Version < mv.Versions[j].Version })
+	return nil
+}
+
+// setDependencies sets the dependencies of the payloads of the previous
+// versions, and checks that the payloads can be versioned.
+func (mv *MessageVersionsExtension) setDependencies(msg *Message, spec Specification) error {
+	if mv == nil {
+		return nil
+	}
+
+	for _, v := range mv.Versions {
+		if err := v.Payload.setDependencies(spec); err != nil {
+			return err
+		}
+	}
+
+	// Only the payloads decoded with the codec of their content type can be
+	// versioned
+	payload := msg.Payload.Follow()
+	if msg.ExtProtobuf != nil || msg.AvroSchema() != "" || payload.IsDiscriminatedUnion() ||
+		(payload.Type != SchemaTypeIsObject.String() && payload.Type != SchemaTypeIsArray.String()) {
+		return fmt.Errorf("%w: x-message-versions requires an object or array payload, without protobuf nor Avro",
+			ErrInvalidExtension)
+	}
+
+	return nil
 }
 
 // GoTypeImportExtension specifies the required import statement
@@ -64,11 +361,33 @@ func (s *Schema) goTypeImports(imports map[GoTypeImportPath]GoTypeImportName) er
 	return nil
 }
 
+// protobufImports collects the import of the Go type of the message set by the
+// x-protobuf key into the imports map.
+func (msg *Message) protobufImports(imports map[GoTypeImportPath]GoTypeImportName) error {
+	if msg.ExtProtobuf == nil || msg.ExtProtobuf.Import == nil {
+		return nil
+	}
+
+	imp := msg.ExtProtobuf.Import
+	name, exists := imports[imp.Path]
+	if exists && name != imp.Name {
+		return fmt.Errorf(
+			"x-protobuf import name conflict for message %s: %s and %s for %s",
+			msg.Name, name, imp.Name, imp.Path,
+		)
+	}
+
+	if !exists {
+		imports[imp.Path] = imp.Name
+	}
+	return nil
+}
+
 // CustomImports collects all custom import paths set by x-go-type-imports
 // in all Schema Objects in the Specification.
 // Returns import strings like `alias "abc.xyz/repo/package"` for code generation.
 // Returns error when import name conflicts.
-func (s Specification) CustomImports() ([]string, error) {
+func (s Specification) CustomImports() ([]string, error) { //nolint:cyclop
 	importsSet := make(map[GoTypeImportPath]GoTypeImportName)
 
 	for _, v := range s.Components.Schemas {
@@ -91,6 +410,22 @@ func (s Specification) CustomImports() ([]string, error) {
 		}
 	}
 
+	// Add the imports of the protobuf messages
+	for _, v := range s.Components.Messages {
+		if err := v.protobufImports(importsSet); err != nil {
+			return nil, fmt.Errorf("/components/messages custom import error: %w", err)
+		}
+	}
+	for _, channels := range []map[string]*Channel{s.Channels, s.Components.Channels} {
+		for _, ch := range channels {
+			for _, v := range ch.Messages {
+				if err := v.protobufImports(importsSet); err != nil {
+					return nil, fmt.Errorf("channel messages custom import error: %w", err)
+				}
+			}
+		}
+	}
+
 	// TODO: support Parameters
 
 	return importsMapToList(importsSet), nil
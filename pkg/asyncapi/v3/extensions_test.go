@@ -1,8 +1,10 @@
 package asyncapiv3
 
 import (
+	"encoding/json"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
 	"github.com/stretchr/testify/suite"
@@ -232,3 +234,221 @@ func (suite *ExtensionsSuite) TextExtGoTypeImportWithConflictingPackageNames() {
 	// It should be an error
 	suite.Require().Error(err)
 }
+
+func (suite *ExtensionsSuite) TestLastValueCacheValidation() {
+	cases := []struct {
+		extension *LastValueCacheExtension
+		valid     bool
+	}{
+		{extension: nil, valid: true},
+		{extension: &LastValueCacheExtension{Bucket: "status"}, valid: true},
+		{extension: &LastValueCacheExtension{Bucket: "status", Store: "kv", History: 10}, valid: true},
+		{extension: &LastValueCacheExtension{Bucket: "status", Store: "object"}, valid: true},
+		{extension: &LastValueCacheExtension{Store: "kv"}, valid: false},
+		{extension: &LastValueCacheExtension{Bucket: "status", History: 65}, valid: false},
+		{extension: &LastValueCacheExtension{Bucket: "status", Store: "object", History: 2}, valid: false},
+		{extension: &LastValueCacheExtension{Bucket: "status", Store: "redis"}, valid: false},
+	}
+
+	for i, c := range cases {
+		err := (&Channel{ExtLastValueCache: c.extension}).generateMetadata("", "status")
+		if c.valid {
+			suite.Require().NoError(err, "case %d", i)
+		} else {
+			suite.Require().ErrorIs(err, ErrInvalidExtension, "case %d", i)
+		}
+	}
+}
+
+func (suite *ExtensionsSuite) TestPartitionKeyExtension() {
+	// Required field in payload
+	msg := Message{
+		ExtPartitionKey: "$message.payload#/customerId",
+		Payload: &Schema{
+			Type: SchemaTypeIsObject.String(),
+			Validations: asyncapi.Validations[Schema]{
+				Required: []string{"customerId"},
+			},
+			Properties: map[string]*Schema{
+				"customerId": {Type: SchemaTypeIsString.String()},
+			},
+		},
+	}
+	suite.Require().NoError(msg.generateMetadata("", "order", nil))
+	suite.Require().True(msg.PartitionKeyRequired)
+
+	// Missing field in headers is created
+	msg = Message{ExtPartitionKey: "$message.header#/accountId"}
+	suite.Require().NoError(msg.generateMetadata("", "invoice", nil))
+	suite.Require().False(msg.PartitionKeyRequired)
+	suite.Require().Contains(msg.Headers.Properties, "accountId")
+
+	// Invalid location
+	msg = Message{ExtPartitionKey: "customerId"}
+	suite.Require().ErrorIs(msg.generateMetadata("", "order", nil), ErrInvalidExtension)
+}
+
+func (suite *ExtensionsSuite) TestJetStreamConsumerValidation() {
+	cases := []struct {
+		extension *JetStreamConsumerExtension
+		valid     bool
+	}{
+		{extension: &JetStreamConsumerExtension{}, valid: true},
+		{extension: &JetStreamConsumerExtension{Durable: "orders", AckWait: "30s", DeliverPolicy: "new"}, valid: true},
+		{extension: &JetStreamConsumerExtension{MaxDeliver: 3, BackOff: []string{"1s", "1m"}}, valid: true},
+		{extension: &JetStreamConsumerExtension{MaxDeliver: -1}, valid: true},
+		{extension: &JetStreamConsumerExtension{AckWait: "30"}, valid: false},
+		{extension: &JetStreamConsumerExtension{MaxDeliver: -2}, valid: false},
+		{extension: &JetStreamConsumerExtension{MaxDeliver: 2, BackOff: []string{"1s", "1m"}}, valid: false},
+		{extension: &JetStreamConsumerExtension{MaxDeliver: 3, BackOff: []string{"1s", "-1m"}}, valid: false},
+		{extension: &JetStreamConsumerExtension{DeliverPolicy: "first"}, valid: false},
+	}
+
+	for i, c := range cases {
+		ob := OperationBindings{NATS: &NATSOperationBinding{ExtJetStreamConsumer: c.extension}}
+		err := ob.setDependencies(Specification{})
+		if c.valid {
+			suite.Require().NoError(err, "case %d", i)
+		} else {
+			suite.Require().ErrorIs(err, ErrInvalidExtension, "case %d", i)
+		}
+	}
+
+	// Durations are parsed
+	ext := &JetStreamConsumerExtension{AckWait: "30s", MaxDeliver: 3, BackOff: []string{"1s", "1m"}}
+	suite.Require().NoError(ext.validate())
+	suite.Require().Equal(30*time.Second, ext.AckWaitDuration)
+	suite.Require().Equal([]time.Duration{time.Second, time.Minute}, ext.BackOffDurations)
+}
+
+func (suite *ExtensionsSuite) TestProtobufExtension() {
+	// Content type is protobuf by default, and the import is collected
+	spec := Specification{
+		DefaultContentType: "application/json",
+		Components: Components{
+			Messages: map[string]*Message{
+				"order": {ExtProtobuf: &ProtobufExtension{
+					Type:   "orderspb.Order",
+					Import: &GoTypeImportExtension{Path: "example.com/orders/orderspb"},
+				}},
+			},
+		},
+	}
+	suite.Require().NoError(spec.Process())
+	suite.Require().Equal("application/x-protobuf", spec.Components.Messages["order"].ContentType)
+
+	imports, err := spec.CustomImports()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{`"example.com/orders/orderspb"`}, imports)
+
+	// Go type is required, without pointer
+	for _, typ := range []string{"", "*orderspb.Order"} {
+		msg := Message{ExtProtobuf: &ProtobufExtension{Type: typ}}
+		suite.Require().ErrorIs(msg.generateMetadata("", "order", nil), ErrInvalidExtension)
+	}
+}
+
+func (suite *ExtensionsSuite) TestGoNameExtension() {
+	// Schemas, messages and operations are renamed
+	schema := &Schema{Type: SchemaTypeIsObject.String(), Extensions: Extensions{ExtGoName: "Customer"}}
+	suite.Require().NoError(schema.generateMetadata("", "user", nil, false))
+	suite.Require().Equal("Customer", schema.Name)
+
+	msg := &Message{ExtGoName: "CustomerCreated"}
+	suite.Require().NoError(msg.generateMetadata("", "userCreated", nil))
+	suite.Require().Equal("CustomerCreated", msg.Name)
+
+	op := &Operation{ExtGoName: "NotifyCustomer"}
+	suite.Require().NoError(op.generateMetadata("", "sendUser"))
+	suite.Require().Equal("NotifyCustomer", op.Name)
+
+	// Only exported identifiers are accepted
+	for _, name := range []string{"customer", "Customer-Created", "1Customer"} {
+		schema := &Schema{Extensions: Extensions{ExtGoName: name}}
+		suite.Require().ErrorIs(schema.generateMetadata("", "user", nil, false), ErrInvalidExtension, name)
+	}
+}
+
+func (suite *ExtensionsSuite) TestOperationNameAffixes() {
+	defer SetOperationNameAffixes("", "Operation")
+
+	op := &Operation{}
+	suite.Require().NoError(op.generateMetadata("", "sendUser"))
+	suite.Require().Equal("SendUserOperation", op.Name)
+
+	SetOperationNameAffixes("Do", "")
+	suite.Require().NoError(op.generateMetadata("", "sendUser"))
+	suite.Require().Equal("DoSendUser", op.Name)
+}
+
+func (suite *ExtensionsSuite) TestReplyStreamExtension() {
+	cases := []struct {
+		json      string
+		endHeader string // Empty if there is no reply stream
+	}{
+		{json: `{}`},
+		{json: `{"x-reply-stream": false}`},
+		{json: `{"x-reply-stream": true}`, endHeader: "endOfStream"},
+		{json: `{"x-reply-stream": {}}`, endHeader: "endOfStream"},
+		{json: `{"x-reply-stream": {"endHeader": "last"}}`, endHeader: "last"},
+	}
+
+	for _, c := range cases {
+		var reply OperationReply
+		suite.Require().NoError(json.Unmarshal([]byte(c.json), &reply), c.json)
+		suite.Require().NoError(reply.generateMetadata("ping", ""), c.json)
+
+		if c.endHeader == "" {
+			suite.Require().Nil(reply.ExtReplyStream, c.json)
+		} else {
+			suite.Require().NotNil(reply.ExtReplyStream, c.json)
+			suite.Require().Equal(c.endHeader, reply.ExtReplyStream.EndHeaderKey(), c.json)
+		}
+	}
+}
+
+func (suite *ExtensionsSuite) TestMessageVersionsValidation() {
+	object := func() *Schema { return &Schema{Type: SchemaTypeIsObject.String()} }
+	cases := []struct {
+		extension *MessageVersionsExtension
+		valid     bool
+	}{
+		{extension: nil, valid: true},
+		{extension: &MessageVersionsExtension{Current: "2"}, valid: true},
+		{extension: &MessageVersionsExtension{Current: "2", Previous: map[string]*Schema{"1": object()}}, valid: true},
+		{extension: &MessageVersionsExtension{Current: "2", Default: "1",
+			Previous: map[string]*Schema{"1": object()}}, valid: true},
+		{extension: &MessageVersionsExtension{Previous: map[string]*Schema{"1": object()}}, valid: false},
+		{extension: &MessageVersionsExtension{Current: "2", Default: "0"}, valid: false},
+		{extension: &MessageVersionsExtension{Current: "2", Previous: map[string]*Schema{"2": object()}}, valid: false},
+		{extension: &MessageVersionsExtension{Current: "2", Previous: map[string]*Schema{"1": nil}}, valid: false},
+		{extension: &MessageVersionsExtension{Current: "3",
+			Previous: map[string]*Schema{"1.2": object(), "12": object()}}, valid: false},
+	}
+
+	for i, c := range cases {
+		err := (&Message{Payload: object(), ExtVersions: c.extension}).generateMetadata("", "user", nil)
+		if c.valid {
+			suite.Require().NoError(err, "case %d", i)
+		} else {
+			suite.Require().ErrorIs(err, ErrInvalidExtension, "case %d", i)
+		}
+	}
+
+	// The versions are sorted, with their payloads named by version
+	ext := &MessageVersionsExtension{Current: "3", Previous: map[string]*Schema{"2": object(), "1.1": object()}}
+	suite.Require().NoError((&Message{Payload: object(), ExtVersions: ext}).generateMetadata("", "user", nil))
+	suite.Require().Len(ext.Versions, 2)
+	suite.Require().Equal("1.1", ext.Versions[0].Version)
+	suite.Require().Equal("V11", ext.Versions[0].GoName)
+	suite.Require().Equal("UserMessagePayloadV11", ext.Versions[0].Payload.Name)
+	suite.Require().Equal("V2", ext.Versions[1].GoName)
+
+	// Only the payloads decoded with their codec can be versioned
+	msg := &Message{
+		Payload:     &Schema{Type: SchemaTypeIsString.String()},
+		ExtVersions: &MessageVersionsExtension{Current: "2"},
+	}
+	suite.Require().NoError(msg.generateMetadata("", "user", nil))
+	suite.Require().ErrorIs(msg.setDependencies(Specification{}), ErrInvalidExtension)
+}
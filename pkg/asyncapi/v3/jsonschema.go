@@ -0,0 +1,136 @@
+package asyncapiv3
+
+import "encoding/json"
+
+// JSONSchema returns the schema as a self-contained JSON Schema, with its
+// references resolved, in order to validate the values at runtime.
+//
+// NOTE: the recursive references accept any value, and the unions only keep
+// their variants, as the fields of the variants are merged in the schema.
+func (s *Schema) JSONSchema() ([]byte, error) {
+	return json.Marshal(s.jsonSchema(make(map[*Schema]bool)))
+}
+
+//nolint:cyclop,funlen // this is a conversion of each JSON Schema keyword
+func (s *Schema) jsonSchema(visiting map[*Schema]bool) any {
+	if s == nil {
+		return true
+	}
+	for s.ReferenceTo != nil {
+		s = s.ReferenceTo
+	}
+
+	switch {
+	case s.isFalse:
+		return false
+	case s.ExtGoType == "any" || visiting[s]:
+		return true
+	}
+	visiting[s] = true
+	defer delete(visiting, s)
+
+	js := make(map[string]any)
+
+	// Unions only keep their variants
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		if len(s.OneOf) > 0 {
+			js["oneOf"] = jsonSchemas(s.OneOf, visiting)
+		}
+		if len(s.AnyOf) > 0 {
+			js["anyOf"] = jsonSchemas(s.AnyOf, visiting)
+		}
+		return js
+	}
+
+	if s.Type != "" {
+		js["type"] = s.Type
+	}
+	if s.Format != "" {
+		js["format"] = s.Format
+	}
+	if len(s.Enum) > 0 {
+		js["enum"] = s.Enum
+	}
+	if s.Const != nil {
+		js["const"] = s.Const
+	}
+
+	// Strings
+	if s.MinLength > 0 {
+		js["minLength"] = s.MinLength
+	}
+	if s.MaxLength > 0 {
+		js["maxLength"] = s.MaxLength
+	}
+	if s.Pattern != "" {
+		js["pattern"] = s.Pattern
+	}
+
+	// Numbers (zero values can't be distinguished from unset constraints)
+	for keyword, limit := range map[string]float64{
+		"minimum":          s.Minimum,
+		"maximum":          s.Maximum,
+		"exclusiveMinimum": s.ExclusiveMinimum,
+		"exclusiveMaximum": s.ExclusiveMaximum,
+	} {
+		if limit != 0 {
+			js[keyword] = limit
+		}
+	}
+
+	// Arrays
+	if s.Items != nil {
+		js["items"] = s.Items.jsonSchema(visiting)
+	}
+	if s.MinItems > 0 {
+		js["minItems"] = s.MinItems
+	}
+	if s.MaxItems > 0 {
+		js["maxItems"] = s.MaxItems
+	}
+	if s.UniqueItems {
+		js["uniqueItems"] = true
+	}
+
+	// Objects
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, p := range s.Properties {
+			properties[name] = p.jsonSchema(visiting)
+		}
+		js["properties"] = properties
+	}
+	if len(s.PatternProperties) > 0 {
+		properties := make(map[string]any, len(s.PatternProperties))
+		for pattern, p := range s.PatternProperties {
+			properties[pattern] = p.jsonSchema(visiting)
+		}
+		js["patternProperties"] = properties
+	}
+	if s.AdditionalProperties != nil {
+		js["additionalProperties"] = s.AdditionalProperties.jsonSchema(visiting)
+	}
+	if len(s.Required) > 0 {
+		js["required"] = s.Required
+	}
+	if s.MinProperties > 0 {
+		js["minProperties"] = s.MinProperties
+	}
+	if s.MaxProperties > 0 {
+		js["maxProperties"] = s.MaxProperties
+	}
+
+	if s.Not != nil {
+		js["not"] = s.Not.jsonSchema(visiting)
+	}
+
+	return js
+}
+
+func jsonSchemas(schemas []*Schema, visiting map[*Schema]bool) []any {
+	res := make([]any, 0, len(schemas))
+	for _, s := range schemas {
+		res = append(res, s.jsonSchema(visiting))
+	}
+	return res
+}
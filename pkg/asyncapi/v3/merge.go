@@ -0,0 +1,109 @@
+package asyncapiv3
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+var (
+	// ErrMergeConflict is sent when merged specifications have different
+	// definitions for the same element.
+	ErrMergeConflict = fmt.Errorf("%w: merge conflict", extensions.ErrAsyncAPI)
+)
+
+// Merge adds the servers, channels, operations and components of another
+// specification to the Specification, with its dependencies. An element that
+// is in both specifications should have the same definition, or an error is
+// returned. The Specification info is kept.
+//
+// NOTE: it should be called before method `Process`.
+func (s *Specification) Merge(spec *Specification) error {
+	if spec.DefaultContentType != "" {
+		if s.DefaultContentType != "" && s.DefaultContentType != spec.DefaultContentType {
+			return fmt.Errorf("%w: default content types %q and %q are different",
+				ErrMergeConflict, s.DefaultContentType, spec.DefaultContentType)
+		}
+		s.DefaultContentType = spec.DefaultContentType
+	}
+
+	if err := mergeMap("servers", &s.Servers, spec.Servers); err != nil {
+		return err
+	}
+	if err := mergeMap("channels", &s.Channels, spec.Channels); err != nil {
+		return err
+	}
+	if err := mergeMap("operations", &s.Operations, spec.Operations); err != nil {
+		return err
+	}
+	if err := s.Components.merge(spec.Components); err != nil {
+		return err
+	}
+
+	// Dependencies with the same path should be the same file, and a reference
+	// to the Specification is not a dependency
+	for path, dep := range spec.dependencies {
+		if dep == s {
+			continue
+		} else if existing, exists := s.dependencies[path]; exists && existing != dep {
+			return fmt.Errorf("%w: %q references different files", ErrMergeConflict, path)
+		}
+		s.dependencies[path] = dep
+	}
+
+	return nil
+}
+
+// merge adds the components of another Components.
+func (c *Components) merge(c2 Components) error {
+	for _, err := range []error{
+		mergeMap("components.schemas", &c.Schemas, c2.Schemas),
+		mergeMap("components.servers", &c.Servers, c2.Servers),
+		mergeMap("components.channels", &c.Channels, c2.Channels),
+		mergeMap("components.operations", &c.Operations, c2.Operations),
+		mergeMap("components.messages", &c.Messages, c2.Messages),
+		mergeMap("components.securitySchemes", &c.SecuritySchemes, c2.SecuritySchemes),
+		mergeMap("components.serverVariables", &c.ServerVariables, c2.ServerVariables),
+		mergeMap("components.parameters", &c.Parameters, c2.Parameters),
+		mergeMap("components.correlationIds", &c.CorrelationIDs, c2.CorrelationIDs),
+		mergeMap("components.replies", &c.Replies, c2.Replies),
+		mergeMap("components.replyAddresses", &c.ReplyAddresses, c2.ReplyAddresses),
+		mergeMap("components.externalDocs", &c.ExternalDocs, c2.ExternalDocs),
+		mergeMap("components.tags", &c.Tags, c2.Tags),
+		mergeMap("components.operationTraits", &c.OperationTraits, c2.OperationTraits),
+		mergeMap("components.messageTraits", &c.MessageTraits, c2.MessageTraits),
+		mergeMap("components.serverBindings", &c.ServerBindings, c2.ServerBindings),
+		mergeMap("components.channelBindings", &c.ChannelBindings, c2.ChannelBindings),
+		mergeMap("components.operationBindings", &c.OperationBindings, c2.OperationBindings),
+		mergeMap("components.messageBindings", &c.MessageBindings, c2.MessageBindings),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeMap adds the elements of the second map to the first one, returning an
+// error if an element with the same key has a different definition.
+func mergeMap[T any](kind string, dst *map[string]T, src map[string]T) error {
+	if len(src) == 0 {
+		return nil
+	}
+
+	if *dst == nil {
+		*dst = make(map[string]T, len(src))
+	}
+
+	for _, key := range utils.MapKeysToSortedList(src) {
+		if existing, exists := (*dst)[key]; exists && !reflect.DeepEqual(existing, src[key]) {
+			return fmt.Errorf("%w: %s.%s has different definitions", ErrMergeConflict, kind, key)
+		}
+		(*dst)[key] = src[key]
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package asyncapiv3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMergeSuite(t *testing.T) {
+	suite.Run(t, new(MergeSuite))
+}
+
+type MergeSuite struct {
+	suite.Suite
+}
+
+func (suite *MergeSuite) TestMerge() {
+	spec := NewSpecification()
+	spec.Info.Title = "Users"
+	spec.Channels["users"] = &Channel{Address: "users"}
+	spec.Components.Schemas = map[string]*Schema{
+		"id": {Type: SchemaTypeIsString.String()},
+	}
+
+	other := NewSpecification()
+	other.Info.Title = "Orders"
+	other.Channels["orders"] = &Channel{Address: "orders"}
+	other.Operations["receiveOrder"] = &Operation{Action: OperationActionIsReceive}
+	other.Components.Schemas = map[string]*Schema{
+		"id":    {Type: SchemaTypeIsString.String()},
+		"order": {Type: SchemaTypeIsObject.String()},
+	}
+
+	// Identical definitions are not conflicts
+	suite.Require().NoError(spec.Merge(other))
+	suite.Require().Equal("Users", spec.Info.Title)
+	suite.Require().Len(spec.Channels, 2)
+	suite.Require().Contains(spec.Operations, "receiveOrder")
+	suite.Require().Len(spec.Components.Schemas, 2)
+	suite.Require().NoError(spec.Process())
+}
+
+func (suite *MergeSuite) TestConflicts() {
+	cases := []struct {
+		name  string
+		other func(*Specification)
+	}{
+		{"channel", func(s *Specification) {
+			s.Channels["users"] = &Channel{Address: "v2.users"}
+		}},
+		{"component", func(s *Specification) {
+			s.Components.Schemas = map[string]*Schema{"id": {Type: SchemaTypeIsInteger.String()}}
+		}},
+		{"default content type", func(s *Specification) {
+			s.DefaultContentType = "application/yaml"
+		}},
+		{"dependency", func(s *Specification) {
+			s.dependencies["common.yaml"] = NewSpecification()
+		}},
+	}
+
+	for _, c := range cases {
+		suite.Run(c.name, func() {
+			spec := NewSpecification()
+			spec.DefaultContentType = "application/json"
+			spec.Channels["users"] = &Channel{Address: "users"}
+			spec.Components.Schemas = map[string]*Schema{"id": {Type: SchemaTypeIsString.String()}}
+			spec.dependencies["common.yaml"] = NewSpecification()
+
+			other := NewSpecification()
+			c.other(other)
+			suite.Require().ErrorIs(spec.Merge(other), ErrMergeConflict)
+		})
+	}
+}
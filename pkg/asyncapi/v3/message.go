@@ -2,9 +2,12 @@ package asyncapiv3
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/codecs/avro"
 	"github.com/lerenn/asyncapi-codegen/pkg/utils"
 	"github.com/mohae/deepcopy"
 )
@@ -53,6 +56,13 @@ type Message struct {
 	Traits        []*MessageTrait        `json:"traits"`
 	Reference     string                 `json:"$ref"`
 
+	// --- asyncapi-codegen extensions -----------------------------------------
+
+	ExtPartitionKey string                    `json:"x-partition-key"`
+	ExtProtobuf     *ProtobufExtension        `json:"x-protobuf"`
+	ExtGoName       string                    `json:"x-go-name"`
+	ExtVersions     *MessageVersionsExtension `json:"x-message-versions"`
+
 	// --- Non AsyncAPI fields -------------------------------------------------
 
 	ReferenceTo *Message `json:"-"`
@@ -60,6 +70,10 @@ type Message struct {
 	// CorrelationIDLocation will indicate where the correlation id is
 	// According to: https://www.asyncapi.com/docs/reference/specification/v3.0.0#correlationIdObject
 	CorrelationIDRequired bool `json:"-"`
+
+	// PartitionKeyRequired will indicate if the field used as partition key,
+	// set with the 'x-partition-key' extension, is required.
+	PartitionKeyRequired bool `json:"-"`
 }
 
 // generateMetadata generates metadata for the Message.
@@ -71,6 +85,11 @@ func (msg *Message) generateMetadata(parentName, name string, number *int) error
 
 	// Set name
 	msg.Name = generateFullName(parentName, name, "Message", number)
+	if err := checkGoName(msg.ExtGoName); err != nil {
+		return err
+	} else if msg.ExtGoName != "" {
+		msg.Name = msg.ExtGoName
+	}
 
 	// Generate Payload metadata
 	// NOTE: Suffix Message name with payload for name and set no parent
@@ -78,6 +97,11 @@ func (msg *Message) generateMetadata(parentName, name string, number *int) error
 		return err
 	}
 
+	// Generate the metadata of the payloads of the previous versions
+	if err := msg.ExtVersions.generateMetadata(msg.Name); err != nil {
+		return fmt.Errorf("message %q: %w", msg.Name, err)
+	}
+
 	// Generate Headers metadata
 	if err := msg.generateHeadersMetadata(); err != nil {
 		return err
@@ -109,6 +133,16 @@ func (msg *Message) generateMetadata(parentName, name string, number *int) error
 	msg.createCorrelationIDFieldIfMissing()
 	msg.CorrelationIDRequired = msg.isCorrelationIDRequired()
 
+	// Process partition key
+	if err := msg.processPartitionKey(); err != nil {
+		return err
+	}
+
+	// Check protobuf payload
+	if err := msg.ExtProtobuf.validate(); err != nil {
+		return fmt.Errorf("message %q: %w", msg.Name, err)
+	}
+
 	return nil
 }
 
@@ -160,7 +194,42 @@ func (msg *Message) setDependencies(spec Specification) error {
 	}
 
 	// Set traits dependencies
-	return msg.setTraitsDependencies(spec)
+	if err := msg.setTraitsDependencies(spec); err != nil {
+		return err
+	}
+
+	// Set correlation ID dependencies, and process it again as its location
+	// can come from a reference or a trait
+	if err := msg.CorrelationID.setDependencies(spec); err != nil {
+		return err
+	}
+	msg.createCorrelationIDFieldIfMissing()
+	msg.CorrelationIDRequired = msg.isCorrelationIDRequired()
+
+	// Use the default content type if none is set by the message or its traits,
+	// or the protobuf/Avro one for protobuf/Avro payloads
+	switch {
+	case msg.ContentType != "":
+	case msg.ExtProtobuf != nil:
+		msg.ContentType = extensions.ContentTypeProtobuf
+	case msg.AvroSchema() != "":
+		msg.ContentType = avro.ContentType
+	default:
+		msg.ContentType = spec.DefaultContentType
+	}
+
+	// Mark the headers schema, that can come from a reference or a trait, to
+	// generate its conversion to broker headers
+	if msg.Headers != nil {
+		msg.Headers.Follow().IsMessageHeaders = true
+	}
+
+	// Set the dependencies of the payloads of the previous versions
+	if err := msg.ExtVersions.setDependencies(msg, spec); err != nil {
+		return fmt.Errorf("message %q: %w", msg.Name, err)
+	}
+
+	return nil
 }
 
 func (msg *Message) setReference(spec Specification) error {
@@ -218,10 +287,11 @@ func (msg *Message) generateHeadersMetadata() error {
 		return err
 	}
 
-	if msg.Headers.Follow().Type != SchemaTypeIsObject.String() {
+	// NOTE: references are not set yet, so their type can't be checked
+	if msg.Headers.Reference == "" && msg.Headers.Type != SchemaTypeIsObject.String() {
 		return fmt.Errorf(
 			"%w: %q headers must be an object, is %q",
-			extensions.ErrAsyncAPI, msg.Name, msg.Headers.Follow().Type)
+			extensions.ErrAsyncAPI, msg.Name, msg.Headers.Type)
 	}
 
 	return nil
@@ -276,7 +346,12 @@ func (msg *Message) setTraitsDependencies(spec Specification) error {
 		if err := t.setDependencies(spec); err != nil {
 			return err
 		}
-		if err := msg.ApplyTrait(t.Follow(), spec); err != nil {
+	}
+
+	// Apply the traits from the last one, as the values of the message take
+	// precedence over the ones of its traits, and the last traits over the first
+	for i := len(msg.Traits) - 1; i >= 0; i-- {
+		if err := msg.ApplyTrait(msg.Traits[i].Follow(), spec); err != nil {
 			return err
 		}
 	}
@@ -285,21 +360,70 @@ func (msg *Message) setTraitsDependencies(spec Specification) error {
 }
 
 func (msg Message) isCorrelationIDRequired() bool {
-	if msg.CorrelationID == nil || msg.CorrelationID.Location == "" {
+	if !msg.CorrelationID.Exists() {
 		return false
 	}
 
-	correlationIDParent := msg.createTreeUntilLocation(msg.CorrelationID.Location)
-	path := strings.Split(msg.CorrelationID.Location, "/")
+	location := msg.CorrelationID.Follow().Location
+	correlationIDParent := msg.createTreeUntilLocation(location)
+	path := strings.Split(location, "/")
 	return correlationIDParent.IsFieldRequired(path[len(path)-1])
 }
 
+// CorrelationIDSchema returns the schema of the correlation ID field, or nil if
+// the message has no correlation ID.
+func (msg Message) CorrelationIDSchema() *Schema {
+	if !msg.Follow().CorrelationID.Exists() {
+		return nil
+	}
+
+	location := msg.Follow().CorrelationID.Follow().Location
+	correlationIDParent := msg.Follow().createTreeUntilLocation(location)
+	path := strings.Split(location, "/")
+	if field := correlationIDParent.Properties[path[len(path)-1]]; field != nil {
+		return field.Follow()
+	}
+	return nil
+}
+
 func (msg *Message) createCorrelationIDFieldIfMissing() {
 	if msg.CorrelationID == nil {
 		return
 	}
 
-	_ = msg.createTreeUntilLocation(msg.CorrelationID.Location)
+	msg.keepOptionalFieldAsPointer(msg.CorrelationID.Follow().Location)
+}
+
+// keepOptionalFieldAsPointer creates the field at the location if it is missing,
+// and generates it as a pointer if it is optional (whatever the strategy for the
+// optional fields), as the code generated for this field expects one.
+func (msg *Message) keepOptionalFieldAsPointer(location string) {
+	parent := msg.createTreeUntilLocation(location)
+	path := strings.Split(location, "/")
+	if field := parent.Properties[path[len(path)-1]]; field != nil {
+		field.ExtGoOptional = GoOptionalPointer
+	}
+}
+
+// processPartitionKey checks the location of the 'x-partition-key' extension
+// and creates the corresponding field if it is missing.
+func (msg *Message) processPartitionKey() error {
+	if msg.ExtPartitionKey == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(msg.ExtPartitionKey, "$message.header#/") &&
+		!strings.HasPrefix(msg.ExtPartitionKey, "$message.payload#/") {
+		return fmt.Errorf("%w: x-partition-key of message %q should be a header or payload location, got %q",
+			ErrInvalidExtension, msg.Name, msg.ExtPartitionKey)
+	}
+
+	msg.keepOptionalFieldAsPointer(msg.ExtPartitionKey)
+	partitionKeyParent := msg.createTreeUntilLocation(msg.ExtPartitionKey)
+	path := strings.Split(msg.ExtPartitionKey, "/")
+	msg.PartitionKeyRequired = partitionKeyParent.IsFieldRequired(path[len(path)-1])
+
+	return nil
 }
 
 func (msg *Message) createTreeUntilLocation(location string) (locationParent *Schema) {
@@ -486,17 +610,30 @@ func (msg *Message) mergeWithMessageHeaders(msg2 Message, spec Specification) er
 	return nil
 }
 
-// Follow returns referenced message if specified or the actual message.
+// Follow returns referenced message if specified or the actual message. The
+// referenced message can also be a reference (like an operation message
+// referencing a channel message that references a component message).
 func (msg *Message) Follow() *Message {
 	if msg.ReferenceTo != nil {
-		return msg.ReferenceTo
+		return msg.ReferenceTo.Follow()
 	}
 	return msg
 }
 
-// ApplyTrait applies a trait to the message.
+// AvroSchema returns the Avro schema of the payload, if it has been converted
+// from an Avro schema.
+func (msg *Message) AvroSchema() string {
+	if msg.Payload == nil {
+		return ""
+	}
+	return msg.Payload.Follow().AvroSchema
+}
+
+// ApplyTrait applies a trait to the message: the values that are not set in
+// the message are taken from the trait, and the headers, tags, bindings and
+// examples are merged.
 //
-//nolint:cyclop
+//nolint:cyclop,funlen
 func (msg *Message) ApplyTrait(mt *MessageTrait, spec Specification) error {
 	// Check message is not nil
 	if msg == nil {
@@ -549,6 +686,17 @@ func (msg *Message) ApplyTrait(mt *MessageTrait, spec Specification) error {
 		msg.ExternalDocs = &extDoc
 	}
 
+	// Merge bindings, on copies as they can be shared with other messages
+	if mt.Bindings != nil {
+		bindings := deepcopy.Copy(mt.Bindings.Follow()).(*MessageBindings)
+		if msg.Bindings != nil {
+			own := deepcopy.Copy(msg.Bindings.Follow()).(*MessageBindings)
+			mergeBindings(own, bindings)
+			bindings = own
+		}
+		msg.Bindings = bindings
+	}
+
 	// Merge examples
 	msg.Examples = append(msg.Examples, mt.Examples...)
 
@@ -561,20 +709,31 @@ func (msg *Message) mergeHeaders(spec Specification, headers *Schema) error {
 		return nil
 	}
 
-	// Check if message headers are nil, then create them
-	if msg.Headers == nil {
-		newHeaders := utils.ToValue(headers.Follow())
-		msg.Headers = &newHeaders
-		if err := newHeaders.generateMetadata(msg.Name, "Headers", nil, false); err != nil {
-			return err
-		}
-		return newHeaders.setDependencies(spec)
+	// Use a copy of the trait headers if the message has none, or merge in a
+	// copy of the referenced headers, as these schemas can be shared with other
+	// messages
+	switch {
+	case msg.Headers == nil:
+		msg.Headers = msg.headersCopy(headers)
+		return nil
+	case msg.Headers.ReferenceTo != nil:
+		msg.Headers = msg.headersCopy(msg.Headers)
 	}
 
 	// Merge headers
 	return msg.Headers.MergeWith(spec, *headers)
 }
 
+// headersCopy returns a copy of the headers schema named after the message, in
+// which other headers can be merged without changing the original schema.
+func (msg *Message) headersCopy(headers *Schema) *Schema {
+	copied := utils.ToValue(headers.Follow())
+	copied.Name = generateFullName(msg.Name, MessageHeadersSuffix, "", nil)
+	copied.Properties = maps.Clone(copied.Properties)
+	copied.Required = slices.Clone(copied.Required)
+	return &copied
+}
+
 func (msg *Message) mergePayload(spec Specification, payload *Schema) error {
 	// Check if payload is nil
 	if payload == nil {
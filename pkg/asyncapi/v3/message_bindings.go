@@ -62,3 +62,11 @@ func (mb *MessageBindings) setDependencies(spec Specification) error {
 
 	return nil
 }
+
+// Follow returns referenced message bindings if specified or the actual message bindings.
+func (mb *MessageBindings) Follow() *MessageBindings {
+	if mb.ReferenceTo != nil {
+		return mb.ReferenceTo
+	}
+	return mb
+}
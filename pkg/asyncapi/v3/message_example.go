@@ -7,7 +7,7 @@ type MessageExample struct {
 	// --- AsyncAPI fields -----------------------------------------------------
 
 	Headers   map[string]any `json:"headers"`
-	Payload   map[string]any `json:"payload"`
+	Payload   any            `json:"payload"`
 	Name      string         `json:"name"`
 	Summary   string         `json:"summary"`
 	Reference string         `json:"$ref"`
@@ -15,6 +15,10 @@ type MessageExample struct {
 	// --- Non AsyncAPI fields -------------------------------------------------
 
 	ReferenceTo *MessageExample `json:"-"`
+
+	// ExampleName is the name of the example in the specification, as the
+	// Name is replaced by a generated one.
+	ExampleName string `json:"-"`
 }
 
 // generateMetadata generates metadata for the MessageExample.
@@ -24,7 +28,8 @@ func (me *MessageExample) generateMetadata(parentName, name string, number *int)
 		return
 	}
 
-	// Set name
+	// Set name, keeping the one of the specification
+	me.ExampleName = me.Name
 	me.Name = generateFullName(parentName, name, "Example", number)
 }
 
@@ -46,3 +51,11 @@ func (me *MessageExample) setDependencies(spec Specification) error {
 
 	return nil
 }
+
+// Follow returns referenced MessageExample if specified or the actual MessageExample.
+func (me *MessageExample) Follow() *MessageExample {
+	if me.ReferenceTo != nil {
+		return me.ReferenceTo
+	}
+	return me
+}
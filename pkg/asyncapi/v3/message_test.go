@@ -161,3 +161,70 @@ func (suite *MessageSuite) TestIsCorrelationIDRequiredWithEmptyMessage() {
 	// Check if true
 	suite.Require().False(msg.isCorrelationIDRequired())
 }
+
+func (suite *MessageSuite) TestDefaultContentType() {
+	spec := Specification{DefaultContentType: "application/json"}
+
+	// Message without content type
+	msg := Message{}
+	suite.Require().NoError(msg.setDependencies(spec))
+	suite.Require().Equal("application/json", msg.ContentType)
+
+	// Message with content type
+	msg = Message{ContentType: "text/plain"}
+	suite.Require().NoError(msg.setDependencies(spec))
+	suite.Require().Equal("text/plain", msg.ContentType)
+}
+
+func (suite *MessageSuite) TestApplyTraitBindings() {
+	trait := &MessageTrait{
+		Bindings: &MessageBindings{
+			Kafka: map[string]any{"key": map[string]any{"type": "string"}, "bindingVersion": "0.5.0"},
+			AMQP:  map[string]any{"contentEncoding": "gzip"},
+		},
+	}
+	msg := Message{
+		Bindings: &MessageBindings{
+			Kafka: map[string]any{"key": map[string]any{"format": "uuid"}, "bindingVersion": "0.4.0"},
+		},
+	}
+	suite.Require().NoError(msg.ApplyTrait(trait, Specification{}))
+
+	// The bindings should be merged, with the message values taking precedence
+	suite.Require().Equal(map[string]any{
+		"key":            map[string]any{"type": "string", "format": "uuid"},
+		"bindingVersion": "0.4.0",
+	}, msg.Bindings.Kafka)
+	suite.Require().Equal(map[string]any{"contentEncoding": "gzip"}, msg.Bindings.AMQP)
+
+	// Without changing the trait ones
+	suite.Require().Equal(map[string]any{"type": "string"}, trait.Bindings.Kafka.(map[string]any)["key"])
+}
+
+func (suite *MessageSuite) TestApplyTraitHeaders() {
+	trait := &MessageTrait{
+		Headers: &Schema{
+			Type:        "object",
+			Properties:  map[string]*Schema{"tenant": {Type: "string"}},
+			Validations: asyncapi.Validations[Schema]{Required: []string{"tenant"}},
+		},
+	}
+	other := &MessageTrait{
+		Headers: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"traceId": {Type: "string"}},
+		},
+	}
+
+	msg := Message{Name: "Msg"}
+	suite.Require().NoError(msg.ApplyTrait(trait, Specification{}))
+	suite.Require().NoError(msg.ApplyTrait(other, Specification{}))
+
+	// The headers of the traits should be merged in the message ones
+	suite.Require().Equal("HeadersFromMsg", msg.Headers.Name)
+	suite.Require().Len(msg.Headers.Properties, 2)
+	suite.Require().Equal([]string{"tenant"}, msg.Headers.Required)
+
+	// Without changing the trait ones
+	suite.Require().Len(trait.Headers.Properties, 1)
+}
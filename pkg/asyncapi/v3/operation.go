@@ -1,5 +1,7 @@
 package asyncapiv3
 
+import "github.com/mohae/deepcopy"
+
 // OperationAction represents an OperationAction.
 type OperationAction string
 
@@ -20,6 +22,19 @@ func (oa OperationAction) IsReceive() bool {
 	return oa == OperationActionIsReceive
 }
 
+var (
+	// operationPrefix is the prefix added to the operations name.
+	operationPrefix = ""
+	// operationSuffix is the suffix added to the operations name.
+	operationSuffix = "Operation"
+)
+
+// SetOperationNameAffixes sets the prefix and suffix added to the operations
+// name, that is used in the name of the generated methods.
+func SetOperationNameAffixes(prefix, suffix string) {
+	operationPrefix, operationSuffix = prefix, suffix
+}
+
 // Operation is a representation of the corresponding asyncapi object filled
 // from an asyncapi specification that will be used to generate code.
 // Source: https://www.asyncapi.com/docs/reference/specification/v3.0.0#operationObject
@@ -30,7 +45,7 @@ type Operation struct {
 	Channel      *Channel               `json:"channel"` // Reference only
 	Title        string                 `json:"title"`
 	Summary      string                 `json:"summary"`
-	Description  string                 `json:"string"`
+	Description  string                 `json:"description"`
 	Security     []*SecurityScheme      `json:"security"`
 	Tags         []*Tag                 `json:"tags"`
 	ExternalDocs *ExternalDocumentation `json:"externalDocs"`
@@ -40,6 +55,10 @@ type Operation struct {
 	Reply        *OperationReply        `json:"reply"`
 	Reference    string                 `json:"$ref"`
 
+	// --- asyncapi-codegen extensions -----------------------------------------
+
+	ExtGoName string `json:"x-go-name"`
+
 	// --- Non AsyncAPI fields -------------------------------------------------
 
 	Name        string     `json:"-"`
@@ -55,7 +74,15 @@ func (op *Operation) generateMetadata(parentName, name string) error {
 	}
 
 	// Set name
-	op.Name = generateFullName(parentName, name, "Operation", nil)
+	if operationPrefix != "" {
+		name = operationPrefix + "_" + name
+	}
+	op.Name = generateFullName(parentName, name, operationSuffix, nil)
+	if err := checkGoName(op.ExtGoName); err != nil {
+		return err
+	} else if op.ExtGoName != "" {
+		op.Name = op.ExtGoName
+	}
 
 	// Generate securities metadata
 	for i, sec := range op.Security {
@@ -160,8 +187,12 @@ func (op *Operation) setTraitsDependenciesAndApply(spec Specification) error {
 		if err := t.setDependencies(spec); err != nil {
 			return err
 		}
+	}
 
-		op.ApplyTrait(t.Follow(), spec)
+	// Apply the traits from the last one, as the values of the operation take
+	// precedence over the ones of its traits, and the last traits over the first
+	for i := len(op.Traits) - 1; i >= 0; i-- {
+		op.ApplyTrait(op.Traits[i].Follow(), spec)
 	}
 
 	return nil
@@ -191,7 +222,9 @@ func (op Operation) GetMessage() (*Message, error) {
 	return op.Channel.GetMessage()
 }
 
-// ApplyTrait applies a trait to the operation.
+// ApplyTrait applies a trait to the operation: the values that are not set in
+// the operation are taken from the trait, and the security schemes, tags and
+// bindings are merged.
 func (op *Operation) ApplyTrait(ot *OperationTrait, spec Specification) {
 	// Check operation is not nil
 	if op == nil {
@@ -227,10 +260,15 @@ func (op *Operation) ApplyTrait(ot *OperationTrait, spec Specification) {
 		op.ExternalDocs = &extDoc
 	}
 
-	// Override bindings if not set
-	if op.Bindings == nil && ot.Bindings != nil {
-		bindings := *ot.Bindings
-		op.Bindings = &bindings
+	// Merge bindings, on copies as they can be shared with other operations
+	if ot.Bindings != nil {
+		bindings := deepcopy.Copy(ot.Bindings.Follow()).(*OperationBindings)
+		if op.Bindings != nil {
+			own := deepcopy.Copy(op.Bindings.Follow()).(*OperationBindings)
+			mergeBindings(own, bindings)
+			bindings = own
+		}
+		op.Bindings = bindings
 	}
 }
 
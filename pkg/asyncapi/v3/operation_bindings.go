@@ -1,31 +1,33 @@
 package asyncapiv3
 
+import "fmt"
+
 // OperationBindings is a representation of the corresponding asyncapi object filled
 // from an asyncapi specification that will be used to generate code.
 // Source: https://www.asyncapi.com/docs/reference/specification/v3.0.0#operationBindingsObject
 type OperationBindings struct {
 	// --- AsyncAPI fields -----------------------------------------------------
 
-	HTTP         HTTPBinding         `json:"http"`
-	WS           WsBinding           `json:"ws"`
-	Kafka        KafkaBinding        `json:"kafka"`
-	AnyPointMQ   AnyPointMqBinding   `json:"anypointmq"`
-	AMQP         AMQPBinding         `json:"amqp"`
-	AMQP1        AMQP1Binding        `json:"amqp1"`
-	MQTT         MQTTBinding         `json:"mqtt"`
-	MQTT5        MQTT5Binding        `json:"mqtt5"`
-	NATS         NATSBinding         `json:"nats"`
-	JMS          JMSBinding          `json:"jms"`
-	SNS          SNSBinding          `json:"sns"`
-	Solace       SolaceBinding       `json:"solace"`
-	SQS          SQSBinding          `json:"sqs"`
-	Stomp        StompBinding        `json:"stomp"`
-	Redis        RedisBinding        `json:"redis"`
-	Mercure      MercureBinding      `json:"mercure"`
-	IBMMQ        IBMMQBinding        `json:"ibmmq"`
-	GooglePubSub GooglePubSubBinding `json:"googlepubsub"`
-	Pulsar       PulsarBinding       `json:"pulsar"`
-	Reference    string              `json:"$ref"`
+	HTTP         HTTPBinding           `json:"http"`
+	WS           WsBinding             `json:"ws"`
+	Kafka        KafkaBinding          `json:"kafka"`
+	AnyPointMQ   AnyPointMqBinding     `json:"anypointmq"`
+	AMQP         *AMQPOperationBinding `json:"amqp"`
+	AMQP1        AMQP1Binding          `json:"amqp1"`
+	MQTT         *MQTTOperationBinding `json:"mqtt"`
+	MQTT5        MQTT5Binding          `json:"mqtt5"`
+	NATS         *NATSOperationBinding `json:"nats"`
+	JMS          JMSBinding            `json:"jms"`
+	SNS          SNSBinding            `json:"sns"`
+	Solace       SolaceBinding         `json:"solace"`
+	SQS          SQSBinding            `json:"sqs"`
+	Stomp        StompBinding          `json:"stomp"`
+	Redis        RedisBinding          `json:"redis"`
+	Mercure      MercureBinding        `json:"mercure"`
+	IBMMQ        IBMMQBinding          `json:"ibmmq"`
+	GooglePubSub GooglePubSubBinding   `json:"googlepubsub"`
+	Pulsar       PulsarBinding         `json:"pulsar"`
+	Reference    string                `json:"$ref"`
 
 	// --- Non AsyncAPI fields -------------------------------------------------
 
@@ -60,5 +62,26 @@ func (ob *OperationBindings) setDependencies(spec Specification) error {
 		ob.ReferenceTo = refTo
 	}
 
+	// Check extensions
+	if ob.NATS != nil {
+		if err := ob.NATS.ExtJetStreamConsumer.validate(); err != nil {
+			return fmt.Errorf("operation bindings %q: %w", ob.Name, err)
+		}
+	}
+
 	return nil
 }
+
+// Follow returns referenced operation bindings if specified or the actual operation bindings.
+func (ob *OperationBindings) Follow() *OperationBindings {
+	if ob.ReferenceTo != nil {
+		return ob.ReferenceTo
+	}
+	return ob
+}
+
+// HasBrokerBindings returns true if the operation bindings have information
+// used by the broker controllers, and generated in the code.
+func (ob *OperationBindings) HasBrokerBindings() bool {
+	return ob.AMQP != nil || ob.MQTT != nil || (ob.NATS != nil && ob.NATS.ExtJetStreamConsumer != nil)
+}
@@ -11,6 +11,10 @@ type OperationReply struct {
 	Messages  []*Message             `json:"messages"` // References only
 	Reference string                 `json:"$ref"`
 
+	// --- asyncapi-codegen extensions -----------------------------------------
+
+	ExtReplyStream *ReplyStreamExtension `json:"x-reply-stream"`
+
 	// --- Non AsyncAPI fields -------------------------------------------------
 
 	Name        string          `json:"-"`
@@ -30,6 +34,11 @@ func (or *OperationReply) generateMetadata(parentName, name string) error {
 	// Generate address metadata
 	or.Address.generateMetadata(or.Name, "")
 
+	// Remove the reply stream if it is disabled
+	if or.ExtReplyStream != nil && or.ExtReplyStream.disabled {
+		or.ExtReplyStream = nil
+	}
+
 	return nil
 }
 
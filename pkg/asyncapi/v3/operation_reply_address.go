@@ -58,6 +58,17 @@ func (ora *OperationReplyAddress) setDependencies(op *Operation, spec Specificat
 	return nil
 }
 
+// HeaderKey returns the header containing the reply address, or an empty string
+// if the reply address is not directly in the message headers.
+func (ora OperationReplyAddress) HeaderKey() string {
+	path := strings.Split(ora.Location, "/")
+	if len(path) != 2 || path[0] != "$message.header#" {
+		return ""
+	}
+
+	return path[1]
+}
+
 func (ora OperationReplyAddress) isLocationRequired(op *Operation) (bool, error) {
 	if ora.Location == "" {
 		return false, nil
@@ -45,3 +45,11 @@ func (p *Parameter) setDependencies(spec Specification) error {
 
 	return nil
 }
+
+// Follow returns referenced parameter if specified or the actual parameter.
+func (p *Parameter) Follow() *Parameter {
+	if p.ReferenceTo != nil {
+		return p.ReferenceTo
+	}
+	return p
+}
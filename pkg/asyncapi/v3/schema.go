@@ -1,8 +1,14 @@
 package asyncapiv3
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
 	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils/template"
 )
 
 // SchemaType is a structure that represents the type of a field.
@@ -49,17 +55,35 @@ type Schema struct {
 
 	// --- AsyncAPI specific ---------------------------------------------------
 
-	Description string `json:"description"`
-	Format      string `json:"format"`
-	Default     any    `json:"default"`
+	Description   string `json:"description"`
+	Format        string `json:"format"`
+	Default       any    `json:"default"`
+	Discriminator string `json:"discriminator"`
 
 	Reference string `json:"$ref"`
 
+	// Multi Format Schema Object, converted on metadata generation
+	SchemaFormat      string `json:"schemaFormat"`
+	MultiFormatSchema any    `json:"schema"`
+
 	// --- Non Json Schema/AsyncAPI fields -------------------------------------
 
 	Name        string  `json:"-"`
 	ReferenceTo *Schema `json:"-"`
 
+	// AvroSchema is the canonical form of the Avro schema this schema has been
+	// converted from, if any.
+	AvroSchema string `json:"-"`
+
+	// IsMessageHeaders is true when the schema is used as headers of a message.
+	IsMessageHeaders bool `json:"-"`
+
+	// allOfMerged is true when the AllOf schemas have been merged into this one.
+	allOfMerged bool
+
+	// isFalse is true when the schema is the boolean schema 'false'.
+	isFalse bool
+
 	// Embedded validation fields
 	asyncapi.Validations[Schema]
 
@@ -78,6 +102,30 @@ func NewSchema() Schema {
 	}
 }
 
+// UnmarshalJSON unmarshals the schema, that can also be a boolean schema:
+// 'true' accepts any value (generated as 'any') and 'false' accepts no value.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = NewSchema()
+		s.ExtGoType = "any"
+		return nil
+	case "false":
+		*s = NewSchema()
+		s.isFalse = true
+		return nil
+	}
+
+	type schema Schema
+	return json.Unmarshal(data, (*schema)(s))
+}
+
+// IsFalse returns true if the schema is the boolean schema 'false', that
+// accepts no value.
+func (s Schema) IsFalse() bool {
+	return s.isFalse
+}
+
 // generateMetadata generates metadata for the Schema and its children.
 //
 //nolint:funlen,cyclop // Not necessary to reduce length and cyclop
@@ -87,9 +135,24 @@ func (s *Schema) generateMetadata(parentName, name string, number *int, isRequir
 		return nil
 	}
 
+	// Convert the schema if it is in another format
+	if err := s.convertMultiFormatSchema(); err != nil {
+		return err
+	}
+
 	// Set name
 	// NOTE: do not specify the type "schema" in the name
 	s.Name = generateFullName(parentName, name, "", number)
+	if err := checkGoName(s.ExtGoName); err != nil {
+		return err
+	} else if s.ExtGoName != "" {
+		s.Name = s.ExtGoName
+	}
+
+	// Check the optional fields strategy
+	if s.ExtGoOptional != "" && !IsValidGoOptional(s.ExtGoOptional) {
+		return fmt.Errorf("%w: invalid x-go-optional %q on %q", ErrInvalidExtension, s.ExtGoOptional, s.Name)
+	}
 
 	// Generate Properties metadata
 	for n, p := range s.Properties {
@@ -133,15 +196,17 @@ func (s *Schema) generateMetadata(parentName, name string, number *int, isRequir
 	}
 
 	// Generate AnyOf metadata
-	for _, v := range s.AnyOf {
-		if err := v.generateMetadata(s.Name, "Any_Of", nil, false); err != nil {
+	// NOTE: number the variants of unions with a discriminator, as they are
+	// generated as different types
+	for i, v := range s.AnyOf {
+		if err := v.generateMetadata(s.Name, "Any_Of", s.variantNumber(i), false); err != nil {
 			return err
 		}
 	}
 
 	// Generate OneOf metadata
-	for _, v := range s.OneOf {
-		if err := v.generateMetadata(s.Name, "One_Of", nil, false); err != nil {
+	for i, v := range s.OneOf {
+		if err := v.generateMetadata(s.Name, "One_Of", s.variantNumber(i), false); err != nil {
 			return err
 		}
 	}
@@ -232,6 +297,11 @@ func (s *Schema) setDependencies(spec Specification) error {
 		return err
 	}
 
+	// Check the variants of unions with a discriminator
+	if err := s.checkDiscriminatedVariants(); err != nil {
+		return err
+	}
+
 	// Set Not dependencies
 	if err := s.Not.setDependencies(spec); err != nil {
 		return err
@@ -241,11 +311,25 @@ func (s *Schema) setDependencies(spec Specification) error {
 }
 
 func (s *Schema) setAllOfDependenciesAndMerge(spec Specification) error {
+	// Merge only once, as referenced schemas can be merged before their turn
+	if s.allOfMerged {
+		return nil
+	}
+	s.allOfMerged = true
+
 	for _, v := range s.AllOf {
 		if err := v.setDependencies(spec); err != nil {
 			return err
 		}
 
+		// Merge the AllOf of the referenced schema first, in order to get all
+		// its fields (this also works for recursive references)
+		if v.ReferenceTo != nil {
+			if err := v.ReferenceTo.setAllOfDependenciesAndMerge(spec); err != nil {
+				return err
+			}
+		}
+
 		// Merge with other fields as one struct (invalidate references)
 		if err := s.MergeWith(spec, *v); err != nil {
 			return err
@@ -261,6 +345,11 @@ func (s *Schema) setOneOfDependenciesAndMerge(spec Specification) error {
 			return err
 		}
 
+		// Keep the variants of unions with a discriminator as different types
+		if s.Discriminator != "" {
+			continue
+		}
+
 		// Merge with other fields as one struct (invalidate references)
 		if err := s.MergeWith(spec, *v); err != nil {
 			return err
@@ -276,6 +365,11 @@ func (s *Schema) setAnyOfDependenciesAndMerge(spec Specification) error {
 			return err
 		}
 
+		// Keep the variants of unions with a discriminator as different types
+		if s.Discriminator != "" {
+			continue
+		}
+
 		// Merge with other fields as one struct (invalidate references)
 		if err := s.MergeWith(spec, *v); err != nil {
 			return err
@@ -440,7 +534,11 @@ func (s *Schema) mergeWithSchemaReferenceProperties(s2 Schema) {
 	}
 
 	// Add properties from s2 reference to s
-	for k, v := range s2.ReferenceTo.Properties {
+	// NOTE: in the order of their names, so the requirements are always in the
+	// same order
+	for _, k := range utils.MapKeysToSortedList(s2.ReferenceTo.Properties) {
+		v := s2.ReferenceTo.Properties[k]
+
 		// Skip if the property already exists
 		_, exists := s.Properties[k]
 		if exists {
@@ -466,6 +564,47 @@ func (s *Schema) mergeWithSchemaReferenceProperties(s2 Schema) {
 	}
 }
 
+// ConstProperties returns the string representation of the constant values of
+// the schema properties, if any.
+func (s *Schema) ConstProperties() map[string]string {
+	consts := make(map[string]string)
+	for name, p := range s.Follow().Properties {
+		if c := p.Follow().Const; c != nil {
+			consts[name] = fmt.Sprintf("%v", c)
+		}
+	}
+	return consts
+}
+
+// IsEnum returns true if the schema is a string or integer enum, generated as
+// a named type with a constant for each of its values.
+func (s *Schema) IsEnum() bool {
+	if len(s.Enum) == 0 || s.ExtGoType != "" {
+		return false
+	}
+
+	for _, e := range s.Enum {
+		switch v := e.(type) {
+		case string:
+			if s.Type != SchemaTypeIsString.String() || template.IsStringFormatGenerated(s.Format) {
+				return false
+			}
+		case float64:
+			if s.Type != SchemaTypeIsInteger.String() || v != math.Trunc(v) {
+				return false
+			}
+		case int, int64:
+			if s.Type != SchemaTypeIsInteger.String() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // Follow returns referenced schema if specified or the actual schema.
 func (s *Schema) Follow() *Schema {
 	if s.ReferenceTo != nil {
@@ -0,0 +1,95 @@
+package asyncapiv3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiscriminatedVariant is a variant of a union with a discriminator.
+type DiscriminatedVariant struct {
+	// Value is the value of the discriminator property for this variant.
+	Value string
+	// Schema is the schema of the variant.
+	Schema *Schema
+}
+
+// IsDiscriminatedUnion returns true if the schema is a oneOf or anyOf union
+// with a discriminator, generated as an interface implemented by its variants.
+func (s *Schema) IsDiscriminatedUnion() bool {
+	return s.Discriminator != "" && len(s.variants()) > 0
+}
+
+// DiscriminatedVariants returns the variants of the union with a discriminator,
+// with the value of the discriminator property for each of them.
+func (s *Schema) DiscriminatedVariants() []DiscriminatedVariant {
+	variants := make([]DiscriminatedVariant, 0, len(s.variants()))
+	for _, v := range s.variants() {
+		variants = append(variants, DiscriminatedVariant{
+			Value:  discriminatorValue(s.Discriminator, v),
+			Schema: v,
+		})
+	}
+	return variants
+}
+
+// variants returns the oneOf variants of the schema, or the anyOf ones.
+func (s *Schema) variants() []*Schema {
+	if len(s.OneOf) > 0 {
+		return s.OneOf
+	}
+	return s.AnyOf
+}
+
+// variantNumber returns the number used in the name of a variant, only for
+// unions with a discriminator.
+func (s *Schema) variantNumber(i int) *int {
+	if s.Discriminator == "" {
+		return nil
+	}
+	return &i
+}
+
+// checkDiscriminatedVariants checks that the variants of the union with a
+// discriminator are objects with a unique value for the discriminator.
+func (s *Schema) checkDiscriminatedVariants() error {
+	if !s.IsDiscriminatedUnion() {
+		return nil
+	}
+
+	values := make(map[string]bool, len(s.variants()))
+	for _, v := range s.DiscriminatedVariants() {
+		switch {
+		case v.Schema.Follow().Type != SchemaTypeIsObject.String():
+			return fmt.Errorf("%w: variants of %q should be objects", ErrInvalidSchema, s.Name)
+		case v.Value == "":
+			return fmt.Errorf("%w: a variant of %q has no value for the discriminator %q "+
+				"(with a reference, a const or an enum of one value)", ErrInvalidSchema, s.Name, s.Discriminator)
+		case values[v.Value]:
+			return fmt.Errorf("%w: variants of %q have the same discriminator value %q",
+				ErrInvalidSchema, s.Name, v.Value)
+		}
+		values[v.Value] = true
+	}
+
+	return nil
+}
+
+// discriminatorValue returns the value of the discriminator property for the
+// variant: its const (or the only value of its enum), or else the name of the
+// referenced schema.
+func discriminatorValue(discriminator string, variant *Schema) string {
+	if p, exists := variant.Follow().Properties[discriminator]; exists && p != nil {
+		p = p.Follow()
+		if p.Const != nil {
+			return fmt.Sprint(p.Const)
+		} else if len(p.Enum) == 1 {
+			return fmt.Sprint(p.Enum[0])
+		}
+	}
+
+	if variant.Reference != "" {
+		return variant.Reference[strings.LastIndex(variant.Reference, "/")+1:]
+	}
+
+	return ""
+}
@@ -0,0 +1,66 @@
+package asyncapiv3
+
+import (
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSchemaDiscriminatorSuite(t *testing.T) {
+	suite.Run(t, new(SchemaDiscriminatorSuite))
+}
+
+type SchemaDiscriminatorSuite struct {
+	suite.Suite
+}
+
+func variantWithType(value any) *Schema {
+	s := NewSchema()
+	s.Type = SchemaTypeIsObject.String()
+	s.Properties["type"] = &Schema{Type: SchemaTypeIsString.String(), Validations: asyncapi.Validations[Schema]{Const: value}}
+	return &s
+}
+
+func (suite *SchemaDiscriminatorSuite) TestVariants() {
+	cat := NewSchema()
+	cat.Type = SchemaTypeIsObject.String()
+	ref := &Schema{Reference: "#/components/schemas/cat", ReferenceTo: &cat}
+
+	dog := NewSchema()
+	dog.Type = SchemaTypeIsObject.String()
+	dog.Properties["type"] = &Schema{
+		Type:        SchemaTypeIsString.String(),
+		Validations: asyncapi.Validations[Schema]{Enum: []any{"dog"}},
+	}
+
+	fish := variantWithType("fish")
+	union := Schema{Name: "Pet", Discriminator: "type", OneOf: []*Schema{ref, &dog, fish}}
+
+	suite.Require().True(union.IsDiscriminatedUnion())
+	suite.Require().NoError(union.checkDiscriminatedVariants())
+	suite.Require().Equal([]DiscriminatedVariant{
+		{Value: "cat", Schema: ref},
+		{Value: "dog", Schema: &dog},
+		{Value: "fish", Schema: fish},
+	}, union.DiscriminatedVariants())
+}
+
+func (suite *SchemaDiscriminatorSuite) TestWithoutDiscriminator() {
+	union := Schema{Name: "Pet", AnyOf: []*Schema{variantWithType("cat")}}
+	suite.Require().False(union.IsDiscriminatedUnion())
+	suite.Require().Nil(union.variantNumber(1))
+}
+
+func (suite *SchemaDiscriminatorSuite) TestInvalidVariants() {
+	cases := map[string][]*Schema{
+		"not an object":   {{Type: SchemaTypeIsString.String()}},
+		"without value":   {variantWithType(nil)},
+		"duplicate value": {variantWithType("cat"), variantWithType("cat")},
+	}
+
+	for name, variants := range cases {
+		union := Schema{Name: "Pet", Discriminator: "type", AnyOf: variants}
+		suite.Require().ErrorIs(union.checkDiscriminatedVariants(), ErrInvalidSchema, name)
+	}
+}
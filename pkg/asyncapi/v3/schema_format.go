@@ -0,0 +1,210 @@
+package asyncapiv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	hamba "github.com/hamba/avro/v2"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrInvalidSchema is sent when a schema can't be used for generation.
+	ErrInvalidSchema = fmt.Errorf("%w: invalid schema", extensions.ErrAsyncAPI)
+)
+
+const (
+	// SchemaFormatAvroPrefix is the prefix of the formats of the Avro schemas.
+	SchemaFormatAvroPrefix = "application/vnd.apache.avro"
+	// SchemaFormatAsyncAPIPrefix is the prefix of the formats of the AsyncAPI schemas.
+	SchemaFormatAsyncAPIPrefix = "application/vnd.aai.asyncapi"
+	// SchemaFormatJSONSchemaPrefix is the prefix of the formats of the JSON schemas.
+	SchemaFormatJSONSchemaPrefix = "application/schema+"
+)
+
+// convertMultiFormatSchema replaces the Multi Format Schema Object by the
+// schema it contains, converted to a JSON Schema if it is an Avro schema.
+// Source: https://www.asyncapi.com/docs/reference/specification/v3.0.0#multiFormatSchemaObject
+func (s *Schema) convertMultiFormatSchema() error {
+	if s.SchemaFormat == "" {
+		return nil
+	}
+
+	raw, err := multiFormatSchemaJSON(s.MultiFormatSchema)
+	if err != nil {
+		return err
+	}
+
+	var converted Schema
+	switch format := strings.ToLower(s.SchemaFormat); {
+	case strings.HasPrefix(format, SchemaFormatAvroPrefix):
+		avroSchema, err := hamba.Parse(string(raw))
+		if err != nil {
+			return fmt.Errorf("%w: invalid Avro schema: %w", ErrInvalidSchema, err)
+		}
+
+		c, err := avroToSchema(avroSchema, make(map[string]bool))
+		if err != nil {
+			return err
+		}
+		converted = *c
+
+		// Keep the schema as it is written, as its canonical form has no defaults
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return fmt.Errorf("%w: invalid Avro schema: %w", ErrInvalidSchema, err)
+		}
+		converted.AvroSchema = compact.String()
+	case strings.HasPrefix(format, SchemaFormatAsyncAPIPrefix),
+		strings.HasPrefix(format, SchemaFormatJSONSchemaPrefix):
+		if err := json.Unmarshal(raw, &converted); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported schema format %q", ErrInvalidSchema, s.SchemaFormat)
+	}
+
+	*s = converted
+	return nil
+}
+
+// multiFormatSchemaJSON returns the JSON of the schema of a Multi Format Schema
+// Object. Schemas set as a string (like Avro schemas written inline) should
+// contain JSON.
+func multiFormatSchemaJSON(schema any) ([]byte, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("%w: missing schema with the schema format", ErrInvalidSchema)
+	}
+
+	if str, ok := schema.(string); ok {
+		str = strings.TrimSpace(str)
+		if strings.HasPrefix(str, "{") || strings.HasPrefix(str, "[") {
+			return []byte(str), nil
+		}
+	}
+
+	return json.Marshal(schema)
+}
+
+// avroToSchema converts the Avro schema to its JSON Schema equivalent, with
+// the JSON representation used by the Avro codec. Records being converted are
+// in 'records', as recursive records are not supported.
+//
+//nolint:funlen,cyclop // this is a type switch on all Avro types
+func avroToSchema(schema hamba.Schema, records map[string]bool) (*Schema, error) {
+	s := NewSchema()
+
+	switch as := schema.(type) {
+	case *hamba.RefSchema:
+		if records[as.Schema().FullName()] {
+			return nil, fmt.Errorf("%w: recursive Avro record %q is not supported",
+				ErrInvalidSchema, as.Schema().FullName())
+		}
+		return avroToSchema(as.Schema(), records)
+	case *hamba.RecordSchema:
+		records[as.FullName()] = true
+		defer delete(records, as.FullName())
+
+		s.Type = "object"
+		s.Description = as.Doc()
+		for _, f := range as.Fields() {
+			p, err := avroToSchema(f.Type(), records)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name(), err)
+			}
+			if f.Doc() != "" {
+				p.Description = f.Doc()
+			}
+			s.Properties[f.Name()] = p
+
+			// Fields that can be omitted are the ones with a default value
+			if !f.HasDefault() && !isNullableAvroSchema(f.Type()) {
+				s.Required = append(s.Required, f.Name())
+			}
+		}
+	case *hamba.EnumSchema:
+		s.Type = "string"
+		s.Description = as.Doc()
+		for _, symbol := range as.Symbols() {
+			s.Enum = append(s.Enum, symbol)
+		}
+	case *hamba.ArraySchema:
+		items, err := avroToSchema(as.Items(), records)
+		if err != nil {
+			return nil, err
+		}
+		s.Type = "array"
+		s.Items = items
+	case *hamba.MapSchema:
+		values, err := avroToSchema(as.Values(), records)
+		if err != nil {
+			return nil, err
+		}
+		s.Type = "object"
+		s.AdditionalProperties = values
+	case *hamba.UnionSchema:
+		// Only the nullable unions have an equivalent, the other ones can have
+		// values of any type
+		if !as.Nullable() {
+			s.ExtGoType = "any"
+			return &s, nil
+		}
+
+		for _, t := range as.Types() {
+			if t.Type() != hamba.Null {
+				return avroToSchema(t, records)
+			}
+		}
+	case *hamba.FixedSchema:
+		s.Type, s.Format = "string", "byte"
+	case *hamba.PrimitiveSchema:
+		avroPrimitiveToSchema(as, &s)
+	default:
+		return nil, fmt.Errorf("%w: unsupported Avro schema %q", ErrInvalidSchema, schema.Type())
+	}
+
+	return &s, nil
+}
+
+// avroPrimitiveToSchema sets the type and format of the schema from the Avro
+// primitive type.
+func avroPrimitiveToSchema(as *hamba.PrimitiveSchema, s *Schema) {
+	if as.Logical() != nil {
+		switch as.Logical().Type() {
+		case hamba.Date:
+			s.Type, s.Format = "string", "date"
+			return
+		case hamba.TimestampMillis, hamba.TimestampMicros:
+			s.Type, s.Format = "string", "date-time"
+			return
+		}
+	}
+
+	switch as.Type() {
+	case hamba.Boolean:
+		s.Type = "boolean"
+	case hamba.Int:
+		s.Type, s.Format = "integer", "int32"
+	case hamba.Long:
+		s.Type, s.Format = "integer", "int64"
+	case hamba.Float:
+		s.Type, s.Format = "number", "float"
+	case hamba.Double:
+		s.Type, s.Format = "number", "double"
+	case hamba.Bytes:
+		s.Type, s.Format = "string", "byte"
+	case hamba.String:
+		s.Type = "string"
+	default:
+		// Null values
+		s.ExtGoType = "any"
+	}
+}
+
+// isNullableAvroSchema returns true if the Avro schema is a union accepting null.
+func isNullableAvroSchema(schema hamba.Schema) bool {
+	union, ok := schema.(*hamba.UnionSchema)
+	return ok && union.Nullable()
+}
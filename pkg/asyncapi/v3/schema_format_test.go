@@ -0,0 +1,108 @@
+package asyncapiv3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSchemaFormatSuite(t *testing.T) {
+	suite.Run(t, new(SchemaFormatSuite))
+}
+
+type SchemaFormatSuite struct {
+	suite.Suite
+}
+
+func (suite *SchemaFormatSuite) TestAvroRecord() {
+	s := Schema{
+		SchemaFormat: "application/vnd.apache.avro;version=1.9.0",
+		MultiFormatSchema: map[string]any{
+			"type": "record",
+			"name": "Order",
+			"fields": []any{
+				map[string]any{"name": "id", "type": "long"},
+				map[string]any{"name": "quantity", "type": "int", "default": 1},
+				map[string]any{"name": "comment", "type": []any{"null", "string"}},
+				map[string]any{"name": "value", "type": []any{"int", "string"}},
+				map[string]any{"name": "status", "type": map[string]any{
+					"type": "enum", "name": "Status", "symbols": []any{"NEW", "SHIPPED"},
+				}},
+				map[string]any{"name": "tags", "type": map[string]any{"type": "array", "items": "string"}},
+				map[string]any{"name": "attributes", "type": map[string]any{"type": "map", "values": "double"}},
+				map[string]any{"name": "date", "type": map[string]any{"type": "int", "logicalType": "date"}},
+				map[string]any{"name": "createdAt", "type": map[string]any{
+					"type": "long", "logicalType": "timestamp-micros",
+				}},
+			},
+		},
+	}
+	suite.Require().NoError(s.convertMultiFormatSchema())
+
+	suite.Require().Equal("object", s.Type)
+	suite.Require().ElementsMatch([]string{"id", "value", "status", "tags", "attributes", "date", "createdAt"}, s.Required)
+	suite.Require().NotEmpty(s.AvroSchema)
+
+	cases := map[string][2]string{
+		"id":        {"integer", "int64"},
+		"quantity":  {"integer", "int32"},
+		"comment":   {"string", ""},
+		"status":    {"string", ""},
+		"tags":      {"array", ""},
+		"date":      {"string", "date"},
+		"createdAt": {"string", "date-time"},
+	}
+	for name, c := range cases {
+		suite.Require().Equal(c[0], s.Properties[name].Type, name)
+		suite.Require().Equal(c[1], s.Properties[name].Format, name)
+	}
+	suite.Require().Equal([]any{"NEW", "SHIPPED"}, s.Properties["status"].Enum)
+	suite.Require().Equal("string", s.Properties["tags"].Items.Type)
+	suite.Require().Equal("number", s.Properties["attributes"].AdditionalProperties.Type)
+	suite.Require().Equal("any", s.Properties["value"].ExtGoType)
+}
+
+func (suite *SchemaFormatSuite) TestAvroSchemaAsString() {
+	s := Schema{
+		SchemaFormat:      "application/vnd.apache.avro+json;version=1.9.0",
+		MultiFormatSchema: `{"type": "array", "items": "bytes"}`,
+	}
+	suite.Require().NoError(s.convertMultiFormatSchema())
+	suite.Require().Equal("array", s.Type)
+	suite.Require().Equal("byte", s.Items.Format)
+	suite.Require().Equal(`{"type":"array","items":"bytes"}`, s.AvroSchema)
+}
+
+func (suite *SchemaFormatSuite) TestRecursiveAvroRecord() {
+	s := Schema{
+		SchemaFormat: "application/vnd.apache.avro",
+		MultiFormatSchema: `{"type": "record", "name": "Node", "fields": [
+			{"name": "next", "type": ["null", "Node"]}
+		]}`,
+	}
+	suite.Require().ErrorIs(s.convertMultiFormatSchema(), ErrInvalidSchema)
+}
+
+func (suite *SchemaFormatSuite) TestJSONSchemaFormat() {
+	s := Schema{
+		SchemaFormat: "application/schema+json;version=draft-07",
+		MultiFormatSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}},
+		},
+	}
+	suite.Require().NoError(s.convertMultiFormatSchema())
+	suite.Require().Equal("object", s.Type)
+	suite.Require().Equal("string", s.Properties["name"].Type)
+	suite.Require().Empty(s.AvroSchema)
+}
+
+func (suite *SchemaFormatSuite) TestInvalidSchemaFormats() {
+	for _, s := range []Schema{
+		{SchemaFormat: "application/raml+yaml;version=1.0", MultiFormatSchema: map[string]any{}},
+		{SchemaFormat: "application/vnd.apache.avro"},
+		{SchemaFormat: "application/vnd.apache.avro", MultiFormatSchema: `{"type": "unknown"}`},
+	} {
+		suite.Require().ErrorIs(s.convertMultiFormatSchema(), ErrInvalidSchema, s.SchemaFormat)
+	}
+}
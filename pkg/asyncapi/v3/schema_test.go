@@ -0,0 +1,103 @@
+package asyncapiv3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(SchemaSuite))
+}
+
+type SchemaSuite struct {
+	suite.Suite
+}
+
+func objectWithProperty(name string) *Schema {
+	return &Schema{
+		Type:        SchemaTypeIsObject.String(),
+		Properties:  map[string]*Schema{name: {Type: SchemaTypeIsString.String()}},
+		Validations: asyncapi.Validations[Schema]{Required: []string{name}},
+	}
+}
+
+func (suite *SchemaSuite) TestAllOfWithNestedReferences() {
+	orderCreated := &Schema{AllOf: []*Schema{
+		{Reference: "#/components/schemas/event"},
+		objectWithProperty("orderId"),
+	}}
+	event := &Schema{AllOf: []*Schema{
+		{Reference: "#/components/schemas/traceable"},
+		objectWithProperty("id"),
+	}}
+	spec := Specification{Components: Components{Schemas: map[string]*Schema{
+		"orderCreated": orderCreated,
+		"event":        event,
+		"traceable":    objectWithProperty("traceId"),
+	}}}
+	suite.Require().NoError(spec.generateMetadata())
+
+	// Set the dependencies of the schema before the referenced one
+	suite.Require().NoError(orderCreated.setDependencies(spec))
+	suite.Require().NoError(event.setDependencies(spec))
+
+	for _, s := range []*Schema{orderCreated, event} {
+		suite.Require().Equal(SchemaTypeIsObject.String(), s.Type)
+		suite.Require().Contains(s.Properties, "traceId")
+		suite.Require().True(s.Properties["traceId"].IsRequired)
+	}
+	suite.Require().Len(orderCreated.Properties, 3)
+	suite.Require().Len(event.Properties, 2)
+}
+
+func (suite *SchemaSuite) TestAllOfWithRecursiveReferences() {
+	node := &Schema{AllOf: []*Schema{
+		{Reference: "#/components/schemas/node"},
+		objectWithProperty("value"),
+	}}
+	spec := Specification{Components: Components{Schemas: map[string]*Schema{"node": node}}}
+	suite.Require().NoError(spec.generateMetadata())
+
+	suite.Require().NoError(node.setDependencies(spec))
+	suite.Require().Len(node.Properties, 1)
+}
+
+func (suite *SchemaSuite) TestIsEnum() {
+	cases := []struct {
+		Type   string
+		Format string
+		Enum   []any
+		Result bool
+	}{
+		{Type: "string", Enum: []any{"a", "b"}, Result: true},
+		{Type: "integer", Enum: []any{float64(1), float64(2)}, Result: true},
+		{Type: "string", Result: false},
+		{Type: "string", Enum: []any{"a", float64(1)}, Result: false},
+		{Type: "integer", Enum: []any{float64(1.5)}, Result: false},
+		{Type: "number", Enum: []any{float64(1.5)}, Result: false},
+		{Type: "string", Format: "date-time", Enum: []any{"2024-01-01T00:00:00Z"}, Result: false},
+	}
+
+	for i, c := range cases {
+		s := Schema{Type: c.Type, Format: c.Format, Validations: asyncapi.Validations[Schema]{Enum: c.Enum}}
+		suite.Require().Equal(c.Result, s.IsEnum(), i)
+	}
+}
+
+func (suite *SchemaSuite) TestBooleanSchemas() {
+	var s Schema
+	suite.Require().NoError(json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {"open": {"type": "object", "additionalProperties": true}},
+		"additionalProperties": false
+	}`), &s))
+
+	suite.Require().Equal("object", s.Type)
+	suite.Require().True(s.AdditionalProperties.IsFalse())
+	suite.Require().False(s.Properties["open"].IsFalse())
+	suite.Require().False(s.Properties["open"].AdditionalProperties.IsFalse())
+	suite.Require().Equal("any", s.Properties["open"].AdditionalProperties.ExtGoType)
+}
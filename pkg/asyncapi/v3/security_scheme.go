@@ -22,7 +22,7 @@ type OAuthFlows struct {
 
 	Implicit          OAuthFlow `json:"implicit"`
 	Password          OAuthFlow `json:"password"`
-	ClientCredentials OAuthFlow `json:"clientCredential"`
+	ClientCredentials OAuthFlow `json:"clientCredentials"`
 	AuthorizationCode OAuthFlow `json:"authorizationCode"`
 
 	// --- Non AsyncAPI fields -------------------------------------------------
@@ -79,6 +79,15 @@ func (s *SecurityScheme) setDependencies(spec Specification) error {
 	return nil
 }
 
+// Follow returns referenced security scheme if specified or the actual
+// security scheme.
+func (s *SecurityScheme) Follow() *SecurityScheme {
+	if s.ReferenceTo != nil {
+		return s.ReferenceTo
+	}
+	return s
+}
+
 // RemoveDuplicateSecuritySchemes removes the security schemes that have the same
 // name, keeping the first occurrence.
 func RemoveDuplicateSecuritySchemes(securities []*SecurityScheme) []*SecurityScheme {
@@ -14,7 +14,7 @@ type Server struct {
 	Title           string                     `json:"title"`
 	Summary         string                     `json:"summary"`
 	Variables       map[string]*ServerVariable `json:"variables"`
-	Security        *SecurityScheme            `json:"security"`
+	Security        []*SecurityScheme          `json:"security"`
 	Tags            []*Tag                     `json:"tags"`
 	ExternalDocs    *ExternalDocumentation     `json:"externalDocs"`
 	Bindings        *ServerBindings            `json:"bindings"`
@@ -41,8 +41,10 @@ func (srv *Server) generateMetadata(parentName, name string) {
 		s.generateMetadata(srv.Name, n)
 	}
 
-	// Generate security metadata
-	srv.Security.generateMetadata(srv.Name, "", nil)
+	// Generate securities metadata
+	for i, sec := range srv.Security {
+		sec.generateMetadata(srv.Name, "", &i)
+	}
 
 	// Generate tags metadata
 	for i, t := range srv.Tags {
@@ -75,9 +77,11 @@ func (srv *Server) setDependencies(spec Specification) error {
 		}
 	}
 
-	// Set security dependencies
-	if err := srv.Security.setDependencies(spec); err != nil {
-		return err
+	// Set securities dependencies
+	for _, sec := range srv.Security {
+		if err := sec.setDependencies(spec); err != nil {
+			return err
+		}
 	}
 
 	// Set tags dependencies
@@ -117,3 +121,11 @@ func (srv *Server) setReference(spec Specification) error {
 
 	return nil
 }
+
+// Follow returns referenced server if specified or the actual server.
+func (srv *Server) Follow() *Server {
+	if srv.ReferenceTo != nil {
+		return srv.ReferenceTo
+	}
+	return srv
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
 )
 
 const (
@@ -78,6 +79,28 @@ func (s *Specification) AddDependency(path string, spec asyncapi.Specification)
 	return nil
 }
 
+// Dependencies returns the specifications referenced by the Specification,
+// directly or by its dependencies, without duplicates and ordered by path.
+func (s Specification) Dependencies() []*Specification {
+	deps := make([]*Specification, 0, len(s.dependencies))
+	return s.appendDependencies(deps, map[*Specification]bool{})
+}
+
+func (s Specification) appendDependencies(deps []*Specification, seen map[*Specification]bool) []*Specification {
+	for _, path := range utils.MapKeysToSortedList(s.dependencies) {
+		dep := s.dependencies[path]
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+
+		deps = append(deps, dep)
+		deps = dep.appendDependencies(deps, seen)
+	}
+
+	return deps
+}
+
 // generateMetadata generate metadata for the Specification and its children.
 //
 //nolint:cyclop // Not necessary to reduce statements
@@ -242,6 +265,30 @@ func (s Specification) ReferenceChannelBindings(ref string) (*ChannelBindings, e
 	return bindings, nil
 }
 
+// ReferenceCorrelationID returns the CorrelationID struct corresponding to the given reference.
+func (s Specification) ReferenceCorrelationID(ref string) (*CorrelationID, error) {
+	// Get object pointed by reference
+	obj, err := s.reference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cast to correlation ID
+	correlationID, ok := obj.(*CorrelationID)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: cannot cast %q into 'CorrelationID' (type is %q)",
+			ErrInvalidReference, ref, reflect.TypeOf(obj))
+	}
+
+	// Check that correlation ID is not nil
+	if correlationID == nil {
+		return nil, fmt.Errorf("%w: empty target for correlation ID reference %q", ErrInvalidReference, ref)
+	}
+
+	return correlationID, nil
+}
+
 // ReferenceExternalDocumentation returns the ExternalDocumentation struct corresponding to the given reference.
 func (s Specification) ReferenceExternalDocumentation(ref string) (*ExternalDocumentation, error) {
 	// Get object pointed by reference
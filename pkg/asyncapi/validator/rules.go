@@ -0,0 +1,264 @@
+package validator
+
+type kind int
+
+const (
+	kindAny kind = iota
+	kindObject
+	kindMap
+	kindArray
+	kindString
+)
+
+// rule is the expected structure of a value of the specification.
+type rule struct {
+	kind kind
+
+	// fields are the fields of an object, and required the ones that should be
+	// set. The extensions fields (starting with 'x-') are always allowed, and
+	// unknown is the message of the other fields.
+	fields   map[string]*rule
+	required []string
+	unknown  string
+
+	// items is the rule of the array items, or of the map values.
+	items *rule
+
+	// enum are the possible values of a string.
+	enum []string
+
+	// reference states if the value can be a reference object, and nullable
+	// if it can be null.
+	reference bool
+	nullable  bool
+}
+
+func object(fields map[string]*rule, required ...string) *rule {
+	return &rule{kind: kindObject, fields: fields, required: required}
+}
+
+func mapOf(values *rule) *rule {
+	return &rule{kind: kindMap, items: values}
+}
+
+func arrayOf(items *rule) *rule {
+	return &rule{kind: kindArray, items: items}
+}
+
+func str(enum ...string) *rule {
+	return &rule{kind: kindString, enum: enum}
+}
+
+func nullable(r *rule) *rule {
+	c := *r
+	c.nullable = true
+	return &c
+}
+
+func orReference(r *rule) *rule {
+	c := *r
+	c.reference = true
+	return &c
+}
+
+// withFields returns the fields with additional ones.
+func withFields(fields map[string]*rule, additional map[string]*rule) map[string]*rule {
+	res := make(map[string]*rule, len(fields)+len(additional))
+	for k, v := range fields {
+		res[k] = v
+	}
+	for k, v := range additional {
+		res[k] = v
+	}
+	return res
+}
+
+// specificationRule is the structure of an AsyncAPI v3 specification.
+// Source: https://github.com/asyncapi/spec-json-schemas/tree/master/schemas/3.0.0
+var specificationRule = newSpecificationRule()
+
+//nolint:funlen // this is the description of the specification structure
+func newSpecificationRule() *rule {
+	anyValue := &rule{kind: kindAny}
+	reference := object(map[string]*rule{"$ref": str()}, "$ref")
+	schema := anyValue
+
+	externalDocs := orReference(object(map[string]*rule{
+		"description": str(),
+		"url":         str(),
+	}, "url"))
+	tags := arrayOf(orReference(object(map[string]*rule{
+		"name":         str(),
+		"description":  str(),
+		"externalDocs": externalDocs,
+	}, "name")))
+
+	bindingsFields := make(map[string]*rule)
+	for _, protocol := range []string{
+		"amqp", "amqp1", "anypointmq", "googlepubsub", "http", "ibmmq", "jms", "kafka", "mercure",
+		"mqtt", "mqtt5", "nats", "pulsar", "redis", "sns", "solace", "sqs", "stomp", "ws",
+	} {
+		bindingsFields[protocol] = anyValue
+	}
+	bindings := orReference(object(bindingsFields))
+	bindings.unknown = "unsupported binding %q"
+
+	securityScheme := orReference(object(map[string]*rule{
+		"type": str("userPassword", "apiKey", "X509", "symmetricEncryption", "asymmetricEncryption",
+			"httpApiKey", "http", "oauth2", "openIdConnect", "plain", "scramSha256", "scramSha512", "gssapi"),
+		"description":      str(),
+		"name":             str(),
+		"in":               str("user", "password", "query", "header", "cookie"),
+		"scheme":           str(),
+		"bearerFormat":     str(),
+		"flows":            anyValue,
+		"openIdConnectUrl": str(),
+		"scopes":           arrayOf(str()),
+	}, "type"))
+	securitySchemes := arrayOf(securityScheme)
+
+	serverVariable := orReference(object(map[string]*rule{
+		"enum":        arrayOf(str()),
+		"default":     str(),
+		"description": str(),
+		"examples":    arrayOf(str()),
+	}))
+	server := orReference(object(map[string]*rule{
+		"host":            str(),
+		"protocol":        str(),
+		"protocolVersion": str(),
+		"pathname":        str(),
+		"description":     str(),
+		"title":           str(),
+		"summary":         str(),
+		"variables":       mapOf(serverVariable),
+		"security":        securitySchemes,
+		"tags":            tags,
+		"externalDocs":    externalDocs,
+		"bindings":        bindings,
+	}, "host", "protocol"))
+
+	correlationID := orReference(object(map[string]*rule{
+		"description": str(),
+		"location":    str(),
+	}, "location"))
+	messageTraitFields := map[string]*rule{
+		"headers":       schema,
+		"correlationId": correlationID,
+		"contentType":   str(),
+		"name":          str(),
+		"title":         str(),
+		"summary":       str(),
+		"description":   str(),
+		"tags":          tags,
+		"externalDocs":  externalDocs,
+		"bindings":      bindings,
+		"examples": arrayOf(object(map[string]*rule{
+			"headers": anyValue,
+			"payload": anyValue,
+			"name":    str(),
+			"summary": str(),
+		})),
+	}
+	messageTrait := orReference(object(messageTraitFields))
+	message := orReference(object(withFields(messageTraitFields, map[string]*rule{
+		"payload": schema,
+		"traits":  arrayOf(messageTrait),
+	})))
+
+	parameter := orReference(object(map[string]*rule{
+		"enum":        arrayOf(str()),
+		"default":     str(),
+		"description": str(),
+		"examples":    arrayOf(str()),
+		"location":    str(),
+	}))
+	channel := orReference(object(map[string]*rule{
+		"address":      nullable(str()),
+		"messages":     mapOf(message),
+		"title":        str(),
+		"summary":      str(),
+		"description":  str(),
+		"servers":      arrayOf(reference),
+		"parameters":   mapOf(parameter),
+		"tags":         tags,
+		"externalDocs": externalDocs,
+		"bindings":     bindings,
+	}))
+
+	replyAddress := orReference(object(map[string]*rule{
+		"description": str(),
+		"location":    str(),
+	}, "location"))
+	reply := orReference(object(map[string]*rule{
+		"address":  replyAddress,
+		"channel":  reference,
+		"messages": arrayOf(reference),
+	}))
+	operationTraitFields := map[string]*rule{
+		"title":        str(),
+		"summary":      str(),
+		"description":  str(),
+		"security":     securitySchemes,
+		"tags":         tags,
+		"externalDocs": externalDocs,
+		"bindings":     bindings,
+	}
+	operationTrait := orReference(object(operationTraitFields))
+	operation := orReference(object(withFields(operationTraitFields, map[string]*rule{
+		"action":   str("send", "receive"),
+		"channel":  reference,
+		"traits":   arrayOf(operationTrait),
+		"messages": arrayOf(reference),
+		"reply":    reply,
+	}), "action", "channel"))
+
+	components := object(map[string]*rule{
+		"schemas":           mapOf(schema),
+		"servers":           mapOf(server),
+		"channels":          mapOf(channel),
+		"operations":        mapOf(operation),
+		"messages":          mapOf(message),
+		"securitySchemes":   mapOf(securityScheme),
+		"serverVariables":   mapOf(serverVariable),
+		"parameters":        mapOf(parameter),
+		"correlationIds":    mapOf(correlationID),
+		"replies":           mapOf(reply),
+		"replyAddresses":    mapOf(replyAddress),
+		"externalDocs":      mapOf(externalDocs),
+		"tags":              mapOf(tags.items),
+		"operationTraits":   mapOf(operationTrait),
+		"messageTraits":     mapOf(messageTrait),
+		"serverBindings":    mapOf(bindings),
+		"channelBindings":   mapOf(bindings),
+		"operationBindings": mapOf(bindings),
+		"messageBindings":   mapOf(bindings),
+	})
+
+	return object(map[string]*rule{
+		"asyncapi": str("3.0.0"),
+		"id":       str(),
+		"info": object(map[string]*rule{
+			"title":          str(),
+			"version":        str(),
+			"description":    str(),
+			"termsOfService": str(),
+			"contact": object(map[string]*rule{
+				"name":  str(),
+				"url":   str(),
+				"email": str(),
+			}),
+			"license": object(map[string]*rule{
+				"name": str(),
+				"url":  str(),
+			}, "name"),
+			"tags":         tags,
+			"externalDocs": externalDocs,
+		}, "title", "version"),
+		"servers":            mapOf(server),
+		"defaultContentType": str(),
+		"channels":           mapOf(channel),
+		"operations":         mapOf(operation),
+		"components":         components,
+	}, "asyncapi", "info")
+}
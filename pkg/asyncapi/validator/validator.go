@@ -0,0 +1,415 @@
+// Package validator validates AsyncAPI v3 specifications files, reporting the
+// issues with their position in the files.
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnsupportedVersion is returned when the specification is not an
+	// AsyncAPI v3 specification.
+	ErrUnsupportedVersion = fmt.Errorf("%w: only AsyncAPI v3 specifications can be validated", extensions.ErrAsyncAPI)
+)
+
+// Issue is a problem of a specification, at a position of one of its files.
+type Issue struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+// String returns the issue with its position, like
+// 'asyncapi.yaml:12:7: /channels/user: unknown field "adress"'.
+func (i Issue) String() string {
+	path := i.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, path, i.Message)
+}
+
+// ValidateFile validates the structure of the AsyncAPI v3 specification file,
+// and resolves its references, including the ones to other local files. The
+// issues are sorted by file and position.
+//
+// NOTE: the references to HTTP(S) URLs are not resolved.
+func ValidateFile(path string) ([]Issue, error) {
+	v := validator{files: make(map[string]*yaml.Node)}
+
+	root, err := v.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if version := field(root, "asyncapi"); version != nil && strings.HasPrefix(version.Value, "2.") {
+		return nil, fmt.Errorf("%w: %q is an AsyncAPI %s specification", ErrUnsupportedVersion, path, version.Value)
+	}
+
+	v.check(path, root, "", specificationRule)
+	v.checkOperations(path, root)
+	v.checkFiles(path)
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		a, b := v.issues[i], v.issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		} else if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return v.issues, nil
+}
+
+type validator struct {
+	files  map[string]*yaml.Node
+	issues []Issue
+}
+
+func (v *validator) addIssue(file string, node *yaml.Node, path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		File:    file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// load returns the root node of the YAML or JSON file, from cache if it was
+// already loaded.
+func (v *validator) load(path string) (*yaml.Node, error) {
+	if root, exists := v.files[path]; exists {
+		return root, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	v.files[path] = root
+
+	return root, nil
+}
+
+// check checks that the node respects the rule.
+//
+//nolint:cyclop // this is a switch on the rule kinds
+func (v *validator) check(file string, node *yaml.Node, path string, r *rule) {
+	node = follow(node)
+
+	if r.reference && field(node, "$ref") != nil {
+		if ref := field(node, "$ref"); ref.Kind != yaml.ScalarNode || ref.Tag != "!!str" {
+			v.addIssue(file, ref, path+"/$ref", "should be a string")
+		}
+		return
+	}
+
+	if node.Tag == "!!null" {
+		if !r.nullable && r.kind != kindAny {
+			v.addIssue(file, node, path, "should not be null")
+		}
+		return
+	}
+
+	switch r.kind {
+	case kindObject:
+		v.checkObject(file, node, path, r)
+	case kindMap:
+		if node.Kind != yaml.MappingNode {
+			v.addIssue(file, node, path, "should be an object")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if !isValidKey(key.Value) {
+				v.addIssue(file, key, path, "key %q should only contain letters, digits, '.', '-' and '_'", key.Value)
+			}
+			v.check(file, value, path+"/"+escape(key.Value), r.items)
+		}
+	case kindArray:
+		if node.Kind != yaml.SequenceNode {
+			v.addIssue(file, node, path, "should be an array")
+			return
+		}
+		for i, item := range node.Content {
+			v.check(file, item, path+"/"+strconv.Itoa(i), r.items)
+		}
+	case kindString:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			v.addIssue(file, node, path, "should be a string")
+		} else if len(r.enum) > 0 && !contains(r.enum, node.Value) {
+			v.addIssue(file, node, path, "should be one of %s, is %q", quoted(r.enum), node.Value)
+		}
+	case kindAny:
+	}
+}
+
+func (v *validator) checkObject(file string, node *yaml.Node, path string, r *rule) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(file, node, path, "should be an object")
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if strings.HasPrefix(key.Value, "x-") {
+			continue
+		}
+
+		fieldRule, exists := r.fields[key.Value]
+		if !exists {
+			unknown := r.unknown
+			if unknown == "" {
+				unknown = "unknown field %q"
+			}
+			v.addIssue(file, key, path, unknown, key.Value)
+			continue
+		}
+		v.check(file, value, path+"/"+escape(key.Value), fieldRule)
+	}
+
+	for _, name := range r.required {
+		if field(node, name) == nil {
+			v.addIssue(file, node, path, "missing required field %q", name)
+		}
+	}
+}
+
+// checkOperations checks that the operations will have different names in the
+// generated code, and that their messages are messages of their channel.
+func (v *validator) checkOperations(file string, root *yaml.Node) {
+	operations := follow(field(root, "operations"))
+	if operations == nil || operations.Kind != yaml.MappingNode {
+		return
+	}
+
+	names := make(map[string]string)
+	for i := 0; i+1 < len(operations.Content); i += 2 {
+		key, op := operations.Content[i], follow(operations.Content[i+1])
+		path := "/operations/" + escape(key.Value)
+
+		// Check the generated name
+		name := key.Value
+		if goName := field(op, "x-go-name"); goName != nil {
+			name = goName.Value
+		}
+		name = template.Namify(name)
+		if other, exists := names[name]; exists && other != key.Value {
+			v.addIssue(file, key, path, "operation %q has the same generated name %q as operation %q",
+				key.Value, name, other)
+		} else {
+			names[name] = key.Value
+		}
+
+		// Check the messages channel
+		channelRef := field(follow(field(op, "channel")), "$ref")
+		messages := follow(field(op, "messages"))
+		if channelRef == nil || !strings.HasPrefix(channelRef.Value, "#/channels/") || messages == nil {
+			continue
+		}
+		for j, msg := range messages.Content {
+			msgRef := field(follow(msg), "$ref")
+			if msgRef != nil && strings.HasPrefix(msgRef.Value, "#/channels/") &&
+				!strings.HasPrefix(msgRef.Value, channelRef.Value+"/messages/") {
+				v.addIssue(file, msgRef, path+"/messages/"+strconv.Itoa(j),
+					"message %q is not a message of the operation channel %q", msgRef.Value, channelRef.Value)
+			}
+		}
+	}
+}
+
+// checkFiles checks the references and the duplicate keys of the file, and of
+// the local files it references.
+func (v *validator) checkFiles(path string) {
+	checked := make(map[string]bool)
+	pending := []string{path}
+
+	for len(pending) > 0 {
+		file := pending[0]
+		pending = pending[1:]
+		if checked[file] {
+			continue
+		}
+		checked[file] = true
+
+		pending = append(pending, v.checkNode(file, v.files[file], "")...)
+	}
+}
+
+// checkNode checks the references and the duplicate keys of the node and of
+// its children, returning the local files that are referenced.
+func (v *validator) checkNode(file string, node *yaml.Node, path string) []string {
+	var files []string
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		keys := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if keys[key.Value] {
+				v.addIssue(file, key, path, "duplicate key %q", key.Value)
+			}
+			keys[key.Value] = true
+
+			if key.Value == "$ref" && value.Kind == yaml.ScalarNode {
+				if f := v.checkReference(file, value, path); f != "" {
+					files = append(files, f)
+				}
+				continue
+			}
+			files = append(files, v.checkNode(file, value, path+"/"+escape(key.Value))...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			files = append(files, v.checkNode(file, item, path+"/"+strconv.Itoa(i))...)
+		}
+	default:
+	}
+
+	return files
+}
+
+// checkReference checks that the reference points to an existing element,
+// returning the local file that is referenced if there is one.
+func (v *validator) checkReference(file string, ref *yaml.Node, path string) string {
+	target, pointer, _ := strings.Cut(ref.Value, "#")
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return ""
+	}
+
+	// Get the referenced file
+	targetFile := file
+	if target != "" {
+		targetFile = target
+		if !filepath.IsAbs(target) {
+			targetFile = filepath.Join(filepath.Dir(file), filepath.FromSlash(target))
+		}
+	}
+
+	root, err := v.load(targetFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		v.addIssue(file, ref, path, "referenced file %q doesn't exist", target)
+		return ""
+	} else if err != nil {
+		v.addIssue(file, ref, path, "referenced file %q can't be read: %s", target, err)
+		return ""
+	}
+
+	if resolve(root, pointer) == nil {
+		v.addIssue(file, ref, path, "reference %q doesn't point to an existing element", ref.Value)
+	}
+
+	if target == "" {
+		return ""
+	}
+	return targetFile
+}
+
+// resolve returns the node pointed by the JSON pointer, or nil if it doesn't
+// exist.
+func resolve(root *yaml.Node, pointer string) *yaml.Node {
+	node := follow(root)
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			node = follow(field(node, part))
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil
+			}
+			node = follow(node.Content[i])
+		default:
+			return nil
+		}
+
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// field returns the value of the field of the mapping node, or nil if there is
+// none.
+func field(node *yaml.Node, name string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// follow returns the node an alias points to, or the node itself.
+func follow(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.AliasNode {
+		return node.Alias
+	}
+	return node
+}
+
+// escape escapes the key to be a part of a JSON pointer.
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func isValidKey(key string) bool {
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '.' && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return key != ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func quoted(values []string) string {
+	q := make([]string, 0, len(values))
+	for _, v := range values {
+		q = append(q, strconv.Quote(v))
+	}
+	return strings.Join(q, ", ")
+}
@@ -0,0 +1,208 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestValidatorSuite(t *testing.T) {
+	suite.Run(t, new(ValidatorSuite))
+}
+
+type ValidatorSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (suite *ValidatorSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+func (suite *ValidatorSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.dir, name)
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// validate validates the specification and returns its issues, without their
+// file to keep the expectations short.
+func (suite *ValidatorSuite) validate(content string) []string {
+	issues, err := ValidateFile(suite.writeFile("asyncapi.yaml", content))
+	suite.Require().NoError(err)
+
+	res := make([]string, 0, len(issues))
+	for _, i := range issues {
+		i.File = "asyncapi.yaml"
+		res = append(res, i.String())
+	}
+	return res
+}
+
+const validSpecification = `asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+channels:
+  user:
+    address: user.{id}
+    parameters:
+      id:
+        description: User id
+    messages:
+      signedUp:
+        $ref: '#/components/messages/signedUp'
+operations:
+  receiveSignedUp:
+    action: receive
+    channel:
+      $ref: '#/channels/user'
+    messages:
+      - $ref: '#/channels/user/messages/signedUp'
+components:
+  messages:
+    signedUp:
+      x-go-name: SignedUp
+      payload:
+        type: object
+`
+
+func (suite *ValidatorSuite) TestValid() {
+	suite.Require().Empty(suite.validate(validSpecification))
+}
+
+func (suite *ValidatorSuite) TestStructure() {
+	suite.Require().Equal([]string{
+		`asyncapi.yaml:1:11: /asyncapi: should be one of "3.0.0", is "3.1.0"`,
+		`asyncapi.yaml:3:3: /info: missing required field "version"`,
+		`asyncapi.yaml:5:3: /channels: key "user/signup" should only contain letters, digits, '.', '-' and '_'`,
+		`asyncapi.yaml:6:5: /channels/user~1signup: unknown field "adress"`,
+		`asyncapi.yaml:8:7: /channels/user~1signup/messages: should be an object`,
+		`asyncapi.yaml:11:5: /operations/sendSignUp: missing required field "channel"`,
+		`asyncapi.yaml:11:13: /operations/sendSignUp/action: should be one of "send", "receive", is "publish"`,
+	}, suite.validate(`asyncapi: 3.1.0
+info:
+  title: Sample
+channels:
+  user/signup:
+    adress: user.signup
+    messages:
+      - payload: {}
+operations:
+  sendSignUp:
+    action: publish
+`))
+}
+
+func (suite *ValidatorSuite) TestBindings() {
+	suite.Require().Equal([]string{
+		`asyncapi.yaml:8:7: /channels/user/bindings: unsupported binding "rabbitmq"`,
+	}, suite.validate(`asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+channels:
+  user:
+    bindings:
+      rabbitmq: {}
+      amqp:
+        is: routingKey
+`))
+}
+
+func (suite *ValidatorSuite) TestReferences() {
+	suite.writeFile("schemas.yaml", `components:
+  schemas:
+    user:
+      type: object
+      properties:
+        address:
+          $ref: '#/components/schemas/address'
+`)
+
+	issues, err := ValidateFile(suite.writeFile("asyncapi.yaml", `asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+components:
+  schemas:
+    local:
+      $ref: '#/components/schemas/missing'
+    distant:
+      $ref: './schemas.yaml#/components/schemas/user'
+    missingFile:
+      $ref: './other.yaml#/components/schemas/user'
+    remote:
+      $ref: 'https://example.com/schemas.yaml#/components/schemas/user'
+`))
+	suite.Require().NoError(err)
+
+	// Issues are sorted by file, and the references of the referenced files
+	// are checked too
+	suite.Require().Len(issues, 3)
+	suite.Require().Equal(`reference "#/components/schemas/missing" doesn't point to an existing element`, issues[0].Message)
+	suite.Require().Equal(8, issues[0].Line)
+	suite.Require().Equal(`referenced file "./other.yaml" doesn't exist`, issues[1].Message)
+	suite.Require().Equal(12, issues[1].Line)
+	suite.Require().Equal(filepath.Join(suite.dir, "schemas.yaml"), issues[2].File)
+	suite.Require().Equal("/components/schemas/user/properties/address", issues[2].Path)
+}
+
+func (suite *ValidatorSuite) TestDuplicates() {
+	suite.Require().Equal([]string{
+		`asyncapi.yaml:8:3: /operations: duplicate key "receive"`,
+		`asyncapi.yaml:12:3: /operations/receive-user: operation "receive-user" has the same generated name "ReceiveUser" as operation "receiveUser"`,
+	}, suite.validate(`asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+operations:
+  receive:
+    $ref: '#/components/operations/receive'
+  receive:
+    $ref: '#/components/operations/receive'
+  receiveUser:
+    $ref: '#/components/operations/receive'
+  receive-user:
+    $ref: '#/components/operations/receive'
+components:
+  operations:
+    receive:
+      action: receive
+      channel:
+        $ref: '#/components/channels/user'
+  channels:
+    user: {}
+`))
+}
+
+func (suite *ValidatorSuite) TestOperationMessages() {
+	suite.Require().Equal([]string{
+		`asyncapi.yaml:18:15: /operations/receiveUser/messages/0: message "#/channels/order/messages/placed" is not a message of the operation channel "#/channels/user"`,
+	}, suite.validate(`asyncapi: 3.0.0
+info:
+  title: Sample
+  version: 1.0.0
+channels:
+  user:
+    messages:
+      signedUp: {}
+  order:
+    messages:
+      placed: {}
+operations:
+  receiveUser:
+    action: receive
+    channel:
+      $ref: '#/channels/user'
+    messages:
+      - $ref: '#/channels/order/messages/placed'
+`))
+}
+
+func (suite *ValidatorSuite) TestVersion2() {
+	_, err := ValidateFile(suite.writeFile("asyncapi.yaml", "asyncapi: 2.6.0\n"))
+	suite.Require().ErrorIs(err, ErrUnsupportedVersion)
+}
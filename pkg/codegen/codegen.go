@@ -2,18 +2,23 @@ package codegen
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 	"runtime/debug"
+	"sort"
+	"strings"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi"
 	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/parser"
 	asyncapiv2 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v2"
 	asyncapiv3 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
 	generatorv2 "github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v2"
 	templatesv2 "github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v2/templates"
 	generatorv3 "github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v3"
 	templatesv3 "github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v3/templates"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/options"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
 	"github.com/lerenn/asyncapi-codegen/pkg/utils/template"
 	"golang.org/x/tools/imports"
 )
@@ -23,20 +28,61 @@ type CodeGen struct {
 	specification asyncapi.Specification
 	modulePath    string
 	moduleVersion string
+	files         []string
 }
 
 // FromFile returns a code generator from a specification file path.
 func FromFile(path string, dependencies ...string) (CodeGen, error) {
+	return FromFileWithParams(FromFileParams{
+		Path:         path,
+		Dependencies: dependencies,
+	})
+}
+
+// FromFileParams are the parameters to get a code generator from a specification file.
+type FromFileParams struct {
+	// Path is the path of the specification file.
+	Path string
+	// Dependencies are the paths of the files referenced by the specification
+	// that are not resolved relatively to it.
+	Dependencies []string
+	// Documents are the paths of the specifications merged into the
+	// specification, in order to generate them together (AsyncAPI v3 only).
+	Documents []string
+	// AllowRemoteReferences allows the references to HTTP(S) URLs.
+	AllowRemoteReferences bool
+	// ConvertToV3 converts the AsyncAPI v2 specifications to AsyncAPI v3, in
+	// order to generate them with the AsyncAPI v3 generator.
+	ConvertToV3 bool
+}
+
+// FromFileWithParams returns a code generator from a specification file, with
+// the files and URLs it references as dependencies.
+func FromFileWithParams(params FromFileParams) (CodeGen, error) {
 	// Get specification from file
 	spec, err := parser.FromFile(parser.FromFileParams{
-		Path: path,
+		Path:        params.Path,
+		ConvertToV3: params.ConvertToV3,
 	})
 	if err != nil {
 		return CodeGen{}, err
 	}
 
+	// Get referenced files
+	resolver := parser.ReferencesResolver{AllowRemote: params.AllowRemoteReferences}
+	if err := resolver.Resolve(spec, params.Path); err != nil {
+		return CodeGen{}, err
+	}
+
+	// Merge documents
+	for _, path := range params.Documents {
+		if err := mergeDocument(spec, path, params.ConvertToV3, &resolver); err != nil {
+			return CodeGen{}, err
+		}
+	}
+
 	// Get dependencies
-	for _, path := range dependencies {
+	for _, path := range params.Dependencies {
 		dep, err := parser.FromFile(parser.FromFileParams{
 			Path:         path,
 			MajorVersion: spec.MajorVersion(),
@@ -50,7 +96,58 @@ func FromFile(path string, dependencies ...string) (CodeGen, error) {
 		}
 	}
 
-	return New(spec)
+	cg, err := New(spec)
+	if err != nil {
+		return CodeGen{}, err
+	}
+	cg.files = append(resolver.Files(), params.Dependencies...)
+	sort.Strings(cg.files)
+
+	return cg, nil
+}
+
+// Files returns the local files of the specification, that are the
+// specification file, its dependencies, and the files they reference. It is
+// empty if the code generator hasn't been created from a file.
+func (cg CodeGen) Files() []string {
+	return cg.files
+}
+
+// mergeDocument merges the specification document, with the files and URLs
+// it references, into the specification.
+func mergeDocument(
+	spec asyncapi.Specification,
+	path string,
+	convertToV3 bool,
+	resolver *parser.ReferencesResolver,
+) error {
+	specV3, ok := spec.(*asyncapiv3.Specification)
+	if !ok {
+		return fmt.Errorf("merging documents is not supported with major version %d", spec.MajorVersion())
+	}
+
+	doc, err := parser.FromFile(parser.FromFileParams{
+		Path:        path,
+		ConvertToV3: convertToV3,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := resolver.Resolve(doc, path); err != nil {
+		return err
+	}
+
+	docV3, ok := doc.(*asyncapiv3.Specification)
+	if !ok {
+		return fmt.Errorf("%w: %q is not an AsyncAPI v3 specification", extensions.ErrAsyncAPI, path)
+	}
+
+	if err := specV3.Merge(docV3); err != nil {
+		return fmt.Errorf("merging %q: %w", path, err)
+	}
+
+	return nil
 }
 
 // New creates a new code generation structure that can be used to generate code.
@@ -89,7 +186,15 @@ func (cg CodeGen) Generate(opt options.Options) error {
 	if err := template.SetNamifyFn(opt.NamingScheme); err != nil {
 		return err
 	}
+	template.SetInitialisms(opt.Initialisms)
+	generators.SetTemplatesOverridesDir(opt.TemplateDir)
+	asyncapiv3.SetOperationNameAffixes(opt.OperationPrefix, opt.OperationSuffix)
 
+	if opt.StringFormats != nil {
+		if err := template.SetGeneratedStringFormats(opt.StringFormats); err != nil {
+			return err
+		}
+	}
 	if opt.IgnoreStringFormat {
 		template.DisableDateOrTimeGeneration()
 	}
@@ -97,12 +202,41 @@ func (cg CodeGen) Generate(opt options.Options) error {
 		templatesv2.ForcePointerOnFields()
 		templatesv3.ForcePointerOnFields()
 	}
+	if opt.OptionalFields != "" {
+		if err := templatesv3.SetOptionalFieldsStrategy(opt.OptionalFields); err != nil {
+			return err
+		}
+	}
 
 	// Process specification
 	if err := cg.specification.Process(); err != nil {
 		return err
 	}
 
+	// Generate the documentation and the code
+	out := &output{check: opt.Check}
+	if err := cg.generateDocs(opt, out); err != nil {
+		return err
+	}
+	if err := cg.generateCode(opt, out); err != nil {
+		return err
+	}
+
+	return out.err()
+}
+
+// generateCode generates the code in the output file, or in the files of the
+// output directory if the code is split.
+func (cg CodeGen) generateCode(opt options.Options, out *output) error {
+	if !opt.Generate.HasCode() {
+		return nil
+	}
+
+	// Generate the files in the output directory if the code is split
+	if opt.Split {
+		return cg.generateFiles(opt, out)
+	}
+
 	// Generate content
 	content, err := cg.generateContent(opt)
 	if err != nil {
@@ -110,29 +244,133 @@ func (cg CodeGen) Generate(opt options.Options) error {
 	}
 
 	// Format content if not disabled
-	var fileContent []byte
-	if !opt.DisableFormatting {
-		fileContent, err = imports.Process("", []byte(content), &imports.Options{
-			TabWidth:  8,
-			TabIndent: true,
-			Comments:  true,
-			Fragment:  true,
-		})
+	fileContent, err := formatContent(content, opt)
+	if err != nil {
+		return err
+	}
+
+	// Write to file
+	return out.write(opt.OutputPath, fileContent)
+}
+
+// generateFiles generates the code in several files, written in the output
+// directory in the order of their names.
+func (cg CodeGen) generateFiles(opt options.Options, out *output) error {
+	if version := cg.specification.MajorVersion(); version != 3 {
+		return fmt.Errorf("split generation is not supported with major version %d", version)
+	}
+
+	spec, err := asyncapiv3.FromUnknownVersion(cg.specification)
+	if err != nil {
+		return err
+	}
+
+	files, err := generatorv3.Generator{
+		Specification: *spec,
+		Options:       opt,
+		ModulePath:    cg.modulePath,
+		ModuleVersion: cg.moduleVersion,
+	}.GenerateFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range utils.MapKeysToSortedList(files) {
+		fileContent, err := formatContent(files[name], opt)
 		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if err := out.write(filepath.Join(opt.OutputPath, name), fileContent); err != nil {
 			return err
 		}
-	} else {
-		fileContent = []byte(content)
 	}
 
-	// Write to file
-	return os.WriteFile(opt.OutputPath, fileContent, 0644)
+	return nil
+}
+
+// generateDocs generates the documentation of the specification in the
+// requested formats.
+func (cg CodeGen) generateDocs(opt options.Options, out *output) error {
+	formats := make(map[string]string)
+	if opt.Generate.Markdown {
+		formats[generatorv3.DocsFormatMarkdown] = ".md"
+	}
+	if opt.Generate.HTML {
+		formats[generatorv3.DocsFormatHTML] = ".html"
+	}
+	if len(formats) == 0 {
+		return nil
+	}
+
+	if version := cg.specification.MajorVersion(); version != 3 {
+		return fmt.Errorf("documentation generation is not supported with major version %d", version)
+	}
+
+	spec, err := asyncapiv3.FromUnknownVersion(cg.specification)
+	if err != nil {
+		return err
+	}
+
+	base := docsOutputPath(opt)
+
+	for _, format := range utils.MapKeysToSortedList(formats) {
+		content, err := generatorv3.DocsGenerator{
+			Specification: *spec,
+			Format:        format,
+		}.Generate()
+		if err != nil {
+			return fmt.Errorf("%s documentation: %w", format, err)
+		}
+
+		if err := out.write(base+formats[format], []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// docsOutputPath returns the path of the documentation files, without their
+// extension.
+func docsOutputPath(opt options.Options) string {
+	switch {
+	case opt.DocsOutputPath != "":
+		return opt.DocsOutputPath
+	case opt.Split:
+		return filepath.Join(opt.OutputPath, "asyncapi")
+	default:
+		return strings.TrimSuffix(opt.OutputPath, filepath.Ext(opt.OutputPath))
+	}
+}
+
+// formatContent formats the generated code with its imports, unless the
+// formatting is disabled.
+func formatContent(content string, opt options.Options) ([]byte, error) {
+	if opt.DisableFormatting {
+		return []byte(content), nil
+	}
+
+	return imports.Process("", []byte(content), &imports.Options{
+		TabWidth:  8,
+		TabIndent: true,
+		Comments:  true,
+		Fragment:  true,
+	})
 }
 
 func (cg CodeGen) generateContent(opt options.Options) (string, error) {
 	version := cg.specification.MajorVersion()
 	switch version {
 	case 2:
+		if opt.Generate.Mocks {
+			return "", fmt.Errorf("mocks generation is not supported with major version %d", version)
+		} else if opt.Generate.Fixtures {
+			return "", fmt.Errorf("fixtures generation is not supported with major version %d", version)
+		} else if opt.Generate.Fuzz {
+			return "", fmt.Errorf("fuzz targets generation is not supported with major version %d", version)
+		}
+
 		spec, err := asyncapiv2.FromUnknownVersion(cg.specification)
 		if err != nil {
 			return "", err
@@ -0,0 +1,69 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/options"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCodeGenSuite(t *testing.T) {
+	suite.Run(t, new(CodeGenSuite))
+}
+
+type CodeGenSuite struct {
+	suite.Suite
+}
+
+func (suite *CodeGenSuite) generate(spec string, opt options.Options) error {
+	cg, err := FromFile(spec)
+	suite.Require().NoError(err)
+
+	opt.PackageName, opt.ConvertKeys, opt.NamingScheme = "test", "none", "none"
+	opt.OperationSuffix, opt.OptionalFields = "Operation", "pointer"
+	opt.Generate = options.GeneratorOptions{Application: true, User: true, Types: true}
+	return cg.Generate(opt)
+}
+
+func (suite *CodeGenSuite) TestDeterministic() {
+	// The merged schemas are in maps, that are not ordered
+	spec := "../../test/v3/features/allof/asyncapi.yaml"
+
+	first := filepath.Join(suite.T().TempDir(), "first.gen.go")
+	suite.Require().NoError(suite.generate(spec, options.Options{OutputPath: first}))
+	expected, err := os.ReadFile(first)
+	suite.Require().NoError(err)
+
+	for i := 0; i < 10; i++ {
+		path := filepath.Join(suite.T().TempDir(), "asyncapi.gen.go")
+		suite.Require().NoError(suite.generate(spec, options.Options{OutputPath: path}))
+
+		content, err := os.ReadFile(path)
+		suite.Require().NoError(err)
+		suite.Require().Equal(string(expected), string(content))
+	}
+}
+
+func (suite *CodeGenSuite) TestCheck() {
+	spec := "../../test/v3/features/requestreply/asyncapi.yaml"
+	path := filepath.Join(suite.T().TempDir(), "asyncapi.gen.go")
+
+	// Missing files are outdated, and are not written
+	err := suite.generate(spec, options.Options{OutputPath: path, Check: true})
+	suite.Require().ErrorIs(err, ErrOutdatedFiles)
+	suite.Require().ErrorContains(err, path)
+	suite.Require().NoFileExists(path)
+
+	// Up to date files are not
+	suite.Require().NoError(suite.generate(spec, options.Options{OutputPath: path}))
+	suite.Require().NoError(suite.generate(spec, options.Options{OutputPath: path, Check: true}))
+
+	// Nor modified files
+	suite.Require().NoError(os.WriteFile(path, []byte("package test\n"), 0644))
+	suite.Require().ErrorIs(suite.generate(spec, options.Options{OutputPath: path, Check: true}), ErrOutdatedFiles)
+	content, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	suite.Require().Equal("package test\n", string(content))
+}
@@ -0,0 +1,54 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templatesOverridesDir is the directory of the templates used in place of the
+// default ones, if set.
+var templatesOverridesDir string
+
+// SetTemplatesOverridesDir sets the directory of the templates used in place
+// of the default ones, with the same path relatively to the directory of the
+// default templates (like 'controller.tmpl' or 'marshaling/time.tmpl').
+func SetTemplatesOverridesDir(dir string) {
+	templatesOverridesDir = dir
+}
+
+// OverrideTemplates replaces the templates loaded from the paths by the ones
+// with the same path in the overrides directory, if there are some. The paths
+// are relative to the default templates directory.
+func OverrideTemplates(tmplt *template.Template, templatesDir string, paths ...string) (*template.Template, error) {
+	if templatesOverridesDir == "" {
+		return tmplt, nil
+	}
+
+	for _, p := range paths {
+		rel := strings.TrimPrefix(p, templatesDir+"/")
+		content, err := os.ReadFile(filepath.Join(templatesOverridesDir, filepath.FromSlash(rel)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Replace the template with the same name, including the first one
+		// that is executed
+		t := tmplt.Lookup(path.Base(p))
+		if t == nil {
+			t = tmplt.New(path.Base(p))
+		}
+		if _, err := t.Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("template override %q: %w", rel, err)
+		}
+	}
+
+	return tmplt, nil
+}
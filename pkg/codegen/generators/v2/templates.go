@@ -2,10 +2,12 @@ package generatorv2
 
 import (
 	"embed"
+	"io/fs"
 	"maps"
 	"path"
 	"text/template"
 
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v2/templates"
 	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
 )
@@ -32,12 +34,27 @@ var (
 	files embed.FS
 )
 
+// Templates returns the default templates, that can be copied in order to be
+// overridden.
+func Templates() fs.FS {
+	sub, err := fs.Sub(files, templatesDir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
 func loadTemplate(paths ...string) (*template.Template, error) {
 	funcs := templateutil.HelpersFunctions()
 	maps.Copy(funcs, templates.HelpersFunctions())
 
-	return template.
+	tmplt, err := template.
 		New(path.Base(paths[0])).
 		Funcs(funcs).
 		ParseFS(files, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return generators.OverrideTemplates(tmplt, templatesDir, paths...)
 }
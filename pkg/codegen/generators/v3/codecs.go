@@ -0,0 +1,55 @@
+package generatorv3
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+// ErrInvalidCodec is raised when a codec of the options is invalid.
+var ErrInvalidCodec = fmt.Errorf("%w: invalid codec", extensions.ErrAsyncAPI)
+
+// Codec is a codec registered by the generated code for a content type.
+type Codec struct {
+	ContentType string
+	// Alias is the name of the package import in the generated code
+	Alias string
+	// Path is the import path of the package
+	Path string
+	// Type is the name of the codec type in its package
+	Type string
+}
+
+// Import returns the import statement of the codec package.
+func (c Codec) Import() string {
+	return fmt.Sprintf("%s %q", c.Alias, c.Path)
+}
+
+// NewCodecs returns the codecs registered for the content types, set with the
+// import path of their package with their type name (like 'github.com/acme/codecs.YAML').
+func NewCodecs(codecs map[string]string) ([]Codec, error) {
+	res := make([]Codec, 0, len(codecs))
+	aliases := make(map[string]string)
+	for _, contentType := range utils.MapKeysToSortedList(codecs) {
+		name := codecs[contentType]
+		i := strings.LastIndex(name, ".")
+		if i <= strings.LastIndex(name, "/") || i == len(name)-1 {
+			return nil, fmt.Errorf("%w: %q for %q, it should be like 'github.com/acme/codecs.YAML'",
+				ErrInvalidCodec, name, contentType)
+		}
+
+		// Use the same import for codecs of the same package
+		path := name[:i]
+		alias, exists := aliases[path]
+		if !exists {
+			alias = fmt.Sprintf("codec%d", len(aliases))
+			aliases[path] = alias
+		}
+
+		res = append(res, Codec{ContentType: contentType, Alias: alias, Path: path, Type: name[i+1:]})
+	}
+
+	return res, nil
+}
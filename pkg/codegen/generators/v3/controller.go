@@ -77,6 +77,8 @@ func (asg ControllerGenerator) Generate() (string, error) {
 	tmplt, err := loadTemplate(
 		controllerTemplatePath,
 		schemaDefinitionTemplatePath,
+		unionDefinitionTemplatePath,
+		enumDefinitionTemplatePath,
 		schemaNameTemplatePath,
 		messageTemplatePath,
 	)
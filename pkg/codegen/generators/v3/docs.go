@@ -0,0 +1,365 @@
+package generatorv3
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+// Formats of the generated documentation.
+const (
+	DocsFormatMarkdown = "markdown"
+	DocsFormatHTML     = "html"
+)
+
+// DocsGenerator is a generator for the human-readable documentation of the
+// servers, channels, operations and messages of an asyncapi specification.
+type DocsGenerator struct {
+	Specification asyncapi.Specification
+	// Format is the format of the documentation: markdown or html
+	Format string
+}
+
+// Documentation is the documentation of a specification, as given to the
+// documentation templates. The elements have the names of the generated code.
+type Documentation struct {
+	Title       string
+	Version     string
+	Description string
+
+	Servers    []DocServer
+	Channels   []DocChannel
+	Operations []DocOperation
+	Messages   []DocMessage
+	Schemas    []DocSchema
+}
+
+// DocServer is the documentation of a server.
+type DocServer struct {
+	Name        string
+	URL         string
+	Protocol    string
+	Description string
+}
+
+// DocChannel is the documentation of a channel.
+type DocChannel struct {
+	Name        string
+	Address     string
+	Description string
+	Parameters  []DocProperty
+	Messages    []string
+}
+
+// DocOperation is the documentation of an operation.
+type DocOperation struct {
+	Name        string
+	Action      string
+	Channel     string
+	Description string
+	Messages    []string
+
+	// ReplyChannel and ReplyMessages are set if the operation has a reply
+	ReplyChannel  string
+	ReplyMessages []string
+}
+
+// DocMessage is the documentation of a message.
+type DocMessage struct {
+	Name          string
+	Title         string
+	Description   string
+	ContentType   string
+	CorrelationID string
+	Headers       []DocProperty
+	Payload       DocProperty
+	// PayloadProperties are the properties of the payload, if it is an object
+	PayloadProperties []DocProperty
+}
+
+// DocSchema is the documentation of a schema of the components.
+type DocSchema struct {
+	Name        string
+	Description string
+	Type        DocProperty
+	Properties  []DocProperty
+}
+
+// DocProperty is the documentation of a property, a parameter or a value.
+type DocProperty struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	// Schema is the name of the documented schema of the type, if any
+	Schema string
+}
+
+// maxDocDepth is the maximum depth of the documented inline properties.
+const maxDocDepth = 5
+
+// Generate generates the documentation.
+func (dg DocsGenerator) Generate() (string, error) {
+	var path string
+	switch dg.Format {
+	case DocsFormatMarkdown:
+		path = docsMarkdownTemplatePath
+	case DocsFormatHTML:
+		path = docsHTMLTemplatePath
+	default:
+		return "", fmt.Errorf("unsupported documentation format %q", dg.Format)
+	}
+
+	tmplt, err := loadTemplateWithFunctions(template.FuncMap{
+		"docsAnchor":   docsAnchor,
+		"markdownCell": markdownCell,
+	}, path)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmplt.Execute(buf, dg.Documentation()); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Documentation returns the documentation of the specification.
+func (dg DocsGenerator) Documentation() Documentation {
+	spec := dg.Specification
+	doc := Documentation{
+		Title:       spec.Info.Title,
+		Version:     spec.Info.Version,
+		Description: spec.Info.Description,
+	}
+
+	for _, name := range utils.MapKeysToSortedList(spec.Servers) {
+		srv := spec.Servers[name].Follow()
+		doc.Servers = append(doc.Servers, DocServer{
+			Name:        name,
+			URL:         srv.Protocol + "://" + srv.Host + srv.PathName,
+			Protocol:    srv.Protocol,
+			Description: srv.Description,
+		})
+	}
+
+	messages := make(map[string]*asyncapi.Message)
+	for _, key := range utils.MapKeysToSortedList(spec.Channels) {
+		ch := spec.Channels[key].Follow()
+		doc.Channels = append(doc.Channels, DocChannel{
+			Name:        ch.Name,
+			Address:     ch.Address,
+			Description: ch.Description,
+			Parameters:  docParameters(ch.Parameters),
+			Messages:    docMessagesNames(utils.MapToList(ch.Messages), messages),
+		})
+	}
+
+	for _, key := range utils.MapKeysToSortedList(spec.Operations) {
+		doc.Operations = append(doc.Operations, docOperation(spec.Operations[key].Follow(), messages))
+	}
+
+	for _, name := range utils.MapKeysToSortedList(messages) {
+		doc.Messages = append(doc.Messages, docMessage(messages[name]))
+	}
+
+	for _, key := range utils.MapKeysToSortedList(spec.Components.Schemas) {
+		s := spec.Components.Schemas[key].Follow()
+		doc.Schemas = append(doc.Schemas, DocSchema{
+			Name:        s.Name,
+			Description: s.Description,
+			Type:        docProperty("", s, false),
+			Properties:  docProperties("", s, 0),
+		})
+	}
+	sort.Slice(doc.Channels, func(i, j int) bool { return doc.Channels[i].Name < doc.Channels[j].Name })
+	sort.Slice(doc.Operations, func(i, j int) bool { return doc.Operations[i].Name < doc.Operations[j].Name })
+	sort.Slice(doc.Schemas, func(i, j int) bool { return doc.Schemas[i].Name < doc.Schemas[j].Name })
+
+	return doc
+}
+
+// docsAnchor returns the anchor of the documentation of an element, as
+// generated from its title.
+func docsAnchor(name string) string {
+	return strings.ToLower(name)
+}
+
+// markdownCell returns the text on a single line, to be put in a markdown table.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+func docOperation(op *asyncapi.Operation, messages map[string]*asyncapi.Message) DocOperation {
+	ch := op.Channel.Follow()
+	opMsgs := op.Messages
+	if len(opMsgs) == 0 {
+		opMsgs = utils.MapToList(ch.Messages)
+	}
+
+	doc := DocOperation{
+		Name:        op.Name,
+		Action:      string(op.Action),
+		Channel:     ch.Name,
+		Description: op.Description,
+		Messages:    docMessagesNames(opMsgs, messages),
+	}
+
+	if op.Reply != nil && op.Reply.Channel != nil {
+		replyCh := op.Reply.Channel.Follow()
+		doc.ReplyChannel = replyCh.Name
+		doc.ReplyMessages = docMessagesNames(utils.MapToList(replyCh.Messages), messages)
+	}
+
+	return doc
+}
+
+// docMessagesNames returns the sorted names of the messages, and adds them to
+// the documented messages.
+func docMessagesNames(msgs []*asyncapi.Message, messages map[string]*asyncapi.Message) []string {
+	names := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		msg = msg.Follow()
+		if !utils.IsInSlice(names, msg.Name) {
+			names = append(names, msg.Name)
+		}
+		messages[msg.Name] = msg
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func docMessage(msg *asyncapi.Message) DocMessage {
+	doc := DocMessage{
+		Name:        msg.Name,
+		Title:       msg.Title,
+		Description: msg.Description,
+		ContentType: msg.ContentType,
+	}
+
+	if msg.CorrelationID != nil {
+		doc.CorrelationID = msg.CorrelationID.Follow().Location
+	}
+
+	if msg.Headers != nil {
+		doc.Headers = docProperties("", msg.Headers.Follow(), 0)
+	}
+
+	switch {
+	case msg.ExtProtobuf != nil:
+		doc.Payload = DocProperty{Type: "protobuf " + msg.ExtProtobuf.Type}
+	case msg.Payload != nil:
+		doc.Payload = docProperty("", msg.Payload, false)
+		if msg.Payload.ReferenceTo == nil {
+			doc.PayloadProperties = docProperties("", msg.Payload, 0)
+		}
+	}
+
+	return doc
+}
+
+func docParameters(params map[string]*asyncapi.Parameter) []DocProperty {
+	docs := make([]DocProperty, 0, len(params))
+	for _, name := range utils.MapKeysToSortedList(params) {
+		p := params[name].Follow()
+
+		typ := "string"
+		if len(p.Enum) > 0 {
+			typ += " (one of " + strings.Join(p.Enum, ", ") + ")"
+		}
+
+		docs = append(docs, DocProperty{
+			Name:        name,
+			Type:        typ,
+			Description: p.Description,
+			Required:    true,
+		})
+	}
+
+	return docs
+}
+
+// docProperties returns the properties of an inline object schema, with the
+// properties of their inline object schemas prefixed with their name.
+func docProperties(prefix string, s *asyncapi.Schema, depth int) []DocProperty {
+	if s.ReferenceTo != nil || depth > maxDocDepth {
+		return nil
+	}
+
+	docs := make([]DocProperty, 0, len(s.Properties))
+	for _, name := range utils.MapKeysToSortedList(s.Properties) {
+		p := s.Properties[name]
+		docs = append(docs, docProperty(prefix+name, p, s.IsFieldRequired(name)))
+
+		if p.ReferenceTo == nil && p.Type == asyncapi.SchemaTypeIsObject.String() {
+			docs = append(docs, docProperties(prefix+name+".", p, depth+1)...)
+		}
+	}
+
+	return docs
+}
+
+func docProperty(name string, s *asyncapi.Schema, required bool) DocProperty {
+	description := s.Description
+	if description == "" {
+		description = s.Follow().Description
+	}
+
+	typ, schema := docType(s)
+	return DocProperty{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Required:    required,
+		Schema:      schema,
+	}
+}
+
+// docType returns the description of the type of the schema, and the name of
+// its referenced schema if any.
+func docType(s *asyncapi.Schema) (string, string) {
+	if s.ReferenceTo != nil {
+		return s.ReferenceTo.Name, s.ReferenceTo.Name
+	}
+
+	switch {
+	case s.IsFalse():
+		return "nothing", ""
+	case s.Type == asyncapi.SchemaTypeIsArray.String() && s.Items != nil:
+		typ, schema := docType(s.Items)
+		return "array of " + typ, schema
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		variants := make([]string, 0, len(s.OneOf)+len(s.AnyOf))
+		for _, v := range append(s.OneOf, s.AnyOf...) {
+			typ, _ := docType(v)
+			variants = append(variants, typ)
+		}
+		return "one of " + strings.Join(variants, ", "), ""
+	}
+
+	typ := s.Type
+	if typ == "" {
+		typ = "any"
+	}
+	if s.Format != "" {
+		typ += " (" + s.Format + ")"
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		typ += " (one of " + strings.Join(values, ", ") + ")"
+	}
+
+	return typ, ""
+}
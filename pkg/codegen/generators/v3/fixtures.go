@@ -0,0 +1,203 @@
+package generatorv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v3/templates"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+// FixturesGenerator is a code generator for the typed messages built from the
+// examples of an asyncapi specification, with the helpers to send them.
+type FixturesGenerator struct {
+	Specification asyncapi.Specification
+	// Sides are the sides of the controllers with the helpers to send the examples
+	Sides []generators.Side
+}
+
+// Fixture is the examples of a message of the specification.
+type Fixture struct {
+	// Message is the name of the message type, like 'UserSignedUpMessage'
+	Message string
+	// HasHeaders states if the message has headers, in which the examples
+	// headers are decoded
+	HasHeaders bool
+	// Examples are the examples of the message, in the order of the specification
+	Examples []FixtureExample
+}
+
+// FixtureExample is an example of a message, with its headers and payload as JSON.
+type FixtureExample struct {
+	// Name is the name of the example in the specification, or its position
+	// in the examples of the message if it has none
+	Name    string
+	Summary string
+	// Headers and Payload are empty if they are not in the example
+	Headers string
+	Payload string
+}
+
+// FixtureSender is a helper sending the examples of a message on an operation.
+type FixtureSender struct {
+	// Prefix is the prefix of the controller sending the examples, like 'User'
+	Prefix    string
+	Operation *asyncapi.Operation
+	Fixture   Fixture
+}
+
+// Generate generates the fixtures code.
+func (fg FixturesGenerator) Generate() (string, error) {
+	fixtures, err := fg.Fixtures()
+	if err != nil {
+		return "", err
+	}
+
+	tmplt, err := loadTemplate(fixturesTemplatePath)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmplt.Execute(buf, struct {
+		Fixtures []Fixture
+		Senders  []FixtureSender
+	}{fixtures, fg.Senders(fixtures)}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Fixtures returns the fixtures of the messages with examples, in the order of
+// the messages names. The protobuf messages are skipped, as their examples are
+// not in their format.
+func (fg FixturesGenerator) Fixtures() ([]Fixture, error) {
+	messages := make(map[string]*asyncapi.Message)
+	for _, ch := range fg.Specification.Channels {
+		for _, msg := range ch.Follow().Messages {
+			msg = msg.Follow()
+			if len(msg.Examples) > 0 && msg.ExtProtobuf == nil {
+				messages[templateutil.Namify(msg.Name)] = msg
+			}
+		}
+	}
+
+	fixtures := make([]Fixture, 0, len(messages))
+	for _, name := range utils.MapKeysToSortedList(messages) {
+		f, err := fixture(name, messages[name])
+		if err != nil {
+			return nil, fmt.Errorf("examples of message %q: %w", name, err)
+		}
+		fixtures = append(fixtures, f)
+	}
+
+	return fixtures, nil
+}
+
+func fixture(name string, msg *asyncapi.Message) (Fixture, error) {
+	f := Fixture{
+		Message:    name,
+		HasHeaders: msg.Headers != nil,
+		Examples:   make([]FixtureExample, 0, len(msg.Examples)),
+	}
+
+	for i, e := range msg.Examples {
+		e = e.Follow()
+
+		example := FixtureExample{
+			Name:    e.ExampleName,
+			Summary: e.Summary,
+		}
+		if example.Name == "" {
+			example.Name = strconv.Itoa(i)
+		}
+		if slices.ContainsFunc(f.Examples, func(fe FixtureExample) bool { return fe.Name == example.Name }) {
+			return Fixture{}, fmt.Errorf("%w: duplicate example %q", extensions.ErrAsyncAPI, example.Name)
+		}
+
+		var err error
+		if e.Headers != nil && f.HasHeaders {
+			if example.Headers, err = exampleJSON(e.Headers); err != nil {
+				return Fixture{}, err
+			}
+		}
+		if e.Payload != nil {
+			if example.Payload, err = exampleJSON(e.Payload); err != nil {
+				return Fixture{}, err
+			}
+		}
+
+		f.Examples = append(f.Examples, example)
+	}
+
+	return f, nil
+}
+
+// exampleJSON returns the JSON of an example value, with its keys converted as
+// the keys of the generated types.
+func exampleJSON(value any) (string, error) {
+	b, err := json.Marshal(convertExampleKeys(value))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func convertExampleKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for k, value := range v {
+			converted[templateutil.ConvertKey(k)] = convertExampleKeys(value)
+		}
+		return converted
+	case []any:
+		converted := make([]any, 0, len(v))
+		for _, value := range v {
+			converted = append(converted, convertExampleKeys(value))
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// Senders returns the helpers sending the examples of the messages on the
+// operations of the controllers, in the order of the operations names.
+func (fg FixturesGenerator) Senders(fixtures []Fixture) []FixtureSender {
+	byMessage := make(map[string]Fixture, len(fixtures))
+	for _, f := range fixtures {
+		byMessage[f.Message] = f
+	}
+
+	senders := make([]FixtureSender, 0)
+	for _, side := range fg.Sides {
+		prefix := "App"
+		if side == generators.SideIsUser {
+			prefix = "User"
+		}
+
+		ops := NewActionOperations(side, fg.Specification).Send
+		for _, key := range utils.MapKeysToSortedList(ops) {
+			// Skip the replies, as they are sent in response to a request
+			op := ops[key].Follow()
+			if op.ReplyOf != nil {
+				continue
+			}
+
+			if f, ok := byMessage[templates.OpToMsgTypeName(*op)]; ok {
+				senders = append(senders, FixtureSender{Prefix: prefix, Operation: op, Fixture: f})
+			}
+		}
+	}
+
+	return senders
+}
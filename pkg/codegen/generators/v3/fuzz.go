@@ -0,0 +1,374 @@
+package generatorv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+const (
+	// maxFuzzDepth is the maximum depth of the optional properties of the
+	// generated payloads.
+	maxFuzzDepth = 5
+	// maxFuzzSeeds is the maximum number of payloads in the corpus of a message.
+	maxFuzzSeeds = 100
+)
+
+// FuzzGenerator is a code generator for the fuzz targets of the subscriptions
+// of the controllers, fed with payloads built from the messages schemas.
+type FuzzGenerator struct {
+	Specification asyncapi.Specification
+	// Sides are the sides of the controllers whose subscriptions are fuzzed
+	Sides []generators.Side
+}
+
+// FuzzTarget is a fuzz target of the subscription of an operation.
+type FuzzTarget struct {
+	// Prefix is the prefix of the controller subscribing, like 'App'
+	Prefix    string
+	Operation *asyncapi.Operation
+	// Address is the address of the channel, with the values of its parameters
+	Address string
+	// Parameters are the values of the channel parameters
+	Parameters []FuzzParameter
+	// ContentType is the content type of the published payloads
+	ContentType string
+	// Seeds are the payloads of the corpus of the target
+	Seeds []string
+}
+
+// FuzzParameter is the value of a channel parameter of a fuzz target.
+type FuzzParameter struct {
+	// Field is the name of the field of the parameter, like 'Region'
+	Field string
+	Value string
+}
+
+// Generate generates the fuzz targets code.
+func (fg FuzzGenerator) Generate() (string, error) {
+	tmplt, err := loadTemplate(fuzzTemplatePath)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmplt.Execute(buf, struct {
+		Targets []FuzzTarget
+	}{fg.Targets()}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Targets returns the fuzz targets of the operations received by the
+// controllers, in the order of the operations names. The replies, the
+// operations on channels without address and the protobuf messages are
+// skipped.
+func (fg FuzzGenerator) Targets() []FuzzTarget {
+	targets := make([]FuzzTarget, 0)
+	for _, side := range fg.Sides {
+		prefix := "App"
+		if side == generators.SideIsUser {
+			prefix = "User"
+		}
+
+		ops := NewActionOperations(side, fg.Specification).Receive
+		for _, key := range utils.MapKeysToSortedList(ops) {
+			op := ops[key].Follow()
+			ch := op.Channel.Follow()
+			if op.ReplyOf != nil || ch.Address == "" {
+				continue
+			}
+
+			msg := fuzzMessage(op)
+			if msg == nil || msg.ExtProtobuf != nil {
+				continue
+			}
+
+			address, params := fuzzAddress(ch)
+			targets = append(targets, FuzzTarget{
+				Prefix:      prefix,
+				Operation:   op,
+				Address:     address,
+				Parameters:  params,
+				ContentType: msg.ContentType,
+				Seeds:       fuzzSeeds(msg),
+			})
+		}
+	}
+
+	return targets
+}
+
+// fuzzMessage returns the message of the operation, as received by the
+// subscription: the first one of the operation or of its channel.
+func fuzzMessage(op *asyncapi.Operation) *asyncapi.Message {
+	if len(op.Messages) > 0 {
+		return op.Messages[0].Follow()
+	}
+
+	msgs := op.Channel.Follow().Messages
+	if len(msgs) == 0 {
+		return nil
+	}
+	return msgs[utils.MapKeysToSortedList(msgs)[0]].Follow()
+}
+
+// fuzzAddress returns the address of the channel with the values of its
+// parameters: their first example, their default value, their first possible
+// value, or 'fuzz'.
+func fuzzAddress(ch *asyncapi.Channel) (string, []FuzzParameter) {
+	address := ch.Address
+	params := make([]FuzzParameter, 0, len(ch.Parameters))
+	for _, name := range utils.MapKeysToSortedList(ch.Parameters) {
+		p := ch.Parameters[name].Follow()
+
+		value := "fuzz"
+		switch {
+		case len(p.Examples) > 0:
+			value = p.Examples[0]
+		case p.Default != "":
+			value = p.Default
+		case len(p.Enum) > 0:
+			value = p.Enum[0]
+		}
+
+		address = strings.ReplaceAll(address, "{"+name+"}", value)
+		params = append(params, FuzzParameter{Field: templateutil.Namify(name), Value: value})
+	}
+
+	return address, params
+}
+
+// fuzzSeeds returns the payloads of the corpus of the message: a payload
+// matching its schema, its examples, and invalid payloads mutated from them.
+// Only the examples are encoded for the payloads that are not in JSON.
+func fuzzSeeds(msg *asyncapi.Message) []string {
+	seeds := []string{""}
+	add := func(seed string) {
+		if len(seeds) < maxFuzzSeeds && !utils.IsInSlice(seeds, seed) {
+			seeds = append(seeds, seed)
+		}
+	}
+
+	// The strings payloads are sent as is
+	if msg.Payload != nil && msg.Payload.Follow().Type == asyncapi.SchemaTypeIsString.String() {
+		add(fuzzString(msg.Payload.Follow()))
+		for _, e := range msg.Examples {
+			if s, ok := e.Follow().Payload.(string); ok {
+				add(s)
+			}
+		}
+		add("\x00\xff")
+		return seeds
+	}
+
+	if _, ok := extensions.CodecFor(msg.ContentType).(extensions.JSONCodec); !ok {
+		for _, e := range msg.Examples {
+			if b, err := extensions.MarshalPayload(msg.ContentType, convertExampleKeys(e.Follow().Payload)); err == nil {
+				add(string(b))
+			}
+		}
+		return seeds
+	}
+
+	values := make([]any, 0, 1+len(msg.Examples))
+	if msg.Payload != nil {
+		values = append(values, fuzzValue(msg.Payload, 0))
+	}
+	for _, e := range msg.Examples {
+		if e.Follow().Payload != nil {
+			values = append(values, convertExampleKeys(e.Follow().Payload))
+		}
+	}
+
+	for _, v := range values {
+		add(fuzzJSON(v))
+	}
+	for _, s := range []string{"null", "{}", "[]", "0", `""`, "{"} {
+		add(s)
+	}
+	for _, v := range values {
+		for _, m := range fuzzMutations(v) {
+			add(fuzzJSON(m))
+		}
+		// Truncated JSON
+		if s := fuzzJSON(v); len(s) > 1 {
+			add(s[:len(s)/2])
+		}
+	}
+	return seeds
+}
+
+func fuzzJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// fuzzValue returns a value matching the schema, with the keys converted as
+// the keys of the generated types.
+//
+//nolint:cyclop // this is a value for each type of schema
+func fuzzValue(s *asyncapi.Schema, depth int) any {
+	s = s.Follow()
+
+	switch {
+	case s.Const != nil:
+		return s.Const
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.OneOf) > 0:
+		return fuzzValue(s.OneOf[0], depth)
+	case len(s.AnyOf) > 0:
+		return fuzzValue(s.AnyOf[0], depth)
+	}
+
+	switch s.Type {
+	case asyncapi.SchemaTypeIsString.String():
+		return fuzzString(s)
+	case asyncapi.SchemaTypeIsInteger.String():
+		return math.Ceil(fuzzNumber(s))
+	case "number":
+		return fuzzNumber(s)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	case asyncapi.SchemaTypeIsArray.String():
+		items := make([]any, 0, s.MinItems)
+		if s.Items != nil && depth < maxFuzzDepth {
+			for i := uint(0); i < max(s.MinItems, 1); i++ {
+				items = append(items, fuzzValue(s.Items, depth+1))
+			}
+		}
+		return items
+	case asyncapi.SchemaTypeIsObject.String(), "":
+		if len(s.Properties) == 0 && s.Type == "" {
+			return nil
+		}
+
+		obj := make(map[string]any, len(s.Properties))
+		for _, name := range utils.MapKeysToSortedList(s.Properties) {
+			if depth < maxFuzzDepth || s.IsFieldRequired(name) {
+				obj[templateutil.ConvertKey(name)] = fuzzValue(s.Properties[name], depth+1)
+			}
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+// fuzzString returns a string matching the format and the lengths of the schema.
+func fuzzString(s *asyncapi.Schema) string {
+	var str string
+	switch s.Format {
+	case "date-time":
+		str = "2024-01-02T03:04:05Z"
+	case "date":
+		str = "2024-01-02"
+	case "time":
+		str = "03:04:05"
+	case "uuid":
+		str = "00000000-0000-4000-8000-000000000000"
+	case "email":
+		str = "user@example.com"
+	case "uri":
+		str = "https://example.com"
+	case "hostname":
+		str = "example.com"
+	case "ipv4":
+		str = "127.0.0.1"
+	case "ipv6":
+		str = "::1"
+	default:
+		str = "fuzz"
+		if n := int(s.MinLength); n > len(str) {
+			str += strings.Repeat("z", n-len(str))
+		}
+		if n := int(s.MaxLength); n > 0 && n < len(str) {
+			str = str[:n]
+		}
+	}
+	return str
+}
+
+// fuzzNumber returns a number between the limits of the schema.
+func fuzzNumber(s *asyncapi.Schema) float64 {
+	switch {
+	case s.Minimum != 0:
+		return s.Minimum
+	case s.ExclusiveMinimum != 0:
+		return s.ExclusiveMinimum + 1
+	case s.Maximum < 0:
+		return s.Maximum
+	case s.ExclusiveMaximum < 0:
+		return s.ExclusiveMaximum - 1
+	default:
+		return 1
+	}
+}
+
+// fuzzMutations returns the values mutated from the value: for each property of
+// the objects, the object without it, and with a value of another type.
+func fuzzMutations(v any) []any {
+	switch v := v.(type) {
+	case map[string]any:
+		mutations := make([]any, 0, 2*len(v))
+		for _, name := range utils.MapKeysToSortedList(v) {
+			without := make(map[string]any, len(v))
+			for k, value := range v {
+				if k != name {
+					without[k] = value
+				}
+			}
+			mutations = append(mutations, without)
+
+			for _, m := range append([]any{fuzzOtherType(v[name])}, fuzzMutations(v[name])...) {
+				with := make(map[string]any, len(v))
+				for k, value := range v {
+					with[k] = value
+				}
+				with[name] = m
+				mutations = append(mutations, with)
+			}
+		}
+		return mutations
+	case []any:
+		mutations := make([]any, 0, 1+len(v))
+		mutations = append(mutations, append([]any{nil}, v...))
+		for i := range v {
+			for _, m := range append([]any{fuzzOtherType(v[i])}, fuzzMutations(v[i])...) {
+				with := append([]any(nil), v...)
+				with[i] = m
+				mutations = append(mutations, with)
+			}
+		}
+		return mutations
+	default:
+		return nil
+	}
+}
+
+// fuzzOtherType returns a value of another type than the value.
+func fuzzOtherType(v any) any {
+	switch v.(type) {
+	case string:
+		return 0
+	case nil:
+		return map[string]any{}
+	default:
+		return "fuzz"
+	}
+}
@@ -2,12 +2,25 @@ package generatorv3
 
 import (
 	"fmt"
+	"slices"
 
 	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/options"
 )
 
+// Names of the files generated when the code is split in several files.
+const (
+	TypesFileName    = "types.gen.go"
+	ChannelsFileName = "channels.gen.go"
+	AppFileName      = "app.gen.go"
+	UserFileName     = "user.gen.go"
+	MocksFileName    = "mocks.gen.go"
+	FixturesFileName = "fixtures.gen.go"
+	// FuzzFileName is a test file, as the fuzz targets use the testing package
+	FuzzFileName = "fuzz.gen_test.go"
+)
+
 // Generator is the structure that contains information to generate the code from
 // the specification.
 type Generator struct {
@@ -19,11 +32,14 @@ type Generator struct {
 
 // Generate generates the source code from the specification.
 func (g Generator) Generate() (string, error) {
-	content, err := g.generateImports(g.Options)
+	content, err := g.generateImports(g.Options, false)
 	if err != nil {
 		return "", err
 	}
 
+	// Get the sides of the mocks and fixtures before the parts are generated
+	sides := g.testingSides()
+
 	for remainingParts, part := true, ""; remainingParts; part = "" {
 		switch {
 		case g.Options.Generate.Application:
@@ -35,6 +51,15 @@ func (g Generator) Generate() (string, error) {
 		case g.Options.Generate.Types:
 			part, err = g.generateTypes()
 			g.Options.Generate.Types = false
+		case g.Options.Generate.Mocks:
+			part, err = g.generateMocks(sides)
+			g.Options.Generate.Mocks = false
+		case g.Options.Generate.Fixtures:
+			part, err = FixturesGenerator{Specification: g.Specification, Sides: sides}.Generate()
+			g.Options.Generate.Fixtures = false
+		case g.Options.Generate.Fuzz:
+			part, err = FuzzGenerator{Specification: g.Specification, Sides: sides}.Generate()
+			g.Options.Generate.Fuzz = false
 		default:
 			remainingParts = false
 		}
@@ -49,22 +74,97 @@ func (g Generator) Generate() (string, error) {
 	return content, nil
 }
 
-func (g Generator) generateImports(opts options.Options) (string, error) {
+// GenerateFiles generates the source code from the specification, split in
+// several files: the types, the channels, the application, the user code, the
+// mocks, the fixtures and the fuzz targets.
+// The package documentation is only in the first file.
+func (g Generator) GenerateFiles() (map[string]string, error) {
+	parts := []struct {
+		enabled  bool
+		fileName string
+		generate func() (string, error)
+	}{
+		{g.Options.Generate.Types, TypesFileName, func() (string, error) {
+			return g.generateTypesWith(TypesGenerator{WithoutChannels: true})
+		}},
+		{g.Options.Generate.Types, ChannelsFileName, func() (string, error) {
+			return g.generateTypesWith(TypesGenerator{ChannelsOnly: true})
+		}},
+		{g.Options.Generate.Application, AppFileName, g.generateApp},
+		{g.Options.Generate.User, UserFileName, g.generateUser},
+		{g.Options.Generate.Mocks, MocksFileName, func() (string, error) {
+			return g.generateMocks(g.testingSides())
+		}},
+		{g.Options.Generate.Fixtures, FixturesFileName, func() (string, error) {
+			return FixturesGenerator{Specification: g.Specification, Sides: g.testingSides()}.Generate()
+		}},
+		{g.Options.Generate.Fuzz, FuzzFileName, func() (string, error) {
+			return FuzzGenerator{Specification: g.Specification, Sides: g.testingSides()}.Generate()
+		}},
+	}
+
+	files := make(map[string]string, len(parts))
+	for _, p := range parts {
+		if !p.enabled {
+			continue
+		}
+
+		imports, err := g.generateImports(g.Options, len(files) > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := p.generate()
+		if err != nil {
+			return nil, err
+		}
+
+		files[p.fileName] = imports + content
+	}
+
+	return files, nil
+}
+
+func (g Generator) generateImports(opts options.Options, withoutPackageDoc bool) (string, error) {
 	imps, err := g.Specification.CustomImports()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate custom imports: %w", err)
 	}
 
+	// Add the imports of the codecs packages
+	codecs, err := NewCodecs(opts.Codecs)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range codecs {
+		if !slices.Contains(imps, c.Import()) {
+			imps = append(imps, c.Import())
+		}
+	}
+
 	return ImportsGenerator{
-		PackageName:   opts.PackageName,
-		ModuleVersion: g.ModuleVersion,
-		ModuleName:    g.ModulePath,
-		CustomImports: imps,
+		PackageName:       opts.PackageName,
+		ModuleVersion:     g.ModuleVersion,
+		ModuleName:        g.ModulePath,
+		CustomImports:     imps,
+		WithoutPackageDoc: withoutPackageDoc,
 	}.Generate()
 }
 
 func (g Generator) generateTypes() (string, error) {
-	return TypesGenerator{Specification: g.Specification}.Generate()
+	return g.generateTypesWith(TypesGenerator{})
+}
+
+// generateTypesWith generates the types with the generator parts, and the
+// specification and codecs of the options.
+func (g Generator) generateTypesWith(tg TypesGenerator) (string, error) {
+	codecs, err := NewCodecs(g.Options.Codecs)
+	if err != nil {
+		return "", err
+	}
+
+	tg.Specification, tg.Codecs = g.Specification, codecs
+	return tg.Generate()
 }
 
 func (g Generator) generateApp() (string, error) {
@@ -117,3 +217,36 @@ func (g Generator) generateUser() (string, error) {
 
 	return content, nil
 }
+
+// testingSides returns the sides whose mocks, fixtures senders and fuzz targets
+// are generated: the ones generated with them, or both if they are generated alone
+// (in another file).
+func (g Generator) testingSides() []generators.Side {
+	gen := g.Options.Generate
+	switch {
+	case gen.Application && gen.User, !gen.Application && !gen.User:
+		return []generators.Side{generators.SideIsApplication, generators.SideIsUser}
+	case gen.Application:
+		return []generators.Side{generators.SideIsApplication}
+	default:
+		return []generators.Side{generators.SideIsUser}
+	}
+}
+
+func (g Generator) generateMocks(sides []generators.Side) (string, error) {
+	var code string
+	for _, side := range sides {
+		generate := g.generateUser
+		if side == generators.SideIsApplication {
+			generate = g.generateApp
+		}
+
+		content, err := generate()
+		if err != nil {
+			return "", err
+		}
+		code += content
+	}
+
+	return MocksGenerator{Code: code}.Generate()
+}
@@ -12,6 +12,10 @@ type ImportsGenerator struct {
 	ModuleVersion string
 	ModuleName    string
 	CustomImports []string
+
+	// WithoutPackageDoc removes the package documentation, that should only be
+	// in one of the files of the package.
+	WithoutPackageDoc bool
 }
 
 // Generate will generate the imports code.
@@ -0,0 +1,183 @@
+package generatorv3
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// MocksGenerator is a code generator for the mocks of the subscribers and the
+// controllers, based on their generated code.
+type MocksGenerator struct {
+	// Code is the generated code of the subscribers and controllers, without
+	// the package clause and the imports.
+	Code string
+}
+
+// Mock is the mock of an interface or a controller of the generated code.
+type Mock struct {
+	// Name is the name of the mock, like 'MockAppSubscriber'
+	Name string
+	// Mocked is the name of the mocked type, like 'AppSubscriber'
+	Mocked string
+	// Interface states if the mocked type is an interface
+	Interface bool
+	// Methods are the mocked methods, in their declaration order
+	Methods []MockMethod
+}
+
+// MockMethod is a method of a mock.
+type MockMethod struct {
+	// Name is the name of the method
+	Name string
+	// Params are the parameters with their types, like 'ctx context.Context, msg X'
+	Params string
+	// Args are the parameters names, like 'ctx, msg'
+	Args string
+	// Results are the types of the results
+	Results []string
+}
+
+// ResultsList returns the results of the method signature.
+func (mm MockMethod) ResultsList() string {
+	switch len(mm.Results) {
+	case 0:
+		return ""
+	case 1:
+		return mm.Results[0]
+	default:
+		return "(" + strings.Join(mm.Results, ", ") + ")"
+	}
+}
+
+// Generate will generate the mocks code.
+func (mg MocksGenerator) Generate() (string, error) {
+	mocks, err := mg.Mocks()
+	if err != nil {
+		return "", err
+	}
+
+	tmplt, err := loadTemplate(mocksTemplatePath)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmplt.Execute(buf, struct{ Mocks []Mock }{mocks}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Mocks returns the mocks of the exported interfaces and controllers of the
+// code, in their declaration order.
+func (mg MocksGenerator) Mocks() ([]Mock, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", "package mocks\n"+mg.Code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the code to mock: %w", err)
+	}
+
+	mocks := make([]*Mock, 0)
+	controllers := make(map[string]*Mock)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+
+				switch t := ts.Type.(type) {
+				case *ast.InterfaceType:
+					m := &Mock{Name: "Mock" + ts.Name.Name, Mocked: ts.Name.Name, Interface: true}
+					for _, field := range t.Methods.List {
+						fn, ok := field.Type.(*ast.FuncType)
+						if !ok || len(field.Names) == 0 {
+							continue
+						}
+						m.Methods = append(m.Methods, newMockMethod(fset, field.Names[0].Name, fn))
+					}
+					mocks = append(mocks, m)
+				case *ast.StructType:
+					if strings.HasSuffix(ts.Name.Name, "Controller") {
+						m := &Mock{Name: "Mock" + ts.Name.Name, Mocked: ts.Name.Name}
+						controllers[ts.Name.Name] = m
+						mocks = append(mocks, m)
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 || !d.Name.IsExported() {
+				continue
+			}
+			if m, exists := controllers[receiverName(d.Recv.List[0].Type)]; exists {
+				m.Methods = append(m.Methods, newMockMethod(fset, d.Name.Name, d.Type))
+			}
+		}
+	}
+
+	res := make([]Mock, 0, len(mocks))
+	for _, m := range mocks {
+		res = append(res, *m)
+	}
+	return res, nil
+}
+
+// receiverName returns the type name of a method receiver.
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func newMockMethod(fset *token.FileSet, name string, fn *ast.FuncType) MockMethod {
+	mm := MockMethod{Name: name}
+
+	params := make([]string, 0)
+	args := make([]string, 0)
+	for _, field := range fn.Params.List {
+		typ := exprString(fset, field.Type)
+
+		names := make([]string, 0, len(field.Names))
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, fmt.Sprintf("arg%d", len(args)))
+		}
+
+		for _, n := range names {
+			params = append(params, n+" "+typ)
+			args = append(args, n)
+		}
+	}
+	mm.Params, mm.Args = strings.Join(params, ", "), strings.Join(args, ", ")
+
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			typ := exprString(fset, field.Type)
+			for i := 0; i < max(1, len(field.Names)); i++ {
+				mm.Results = append(mm.Results, typ)
+			}
+		}
+	}
+
+	return mm
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	buf := new(bytes.Buffer)
+	_ = printer.Fprint(buf, fset, expr)
+	return buf.String()
+}
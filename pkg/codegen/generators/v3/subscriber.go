@@ -36,6 +36,8 @@ func (asg SubscriberGenerator) Generate() (string, error) {
 	tmplt, err := loadTemplate(
 		subscriberTemplatePath,
 		schemaDefinitionTemplatePath,
+		unionDefinitionTemplatePath,
+		enumDefinitionTemplatePath,
 		schemaNameTemplatePath,
 		messageTemplatePath,
 	)
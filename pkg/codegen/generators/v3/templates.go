@@ -2,10 +2,12 @@ package generatorv3
 
 import (
 	"embed"
+	"io/fs"
 	"maps"
 	"path"
 	"text/template"
 
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
 	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators/v3/templates"
 	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
 )
@@ -17,13 +19,25 @@ const (
 	typesTemplatePath            = templatesDir + "/types.tmpl"
 	schemaDefinitionTemplatePath = templatesDir + "/schema_definition.tmpl"
 	schemaNameTemplatePath       = templatesDir + "/schema_name.tmpl"
+	unionDefinitionTemplatePath  = templatesDir + "/union_definition.tmpl"
+	enumDefinitionTemplatePath   = templatesDir + "/enum_definition.tmpl"
 	messageTemplatePath          = templatesDir + "/message.tmpl"
 	subscriberTemplatePath       = templatesDir + "/subscriber.tmpl"
 	controllerTemplatePath       = templatesDir + "/controller.tmpl"
+	mocksTemplatePath            = templatesDir + "/mocks.tmpl"
+	fixturesTemplatePath         = templatesDir + "/fixtures.tmpl"
+	fuzzTemplatePath             = templatesDir + "/fuzz.tmpl"
 
 	marshalingTemplatesDir                     = templatesDir + "/marshaling"
 	marshalingAdditionalPropertiesTemplatePath = marshalingTemplatesDir + "/additional_properties.tmpl"
 	marshalingTimeTemplatePath                 = marshalingTemplatesDir + "/time.tmpl"
+	marshalingTextTemplatePath                 = marshalingTemplatesDir + "/text.tmpl"
+	marshalingUnionsTemplatePath               = marshalingTemplatesDir + "/unions.tmpl"
+	marshalingHeadersTemplatePath              = marshalingTemplatesDir + "/headers.tmpl"
+
+	docsTemplatesDir         = templatesDir + "/docs"
+	docsMarkdownTemplatePath = docsTemplatesDir + "/markdown.tmpl"
+	docsHTMLTemplatePath     = docsTemplatesDir + "/html.tmpl"
 )
 
 var (
@@ -31,12 +45,34 @@ var (
 	files embed.FS
 )
 
+// Templates returns the default templates, that can be copied in order to be
+// overridden.
+func Templates() fs.FS {
+	sub, err := fs.Sub(files, templatesDir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
 func loadTemplate(paths ...string) (*template.Template, error) {
+	return loadTemplateWithFunctions(nil, paths...)
+}
+
+// loadTemplateWithFunctions loads the templates with the helpers functions
+// and the given additional ones.
+func loadTemplateWithFunctions(additional template.FuncMap, paths ...string) (*template.Template, error) {
 	funcs := templateutil.HelpersFunctions()
 	maps.Copy(funcs, templates.HelpersFunctions())
+	maps.Copy(funcs, additional)
 
-	return template.
+	tmplt, err := template.
 		New(path.Base(paths[0])).
 		Funcs(funcs).
 		ParseFS(files, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return generators.OverrideTemplates(tmplt, templatesDir, paths...)
 }
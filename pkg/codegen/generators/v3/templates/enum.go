@@ -0,0 +1,48 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+// EnumConstant is a constant generated for a value of an enum.
+type EnumConstant struct {
+	// Name is the name of the constant.
+	Name string
+	// Value is the Go literal of the value.
+	Value string
+}
+
+// EnumConstants returns the constants generated for the values of the enum,
+// named after the type and the value.
+func EnumConstants(s asyncapi.Schema) []EnumConstant {
+	typeName := templateutil.Namify(s.Name)
+
+	constants := make([]EnumConstant, 0, len(s.Enum))
+	names := make(map[string]bool, len(s.Enum))
+	for i, e := range s.Enum {
+		var c EnumConstant
+		switch v := e.(type) {
+		case string:
+			c.Name, c.Value = templateutil.Namify(typeName+"_"+v), strconv.Quote(v)
+		default:
+			c.Value = fmt.Sprint(v)
+			c.Name = typeName + strings.Replace(c.Value, "-", "Minus", 1)
+		}
+
+		// Values without letters or digits, or giving the same name as
+		// another one, are named after their position
+		if c.Name == typeName || names[c.Name] {
+			c.Name = fmt.Sprintf("%sValue%d", typeName, i)
+		}
+		names[c.Name] = true
+
+		constants = append(constants, c)
+	}
+
+	return constants
+}
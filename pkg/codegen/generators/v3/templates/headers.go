@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+// GenerateStandardHeaders will generate the code setting the standard headers
+// (timestamp and contentType) of the headers named 'name' if they are not set,
+// when they are strings or a generated date-time. The messageId header is set
+// by the controller, with its IDGenerator.
+func GenerateStandardHeaders(headers asyncapi.Schema, name, contentType string) string {
+	s := headers.Follow()
+
+	values := map[string]string{
+		extensions.StandardHeaderTimestamp: "time.Now().UTC().Format(time.RFC3339)",
+	}
+	if contentType != "" {
+		values[extensions.StandardHeaderContentType] = fmt.Sprintf("%q", contentType)
+	}
+
+	var code strings.Builder
+	for _, key := range []string{
+		extensions.StandardHeaderTimestamp,
+		extensions.StandardHeaderContentType,
+	} {
+		prop, ok := s.Properties[key]
+		if !ok || values[key] == "" {
+			continue
+		}
+
+		value := values[key]
+		isString := prop.Reference == "" && zeroValue(prop) == `""` && !prop.IsEnum()
+		isTime := key == extensions.StandardHeaderTimestamp && prop.Reference == "" && prop.ExtGoType == "" &&
+			prop.Format == "date-time" && templateutil.IsDateOrDateTimeGenerated(prop.Format)
+		switch {
+		case isTime:
+			value = "time.Now().UTC()"
+		case !isString:
+			continue
+		}
+
+		field := fmt.Sprintf("%s.%s", name, FieldName(key, *prop))
+		switch OptionalField(*s, key, *prop) {
+		case asyncapi.GoOptionalOption:
+			fmt.Fprintf(&code, "if !%s.IsSet() {\n%s.Set(%s)\n}\n", field, field, value)
+		case asyncapi.GoOptionalPointer:
+			fmt.Fprintf(&code, "if %s == nil {\nh := %s\n%s = &h\n}\n", field, value, field)
+		default:
+			if isTime {
+				fmt.Fprintf(&code, "if %s.IsZero() {\n%s = %s\n}\n", field, field, value)
+			} else {
+				fmt.Fprintf(&code, "if %s == \"\" {\n%s = %s\n}\n", field, field, value)
+			}
+		}
+	}
+
+	return code.String()
+}
@@ -3,6 +3,7 @@ package templates
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -12,10 +13,14 @@ import (
 	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
 )
 
-// GetChildrenObjectSchemas will return all the children object schemas of a
-// schema, only from first level and without AnyOf, AllOf and OneOf.
+// GetChildrenObjectSchemas will return all the children object (and enum) schemas of a
+// schema, only from first level and without AnyOf, AllOf and OneOf, in the order of
+// the properties names.
 func GetChildrenObjectSchemas(s asyncapi.Schema) []*asyncapi.Schema {
-	allSchemas := utils.MapToList(s.Properties)
+	allSchemas := make([]*asyncapi.Schema, 0, len(s.Properties))
+	for _, name := range utils.MapKeysToSortedList(s.Properties) {
+		allSchemas = append(allSchemas, s.Properties[name])
+	}
 
 	if s.Items != nil {
 		allSchemas = append(allSchemas, s.Items)
@@ -25,14 +30,18 @@ func GetChildrenObjectSchemas(s asyncapi.Schema) []*asyncapi.Schema {
 		allSchemas = append(allSchemas, s.AdditionalProperties)
 	}
 
-	// Only keep object schemas
+	for _, pattern := range utils.MapKeysToSortedList(s.PatternProperties) {
+		allSchemas = append(allSchemas, s.PatternProperties[pattern])
+	}
+
+	// Only keep object (and enum) schemas
 	filteredSchemas := make([]*asyncapi.Schema, 0, len(allSchemas))
 	for _, schema := range allSchemas {
-		if schema.Type == asyncapi.SchemaTypeIsObject.String() {
+		if schema.Type == asyncapi.SchemaTypeIsObject.String() || schema.IsEnum() {
 			filteredSchemas = append(filteredSchemas, schema)
 		} else if schema.Type == asyncapi.SchemaTypeIsArray.String() &&
 			schema.Items != nil &&
-			schema.Items.Type == asyncapi.SchemaTypeIsObject.String() {
+			(schema.Items.Type == asyncapi.SchemaTypeIsObject.String() || schema.Items.IsEnum()) {
 			filteredSchemas = append(filteredSchemas, schema.Items)
 		}
 	}
@@ -86,6 +95,23 @@ func OpToMsgTypeName(op asyncapi.Operation) string {
 	return templateutil.Namify(msg.Follow().Name)
 }
 
+// PayloadJSONSchemaName returns the name of the constant containing the JSON
+// Schema of the message payload, or an empty string if there is none (like for
+// protobuf payloads).
+func PayloadJSONSchemaName(msg asyncapi.Message) string {
+	m := msg.Follow()
+	if m.Payload == nil || m.ExtProtobuf != nil {
+		return ""
+	}
+	return "jsonSchemaFor" + templateutil.Namify(m.Name)
+}
+
+// PayloadJSONSchema returns the JSON Schema of the message payload.
+func PayloadJSONSchema(msg asyncapi.Message) (string, error) {
+	schema, err := msg.Follow().Payload.JSONSchema()
+	return string(schema), err
+}
+
 // OpToChannelTypeName will convert an operation to a channel type name in the
 // form of golang conventional type names.
 func OpToChannelTypeName(op asyncapi.Operation) string {
@@ -93,6 +119,15 @@ func OpToChannelTypeName(op asyncapi.Operation) string {
 	return templateutil.Namify(ch.Name)
 }
 
+// FieldName will give the name of the struct field of a property, which is the
+// one set with the x-go-name extension if there is one.
+func FieldName(key string, property asyncapi.Schema) string {
+	if property.ExtGoName != "" {
+		return property.ExtGoName
+	}
+	return templateutil.Namify(key)
+}
+
 // IsRequired will check if a field is required in a asyncapi struct.
 func IsRequired(schema asyncapi.Schema, field string) bool {
 	return schema.IsFieldRequired(field)
@@ -127,31 +162,82 @@ func GenerateChannelAddr(ch *asyncapi.Channel) string {
 	return sprint[:len(sprint)-1] + ")"
 }
 
-var isFieldPointer = func(parent asyncapi.Schema, field string, schema asyncapi.Schema) bool {
-	return !(IsRequired(parent, field) || schema.IsRequired) && schema.Type != "array"
+// UnionProperties returns the sorted names of the properties of the schema
+// that are unions with a discriminator.
+func UnionProperties(s asyncapi.Schema) []string {
+	names := make([]string, 0)
+	for name, p := range s.Properties {
+		if p.Follow().IsDiscriminatedUnion() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AdditionalPropertiesSchema returns the schema of the values of the object
+// properties that are not in its properties, or nil if they are not kept.
+//
+// These are the additional properties and the pattern properties: values are
+// of any type if there is more than one of their schemas.
+func AdditionalPropertiesSchema(s asyncapi.Schema) *asyncapi.Schema {
+	additional := s.AdditionalProperties
+	if additional != nil && additional.IsFalse() {
+		additional = nil
+	}
+
+	if len(s.PatternProperties) == 0 {
+		return additional
+	} else if len(s.PatternProperties) == 1 && additional == nil {
+		for _, p := range s.PatternProperties {
+			return p
+		}
+	}
+
+	anySchema := asyncapi.NewSchema()
+	anySchema.ExtGoType = "any"
+	return &anySchema
 }
 
-// ForcePointerOnFields is used to force the generation of all fields as pointers, except for arrays.
-func ForcePointerOnFields() {
-	isFieldPointer = func(parent asyncapi.Schema, field string, schema asyncapi.Schema) bool {
-		return schema.Type != "array"
+// AdditionalPropertiesPatterns returns the sorted patterns of the pattern
+// properties of the object if only the properties matching them are kept
+// with the additional properties, or nil if all of them are kept.
+func AdditionalPropertiesPatterns(s asyncapi.Schema) []string {
+	if s.AdditionalProperties != nil && !s.AdditionalProperties.IsFalse() {
+		return nil
 	}
+
+	return utils.MapKeysToSortedList(s.PatternProperties)
 }
 
 // HelpersFunctions returns the functions that can be used as helpers
 // in a golang template.
 func HelpersFunctions() template.FuncMap {
 	return template.FuncMap{
-		"getChildrenObjectSchemas":       GetChildrenObjectSchemas,
-		"channelToMessageTypeName":       ChannelToMessageTypeName,
-		"opToMsgTypeName":                OpToMsgTypeName,
-		"opToChannelTypeName":            OpToChannelTypeName,
-		"isRequired":                     IsRequired,
-		"isFieldPointer":                 isFieldPointer,
-		"generateChannelAddr":            GenerateChannelAddr,
-		"generateChannelAddrFromOp":      GenerateChannelAddrFromOp,
-		"referenceToStructAttributePath": ReferenceToStructAttributePath,
-		"generateValidateTags":           generators.GenerateValidateTags[asyncapi.Schema],
-		"generateJSONTags":               generators.GenerateJSONTags[asyncapi.Schema],
+		"getChildrenObjectSchemas":        GetChildrenObjectSchemas,
+		"channelToMessageTypeName":        ChannelToMessageTypeName,
+		"opToMsgTypeName":                 OpToMsgTypeName,
+		"opToChannelTypeName":             OpToChannelTypeName,
+		"payloadJSONSchemaName":           PayloadJSONSchemaName,
+		"payloadJSONSchema":               PayloadJSONSchema,
+		"fieldName":                       FieldName,
+		"isRequired":                      IsRequired,
+		"isFieldPointer":                  isFieldPointer,
+		"optionalField":                   OptionalField,
+		"zeroValue":                       ZeroValue,
+		"generateChannelAddr":             GenerateChannelAddr,
+		"generateChannelAddrFromOp":       GenerateChannelAddrFromOp,
+		"referenceToStructAttributePath":  ReferenceToStructAttributePath,
+		"generateValidateTags":            generators.GenerateValidateTags[asyncapi.Schema],
+		"generateJSONTags":                generators.GenerateJSONTags[asyncapi.Schema],
+		"generateFieldJSONTags":           GenerateFieldJSONTags,
+		"generateValidations":             GenerateValidations,
+		"generateFieldValidations":        GenerateFieldValidations,
+		"generateStandardHeaders":         GenerateStandardHeaders,
+		"unionProperties":                 UnionProperties,
+		"enumConstants":                   EnumConstants,
+		"additionalPropertiesSchema":      AdditionalPropertiesSchema,
+		"additionalPropertiesPatterns":    AdditionalPropertiesPatterns,
+		"additionalPropertiesValidations": AdditionalPropertiesValidations,
 	}
 }
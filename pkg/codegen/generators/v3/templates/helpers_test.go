@@ -49,6 +49,156 @@ func (suite *HelpersSuite) TestIsRequired() {
 	}
 }
 
+func (suite *HelpersSuite) TestFieldName() {
+	suite.Require().Equal("UserId", FieldName("user_id", asyncapiv3.Schema{}))
+	suite.Require().Equal("UserID", FieldName("user_id", asyncapiv3.Schema{
+		Extensions: asyncapiv3.Extensions{ExtGoName: "UserID"},
+	}))
+}
+
 func (suite *HelpersSuite) TestGetChildrenObjectSchemas() {
 	// TODO
 }
+
+func (suite *HelpersSuite) TestEnumConstants() {
+	cases := []struct {
+		Schema asyncapiv3.Schema
+		Result []EnumConstant
+	}{
+		// String values
+		{
+			Schema: asyncapiv3.Schema{
+				Name: "Status",
+				Type: "string",
+				Validations: asyncapi.Validations[asyncapiv3.Schema]{
+					Enum: []any{"in-progress", "done", "", "in_progress"},
+				},
+			},
+			Result: []EnumConstant{
+				{Name: "StatusInProgress", Value: `"in-progress"`},
+				{Name: "StatusDone", Value: `"done"`},
+				{Name: "StatusValue2", Value: `""`},
+				{Name: "StatusValue3", Value: `"in_progress"`},
+			},
+		},
+		// Integer values
+		{
+			Schema: asyncapiv3.Schema{
+				Name: "Level",
+				Type: "integer",
+				Validations: asyncapi.Validations[asyncapiv3.Schema]{
+					Enum: []any{float64(1), float64(-1)},
+				},
+			},
+			Result: []EnumConstant{
+				{Name: "Level1", Value: "1"},
+				{Name: "LevelMinus1", Value: "-1"},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		suite.Require().Equal(c.Result, EnumConstants(c.Schema), i)
+	}
+}
+
+func (suite *HelpersSuite) TestOptionalField() {
+	stringSchema := func(optional string) asyncapiv3.Schema {
+		return asyncapiv3.Schema{Type: "string", Extensions: asyncapiv3.Extensions{ExtGoOptional: optional}}
+	}
+	object := func(optional string, required ...string) asyncapiv3.Schema {
+		return asyncapiv3.Schema{
+			Type:        "object",
+			Extensions:  asyncapiv3.Extensions{ExtGoOptional: optional},
+			Validations: asyncapi.Validations[asyncapiv3.Schema]{Required: required},
+		}
+	}
+
+	cases := []struct {
+		Parent asyncapiv3.Schema
+		Schema asyncapiv3.Schema
+		Result string
+	}{
+		// Default strategy
+		{Parent: object(""), Schema: stringSchema(""), Result: asyncapiv3.GoOptionalPointer},
+		// Required field
+		{Parent: object(asyncapiv3.GoOptionalOption, "field"), Schema: stringSchema(""), Result: ""},
+		// Strategy from the object
+		{Parent: object(asyncapiv3.GoOptionalOption), Schema: stringSchema(""), Result: asyncapiv3.GoOptionalOption},
+		// Strategy from the field, over the object one
+		{
+			Parent: object(asyncapiv3.GoOptionalOption),
+			Schema: stringSchema(asyncapiv3.GoOptionalOmitEmpty),
+			Result: asyncapiv3.GoOptionalOmitEmpty,
+		},
+		// Omitempty is only used on scalars
+		{
+			Parent: object(asyncapiv3.GoOptionalOmitEmpty),
+			Schema: asyncapiv3.Schema{Type: "object"},
+			Result: asyncapiv3.GoOptionalPointer,
+		},
+		// Arrays are never pointers
+		{Parent: object(""), Schema: asyncapiv3.Schema{Type: "array"}, Result: ""},
+	}
+
+	for i, c := range cases {
+		suite.Require().Equal(c.Result, OptionalField(c.Parent, "field", c.Schema), i)
+	}
+}
+
+func (suite *HelpersSuite) TestAdditionalPropertiesSchema() {
+	integer := &asyncapiv3.Schema{Type: "integer"}
+	falseSchema := &asyncapiv3.Schema{}
+	suite.Require().NoError(falseSchema.UnmarshalJSON([]byte("false")))
+
+	cases := []struct {
+		Schema   asyncapiv3.Schema
+		Type     string
+		Patterns []string
+	}{
+		// No additional properties
+		{Schema: asyncapiv3.Schema{}},
+		{Schema: asyncapiv3.Schema{AdditionalProperties: falseSchema}},
+		// Additional properties
+		{Schema: asyncapiv3.Schema{AdditionalProperties: integer}, Type: "integer"},
+		// One pattern property
+		{
+			Schema:   asyncapiv3.Schema{PatternProperties: map[string]*asyncapiv3.Schema{"^x-": integer}},
+			Type:     "integer",
+			Patterns: []string{"^x-"},
+		},
+		// Pattern properties with additional properties
+		{
+			Schema: asyncapiv3.Schema{
+				PatternProperties:    map[string]*asyncapiv3.Schema{"^x-": integer},
+				AdditionalProperties: integer,
+			},
+			Type: "any",
+		},
+		// Several pattern properties
+		{
+			Schema: asyncapiv3.Schema{
+				PatternProperties:    map[string]*asyncapiv3.Schema{"^y-": integer, "^x-": integer},
+				AdditionalProperties: falseSchema,
+			},
+			Type:     "any",
+			Patterns: []string{"^x-", "^y-"},
+		},
+	}
+
+	for i, c := range cases {
+		schema := AdditionalPropertiesSchema(c.Schema)
+		switch {
+		case c.Type == "":
+			suite.Require().Nil(schema, i)
+		case c.Type == "any":
+			suite.Require().Equal("any", schema.ExtGoType, i)
+		default:
+			suite.Require().Equal(c.Type, schema.Type, i)
+		}
+
+		if c.Type != "" {
+			suite.Require().Equal(c.Patterns, AdditionalPropertiesPatterns(c.Schema), i)
+		}
+	}
+}
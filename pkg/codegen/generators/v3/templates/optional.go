@@ -0,0 +1,139 @@
+package templates
+
+import (
+	"fmt"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/codegen/generators"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+var (
+	// optionalFields is the strategy used for the optional fields without
+	// x-go-optional extension.
+	optionalFields = asyncapi.GoOptionalPointer
+
+	// forcePointers is true if all fields should be generated as pointers.
+	forcePointers bool
+)
+
+// SetOptionalFieldsStrategy sets how the optional fields are generated when
+// there is no x-go-optional extension on them.
+func SetOptionalFieldsStrategy(strategy string) error {
+	if !asyncapi.IsValidGoOptional(strategy) {
+		return fmt.Errorf("unknown optional fields strategy %s, supported values: pointer, omitempty, option", strategy)
+	}
+
+	optionalFields = strategy
+
+	return nil
+}
+
+// ForcePointerOnFields is used to force the generation of all fields as pointers,
+// except for arrays and unions with a discriminator (as they are interfaces).
+func ForcePointerOnFields() {
+	forcePointers = true
+}
+
+// OptionalField returns how the field is generated if it is optional (pointer,
+// omitempty or option), or an empty string if it is generated as a value.
+//
+// Arrays and unions with a discriminator are always values, as they can be nil.
+// The 'omitempty' strategy is only used on string, integer, number or boolean
+// fields (the other ones are pointers), as the others are not omitted by JSON.
+func OptionalField(parent asyncapi.Schema, field string, schema asyncapi.Schema) string {
+	s := schema.Follow()
+	if schema.Type == asyncapi.SchemaTypeIsArray.String() || s.IsDiscriminatedUnion() {
+		return ""
+	}
+
+	if forcePointers {
+		return asyncapi.GoOptionalPointer
+	} else if IsRequired(parent, field) || schema.IsRequired {
+		return ""
+	}
+
+	// Get the strategy from the field, the object, then the default one
+	strategy := optionalFields
+	if schema.ExtGoOptional != "" {
+		strategy = schema.ExtGoOptional
+	} else if parent.ExtGoOptional != "" {
+		strategy = parent.ExtGoOptional
+	}
+
+	if strategy == asyncapi.GoOptionalOmitEmpty && zeroValue(s) == "" {
+		return asyncapi.GoOptionalPointer
+	}
+
+	return strategy
+}
+
+// isFieldPointer returns true if the field is generated as a pointer.
+func isFieldPointer(parent asyncapi.Schema, field string, schema asyncapi.Schema) bool {
+	return OptionalField(parent, field, schema) == asyncapi.GoOptionalPointer
+}
+
+// zeroValue returns the Go zero value of the schema, if it is a string, an
+// integer, a number or a boolean generated without custom type.
+func zeroValue(s *asyncapi.Schema) string {
+	if s.ExtGoType != "" {
+		return ""
+	}
+
+	switch s.Type {
+	case asyncapi.SchemaTypeIsString.String():
+		if templateutil.IsStringFormatGenerated(s.Format) {
+			return ""
+		}
+		return `""`
+	case asyncapi.SchemaTypeIsInteger.String(), "number":
+		return "0"
+	case "boolean":
+		return "false"
+	default:
+		return ""
+	}
+}
+
+// ZeroValue returns the Go zero value of the schema, for the fields generated
+// with the 'omitempty' strategy.
+func ZeroValue(schema asyncapi.Schema) string {
+	return zeroValue(schema.Follow())
+}
+
+// GenerateFieldJSONTags returns the "json" tag of a field, with 'omitzero' on
+// the options in order to omit them when unset (from Go 1.24).
+func GenerateFieldJSONTags(parent asyncapi.Schema, field string, schema asyncapi.Schema) string {
+	tags := generators.GenerateJSONTags(schema.Validations, field)
+	if OptionalField(parent, field, schema) == asyncapi.GoOptionalOption {
+		tags = tags[:len(tags)-1] + `,omitzero"`
+	}
+	return tags
+}
+
+// GenerateFieldValidations will generate the code checking that the value of
+// the field of a struct named 't' respects the constraints of its schema.
+func GenerateFieldValidations(parent asyncapi.Schema, field string, schema *asyncapi.Schema) string {
+	value := "t." + FieldName(field, *schema)
+	path := fmt.Sprintf("%q", templateutil.ConvertKey(field))
+	isRequired := IsRequired(parent, field) || schema.IsRequired
+
+	switch OptionalField(parent, field, *schema) {
+	case asyncapi.GoOptionalPointer:
+		return GenerateValidations(value, path, schema, true, isRequired)
+	case asyncapi.GoOptionalOmitEmpty:
+		checks := GenerateValidations(value, path, schema, false, false)
+		if checks == "" {
+			return ""
+		}
+		return fmt.Sprintf("if %s != %s {\n%s}\n", value, ZeroValue(*schema), checks)
+	case asyncapi.GoOptionalOption:
+		checks := GenerateValidations("v", path, schema, false, false)
+		if checks == "" {
+			return ""
+		}
+		return fmt.Sprintf("if v, ok := %s.Get(); ok {\n%s}\n", value, checks)
+	default:
+		return GenerateValidations(value, path, schema, false, isRequired)
+	}
+}
@@ -0,0 +1,230 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	templateutil "github.com/lerenn/asyncapi-codegen/pkg/utils/template"
+)
+
+// validatedFormats are the formats checked by extensions.MatchFormat.
+var validatedFormats = map[string]bool{
+	"date": true, "date-time": true, "email": true, "hostname": true,
+	"ipv4": true, "ipv6": true, "uri": true, "uuid": true, "byte": true,
+}
+
+// GenerateValidations will generate the code checking that the value of a
+// field respects the constraints of its schema, by adding the errors to an
+// 'errs' variable of type extensions.ValidationErrors. The path is the Go
+// expression of the field path used in the errors.
+func GenerateValidations(value, path string, schema *asyncapi.Schema, isPointer, isRequired bool) string {
+	if schema == nil {
+		return ""
+	}
+
+	var code string
+	if isRequired && (isPointer || schema.Follow().Type == asyncapi.SchemaTypeIsArray.String() ||
+		schema.Follow().IsDiscriminatedUnion()) {
+		code = fmt.Sprintf("if %s == nil {\n%s\n}\n", value, addValidationError(path, "required", "is required"))
+	}
+
+	if isPointer {
+		if checks := valueValidations("*"+value, path, schema, 0); checks != "" {
+			code += fmt.Sprintf("if %s != nil {\n%s}\n", value, checks)
+		}
+		return code
+	}
+
+	return code + valueValidations(value, path, schema, 0)
+}
+
+// AdditionalPropertiesValidations will generate the code checking that the
+// additional properties of an object named 't' respect the constraints of
+// their schema.
+func AdditionalPropertiesValidations(s asyncapi.Schema) string {
+	schema := AdditionalPropertiesSchema(s)
+	if schema == nil {
+		return ""
+	}
+
+	checks := valueValidations("v", "k", schema, 0)
+	if checks == "" {
+		return ""
+	}
+	return fmt.Sprintf("for k, v := range t.AdditionalProperties {\n%s}\n", checks)
+}
+
+// valueValidations will generate the checks of the constraints of the schema
+// on the value, with 'depth' being the number of arrays containing it.
+//
+//nolint:cyclop // this is a switch on the schema types
+func valueValidations(value, path string, schema *asyncapi.Schema, depth int) string {
+	s := schema.Follow()
+	if schema.ExtGoType != "" || s.ExtGoType != "" {
+		return ""
+	}
+
+	// Unions with a discriminator are validated with their variant, and the
+	// other ones are not validated
+	if s.IsDiscriminatedUnion() {
+		return fmt.Sprintf("if v, ok := %s.(interface{ Validate() error }); ok {\nerrs.AddNested(%s, v.Validate())\n}\n",
+			value, path)
+	} else if len(s.AnyOf) > 0 || len(s.OneOf) > 0 {
+		return ""
+	}
+
+	switch s.Type {
+	case asyncapi.SchemaTypeIsObject.String():
+		if strings.HasPrefix(value, "*") {
+			value = "(" + value + ")"
+		}
+		return fmt.Sprintf("errs.AddNested(%s, %s.Validate())\n", path, value)
+	case asyncapi.SchemaTypeIsString.String():
+		if templateutil.IsStringFormatGenerated(s.Format) {
+			return ""
+		}
+
+		// Types generated from references or enums need a conversion
+		if schema.ReferenceTo != nil || s.IsEnum() {
+			value = "string(" + value + ")"
+		}
+		return stringValidations(value, path, s)
+	case asyncapi.SchemaTypeIsInteger.String(), "number":
+		return numberValidations(value, path, s)
+	case asyncapi.SchemaTypeIsArray.String():
+		return arrayValidations(value, path, s, depth)
+	default:
+		return ""
+	}
+}
+
+// stringValidations will generate the checks of the string constraints.
+func stringValidations(value, path string, s *asyncapi.Schema) string {
+	var b strings.Builder
+
+	if s.MinLength > 0 {
+		fmt.Fprintf(&b, "if utf8.RuneCountInString(%s) < %d {\n%s\n}\n", value, s.MinLength,
+			addValidationError(path, "minLength", fmt.Sprintf("should have at least %d characters", s.MinLength)))
+	}
+	if s.MaxLength > 0 {
+		fmt.Fprintf(&b, "if utf8.RuneCountInString(%s) > %d {\n%s\n}\n", value, s.MaxLength,
+			addValidationError(path, "maxLength", fmt.Sprintf("should have at most %d characters", s.MaxLength)))
+	}
+	if s.Pattern != "" {
+		fmt.Fprintf(&b, "if !extensions.MatchPattern(%q, %s) {\n%s\n}\n", s.Pattern, value,
+			addValidationError(path, "pattern", fmt.Sprintf("should match %q", s.Pattern)))
+	}
+	if validatedFormats[s.Format] {
+		fmt.Fprintf(&b, "if !extensions.MatchFormat(%q, %s) {\n%s\n}\n", s.Format, value,
+			addValidationError(path, "format", fmt.Sprintf("should be in the %q format", s.Format)))
+	}
+
+	// Only string enums are supported on strings
+	symbols := make([]string, 0, len(s.Enum))
+	for _, e := range s.Enum {
+		str, ok := e.(string)
+		if !ok {
+			return b.String()
+		}
+		symbols = append(symbols, strconv.Quote(str))
+	}
+	if len(symbols) > 0 {
+		b.WriteString(enumValidation(value, path, symbols))
+	}
+
+	return b.String()
+}
+
+// numberValidations will generate the checks of the numeric constraints.
+func numberValidations(value, path string, s *asyncapi.Schema) string {
+	var b strings.Builder
+
+	limits := []struct {
+		limit      float64
+		operator   string
+		constraint string
+		message    string
+	}{
+		{s.Minimum, "<", "minimum", "should be greater than or equal to"},
+		{s.Maximum, ">", "maximum", "should be less than or equal to"},
+		{s.ExclusiveMinimum, "<=", "exclusiveMinimum", "should be greater than"},
+		{s.ExclusiveMaximum, ">=", "exclusiveMaximum", "should be less than"},
+	}
+	for _, l := range limits {
+		// NOTE: zero values can't be distinguished from unset constraints
+		if l.limit == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "if %s %s %s {\n%s\n}\n", numberOperand(value, s, l.limit), l.operator, numberLiteral(l.limit),
+			addValidationError(path, l.constraint, l.message+" "+numberLiteral(l.limit)))
+	}
+
+	// Only integer enums are supported on integers
+	symbols := make([]string, 0, len(s.Enum))
+	for _, e := range s.Enum {
+		n, ok := e.(float64)
+		if !ok || (s.Type == asyncapi.SchemaTypeIsInteger.String() && n != math.Trunc(n)) {
+			return b.String()
+		}
+		symbols = append(symbols, numberLiteral(n))
+	}
+	if len(symbols) > 0 {
+		b.WriteString(enumValidation(value, path, symbols))
+	}
+
+	return b.String()
+}
+
+// arrayValidations will generate the checks of the array constraints, and of
+// the constraints of its items.
+func arrayValidations(value, path string, s *asyncapi.Schema, depth int) string {
+	var b strings.Builder
+
+	if s.MinItems > 0 {
+		fmt.Fprintf(&b, "if len(%s) < %d {\n%s\n}\n", value, s.MinItems,
+			addValidationError(path, "minItems", fmt.Sprintf("should have at least %d items", s.MinItems)))
+	}
+	if s.MaxItems > 0 {
+		fmt.Fprintf(&b, "if len(%s) > %d {\n%s\n}\n", value, s.MaxItems,
+			addValidationError(path, "maxItems", fmt.Sprintf("should have at most %d items", s.MaxItems)))
+	}
+
+	if s.Items != nil {
+		index := fmt.Sprintf("i%d", depth)
+		itemPath := fmt.Sprintf("fmt.Sprintf(\"%%s[%%d]\", %s, %s)", path, index)
+		if checks := valueValidations(value+"["+index+"]", itemPath, s.Items, depth+1); checks != "" {
+			fmt.Fprintf(&b, "for %s := range %s {\n%s}\n", index, value, checks)
+		}
+	}
+
+	return b.String()
+}
+
+// enumValidation will generate the check of the enum constraint.
+func enumValidation(value, path string, symbols []string) string {
+	return fmt.Sprintf("switch %s {\ncase %s:\ndefault:\n%s\n}\n", value, strings.Join(symbols, ", "),
+		addValidationError(path, "enum", "should be one of "+strings.Join(symbols, ", ")))
+}
+
+// numberOperand returns the value to compare with the limit, converted to a
+// float if the limit is not an integer and the value is.
+func numberOperand(value string, s *asyncapi.Schema, limit float64) string {
+	if s.Type == asyncapi.SchemaTypeIsInteger.String() && limit != math.Trunc(limit) {
+		return "float64(" + value + ")"
+	}
+	return value
+}
+
+// numberLiteral returns the Go literal of the number.
+func numberLiteral(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+// addValidationError will generate the code adding the validation error.
+func addValidationError(path, constraint, message string) string {
+	return fmt.Sprintf("errs.Add(%s, %q, %q)", path, constraint, strings.ReplaceAll(message, "%", "%%"))
+}
@@ -2,14 +2,24 @@ package generatorv3
 
 import (
 	"bytes"
+	"slices"
 
 	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
 )
 
 // TypesGenerator is a code generator for types that will generate all schemas
 // contained in an asyncapi specification to golang structures code.
 type TypesGenerator struct {
 	asyncapi.Specification
+
+	// ChannelsOnly generates only the code of the channels (parameters,
+	// messages, paths and bindings), and WithoutChannels everything else.
+	ChannelsOnly    bool
+	WithoutChannels bool
+
+	// Codecs are registered for their content type by the generated code
+	Codecs []Codec
 }
 
 // Generate will create a new types code generator.
@@ -17,11 +27,16 @@ func (tg TypesGenerator) Generate() (string, error) {
 	tmplt, err := loadTemplate(
 		typesTemplatePath,
 		schemaDefinitionTemplatePath,
+		unionDefinitionTemplatePath,
+		enumDefinitionTemplatePath,
 		schemaNameTemplatePath,
 		messageTemplatePath,
 
 		marshalingAdditionalPropertiesTemplatePath,
 		marshalingTimeTemplatePath,
+		marshalingTextTemplatePath,
+		marshalingUnionsTemplatePath,
+		marshalingHeadersTemplatePath,
 	)
 	if err != nil {
 		return "", err
@@ -34,3 +49,60 @@ func (tg TypesGenerator) Generate() (string, error) {
 
 	return buf.String(), nil
 }
+
+// HasServerProtocol returns true if one of the servers of the specification uses
+// one of the protocols.
+func (tg TypesGenerator) HasServerProtocol(protocols ...string) bool {
+	for _, srv := range tg.Servers {
+		if slices.Contains(protocols, srv.Follow().Protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// DependenciesMessages returns the messages of the components of the
+// specification dependencies, by name. The messages whose name is already
+// used by the specification, or by a previous dependency, are not returned.
+func (tg TypesGenerator) DependenciesMessages() map[string]*asyncapi.Message {
+	names := make(map[string]bool)
+	for _, msg := range tg.Components.Messages {
+		names[msg.Name] = true
+	}
+
+	msgs := make(map[string]*asyncapi.Message)
+	for _, dep := range tg.Specification.Dependencies() {
+		for _, key := range utils.MapKeysToSortedList(dep.Components.Messages) {
+			msg := dep.Components.Messages[key]
+			if !names[msg.Name] {
+				names[msg.Name] = true
+				msgs[msg.Name] = msg
+			}
+		}
+	}
+
+	return msgs
+}
+
+// DependenciesSchemas returns the schemas of the components of the
+// specification dependencies, by name. The schemas whose name is already used
+// by the specification, or by a previous dependency, are not returned.
+func (tg TypesGenerator) DependenciesSchemas() map[string]*asyncapi.Schema {
+	names := make(map[string]bool)
+	for _, schema := range tg.Components.Schemas {
+		names[schema.Name] = true
+	}
+
+	schemas := make(map[string]*asyncapi.Schema)
+	for _, dep := range tg.Specification.Dependencies() {
+		for _, key := range utils.MapKeysToSortedList(dep.Components.Schemas) {
+			schema := dep.Components.Schemas[key]
+			if !names[schema.Name] {
+				names[schema.Name] = true
+				schemas[schema.Name] = schema
+			}
+		}
+	}
+
+	return schemas
+}
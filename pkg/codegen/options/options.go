@@ -8,6 +8,26 @@ type GeneratorOptions struct {
 	User bool
 	// Types should be true for type code (or common code) generation to be generated
 	Types bool
+	// Mocks should be true for the mocks of the subscribers and controllers to
+	// be generated (asyncapiv3 only)
+	Mocks bool
+	// Fixtures should be true for the messages built from the examples of the
+	// specification, with the helpers to send them, to be generated (asyncapiv3 only)
+	Fixtures bool
+	// Fuzz should be true for the fuzz targets of the subscriptions, fed with
+	// payloads built from the messages schemas, to be generated (asyncapiv3 only)
+	Fuzz bool
+
+	// Markdown and HTML should be true for the documentation of the
+	// specification to be generated in these formats (asyncapiv3 only)
+	Markdown bool
+	HTML     bool
+}
+
+// HasCode returns true if some golang code should be generated, and not only
+// documentation.
+func (g GeneratorOptions) HasCode() bool {
+	return g.Application || g.User || g.Types || g.Mocks || g.Fixtures || g.Fuzz
 }
 
 // Options is the struct that gather configuration of codegen.
@@ -18,13 +38,30 @@ type Options struct {
 	// PackageName is the package name of the generated code
 	PackageName string
 
+	// DocsOutputPath is the path to the generated documentation files, without
+	// their extension. Defaults to OutputPath without its extension, or to the
+	// 'asyncapi' file in the OutputPath directory if the code is split.
+	DocsOutputPath string
+
 	// Generate contains options regarding which golang code should be generated
 	Generate GeneratorOptions
 
+	// Split states if the code should be generated in several files, with
+	// OutputPath being their directory, instead of one file (asyncapiv3 only)
+	Split bool
+
+	// TemplateDir is the directory of the templates used in place of the
+	// default ones, with the same path (like 'controller.tmpl')
+	TemplateDir string
+
 	// DisableFormatting states if the formatting should be disabled when
 	// writing the generated code
 	DisableFormatting bool
 
+	// Check states if the generated files should be compared with the existing
+	// ones instead of being written, in order to detect the outdated ones
+	Check bool
+
 	// ConvertKeys defines a schema property keys conversion strategy.
 	// Supported values: snake, camel, kebab, none
 	ConvertKeys string
@@ -33,9 +70,31 @@ type Options struct {
 	// Supported values: camel, none
 	NamingScheme string
 
+	// Initialisms are the words, like ID or URL, written in upper case in the
+	// generated golang names
+	Initialisms []string
+
+	// OperationPrefix and OperationSuffix are added to the operations name, used
+	// in the generated methods names (asyncapiv3 only)
+	OperationPrefix string
+	OperationSuffix string
+
 	// IgnoreStringFormat states whether the properties' format (date, date-time) should impact the type in types
 	IgnoreStringFormat bool
 
+	// StringFormats are the formats of the strings generated with a specific type
+	// (all of them if nil). Supported values: date, date-time, uuid, duration
+	StringFormats []string
+
+	// Codecs are the codecs registered by the generated code, with the content
+	// types as keys and the import path of their package with their type name
+	// as values, like 'github.com/acme/codecs.YAML' (asyncapiv3 only)
+	Codecs map[string]string
+
 	// ForcePointers can be used to force all struct fields to be generated as pointers
 	ForcePointers bool
+
+	// OptionalFields defines how the optional struct fields are generated (asyncapiv3 only).
+	// Supported values: pointer, omitempty, option
+	OptionalFields string
 }
@@ -0,0 +1,51 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutdatedFiles is returned when checking the generated files, if some of
+// them would be changed by the generation.
+var ErrOutdatedFiles = errors.New("generated files are not up to date")
+
+// output writes the generated files or, in check mode, compares them with the
+// existing ones.
+type output struct {
+	check    bool
+	outdated []string
+}
+
+// write writes the file, creating its directory if needed, or checks that it
+// is up to date.
+func (o *output) write(path string, content []byte) error {
+	if !o.check {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, content, 0644)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err != nil || !bytes.Equal(current, content) {
+		o.outdated = append(o.outdated, path)
+	}
+
+	return nil
+}
+
+// err returns an error listing the outdated files, if any.
+func (o output) err() error {
+	if len(o.outdated) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOutdatedFiles, strings.Join(o.outdated, ", "))
+}
@@ -0,0 +1,534 @@
+// Package contract tests an application against its AsyncAPI specification,
+// by replaying the examples of the specification on the operations it
+// receives, and verifying that the messages it publishes in return respect
+// the specification.
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/parser"
+	asyncapi "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+var (
+	// ErrContractBroken is returned when the application doesn't respect the
+	// specification on some cases.
+	ErrContractBroken = fmt.Errorf("%w: contract broken", extensions.ErrAsyncAPI)
+)
+
+// DefaultTimeout is the default maximum duration of each case.
+const DefaultTimeout = time.Second
+
+// replyAddressPrefix is the prefix of the reply addresses of the replayed
+// examples, when the operation reply address is in the messages.
+const replyAddressPrefix = "contract.reply."
+
+// Params are the parameters of the contract tests.
+type Params struct {
+	// Specification is the path of the AsyncAPI v3 specification file
+	Specification string
+	// Start starts the tested application on the broker, subscribed to its
+	// channels, and returns the function stopping it.
+	Start func(ctx context.Context, broker extensions.BrokerController) (stop func(), err error)
+	// Timeout is the maximum duration of each case, DefaultTimeout if not set
+	Timeout time.Duration
+}
+
+// Run replays each example of the messages received by the application, as
+// described by the receive operations of the specification, and verifies that:
+//   - the application receives and acknowledges the message;
+//   - it replies if the operation has a reply, with the correlation ID of the
+//     example if the messages have one;
+//   - the messages it publishes are on the addresses of the channels of the
+//     specification, with payloads matching the messages schemas.
+//
+// The application is started once on an in-memory broker, and the cases are
+// replayed one after the other, by operation name and by message name.
+// The returned error is only set if the tests couldn't be run: the failures
+// of the application are in the report.
+func Run(ctx context.Context, params Params) (Report, error) {
+	if params.Timeout == 0 {
+		params.Timeout = DefaultTimeout
+	}
+	if params.Start == nil {
+		return Report{}, fmt.Errorf("%w: no function starting the application", extensions.ErrAsyncAPI)
+	}
+
+	spec, err := load(params.Specification)
+	if err != nil {
+		return Report{}, err
+	}
+
+	broker, err := inmemory.NewController()
+	if err != nil {
+		return Report{}, err
+	}
+	defer broker.Close()
+	rec := &recorder{Controller: broker}
+
+	stop, err := params.Start(ctx, rec)
+	if err != nil {
+		return Report{}, fmt.Errorf("starting the application: %w", err)
+	}
+	if stop != nil {
+		defer stop()
+	}
+
+	r := runner{spec: spec, broker: rec, timeout: params.Timeout}
+	report := Report{Name: spec.Info.Title}
+	for _, key := range utils.MapKeysToSortedList(spec.Operations) {
+		op := spec.Operations[key].Follow()
+		if op.Action != asyncapi.OperationActionIsReceive {
+			continue
+		}
+		report.Cases = append(report.Cases, r.operation(ctx, key, op)...)
+	}
+
+	return report, nil
+}
+
+// load parses the specification, with the files it references, and processes it.
+func load(path string) (*asyncapi.Specification, error) {
+	s, err := parser.FromFile(parser.FromFileParams{Path: path})
+	if err != nil {
+		return nil, err
+	}
+
+	var resolver parser.ReferencesResolver
+	if err := resolver.Resolve(s, path); err != nil {
+		return nil, err
+	}
+
+	spec, err := asyncapi.FromUnknownVersion(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", extensions.ErrAsyncAPI, err)
+	}
+
+	if err := spec.Process(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// runner replays the examples of the specification on the broker.
+type runner struct {
+	spec    *asyncapi.Specification
+	broker  *recorder
+	timeout time.Duration
+	sent    int
+}
+
+// operation replays the examples of the messages of the operation.
+func (r *runner) operation(ctx context.Context, key string, op *asyncapi.Operation) []Case {
+	var cases []Case
+	for _, msg := range operationMessages(op) {
+		for i, e := range msg.Examples {
+			e = e.Follow()
+
+			name := e.ExampleName
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+
+			c := Case{
+				Name:      key + "/" + msg.Name + "/" + name,
+				Operation: key,
+				Message:   msg.Name,
+				Example:   name,
+			}
+
+			start := time.Now()
+			c.Failures = r.replay(ctx, op, msg, e)
+			c.Duration = time.Since(start)
+
+			cases = append(cases, c)
+		}
+	}
+
+	return cases
+}
+
+// operationMessages returns the messages of the operation, or of its channel
+// if it has none, by name.
+func operationMessages(op *asyncapi.Operation) []*asyncapi.Message {
+	msgs := op.Messages
+	if len(msgs) == 0 {
+		msgs = utils.MapToList(op.Channel.Follow().Messages)
+	}
+
+	byName := make(map[string]*asyncapi.Message, len(msgs))
+	for _, msg := range msgs {
+		msg = msg.Follow()
+		byName[msg.Name] = msg
+	}
+
+	list := make([]*asyncapi.Message, 0, len(byName))
+	for _, name := range utils.MapKeysToSortedList(byName) {
+		list = append(list, byName[name])
+	}
+	return list
+}
+
+// replay publishes the example for the operation, and returns the failures of
+// the application.
+func (r *runner) replay(
+	ctx context.Context,
+	op *asyncapi.Operation,
+	msg *asyncapi.Message,
+	e *asyncapi.MessageExample,
+) []string {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.sent++
+
+	addr, err := channelAddress(op.Channel.Follow())
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	bm, err := exampleMessage(msg, e)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// Set the reply address in the message, if it is there and not in the example
+	replyAddr, err := r.replyAddress(op, &bm)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	// Publish the example, without recording it
+	r.broker.take()
+	deliveries := len(r.broker.Deliveries())
+	if err := r.broker.Controller.Publish(ctx, addr, bm); err != nil {
+		return []string{fmt.Sprintf("publishing the example on %q: %s", addr, err)}
+	}
+
+	var failures []string
+	if err := r.broker.WaitForAcknowledgements(ctx); err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	// Check the reception of the example
+	received := false
+	for _, d := range r.broker.Deliveries()[deliveries:] {
+		if d.Channel != addr {
+			continue
+		}
+		received = true
+		if d.Status == inmemory.DeliveryNaked {
+			failures = append(failures, "the message has been negatively acknowledged by the application")
+			break
+		}
+	}
+	if !received {
+		failures = append(failures, fmt.Sprintf("the message on %q has not been received by the application", addr))
+	}
+
+	return append(failures, r.checkPublications(op, msg, bm, replyAddr)...)
+}
+
+// channelAddress returns the address of the channel, with its parameters
+// replaced by their first example, their default value or their first
+// possible value.
+func channelAddress(ch *asyncapi.Channel) (string, error) {
+	addr := ch.Address
+	for name, p := range ch.Parameters {
+		p = p.Follow()
+
+		var value string
+		switch {
+		case len(p.Examples) > 0:
+			value = p.Examples[0]
+		case p.Default != "":
+			value = p.Default
+		case len(p.Enum) > 0:
+			value = p.Enum[0]
+		default:
+			return "", fmt.Errorf("no value for the parameter %q of the channel %q: "+
+				"it should have examples, a default value or an enum", name, ch.Name)
+		}
+
+		addr = strings.ReplaceAll(addr, "{"+name+"}", value)
+	}
+
+	if addr == "" {
+		return "", fmt.Errorf("the channel %q has no address", ch.Name)
+	}
+	return addr, nil
+}
+
+// exampleMessage returns the broker message of the example.
+func exampleMessage(msg *asyncapi.Message, e *asyncapi.MessageExample) (extensions.BrokerMessage, error) {
+	bm := extensions.BrokerMessage{
+		Headers:     make(map[string][]byte, len(e.Headers)),
+		ContentType: msg.ContentType,
+	}
+
+	for name, value := range e.Headers {
+		h, err := extensions.MarshalHeader(value)
+		if err != nil {
+			return bm, fmt.Errorf("encoding the header %q of the example: %w", name, err)
+		}
+		bm.Headers[name] = h
+	}
+
+	// Strings payloads are sent as is
+	if s, ok := e.Payload.(string); ok && isStringPayload(msg) {
+		bm.Payload = []byte(s)
+		return bm, nil
+	}
+
+	payload, err := extensions.MarshalPayload(msg.ContentType, e.Payload)
+	if err != nil {
+		return bm, fmt.Errorf("encoding the payload of the example: %w", err)
+	}
+	bm.Payload = payload
+
+	return bm, nil
+}
+
+// isStringPayload states if the payload of the message is a string, that is
+// sent as is instead of being encoded.
+func isStringPayload(msg *asyncapi.Message) bool {
+	return msg.Payload != nil && msg.Payload.Follow().Type == asyncapi.SchemaTypeIsString.String()
+}
+
+// replyAddress returns the address on which the reply of the operation is
+// expected, that is set in the message headers if the reply address is there
+// and not in the example. It returns an empty address if the operation has
+// no reply or if it is on the reply channel address.
+func (r *runner) replyAddress(op *asyncapi.Operation, bm *extensions.BrokerMessage) (string, error) {
+	if op.Reply == nil || op.Reply.Follow().Address == nil {
+		return "", nil
+	}
+
+	location := op.Reply.Follow().Address.Location
+	name, ok := strings.CutPrefix(location, "$message.header#/")
+	if !ok {
+		return "", fmt.Errorf("unsupported reply address location %q", location)
+	}
+
+	if addr := string(bm.Headers[name]); addr != "" {
+		return addr, nil
+	}
+
+	addr := replyAddressPrefix + strconv.Itoa(r.sent)
+	bm.Headers[name] = []byte(addr)
+	return addr, nil
+}
+
+// checkPublications checks the messages published by the application while
+// handling the example.
+func (r *runner) checkPublications(
+	op *asyncapi.Operation,
+	msg *asyncapi.Message,
+	bm extensions.BrokerMessage,
+	replyAddr string,
+) []string {
+	var failures []string
+	var replied bool
+	for _, pub := range r.broker.take() {
+		if op.Reply != nil && isReply(op.Reply.Follow(), pub.address, replyAddr) {
+			replied = true
+			failures = append(failures, checkReply(op.Reply.Follow(), msg, bm, pub)...)
+			continue
+		}
+
+		msgs := r.sentMessages(pub.address)
+		if msgs == nil {
+			failures = append(failures, fmt.Sprintf(
+				"a message has been published on %q, that is not the address of a sent channel", pub.address))
+			continue
+		}
+		if err := checkMessage(msgs, pub.message); err != nil {
+			failures = append(failures, fmt.Sprintf("the message published on %q %s", pub.address, err))
+		}
+	}
+
+	if op.Reply != nil && !replied {
+		failures = append(failures, "the application has not replied")
+	}
+
+	return failures
+}
+
+// isReply states if the address is the one of the reply.
+func isReply(reply *asyncapi.OperationReply, addr, replyAddr string) bool {
+	if replyAddr != "" {
+		return addr == replyAddr
+	}
+	return reply.Channel != nil && matchAddress(reply.Channel.Follow(), addr)
+}
+
+// checkReply checks the reply, and that it has the correlation ID of the
+// example if they both have one.
+func checkReply(
+	reply *asyncapi.OperationReply,
+	msg *asyncapi.Message,
+	bm extensions.BrokerMessage,
+	pub publication,
+) []string {
+	msgs := reply.Messages
+	if len(msgs) == 0 && reply.Channel != nil {
+		msgs = utils.MapToList(reply.Channel.Follow().Messages)
+	}
+
+	var failures []string
+	if err := checkMessage(msgs, pub.message); err != nil {
+		failures = append(failures, "the reply "+err.Error())
+	}
+
+	id, ok := correlationID(msg, bm)
+	if !ok {
+		return failures
+	}
+	for _, m := range msgs {
+		if replyID, ok := correlationID(m.Follow(), pub.message); ok && replyID != id {
+			failures = append(failures, fmt.Sprintf(
+				"the reply should have the correlation ID %q of the message, got %q", id, replyID))
+		}
+	}
+
+	return failures
+}
+
+// sentMessages returns the messages of the send operations on the channel
+// with the address, or nil if there is none.
+func (r *runner) sentMessages(addr string) []*asyncapi.Message {
+	var msgs []*asyncapi.Message
+	for _, op := range r.spec.Operations {
+		op = op.Follow()
+		if op.Action != asyncapi.OperationActionIsSend || !matchAddress(op.Channel.Follow(), addr) {
+			continue
+		}
+
+		if len(op.Messages) > 0 {
+			msgs = append(msgs, op.Messages...)
+		} else {
+			msgs = append(msgs, utils.MapToList(op.Channel.Follow().Messages)...)
+		}
+	}
+	return msgs
+}
+
+// matchAddress states if the address is the one of the channel, with its parameters.
+func matchAddress(ch *asyncapi.Channel, addr string) bool {
+	if ch.Address == "" {
+		return false
+	}
+	_, err := extensions.ParseChannelAddress(ch.Address, addr)
+	return err == nil
+}
+
+// checkMessage checks that the published message matches one of the messages,
+// with their required headers and their payload schema.
+func checkMessage(msgs []*asyncapi.Message, bm extensions.BrokerMessage) error {
+	var errs []string
+	for _, msg := range msgs {
+		msg = msg.Follow()
+
+		err := checkMessageSchemas(msg, bm)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 1 {
+		return fmt.Errorf("doesn't match the specification: %s", errs[0])
+	}
+	return fmt.Errorf("doesn't match any message of the specification: %s", strings.Join(errs, "; "))
+}
+
+func checkMessageSchemas(msg *asyncapi.Message, bm extensions.BrokerMessage) error {
+	if msg.Headers != nil {
+		for _, name := range msg.Headers.Follow().Required {
+			if _, ok := bm.Headers[name]; !ok {
+				return fmt.Errorf("%s: missing required header %q", msg.Name, name)
+			}
+		}
+	}
+
+	// The protobuf payloads are not validated, as they are not described by
+	// a JSON Schema
+	if msg.Payload == nil || msg.ExtProtobuf != nil {
+		return nil
+	}
+
+	data, err := payloadJSON(msg, bm)
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg.Name, err)
+	}
+
+	schema, err := msg.Payload.JSONSchema()
+	if err != nil {
+		return err
+	}
+	s, err := extensions.CompileJSONSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Validate(data); err != nil {
+		return fmt.Errorf("%s: %w", msg.Name, err)
+	}
+	return nil
+}
+
+// payloadJSON returns the payload of the broker message as JSON, in order to
+// be validated with the JSON Schema.
+func payloadJSON(msg *asyncapi.Message, bm extensions.BrokerMessage) ([]byte, error) {
+	if isStringPayload(msg) {
+		return json.Marshal(string(bm.Payload))
+	}
+
+	contentType := bm.ContentType
+	if contentType == "" {
+		contentType = msg.ContentType
+	}
+	if _, ok := extensions.CodecFor(contentType).(extensions.JSONCodec); ok {
+		return bm.Payload, nil
+	}
+
+	var v any
+	if err := extensions.UnmarshalPayload(contentType, bm.Payload, &v); err != nil {
+		return nil, fmt.Errorf("decoding the payload: %w", err)
+	}
+	return json.Marshal(v)
+}
+
+// correlationID returns the correlation ID of the broker message, if the
+// message has one in its headers or at the root of its payload.
+func correlationID(msg *asyncapi.Message, bm extensions.BrokerMessage) (string, bool) {
+	if !msg.CorrelationID.Exists() {
+		return "", false
+	}
+	location := msg.CorrelationID.Follow().Location
+
+	if name, ok := strings.CutPrefix(location, "$message.header#/"); ok {
+		id, exists := bm.Headers[name]
+		return string(id), exists && len(id) > 0
+	}
+
+	if name, ok := strings.CutPrefix(location, "$message.payload#/"); ok {
+		var payload map[string]any
+		if err := json.Unmarshal(bm.Payload, &payload); err != nil {
+			return "", false
+		}
+		id, exists := payload[name].(string)
+		return id, exists && id != ""
+	}
+
+	return "", false
+}
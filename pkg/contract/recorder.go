@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*recorder)(nil)
+	_ extensions.DelayedPublisher  = (*recorder)(nil)
+	_ extensions.PatternSubscriber = (*recorder)(nil)
+)
+
+// publication is a message published by the tested application.
+type publication struct {
+	address string
+	message extensions.BrokerMessage
+}
+
+// recorder is the in-memory broker given to the tested application, that
+// records the messages it publishes.
+type recorder struct {
+	*inmemory.Controller
+
+	mu           sync.Mutex
+	publications []publication
+}
+
+// Publish a message to the broker.
+func (r *recorder) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return r.PublishWithDelay(ctx, channel, bm, 0)
+}
+
+// PublishBatch publishes several messages.
+func (r *recorder) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		if err := r.PublishWithDelay(ctx, channel, bm, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishWithDelay publishes a message that will be delivered after the delay.
+func (r *recorder) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	r.mu.Lock()
+	r.publications = append(r.publications, publication{address: channel, message: bm})
+	r.mu.Unlock()
+
+	return r.Controller.PublishWithDelay(ctx, channel, bm, delay)
+}
+
+// take returns the recorded publications, and forgets them.
+func (r *recorder) take() []publication {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pubs := r.publications
+	r.publications = nil
+	return pubs
+}
@@ -0,0 +1,136 @@
+package contract
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Case is the result of the replay of an example of the specification.
+type Case struct {
+	// Name is the name of the case, like 'ping/Ping/hello'
+	Name      string
+	Operation string
+	Message   string
+	Example   string
+	Duration  time.Duration
+	// Failures are the reasons why the application doesn't respect the
+	// specification on this example, if any
+	Failures []string
+}
+
+// Failed states if the application doesn't respect the specification on the case.
+func (c Case) Failed() bool {
+	return len(c.Failures) > 0
+}
+
+// Report is the result of the contract tests of an application.
+type Report struct {
+	// Name is the name of the tested specification, from its title
+	Name  string
+	Cases []Case
+}
+
+// Failures returns the number of failed cases.
+func (r Report) Failures() int {
+	var n int
+	for _, c := range r.Cases {
+		if c.Failed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Err returns an error listing the failed cases, or nil if the application
+// respects the specification on all of them.
+func (r Report) Err() error {
+	var failures []string
+	for _, c := range r.Cases {
+		for _, f := range c.Failures {
+			failures = append(failures, c.Name+": "+f)
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d case(s) failed:\n%s",
+		ErrContractBroken, r.Failures(), len(r.Cases), strings.Join(failures, "\n"))
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report in the JUnit XML format, that can be read by
+// the continuous integration tools. Each case is a test case, with the
+// operation as class name.
+func (r Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:     r.Name,
+		Tests:    len(r.Cases),
+		Failures: r.Failures(),
+		Cases:    make([]junitTestCase, 0, len(r.Cases)),
+	}
+
+	var total time.Duration
+	for _, c := range r.Cases {
+		total += c.Duration
+
+		tc := junitTestCase{
+			Name:      c.Name,
+			ClassName: c.Operation,
+			Time:      junitTime(c.Duration),
+		}
+		if c.Failed() {
+			tc.Failure = &junitFailure{
+				Message: c.Failures[0],
+				Text:    strings.Join(c.Failures, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime returns the duration in seconds, as in the JUnit XML format.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,93 @@
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// AckPolicy is the way the controllers acknowledge the received messages once
+// handled. By default, the messages handled without error are acknowledged,
+// and the others are negatively acknowledged.
+type AckPolicy struct {
+	// ManualAck disables the acknowledgment of the messages handled without
+	// error: they should be acknowledged with AckFromContext.
+	ManualAck bool
+	// ManualNak disables the negative acknowledgment of the messages whose
+	// handling failed: they can be negatively acknowledged with NakFromContext,
+	// or be redelivered by the broker depending on its implementation.
+	ManualNak bool
+}
+
+// MessageAcknowledgment acknowledges a received message, from the subscription
+// function with AckFromContext or NakFromContext, or from the controller. Only
+// the first acknowledgment has an effect, and it is safe for concurrent use.
+type MessageAcknowledgment struct {
+	mutex sync.Mutex
+	done  bool
+	msg   *AcknowledgeableBrokerMessage
+	onAck func()
+	onNak func()
+}
+
+// NewMessageAcknowledgment creates the acknowledgment of a received message,
+// with functions called on its acknowledgment (e.g. to record metrics).
+func NewMessageAcknowledgment(msg *AcknowledgeableBrokerMessage, onAck, onNak func()) *MessageAcknowledgment {
+	return &MessageAcknowledgment{msg: msg, onAck: onAck, onNak: onNak}
+}
+
+// Ack acknowledges the message, if it has not been acknowledged yet.
+func (a *MessageAcknowledgment) Ack() {
+	a.acknowledge(a.msg.Ack, a.onAck)
+}
+
+// Nak negatively acknowledges the message, if it has not been acknowledged yet.
+func (a *MessageAcknowledgment) Nak() {
+	a.acknowledge(a.msg.Nak, a.onNak)
+}
+
+func (a *MessageAcknowledgment) acknowledge(fn, callback func()) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.done {
+		return
+	}
+	a.done = true
+
+	fn()
+	if callback != nil {
+		callback()
+	}
+}
+
+// AckFromContext acknowledges the received message of the context given to the
+// subscription functions, if it has not been acknowledged yet. It returns an
+// error wrapping ErrNoMessageAcknowledgment if there is no such message.
+func AckFromContext(ctx context.Context) error {
+	a, err := acknowledgmentFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	a.Ack()
+	return nil
+}
+
+// NakFromContext negatively acknowledges the received message of the context
+// given to the subscription functions, as AckFromContext acknowledges it.
+func NakFromContext(ctx context.Context) error {
+	a, err := acknowledgmentFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	a.Nak()
+	return nil
+}
+
+func acknowledgmentFromContext(ctx context.Context) (*MessageAcknowledgment, error) {
+	a, ok := ctx.Value(ContextKeyIsAcknowledgment).(*MessageAcknowledgment)
+	if !ok || a == nil {
+		return nil, fmt.Errorf("%w: no received message in context", ErrNoMessageAcknowledgment)
+	}
+	return a, nil
+}
@@ -0,0 +1,57 @@
+package extensions
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	baggageMutex sync.RWMutex
+	baggage      = map[string]any{}
+)
+
+// RegisterBaggage registers a context key whose value, as a string, is sent in
+// the header of the messages published with this context, and set back in the
+// context of their reception. It can be used for metadata like a user ID, a
+// locale or a request ID, that should follow a message across the services.
+//
+// Registering another key with the same header replaces the existing one.
+func RegisterBaggage(key any, header string) {
+	baggageMutex.Lock()
+	defer baggageMutex.Unlock()
+
+	baggage[header] = key
+}
+
+// InjectBaggage sets the headers of the message with the values of the
+// registered context keys, the ones that are already set being kept.
+func InjectBaggage(ctx context.Context, msg *BrokerMessage) {
+	baggageMutex.RLock()
+	defer baggageMutex.RUnlock()
+
+	for header, key := range baggage {
+		value, ok := ctx.Value(key).(string)
+		if !ok || len(msg.Headers[header]) > 0 {
+			continue
+		}
+
+		if msg.Headers == nil {
+			msg.Headers = make(map[string][]byte, len(baggage))
+		}
+		msg.Headers[header] = []byte(value)
+	}
+}
+
+// ExtractBaggage returns the context with the values of the registered context
+// keys, from the headers of the received message.
+func ExtractBaggage(ctx context.Context, msg BrokerMessage) context.Context {
+	baggageMutex.RLock()
+	defer baggageMutex.RUnlock()
+
+	for header, key := range baggage {
+		if value, exists := msg.Headers[header]; exists {
+			ctx = context.WithValue(ctx, key, string(value))
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,56 @@
+package extensions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBaggageSuite(t *testing.T) {
+	suite.Run(t, new(BaggageSuite))
+}
+
+type BaggageSuite struct {
+	suite.Suite
+}
+
+type baggageKey string
+
+func (suite *BaggageSuite) SetupSuite() {
+	RegisterBaggage(baggageKey("user"), "baggage-user")
+	RegisterBaggage(baggageKey("locale"), "baggage-locale")
+}
+
+func (suite *BaggageSuite) TestInjectBaggage() {
+	ctx := context.WithValue(context.Background(), baggageKey("user"), "alice")
+	ctx = context.WithValue(ctx, baggageKey("locale"), "fr-FR")
+
+	var msg BrokerMessage
+	InjectBaggage(ctx, &msg)
+	suite.Require().Equal(map[string][]byte{
+		"baggage-user":   []byte("alice"),
+		"baggage-locale": []byte("fr-FR"),
+	}, msg.Headers)
+
+	// The headers already set are kept
+	msg = BrokerMessage{Headers: map[string][]byte{"baggage-user": []byte("bob")}}
+	InjectBaggage(ctx, &msg)
+	suite.Require().Equal("bob", string(msg.Headers["baggage-user"]))
+	suite.Require().Equal("fr-FR", string(msg.Headers["baggage-locale"]))
+
+	// The values that are not strings are not sent
+	msg = BrokerMessage{}
+	InjectBaggage(context.WithValue(context.Background(), baggageKey("user"), 42), &msg)
+	suite.Require().Empty(msg.Headers)
+}
+
+func (suite *BaggageSuite) TestExtractBaggage() {
+	ctx := ExtractBaggage(context.Background(), BrokerMessage{Headers: map[string][]byte{
+		"baggage-user": []byte("alice"),
+		"other":        []byte("value"),
+	}})
+
+	suite.Require().Equal("alice", ctx.Value(baggageKey("user")))
+	suite.Require().Nil(ctx.Value(baggageKey("locale")))
+}
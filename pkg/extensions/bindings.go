@@ -0,0 +1,127 @@
+package extensions
+
+import "time"
+
+// ChannelBindings is the protocol-specific information of a channel, as
+// described in the AsyncAPI specification.
+//
+// It is set by the generated controllers in the context passed to the broker
+// controller, under the ContextKeyIsChannelBindings key.
+type ChannelBindings struct {
+	AMQP  *AMQPChannelBindings
+	Kafka *KafkaChannelBindings
+}
+
+// AMQPChannelBindings is the AMQP 0-9-1 specific information of a channel.
+type AMQPChannelBindings struct {
+	// Is is the type of the channel: either "routingKey" when the channel
+	// address is a routing key on an exchange, or "queue" when it is a queue.
+	Is       string
+	Exchange AMQPExchangeBindings
+	Queue    AMQPQueueBindings
+}
+
+// AMQPExchangeBindings is the exchange information of an AMQP 0-9-1 channel.
+type AMQPExchangeBindings struct {
+	Name       string
+	Type       string
+	Durable    bool
+	AutoDelete bool
+	VHost      string
+}
+
+// AMQPQueueBindings is the queue information of an AMQP 0-9-1 channel.
+type AMQPQueueBindings struct {
+	Name       string
+	Durable    bool
+	Exclusive  bool
+	AutoDelete bool
+	VHost      string
+}
+
+// KafkaChannelBindings is the Kafka specific information of a channel.
+type KafkaChannelBindings struct {
+	// Topic is the name of the Kafka topic, if different from the channel address.
+	Topic      string
+	Partitions int // Number of partitions of the topic, 0 if not set
+	Replicas   int // Number of replicas of each partition, 0 if not set
+}
+
+// OperationBindings is the protocol-specific information of an operation, as
+// described in the AsyncAPI specification.
+//
+// It is set by the generated controllers in the context passed to the broker
+// controller, under the ContextKeyIsOperationBindings key.
+type OperationBindings struct {
+	AMQP *AMQPOperationBindings
+	MQTT *MQTTOperationBindings
+	NATS *NATSOperationBindings
+}
+
+// AMQPOperationBindings is the AMQP 0-9-1 specific information of an operation.
+type AMQPOperationBindings struct {
+	Expiration   int // Time to live of the message, in milliseconds
+	UserID       string
+	CC           []string
+	Priority     int
+	DeliveryMode int // 1 for transient, 2 for persistent
+	Mandatory    bool
+	BCC          []string
+	Timestamp    bool
+	Ack          bool
+}
+
+// MQTTOperationBindings is the MQTT specific information of an operation.
+type MQTTOperationBindings struct {
+	QoS    int // 0 for at most once, 1 for at least once, 2 for exactly once
+	Retain bool
+}
+
+// NATSOperationBindings is the NATS specific information of an operation.
+type NATSOperationBindings struct {
+	// JetStreamConsumer overrides the configuration of the NATS JetStream
+	// consumer for the operation, from the x-jetstream-consumer extension.
+	JetStreamConsumer *JetStreamConsumerBindings
+}
+
+// JetStream deliver policies.
+const (
+	// JetStreamDeliverAll delivers all the messages of the stream.
+	JetStreamDeliverAll = "all"
+	// JetStreamDeliverLast delivers from the last message of the stream.
+	JetStreamDeliverLast = "last"
+	// JetStreamDeliverNew delivers the messages published after the consumer creation.
+	JetStreamDeliverNew = "new"
+	// JetStreamDeliverLastPerSubject delivers from the last message of each subject.
+	JetStreamDeliverLastPerSubject = "lastPerSubject"
+)
+
+// JetStreamConsumerBindings is the configuration of a NATS JetStream consumer.
+// Zero values keep the configuration of the controller.
+type JetStreamConsumerBindings struct {
+	Durable       string
+	AckWait       time.Duration
+	MaxDeliver    int
+	DeliverPolicy string // One of the JetStreamDeliver* policies
+	BackOff       []time.Duration
+}
+
+// Last value cache stores.
+const (
+	// LastValueCacheStoreKV keeps the last values in a key-value store.
+	LastValueCacheStoreKV = "kv"
+	// LastValueCacheStoreObject keeps the last values in an object store.
+	LastValueCacheStoreObject = "object"
+)
+
+// LastValueCache is the configuration of the cache keeping the last messages
+// published on each address of a channel, as described with the
+// x-last-value-cache extension in the AsyncAPI specification.
+//
+// It is set by the generated controllers in the context passed to the broker
+// controller, under the ContextKeyIsLastValueCache key.
+type LastValueCache struct {
+	Store   string // LastValueCacheStoreKV or LastValueCacheStoreObject
+	Bucket  string
+	History int // Number of values kept per address, for key-value stores
+}
@@ -0,0 +1,218 @@
+// Package bridge republishes the messages of a broker controller to another,
+// like from RabbitMQ to Kafka during a migration.
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrInvalidRule is raised when a rule of the bridge is invalid, like when
+	// its destination has parameters that are not in its source.
+	ErrInvalidRule = fmt.Errorf("%w: invalid bridge rule", extensions.ErrAsyncAPI)
+
+	// ErrAlreadyStarted is raised when starting a bridge that is already started.
+	ErrAlreadyStarted = fmt.Errorf("%w: bridge already started", extensions.ErrAsyncAPI)
+)
+
+var parameterRegexp = regexp.MustCompile("{([^{}]*)}")
+
+// Rule is a channel whose messages are republished to another channel.
+type Rule struct {
+	// From is the address of the channel subscribed on the source. With
+	// parameters, like 'user.{userId}.signedup', all its addresses are
+	// subscribed with a pattern subscription.
+	From string
+	// To is the address of the channel where the messages are republished on
+	// the destination. It can contain the parameters of From, like
+	// 'users-{userId}', that are replaced by their value. If empty, the
+	// messages are republished on the address where they are received.
+	To string
+	// Transform modifies the messages before their republication, if set. It
+	// can return extensions.ErrSkipMessage to not republish a message.
+	Transform func(ctx context.Context, bm extensions.BrokerMessage) (extensions.BrokerMessage, error)
+}
+
+// validate checks that the parameters of the destination are in the source.
+func (r Rule) validate() error {
+	if r.From == "" {
+		return fmt.Errorf("%w: no source channel", ErrInvalidRule)
+	}
+
+	for _, m := range parameterRegexp.FindAllStringSubmatch(r.To, -1) {
+		if !strings.Contains(r.From, m[0]) {
+			return fmt.Errorf("%w: parameter %q of %q is not in %q", ErrInvalidRule, m[1], r.To, r.From)
+		}
+	}
+	return nil
+}
+
+// destination returns the address where a message received at the address is
+// republished.
+func (r Rule) destination(addr string) (string, error) {
+	if r.To == "" {
+		return addr, nil
+	}
+	if !parameterRegexp.MatchString(r.To) {
+		return r.To, nil
+	}
+
+	params, err := extensions.ParseChannelAddress(r.From, addr)
+	if err != nil {
+		return "", err
+	}
+	return parameterRegexp.ReplaceAllStringFunc(r.To, func(p string) string {
+		return params[p[1:len(p)-1]]
+	}), nil
+}
+
+// Bridge subscribes to the channels of a source broker controller, and
+// republishes their messages to a destination broker controller.
+//
+// A received message is acknowledged once republished, and negatively
+// acknowledged if it can't be republished. The messages of a rule are
+// republished in their order of reception.
+type Bridge struct {
+	source      extensions.BrokerController
+	destination extensions.BrokerController
+	rules       []Rule
+	logger      extensions.Logger
+
+	mu            sync.Mutex
+	subscriptions []extensions.BrokerChannelSubscription
+	running       sync.WaitGroup
+}
+
+// BridgeOption is an option of the Bridge.
+type BridgeOption func(bridge *Bridge)
+
+// NewBridge creates a bridge from the source to the destination, for the
+// channels of the rules.
+func NewBridge(
+	source, destination extensions.BrokerController,
+	rules []Rule,
+	options ...BridgeOption,
+) *Bridge {
+	b := &Bridge{
+		source:      source,
+		destination: destination,
+		rules:       rules,
+		logger:      extensions.DummyLogger{},
+	}
+
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+// WithLogger sets the logger of the messages that can't be republished.
+func WithLogger(logger extensions.Logger) BridgeOption {
+	return func(bridge *Bridge) {
+		bridge.logger = logger
+	}
+}
+
+// Start subscribes to the channels of the rules on the source, and republishes
+// their messages until the bridge is closed. If a subscription fails, the
+// previous ones are canceled.
+func (b *Bridge) Start(ctx context.Context) error {
+	for _, r := range b.rules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscriptions != nil {
+		return ErrAlreadyStarted
+	}
+
+	subs := make([]extensions.BrokerChannelSubscription, 0, len(b.rules))
+	for _, r := range b.rules {
+		var sub extensions.BrokerChannelSubscription
+		var err error
+		if parameterRegexp.MatchString(r.From) {
+			sub, err = extensions.SubscribeToPattern(ctx, b.source, r.From)
+		} else {
+			sub, err = b.source.Subscribe(ctx, r.From)
+		}
+		if err != nil {
+			for _, s := range subs {
+				s.Cancel(ctx)
+			}
+			return fmt.Errorf("subscribing to %q: %w", r.From, err)
+		}
+		subs = append(subs, sub)
+	}
+
+	for i, sub := range subs {
+		b.running.Add(1)
+		go b.republish(ctx, b.rules[i], sub)
+	}
+	b.subscriptions = subs
+
+	return nil
+}
+
+// Close cancels the subscriptions, and waits for the messages being
+// republished. The bridge can then be started again.
+func (b *Bridge) Close(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subscriptions {
+		sub.Cancel(ctx)
+	}
+	b.running.Wait()
+	b.subscriptions = nil
+}
+
+// republish republishes the messages of the subscription to the destination.
+func (b *Bridge) republish(ctx context.Context, r Rule, sub extensions.BrokerChannelSubscription) {
+	defer b.running.Done()
+
+	for msg := range sub.MessagesChannel() {
+		msgCtx := context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, msg)
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsChannel, r.From)
+
+		switch err := b.republishMessage(msgCtx, r, msg.BrokerMessage); {
+		case err == nil, errors.Is(err, extensions.ErrSkipMessage):
+			msg.Ack()
+		default:
+			b.logger.Error(msgCtx, fmt.Sprintf("message from %q not republished: %s", r.From, err))
+			msg.Nak()
+		}
+	}
+}
+
+func (b *Bridge) republishMessage(ctx context.Context, r Rule, bm extensions.BrokerMessage) error {
+	addr := r.From
+	if bm.Address != "" {
+		addr = bm.Address
+	}
+	to, err := r.destination(addr)
+	if err != nil {
+		return err
+	}
+
+	// The address of the reception is not part of the republished message
+	bm.Address = ""
+	if r.Transform != nil {
+		if bm, err = r.Transform(ctx, bm); err != nil {
+			return err
+		}
+	}
+
+	return b.destination.Publish(ctx, to, bm)
+}
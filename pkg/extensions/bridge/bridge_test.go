@@ -0,0 +1,135 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBridgeSuite(t *testing.T) {
+	suite.Run(t, new(BridgeSuite))
+}
+
+type BridgeSuite struct {
+	suite.Suite
+	source      *inmemory.Controller
+	destination *inmemory.Controller
+}
+
+func (suite *BridgeSuite) SetupTest() {
+	var err error
+	suite.source, err = inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.destination, err = inmemory.NewController()
+	suite.Require().NoError(err)
+}
+
+// bridge starts a bridge with the rules, that is closed at the end of the test.
+func (suite *BridgeSuite) bridge(rules ...Rule) {
+	b := NewBridge(suite.source, suite.destination, rules)
+	suite.Require().NoError(b.Start(context.Background()))
+	suite.T().Cleanup(func() { b.Close(context.Background()) })
+}
+
+// publish publishes the message on the source, and waits for its acknowledgment.
+func (suite *BridgeSuite) publish(channel string, bm extensions.BrokerMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	suite.Require().NoError(suite.source.Publish(ctx, channel, bm))
+	suite.Require().NoError(suite.source.WaitForAcknowledgements(ctx))
+}
+
+func (suite *BridgeSuite) TestRepublish() {
+	suite.bridge(Rule{From: "orders", To: "orders-v2"})
+
+	sent := extensions.BrokerMessage{
+		Headers:     map[string][]byte{"h": []byte("v")},
+		Payload:     []byte(`{"id":1}`),
+		ContentType: "application/json",
+		Key:         "1",
+	}
+	suite.publish("orders", sent)
+
+	suite.Require().Equal([]extensions.BrokerMessage{sent}, suite.destination.Published("orders-v2"))
+	suite.Require().Equal(inmemory.DeliveryAcked, suite.source.Deliveries()[0].Status)
+}
+
+func (suite *BridgeSuite) TestRepublishWithParameters() {
+	suite.bridge(
+		Rule{From: "user.{userId}.signedup", To: "users-{userId}"},
+		Rule{From: "user.{userId}.deleted"},
+	)
+
+	suite.publish("user.1234.signedup", extensions.BrokerMessage{Headers: map[string][]byte{}, Payload: []byte("a")})
+	suite.publish("user.5678.deleted", extensions.BrokerMessage{Headers: map[string][]byte{}, Payload: []byte("b")})
+
+	// The address of the reception is removed
+	suite.Require().Equal([]extensions.BrokerMessage{{Headers: map[string][]byte{}, Payload: []byte("a")}},
+		suite.destination.Published("users-1234"))
+	suite.Require().Equal([]extensions.BrokerMessage{{Headers: map[string][]byte{}, Payload: []byte("b")}},
+		suite.destination.Published("user.5678.deleted"))
+}
+
+func (suite *BridgeSuite) TestTransform() {
+	suite.bridge(Rule{
+		From: "in",
+		To:   "out",
+		Transform: func(_ context.Context, bm extensions.BrokerMessage) (extensions.BrokerMessage, error) {
+			switch string(bm.Payload) {
+			case "skip":
+				return bm, extensions.ErrSkipMessage
+			case "fail":
+				return bm, errors.New("failure")
+			}
+			bm.Payload = []byte(strings.ToUpper(string(bm.Payload)))
+			return bm, nil
+		},
+	})
+
+	for _, p := range []string{"hello", "skip", "fail"} {
+		suite.publish("in", extensions.BrokerMessage{Headers: map[string][]byte{}, Payload: []byte(p)})
+	}
+
+	suite.Require().Equal([]extensions.BrokerMessage{{Headers: map[string][]byte{}, Payload: []byte("HELLO")}},
+		suite.destination.Published("out"))
+
+	// The skipped message is acknowledged, and the failed one negatively acknowledged
+	deliveries := suite.source.Deliveries()
+	suite.Require().Len(deliveries, 3)
+	suite.Require().Equal(inmemory.DeliveryAcked, deliveries[1].Status)
+	suite.Require().Equal(inmemory.DeliveryNaked, deliveries[2].Status)
+}
+
+func (suite *BridgeSuite) TestClose() {
+	b := NewBridge(suite.source, suite.destination, []Rule{{From: "in", To: "out"}})
+	suite.Require().NoError(b.Start(context.Background()))
+	suite.Require().ErrorIs(b.Start(context.Background()), ErrAlreadyStarted)
+	b.Close(context.Background())
+
+	// The messages are not republished anymore
+	suite.Require().NoError(suite.source.Publish(context.Background(), "in", extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+	}))
+	suite.Require().Empty(suite.destination.Published("out"))
+
+	// And it can be started again
+	suite.Require().NoError(b.Start(context.Background()))
+	b.Close(context.Background())
+}
+
+func (suite *BridgeSuite) TestInvalidRules() {
+	for _, r := range []Rule{
+		{To: "out"},
+		{From: "user.{userId}", To: "users-{id}"},
+	} {
+		b := NewBridge(suite.source, suite.destination, []Rule{r})
+		suite.Require().ErrorIs(b.Start(context.Background()), ErrInvalidRule)
+	}
+}
@@ -3,13 +3,36 @@ package extensions
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// ReplyCancellationTimeout is the maximum time waited for the release of the
+// subscription to the reply channel of a request, once the reply is received
+// or the request has timed out.
+const ReplyCancellationTimeout = 10 * time.Second
+
 // BrokerChannelSubscription is a struct that contains every returned structures
 // when subscribing a channel.
 type BrokerChannelSubscription struct {
 	messages chan AcknowledgeableBrokerMessage
 	cancel   chan any
+	done     chan struct{}
+	flow     FlowController
+}
+
+// FlowController can be set by the brokers on a subscription, in order to stop
+// and restart the delivery of its messages by the broker when it is paused and
+// resumed.
+type FlowController interface {
+	// Pause stops the delivery of the messages by the broker. It should not
+	// fail if the delivery is already stopped.
+	Pause(ctx context.Context) error
+	// Resume restarts the delivery of the messages by the broker. It should
+	// not fail if the delivery has not been stopped.
+	Resume(ctx context.Context) error
 }
 
 // NewBrokerChannelSubscription creates a new broker channel subscription based
@@ -21,9 +44,36 @@ func NewBrokerChannelSubscription(
 	return BrokerChannelSubscription{
 		messages: messages,
 		cancel:   cancel,
+		done:     make(chan struct{}),
 	}
 }
 
+// WithFlowController returns the subscription with a controller of the delivery
+// of its messages by the broker. It should only be used by the broker, before
+// returning the subscription.
+func (bcs BrokerChannelSubscription) WithFlowController(flow FlowController) BrokerChannelSubscription {
+	bcs.flow = flow
+	return bcs
+}
+
+// Pause stops the delivery of the messages by the broker, if it has set a flow
+// controller. Otherwise, the messages are buffered until they are read.
+func (bcs BrokerChannelSubscription) Pause(ctx context.Context) error {
+	if bcs.flow == nil {
+		return nil
+	}
+	return bcs.flow.Pause(ctx)
+}
+
+// Resume restarts the delivery of the messages by the broker, if it has set a
+// flow controller.
+func (bcs BrokerChannelSubscription) Resume(ctx context.Context) error {
+	if bcs.flow == nil {
+		return nil
+	}
+	return bcs.flow.Resume(ctx)
+}
+
 // TransmitReceivedMessage should only be used by the broker to transmit the
 // new received messages to the user.
 func (bcs BrokerChannelSubscription) TransmitReceivedMessage(msg AcknowledgeableBrokerMessage) {
@@ -49,8 +99,8 @@ func (bcs BrokerChannelSubscription) WaitForCancellationAsync(cleanup func()) {
 		// Close messages in order to avoid new messages
 		close(bcs.messages)
 
-		// Close cancel to let listeners know that the cancellation is complete
-		close(bcs.cancel)
+		// Let listeners know that the cancellation is complete
+		close(bcs.done)
 	}()
 }
 
@@ -58,20 +108,105 @@ func (bcs BrokerChannelSubscription) WaitForCancellationAsync(cleanup func()) {
 // up on broker, which will return when finished to avoid dangling resources, such
 // as non-existent queue listeners on (broker) server side.
 func (bcs BrokerChannelSubscription) Cancel(ctx context.Context) {
-	// Send a cancellation request
-	bcs.cancel <- true
+	// Send a cancellation request, unless the subscription is already cancelled.
+	// It is sent even if the context is done, when it doesn't block.
+	select {
+	case <-bcs.done:
+		return
+	case bcs.cancel <- true:
+	default:
+		select {
+		case bcs.cancel <- true:
+		case <-bcs.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
 
 	// Wait for the cancellation to be effective
 	select {
-	case <-bcs.cancel:
+	case <-bcs.done:
 	case <-ctx.Done():
 	}
 }
 
+// WrapBrokerChannelSubscription returns a subscription receiving the messages
+// of the subscription through the transmit function, that can modify, drop or
+// duplicate them by calling send any number of times. Send can also be called
+// from other goroutines, after transmit returns: the messages sent once the
+// subscription is canceled are dropped. Pausing, resuming and canceling the
+// returned subscription do the same on the wrapped one.
+func WrapBrokerChannelSubscription(
+	sub BrokerChannelSubscription,
+	transmit func(msg AcknowledgeableBrokerMessage, send func(AcknowledgeableBrokerMessage)),
+) BrokerChannelSubscription {
+	messages := make(chan AcknowledgeableBrokerMessage, max(cap(sub.messages), 1))
+	wrapped := NewBrokerChannelSubscription(messages, make(chan any, 1)).WithFlowController(sub)
+
+	// The messages are not sent anymore once stopped, so the messages channel
+	// can be closed after the end of the forwarding and of the pending sends
+	var (
+		mu      sync.Mutex
+		stopped bool
+		sending sync.WaitGroup
+	)
+	stop, forwarded := make(chan struct{}), make(chan struct{})
+	send := func(msg AcknowledgeableBrokerMessage) {
+		mu.Lock()
+		if stopped {
+			mu.Unlock()
+			return
+		}
+		sending.Add(1)
+		mu.Unlock()
+		defer sending.Done()
+
+		select {
+		case messages <- msg:
+		case <-stop:
+		}
+	}
+	go func() {
+		defer close(forwarded)
+		for msg := range sub.MessagesChannel() {
+			transmit(msg, send)
+		}
+	}()
+
+	wrapped.WaitForCancellationAsync(func() {
+		close(stop)
+		sub.Cancel(context.Background())
+		<-forwarded
+
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+		sending.Wait()
+	})
+
+	return wrapped
+}
+
 // BrokerMessage is a wrapper that will contain all information regarding a message.
 type BrokerMessage struct {
 	Headers map[string][]byte
 	Payload []byte
+
+	// ContentType is the media type of the payload (e.g. "application/json").
+	// It is set from the AsyncAPI message content type, and can be overridden
+	// before publication. Brokers may leave it empty on reception if unknown.
+	ContentType string
+
+	// Key is the key used by the brokers to partition the messages (e.g. the
+	// Kafka record key), so messages with the same key keep their order. It is
+	// set from the 'x-partition-key' extension of the AsyncAPI message, if any.
+	Key string
+
+	// Address is the address where the message has been received. It is set by
+	// the brokers on the messages received from a subscription with wildcards,
+	// like the pattern subscriptions.
+	Address string
 }
 
 // IsUninitialized check if the BrokerMessage is at zero value, i.e. the
@@ -102,6 +237,10 @@ func (bm BrokerMessage) String() string {
 type AcknowledgeableBrokerMessage struct {
 	BrokerMessage
 
+	// Metadata is the delivery metadata of the received message, if set by
+	// the broker.
+	Metadata *MessageMetadata
+
 	acked          bool
 	acknowledgment BrokerAcknowledgment
 }
@@ -115,19 +254,29 @@ func NewAcknowledgeableBrokerMessage(
 	return AcknowledgeableBrokerMessage{BrokerMessage: bm, acknowledgment: acknowledgment}
 }
 
+// WithMetadata returns the message with the delivery metadata set by the
+// broker on reception.
+func (bm AcknowledgeableBrokerMessage) WithMetadata(md MessageMetadata) AcknowledgeableBrokerMessage {
+	bm.Metadata = &md
+	return bm
+}
+
 // Ack will call the AckMessage of the underlying BrokerAcknowledgment
-// implementation if the message was not already acked.
+// implementation if the message was not already acked. It has no effect on
+// a message without BrokerAcknowledgment (e.g. a message that failed to be
+// published).
 func (bm *AcknowledgeableBrokerMessage) Ack() {
-	if !bm.acked {
+	if !bm.acked && bm.acknowledgment != nil {
 		bm.acknowledgment.AckMessage()
 		bm.acked = true
 	}
 }
 
 // Nak will call the NakMessage of the underlying BrokerAcknowledgment
-// implementation if the message was not already acked.
+// implementation if the message was not already acked. It has no effect on
+// a message without BrokerAcknowledgment.
 func (bm *AcknowledgeableBrokerMessage) Nak() {
-	if !bm.acked {
+	if !bm.acked && bm.acknowledgment != nil {
 		bm.acknowledgment.NakMessage()
 		bm.acked = true
 	}
@@ -139,10 +288,117 @@ type BrokerController interface {
 	// Publish a message to the broker
 	Publish(ctx context.Context, channel string, mw BrokerMessage) error
 
+	// PublishBatch publishes several messages to the broker at once
+	PublishBatch(ctx context.Context, channel string, mws []BrokerMessage) error
+
 	// Subscribe to messages from the broker
 	Subscribe(ctx context.Context, channel string) (BrokerChannelSubscription, error)
 }
 
+// DelayedPublisher is implemented by the broker controllers that can deliver
+// a published message after a delay.
+type DelayedPublisher interface {
+	// PublishWithDelay publishes a message that will be delivered after the delay
+	PublishWithDelay(ctx context.Context, channel string, mw BrokerMessage, delay time.Duration) error
+}
+
+// BrokerRequester is implemented by the broker controllers with a native
+// request/reply mechanism. It is used instead of subscribing to the reply
+// address when this address is dynamic and set in the message headers.
+type BrokerRequester interface {
+	// Request publishes a message and waits for its reply. The broker sets its
+	// own reply address in the header set under ContextKeyIsReplyAddressHeader
+	// of the received messages, where the replier will send the reply.
+	Request(ctx context.Context, channel string, mw BrokerMessage) (BrokerMessage, error)
+}
+
+// PatternSubscriber is implemented by the broker controllers able to subscribe
+// to all the addresses of a channel with parameters, with their wildcards.
+type PatternSubscriber interface {
+	// SubscribeToPattern subscribes to all the addresses matching the channel
+	// address with its parameters, like 'user.{userId}.signedup'. The address
+	// of each received message is set in its Address field.
+	SubscribeToPattern(ctx context.Context, channelAddr string) (BrokerChannelSubscription, error)
+}
+
+// SubscribeToPattern subscribes to all the addresses matching the channel
+// address with its parameters. The broker controller has to implement
+// PatternSubscriber, otherwise ErrPatternSubscriptionNotSupported is returned.
+func SubscribeToPattern(
+	ctx context.Context,
+	broker BrokerController,
+	channelAddr string,
+) (BrokerChannelSubscription, error) {
+	subscriber, ok := broker.(PatternSubscriber)
+	if !ok {
+		return BrokerChannelSubscription{}, ErrPatternSubscriptionNotSupported
+	}
+	return subscriber.SubscribeToPattern(ctx, channelAddr)
+}
+
+// DefaultReplyAddressPrefix is the prefix of the reply addresses created for
+// the requests, when the broker controller doesn't provide them.
+const DefaultReplyAddressPrefix = "reply."
+
+// ReplyAddressProvider is implemented by the broker controllers providing the
+// temporary reply addresses of the requests, like inboxes or exclusive queues.
+type ReplyAddressProvider interface {
+	// NewReplyAddress returns a new address, unique to a request, where its
+	// reply can be received. The resources behind it should be released when
+	// the subscription to this address is canceled.
+	NewReplyAddress(ctx context.Context) (string, error)
+}
+
+// NewReplyAddress returns a new address, unique to a request, where its reply
+// can be received. It is provided by the broker controller if it implements
+// ReplyAddressProvider, otherwise it is the DefaultReplyAddressPrefix followed
+// by a random UUID.
+func NewReplyAddress(ctx context.Context, broker BrokerController) (string, error) {
+	if provider, ok := broker.(ReplyAddressProvider); ok {
+		return provider.NewReplyAddress(ctx)
+	}
+	return DefaultReplyAddressPrefix + uuid.NewString(), nil
+}
+
+// Publish publishes a message on the broker controller. If a delay is set in
+// the context under ContextKeyIsDelay, the broker controller has to implement
+// DelayedPublisher, otherwise ErrDelayedPublishNotSupported is returned.
+func Publish(ctx context.Context, broker BrokerController, channel string, mw BrokerMessage) error {
+	var delay time.Duration
+	IfContextSetWith(ctx, ContextKeyIsDelay, func(d time.Duration) {
+		delay = d
+	})
+	if delay <= 0 {
+		return broker.Publish(ctx, channel, mw)
+	}
+
+	dp, ok := broker.(DelayedPublisher)
+	if !ok {
+		return ErrDelayedPublishNotSupported
+	}
+	return dp.PublishWithDelay(ctx, channel, mw, delay)
+}
+
+// PublishBatch publishes several messages at once on the broker controller. If
+// a delay is set in the context under ContextKeyIsDelay, the messages are
+// published one by one with this delay, as for Publish.
+func PublishBatch(ctx context.Context, broker BrokerController, channel string, mws []BrokerMessage) error {
+	var delay time.Duration
+	IfContextSetWith(ctx, ContextKeyIsDelay, func(d time.Duration) {
+		delay = d
+	})
+	if delay <= 0 {
+		return broker.PublishBatch(ctx, channel, mws)
+	}
+
+	for _, mw := range mws {
+		if err := Publish(ctx, broker, channel, mw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // BrokerAcknowledgment represents the function that should be implemented to acknowledge a
 // message from subscriber to the broker.
 // Some brokers may do not support naks so is it up to the broker implementation to handle naks correctly.
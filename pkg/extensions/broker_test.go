@@ -1,7 +1,10 @@
 package extensions
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -21,3 +24,168 @@ func (suite *BrokerSuite) TestIsUninitialized() {
 		Headers: make(map[string][]byte),
 	}.IsUninitialized())
 }
+
+func (suite *BrokerSuite) TestCancelSubscription() {
+	for i := 0; i < 100; i++ {
+		cleaned := false
+		bcs := NewBrokerChannelSubscription(make(chan AcknowledgeableBrokerMessage, 1), make(chan any, 1))
+
+		// Cancel right after waiting for the cancellation, that may not have
+		// started yet
+		bcs.WaitForCancellationAsync(func() { cleaned = true })
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		bcs.Cancel(ctx)
+		suite.Require().NoError(ctx.Err())
+		suite.Require().True(cleaned)
+
+		_, open := <-bcs.MessagesChannel()
+		suite.Require().False(open)
+
+		// A second cancellation returns immediately
+		bcs.Cancel(ctx)
+		suite.Require().NoError(ctx.Err())
+		cancel()
+	}
+}
+
+func (suite *BrokerSuite) TestCancelSubscriptionWithDoneContext() {
+	bcs := NewBrokerChannelSubscription(make(chan AcknowledgeableBrokerMessage, 1), make(chan any, 1))
+	bcs.WaitForCancellationAsync(func() {})
+
+	// The cancellation is requested even if the context is already done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bcs.Cancel(ctx)
+
+	select {
+	case _, open := <-bcs.MessagesChannel():
+		suite.Require().False(open)
+	case <-time.After(time.Second):
+		suite.FailNow("subscription not cancelled")
+	}
+}
+
+func (suite *BrokerSuite) TestWrapBrokerChannelSubscription() {
+	sub := NewBrokerChannelSubscription(make(chan AcknowledgeableBrokerMessage, 4), make(chan any, 1))
+	cleaned := make(chan struct{})
+	sub.WaitForCancellationAsync(func() { close(cleaned) })
+
+	// Duplicate the messages, and drop the ones without payload
+	var later func(AcknowledgeableBrokerMessage)
+	wrapped := WrapBrokerChannelSubscription(sub, func(
+		msg AcknowledgeableBrokerMessage,
+		send func(AcknowledgeableBrokerMessage),
+	) {
+		later = send
+		if len(msg.Payload) > 0 {
+			send(msg)
+			send(msg)
+		}
+	})
+
+	sub.TransmitReceivedMessage(AcknowledgeableBrokerMessage{})
+	sub.TransmitReceivedMessage(AcknowledgeableBrokerMessage{BrokerMessage: BrokerMessage{Payload: []byte("1")}})
+	for i := 0; i < 2; i++ {
+		msg := <-wrapped.MessagesChannel()
+		suite.Require().Equal("1", string(msg.Payload))
+	}
+
+	// The cancellation ends the transmission, even of the pending messages
+	sub.TransmitReceivedMessage(AcknowledgeableBrokerMessage{BrokerMessage: BrokerMessage{Payload: []byte("2")}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	wrapped.Cancel(ctx)
+	suite.Require().NoError(ctx.Err())
+
+	<-cleaned
+	for msg := range wrapped.MessagesChannel() {
+		suite.Require().Equal("2", string(msg.Payload))
+	}
+
+	// The messages sent after the cancellation are dropped
+	later(AcknowledgeableBrokerMessage{BrokerMessage: BrokerMessage{Payload: []byte("3")}})
+}
+
+// publishRecorder is a broker controller that records the delays of its publications.
+type publishRecorder struct {
+	delays []time.Duration
+}
+
+func (pr *publishRecorder) Publish(_ context.Context, _ string, _ BrokerMessage) error {
+	pr.delays = append(pr.delays, 0)
+	return nil
+}
+
+func (pr *publishRecorder) PublishBatch(_ context.Context, _ string, mws []BrokerMessage) error {
+	for range mws {
+		pr.delays = append(pr.delays, 0)
+	}
+	return nil
+}
+
+func (pr *publishRecorder) Subscribe(_ context.Context, _ string) (BrokerChannelSubscription, error) {
+	return BrokerChannelSubscription{}, nil
+}
+
+// delayedPublishRecorder is a publishRecorder that supports delayed publications.
+type delayedPublishRecorder struct {
+	publishRecorder
+}
+
+func (dpr *delayedPublishRecorder) PublishWithDelay(
+	_ context.Context,
+	_ string,
+	_ BrokerMessage,
+	delay time.Duration,
+) error {
+	dpr.delays = append(dpr.delays, delay)
+	return nil
+}
+
+func (suite *BrokerSuite) TestPublishWithDelay() {
+	delayed := context.WithValue(context.Background(), ContextKeyIsDelay, time.Second)
+
+	broker := &delayedPublishRecorder{}
+	suite.Require().NoError(Publish(context.Background(), broker, "channel", BrokerMessage{}))
+	suite.Require().NoError(Publish(delayed, broker, "channel", BrokerMessage{}))
+	suite.Require().Equal([]time.Duration{0, time.Second}, broker.delays)
+
+	// Broker without delayed publish support
+	suite.Require().ErrorIs(
+		Publish(delayed, &publishRecorder{}, "channel", BrokerMessage{}),
+		ErrDelayedPublishNotSupported)
+}
+
+func (suite *BrokerSuite) TestPublishBatchWithDelay() {
+	delayed := context.WithValue(context.Background(), ContextKeyIsDelay, time.Second)
+	msgs := []BrokerMessage{{}, {}}
+
+	broker := &delayedPublishRecorder{}
+	suite.Require().NoError(PublishBatch(context.Background(), broker, "channel", msgs))
+	suite.Require().NoError(PublishBatch(delayed, broker, "channel", msgs))
+	suite.Require().Equal([]time.Duration{0, 0, time.Second, time.Second}, broker.delays)
+}
+
+// replyAddressRecorder is a publishRecorder providing its reply addresses.
+type replyAddressRecorder struct {
+	publishRecorder
+}
+
+func (rar *replyAddressRecorder) NewReplyAddress(_ context.Context) (string, error) {
+	return "inbox", nil
+}
+
+func (suite *BrokerSuite) TestNewReplyAddress() {
+	// Broker providing the reply addresses
+	addr, err := NewReplyAddress(context.Background(), &replyAddressRecorder{})
+	suite.Require().NoError(err)
+	suite.Require().Equal("inbox", addr)
+
+	// Broker without reply addresses: they should be unique
+	first, err := NewReplyAddress(context.Background(), &publishRecorder{})
+	suite.Require().NoError(err)
+	second, err := NewReplyAddress(context.Background(), &publishRecorder{})
+	suite.Require().NoError(err)
+	suite.Require().True(strings.HasPrefix(first, DefaultReplyAddressPrefix), first)
+	suite.Require().NotEqual(first, second)
+}
@@ -8,4 +8,20 @@ const (
 	// BrokerMessagesQueueSize is the size of the broker messages queue that
 	// will hold the messages processed from the broker to the universal format.
 	BrokerMessagesQueueSize = 64
+
+	// ContentTypeHeaderKey is the header used to transmit the content type of
+	// the messages with brokers that don't support it natively.
+	ContentTypeHeaderKey = "content-type"
 )
+
+// ExtractContentType removes the content type header from the headers and
+// returns its value, or an empty string if there is none.
+func ExtractContentType(headers map[string][]byte) string {
+	ct, ok := headers[ContentTypeHeaderKey]
+	if !ok {
+		return ""
+	}
+
+	delete(headers, ContentTypeHeaderKey)
+	return string(ct)
+}
@@ -0,0 +1,549 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Controller)(nil)
+	_ extensions.DelayedPublisher  = (*Controller)(nil)
+	_ extensions.PatternSubscriber = (*Controller)(nil)
+)
+
+// DeliveryStatus is the status of the delivery of a message to a subscription.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is the status of a delivered message that has not been
+	// acknowledged yet.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryAcked is the status of a delivered message that has been acknowledged.
+	DeliveryAcked DeliveryStatus = "acked"
+	// DeliveryNaked is the status of a delivered message that has been negatively
+	// acknowledged.
+	DeliveryNaked DeliveryStatus = "naked"
+	// DeliveryDropped is the status of a message that has been dropped instead
+	// of being delivered.
+	DeliveryDropped DeliveryStatus = "dropped"
+)
+
+// Delivery is the delivery of a published message to a subscription.
+type Delivery struct {
+	Channel string
+	Message extensions.BrokerMessage
+	// Attempt is the number of the delivery of the message to the subscription,
+	// starting at 1 and incremented on each redelivery.
+	Attempt int
+	Status  DeliveryStatus
+}
+
+// Controller is the in-memory implementation for asyncapi-codegen, that can be
+// used to test the generated code without any broker. Messages are delivered
+// to the subscriptions of the same controller.
+type Controller struct {
+	logger          extensions.Logger
+	minDelay        time.Duration
+	maxDelay        time.Duration
+	dropRate        float64
+	maxRedeliveries int
+
+	mu            sync.Mutex
+	rand          *rand.Rand
+	closed        bool
+	subscriptions map[string][]*subscription
+	patterns      map[string][]*subscription
+	published     map[string][]extensions.BrokerMessage
+	deliveries    []Delivery
+	scheduled     int
+	changed       chan struct{}
+}
+
+// ControllerOption is a function that can be used to configure an in-memory controller
+// Examples: WithDeliveryDelay(), WithDropRate(), WithLogger().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a new in-memory controller.
+func NewController(options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		logger:        extensions.DummyLogger{},
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		subscriptions: make(map[string][]*subscription),
+		patterns:      make(map[string][]*subscription),
+		published:     make(map[string][]extensions.BrokerMessage),
+		changed:       make(chan struct{}),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	return controller, nil
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithDeliveryDelay set the delay before the messages are delivered to the
+// subscriptions, picked randomly between minDelay and maxDelay for each
+// delivery. Without it, messages are delivered before the publication returns.
+func WithDeliveryDelay(minDelay, maxDelay time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if minDelay < 0 || maxDelay < minDelay {
+			return fmt.Errorf("invalid delivery delay: from %s to %s", minDelay, maxDelay)
+		}
+		controller.minDelay, controller.maxDelay = minDelay, maxDelay
+		return nil
+	}
+}
+
+// WithDropRate set the rate, between 0 and 1, of the deliveries that will be
+// dropped instead of transmitted to the subscriptions.
+func WithDropRate(rate float64) ControllerOption {
+	return func(controller *Controller) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("drop rate should be between 0 and 1: %f", rate)
+		}
+		controller.dropRate = rate
+		return nil
+	}
+}
+
+// WithMaxRedeliveries set the number of times a naked message is delivered
+// again to its subscription. Without it, naked messages are not redelivered.
+func WithMaxRedeliveries(n int) ControllerOption {
+	return func(controller *Controller) error {
+		if n < 0 {
+			return fmt.Errorf("max redeliveries should not be negative: %d", n)
+		}
+		controller.maxRedeliveries = n
+		return nil
+	}
+}
+
+// WithSeed set the seed used to pick the delivery delays and the dropped
+// deliveries, in order to get reproducible tests.
+func WithSeed(seed int64) ControllerOption {
+	return func(controller *Controller) error {
+		controller.rand = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishWithDelay(ctx, channel, bm, 0)
+}
+
+// PublishBatch publishes several messages.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		if err := c.PublishWithDelay(ctx, channel, bm, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishWithDelay publishes a message that will be delivered after the delay,
+// in addition to the delivery delay of the controller.
+func (c *Controller) PublishWithDelay(
+	_ context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	// Get the subscriptions at the time of publication
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fmt.Errorf("controller is closed")
+	}
+	c.published[channel] = append(c.published[channel], bm)
+	subs := append([]*subscription(nil), c.subscriptions[channel]...)
+	patternSubs := c.patternSubscriptions(channel)
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		c.deliver(s, Delivery{Channel: channel, Message: bm, Attempt: 1}, delay)
+	}
+
+	// The messages received from pattern subscriptions have their address
+	patternMsg := bm
+	patternMsg.Address = channel
+	for _, s := range patternSubs {
+		c.deliver(s, Delivery{Channel: channel, Message: patternMsg, Attempt: 1}, delay)
+	}
+
+	return nil
+}
+
+// deliver transmits the message to the subscription after the delivery delay,
+// unless it is dropped.
+func (c *Controller) deliver(s *subscription, d Delivery, delay time.Duration) {
+	c.mu.Lock()
+	delay += c.deliveryDelay()
+
+	// Drop the delivery
+	if c.dropRate > 0 && c.rand.Float64() < c.dropRate {
+		d.Status = DeliveryDropped
+		c.deliveries = append(c.deliveries, d)
+		c.notify()
+		c.mu.Unlock()
+
+		c.logger.Info(context.Background(), "message dropped",
+			extensions.LogInfo{Key: "channel", Value: d.Channel},
+			extensions.LogInfo{Key: "attempt", Value: d.Attempt})
+		return
+	}
+
+	// Deliver right away
+	if delay <= 0 {
+		c.mu.Unlock()
+		s.transmit(c, d)
+		return
+	}
+
+	// Deliver after the delay
+	c.scheduled++
+	c.mu.Unlock()
+	time.AfterFunc(delay, func() {
+		c.mu.Lock()
+		closed := c.closed
+		c.mu.Unlock()
+
+		if !closed {
+			s.transmit(c, d)
+		}
+		c.unschedule()
+	})
+}
+
+// unschedule ends a scheduled delivery.
+func (c *Controller) unschedule() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scheduled--
+	c.notify()
+}
+
+// deliveryDelay returns a random delay between the minimum and maximum delivery
+// delays. The controller mutex should be held.
+func (c *Controller) deliveryDelay() time.Duration {
+	if c.maxDelay <= c.minDelay {
+		return c.minDelay
+	}
+	return c.minDelay + time.Duration(c.rand.Int63n(int64(c.maxDelay-c.minDelay)+1))
+}
+
+// record adds a pending delivery and returns its ID.
+func (c *Controller) record(d Delivery) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d.Status = DeliveryPending
+	c.deliveries = append(c.deliveries, d)
+	c.notify()
+
+	return len(c.deliveries) - 1
+}
+
+// settle sets the status of a pending delivery, unless it was already
+// acknowledged.
+func (c *Controller) settle(id int, status DeliveryStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.deliveries[id].Status != DeliveryPending {
+		return
+	}
+	c.deliveries[id].Status = status
+	c.notify()
+}
+
+// nak sets a pending delivery as naked. If it should be delivered again, it
+// returns the redelivery, that is scheduled until delivered.
+func (c *Controller) nak(id int) (Delivery, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.deliveries[id].Status != DeliveryPending {
+		return Delivery{}, false
+	}
+	c.deliveries[id].Status = DeliveryNaked
+	c.notify()
+
+	d := c.deliveries[id]
+	if d.Attempt > c.maxRedeliveries {
+		return Delivery{}, false
+	}
+	d.Attempt++
+	c.scheduled++
+
+	return d, true
+}
+
+// notify wakes up the goroutines waiting for a change of the deliveries. The
+// controller mutex should be held.
+func (c *Controller) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Published returns the messages published on the channel.
+func (c *Controller) Published(channel string) []extensions.BrokerMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]extensions.BrokerMessage(nil), c.published[channel]...)
+}
+
+// Deliveries returns the deliveries of the published messages to the
+// subscriptions, in the order they happened.
+func (c *Controller) Deliveries() []Delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Delivery(nil), c.deliveries...)
+}
+
+// WaitForAcknowledgements waits until all the published messages have been
+// delivered, and all the deliveries have been acknowledged or dropped.
+func (c *Controller) WaitForAcknowledgements(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		settled := c.scheduled == 0
+		for _, d := range c.deliveries {
+			if d.Status == DeliveryPending {
+				settled = false
+				break
+			}
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		if settled {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("messages not acknowledged: %w", ctx.Err())
+		}
+	}
+}
+
+// Subscribe to messages from the broker.
+func (c *Controller) Subscribe(_ context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	// Create a new subscription
+	s := newSubscription()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("controller is closed")
+	}
+	c.subscriptions[channel] = append(c.subscriptions[channel], s)
+	c.mu.Unlock()
+
+	// Wait for cancellation and remove the subscription
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+		c.removeSubscription(channel, s)
+	})
+
+	return s.sub, nil
+}
+
+// SubscribeToPattern subscribes to all the addresses matching the channel
+// address with its parameters.
+func (c *Controller) SubscribeToPattern(
+	_ context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	// Create a new subscription
+	s := newSubscription()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("controller is closed")
+	}
+	c.patterns[channelAddr] = append(c.patterns[channelAddr], s)
+	c.mu.Unlock()
+
+	// Wait for cancellation and remove the subscription
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		removeSubscription(c.patterns, channelAddr, s)
+	})
+
+	return s.sub, nil
+}
+
+// patternSubscriptions returns the pattern subscriptions matching the channel.
+// The controller mutex should be held.
+func (c *Controller) patternSubscriptions(channel string) []*subscription {
+	var subs []*subscription
+	for channelAddr, patternSubs := range c.patterns {
+		if _, err := extensions.ParseChannelAddress(channelAddr, channel); err == nil {
+			subs = append(subs, patternSubs...)
+		}
+	}
+	return subs
+}
+
+// removeSubscription removes the subscription from the channel.
+func (c *Controller) removeSubscription(channel string, s *subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removeSubscription(c.subscriptions, channel, s)
+}
+
+// removeSubscription removes the subscription from the subscriptions of the
+// channel.
+func removeSubscription(subscriptions map[string][]*subscription, channel string, s *subscription) {
+	subs := subscriptions[channel]
+	for i, sub := range subs {
+		if sub == s {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(subs) > 0 {
+		subscriptions[channel] = subs
+	} else {
+		delete(subscriptions, channel)
+	}
+}
+
+// Healthy returns an error if the controller is closed.
+func (c *Controller) Healthy(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("controller is closed")
+	}
+	return nil
+}
+
+// Close closes everything related to the broker: the scheduled deliveries are
+// abandoned and no more messages can be published.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+}
+
+// subscription is a subscription of the user on a channel.
+type subscription struct {
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	mu      sync.Mutex
+	stopped bool
+	sending sync.WaitGroup
+	done    chan struct{}
+}
+
+func newSubscription() *subscription {
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	return &subscription{
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		done:     make(chan struct{}),
+	}
+}
+
+// transmit records the delivery and transmits the message to the user, unless
+// the subscription is stopped. If it is stopped while waiting for the user to
+// read the messages, the delivery is dropped.
+func (s *subscription) transmit(c *Controller, d Delivery) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.sending.Add(1)
+	s.mu.Unlock()
+	defer s.sending.Done()
+
+	id := c.record(d)
+	select {
+	case s.messages <- extensions.NewAcknowledgeableBrokerMessage(
+		d.Message,
+		AcknowledgementHandler{controller: c, subscription: s, id: id},
+	):
+	case <-s.done:
+		c.settle(id, DeliveryDropped)
+	}
+}
+
+// stop prevents new messages from being transmitted and waits for the pending
+// transmissions, before the messages channel is closed.
+func (s *subscription) stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	s.sending.Wait()
+}
+
+var _ extensions.BrokerAcknowledgment = (*AcknowledgementHandler)(nil)
+
+// AcknowledgementHandler for in-memory broker, that records the acknowledgements
+// in the deliveries of the controller.
+type AcknowledgementHandler struct {
+	controller   *Controller
+	subscription *subscription
+	id           int
+}
+
+// AckMessage acknowledges the message.
+func (h AcknowledgementHandler) AckMessage() {
+	h.controller.settle(h.id, DeliveryAcked)
+}
+
+// NakMessage negatively acknowledges the message, that is delivered again to
+// the subscription if it has not reached the maximum number of redeliveries.
+func (h AcknowledgementHandler) NakMessage() {
+	d, ok := h.controller.nak(h.id)
+	if !ok {
+		return
+	}
+
+	// Redeliver from another goroutine, as the caller can be the one reading
+	// the messages of the subscription
+	go func() {
+		h.controller.deliver(h.subscription, d, 0)
+		h.controller.unschedule()
+	}()
+}
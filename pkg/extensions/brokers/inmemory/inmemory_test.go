@@ -0,0 +1,245 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func receive(t *testing.T, sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+func TestOptionsValidation(t *testing.T) {
+	_, err := NewController(WithDeliveryDelay(-time.Second, time.Second))
+	assert.Error(t, err)
+
+	_, err = NewController(WithDeliveryDelay(time.Second, time.Millisecond))
+	assert.Error(t, err)
+
+	_, err = NewController(WithDropRate(1.5))
+	assert.Error(t, err)
+
+	_, err = NewController(WithMaxRedeliveries(-1))
+	assert.Error(t, err)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	c, err := NewController()
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub1, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	sub2, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	bm := extensions.BrokerMessage{
+		Headers:     map[string][]byte{"id": []byte("1")},
+		ContentType: "text/plain",
+		Payload:     []byte("order"),
+	}
+	require.NoError(t, c.PublishBatch(context.Background(), "orders", []extensions.BrokerMessage{bm}))
+	require.NoError(t, c.Publish(context.Background(), "invoices", bm))
+	assert.Equal(t, []extensions.BrokerMessage{bm}, c.Published("orders"))
+
+	// Each subscription receives the message
+	msg := receive(t, sub1)
+	assert.Equal(t, bm, msg.BrokerMessage)
+	msg.Ack()
+	msg = receive(t, sub2)
+	msg.Nak()
+
+	require.NoError(t, c.WaitForAcknowledgements(context.Background()))
+	assert.Equal(t, []Delivery{
+		{Channel: "orders", Message: bm, Attempt: 1, Status: DeliveryAcked},
+		{Channel: "orders", Message: bm, Attempt: 1, Status: DeliveryNaked},
+	}, c.Deliveries())
+}
+
+func TestDeliveryDelay(t *testing.T) {
+	c, err := NewController(WithDeliveryDelay(50*time.Millisecond, 100*time.Millisecond))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	assert.Empty(t, c.Deliveries())
+
+	msg := receive(t, sub)
+	msg.Ack()
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Delay from the context is added to the controller one
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsDelay, 100*time.Millisecond)
+	start = time.Now()
+	require.NoError(t, extensions.Publish(ctx, c, "orders", extensions.BrokerMessage{}))
+
+	msg = receive(t, sub)
+	msg.Ack()
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestDropRate(t *testing.T) {
+	c, err := NewController(WithDropRate(1))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	require.NoError(t, c.WaitForAcknowledgements(context.Background()))
+	assert.Len(t, sub.MessagesChannel(), 0)
+	assert.Equal(t, []Delivery{
+		{Channel: "orders", Message: extensions.BrokerMessage{}, Attempt: 1, Status: DeliveryDropped},
+	}, c.Deliveries())
+}
+
+func TestSeed(t *testing.T) {
+	dropped := func() []DeliveryStatus {
+		c, err := NewController(WithDropRate(0.5), WithSeed(42))
+		require.NoError(t, err)
+		defer c.Close()
+
+		sub, err := c.Subscribe(context.Background(), "orders")
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+		}
+		for len(sub.MessagesChannel()) > 0 {
+			msg := <-sub.MessagesChannel()
+			msg.Ack()
+		}
+
+		var statuses []DeliveryStatus
+		for _, d := range c.Deliveries() {
+			statuses = append(statuses, d.Status)
+		}
+		return statuses
+	}
+
+	assert.Equal(t, dropped(), dropped())
+}
+
+func TestRedeliveries(t *testing.T) {
+	c, err := NewController(WithMaxRedeliveries(1))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+
+	// Naked message is delivered again, only once
+	msg := receive(t, sub)
+	msg.Nak()
+	msg = receive(t, sub)
+	msg.Nak()
+	assert.Len(t, sub.MessagesChannel(), 0)
+
+	require.NoError(t, c.WaitForAcknowledgements(context.Background()))
+	deliveries := c.Deliveries()
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, 1, deliveries[0].Attempt)
+	assert.Equal(t, 2, deliveries[1].Attempt)
+}
+
+func TestFullSubscription(t *testing.T) {
+	c, err := NewController(WithMaxRedeliveries(1))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	for i := 0; i < brokers.BrokerMessagesQueueSize; i++ {
+		require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	}
+
+	// The redelivery of a naked message doesn't block the reader
+	msg := receive(t, sub)
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	msg.Nak()
+
+	// The subscription can be cancelled while the publication is blocked
+	published := make(chan error, 1)
+	go func() {
+		published <- c.Publish(context.Background(), "orders", extensions.BrokerMessage{})
+	}()
+	assert.Eventually(t, func() bool {
+		return len(c.Deliveries()) == brokers.BrokerMessagesQueueSize+3
+	}, time.Second, time.Millisecond, "the redelivery and the publication should wait for the reader")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sub.Cancel(ctx)
+	require.NoError(t, ctx.Err())
+	require.NoError(t, <-published)
+
+	// The messages that were not transmitted are dropped
+	dropped := 0
+	for _, d := range c.Deliveries() {
+		if d.Status == DeliveryDropped {
+			dropped++
+		}
+	}
+	assert.Equal(t, 2, dropped)
+}
+
+func TestWaitForAcknowledgements(t *testing.T) {
+	c, err := NewController()
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+
+	// Message is not acknowledged yet
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.WaitForAcknowledgements(ctx), context.DeadlineExceeded)
+
+	msg := receive(t, sub)
+	go msg.Ack()
+	assert.NoError(t, c.WaitForAcknowledgements(context.Background()))
+}
+
+func TestClose(t *testing.T) {
+	c, err := NewController()
+	require.NoError(t, err)
+	c.Close()
+
+	assert.Error(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	_, err = c.Subscribe(context.Background(), "orders")
+	assert.Error(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController(WithMaxRedeliveries(1))
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		Redelivery: true,
+	})
+}
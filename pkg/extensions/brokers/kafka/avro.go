@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"encoding/binary"
+
+	"github.com/hamba/avro/v2"
+	avrocodec "github.com/lerenn/asyncapi-codegen/pkg/extensions/codecs/avro"
+)
+
+const (
+	// wireFormatMagicByte is the first byte of the payloads encoded with the
+	// Confluent Schema Registry wire format.
+	wireFormatMagicByte = 0
+	// wireFormatHeaderSize is the size of the magic byte and the schema ID that
+	// prefix the Avro data in the Confluent Schema Registry wire format.
+	wireFormatHeaderSize = 5
+)
+
+// encodeWireFormat encodes the JSON payload with the Avro schema, prefixed with
+// the magic byte and the schema ID.
+func encodeWireFormat(schema avro.Schema, id int, payload []byte) ([]byte, error) {
+	data, err := avrocodec.FromJSON(schema, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, wireFormatHeaderSize, wireFormatHeaderSize+len(data))
+	header[0] = wireFormatMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return append(header, data...), nil
+}
+
+// wireFormatSchemaID returns the schema ID of the payload, or false if it is
+// not encoded with the Confluent Schema Registry wire format.
+func wireFormatSchemaID(payload []byte) (int, bool) {
+	if len(payload) < wireFormatHeaderSize || payload[0] != wireFormatMagicByte {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(payload[1:wireFormatHeaderSize])), true
+}
+
+// decodeWireFormat decodes the Avro data of the payload (without its header)
+// with the schema, and returns it as JSON.
+func decodeWireFormat(schema avro.Schema, payload []byte) ([]byte, error) {
+	return avrocodec.ToJSON(schema, payload[wireFormatHeaderSize:])
+}
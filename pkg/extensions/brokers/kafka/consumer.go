@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/segmentio/kafka-go"
+)
+
+// StartOffset is the offset from which a consumer group starts to read a
+// partition when it has no committed offset yet.
+type StartOffset int64
+
+const (
+	// StartOffsetEarliest starts from the first message of the partition. This
+	// is the default.
+	StartOffsetEarliest StartOffset = StartOffset(kafka.FirstOffset)
+	// StartOffsetLatest starts from the messages published after the subscription.
+	StartOffsetLatest StartOffset = StartOffset(kafka.LastOffset)
+)
+
+// PartitionsHandler is a function called when partitions of a topic are
+// assigned to or revoked from the controller by the consumer group.
+type PartitionsHandler func(ctx context.Context, topic string, partitions []int)
+
+// WithStartOffset set the offset from which the partitions are read when the
+// consumer group has no committed offset for them.
+func WithStartOffset(offset StartOffset) ControllerOption {
+	return func(controller *Controller) {
+		controller.startOffset = offset
+	}
+}
+
+// WithCommitInterval set the interval at which the offsets are committed to
+// Kafka. If not set or zero, the offsets are committed synchronously, which is
+// always the case with partitions handlers.
+func WithCommitInterval(interval time.Duration) ControllerOption {
+	return func(controller *Controller) {
+		controller.commitInterval = interval
+	}
+}
+
+// WithPartitionsAssignedHandler set a function called with the partitions
+// assigned to the controller, each time the consumer group is rebalanced.
+func WithPartitionsAssignedHandler(handler PartitionsHandler) ControllerOption {
+	return func(controller *Controller) {
+		controller.onPartitionsAssigned = handler
+	}
+}
+
+// WithPartitionsRevokedHandler set a function called with the partitions
+// revoked from the controller, before the consumer group is rebalanced. The
+// messages of these partitions that are not committed yet will be received
+// again by their new consumer.
+func WithPartitionsRevokedHandler(handler PartitionsHandler) ControllerOption {
+	return func(controller *Controller) {
+		controller.onPartitionsRevoked = handler
+	}
+}
+
+// hasRebalanceHandlers returns true if partitions handlers have been set on
+// the controller.
+func (c *Controller) hasRebalanceHandlers() bool {
+	return c.onPartitionsAssigned != nil || c.onPartitionsRevoked != nil
+}
+
+// subscribeWithConsumerGroup subscribes to the channel by managing the consumer
+// group generations, in order to call the partitions handlers on rebalance.
+func (c *Controller) subscribeWithConsumerGroup(
+	ctx context.Context,
+	channel string,
+) (extensions.BrokerChannelSubscription, error) {
+	if c.groupID == "" {
+		return extensions.BrokerChannelSubscription{},
+			fmt.Errorf("%w: partitions handlers need a group ID", extensions.ErrAsyncAPI)
+	}
+
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:          c.groupID,
+		Brokers:     c.hosts,
+		Dialer:      c.dialer,
+		Topics:      []string{channel},
+		StartOffset: int64(c.startOffset),
+	})
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	// Create subscription
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize),
+		make(chan any, 1),
+	)
+	untrack := c.lag.track(channel)
+
+	// Handle generations
+	go func() {
+		for {
+			gen, err := group.Next(ctx)
+			if err != nil {
+				if !errors.Is(err, kafka.ErrGroupClosed) && !errors.Is(err, context.Canceled) {
+					c.logger.Warning(ctx, fmt.Sprintf("Error when joining consumer group: %q", err.Error()))
+				}
+				return
+			}
+
+			c.handleGeneration(ctx, channel, gen, sub)
+		}
+	}()
+
+	// Wait for cancellation and leave the consumer group when it happens
+	sub.WaitForCancellationAsync(func() {
+		untrack()
+		if err := group.Close(); err != nil {
+			c.logger.Error(ctx, err.Error())
+		}
+	})
+
+	return sub, nil
+}
+
+// handleGeneration reads the partitions assigned in the consumer group
+// generation until it ends, calling the partitions handlers.
+func (c *Controller) handleGeneration(
+	ctx context.Context,
+	channel string,
+	gen *kafka.Generation,
+	sub extensions.BrokerChannelSubscription,
+) {
+	assignments := gen.Assignments[channel]
+	partitions := make([]int, 0, len(assignments))
+	for _, a := range assignments {
+		partitions = append(partitions, a.ID)
+	}
+
+	if c.onPartitionsAssigned != nil {
+		c.onPartitionsAssigned(ctx, channel, partitions)
+	}
+
+	// Call the revoked handler when the generation ends, as the next one will
+	// not start before
+	gen.Start(func(genCtx context.Context) {
+		<-genCtx.Done()
+		if c.onPartitionsRevoked != nil {
+			c.onPartitionsRevoked(ctx, channel, partitions)
+		}
+	})
+
+	for _, a := range assignments {
+		assignment := a
+		gen.Start(func(genCtx context.Context) {
+			c.readPartition(genCtx, channel, gen, assignment, sub)
+		})
+	}
+}
+
+// readPartition reads the messages of the partition assigned in the consumer
+// group generation, committing their offset on acknowledgement (or on
+// reception with auto commit).
+func (c *Controller) readPartition(
+	ctx context.Context,
+	channel string,
+	gen *kafka.Generation,
+	assignment kafka.PartitionAssignment,
+	sub extensions.BrokerChannelSubscription,
+) {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        c.hosts,
+		Topic:          channel,
+		Partition:      assignment.ID,
+		MaxBytes:       c.maxBytes,
+		Dialer:         c.dialer,
+		IsolationLevel: c.isolationLevel(),
+	})
+	defer r.Close()
+
+	if err := r.SetOffset(assignment.Offset); err != nil {
+		c.logger.Error(ctx, fmt.Sprintf("error on setting offset of partition %d: %q", assignment.ID, err.Error()))
+		return
+	}
+
+	for {
+		msg, err := r.ReadMessage(ctx)
+		if err != nil {
+			// If the error is not io.EOF or the end of the generation, then it is a real error
+			if !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+				c.logger.Warning(ctx, fmt.Sprintf("Error when reading message: %q", err.Error()))
+			}
+
+			return
+		}
+
+		commit := func() {
+			offsets := map[string]map[int]int64{channel: {msg.Partition: msg.Offset + 1}}
+			if err := gen.CommitOffsets(offsets); err != nil {
+				c.logger.Error(ctx, fmt.Sprintf("error on committing message: %q", err.Error()))
+			}
+		}
+
+		// Send received message
+		if c.autoCommit {
+			commit()
+			commit = NoopCommit
+		}
+		sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
+			c.brokerMessage(ctx, msg),
+			BrokerAcknowledgment{doCommit: commit}).WithMetadata(messageMetadata(msg)))
+	}
+}
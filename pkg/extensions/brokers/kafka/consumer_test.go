@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerOptions(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false))
+	require.NoError(t, err)
+	assert.Equal(t, StartOffsetEarliest, c.startOffset)
+	assert.Zero(t, c.commitInterval)
+	assert.False(t, c.hasRebalanceHandlers())
+
+	handler := func(_ context.Context, _ string, _ []int) {}
+	c, err = NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithStartOffset(StartOffsetLatest),
+		WithCommitInterval(time.Second),
+		WithPartitionsRevokedHandler(handler))
+	require.NoError(t, err)
+	assert.Equal(t, StartOffsetLatest, c.startOffset)
+	assert.Equal(t, time.Second, c.commitInterval)
+	assert.True(t, c.hasRebalanceHandlers())
+}
+
+func TestConsumerGroupNeedsGroupID(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithGroupID(""),
+		WithPartitionsAssignedHandler(func(_ context.Context, _ string, _ []int) {}))
+	require.NoError(t, err)
+
+	_, err = c.subscribeWithConsumerGroup(context.Background(), "orders")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderCodec converts the headers of the broker messages into the headers of
+// the Kafka records, and back.
+type HeaderCodec interface {
+	// EncodeHeaders returns the headers of the record of the message
+	EncodeHeaders(headers map[string][]byte) ([]kafka.Header, error)
+	// DecodeHeaders returns the headers of the message of the record
+	DecodeHeaders(headers []kafka.Header) (map[string][]byte, error)
+}
+
+// Check that it still fills the interface.
+var _ HeaderCodec = DefaultHeaderCodec{}
+
+// DefaultHeaderCodec is the header codec used by default. The values of the
+// headers are kept as they are, so they can be binary.
+//
+// The Kafka headers repeated in a record are multi-value headers: their values
+// are received as a JSON array of strings, in their order in the record, like
+// the typed headers with an array of strings.
+type DefaultHeaderCodec struct {
+	// MultiValueHeaders are the headers always received as a JSON array, even
+	// with one value, and published with a record header for each value of
+	// their JSON array.
+	MultiValueHeaders []string
+}
+
+// EncodeHeaders returns the headers of the record of the message, sorted by
+// key. The multi-value headers are repeated for each of their values.
+func (c DefaultHeaderCodec) EncodeHeaders(headers map[string][]byte) ([]kafka.Header, error) {
+	encoded := make([]kafka.Header, 0, len(headers))
+	for _, k := range utils.MapKeysToSortedList(headers) {
+		if !utils.IsInSlice(c.MultiValueHeaders, k) {
+			encoded = append(encoded, kafka.Header{Key: k, Value: headers[k]})
+			continue
+		}
+
+		var values []json.RawMessage
+		if err := json.Unmarshal(headers[k], &values); err != nil {
+			return nil, fmt.Errorf("%w: multi-value header %s is not a JSON array: %w",
+				extensions.ErrInvalidHeader, k, err)
+		}
+		for _, v := range values {
+			// The strings are sent without their quotes
+			var s string
+			if err := json.Unmarshal(v, &s); err == nil {
+				v = []byte(s)
+			}
+			encoded = append(encoded, kafka.Header{Key: k, Value: v})
+		}
+	}
+
+	return encoded, nil
+}
+
+// DecodeHeaders returns the headers of the message of the record. The repeated
+// headers and the multi-value headers are JSON arrays of their values.
+func (c DefaultHeaderCodec) DecodeHeaders(headers []kafka.Header) (map[string][]byte, error) {
+	values := make(map[string][][]byte, len(headers))
+	for _, h := range headers {
+		values[h.Key] = append(values[h.Key], h.Value)
+	}
+
+	decoded := make(map[string][]byte, len(values))
+	for k, vs := range values {
+		if len(vs) == 1 && !utils.IsInSlice(c.MultiValueHeaders, k) {
+			decoded[k] = vs[0]
+			continue
+		}
+
+		strs := make([]string, 0, len(vs))
+		for _, v := range vs {
+			if !utf8.Valid(v) {
+				return nil, fmt.Errorf("%w: value of multi-value header %s is not valid UTF-8",
+					extensions.ErrInvalidHeader, k)
+			}
+			strs = append(strs, string(v))
+		}
+
+		b, err := extensions.MarshalHeader(strs)
+		if err != nil {
+			return nil, err
+		}
+		decoded[k] = b
+	}
+
+	return decoded, nil
+}
+
+// WithHeaderCodec set the codec converting the headers of the messages into
+// the headers of the Kafka records, and back.
+func WithHeaderCodec(codec HeaderCodec) ControllerOption {
+	return func(controller *Controller) {
+		controller.headerCodec = codec
+	}
+}
+
+// WithMultiValueHeaders set the headers that are published with a Kafka header
+// for each value of their JSON array, and always received as a JSON array,
+// with the DefaultHeaderCodec.
+func WithMultiValueHeaders(keys ...string) ControllerOption {
+	return WithHeaderCodec(DefaultHeaderCodec{MultiValueHeaders: keys})
+}
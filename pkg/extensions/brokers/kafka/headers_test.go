@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderCodecRoundTrip(t *testing.T) {
+	codec := DefaultHeaderCodec{MultiValueHeaders: []string{"tags"}}
+
+	// The values are kept as they are, and the multi-value headers are repeated
+	headers := map[string][]byte{
+		"binary": {0x00, 0xff, 0x10},
+		"text":   []byte("value"),
+		"tags":   []byte(`["a","b",1]`),
+	}
+	encoded, err := codec.EncodeHeaders(headers)
+	require.NoError(t, err)
+	assert.Equal(t, []kafka.Header{
+		{Key: "binary", Value: []byte{0x00, 0xff, 0x10}},
+		{Key: "tags", Value: []byte("a")},
+		{Key: "tags", Value: []byte("b")},
+		{Key: "tags", Value: []byte("1")},
+		{Key: "text", Value: []byte("value")},
+	}, encoded)
+
+	decoded, err := codec.DecodeHeaders(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"binary": {0x00, 0xff, 0x10},
+		"text":   []byte("value"),
+		"tags":   []byte(`["a","b","1"]`),
+	}, decoded)
+
+	// A multi-value header with one value is still an array
+	decoded, err = codec.DecodeHeaders([]kafka.Header{{Key: "tags", Value: []byte("a")}})
+	require.NoError(t, err)
+	assert.Equal(t, `["a"]`, string(decoded["tags"]))
+}
+
+func TestHeaderCodecRepeatedHeaders(t *testing.T) {
+	decoded, err := DefaultHeaderCodec{}.DecodeHeaders([]kafka.Header{
+		{Key: "forwarded", Value: []byte("a")},
+		{Key: "single", Value: []byte("b")},
+		{Key: "forwarded", Value: []byte("c")},
+	})
+	require.NoError(t, err)
+
+	// The repeated headers are received as typed headers with an array of strings
+	var forwarded []string
+	require.NoError(t, extensions.UnmarshalHeader(decoded["forwarded"], &forwarded))
+	assert.Equal(t, []string{"a", "c"}, forwarded)
+	assert.Equal(t, "b", string(decoded["single"]))
+
+	// The binary values of a repeated header can't be in a JSON array
+	_, err = DefaultHeaderCodec{}.DecodeHeaders([]kafka.Header{
+		{Key: "forwarded", Value: []byte{0xff}},
+		{Key: "forwarded", Value: []byte{0xfe}},
+	})
+	assert.ErrorIs(t, err, extensions.ErrInvalidHeader)
+}
+
+func TestHeaderCodecInvalidMultiValueHeader(t *testing.T) {
+	_, err := DefaultHeaderCodec{MultiValueHeaders: []string{"tags"}}.EncodeHeaders(map[string][]byte{
+		"tags": []byte("a"),
+	})
+	assert.ErrorIs(t, err, extensions.ErrInvalidHeader)
+}
+
+func TestBrokerMessageHeaders(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithMultiValueHeaders("tags"))
+	require.NoError(t, err)
+
+	bm := c.brokerMessage(context.Background(), kafka.Message{
+		Headers: []kafka.Header{
+			{Key: "tags", Value: []byte("a")},
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "tags", Value: []byte("b")},
+		},
+		Value: []byte("{}"),
+	})
+	assert.Equal(t, "application/json", bm.ContentType)
+	assert.Equal(t, map[string][]byte{"tags": []byte(`["a","b"]`)}, bm.Headers)
+}
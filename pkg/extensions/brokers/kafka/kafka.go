@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
@@ -13,8 +14,11 @@ import (
 	"github.com/segmentio/kafka-go/sasl"
 )
 
-// Check that it still fills the interface.
-var _ extensions.BrokerController = (*Controller)(nil)
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Controller)(nil)
+	_ extensions.PatternSubscriber = (*Controller)(nil)
+)
 
 // Controller is the Kafka implementation for asyncapi-codegen.
 type Controller struct {
@@ -24,12 +28,47 @@ type Controller struct {
 
 	dialer *kafka.Dialer
 
+	saslCredentials *saslCredentials
+	tlsFilesConfig  *TLSConfig
+	security        *security
+
 	partition  int
 	maxBytes   int
 	autoCommit bool
 
+	startOffset          StartOffset
+	commitInterval       time.Duration
+	onPartitionsAssigned PartitionsHandler
+	onPartitionsRevoked  PartitionsHandler
+
 	connectionTest bool
 
+	schemaRegistryConfig *SchemaRegistryConfig
+	schemaRegistry       *schemaRegistry
+
+	headerCodec HeaderCodec
+
+	// Consumer lag reporting, with WithLagCollector
+	lagCollector extensions.LagCollector
+	lagInterval  time.Duration
+	lag          lagReporter
+
+	// Topics created or checked at startup, with WithTopic
+	topics map[string]extensions.KafkaChannelBindings
+
+	// Publication only
+	compression  Compression
+	linger       time.Duration
+	batchSize    int
+	batchBytes   int64
+	requiredAcks RequiredAcks
+	writer       *kafka.Writer
+
+	// Transactional publications, with WithTransactionalProducer
+	transactionalID string
+	transactions    *transactionalProducer
+	readCommitted   bool
+
 	logger extensions.Logger
 }
 
@@ -46,16 +85,21 @@ type ControllerOption func(controller *Controller)
 
 // NewController creates a new KafkaController that fulfill the BrokerLinker interface.
 func NewController(hosts []string, options ...ControllerOption) (*Controller, error) {
-	// Create default controller
+	// Create default controller, with a copy of the default dialer as it is
+	// modified by the options
+	dialer := *kafka.DefaultDialer
 	controller := &Controller{
 		hosts:          hosts,
 		logger:         extensions.DummyLogger{},
 		groupID:        brokers.DefaultQueueGroupID,
-		dialer:         kafka.DefaultDialer,
+		dialer:         &dialer,
 		partition:      0,
 		maxBytes:       10e6, // 10MB
 		autoCommit:     true,
+		startOffset:    StartOffsetEarliest,
 		connectionTest: true,
+		headerCodec:    DefaultHeaderCodec{},
+		linger:         DefaultLinger,
 	}
 
 	// Execute options
@@ -63,6 +107,39 @@ func NewController(hosts []string, options ...ControllerOption) (*Controller, er
 		option(controller)
 	}
 
+	// Set authentication and TLS from WithSASL and WithTLSConfig
+	if err := controller.applySecurityOptions(); err != nil {
+		return nil, fmt.Errorf("could not apply option to controller: %w", err)
+	}
+
+	// Create the writer of the publications, with the producer options
+	if err := controller.applyProducerOptions(); err != nil {
+		return nil, fmt.Errorf("could not apply option to controller: %w", err)
+	}
+
+	// Create the producer of the transactions, if set with WithTransactionalProducer
+	if controller.transactionalID != "" {
+		controller.transactions = controller.newTransactionalProducer()
+	}
+
+	// Create the schema registry client, if set with WithSchemaRegistry
+	if controller.schemaRegistryConfig != nil {
+		registry, err := newSchemaRegistry(*controller.schemaRegistryConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema registry configuration: %w", err)
+		}
+		controller.schemaRegistry = registry
+	}
+
+	if controller.lagCollector != nil && controller.lagInterval <= 0 {
+		return nil, fmt.Errorf("%w: the consumer lag interval should be positive", extensions.ErrAsyncAPI)
+	}
+
+	// Create or check the topics set with WithTopic
+	if err := controller.checkTopics(context.Background()); err != nil {
+		return nil, err
+	}
+
 	// kafka has no ping or something like this to test if dialer can create a successful connection to kafka
 	// so if connectionTest is enabled create a connection and try to list brokers from kafka and validate
 	// we can make a connection to kafka
@@ -79,6 +156,8 @@ func NewController(hosts []string, options ...ControllerOption) (*Controller, er
 		conn.Close()
 	}
 
+	controller.startLagReporting()
+
 	return controller, nil
 }
 
@@ -140,37 +219,53 @@ func WithConnectionTest(enabled bool) ControllerOption {
 	}
 }
 
-// Publish a message to the broker.
-func (c *Controller) Publish(ctx context.Context, channel string, um extensions.BrokerMessage) error {
-	// Create new writer
-	w := kafka.Writer{
-		Addr:     kafka.TCP(c.hosts...),
-		Topic:    channel,
-		Balancer: &kafka.LeastBytes{},
-		Transport: &kafka.Transport{
-			// reuse the optionally TLS and SASLMechanism from dialer provided by the user to pass it to the writer
-			// it can be nil
-			TLS:  c.dialer.TLS.Clone(),
-			SASL: c.dialer.SASLMechanism,
-		},
+// WithSchemaRegistry set the Confluent Schema Registry used to encode the JSON
+// payloads with Avro, in the Confluent wire format (magic byte and schema ID
+// before the Avro data). Received payloads in this format are decoded back to
+// JSON, while others are kept as they are.
+func WithSchemaRegistry(config SchemaRegistryConfig) ControllerOption {
+	return func(controller *Controller) {
+		controller.schemaRegistryConfig = &config
 	}
+}
 
-	defer w.Close()
+// Healthy returns an error if none of the Kafka hosts can be reached.
+func (c *Controller) Healthy(ctx context.Context) error {
+	var errs error
+	for _, host := range c.hosts {
+		conn, err := c.dialer.DialContext(ctx, "tcp", host)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = errors.Join(errs, err)
+	}
+	return fmt.Errorf("kafka is not reachable: %w", errs)
+}
 
-	// Create the message
-	msg := kafka.Message{
-		Headers: make([]kafka.Header, 0),
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, um extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{um})
+}
+
+// PublishBatch publishes several messages at once, letting the producer
+// batch them in the least possible requests.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, ums []extensions.BrokerMessage) error {
+	msgs, err := c.kafkaMessages(ctx, channel, ums)
+	if err != nil {
+		return err
 	}
 
-	// Set message content and headers
-	msg.Value = um.Payload
-	for k, v := range um.Headers {
-		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: v})
+	// Publish the messages in the transaction of the context, if there is one
+	if tx, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		return c.publishInTransaction(ctx, tx, channel, msgs)
 	}
 
+	// The shared writer keeps the topics metadata, so the retries after the
+	// creation of a topic are done with a new writer
+	w := c.writer
 	for {
-		// Publish message
-		err := w.WriteMessages(ctx, msg)
+		// Publish messages
+		err := w.WriteMessages(ctx, msgs...)
 
 		// If there is no error then return
 		if err == nil {
@@ -185,6 +280,12 @@ func (c *Controller) Publish(ctx context.Context, channel string, um extensions.
 				return err
 			}
 
+			if w == c.writer {
+				if w, err = c.newWriter(); err != nil {
+					return err
+				}
+				defer w.Close()
+			}
 			continue
 		}
 
@@ -193,6 +294,59 @@ func (c *Controller) Publish(ctx context.Context, channel string, um extensions.
 	}
 }
 
+// kafkaMessages converts the broker messages to the Kafka messages published
+// on the topic.
+func (c *Controller) kafkaMessages(
+	ctx context.Context,
+	channel string,
+	ums []extensions.BrokerMessage,
+) ([]kafka.Message, error) {
+	msgs := make([]kafka.Message, 0, len(ums))
+	for _, um := range ums {
+		headers, err := c.headerCodec.EncodeHeaders(um.Headers)
+		if err != nil {
+			return nil, err
+		}
+		msg := kafka.Message{
+			Topic:   channel,
+			Headers: headers,
+		}
+
+		// Set message content and headers
+		msg.Value = um.Payload
+		if c.schemaRegistry != nil {
+			payload, err := c.schemaRegistry.encode(ctx, channel, um.Payload)
+			if err != nil {
+				return nil, err
+			}
+			msg.Value = payload
+		}
+		if um.ContentType != "" {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: brokers.ContentTypeHeaderKey, Value: []byte(um.ContentType)})
+		}
+		if um.Key != "" {
+			msg.Key = []byte(um.Key)
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+// Close stops the consumer lag reporting, and closes the producer after
+// sending the pending publications.
+func (c *Controller) Close() {
+	c.stopLagReporting()
+	if c.transactions != nil {
+		c.transactions.close()
+	}
+	if err := c.writer.Close(); err != nil {
+		c.logger.Error(context.Background(), "failed to close producer",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+}
+
 // Subscribe to messages from the broker.
 func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
 	// Check that topic exists before
@@ -200,15 +354,98 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 		return extensions.BrokerChannelSubscription{}, err
 	}
 
-	// Create reader
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:   c.hosts,
-		Topic:     channel,
-		Partition: c.partition,
-		MaxBytes:  c.maxBytes,
-		GroupID:   c.groupID,
-		Dialer:    c.dialer,
-	})
+	// Manage the consumer group generations to call the partitions handlers
+	if c.hasRebalanceHandlers() {
+		return c.subscribeWithConsumerGroup(ctx, channel)
+	}
+
+	return c.subscribeWithReader(ctx, kafka.ReaderConfig{
+		Brokers:        c.hosts,
+		Topic:          channel,
+		Partition:      c.partition,
+		MaxBytes:       c.maxBytes,
+		GroupID:        c.groupID,
+		Dialer:         c.dialer,
+		StartOffset:    int64(c.startOffset),
+		CommitInterval: c.commitInterval,
+		IsolationLevel: c.isolationLevel(),
+	}), nil
+}
+
+// SubscribeToPattern subscribes to the messages of all the existing topics that
+// match the channel address, with the consumer group of the controller.
+//
+// NOTE: the topics created after the subscription are not consumed, and the
+// controller should have a consumer group (set with WithGroupID).
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	if c.groupID == "" {
+		return extensions.BrokerChannelSubscription{},
+			fmt.Errorf("%w: a consumer group is needed", extensions.ErrPatternSubscriptionNotSupported)
+	}
+
+	topics, err := c.matchingTopics(channelAddr)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	} else if len(topics) == 0 {
+		return extensions.BrokerChannelSubscription{},
+			fmt.Errorf("%w: no existing topic matches %q", extensions.ErrAsyncAPI, channelAddr)
+	}
+
+	return c.subscribeWithReader(ctx, kafka.ReaderConfig{
+		Brokers:        c.hosts,
+		GroupTopics:    topics,
+		MaxBytes:       c.maxBytes,
+		GroupID:        c.groupID,
+		Dialer:         c.dialer,
+		StartOffset:    int64(c.startOffset),
+		CommitInterval: c.commitInterval,
+		IsolationLevel: c.isolationLevel(),
+	}), nil
+}
+
+// matchingTopics returns the existing topics that match the channel address.
+func (c *Controller) matchingTopics(channelAddr string) ([]string, error) {
+	conn, err := c.dialer.Dial("tcp", c.hosts[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]string, 0)
+	matched := make(map[string]bool)
+	for _, p := range partitions {
+		if matched[p.Topic] {
+			continue
+		}
+		if _, err := extensions.ParseChannelAddress(channelAddr, p.Topic); err == nil {
+			matched[p.Topic] = true
+			topics = append(topics, p.Topic)
+		}
+	}
+
+	return topics, nil
+}
+
+// subscribeWithReader creates a subscription on the messages of a new reader.
+func (c *Controller) subscribeWithReader(
+	ctx context.Context,
+	config kafka.ReaderConfig,
+) extensions.BrokerChannelSubscription {
+	r := kafka.NewReader(config)
+	// Report the consumer lag of the topic, or of the topics of the pattern
+	topics := config.GroupTopics
+	if config.Topic != "" {
+		topics = []string{config.Topic}
+	}
+	untrack := c.lag.track(topics...)
 
 	// Create subscription
 	sub := extensions.NewBrokerChannelSubscription(
@@ -218,26 +455,38 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 
 	// Handle events
 	if c.autoCommit {
-		go autoCommitMessagesHandler(&c.logger)(ctx, r, sub)
+		go c.autoCommitMessagesHandler()(ctx, r, sub)
 	} else {
-		go manualCommitMessagesHandler(&c.logger)(ctx, r, sub)
+		go c.manualCommitMessagesHandler()(ctx, r, sub)
 	}
 
 	// Wait for cancellation and stop the kafka listener when it happens
 	sub.WaitForCancellationAsync(func() {
+		untrack()
 		if err := r.Close(); err != nil {
 			c.logger.Error(ctx, err.Error())
 		}
 	})
 
-	return sub, nil
+	return sub
 }
 
 func (c *Controller) checkTopicExistOrCreateIt(ctx context.Context, topic string) error {
+	_, err := c.createTopic(ctx, topic, c.topicBindings(ctx, topic))
+	return err
+}
+
+// createTopic creates the topic with the partitions and replicas of its
+// bindings if it doesn't exist, and returns its partitions.
+func (c *Controller) createTopic(
+	ctx context.Context,
+	topic string,
+	bindings extensions.KafkaChannelBindings,
+) ([]kafka.Partition, error) {
 	// Get connection to first host
 	conn, err := c.dialer.Dial("tcp", c.hosts[0])
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer conn.Close()
 
@@ -245,28 +494,32 @@ func (c *Controller) checkTopicExistOrCreateIt(ctx context.Context, topic string
 		// Create topic
 		topicConfigs := []kafka.TopicConfig{{
 			Topic:             topic,
-			NumPartitions:     1,
-			ReplicationFactor: 1,
+			NumPartitions:     max(bindings.Partitions, 1),
+			ReplicationFactor: max(bindings.Replicas, 1),
 		}}
 		err = conn.CreateTopics(topicConfigs...)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Read partitions
 		partitions, err := conn.ReadPartitions()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Get topic from partitions
+		var topicPartitions []kafka.Partition
 		for _, p := range partitions {
 			if topic == p.Topic {
-				if i > 0 {
-					c.logger.Warning(ctx, fmt.Sprintf("Topic %s has been created.", topic))
-				}
-				return nil
+				topicPartitions = append(topicPartitions, p)
+			}
+		}
+		if len(topicPartitions) > 0 {
+			if i > 0 {
+				c.logger.Warning(ctx, fmt.Sprintf("Topic %s has been created.", topic))
 			}
+			return topicPartitions, nil
 		}
 
 		c.logger.Warning(ctx, fmt.Sprintf("Topic %s doesn't exists yet, retrying (#%d)", topic, i))
@@ -279,77 +532,116 @@ func (c *Controller) checkTopicExistOrCreateIt(ctx context.Context, topic string
 // message is fully processed and handled but allow more throughput.
 //
 // Maybe consider to use the manualCommitMessagesHandler.
-func autoCommitMessagesHandler(
-	logger *extensions.Logger,
-) func(ctx context.Context, r *kafka.Reader, sub extensions.BrokerChannelSubscription) {
+func (c *Controller) autoCommitMessagesHandler() func(
+	ctx context.Context,
+	r *kafka.Reader,
+	sub extensions.BrokerChannelSubscription,
+) {
 	return func(ctx context.Context, r *kafka.Reader, sub extensions.BrokerChannelSubscription) {
 		for {
 			msg, err := r.ReadMessage(ctx)
 			if err != nil {
 				// If the error is not io.EOF, then it is a real error
 				if !errors.Is(err, io.EOF) {
-					(*logger).Warning(ctx, fmt.Sprintf("Error when reading message: %q", err.Error()))
+					c.logger.Warning(ctx, fmt.Sprintf("Error when reading message: %q", err.Error()))
 				}
 
 				return
 			}
 
-			// Get headers
-			headers := make(map[string][]byte, len(msg.Headers))
-			for _, header := range msg.Headers {
-				headers[header.Key] = header.Value
-			}
-
 			// Send received message
 			sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
-				extensions.BrokerMessage{
-					Headers: headers,
-					Payload: msg.Value,
-				},
-				BrokerAcknowledgment{NoopCommit}))
+				c.readerMessage(ctx, r, msg),
+				BrokerAcknowledgment{NoopCommit}).WithMetadata(messageMetadata(msg)))
 		}
 	}
 }
 
 // manualCommitMessagesHandler provides a MessagesHandler with manual commit
 // the message is committed by user via the AcknowledgementHandler.
-func manualCommitMessagesHandler(
-	logger *extensions.Logger,
-) func(ctx context.Context, r *kafka.Reader, sub extensions.BrokerChannelSubscription) {
+func (c *Controller) manualCommitMessagesHandler() func(
+	ctx context.Context,
+	r *kafka.Reader,
+	sub extensions.BrokerChannelSubscription,
+) {
 	return func(ctx context.Context, r *kafka.Reader, sub extensions.BrokerChannelSubscription) {
 		for {
 			msg, err := r.FetchMessage(ctx)
 			if err != nil {
 				// If the error is not io.EOF, then it is a real error
 				if !errors.Is(err, io.EOF) {
-					(*logger).Warning(ctx, fmt.Sprintf("Error when reading message: %q", err.Error()))
+					c.logger.Warning(ctx, fmt.Sprintf("Error when reading message: %q", err.Error()))
 				}
 
 				return
 			}
 
-			// Get headers
-			headers := make(map[string][]byte, len(msg.Headers))
-			for _, header := range msg.Headers {
-				headers[header.Key] = header.Value
-			}
-
 			// Send received message
 			sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
-				extensions.BrokerMessage{
-					Headers: headers,
-					Payload: msg.Value,
-				},
+				c.readerMessage(ctx, r, msg),
 				BrokerAcknowledgment{doCommit: func() {
 					if err := r.CommitMessages(ctx, msg); err != nil {
-						(*logger).Error(ctx, fmt.Sprintf("error on committing message: %q", err.Error()))
+						c.logger.Error(ctx, fmt.Sprintf("error on committing message: %q", err.Error()))
 					}
 				}},
-			))
+			).WithMetadata(messageMetadata(msg)))
 		}
 	}
 }
 
+// readerMessage converts the Kafka message of the reader to a broker message,
+// with its topic as address if the reader consumes several topics.
+func (c *Controller) readerMessage(ctx context.Context, r *kafka.Reader, msg kafka.Message) extensions.BrokerMessage {
+	bm := c.brokerMessage(ctx, msg)
+	if len(r.Config().GroupTopics) > 0 {
+		bm.Address = msg.Topic
+	}
+	return bm
+}
+
+// brokerMessage converts the Kafka message to a broker message, decoding its
+// payload with the schema registry if there is one.
+func (c *Controller) brokerMessage(ctx context.Context, msg kafka.Message) extensions.BrokerMessage {
+	// Get headers, or only their last value if they can't be decoded
+	headers, err := c.headerCodec.DecodeHeaders(msg.Headers)
+	if err != nil {
+		c.logger.Error(ctx, fmt.Sprintf("error on decoding message headers: %q", err.Error()))
+		headers = make(map[string][]byte, len(msg.Headers))
+		for _, header := range msg.Headers {
+			headers[header.Key] = header.Value
+		}
+	}
+
+	// Decode payload, or keep it as it is to let the user handle it
+	payload := msg.Value
+	if c.schemaRegistry != nil {
+		decoded, err := c.schemaRegistry.decode(ctx, msg.Value)
+		if err != nil {
+			c.logger.Error(ctx, fmt.Sprintf("error on decoding message with schema registry: %q", err.Error()))
+		} else {
+			payload = decoded
+		}
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     payload,
+		Key:         string(msg.Key),
+	}
+}
+
+// messageMetadata returns the delivery metadata of the Kafka message. Kafka
+// doesn't count the deliveries, as the messages are committed by offset.
+func messageMetadata(msg kafka.Message) extensions.MessageMetadata {
+	return extensions.MessageMetadata{
+		Timestamp:  msg.Time,
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		RoutingKey: msg.Topic,
+	}
+}
+
 var _ extensions.BrokerAcknowledgment = (*BrokerAcknowledgment)(nil)
 
 // BrokerAcknowledgment for kafka broker.
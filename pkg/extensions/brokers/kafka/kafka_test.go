@@ -3,10 +3,14 @@ package kafka
 import (
 	"crypto/tls"
 	"testing"
+	"time"
 
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
 	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
 	"github.com/segmentio/kafka-go/sasl/scram"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
 )
 
 //nolint:funlen
@@ -77,4 +81,43 @@ func TestSecureConnectionToKafka(t *testing.T) {
 			)
 			assert.NoError(t, err, "new connection to TLS secured kafka broker with TLS config and basic credentials should return no error") //nolint:lll
 		})
+
+	t.Run("test connection is successfully to TLS secured kafka broker with TLS and SASL options",
+		func(t *testing.T) {
+			_, err := NewController(
+				[]string{
+					testutil.BrokerAddress(testutil.BrokerAddressParams{
+						DockerizedAddr: "kafka-tls-basic-auth",
+						DockerizedPort: "9092",
+						LocalPort:      "9096",
+					}),
+				},
+				WithGroupID("secureConnectTestWithTLSAndSASLOptions"),
+				WithTLSConfig(TLSConfig{InsecureSkipVerify: true}),
+				WithSASL(SASLScramSHA512, "user", "password"),
+			)
+			assert.NoError(t, err, "new connection to TLS secured kafka broker with TLS and SASL options should return no error") //nolint:lll
+		})
+}
+
+func TestConformance(t *testing.T) {
+	addr := testutil.BrokerAddress(testutil.BrokerAddressParams{
+		DockerizedAddr: "kafka",
+		Port:           "9092",
+	})
+	testutil.SkipIfBrokerUnavailable(t, addr)
+
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController([]string{addr}, WithGroupID("kafkaConformance"))
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		// The consumer group has to be joined before receiving messages
+		Timeout: 30 * time.Second,
+		// The pattern subscriptions only consume the topics existing when
+		// subscribing, and the topics are created on publication
+		WithoutPatternSubscriptions: true,
+	})
 }
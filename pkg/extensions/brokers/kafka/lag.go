@@ -0,0 +1,277 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// PartitionLag is the consumer lag of a partition of a topic, for the consumer
+// group of the controller.
+type PartitionLag struct {
+	Topic     string
+	Partition int
+	// CommittedOffset is the offset of the next message consumed by the group,
+	// or -1 if the group has not committed any offset on the partition.
+	CommittedOffset int64
+	// LastOffset is the offset of the next message published on the partition.
+	LastOffset int64
+	// Lag is the number of messages of the partition not consumed yet by the group.
+	Lag int64
+}
+
+// WithLagCollector set a collector receiving the consumer lag of each partition
+// of the subscribed topics, every interval, until the controller is closed.
+func WithLagCollector(collector extensions.LagCollector, interval time.Duration) ControllerOption {
+	return func(controller *Controller) {
+		controller.lagCollector = collector
+		controller.lagInterval = interval
+	}
+}
+
+// Lag returns the consumer lag of the partitions of the topic, for the consumer
+// group of the controller, sorted by partition.
+func (c *Controller) Lag(ctx context.Context, channel string) ([]PartitionLag, error) {
+	if c.groupID == "" {
+		return nil, fmt.Errorf("%w: the consumer lag needs a group ID", extensions.ErrAsyncAPI)
+	}
+
+	transport := c.newTransport()
+	defer transport.CloseIdleConnections()
+	client := &kafka.Client{Addr: kafka.TCP(c.hosts...), Transport: transport}
+
+	partitions, err := topicPartitions(ctx, client, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the offsets committed by the consumer group
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: c.groupID,
+		Topics:  map[string][]int{channel: partitions},
+	})
+	if err != nil {
+		return nil, err
+	} else if committed.Error != nil {
+		return nil, committed.Error
+	}
+
+	lags := make(map[int]PartitionLag, len(partitions))
+	for _, p := range committed.Topics[channel] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("could not fetch offset of partition %d: %w", p.Partition, p.Error)
+		}
+		lags[p.Partition] = PartitionLag{Topic: channel, Partition: p.Partition, CommittedOffset: p.CommittedOffset}
+	}
+
+	// Get the last offsets of the partitions, and the first ones of the
+	// partitions without committed offset that are read from the start
+	last, err := listOffsets(ctx, client, channel, partitions, kafka.LastOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var uncommitted []int
+	for _, p := range partitions {
+		l := lags[p]
+		l.Topic, l.Partition, l.LastOffset = channel, p, last[p]
+		if l.CommittedOffset < 0 {
+			l.CommittedOffset = -1
+			uncommitted = append(uncommitted, p)
+		}
+		lags[p] = l
+	}
+
+	first := make(map[int]int64)
+	if len(uncommitted) > 0 && c.startOffset == StartOffsetEarliest {
+		if first, err = listOffsets(ctx, client, channel, uncommitted, kafka.FirstOffsetOf); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]PartitionLag, 0, len(lags))
+	for _, p := range partitions {
+		l := lags[p]
+		l.Lag = partitionLag(l, first[p], c.startOffset)
+		res = append(res, l)
+	}
+
+	return res, nil
+}
+
+// partitionLag returns the lag of the partition: the messages after the
+// committed offset, or the ones that would be read from the start offset
+// (with the first offset of the partition) when nothing has been committed.
+func partitionLag(l PartitionLag, firstOffset int64, startOffset StartOffset) int64 {
+	switch {
+	case l.CommittedOffset >= 0:
+		return max(l.LastOffset-l.CommittedOffset, 0)
+	case startOffset == StartOffsetEarliest:
+		return max(l.LastOffset-firstOffset, 0)
+	default:
+		return 0
+	}
+}
+
+// topicPartitions returns the sorted IDs of the partitions of the topic.
+func topicPartitions(ctx context.Context, client *kafka.Client, topic string) ([]int, error) {
+	// The metadata of all the topics is requested, as requesting the ones of
+	// a topic that doesn't exist can create it
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		} else if t.Error != nil {
+			return nil, t.Error
+		}
+
+		ids := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			ids = append(ids, p.ID)
+		}
+		sort.Ints(ids)
+		return ids, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", kafka.UnknownTopicOrPartition, topic)
+}
+
+// listOffsets returns the offsets of the partitions of the topic, requested
+// with kafka.FirstOffsetOf or kafka.LastOffsetOf.
+func listOffsets(
+	ctx context.Context,
+	client *kafka.Client,
+	topic string,
+	partitions []int,
+	request func(partition int) kafka.OffsetRequest,
+) (map[int]int64, error) {
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		requests = append(requests, request(p))
+	}
+
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("could not list offsets of partition %d: %w", p.Partition, p.Error)
+		}
+		// The offset that is not requested is -1
+		offsets[p.Partition] = max(p.FirstOffset, p.LastOffset)
+	}
+
+	return offsets, nil
+}
+
+// lagReporter reports the consumer lag of the subscribed topics to the lag
+// collector of the controller.
+type lagReporter struct {
+	mu     sync.Mutex
+	topics map[string]int // Number of subscriptions of each topic
+
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// track adds the topics to the ones whose lag is reported, and returns the
+// function removing them when their subscription is canceled.
+func (r *lagReporter) track(topics ...string) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.topics == nil {
+		r.topics = make(map[string]int)
+	}
+	for _, t := range topics {
+		r.topics[t]++
+	}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		for _, t := range topics {
+			if r.topics[t]--; r.topics[t] <= 0 {
+				delete(r.topics, t)
+			}
+		}
+	}
+}
+
+// trackedTopics returns the sorted topics whose lag is reported.
+func (r *lagReporter) trackedTopics() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return utils.MapKeysToSortedList(r.topics)
+}
+
+// startLagReporting reports the lag every interval, if a lag collector has
+// been set with WithLagCollector, until stopLagReporting is called.
+func (c *Controller) startLagReporting() {
+	if c.lagCollector == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.lag.stop, c.lag.stopped = cancel, make(chan struct{})
+
+	go func() {
+		defer close(c.lag.stopped)
+
+		ticker := time.NewTicker(c.lagInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.reportLag(ctx)
+			}
+		}
+	}()
+}
+
+// reportLag reports the lag of the partitions of the subscribed topics.
+func (c *Controller) reportLag(ctx context.Context) {
+	for _, topic := range c.lag.trackedTopics() {
+		lags, err := c.Lag(ctx, topic)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger.Warning(ctx, fmt.Sprintf("Could not get the consumer lag of topic %s: %q", topic, err.Error()))
+			}
+			continue
+		}
+
+		for _, l := range lags {
+			c.lagCollector.ConsumerLag(l.Topic, l.Partition, l.Lag)
+		}
+	}
+}
+
+// stopLagReporting stops the lag reporting, and waits for the current report.
+func (c *Controller) stopLagReporting() {
+	if c.lag.stop == nil {
+		return
+	}
+
+	c.lag.stop()
+	<-c.lag.stopped
+}
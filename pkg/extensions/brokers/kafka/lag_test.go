@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionLag(t *testing.T) {
+	// The messages after the committed offset are not consumed yet
+	assert.Equal(t, int64(5), partitionLag(PartitionLag{CommittedOffset: 10, LastOffset: 15}, 0, StartOffsetEarliest))
+	assert.Equal(t, int64(0), partitionLag(PartitionLag{CommittedOffset: 15, LastOffset: 15}, 0, StartOffsetLatest))
+
+	// Without committed offset, it depends on where the group starts reading
+	assert.Equal(t, int64(12), partitionLag(PartitionLag{CommittedOffset: -1, LastOffset: 15}, 3, StartOffsetEarliest))
+	assert.Equal(t, int64(0), partitionLag(PartitionLag{CommittedOffset: -1, LastOffset: 15}, 3, StartOffsetLatest))
+}
+
+func TestLagReporterTrack(t *testing.T) {
+	var r lagReporter
+
+	untrack1 := r.track("orders")
+	untrack2 := r.track("orders", "users")
+	assert.Equal(t, []string{"orders", "users"}, r.trackedTopics())
+
+	// The topics are reported until all their subscriptions are canceled
+	untrack2()
+	assert.Equal(t, []string{"orders"}, r.trackedTopics())
+	untrack1()
+	assert.Empty(t, r.trackedTopics())
+}
+
+func TestLagWithoutGroupID(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false), WithGroupID(""))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Lag(context.Background(), "orders")
+	assert.Error(t, err)
+}
+
+func TestLagCollectorInvalidInterval(t *testing.T) {
+	_, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false),
+		WithLagCollector(lagCollectorFunc(func(string, int, int64) {}), -time.Second))
+	assert.Error(t, err)
+}
+
+type lagCollectorFunc func(channel string, partition int, lag int64)
+
+func (f lagCollectorFunc) ConsumerLag(channel string, partition int, lag int64) {
+	f(channel, partition, lag)
+}
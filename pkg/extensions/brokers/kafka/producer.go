@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// DefaultLinger is the time the producer waits for other messages before
+// sending a batch, when not set with WithLinger.
+const DefaultLinger = time.Millisecond
+
+// Compression is the codec used by the producer to compress the batches of
+// messages.
+type Compression string
+
+const (
+	// CompressionNone doesn't compress the messages. This is the default.
+	CompressionNone Compression = ""
+	// CompressionGzip compresses the messages with gzip.
+	CompressionGzip Compression = "gzip"
+	// CompressionSnappy compresses the messages with snappy.
+	CompressionSnappy Compression = "snappy"
+	// CompressionLz4 compresses the messages with lz4.
+	CompressionLz4 Compression = "lz4"
+	// CompressionZstd compresses the messages with zstd.
+	CompressionZstd Compression = "zstd"
+)
+
+// codec returns the kafka-go compression of the codec.
+func (c Compression) codec() (kafka.Compression, error) {
+	switch c {
+	case CompressionNone:
+		return 0, nil
+	case CompressionGzip:
+		return kafka.Gzip, nil
+	case CompressionSnappy:
+		return kafka.Snappy, nil
+	case CompressionLz4:
+		return kafka.Lz4, nil
+	case CompressionZstd:
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression: %q", c)
+	}
+}
+
+// RequiredAcks is the number of acknowledgments of the partition replicas
+// needed by the producer before a publication succeeds.
+type RequiredAcks int
+
+const (
+	// AcksNone doesn't wait for any acknowledgment. This is the default.
+	AcksNone RequiredAcks = RequiredAcks(kafka.RequireNone)
+	// AcksLeader waits for the acknowledgment of the partition leader.
+	AcksLeader RequiredAcks = RequiredAcks(kafka.RequireOne)
+	// AcksAll waits for the acknowledgment of all the in-sync replicas.
+	AcksAll RequiredAcks = RequiredAcks(kafka.RequireAll)
+)
+
+// WithCompression set the codec used to compress the published messages.
+func WithCompression(compression Compression) ControllerOption {
+	return func(controller *Controller) {
+		controller.compression = compression
+	}
+}
+
+// WithLinger set the time the producer waits for other messages before sending
+// a batch that is not full. A higher value makes bigger batches to increase the
+// throughput, while increasing the latency of the publications.
+func WithLinger(linger time.Duration) ControllerOption {
+	return func(controller *Controller) {
+		controller.linger = linger
+	}
+}
+
+// WithBatchSize set the maximum number of messages in a batch, 100 by default.
+func WithBatchSize(size int) ControllerOption {
+	return func(controller *Controller) {
+		controller.batchSize = size
+	}
+}
+
+// WithBatchBytes set the maximum size of a batch in bytes, 1MB by default.
+func WithBatchBytes(size int64) ControllerOption {
+	return func(controller *Controller) {
+		controller.batchBytes = size
+	}
+}
+
+// WithRequiredAcks set the acknowledgments of the partition replicas needed
+// before a publication succeeds.
+func WithRequiredAcks(acks RequiredAcks) ControllerOption {
+	return func(controller *Controller) {
+		controller.requiredAcks = acks
+	}
+}
+
+// applyProducerOptions checks the producer options, and creates the writer
+// used by the publications.
+func (c *Controller) applyProducerOptions() error {
+	switch c.requiredAcks {
+	case AcksNone, AcksLeader, AcksAll:
+	default:
+		return fmt.Errorf("unsupported required acks: %d", c.requiredAcks)
+	}
+
+	if c.linger < 0 || c.batchSize < 0 || c.batchBytes < 0 {
+		return fmt.Errorf("negative producer option: linger %s, batch size %d, batch bytes %d",
+			c.linger, c.batchSize, c.batchBytes)
+	}
+
+	w, err := c.newWriter()
+	if err != nil {
+		return err
+	}
+	c.writer = w
+
+	return nil
+}
+
+// newWriter creates a writer for the publications, with the producer options.
+func (c *Controller) newWriter() (*kafka.Writer, error) {
+	compression, err := c.compression.codec()
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Writer{
+		Addr:         kafka.TCP(c.hosts...),
+		Balancer:     &keyBalancer{},
+		BatchSize:    c.batchSize,
+		BatchBytes:   c.batchBytes,
+		BatchTimeout: c.linger,
+		RequiredAcks: kafka.RequiredAcks(c.requiredAcks),
+		Compression:  compression,
+		Transport:    c.newTransport(),
+	}, nil
+}
+
+// newTransport creates a transport for the requests that are not made with a
+// connection from the dialer.
+func (c *Controller) newTransport() *kafka.Transport {
+	return &kafka.Transport{
+		// reuse the optionally TLS and SASLMechanism from dialer provided by the user to pass it to the writer
+		// it can be nil
+		TLS:  c.dialer.TLS.Clone(),
+		SASL: c.dialer.SASLMechanism,
+	}
+}
+
+// keyBalancer is the balancer of the published messages: messages with a key
+// are hashed on their partition to keep their order, while the others are
+// sent to the partitions with the least bytes.
+type keyBalancer struct {
+	hash       kafka.Hash
+	leastBytes kafka.LeastBytes
+}
+
+// Balance returns the partition of the message.
+func (b *keyBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	if len(msg.Key) > 0 {
+		return b.hash.Balance(msg, partitions...)
+	}
+	return b.leastBytes.Balance(msg, partitions...)
+}
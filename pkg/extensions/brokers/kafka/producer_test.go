@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducerOptions(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithCompression(CompressionZstd),
+		WithLinger(50*time.Millisecond),
+		WithBatchSize(500),
+		WithBatchBytes(4<<20),
+		WithRequiredAcks(AcksAll))
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, kafka.Zstd, c.writer.Compression)
+	assert.Equal(t, 50*time.Millisecond, c.writer.BatchTimeout)
+	assert.Equal(t, 500, c.writer.BatchSize)
+	assert.Equal(t, int64(4<<20), c.writer.BatchBytes)
+	assert.Equal(t, kafka.RequireAll, c.writer.RequiredAcks)
+}
+
+func TestProducerDefaultOptions(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false))
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, kafka.Compression(0), c.writer.Compression)
+	assert.Equal(t, DefaultLinger, c.writer.BatchTimeout)
+	assert.Equal(t, kafka.RequireNone, c.writer.RequiredAcks)
+}
+
+func TestProducerInvalidOptions(t *testing.T) {
+	for _, option := range []ControllerOption{
+		WithCompression("brotli"),
+		WithRequiredAcks(2),
+		WithLinger(-time.Second),
+		WithBatchSize(-1),
+	} {
+		_, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false), option)
+		assert.Error(t, err)
+	}
+}
+
+func TestKeyBalancer(t *testing.T) {
+	b := &keyBalancer{}
+	partitions := []int{0, 1, 2, 3}
+
+	// The messages with the same key are always on the same partition
+	p := b.Balance(kafka.Message{Key: []byte("user-1")}, partitions...)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, p, b.Balance(kafka.Message{Key: []byte("user-1")}, partitions...))
+	}
+
+	// The others are spread over the partitions
+	used := make(map[int]bool)
+	for i := 0; i < len(partitions); i++ {
+		used[b.Balance(kafka.Message{Value: []byte("v")}, partitions...)] = true
+	}
+	assert.Len(t, used, len(partitions))
+}
@@ -0,0 +1,270 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// ErrSchemaNotFound is returned when the schema of a message can't be found in
+// the schema registry.
+var ErrSchemaNotFound = fmt.Errorf("%w: schema not found", extensions.ErrAsyncAPI)
+
+// SubjectNameStrategy is the strategy used to get the subject of the schemas
+// of a channel in the schema registry.
+type SubjectNameStrategy string
+
+const (
+	// TopicNameStrategy uses '<topic>-value' as subject. This is the default.
+	TopicNameStrategy SubjectNameStrategy = "topic"
+	// RecordNameStrategy uses the full name of the Avro record as subject.
+	RecordNameStrategy SubjectNameStrategy = "record"
+	// TopicRecordNameStrategy uses '<topic>-<record full name>' as subject.
+	TopicRecordNameStrategy SubjectNameStrategy = "topic-record"
+)
+
+// schemaRegistryContentType is the content type of the schema registry API.
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// SchemaRegistryConfig is the configuration of the Confluent Schema Registry
+// used to encode the payloads with Avro.
+type SchemaRegistryConfig struct {
+	// URL is the URL of the schema registry.
+	URL string
+	// Username and Password are used for basic authentication, if set.
+	Username string
+	Password string
+	// HTTPClient is the client used to reach the schema registry. If not set,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// SubjectNameStrategy is the strategy used to get the subjects of the
+	// schemas. If not set, TopicNameStrategy is used.
+	SubjectNameStrategy SubjectNameStrategy
+	// Schemas are the Avro schemas used to publish on the channels, by channel.
+	// Without a schema for a channel, the latest version of its subject is used,
+	// which is only possible with TopicNameStrategy.
+	Schemas map[string]string
+	// AutoRegister registers the schemas of Schemas in the registry, instead
+	// of only looking them up.
+	AutoRegister bool
+}
+
+// schemaRegistry is a client of a Confluent Schema Registry.
+type schemaRegistry struct {
+	config  SchemaRegistryConfig
+	schemas map[string]avro.Schema
+
+	mu          sync.Mutex
+	schemasByID map[int]avro.Schema
+	channelIDs  map[string]int
+}
+
+func newSchemaRegistry(config SchemaRegistryConfig) (*schemaRegistry, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	switch config.SubjectNameStrategy {
+	case "":
+		config.SubjectNameStrategy = TopicNameStrategy
+	case TopicNameStrategy, RecordNameStrategy, TopicRecordNameStrategy:
+	default:
+		return nil, fmt.Errorf("invalid subject name strategy: %q", config.SubjectNameStrategy)
+	}
+
+	// Parse the schemas of the channels
+	schemas := make(map[string]avro.Schema, len(config.Schemas))
+	for channel, s := range config.Schemas {
+		schema, err := avro.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Avro schema for channel %q: %w", channel, err)
+		}
+		schemas[channel] = schema
+	}
+
+	return &schemaRegistry{
+		config:      config,
+		schemas:     schemas,
+		schemasByID: make(map[int]avro.Schema),
+		channelIDs:  make(map[string]int),
+	}, nil
+}
+
+// subject returns the subject of the schema for the channel.
+func (r *schemaRegistry) subject(channel string, schema avro.Schema) (string, error) {
+	if r.config.SubjectNameStrategy == TopicNameStrategy {
+		return channel + "-value", nil
+	}
+
+	named, ok := schema.(avro.NamedSchema)
+	if !ok {
+		return "", fmt.Errorf("%s subject name strategy needs a named schema for channel %q",
+			r.config.SubjectNameStrategy, channel)
+	}
+
+	if r.config.SubjectNameStrategy == RecordNameStrategy {
+		return named.FullName(), nil
+	}
+	return channel + "-" + named.FullName(), nil
+}
+
+// encode encodes the JSON payload of a message published on the channel with
+// the Confluent Schema Registry wire format.
+func (r *schemaRegistry) encode(ctx context.Context, channel string, payload []byte) ([]byte, error) {
+	schema, id, err := r.channelSchema(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeWireFormat(schema, id, payload)
+}
+
+// decode decodes the payload of a received message to JSON, if it is encoded
+// with the Confluent Schema Registry wire format.
+func (r *schemaRegistry) decode(ctx context.Context, payload []byte) ([]byte, error) {
+	id, ok := wireFormatSchemaID(payload)
+	if !ok {
+		return payload, nil
+	}
+
+	schema, err := r.schemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeWireFormat(schema, payload)
+}
+
+// channelSchema returns the schema used to publish on the channel, with its ID.
+func (r *schemaRegistry) channelSchema(ctx context.Context, channel string) (avro.Schema, int, error) {
+	r.mu.Lock()
+	id, exists := r.channelIDs[channel]
+	schema := r.schemasByID[id]
+	r.mu.Unlock()
+	if exists {
+		return schema, id, nil
+	}
+
+	schema, exists = r.schemas[channel]
+	var resp subjectSchema
+	var err error
+	switch {
+	case exists:
+		subject, serr := r.subject(channel, schema)
+		if serr != nil {
+			return nil, 0, serr
+		}
+
+		// Register or look up the schema of the channel
+		path := "/subjects/" + url.PathEscape(subject)
+		if r.config.AutoRegister {
+			path += "/versions"
+		}
+		err = r.do(ctx, http.MethodPost, path, subjectSchema{Schema: schema.String()}, &resp)
+	case r.config.SubjectNameStrategy == TopicNameStrategy:
+		// Get the latest schema of the subject
+		path := "/subjects/" + url.PathEscape(channel+"-value") + "/versions/latest"
+		if err = r.do(ctx, http.MethodGet, path, nil, &resp); err == nil {
+			schema, err = avro.Parse(resp.Schema)
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: no Avro schema for channel %q", ErrSchemaNotFound, channel)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get schema of channel %q: %w", channel, err)
+	}
+
+	r.mu.Lock()
+	r.channelIDs[channel] = resp.ID
+	r.schemasByID[resp.ID] = schema
+	r.mu.Unlock()
+
+	return schema, resp.ID, nil
+}
+
+// schemaByID returns the schema with the ID, from the cache or the registry.
+func (r *schemaRegistry) schemaByID(ctx context.Context, id int) (avro.Schema, error) {
+	r.mu.Lock()
+	schema, exists := r.schemasByID[id]
+	r.mu.Unlock()
+	if exists {
+		return schema, nil
+	}
+
+	var resp subjectSchema
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+
+	schema, err := avro.Parse(resp.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	r.schemasByID[id] = schema
+	r.mu.Unlock()
+
+	return schema, nil
+}
+
+// subjectSchema is the schema representation in the requests and responses of
+// the schema registry API.
+type subjectSchema struct {
+	ID     int    `json:"id,omitempty"`
+	Schema string `json:"schema,omitempty"`
+}
+
+// schemaRegistryError is the error returned by the schema registry API.
+type schemaRegistryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// do sends a request to the schema registry API and decodes its response.
+func (r *schemaRegistry) do(ctx context.Context, method, path string, body, resp any) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.config.URL+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	if r.config.Username != "" || r.config.Password != "" {
+		req.SetBasicAuth(r.config.Username, r.config.Password)
+	}
+
+	res, err := r.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrSchemaNotFound
+	} else if res.StatusCode >= http.StatusBadRequest {
+		var e schemaRegistryError
+		_ = json.NewDecoder(res.Body).Decode(&e)
+		return fmt.Errorf("schema registry returned %d: %s", res.StatusCode, e.Message)
+	}
+
+	return json.NewDecoder(res.Body).Decode(resp)
+}
@@ -0,0 +1,210 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "shop",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "quantity", "type": "int", "default": 1},
+		{"name": "price", "type": "double"},
+		{"name": "comment", "type": ["null", "string"], "default": null},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "SHIPPED"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attributes", "type": {"type": "map", "values": "string"}},
+		{"name": "checksum", "type": {"type": "fixed", "name": "Checksum", "size": 2}},
+		{"name": "date", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+	]
+}`
+
+// fakeSchemaRegistry is a minimal Confluent Schema Registry.
+type fakeSchemaRegistry struct {
+	mu       sync.Mutex
+	schemas  []string
+	subjects map[string]int
+	requests []string
+}
+
+func newFakeSchemaRegistry(t *testing.T) (*fakeSchemaRegistry, string) {
+	fsr := &fakeSchemaRegistry{subjects: make(map[string]int)}
+	ts := httptest.NewServer(fsr)
+	t.Cleanup(ts.Close)
+	return fsr, ts.URL
+}
+
+func (fsr *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fsr.mu.Lock()
+	defer fsr.mu.Unlock()
+	fsr.requests = append(fsr.requests, r.Method+" "+r.URL.Path)
+
+	var req subjectSchema
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, _ := strconv.Atoi(parts[2])
+		if id < 1 || id > len(fsr.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(subjectSchema{Schema: fsr.schemas[id-1]})
+	case len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		fsr.schemas = append(fsr.schemas, req.Schema)
+		fsr.subjects[parts[1]] = len(fsr.schemas)
+		_ = json.NewEncoder(w).Encode(subjectSchema{ID: len(fsr.schemas)})
+	case len(parts) == 4 && parts[0] == "subjects" && parts[3] == "latest":
+		id, exists := fsr.subjects[parts[1]]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(subjectSchema{ID: id, Schema: fsr.schemas[id-1]})
+	case len(parts) == 2 && parts[0] == "subjects":
+		id, exists := fsr.subjects[parts[1]]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(subjectSchema{ID: id})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestSchemaRegistryConfigValidation(t *testing.T) {
+	_, err := newSchemaRegistry(SchemaRegistryConfig{SubjectNameStrategy: "unknown"})
+	assert.Error(t, err)
+
+	_, err = newSchemaRegistry(SchemaRegistryConfig{Schemas: map[string]string{"orders": "{"}})
+	assert.Error(t, err)
+}
+
+func TestSchemaRegistryRoundTrip(t *testing.T) {
+	fsr, url := newFakeSchemaRegistry(t)
+	r, err := newSchemaRegistry(SchemaRegistryConfig{
+		URL:          url,
+		Schemas:      map[string]string{"orders": orderSchema},
+		AutoRegister: true,
+	})
+	require.NoError(t, err)
+
+	payload := `{"id":42,"price":9.5,"comment":"fragile","status":"NEW","tags":["a","b"],` +
+		`"attributes":{"color":"red"},"checksum":"AQI=","date":"2024-05-01","createdAt":"2024-05-01T10:00:00Z"}`
+	encoded, err := r.encode(context.Background(), "orders", []byte(payload))
+	require.NoError(t, err)
+
+	// Schema has been registered under the topic subject
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, encoded[:5])
+	assert.Equal(t, map[string]int{"orders-value": 1}, fsr.subjects)
+
+	// Schema is fetched by ID by a new client
+	r, err = newSchemaRegistry(SchemaRegistryConfig{URL: url})
+	require.NoError(t, err)
+	decoded, err := r.decode(context.Background(), encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":42,"quantity":1,"price":9.5,"comment":"fragile","status":"NEW","tags":["a","b"],`+
+		`"attributes":{"color":"red"},"checksum":"AQI=","date":"2024-05-01","createdAt":"2024-05-01T10:00:00Z"}`,
+		string(decoded))
+	assert.Equal(t, "GET /schemas/ids/1", fsr.requests[len(fsr.requests)-1])
+
+	// Schema is cached
+	_, err = r.decode(context.Background(), encoded)
+	require.NoError(t, err)
+	assert.Len(t, fsr.requests, 2)
+
+	// Payloads without the wire format are kept as they are
+	decoded, err = r.decode(context.Background(), []byte(payload))
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(decoded))
+}
+
+func TestSchemaRegistryEncodingErrors(t *testing.T) {
+	_, url := newFakeSchemaRegistry(t)
+	r, err := newSchemaRegistry(SchemaRegistryConfig{
+		URL:          url,
+		Schemas:      map[string]string{"orders": orderSchema},
+		AutoRegister: true,
+	})
+	require.NoError(t, err)
+
+	for _, payload := range []string{
+		`not json`,
+		`{"id":"42"}`,
+		`{"id":4.2}`,
+		`{"id":42,"price":1,"comment":12}`,
+	} {
+		_, err := r.encode(context.Background(), "orders", []byte(payload))
+		assert.Error(t, err, payload)
+	}
+}
+
+func TestSchemaRegistrySubjectNameStrategies(t *testing.T) {
+	cases := []struct {
+		strategy SubjectNameStrategy
+		subject  string
+	}{
+		{strategy: TopicNameStrategy, subject: "orders-value"},
+		{strategy: RecordNameStrategy, subject: "shop.Order"},
+		{strategy: TopicRecordNameStrategy, subject: "orders-shop.Order"},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.strategy), func(t *testing.T) {
+			fsr, url := newFakeSchemaRegistry(t)
+
+			// Without auto registration, the schema should be registered beforehand
+			r, err := newSchemaRegistry(SchemaRegistryConfig{
+				URL:                 url,
+				SubjectNameStrategy: c.strategy,
+				Schemas:             map[string]string{"orders": orderSchema},
+			})
+			require.NoError(t, err)
+
+			_, err = r.encode(context.Background(), "orders", []byte(`{}`))
+			assert.ErrorIs(t, err, ErrSchemaNotFound)
+			assert.Equal(t, []string{"POST /subjects/" + c.subject}, fsr.requests)
+
+			fsr.schemas = append(fsr.schemas, orderSchema)
+			fsr.subjects[c.subject] = 1
+
+			_, err = r.encode(context.Background(), "orders", []byte(`{"id":1,"price":1,"status":"NEW",`+
+				`"tags":[],"attributes":{},"checksum":"AQI=","date":"2024-05-01","createdAt":"2024-05-01T10:00:00Z"}`))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSchemaRegistryLatestSchema(t *testing.T) {
+	fsr, url := newFakeSchemaRegistry(t)
+	fsr.schemas = append(fsr.schemas, `"string"`)
+	fsr.subjects["orders-value"] = 1
+
+	// Latest schema of the topic subject is used without local schema
+	r, err := newSchemaRegistry(SchemaRegistryConfig{URL: url})
+	require.NoError(t, err)
+	encoded, err := r.encode(context.Background(), "orders", []byte(`"order"`))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1, 10, 'o', 'r', 'd', 'e', 'r'}, encoded)
+
+	// Only possible with the topic name strategy
+	r, err = newSchemaRegistry(SchemaRegistryConfig{URL: url, SubjectNameStrategy: RecordNameStrategy})
+	require.NoError(t, err)
+	_, err = r.encode(context.Background(), "orders", []byte(`"order"`))
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
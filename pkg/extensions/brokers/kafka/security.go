@@ -0,0 +1,232 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASLMechanism is the SASL mechanism used to authenticate to Kafka.
+type SASLMechanism string
+
+const (
+	// SASLPlain is the PLAIN mechanism, sending the credentials in clear text:
+	// it should be used with TLS.
+	SASLPlain SASLMechanism = "PLAIN"
+	// SASLScramSHA256 is the SCRAM-SHA-256 mechanism.
+	SASLScramSHA256 SASLMechanism = "SCRAM-SHA-256"
+	// SASLScramSHA512 is the SCRAM-SHA-512 mechanism.
+	SASLScramSHA512 SASLMechanism = "SCRAM-SHA-512"
+)
+
+// saslCredentials are the credentials set with WithSASL.
+type saslCredentials struct {
+	mechanism SASLMechanism
+	username  string
+	password  string
+}
+
+// saslMechanism returns the kafka-go implementation of the SASL mechanism.
+func (sc saslCredentials) saslMechanism() (sasl.Mechanism, error) {
+	switch sc.mechanism {
+	case SASLPlain:
+		return plain.Mechanism{Username: sc.username, Password: sc.password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, sc.username, sc.password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, sc.username, sc.password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %q", sc.mechanism)
+	}
+}
+
+// TLSConfig is the configuration of the TLS connections to Kafka, based on PEM
+// files.
+type TLSConfig struct {
+	// CAFile is the CA certificate used to verify the brokers. If not set, the
+	// system certificates are used.
+	CAFile string
+	// CertFile and KeyFile are the client certificate and key, used to
+	// authenticate with mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name used to verify the brokers certificates. If not
+	// set, the host of the brokers is used.
+	ServerName string
+	// InsecureSkipVerify disables the verification of the brokers certificates.
+	InsecureSkipVerify bool
+}
+
+// tlsConfig creates the tls.Config from the files.
+func (tc TLSConfig) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         tc.ServerName,
+		InsecureSkipVerify: tc.InsecureSkipVerify, //nolint:gosec // this is up to the user
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if tc.CAFile != "" {
+		ca, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificate found in %q", tc.CAFile)
+		}
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// WithSASL set the SASL mechanism (SASLPlain, SASLScramSHA256 or SASLScramSHA512)
+// and credentials used to authenticate to Kafka.
+func WithSASL(mechanism SASLMechanism, username, password string) ControllerOption {
+	return func(controller *Controller) {
+		controller.saslCredentials = &saslCredentials{
+			mechanism: mechanism,
+			username:  username,
+			password:  password,
+		}
+	}
+}
+
+// WithTLSConfig set the TLS configuration used to connect to Kafka from PEM
+// files, with a client certificate for mutual TLS if CertFile and KeyFile are set.
+func WithTLSConfig(config TLSConfig) ControllerOption {
+	return func(controller *Controller) {
+		controller.tlsFilesConfig = &config
+	}
+}
+
+// security are the security schemes and credentials set with WithSecurity.
+type security struct {
+	schemes     []extensions.SecurityScheme
+	credentials extensions.Credentials
+}
+
+// WithSecurity authenticates to Kafka with the first of the security schemes
+// (plain, scramSha256, scramSha512, userPassword as PLAIN, X509 or oauth2 as
+// OAUTHBEARER) whose credentials are given. The creation of the controller
+// fails if there are security schemes but none of them can be used.
+func WithSecurity(schemes []extensions.SecurityScheme, credentials extensions.Credentials) ControllerOption {
+	return func(controller *Controller) {
+		controller.security = &security{schemes: schemes, credentials: credentials}
+	}
+}
+
+// apply sets the SASL credentials or the TLS configuration of the controller
+// from the selected security scheme.
+func (s security) apply(c *Controller) error {
+	scheme, err := extensions.SelectSecurityScheme(s.schemes, s.credentials,
+		extensions.SecuritySchemePlain, extensions.SecuritySchemeScramSha256, extensions.SecuritySchemeScramSha512,
+		extensions.SecuritySchemeUserPassword, extensions.SecuritySchemeX509, extensions.SecuritySchemeOAuth2)
+	if err != nil || scheme == nil {
+		return err
+	}
+
+	switch scheme.Type {
+	case extensions.SecuritySchemePlain, extensions.SecuritySchemeUserPassword:
+		c.saslCredentials = &saslCredentials{
+			mechanism: SASLPlain,
+			username:  s.credentials.UserPassword.Username,
+			password:  s.credentials.UserPassword.Password,
+		}
+	case extensions.SecuritySchemeScramSha256, extensions.SecuritySchemeScramSha512:
+		mechanism := SASLScramSHA256
+		if scheme.Type == extensions.SecuritySchemeScramSha512 {
+			mechanism = SASLScramSHA512
+		}
+		c.saslCredentials = &saslCredentials{
+			mechanism: mechanism,
+			username:  s.credentials.UserPassword.Username,
+			password:  s.credentials.UserPassword.Password,
+		}
+	case extensions.SecuritySchemeX509:
+		serverName := ""
+		if c.tlsFilesConfig != nil {
+			serverName = c.tlsFilesConfig.ServerName
+		}
+		c.tlsFilesConfig = &TLSConfig{
+			CAFile:     s.credentials.X509.CAFile,
+			CertFile:   s.credentials.X509.CertFile,
+			KeyFile:    s.credentials.X509.KeyFile,
+			ServerName: serverName,
+		}
+	case extensions.SecuritySchemeOAuth2:
+		c.saslCredentials = nil
+		c.dialer.SASLMechanism = oauthBearer{credentials: *s.credentials.OAuth2, scheme: *scheme}
+	}
+
+	return nil
+}
+
+// oauthBearer is the SASL OAUTHBEARER mechanism, requesting a new access token
+// at each connection.
+type oauthBearer struct {
+	credentials extensions.OAuth2ClientCredentials
+	scheme      extensions.SecurityScheme
+}
+
+func (ob oauthBearer) Name() string {
+	return "OAUTHBEARER"
+}
+
+func (ob oauthBearer) Start(ctx context.Context) (sasl.StateMachine, []byte, error) {
+	token, err := ob.credentials.Token(ctx, ob.scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ob, []byte("n,,\x01auth=Bearer " + token + "\x01\x01"), nil
+}
+
+func (ob oauthBearer) Next(_ context.Context, challenge []byte) (bool, []byte, error) {
+	// A challenge is only sent by the server on failure
+	if len(challenge) > 0 {
+		return false, nil, fmt.Errorf("OAUTHBEARER authentication failed: %s", challenge)
+	}
+	return true, nil, nil
+}
+
+// applySecurityOptions sets the SASL mechanism and TLS configuration of the
+// dialer from WithSASL and WithTLSConfig, as they can fail.
+func (c *Controller) applySecurityOptions() error {
+	if c.security != nil {
+		if err := c.security.apply(c); err != nil {
+			return err
+		}
+	}
+
+	if c.saslCredentials != nil {
+		mechanism, err := c.saslCredentials.saslMechanism()
+		if err != nil {
+			return err
+		}
+		c.dialer.SASLMechanism = mechanism
+	}
+
+	if c.tlsFilesConfig != nil {
+		config, err := c.tlsFilesConfig.tlsConfig()
+		if err != nil {
+			return err
+		}
+		c.dialer.TLS = config
+	}
+
+	return nil
+}
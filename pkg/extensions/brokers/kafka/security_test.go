@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSASL(t *testing.T) {
+	for _, mechanism := range []SASLMechanism{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		c, err := NewController([]string{"localhost:9092"},
+			WithConnectionTest(false),
+			WithSASL(mechanism, "user", "password"))
+		require.NoError(t, err)
+		assert.Equal(t, string(mechanism), c.dialer.SASLMechanism.Name())
+	}
+
+	_, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithSASL("GSSAPI", "user", "password"))
+	assert.Error(t, err)
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		return path
+	}
+
+	key, cert, ca, err := testutil.GenerateSelfSignedCertificateWithCA("localhost")
+	require.NoError(t, err)
+	keyFile, certFile, caFile := write("key.pem", key), write("cert.pem", cert), write("ca.pem", ca)
+	invalid := write("invalid.pem", []byte("invalid"))
+
+	// Mutual TLS
+	c, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithTLSConfig(TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "kafka"}))
+	require.NoError(t, err)
+	assert.Len(t, c.dialer.TLS.Certificates, 1)
+	assert.NotNil(t, c.dialer.TLS.RootCAs)
+	assert.Equal(t, "kafka", c.dialer.TLS.ServerName)
+
+	// Default dialer is not modified
+	c, err = NewController([]string{"localhost:9092"}, WithConnectionTest(false))
+	require.NoError(t, err)
+	assert.Nil(t, c.dialer.TLS)
+
+	// Invalid files
+	for _, config := range []TLSConfig{
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CAFile: invalid},
+		{CertFile: certFile},
+		{CertFile: invalid, KeyFile: invalid},
+	} {
+		_, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false), WithTLSConfig(config))
+		assert.Error(t, err)
+	}
+}
+
+func TestWithSecurity(t *testing.T) {
+	userPassword := extensions.Credentials{
+		UserPassword: &extensions.UserPasswordCredentials{Username: "user", Password: "password"},
+	}
+
+	// SASL mechanisms from the security schemes
+	for scheme, mechanism := range map[string]SASLMechanism{
+		extensions.SecuritySchemePlain:        SASLPlain,
+		extensions.SecuritySchemeUserPassword: SASLPlain,
+		extensions.SecuritySchemeScramSha256:  SASLScramSHA256,
+		extensions.SecuritySchemeScramSha512:  SASLScramSHA512,
+	} {
+		c, err := NewController([]string{"localhost:9092"},
+			WithConnectionTest(false),
+			WithSecurity([]extensions.SecurityScheme{{Type: scheme}}, userPassword))
+		require.NoError(t, err)
+		assert.Equal(t, string(mechanism), c.dialer.SASLMechanism.Name())
+	}
+
+	// OAUTHBEARER, with a token requested at each connection
+	c, err := NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithSecurity([]extensions.SecurityScheme{{Type: extensions.SecuritySchemeOAuth2}}, extensions.Credentials{
+			OAuth2: &extensions.OAuth2ClientCredentials{ClientID: "client", ClientSecret: "secret"},
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "OAUTHBEARER", c.dialer.SASLMechanism.Name())
+	_, _, err = c.dialer.SASLMechanism.Start(context.Background())
+	assert.Error(t, err)
+
+	// No security scheme
+	c, err = NewController([]string{"localhost:9092"}, WithConnectionTest(false), WithSecurity(nil, userPassword))
+	require.NoError(t, err)
+	assert.Nil(t, c.dialer.SASLMechanism)
+
+	// Missing credentials
+	_, err = NewController([]string{"localhost:9092"},
+		WithConnectionTest(false),
+		WithSecurity([]extensions.SecurityScheme{{Type: extensions.SecuritySchemeX509}}, userPassword))
+	assert.ErrorIs(t, err, extensions.ErrMissingCredentials)
+}
+
+func TestOAuthBearer(t *testing.T) {
+	ob := oauthBearer{}
+
+	done, _, err := ob.Next(context.Background(), nil)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	_, _, err = ob.Next(context.Background(), []byte(`{"status":"invalid_token"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrTopicMismatch is raised when an existing topic doesn't have the partitions
+// or the replicas of its Kafka channel bindings.
+var ErrTopicMismatch = fmt.Errorf("%w: topic doesn't match its bindings", extensions.ErrAsyncAPI)
+
+// WithTopic creates the topic with the partitions and replicas of its Kafka
+// channel bindings when the controller is created, or checks that the existing
+// topic has them. The bindings can be nil to create the topic with one
+// partition and one replica, without checking an existing topic.
+//
+// The bindings are also used to create the topic on the first publication or
+// subscription, instead of the bindings set by the generated controllers.
+func WithTopic(topic string, bindings *extensions.KafkaChannelBindings) ControllerOption {
+	return func(controller *Controller) {
+		if controller.topics == nil {
+			controller.topics = make(map[string]extensions.KafkaChannelBindings)
+		}
+
+		var b extensions.KafkaChannelBindings
+		if bindings != nil {
+			b = *bindings
+		}
+		controller.topics[topic] = b
+	}
+}
+
+// topicBindings returns the Kafka bindings of the topic, from WithTopic or from
+// the channel bindings set in the context by the generated controllers.
+func (c *Controller) topicBindings(ctx context.Context, topic string) extensions.KafkaChannelBindings {
+	if b, exists := c.topics[topic]; exists {
+		return b
+	}
+
+	var b extensions.KafkaChannelBindings
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannelBindings, func(cb extensions.ChannelBindings) {
+		if cb.Kafka != nil {
+			b = *cb.Kafka
+		}
+	})
+	return b
+}
+
+// checkTopics creates the topics set with WithTopic, or checks that the
+// existing ones match their bindings.
+func (c *Controller) checkTopics(ctx context.Context) error {
+	for _, topic := range utils.MapKeysToSortedList(c.topics) {
+		bindings := c.topics[topic]
+
+		partitions, err := c.createTopic(ctx, topic, bindings)
+		if err != nil {
+			return fmt.Errorf("could not create topic %q: %w", topic, err)
+		}
+
+		if err := checkTopicPartitions(topic, bindings, partitions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkTopicPartitions checks that the partitions of the topic have the number
+// of partitions and replicas of its bindings, when they are set.
+func checkTopicPartitions(topic string, bindings extensions.KafkaChannelBindings, partitions []kafka.Partition) error {
+	if bindings.Partitions > 0 && len(partitions) != bindings.Partitions {
+		return fmt.Errorf("%w: topic %q has %d partitions instead of %d",
+			ErrTopicMismatch, topic, len(partitions), bindings.Partitions)
+	}
+
+	if bindings.Replicas > 0 {
+		for _, p := range partitions {
+			if len(p.Replicas) != bindings.Replicas {
+				return fmt.Errorf("%w: partition %d of topic %q has %d replicas instead of %d",
+					ErrTopicMismatch, p.ID, topic, len(p.Replicas), bindings.Replicas)
+			}
+		}
+	}
+
+	return nil
+}
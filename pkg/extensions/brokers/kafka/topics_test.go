@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTopicPartitions(t *testing.T) {
+	partitions := []kafka.Partition{
+		{Topic: "orders", ID: 0, Replicas: make([]kafka.Broker, 3)},
+		{Topic: "orders", ID: 1, Replicas: make([]kafka.Broker, 3)},
+	}
+
+	cases := []struct {
+		bindings extensions.KafkaChannelBindings
+		err      error
+	}{
+		{bindings: extensions.KafkaChannelBindings{}},
+		{bindings: extensions.KafkaChannelBindings{Partitions: 2, Replicas: 3}},
+		{bindings: extensions.KafkaChannelBindings{Partitions: 4}, err: ErrTopicMismatch},
+		{bindings: extensions.KafkaChannelBindings{Replicas: 1}, err: ErrTopicMismatch},
+	}
+
+	for _, c := range cases {
+		err := checkTopicPartitions("orders", c.bindings, partitions)
+		if c.err != nil {
+			assert.ErrorIs(t, err, c.err, c.bindings)
+		} else {
+			assert.NoError(t, err, c.bindings)
+		}
+	}
+}
+
+func TestTopicBindings(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false))
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The bindings are read from the context
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsChannelBindings, extensions.ChannelBindings{
+		Kafka: &extensions.KafkaChannelBindings{Partitions: 3, Replicas: 2},
+	})
+	assert.Equal(t, extensions.KafkaChannelBindings{Partitions: 3, Replicas: 2}, c.topicBindings(ctx, "orders"))
+	assert.Equal(t, extensions.KafkaChannelBindings{}, c.topicBindings(context.Background(), "orders"))
+
+	// Unless they are set on the controller
+	c.topics = map[string]extensions.KafkaChannelBindings{"orders": {Partitions: 6}}
+	assert.Equal(t, extensions.KafkaChannelBindings{Partitions: 6}, c.topicBindings(ctx, "orders"))
+}
@@ -0,0 +1,418 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+)
+
+// TransactionTimeout is the time after which a transaction that is not
+// committed is aborted by Kafka.
+const TransactionTimeout = time.Minute
+
+var (
+	// ErrNoTransactionalProducer is raised when publishing in a transaction with
+	// a controller without transactional producer.
+	ErrNoTransactionalProducer = fmt.Errorf("%w: no transactional producer, set with WithTransactionalProducer",
+		extensions.ErrAsyncAPI)
+	// ErrTransactionEnded is raised when publishing with the context of a
+	// transaction that is committed or aborted.
+	ErrTransactionEnded = fmt.Errorf("%w: transaction already ended", extensions.ErrAsyncAPI)
+)
+
+// WithTransactionalProducer set the transactional ID of the producer of the
+// publications done with PublishInTransaction. It should be the same across the
+// restarts of an instance, and unique among the running instances, so Kafka
+// can abort the transactions left by a previous run of the instance.
+func WithTransactionalProducer(id string) ControllerOption {
+	return func(controller *Controller) {
+		controller.transactionalID = id
+	}
+}
+
+// WithReadCommitted set if the subscriptions only read the messages published
+// outside of a transaction or in a committed one, instead of all the messages.
+func WithReadCommitted(enabled bool) ControllerOption {
+	return func(controller *Controller) {
+		controller.readCommitted = enabled
+	}
+}
+
+// isolationLevel returns the isolation level of the readers of the subscriptions.
+func (c *Controller) isolationLevel() kafka.IsolationLevel {
+	if c.readCommitted {
+		return kafka.ReadCommitted
+	}
+	return kafka.ReadUncommitted
+}
+
+// PublishInTransaction calls the function with a context whose publications on
+// the controller are done in a transaction: they are visible to the consumers
+// reading the committed messages only if the function returns no error, and
+// they are aborted otherwise.
+//
+// When the context is the one of a message received by the controller (with
+// its delivery metadata), its offset is committed for the consumer group of
+// the controller in the same transaction. This way, a handler can consume a
+// message, publish its replies and commit its offset atomically:
+//
+//	func (s subscriber) PingOperationReceived(ctx context.Context, msg PingMessage) error {
+//		return s.broker.PublishInTransaction(ctx, func(ctx context.Context) error {
+//			return s.app.ReplyToPingOperation(ctx, msg, func(reply *PongMessage) { /* ... */ })
+//		})
+//	}
+//
+// The transactions of a controller are done one at a time.
+func (c *Controller) PublishInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if c.transactions == nil {
+		return ErrNoTransactionalProducer
+	} else if _, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		return fmt.Errorf("%w: nested transactions are not supported", extensions.ErrAsyncAPI)
+	}
+
+	return c.transactions.run(ctx, func(tx *transaction) error {
+		if err := fn(context.WithValue(ctx, transactionKey{}, tx)); err != nil {
+			return err
+		}
+		return c.commitReceivedOffset(ctx, tx)
+	})
+}
+
+// transactionKey is the key of the transaction in the context of its publications.
+type transactionKey struct{}
+
+// topicPartition is a partition of a topic.
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// transactionalProducer is the producer of the transactions of a controller.
+// As kafka-go writes the record batches without producer, they are encoded
+// with the producer session of the transactional ID before being published.
+type transactionalProducer struct {
+	// mu allows one transaction at a time
+	mu        sync.Mutex
+	id        string
+	client    *kafka.Client
+	transport *kafka.Transport
+	balancer  keyBalancer
+
+	// session is the producer of the transactional ID, initialized on the first
+	// transaction, or after a failed one
+	session   *kafka.ProducerSession
+	sequences map[topicPartition]int32
+}
+
+// newTransactionalProducer creates the producer of the transactions of the
+// controller, with its transactional ID.
+func (c *Controller) newTransactionalProducer() *transactionalProducer {
+	transport := c.newTransport()
+	return &transactionalProducer{
+		id:        c.transactionalID,
+		client:    &kafka.Client{Addr: kafka.TCP(c.hosts...), Transport: transport},
+		transport: transport,
+	}
+}
+
+// run calls the function in a new transaction, committed if it returns no
+// error and aborted otherwise.
+func (p *transactionalProducer) run(ctx context.Context, fn func(tx *transaction) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.initSession(ctx); err != nil {
+		return fmt.Errorf("could not initialize transactional producer %q: %w", p.id, err)
+	}
+
+	tx := &transaction{producer: p, partitions: make(map[topicPartition]bool)}
+	err := fn(tx)
+
+	if errEnd := tx.end(ctx, err == nil); errEnd != nil {
+		err = errors.Join(err, errEnd)
+	}
+
+	// Start with a new session after a failure, as the sequences of the
+	// partitions may not be the ones expected by Kafka anymore
+	if err != nil {
+		p.session = nil
+	}
+
+	return err
+}
+
+// initSession initializes the producer session of the transactional ID, if
+// there is none. The transactions of a previous session are aborted by Kafka.
+func (p *transactionalProducer) initSession(ctx context.Context) error {
+	if p.session != nil {
+		return nil
+	}
+
+	resp, err := p.client.InitProducerID(ctx, &kafka.InitProducerIDRequest{
+		TransactionalID:      p.id,
+		TransactionTimeoutMs: int(TransactionTimeout.Milliseconds()),
+		ProducerID:           -1,
+		ProducerEpoch:        -1,
+	})
+	if err != nil {
+		return err
+	} else if resp.Error != nil {
+		return resp.Error
+	}
+
+	p.session = resp.Producer
+	p.sequences = make(map[topicPartition]int32)
+	return nil
+}
+
+// close releases the connections of the producer.
+func (p *transactionalProducer) close() {
+	p.transport.CloseIdleConnections()
+}
+
+// transaction is a transaction of the transactional producer.
+type transaction struct {
+	// mu protects the transaction from concurrent publications
+	mu         sync.Mutex
+	producer   *transactionalProducer
+	partitions map[topicPartition]bool
+	topics     map[string][]int
+	offsets    bool
+	ended      bool
+}
+
+// addPartition adds the partition to the transaction, if it is not yet.
+func (tx *transaction) addPartition(ctx context.Context, tp topicPartition) error {
+	if tx.partitions[tp] {
+		return nil
+	}
+
+	p := tx.producer
+	resp, err := p.client.AddPartitionsToTxn(ctx, &kafka.AddPartitionsToTxnRequest{
+		TransactionalID: p.id,
+		ProducerID:      p.session.ProducerID,
+		ProducerEpoch:   p.session.ProducerEpoch,
+		Topics:          map[string][]kafka.AddPartitionToTxn{tp.topic: {{Partition: tp.partition}}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, added := range resp.Topics[tp.topic] {
+		if added.Error != nil {
+			return fmt.Errorf("could not add partition %d of topic %q to transaction: %w",
+				added.Partition, tp.topic, added.Error)
+		}
+	}
+
+	tx.partitions[tp] = true
+	return nil
+}
+
+// end commits or aborts the transaction, if something has been added to it.
+func (tx *transaction) end(ctx context.Context, commit bool) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.ended = true
+	if len(tx.partitions) == 0 && !tx.offsets {
+		return nil
+	}
+
+	p := tx.producer
+	resp, err := p.client.EndTxn(ctx, &kafka.EndTxnRequest{
+		TransactionalID: p.id,
+		ProducerID:      p.session.ProducerID,
+		ProducerEpoch:   p.session.ProducerEpoch,
+		Committed:       commit,
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Error
+}
+
+// publishInTransaction publishes the messages on the topic in the transaction,
+// in a record batch per partition.
+func (c *Controller) publishInTransaction(
+	ctx context.Context,
+	tx *transaction,
+	topic string,
+	msgs []kafka.Message,
+) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.ended {
+		return ErrTransactionEnded
+	}
+
+	// Get the partitions of the topic, creating it if it doesn't exist
+	partitions, exists := tx.topics[topic]
+	if !exists {
+		topicPartitions, err := c.createTopic(ctx, topic, c.topicBindings(ctx, topic))
+		if err != nil {
+			return err
+		}
+		for _, p := range topicPartitions {
+			partitions = append(partitions, p.ID)
+		}
+		if tx.topics == nil {
+			tx.topics = make(map[string][]int)
+		}
+		tx.topics[topic] = partitions
+	}
+
+	// Group the messages by partition, keeping their order
+	p := tx.producer
+	var order []topicPartition
+	batches := make(map[topicPartition][]kafka.Message)
+	for _, msg := range msgs {
+		tp := topicPartition{topic: topic, partition: p.balancer.Balance(msg, partitions...)}
+		if _, exists := batches[tp]; !exists {
+			order = append(order, tp)
+		}
+		batches[tp] = append(batches[tp], msg)
+	}
+
+	compression, err := c.compression.codec()
+	if err != nil {
+		return err
+	}
+
+	for _, tp := range order {
+		if err := tx.addPartition(ctx, tp); err != nil {
+			return err
+		}
+
+		records, err := transactionalRecords(batches[tp], compression, *p.session, p.sequences[tp])
+		if err != nil {
+			return err
+		}
+
+		resp, err := p.client.RawProduce(ctx, &kafka.RawProduceRequest{
+			Topic:           tp.topic,
+			Partition:       tp.partition,
+			RequiredAcks:    kafka.RequireAll,
+			TransactionalID: p.id,
+			RawRecords:      records,
+		})
+		if err != nil {
+			return err
+		} else if resp.Error != nil {
+			return resp.Error
+		}
+
+		p.sequences[tp] += int32(len(batches[tp]))
+	}
+
+	return nil
+}
+
+// commitReceivedOffset commits in the transaction the offset of the message
+// received by the controller, if the context is the one of a received message.
+func (c *Controller) commitReceivedOffset(ctx context.Context, tx *transaction) error {
+	md, ok := extensions.MessageMetadataFromContext(ctx)
+	if !ok || md.RoutingKey == "" || c.groupID == "" {
+		return nil
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	p := tx.producer
+	added, err := p.client.AddOffsetsToTxn(ctx, &kafka.AddOffsetsToTxnRequest{
+		TransactionalID: p.id,
+		ProducerID:      p.session.ProducerID,
+		ProducerEpoch:   p.session.ProducerEpoch,
+		GroupID:         c.groupID,
+	})
+	if err != nil {
+		return err
+	} else if added.Error != nil {
+		return added.Error
+	}
+	tx.offsets = true
+
+	// The generation of the consumer group is not known, so it is not checked
+	committed, err := p.client.TxnOffsetCommit(ctx, &kafka.TxnOffsetCommitRequest{
+		TransactionalID: p.id,
+		GroupID:         c.groupID,
+		ProducerID:      p.session.ProducerID,
+		ProducerEpoch:   p.session.ProducerEpoch,
+		GenerationID:    -1,
+		Topics: map[string][]kafka.TxnOffsetCommit{
+			md.RoutingKey: {{Partition: md.Partition, Offset: md.Offset + 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, partition := range committed.Topics[md.RoutingKey] {
+		if partition.Error != nil {
+			return fmt.Errorf("could not commit offset of partition %d of topic %q in transaction: %w",
+				partition.Partition, md.RoutingKey, partition.Error)
+		}
+	}
+
+	return nil
+}
+
+// Offsets of the fields of a record batch, after the size of the record set.
+const (
+	recordBatchCRCOffset        = 4 + 17
+	recordBatchAttributesOffset = 4 + 21
+	recordBatchProducerOffset   = 4 + 43
+	recordBatchEpochOffset      = 4 + 51
+	recordBatchSequenceOffset   = 4 + 53
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// transactionalRecords encodes the messages in a transactional record batch
+// of the producer session, starting at the sequence. The batch is encoded by
+// kafka-go, that writes it without producer, then the producer is set and the
+// checksum of the batch updated.
+func transactionalRecords(
+	msgs []kafka.Message,
+	compression kafka.Compression,
+	session kafka.ProducerSession,
+	sequence int32,
+) (protocol.RawRecordSet, error) {
+	records := make([]protocol.Record, 0, len(msgs))
+	for _, msg := range msgs {
+		records = append(records, protocol.Record{
+			Time:    msg.Time,
+			Key:     protocol.NewBytes(msg.Key),
+			Value:   protocol.NewBytes(msg.Value),
+			Headers: msg.Headers,
+		})
+	}
+
+	rs := protocol.RecordSet{
+		Version:    2,
+		Attributes: protocol.Attributes(compression)&0x7 | protocol.Transactional,
+		Records:    protocol.NewRecordReader(records...),
+	}
+
+	var buf bytes.Buffer
+	if _, err := rs.WriteTo(&buf); err != nil {
+		return protocol.RawRecordSet{}, err
+	}
+
+	b := buf.Bytes()
+	binary.BigEndian.PutUint64(b[recordBatchProducerOffset:], uint64(session.ProducerID))
+	binary.BigEndian.PutUint16(b[recordBatchEpochOffset:], uint16(session.ProducerEpoch))
+	binary.BigEndian.PutUint32(b[recordBatchSequenceOffset:], uint32(sequence))
+	binary.BigEndian.PutUint32(b[recordBatchCRCOffset:], crc32.Checksum(b[recordBatchAttributesOffset:], castagnoliTable))
+
+	return protocol.RawRecordSet{Reader: bytes.NewReader(b)}, nil
+}
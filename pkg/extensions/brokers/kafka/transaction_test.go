@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionalRecords(t *testing.T) {
+	msgs := []kafka.Message{
+		{Key: []byte("key"), Value: []byte("first"), Headers: []kafka.Header{{Key: "h", Value: []byte("v")}}},
+		{Value: []byte("second")},
+	}
+	session := kafka.ProducerSession{ProducerID: 42, ProducerEpoch: 3}
+
+	for _, compression := range []kafka.Compression{0, kafka.Gzip} {
+		records, err := transactionalRecords(msgs, compression, session, 7)
+		require.NoError(t, err)
+		b, err := io.ReadAll(records.Reader)
+		require.NoError(t, err)
+
+		// The checksum covers the batch from its attributes
+		crc := crc32.Checksum(b[recordBatchAttributesOffset:], crc32.MakeTable(crc32.Castagnoli))
+		assert.Equal(t, crc, binary.BigEndian.Uint32(b[recordBatchCRCOffset:]))
+
+		// The batch is transactional, with the producer and the sequence
+		var rs protocol.RecordSet
+		_, err = rs.ReadFrom(bytes.NewReader(b))
+		require.NoError(t, err)
+		assert.True(t, rs.Attributes.Transactional())
+		assert.Equal(t, compression, rs.Attributes.Compression())
+
+		stream, ok := rs.Records.(*protocol.RecordStream)
+		require.True(t, ok)
+		require.Len(t, stream.Records, 1)
+		batch, ok := stream.Records[0].(*protocol.RecordBatch)
+		require.True(t, ok)
+		assert.Equal(t, int64(42), batch.ProducerID)
+		assert.Equal(t, int16(3), batch.ProducerEpoch)
+		assert.Equal(t, int32(7), batch.BaseSequence)
+
+		var values []string
+		for {
+			r, err := batch.ReadRecord()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			v, err := protocol.ReadAll(r.Value)
+			require.NoError(t, err)
+			values = append(values, string(v))
+		}
+		assert.Equal(t, []string{"first", "second"}, values)
+	}
+}
+
+func TestPublishInTransactionWithoutProducer(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false))
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.PublishInTransaction(context.Background(), func(_ context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrNoTransactionalProducer)
+}
+
+func TestReadCommitted(t *testing.T) {
+	c, err := NewController([]string{"localhost:9092"}, WithConnectionTest(false), WithReadCommitted(true))
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, kafka.ReadCommitted, c.isolationLevel())
+}
@@ -0,0 +1,559 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Controller)(nil)
+	_ extensions.PatternSubscriber = (*Controller)(nil)
+	_ extensions.ReconnectNotifier = (*Controller)(nil)
+)
+
+const (
+	// DefaultQoS is the quality of service used when there is no MQTT
+	// operation binding: messages are delivered at least once.
+	DefaultQoS = 1
+
+	// clientIDLength is the maximum length of the client identifier that every
+	// MQTT 3.1.1 broker has to accept.
+	clientIDLength = 23
+
+	// disconnectQuiesce is the time given, in milliseconds, to complete the
+	// ongoing work before closing the connection.
+	disconnectQuiesce = 250
+)
+
+// Controller is the Controller implementation for asyncapi-codegen.
+type Controller struct {
+	client         paho.Client
+	options        *paho.ClientOptions
+	customClientID bool
+	logger         extensions.Logger
+	queueGroup     string
+	qos            byte
+	retained       bool
+
+	mu            sync.Mutex
+	subscriptions map[string]*topicSubscriptions
+
+	connected  atomic.Bool // True once connected for the first time
+	reconnects extensions.ReconnectListeners
+}
+
+// ControllerOption is a function that can be used to configure a MQTT controller
+// Examples: WithQueueGroup(), WithLogger().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a new MQTT controller.
+func NewController(url string, options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		options: paho.NewClientOptions().
+			AddBroker(url).
+			SetClientID(newClientID()).
+			SetCleanSession(true).
+			SetAutoReconnect(true).
+			// Messages are acknowledged once processed by the user
+			SetAutoAckDisabled(true),
+		logger:        extensions.DummyLogger{},
+		qos:           DefaultQoS,
+		subscriptions: make(map[string]*topicSubscriptions),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	// A session can only be resumed by a client with the same identifier
+	if !controller.options.CleanSession && !controller.customClientID {
+		return nil, fmt.Errorf("a client ID is required to keep the session between connections")
+	}
+
+	controller.setConnectionHandlers()
+
+	// Connect to MQTT
+	controller.client = paho.NewClient(controller.options)
+	if token := controller.client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("could not connect to mqtt: %w", token.Error())
+	}
+
+	return controller, nil
+}
+
+// newClientID returns a random client identifier.
+func newClientID() string {
+	id := extensions.Prefix + strings.ReplaceAll(uuid.NewString(), "-", "")
+	return id[:clientIDLength]
+}
+
+// WithQueueGroup set a queue group for channel subscription, using MQTT shared
+// subscriptions. The broker has to support them, and it doesn't send the
+// retained messages to shared subscriptions. There is no queue group by default.
+func WithQueueGroup(name string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.queueGroup = name
+		return nil
+	}
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithClientID sets the identifier of the client on the broker, instead of a
+// random one.
+func WithClientID(id string) ControllerOption {
+	return func(controller *Controller) error {
+		if id == "" {
+			return fmt.Errorf("client ID cannot be empty")
+		}
+		controller.options.SetClientID(id)
+		controller.customClientID = true
+		return nil
+	}
+}
+
+// WithCleanSession sets if the broker should discard the session of the client
+// when it disconnects. It is true by default.
+//
+// When false, the broker keeps the subscriptions of the client and queues their
+// messages while it is disconnected. This requires a client ID set with
+// WithClientID().
+func WithCleanSession(clean bool) ControllerOption {
+	return func(controller *Controller) error {
+		controller.options.SetCleanSession(clean)
+		return nil
+	}
+}
+
+// WithQoS sets the quality of service of the publications and subscriptions
+// that don't have an MQTT operation binding: 0 for at most once, 1 for at
+// least once and 2 for exactly once.
+func WithQoS(qos byte) ControllerOption {
+	return func(controller *Controller) error {
+		if err := validateQoS(int(qos)); err != nil {
+			return err
+		}
+		controller.qos = qos
+		return nil
+	}
+}
+
+// WithRetained sets if the broker should retain the last message published on
+// a topic for future subscribers, when there is no MQTT operation binding.
+func WithRetained(retained bool) ControllerOption {
+	return func(controller *Controller) error {
+		controller.retained = retained
+		return nil
+	}
+}
+
+// WithCredentials sets the credentials used to authenticate to the broker.
+func WithCredentials(username, password string) ControllerOption {
+	return func(controller *Controller) error {
+		if username == "" {
+			return fmt.Errorf("username cannot be empty")
+		}
+		controller.options.SetUsername(username).SetPassword(password)
+		return nil
+	}
+}
+
+// WithTLS sets the TLS configuration used to connect to the broker. The URL of
+// the controller must use the ssl://, tls:// or mqtts:// scheme.
+func WithTLS(config *tls.Config) ControllerOption {
+	return func(controller *Controller) error {
+		if config == nil {
+			return fmt.Errorf("TLS configuration cannot be nil")
+		}
+		controller.options.SetTLSConfig(config)
+		return nil
+	}
+}
+
+// WithConnectionOpts set the paho.ClientOptions used to connect to the broker.
+func WithConnectionOpts(fn func(opts *paho.ClientOptions)) ControllerOption {
+	return func(controller *Controller) error {
+		fn(controller.options)
+		return nil
+	}
+}
+
+// setConnectionHandlers logs the connection losses and subscribes again on
+// reconnection (notifying it), while keeping the handlers that could have been
+// set by the user.
+func (c *Controller) setConnectionHandlers() {
+	onConnect, onConnectionLost := c.options.OnConnect, c.options.OnConnectionLost
+
+	c.options.SetOnConnectHandler(func(client paho.Client) {
+		c.resubscribe()
+		if onConnect != nil {
+			onConnect(client)
+		}
+
+		// Notify the connections following the first one
+		if c.connected.Swap(true) {
+			c.reconnects.Notify()
+		}
+	})
+
+	c.options.SetConnectionLostHandler(func(client paho.Client, err error) {
+		c.logger.Warning(context.Background(), "connection to MQTT lost",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+		if onConnectionLost != nil {
+			onConnectionLost(client, err)
+		}
+	})
+}
+
+// operationOptions returns the quality of service and retain flag of the
+// operation, from the MQTT operation bindings if there are some in the context.
+func (c *Controller) operationOptions(ctx context.Context) (qos byte, retained bool, err error) {
+	qos, retained = c.qos, c.retained
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		if b.MQTT == nil {
+			return
+		}
+
+		if err = validateQoS(b.MQTT.QoS); err == nil {
+			qos, retained = byte(b.MQTT.QoS), b.MQTT.Retain
+		}
+	})
+	return qos, retained, err
+}
+
+func validateQoS(qos int) error {
+	if qos < 0 || qos > 2 {
+		return fmt.Errorf("invalid QoS %d: should be 0, 1 or 2", qos)
+	}
+	return nil
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+// PublishBatch publishes several messages, then waits for all of them to be
+// sent, or acknowledged by the broker depending on the quality of service.
+//
+// Note: MQTT 3.1.1 does not support headers, so only the payload of the
+// messages is sent.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	qos, retained, err := c.operationOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	tokens := make([]paho.Token, 0, len(bms))
+	for _, bm := range bms {
+		tokens = append(tokens, c.client.Publish(channel, qos, retained, bm.Payload))
+	}
+
+	for i, token := range tokens {
+		if err := waitToken(ctx, token); err != nil {
+			return fmt.Errorf("failed to publish message %d/%d: %w", i+1, len(tokens), err)
+		}
+	}
+
+	return nil
+}
+
+// waitToken waits for the operation of the token to complete, or the context
+// to be done.
+func waitToken(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// topic returns the topic to subscribe to for the channel, which is a shared
+// subscription if there is a queue group.
+func (c *Controller) topic(channel string) string {
+	if c.queueGroup == "" {
+		return channel
+	}
+	return fmt.Sprintf("$share/%s/%s", c.queueGroup, channel)
+}
+
+// Subscribe to messages from the broker.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	qos, _, err := c.operationOptions(ctx)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	// Create a new subscription
+	s := newSubscription()
+
+	// Subscribe on topic, if there is no subscription on it yet
+	topic := c.topic(channel)
+	if c.addSubscription(topic, qos, s) {
+		if err := waitToken(ctx, c.client.Subscribe(topic, qos, c.messagesHandler(topic))); err != nil {
+			c.removeSubscription(topic, s)
+			return extensions.BrokerChannelSubscription{}, fmt.Errorf("failed to subscribe to %q: %w", topic, err)
+		}
+	}
+
+	// Wait for cancellation and unsubscribe from the topic
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+		if !c.removeSubscription(topic, s) {
+			return
+		}
+
+		if token := c.client.Unsubscribe(topic); token.Wait() && token.Error() != nil {
+			c.logger.Error(ctx, token.Error().Error())
+		}
+	})
+
+	return s.sub, nil
+}
+
+// SubscribeToPattern subscribes to the messages of all the addresses of the
+// channel, with the '+' single level wildcard in place of the parameters.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	filter, err := extensions.ChannelAddressPattern(channelAddr, "/", "+")
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.Subscribe(ctx, filter)
+}
+
+// addSubscription adds the subscription on the topic and returns true if it is
+// the first one.
+func (c *Controller) addSubscription(topic string, qos byte, s *subscription) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ts, exists := c.subscriptions[topic]
+	if !exists {
+		ts = &topicSubscriptions{qos: qos}
+		c.subscriptions[topic] = ts
+	}
+	ts.subs = append(ts.subs, s)
+
+	return !exists
+}
+
+// removeSubscription removes the subscription from the topic and returns true
+// if it was the last one.
+func (c *Controller) removeSubscription(topic string, s *subscription) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ts, exists := c.subscriptions[topic]
+	if !exists {
+		return false
+	}
+
+	for i, sub := range ts.subs {
+		if sub == s {
+			ts.subs = append(ts.subs[:i], ts.subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(ts.subs) > 0 {
+		return false
+	}
+	delete(c.subscriptions, topic)
+	return true
+}
+
+// resubscribe subscribes again to the topics with active subscriptions, as the
+// broker may have discarded them with the previous session.
+func (c *Controller) resubscribe() {
+	c.mu.Lock()
+	topics := make(map[string]byte, len(c.subscriptions))
+	for topic, ts := range c.subscriptions {
+		topics[topic] = ts.qos
+	}
+	c.mu.Unlock()
+
+	for topic, qos := range topics {
+		// Don't wait for the token as it would block the client
+		c.client.Subscribe(topic, qos, c.messagesHandler(topic))
+	}
+}
+
+func (c *Controller) messagesHandler(topic string) paho.MessageHandler {
+	withAddress := hasWildcard(topic)
+
+	return func(_ paho.Client, msg paho.Message) {
+		// Get the subscriptions at the time of reception
+		c.mu.Lock()
+		var subs []*subscription
+		if ts, exists := c.subscriptions[topic]; exists {
+			subs = append(subs, ts.subs...)
+		}
+		c.mu.Unlock()
+
+		bm := extensions.BrokerMessage{
+			Payload: msg.Payload(),
+		}
+		if withAddress {
+			bm.Address = msg.Topic()
+		}
+
+		// The message is a duplicate if it is delivered again by the broker. The
+		// packet identifier is reused across the messages of the session, so it
+		// is not the identifier of the message
+		md := extensions.MessageMetadata{
+			Redelivered: msg.Duplicate(),
+			RoutingKey:  msg.Topic(),
+		}
+
+		// Create and transmit message to users
+		transmitted := false
+		for _, s := range subs {
+			transmitted = s.transmit(extensions.NewAcknowledgeableBrokerMessage(
+				bm,
+				AcknowledgementHandler{msg: msg},
+			).WithMetadata(md)) || transmitted
+		}
+
+		// Acknowledge the message if no one will, to not block the delivery
+		// of the next ones
+		if !transmitted {
+			msg.Ack()
+		}
+	}
+}
+
+// hasWildcard returns true if the topic filter has wildcards.
+func hasWildcard(topic string) bool {
+	for _, level := range strings.Split(topic, "/") {
+		if level == "+" || level == "#" {
+			return true
+		}
+	}
+	return false
+}
+
+// Healthy returns an error if the connection to the MQTT broker is not open,
+// for example while reconnecting.
+func (c *Controller) Healthy(_ context.Context) error {
+	if !c.client.IsConnectionOpen() {
+		return fmt.Errorf("mqtt connection is not open")
+	}
+	return nil
+}
+
+// NotifyReconnect registers a function called after each reconnection to the
+// MQTT broker, once the subscriptions are resumed, until the returned function
+// is called.
+func (c *Controller) NotifyReconnect(fn func()) (stop func()) {
+	return c.reconnects.NotifyReconnect(fn)
+}
+
+// Close closes everything related to the broker.
+func (c *Controller) Close() {
+	c.client.Disconnect(disconnectQuiesce)
+}
+
+// topicSubscriptions are the subscriptions of the controller on a topic, that
+// share the same subscription on the broker.
+type topicSubscriptions struct {
+	qos  byte
+	subs []*subscription
+}
+
+// subscription is a subscription of the user on a topic.
+type subscription struct {
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	mu      sync.Mutex
+	stopped bool
+	sending sync.WaitGroup
+	done    chan struct{}
+}
+
+func newSubscription() *subscription {
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	return &subscription{
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		done:     make(chan struct{}),
+	}
+}
+
+// transmit transmits the message to the user, unless the subscription is
+// stopped, even while waiting for the user to read the messages. It returns
+// true if the message has been transmitted.
+func (s *subscription) transmit(msg extensions.AcknowledgeableBrokerMessage) bool {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return false
+	}
+	s.sending.Add(1)
+	s.mu.Unlock()
+	defer s.sending.Done()
+
+	select {
+	case s.messages <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// stop prevents new messages from being transmitted and waits for the pending
+// transmissions, before the messages channel is closed.
+func (s *subscription) stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	s.sending.Wait()
+}
+
+var _ extensions.BrokerAcknowledgment = (*AcknowledgementHandler)(nil)
+
+// AcknowledgementHandler for MQTT broker.
+type AcknowledgementHandler struct {
+	msg paho.Message
+}
+
+// AckMessage acknowledges the message.
+func (h AcknowledgementHandler) AckMessage() {
+	h.msg.Ack()
+}
+
+// NakMessage negatively acknowledges the message.
+//
+// Note: MQTT does not support negative acknowledgements, so the message is
+// acknowledged in order to not block the delivery of the next ones.
+func (h AcknowledgementHandler) NakMessage() {
+	h.msg.Ack()
+}
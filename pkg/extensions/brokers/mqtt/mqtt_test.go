@@ -0,0 +1,163 @@
+package mqtt
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func brokerAddress() string {
+	return testutil.BrokerAddress(testutil.BrokerAddressParams{
+		Schema:         "tcp",
+		DockerizedAddr: "mosquitto",
+		Port:           "1883",
+	})
+}
+
+func TestWithQoS(t *testing.T) {
+	_, err := NewController(brokerAddress(), WithQoS(3))
+	assert.Error(t, err)
+}
+
+func TestWithCleanSession(t *testing.T) {
+	_, err := NewController(brokerAddress(), WithCleanSession(false))
+	assert.ErrorContains(t, err, "client ID is required")
+}
+
+func TestNewClientID(t *testing.T) {
+	id := newClientID()
+	assert.Len(t, id, clientIDLength)
+	assert.True(t, strings.HasPrefix(id, extensions.Prefix))
+	assert.NotEqual(t, id, newClientID())
+}
+
+func TestTopic(t *testing.T) {
+	assert.Equal(t, "events", (&Controller{}).topic("events"))
+	assert.Equal(t, "$share/group/events", (&Controller{queueGroup: "group"}).topic("events"))
+}
+
+func TestOperationOptions(t *testing.T) {
+	c := &Controller{qos: DefaultQoS, retained: true}
+
+	qos, retained, err := c.operationOptions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, byte(DefaultQoS), qos)
+	assert.True(t, retained)
+
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsOperationBindings,
+		extensions.OperationBindings{MQTT: &extensions.MQTTOperationBindings{QoS: 2}})
+	qos, retained, err = c.operationOptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, byte(2), qos)
+	assert.False(t, retained)
+
+	ctx = context.WithValue(context.Background(), extensions.ContextKeyIsOperationBindings,
+		extensions.OperationBindings{MQTT: &extensions.MQTTOperationBindings{QoS: 3}})
+	_, _, err = c.operationOptions(ctx)
+	assert.Error(t, err)
+}
+
+func TestSubscriptionStopWhileFull(t *testing.T) {
+	s := newSubscription()
+	for i := 0; i < cap(s.messages); i++ {
+		require.True(t, s.transmit(extensions.NewAcknowledgeableBrokerMessage(extensions.BrokerMessage{}, nil)))
+	}
+
+	// The next transmission waits for the user, but not for the stop
+	transmitted := make(chan bool)
+	go func() {
+		transmitted <- s.transmit(extensions.NewAcknowledgeableBrokerMessage(extensions.BrokerMessage{}, nil))
+	}()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop blocked on the pending transmission")
+	}
+	assert.False(t, <-transmitted)
+	assert.False(t, s.transmit(extensions.NewAcknowledgeableBrokerMessage(extensions.BrokerMessage{}, nil)))
+}
+
+func TestMQTTController_PublishSubscribe(t *testing.T) {
+	topic := "mqtt/publish-subscribe"
+	c, err := NewController(brokerAddress())
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), topic)
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	err = c.PublishBatch(context.Background(), topic, []extensions.BrokerMessage{
+		{Payload: []byte("first")},
+		{Payload: []byte("second")},
+	})
+	require.NoError(t, err)
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case msg := <-sub.MessagesChannel():
+			assert.Equal(t, expected, string(msg.Payload))
+			msg.Ack()
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestMQTTController_WithRetained(t *testing.T) {
+	topic := "mqtt/retained"
+	c, err := NewController(brokerAddress(), WithRetained(true))
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Publish before subscribing
+	err = c.Publish(context.Background(), topic, extensions.BrokerMessage{Payload: []byte("retained")})
+	require.NoError(t, err)
+
+	sub, err := c.Subscribe(context.Background(), topic)
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	select {
+	case msg := <-sub.MessagesChannel():
+		assert.Equal(t, "retained", string(msg.Payload))
+		msg.Ack()
+	case <-time.After(5 * time.Second):
+		t.Fatal("retained message not received")
+	}
+
+	// Clear the retained message
+	err = c.Publish(context.Background(), topic, extensions.BrokerMessage{})
+	require.NoError(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	testutil.SkipIfBrokerUnavailable(t, brokerAddress())
+
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController(brokerAddress())
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		ChannelPrefix:    "mqtt/",
+		WithoutHeaders:   true,
+		PatternSeparator: "/",
+	})
+}
@@ -0,0 +1,45 @@
+package mqtt
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// WithSecurity authenticates to the broker with the first of the security
+// schemes (userPassword, X509 or oauth2) whose credentials are given. It fails
+// if there are security schemes but none of them can be used.
+//
+// With an oauth2 security scheme, a new access token is requested at each
+// connection and used as password, with the client ID as username.
+func WithSecurity(schemes []extensions.SecurityScheme, credentials extensions.Credentials) ControllerOption {
+	return func(controller *Controller) error {
+		scheme, err := extensions.SelectSecurityScheme(schemes, credentials,
+			extensions.SecuritySchemeUserPassword, extensions.SecuritySchemeX509, extensions.SecuritySchemeOAuth2)
+		if err != nil || scheme == nil {
+			return err
+		}
+
+		switch scheme.Type {
+		case extensions.SecuritySchemeUserPassword:
+			return WithCredentials(credentials.UserPassword.Username, credentials.UserPassword.Password)(controller)
+		case extensions.SecuritySchemeX509:
+			config, err := credentials.X509.TLSConfig()
+			if err != nil {
+				return err
+			}
+			return WithTLS(config)(controller)
+		case extensions.SecuritySchemeOAuth2:
+			oauth2, sch := *credentials.OAuth2, *scheme
+			controller.options.SetCredentialsProvider(func() (string, string) {
+				token, err := oauth2.Token(context.Background(), sch)
+				if err != nil {
+					controller.logger.Error(context.Background(), err.Error())
+				}
+				return oauth2.ClientID, token
+			})
+		}
+
+		return nil
+	}
+}
@@ -3,21 +3,30 @@ package nats
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
 	"github.com/nats-io/nats.go"
 )
 
-// Check that it still fills the interface.
-var _ extensions.BrokerController = (*Controller)(nil)
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController     = (*Controller)(nil)
+	_ extensions.BrokerRequester      = (*Controller)(nil)
+	_ extensions.ReplyAddressProvider = (*Controller)(nil)
+	_ extensions.PatternSubscriber    = (*Controller)(nil)
+	_ extensions.ReconnectNotifier    = (*Controller)(nil)
+)
 
 // Controller is the Controller implementation for asyncapi-codegen.
 type Controller struct {
 	url        string
 	connection *nats.Conn
+	connOpts   []nats.Option
 	logger     extensions.Logger
 	queueGroup string
+	reconnects extensions.ReconnectListeners
 }
 
 // ControllerOption is a function that can be used to configure a NATS controller
@@ -40,15 +49,21 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 		}
 	}
 
-	// If connection not already created with WithConnectionOpts, connect to NATS
-	if controller.connection == nil {
-		nc, err := nats.Connect(url)
-		if err != nil {
-			return nil, fmt.Errorf("could not connect to nats: %w", err)
-		}
-
-		controller.connection = nc
+	// Connect to NATS, with the options set with WithConnectionOpts and WithSecurity
+	nc, err := nats.Connect(url, controller.connOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to nats: %w", err)
 	}
+	controller.connection = nc
+
+	// Notify the reconnections, while keeping the handler set by the user
+	reconnectedCB := controller.connection.Opts.ReconnectedCB
+	controller.connection.SetReconnectHandler(func(nc *nats.Conn) {
+		if reconnectedCB != nil {
+			reconnectedCB(nc)
+		}
+		controller.reconnects.Notify()
+	})
 
 	return controller, nil
 }
@@ -72,34 +87,50 @@ func WithLogger(logger extensions.Logger) ControllerOption {
 // WithConnectionOpts set the nats.Options to connect to nats.
 func WithConnectionOpts(opts ...nats.Option) ControllerOption {
 	return func(controller *Controller) error {
-		nc, err := nats.Connect(controller.url, opts...)
-		if err != nil {
-			return fmt.Errorf("could not connect to nats: %w", err)
-		}
-		controller.connection = nc
+		controller.connOpts = append(controller.connOpts, opts...)
 		return nil
 	}
 }
 
 // Publish a message to the broker.
-func (c *Controller) Publish(_ context.Context, channel string, bm extensions.BrokerMessage) error {
-	msg := nats.NewMsg(channel)
-
-	// Set message headers and content
-	for k, v := range bm.Headers {
-		msg.Header.Set(k, string(v))
-	}
-	msg.Data = bm.Payload
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
 
-	// Publish message
-	if err := c.connection.PublishMsg(msg); err != nil {
-		return err
+// PublishBatch publishes several messages, flushing the connection only once.
+func (c *Controller) PublishBatch(_ context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		// Publish message
+		if err := c.connection.PublishMsg(natsMessage(channel, bm)); err != nil {
+			return err
+		}
 	}
 
 	// Flush the queue
 	return c.connection.Flush()
 }
 
+// Request publishes a message with the NATS request/reply, using an inbox as
+// reply subject, and waits for its reply.
+func (c *Controller) Request(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+) (extensions.BrokerMessage, error) {
+	reply, err := c.connection.RequestMsgWithContext(ctx, natsMessage(channel, bm))
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	return brokerMessage(reply), nil
+}
+
+// NewReplyAddress returns a new inbox, where the reply of a request can be
+// received.
+func (c *Controller) NewReplyAddress(_ context.Context) (string, error) {
+	return c.connection.NewInbox(), nil
+}
+
 // Subscribe to messages from the broker.
 func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
 	// Create a new subscription
@@ -109,7 +140,7 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 	)
 
 	// Subscribe on subject
-	natsSub, err := c.connection.QueueSubscribe(channel, c.queueGroup, c.messagesHandler(ctx, sub))
+	natsSub, err := c.connection.QueueSubscribe(channel, c.queueGroup, c.messagesHandler(ctx, channel, sub))
 	if err != nil {
 		return extensions.BrokerChannelSubscription{}, err
 	}
@@ -124,25 +155,109 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 	return sub, nil
 }
 
-func (c *Controller) messagesHandler(_ context.Context, sub extensions.BrokerChannelSubscription) nats.MsgHandler {
+// SubscribeToPattern subscribes to all the subjects matching the channel
+// address, with a wildcard for each parameter.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	subject, err := extensions.ChannelAddressPattern(channelAddr, ".", "*")
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.Subscribe(ctx, subject)
+}
+
+func (c *Controller) messagesHandler(
+	ctx context.Context,
+	subject string,
+	sub extensions.BrokerChannelSubscription,
+) nats.MsgHandler {
+	// Get the header where the reply subject of the requests should be set
+	var replyHeader string
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsReplyAddressHeader, func(header string) {
+		replyHeader = header
+	})
+
+	// Set the address of the messages received with wildcards
+	withAddress := hasWildcard(subject)
+
 	return func(msg *nats.Msg) {
-		// Get headers
-		headers := make(map[string][]byte, len(msg.Header))
-		for k, v := range msg.Header {
-			if len(v) > 0 {
-				headers[k] = []byte(v[0])
-			}
+		bm := brokerMessage(msg)
+		if withAddress {
+			bm.Address = msg.Subject
+		}
+
+		// Reply to the inbox if the message comes from a NATS request
+		if replyHeader != "" && msg.Reply != "" {
+			bm.Headers[replyHeader] = []byte(msg.Reply)
 		}
 
 		// Create and transmit message to user
+		// NATS core doesn't redeliver the messages, so only the subject is known
 		sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
-			extensions.BrokerMessage{
-				Headers: headers,
-				Payload: msg.Data,
-			},
+			bm,
 			NoopAcknowledgementHandler{},
-		))
+		).WithMetadata(extensions.MessageMetadata{RoutingKey: msg.Subject}))
+	}
+}
+
+// hasWildcard returns true if the subject has wildcards.
+func hasWildcard(subject string) bool {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// natsMessage converts the broker message to a NATS message on the subject.
+func natsMessage(subject string, bm extensions.BrokerMessage) *nats.Msg {
+	msg := nats.NewMsg(subject)
+
+	// Set message headers and content
+	for k, v := range bm.Headers {
+		msg.Header.Set(k, string(v))
 	}
+	if bm.ContentType != "" {
+		msg.Header.Set(brokers.ContentTypeHeaderKey, bm.ContentType)
+	}
+	msg.Data = bm.Payload
+
+	return msg
+}
+
+// brokerMessage converts the NATS message to a broker message.
+func brokerMessage(msg *nats.Msg) extensions.BrokerMessage {
+	// Get headers
+	headers := make(map[string][]byte, len(msg.Header))
+	for k, v := range msg.Header {
+		if len(v) > 0 {
+			headers[k] = []byte(v[0])
+		}
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     msg.Data,
+	}
+}
+
+// Healthy returns an error if the connection to NATS is not established, for
+// example while reconnecting.
+func (c *Controller) Healthy(_ context.Context) error {
+	if !c.connection.IsConnected() {
+		return fmt.Errorf("nats connection is %s", c.connection.Status())
+	}
+	return nil
+}
+
+// NotifyReconnect registers a function called after each reconnection to NATS,
+// until the returned function is called.
+func (c *Controller) NotifyReconnect(fn func()) (stop func()) {
+	return c.reconnects.NotifyReconnect(fn)
 }
 
 // Close closes everything related to the broker.
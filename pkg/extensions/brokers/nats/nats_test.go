@@ -1,14 +1,20 @@
 package nats
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
 	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
 )
 
 func TestValidateAckMechanism(t *testing.T) {
@@ -72,6 +78,75 @@ func TestValidateAckMechanism(t *testing.T) {
 	})
 }
 
+func TestRequest(t *testing.T) {
+	subj := "CoreNatsRequest"
+	nb, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats",
+			Port:           "4222",
+		}),
+		WithQueueGroup(subj))
+	assert.NoError(t, err, "new controller should not return error")
+	defer nb.Close()
+
+	// Reply on the address set in the header by the controller
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsReplyAddressHeader, "replyTo")
+	sub, err := nb.Subscribe(ctx, subj)
+	assert.NoError(t, err, "subscribe should not return error")
+	defer sub.Cancel(context.Background())
+	go func() {
+		msg := <-sub.MessagesChannel()
+		err := nb.Publish(context.Background(), string(msg.Headers["replyTo"]), extensions.BrokerMessage{
+			Headers: map[string][]byte{},
+			Payload: append([]byte("re: "), msg.Payload...),
+		})
+		assert.NoError(t, err, "publish should not return error")
+	}()
+
+	reply, err := nb.Request(context.Background(), subj, extensions.BrokerMessage{
+		Headers: map[string][]byte{"replyTo": []byte("ignored")},
+		Payload: []byte("request"),
+	})
+	assert.NoError(t, err, "request should not return error")
+	assert.Equal(t, "re: request", string(reply.Payload))
+}
+
+func TestNewReplyAddress(t *testing.T) {
+	nb, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats",
+			Port:           "4222",
+		}),
+		WithQueueGroup("CoreNatsReplyAddress"))
+	assert.NoError(t, err, "new controller should not return error")
+	defer nb.Close()
+
+	// The reply address should be a new inbox
+	addr, err := nb.NewReplyAddress(context.Background())
+	assert.NoError(t, err, "new reply address should not return error")
+	assert.True(t, strings.HasPrefix(addr, nats.InboxPrefix), addr)
+
+	// The reply should be received on it
+	sub, err := nb.Subscribe(context.Background(), addr)
+	assert.NoError(t, err, "subscribe should not return error")
+	defer sub.Cancel(context.Background())
+
+	err = nb.Publish(context.Background(), addr, extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+		Payload: []byte("reply"),
+	})
+	assert.NoError(t, err, "publish should not return error")
+
+	select {
+	case msg := <-sub.MessagesChannel():
+		assert.Equal(t, "reply", string(msg.Payload))
+	case <-time.After(time.Second):
+		assert.Fail(t, "no reply received")
+	}
+}
+
 //nolint:funlen
 func TestSecureConnectionToNATSCore(t *testing.T) {
 	// for testing with InsecureSkipVerify to skip server certificate validation for our self-signed certificate
@@ -138,3 +213,20 @@ func TestSecureConnectionToNATSCore(t *testing.T) {
 			defer nb.Close()
 		})
 }
+
+func TestConformance(t *testing.T) {
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController(
+				testutil.BrokerAddress(testutil.BrokerAddressParams{
+					Schema:         "nats",
+					DockerizedAddr: "nats",
+					Port:           "4222",
+				}),
+				WithQueueGroup("CoreNatsConformance"))
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+	})
+}
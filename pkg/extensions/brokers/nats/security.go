@@ -0,0 +1,47 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/nats-io/nats.go"
+)
+
+// WithSecurity authenticates to NATS with the first of the security schemes
+// (userPassword, X509 or oauth2) whose credentials are given. It fails if
+// there are security schemes but none of them can be used.
+//
+// With an oauth2 security scheme, a new access token is requested at each
+// connection and used as NATS token.
+func WithSecurity(schemes []extensions.SecurityScheme, credentials extensions.Credentials) ControllerOption {
+	return func(controller *Controller) error {
+		scheme, err := extensions.SelectSecurityScheme(schemes, credentials,
+			extensions.SecuritySchemeUserPassword, extensions.SecuritySchemeX509, extensions.SecuritySchemeOAuth2)
+		if err != nil || scheme == nil {
+			return err
+		}
+
+		switch scheme.Type {
+		case extensions.SecuritySchemeUserPassword:
+			controller.connOpts = append(controller.connOpts,
+				nats.UserInfo(credentials.UserPassword.Username, credentials.UserPassword.Password))
+		case extensions.SecuritySchemeX509:
+			config, err := credentials.X509.TLSConfig()
+			if err != nil {
+				return err
+			}
+			controller.connOpts = append(controller.connOpts, nats.Secure(config))
+		case extensions.SecuritySchemeOAuth2:
+			oauth2, sch := *credentials.OAuth2, *scheme
+			controller.connOpts = append(controller.connOpts, nats.TokenHandler(func() string {
+				token, err := oauth2.Token(context.Background(), sch)
+				if err != nil {
+					controller.logger.Error(context.Background(), err.Error())
+				}
+				return token
+			}))
+		}
+
+		return nil
+	}
+}
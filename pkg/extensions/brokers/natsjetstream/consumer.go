@@ -0,0 +1,233 @@
+package natsjetstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// WithDurable set the name of the durable consumer used by the controller. It
+// is created or updated with the other consumer options.
+func WithDurable(name string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.consumerOptions = append(controller.consumerOptions, func(cc *jetstream.ConsumerConfig) {
+			cc.Name = name
+			cc.Durable = name
+		})
+		return nil
+	}
+}
+
+// WithAckWait set the duration the server waits for an acknowledgement before
+// redelivering a message.
+func WithAckWait(duration time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		controller.consumerOptions = append(controller.consumerOptions, func(cc *jetstream.ConsumerConfig) {
+			cc.AckWait = duration
+		})
+		return nil
+	}
+}
+
+// WithMaxDeliver set the maximum number of deliveries of a message (-1 for unlimited).
+func WithMaxDeliver(n int) ControllerOption {
+	return func(controller *Controller) error {
+		controller.consumerOptions = append(controller.consumerOptions, func(cc *jetstream.ConsumerConfig) {
+			cc.MaxDeliver = n
+		})
+		return nil
+	}
+}
+
+// WithDeliverPolicy set the point of the stream from which the consumer starts
+// to receive messages.
+func WithDeliverPolicy(policy jetstream.DeliverPolicy) ControllerOption {
+	return func(controller *Controller) error {
+		controller.consumerOptions = append(controller.consumerOptions, func(cc *jetstream.ConsumerConfig) {
+			cc.DeliverPolicy = policy
+		})
+		return nil
+	}
+}
+
+// WithBackOff set the delays between the redeliveries of a message that is not
+// acknowledged, instead of the ack wait. The maximum number of deliveries should
+// be greater than the number of delays.
+func WithBackOff(delays ...time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		controller.consumerOptions = append(controller.consumerOptions, func(cc *jetstream.ConsumerConfig) {
+			cc.BackOff = delays
+		})
+		return nil
+	}
+}
+
+// applyConsumerOptions applies the consumer options on the consumer
+// configuration, creating it for the consumer set with WithConsumer if needed.
+func (c *Controller) applyConsumerOptions() {
+	if len(c.consumerOptions) == 0 {
+		return
+	}
+
+	if c.consumerConfig == nil {
+		c.consumerConfig = &jetstream.ConsumerConfig{Name: c.consumerName}
+	}
+	for _, option := range c.consumerOptions {
+		option(c.consumerConfig)
+	}
+}
+
+// deliverPolicy returns the JetStream deliver policy from its name in the bindings.
+func deliverPolicy(name string) (jetstream.DeliverPolicy, error) {
+	switch name {
+	case "", extensions.JetStreamDeliverAll:
+		return jetstream.DeliverAllPolicy, nil
+	case extensions.JetStreamDeliverLast:
+		return jetstream.DeliverLastPolicy, nil
+	case extensions.JetStreamDeliverNew:
+		return jetstream.DeliverNewPolicy, nil
+	case extensions.JetStreamDeliverLastPerSubject:
+		return jetstream.DeliverLastPerSubjectPolicy, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown deliver policy %q", extensions.ErrAsyncAPI, name)
+	}
+}
+
+// operationConsumerConfig returns the configuration of the consumer dedicated
+// to the channel, from the controller configuration overridden by the bindings.
+func (c *Controller) operationConsumerConfig(
+	channel string,
+	b extensions.JetStreamConsumerBindings,
+) (jetstream.ConsumerConfig, error) {
+	var config jetstream.ConsumerConfig
+	if c.consumerConfig != nil {
+		config = *c.consumerConfig
+	}
+
+	// The consumer is ephemeral without a durable name in the bindings
+	config.Name, config.Durable = b.Durable, b.Durable
+	config.FilterSubject, config.FilterSubjects = channel, nil
+
+	if b.AckWait > 0 {
+		config.AckWait = b.AckWait
+	}
+	if b.MaxDeliver != 0 {
+		config.MaxDeliver = b.MaxDeliver
+	}
+	if b.DeliverPolicy != "" {
+		policy, err := deliverPolicy(b.DeliverPolicy)
+		if err != nil {
+			return jetstream.ConsumerConfig{}, err
+		}
+		config.DeliverPolicy = policy
+	}
+	if len(b.BackOff) > 0 {
+		config.BackOff = b.BackOff
+	}
+
+	return config, nil
+}
+
+// subscribeWithConsumer subscribes to the channel with a consumer dedicated to
+// it, configured with the JetStream consumer bindings of the operation.
+func (c *Controller) subscribeWithConsumer(
+	ctx context.Context,
+	channel string,
+	b extensions.JetStreamConsumerBindings,
+) (extensions.BrokerChannelSubscription, error) {
+	config, err := c.operationConsumerConfig(channel, b)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	consumer, err := c.jetStream.CreateOrUpdateConsumer(ctx, c.streamName, config)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{},
+			fmt.Errorf("could not create or update consumer for %q: %w", channel, err)
+	}
+
+	// Create a new subscription
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize),
+		make(chan any, 1),
+	)
+
+	withAddress := hasWildcard(channel)
+	flow := &consumerFlow{
+		consumer: consumer,
+		handler: func(msg jetstream.Msg) {
+			c.logger.Info(ctx, fmt.Sprintf("Received message for %s", channel), extensions.LogInfo{
+				Key:   "message",
+				Value: msg,
+			})
+			c.handleMessage(ctx, msg, sub, withAddress)
+		},
+	}
+	if err := flow.Resume(ctx); err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	// Wait for cancellation and stop consuming
+	sub.WaitForCancellationAsync(flow.stop)
+
+	// The consumption is stopped while the subscription is paused
+	return sub.WithFlowController(flow), nil
+}
+
+// consumerFlow stops and restarts the consumption of a dedicated consumer, when
+// its subscription is paused and resumed.
+type consumerFlow struct {
+	consumer jetstream.Consumer
+	handler  jetstream.MessageHandler
+
+	mutex   sync.Mutex
+	consume jetstream.ConsumeContext // Nil while paused
+	stopped bool
+}
+
+// Pause stops the consumption. The messages fetched but not delivered yet are
+// redelivered after the acknowledgment delay of the consumer.
+func (f *consumerFlow) Pause(_ context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.consume != nil {
+		f.consume.Stop()
+		f.consume = nil
+	}
+	return nil
+}
+
+// Resume restarts the consumption, unless the subscription has been cancelled.
+func (f *consumerFlow) Resume(_ context.Context) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.consume != nil || f.stopped {
+		return nil
+	}
+
+	consume, err := f.consumer.Consume(f.handler)
+	if err != nil {
+		return err
+	}
+	f.consume = consume
+	return nil
+}
+
+// stop stops the consumption definitely, on the subscription cancellation.
+func (f *consumerFlow) stop() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.stopped = true
+	if f.consume != nil {
+		f.consume.Stop()
+		f.consume = nil
+	}
+}
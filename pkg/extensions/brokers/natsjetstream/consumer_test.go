@@ -0,0 +1,195 @@
+package natsjetstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerOptions(t *testing.T) {
+	subj := "NatsJetstreamConsumerOptions"
+	broker, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats-jetstream",
+			DockerizedPort: "4222",
+			LocalPort:      "4225",
+		}),
+		WithStreamConfig(jetstream.StreamConfig{
+			Name:     subj,
+			Subjects: []string{subj + ".>"},
+		}),
+		WithConsumerConfig(jetstream.ConsumerConfig{FilterSubject: subj + ".>"}),
+		WithDurable("natsJetstreamConsumerOptions"),
+		WithMaxDeliver(4),
+		WithDeliverPolicy(jetstream.DeliverNewPolicy),
+		WithBackOff(time.Second, 10*time.Second),
+	)
+	require.NoError(t, err, "new controller should not return error")
+	defer broker.Close()
+
+	consumer, err := broker.jetStream.Consumer(context.Background(), subj, "natsJetstreamConsumerOptions")
+	require.NoError(t, err)
+	config := consumer.CachedInfo().Config
+	assert.Equal(t, "natsJetstreamConsumerOptions", config.Durable)
+	assert.Equal(t, subj+".>", config.FilterSubject)
+	assert.Equal(t, 4, config.MaxDeliver)
+	assert.Equal(t, jetstream.DeliverNewPolicy, config.DeliverPolicy)
+	assert.Equal(t, []time.Duration{time.Second, 10 * time.Second}, config.BackOff)
+}
+
+func TestSubscribeWithConsumerBindings(t *testing.T) {
+	subj := "NatsJetstreamConsumerBindings"
+	broker, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats-jetstream",
+			DockerizedPort: "4222",
+			LocalPort:      "4225",
+		}),
+		WithStreamConfig(jetstream.StreamConfig{
+			Name:     subj,
+			Subjects: []string{subj + ".>"},
+		}),
+		WithAckWait(time.Minute),
+	)
+	require.NoError(t, err, "new controller should not return error")
+	defer broker.Close()
+
+	channel := subj + ".orders"
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsOperationBindings, extensions.OperationBindings{
+		NATS: &extensions.NATSOperationBindings{
+			JetStreamConsumer: &extensions.JetStreamConsumerBindings{
+				Durable:       "natsJetstreamConsumerBindings",
+				MaxDeliver:    3,
+				DeliverPolicy: extensions.JetStreamDeliverLast,
+			},
+		},
+	})
+
+	// Start from an empty stream, without the consumer of a previous run
+	stream, err := broker.jetStream.Stream(context.Background(), subj)
+	require.NoError(t, err)
+	require.NoError(t, stream.Purge(context.Background()))
+	_ = stream.DeleteConsumer(context.Background(), "natsJetstreamConsumerBindings")
+
+	// Only the last message should be received
+	require.NoError(t, broker.PublishBatch(context.Background(), channel, []extensions.BrokerMessage{
+		{Payload: []byte("first")},
+		{Payload: []byte("second")},
+	}))
+
+	sub, err := broker.Subscribe(ctx, channel)
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	select {
+	case abm := <-sub.MessagesChannel():
+		assert.Equal(t, "second", string(abm.BrokerMessage.Payload))
+		abm.Ack()
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+
+	// Dedicated consumer has the bindings over the controller configuration
+	consumer, err := broker.jetStream.Consumer(context.Background(), subj, "natsJetstreamConsumerBindings")
+	require.NoError(t, err)
+	config := consumer.CachedInfo().Config
+	assert.Equal(t, channel, config.FilterSubject)
+	assert.Equal(t, time.Minute, config.AckWait)
+	assert.Equal(t, 3, config.MaxDeliver)
+	assert.Equal(t, jetstream.DeliverLastPolicy, config.DeliverPolicy)
+}
+
+func TestSubscribeToPattern(t *testing.T) {
+	subj := "NatsJetstreamPattern"
+	broker, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats-jetstream",
+			DockerizedPort: "4222",
+			LocalPort:      "4225",
+		}),
+		WithStreamConfig(jetstream.StreamConfig{
+			Name:     subj,
+			Subjects: []string{subj + ".>"},
+		}),
+	)
+	require.NoError(t, err, "new controller should not return error")
+	defer broker.Close()
+
+	// Start from an empty stream
+	stream, err := broker.jetStream.Stream(context.Background(), subj)
+	require.NoError(t, err)
+	require.NoError(t, stream.Purge(context.Background()))
+
+	sub, err := broker.SubscribeToPattern(context.Background(), subj+".{id}.events")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	require.NoError(t, broker.Publish(context.Background(), subj+".1.other", extensions.BrokerMessage{Payload: []byte("other")}))
+	require.NoError(t, broker.Publish(context.Background(), subj+".2.events", extensions.BrokerMessage{Payload: []byte("event")}))
+
+	select {
+	case abm := <-sub.MessagesChannel():
+		assert.Equal(t, "event", string(abm.BrokerMessage.Payload))
+		assert.Equal(t, subj+".2.events", abm.BrokerMessage.Address)
+		abm.Ack()
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestPauseSubscription(t *testing.T) {
+	subj := "NatsJetstreamPause"
+	broker, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats-jetstream",
+			DockerizedPort: "4222",
+			LocalPort:      "4225",
+		}),
+		WithStreamConfig(jetstream.StreamConfig{
+			Name:     subj,
+			Subjects: []string{subj + ".>"},
+		}),
+	)
+	require.NoError(t, err, "new controller should not return error")
+	defer broker.Close()
+
+	// Start from an empty stream
+	stream, err := broker.jetStream.Stream(context.Background(), subj)
+	require.NoError(t, err)
+	require.NoError(t, stream.Purge(context.Background()))
+
+	sub, err := broker.SubscribeToPattern(context.Background(), subj+".{id}")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	// The messages are not delivered while paused
+	require.NoError(t, sub.Pause(context.Background()))
+	require.NoError(t, sub.Pause(context.Background()))
+	require.NoError(t, broker.Publish(context.Background(), subj+".1", extensions.BrokerMessage{Payload: []byte("event")}))
+	select {
+	case <-sub.MessagesChannel():
+		t.Fatal("the message should not be delivered while paused")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// They are delivered once resumed
+	require.NoError(t, sub.Resume(context.Background()))
+	require.NoError(t, sub.Resume(context.Background()))
+	select {
+	case abm := <-sub.MessagesChannel():
+		assert.Equal(t, "event", string(abm.BrokerMessage.Payload))
+		abm.Ack()
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
@@ -0,0 +1,204 @@
+package natsjetstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var (
+	// ErrNoLastValue is the error returned when there is no message kept in
+	// the last value cache for a channel address.
+	ErrNoLastValue = fmt.Errorf("%w: no last value", extensions.ErrAsyncAPI)
+)
+
+// lastValueCacheFromContext returns the last value cache of the channel, if
+// there is one in the context.
+func lastValueCacheFromContext(ctx context.Context) *extensions.LastValueCache {
+	var cache *extensions.LastValueCache
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsLastValueCache, func(lvc extensions.LastValueCache) {
+		cache = &lvc
+	})
+	return cache
+}
+
+// persistLastValues keeps the published messages in the last value cache of
+// the channel, if there is one in the context, with the channel address as key.
+func (c *Controller) persistLastValues(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	cache := lastValueCacheFromContext(ctx)
+	if cache == nil || len(bms) == 0 {
+		return nil
+	}
+
+	if cache.Store == extensions.LastValueCacheStoreObject {
+		// Only the last message would be kept anyway
+		return c.putObject(ctx, *cache, channel, bms[len(bms)-1])
+	}
+
+	kv, err := c.keyValue(ctx, *cache)
+	if err != nil {
+		return err
+	}
+	for _, bm := range bms {
+		if _, err := kv.Put(ctx, channel, bm.Payload); err != nil {
+			return fmt.Errorf("failed to put last value of %q in bucket %q: %w", channel, cache.Bucket, err)
+		}
+	}
+
+	return nil
+}
+
+// LastValue returns the last message published on the channel address, from
+// its last value cache.
+//
+// Note: key-value stores only keep the payload of the messages, while object
+// stores also keep their headers.
+func (c *Controller) LastValue(
+	ctx context.Context,
+	cache extensions.LastValueCache,
+	channel string,
+) (extensions.BrokerMessage, error) {
+	if cache.Store == extensions.LastValueCacheStoreObject {
+		return c.getObject(ctx, cache, channel)
+	}
+
+	kv, err := c.keyValue(ctx, cache)
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	entry, err := kv.Get(ctx, channel)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return extensions.BrokerMessage{}, fmt.Errorf("%w for %q", ErrNoLastValue, channel)
+	} else if err != nil {
+		return extensions.BrokerMessage{}, fmt.Errorf("failed to get last value of %q: %w", channel, err)
+	}
+
+	return extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+		Payload: entry.Value(),
+	}, nil
+}
+
+// keyValue returns the key-value store of the cache, creating it if needed.
+func (c *Controller) keyValue(ctx context.Context, cache extensions.LastValueCache) (jetstream.KeyValue, error) {
+	c.storesMutex.Lock()
+	defer c.storesMutex.Unlock()
+
+	if kv, exists := c.keyValues[cache.Bucket]; exists {
+		return kv, nil
+	}
+
+	kv, err := c.jetStream.KeyValue(ctx, cache.Bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = c.jetStream.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:  cache.Bucket,
+			History: uint8(max(cache.History, 1)),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get key-value store %q: %w", cache.Bucket, err)
+	}
+	c.keyValues[cache.Bucket] = kv
+
+	return kv, nil
+}
+
+// objectStore returns the object store of the cache, creating it if needed.
+func (c *Controller) objectStore(cache extensions.LastValueCache) (nats.ObjectStore, error) {
+	c.storesMutex.Lock()
+	defer c.storesMutex.Unlock()
+
+	if obs, exists := c.objectStores[cache.Bucket]; exists {
+		return obs, nil
+	}
+
+	// Object stores are not yet supported by the new JetStream API
+	js, err := c.natsConn.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to jetstream: %w", err)
+	}
+
+	obs, err := js.ObjectStore(cache.Bucket)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		obs, err = js.CreateObjectStore(&nats.ObjectStoreConfig{Bucket: cache.Bucket})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get object store %q: %w", cache.Bucket, err)
+	}
+	c.objectStores[cache.Bucket] = obs
+
+	return obs, nil
+}
+
+func (c *Controller) putObject(
+	ctx context.Context,
+	cache extensions.LastValueCache,
+	channel string,
+	bm extensions.BrokerMessage,
+) error {
+	obs, err := c.objectStore(cache)
+	if err != nil {
+		return err
+	}
+
+	meta := &nats.ObjectMeta{
+		Name:    channel,
+		Headers: newMsg(channel, bm).Header,
+	}
+	if _, err := obs.Put(meta, bytes.NewReader(bm.Payload), nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to put last value of %q in object store %q: %w", channel, cache.Bucket, err)
+	}
+
+	return nil
+}
+
+func (c *Controller) getObject(
+	ctx context.Context,
+	cache extensions.LastValueCache,
+	channel string,
+) (extensions.BrokerMessage, error) {
+	obs, err := c.objectStore(cache)
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	res, err := obs.Get(channel, nats.Context(ctx))
+	if errors.Is(err, nats.ErrObjectNotFound) {
+		return extensions.BrokerMessage{}, fmt.Errorf("%w for %q", ErrNoLastValue, channel)
+	} else if err != nil {
+		return extensions.BrokerMessage{}, fmt.Errorf("failed to get last value of %q: %w", channel, err)
+	}
+	defer res.Close()
+
+	payload, err := io.ReadAll(res)
+	if err != nil {
+		return extensions.BrokerMessage{}, fmt.Errorf("failed to read last value of %q: %w", channel, err)
+	}
+
+	info, err := res.Info()
+	if err != nil {
+		return extensions.BrokerMessage{}, fmt.Errorf("failed to get last value info of %q: %w", channel, err)
+	}
+
+	// Get headers
+	headers := make(map[string][]byte, len(info.Headers))
+	for k, v := range info.Headers {
+		if len(v) > 0 {
+			headers[k] = []byte(v[0])
+		}
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     payload,
+	}, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
@@ -12,8 +14,12 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 )
 
-// Check that it still fills the interface.
-var _ extensions.BrokerController = (*Controller)(nil)
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Controller)(nil)
+	_ extensions.PatternSubscriber = (*Controller)(nil)
+	_ extensions.ReconnectNotifier = (*Controller)(nil)
+)
 
 // Controller is the Controller implementation for asyncapi-codegen.
 type Controller struct {
@@ -29,7 +35,17 @@ type Controller struct {
 	streamConfig   *jetstream.StreamConfig
 	consumerConfig *jetstream.ConsumerConfig
 
+	// Options applied on the consumer configuration, from WithDurable, WithAckWait, etc
+	consumerOptions []func(*jetstream.ConsumerConfig)
+
 	nakDelay time.Duration
+
+	reconnects extensions.ReconnectListeners
+
+	// Stores of the last value caches, by bucket
+	storesMutex  sync.Mutex
+	keyValues    map[string]jetstream.KeyValue
+	objectStores map[string]nats.ObjectStore
 }
 
 // NewController creates a new NATS JetStream controller.
@@ -41,6 +57,8 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 		channels:       make(map[string]chan jetstream.Msg),
 		consumeContext: nil,
 		nakDelay:       time.Second * 5,
+		keyValues:      make(map[string]jetstream.KeyValue),
+		objectStores:   make(map[string]nats.ObjectStore),
 	}
 
 	// Execute options
@@ -60,6 +78,15 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 		controller.setNatsConnection(nc, true)
 	}
 
+	// Notify the reconnections, while keeping the handler set by the user
+	reconnectedCB := controller.natsConn.Opts.ReconnectedCB
+	controller.natsConn.SetReconnectHandler(func(nc *nats.Conn) {
+		if reconnectedCB != nil {
+			reconnectedCB(nc)
+		}
+		controller.reconnects.Notify()
+	})
+
 	// Create a JetStream management interface
 	js, err := jetstream.New(controller.natsConn)
 	if err != nil {
@@ -75,8 +102,12 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 		}
 	}
 
-	// if a ConsumerConfig was configured by the user via WithConsumerConfig register the consumer
-	if controller.consumerConfig != nil {
+	// if a ConsumerConfig was configured by the user via WithConsumerConfig or named
+	// with the consumer options, register the consumer (without name, the consumer
+	// options are only the defaults of the operations consumers)
+	hasConsumerConfig := controller.consumerConfig != nil
+	controller.applyConsumerOptions()
+	if controller.consumerConfig != nil && (hasConsumerConfig || controller.consumerConfig.Name != "") {
 		if err := controller.registerConsumer(); err != nil {
 			return nil, err
 		}
@@ -205,24 +236,67 @@ func (c *Controller) registerStream() error {
 
 // Publish a message to the broker.
 func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	// Publish message
+	if _, err := c.jetStream.PublishMsg(ctx, newMsg(channel, bm)); err != nil {
+		return err
+	}
+
+	return c.persistLastValues(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+// PublishBatch publishes several messages asynchronously, then waits for all
+// of them to be acknowledged by the stream.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	futures := make([]jetstream.PubAckFuture, 0, len(bms))
+	for _, bm := range bms {
+		f, err := c.jetStream.PublishMsgAsync(newMsg(channel, bm))
+		if err != nil {
+			return err
+		}
+		futures = append(futures, f)
+	}
+
+	for _, f := range futures {
+		select {
+		case <-f.Ok():
+		case err := <-f.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return c.persistLastValues(ctx, channel, bms)
+}
+
+func newMsg(channel string, bm extensions.BrokerMessage) *nats.Msg {
 	msg := nats.NewMsg(channel)
 
 	// Set message headers and content
 	for k, v := range bm.Headers {
 		msg.Header.Set(k, string(v))
 	}
-	msg.Data = bm.Payload
-
-	// Publish message
-	if _, err := c.jetStream.PublishMsg(ctx, msg); err != nil {
-		return err
+	if bm.ContentType != "" {
+		msg.Header.Set(brokers.ContentTypeHeaderKey, bm.ContentType)
 	}
+	msg.Data = bm.Payload
 
-	return nil
+	return msg
 }
 
 // Subscribe to messages from the broker.
 func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	// Use a dedicated consumer if the operation overrides its configuration
+	var consumerBindings *extensions.JetStreamConsumerBindings
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		if b.NATS != nil {
+			consumerBindings = b.NATS.JetStreamConsumer
+		}
+	})
+	if consumerBindings != nil {
+		return c.subscribeWithConsumer(ctx, channel, *consumerBindings)
+	}
+
 	// Create a new subscription
 	sub := extensions.NewBrokerChannelSubscription(
 		make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize),
@@ -236,8 +310,11 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 		return extensions.BrokerChannelSubscription{}, err
 	}
 
+	// Get the channel now, as it is replaced if the channel is subscribed again
+	// after the cancellation of this subscription
+	messages := c.channels[channel]
 	go func() {
-		for message := range c.channels[channel] {
+		for message := range messages {
 			c.logger.Info(ctx, fmt.Sprintf("Received message for %s", channel), extensions.LogInfo{
 				Key:   "message",
 				Value: message,
@@ -256,8 +333,55 @@ func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.
 	return sub, nil
 }
 
+// SubscribeToPattern subscribes to the messages of all the addresses of the
+// channel, with a dedicated consumer filtering the subjects with the '*'
+// wildcard in place of the parameters.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	subject, err := extensions.ChannelAddressPattern(channelAddr, ".", "*")
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	// The consumer is ephemeral, except if the operation bindings set a durable name
+	var consumerBindings extensions.JetStreamConsumerBindings
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		if b.NATS != nil && b.NATS.JetStreamConsumer != nil {
+			consumerBindings = *b.NATS.JetStreamConsumer
+		}
+	})
+
+	return c.subscribeWithConsumer(ctx, subject, consumerBindings)
+}
+
+// messageMetadata returns the delivery metadata of the JetStream message, from
+// its reply subject.
+func messageMetadata(msg jetstream.Msg) extensions.MessageMetadata {
+	md := extensions.MessageMetadata{RoutingKey: msg.Subject()}
+	if meta, err := msg.Metadata(); err == nil {
+		md.DeliveryCount = int(meta.NumDelivered)
+		md.Redelivered = meta.NumDelivered > 1
+		md.Timestamp = meta.Timestamp
+		md.Offset = int64(meta.Sequence.Stream)
+	}
+	return md
+}
+
 // HandleMessage handles a message received from a stream.
 func (c *Controller) HandleMessage(ctx context.Context, msg jetstream.Msg, sub extensions.BrokerChannelSubscription) {
+	c.handleMessage(ctx, msg, sub, false)
+}
+
+// handleMessage handles a message received from a stream, with its subject as
+// address if withAddress is set.
+func (c *Controller) handleMessage(
+	ctx context.Context,
+	msg jetstream.Msg,
+	sub extensions.BrokerChannelSubscription,
+	withAddress bool,
+) {
 	// Get headers
 	headers := make(map[string][]byte, len(msg.Headers()))
 	for k, v := range msg.Headers() {
@@ -266,12 +390,18 @@ func (c *Controller) HandleMessage(ctx context.Context, msg jetstream.Msg, sub e
 		}
 	}
 
+	bm := extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     msg.Data(),
+	}
+	if withAddress {
+		bm.Address = msg.Subject()
+	}
+
 	// Create and transmit message to user
 	sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
-		extensions.BrokerMessage{
-			Headers: headers,
-			Payload: msg.Data(),
-		},
+		bm,
 		AcknowledgementHandler{
 			doAck: func() {
 				if err := msg.Ack(); err != nil {
@@ -283,7 +413,32 @@ func (c *Controller) HandleMessage(ctx context.Context, msg jetstream.Msg, sub e
 					c.logger.Error(ctx, fmt.Sprintf("error on nak message: %q", err.Error()))
 				}
 			},
-		}))
+		}).WithMetadata(messageMetadata(msg)))
+}
+
+// hasWildcard returns true if the subject has wildcards.
+func hasWildcard(subject string) bool {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// Healthy returns an error if the connection to NATS is not established, for
+// example while reconnecting.
+func (c *Controller) Healthy(_ context.Context) error {
+	if !c.natsConn.IsConnected() {
+		return fmt.Errorf("nats connection is %s", c.natsConn.Status())
+	}
+	return nil
+}
+
+// NotifyReconnect registers a function called after each reconnection to NATS,
+// until the returned function is called.
+func (c *Controller) NotifyReconnect(fn func()) (stop func()) {
+	return c.reconnects.NotifyReconnect(fn)
 }
 
 // Close closes everything related to the broker.
@@ -5,12 +5,16 @@ import (
 	"crypto/tls"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
 	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
 )
 
 //nolint:funlen // this is only for testing
@@ -214,3 +218,73 @@ func TestExistingNatsConnection(t *testing.T) {
 
 	assert.True(t, nc.IsConnected(), "our connection should still be intact")
 }
+
+func TestLastValueCache(t *testing.T) {
+	subj := "NatsJetstreamLastValueCache"
+	broker, err := NewController(
+		testutil.BrokerAddress(testutil.BrokerAddressParams{
+			Schema:         "nats",
+			DockerizedAddr: "nats-jetstream",
+			DockerizedPort: "4222",
+			LocalPort:      "4225",
+		}),
+		WithStreamConfig(jetstream.StreamConfig{
+			Name:     subj,
+			Subjects: []string{subj + ".>"},
+		}),
+	)
+	require.NoError(t, err, "new controller should not return error")
+	defer broker.Close()
+
+	for _, store := range []string{extensions.LastValueCacheStoreKV, extensions.LastValueCacheStoreObject} {
+		t.Run(store, func(t *testing.T) {
+			cache := extensions.LastValueCache{Store: store, Bucket: subj + "-" + store}
+			ctx := context.WithValue(context.Background(), extensions.ContextKeyIsLastValueCache, cache)
+			channel := subj + "." + store + ".42"
+
+			_, err := broker.LastValue(context.Background(), cache, channel)
+			assert.ErrorIs(t, err, ErrNoLastValue)
+
+			err = broker.PublishBatch(ctx, channel, []extensions.BrokerMessage{
+				{Payload: []byte("first")},
+				{Payload: []byte("second"), ContentType: "text/plain"},
+			})
+			require.NoError(t, err)
+
+			bm, err := broker.LastValue(context.Background(), cache, channel)
+			require.NoError(t, err)
+			assert.Equal(t, "second", string(bm.Payload))
+			if store == extensions.LastValueCacheStoreObject {
+				assert.Equal(t, "text/plain", bm.ContentType)
+			}
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	subj := "NatsJetstreamConformance"
+	addr := testutil.BrokerAddress(testutil.BrokerAddressParams{
+		Schema:         "nats",
+		DockerizedAddr: "nats-jetstream",
+		DockerizedPort: "4222",
+		LocalPort:      "4225",
+	})
+	testutil.SkipIfBrokerUnavailable(t, addr)
+
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController(addr,
+				WithStreamConfig(jetstream.StreamConfig{
+					Name:     subj,
+					Subjects: []string{subj + ".>"},
+				}),
+				WithConsumerConfig(jetstream.ConsumerConfig{Name: "natsJetstreamConformance"}),
+				WithNakDelay(100*time.Millisecond))
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		ChannelPrefix: subj + ".",
+		Redelivery:    true,
+	})
+}
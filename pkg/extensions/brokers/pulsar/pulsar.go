@@ -0,0 +1,367 @@
+package pulsar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interface.
+var _ extensions.BrokerController = (*Controller)(nil)
+
+// Controller is the Pulsar implementation for asyncapi-codegen.
+type Controller struct {
+	client        pulsar.Client
+	clientOptions pulsar.ClientOptions
+	logger        extensions.Logger
+
+	topicPrefix         string
+	queueGroup          string
+	subscriptionType    *pulsar.SubscriptionType
+	nackRedeliveryDelay time.Duration
+	messageKey          func(channel string, bm extensions.BrokerMessage) string
+
+	mu        sync.Mutex
+	producers map[string]pulsar.Producer
+}
+
+// ControllerOption is a function that can be used to configure a Pulsar controller
+// Examples: WithQueueGroup(), WithSubscriptionType(), WithLogger().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a new Pulsar controller.
+func NewController(url string, options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		clientOptions: pulsar.ClientOptions{URL: url},
+		logger:        extensions.DummyLogger{},
+		queueGroup:    brokers.DefaultQueueGroupID,
+		producers:     make(map[string]pulsar.Producer),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	// Create the client, which connects lazily to Pulsar
+	client, err := pulsar.NewClient(controller.clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("could not create pulsar client: %w", err)
+	}
+	controller.client = client
+
+	return controller, nil
+}
+
+// WithQueueGroup set a custom queue group for channel subscription: the
+// controllers with the same queue group share a durable subscription named after
+// it, with the Shared subscription type unless WithSubscriptionType is used.
+//
+// Without queue group, each subscription gets all the messages through an
+// exclusive non-durable subscription.
+func WithQueueGroup(name string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.queueGroup = name
+		return nil
+	}
+}
+
+// WithSubscriptionType set the type of the subscriptions: pulsar.Exclusive,
+// pulsar.Shared, pulsar.Failover or pulsar.KeyShared.
+func WithSubscriptionType(t pulsar.SubscriptionType) ControllerOption {
+	return func(controller *Controller) error {
+		switch t {
+		case pulsar.Exclusive, pulsar.Shared, pulsar.Failover, pulsar.KeyShared:
+			controller.subscriptionType = &t
+			return nil
+		default:
+			return fmt.Errorf("invalid subscription type: %d", t)
+		}
+	}
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithTopicPrefix set a prefix added to the channels to get their Pulsar topic
+// (for example 'persistent://<tenant>/<namespace>/'). Without it, topics are in
+// the default tenant and namespace.
+func WithTopicPrefix(prefix string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.topicPrefix = prefix
+		return nil
+	}
+}
+
+// WithMessageKey set the function that returns the key of the published
+// messages, used to route them with the KeyShared subscription type and to
+// compact topics.
+func WithMessageKey(fn func(channel string, bm extensions.BrokerMessage) string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.messageKey = fn
+		return nil
+	}
+}
+
+// WithNackRedeliveryDelay set the delay after which naked messages are
+// redelivered. Pulsar default is one minute.
+func WithNackRedeliveryDelay(d time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if d <= 0 {
+			return fmt.Errorf("nack redelivery delay should be positive")
+		}
+		controller.nackRedeliveryDelay = d
+		return nil
+	}
+}
+
+// WithConnectionOpts modifies the pulsar.ClientOptions used to connect to Pulsar.
+func WithConnectionOpts(fn func(opts *pulsar.ClientOptions)) ControllerOption {
+	return func(controller *Controller) error {
+		fn(&controller.clientOptions)
+		return nil
+	}
+}
+
+// topic returns the Pulsar topic of the channel.
+func (c *Controller) topic(channel string) string {
+	return c.topicPrefix + channel
+}
+
+// producer returns the producer of the topic, creating it if needed.
+func (c *Controller) producer(topic string) (pulsar.Producer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, exists := c.producers[topic]; exists {
+		return p, nil
+	}
+
+	p, err := c.client.CreateProducer(pulsar.ProducerOptions{Topic: topic})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer on topic %q: %w", topic, err)
+	}
+	c.producers[topic] = p
+
+	return p, nil
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+// PublishBatch publishes several messages, then waits for all of them to be
+// acknowledged by Pulsar.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	p, err := c.producer(c.topic(channel))
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, len(bms))
+	for _, bm := range bms {
+		p.SendAsync(ctx, c.producerMessage(channel, bm), func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+			errs <- err
+		})
+	}
+
+	// Messages are sent in batches by the producer
+	if err := p.FlushWithCtx(ctx); err != nil {
+		return fmt.Errorf("failed to flush producer: %w", err)
+	}
+
+	var failed []error
+	for range bms {
+		if err := <-errs; err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to publish %d/%d messages: %w", len(failed), len(bms), errors.Join(failed...))
+	}
+
+	return nil
+}
+
+// producerMessage converts the broker message to a Pulsar message, with its
+// headers and content type as properties.
+func (c *Controller) producerMessage(channel string, bm extensions.BrokerMessage) *pulsar.ProducerMessage {
+	properties := make(map[string]string, len(bm.Headers)+1)
+	for k, v := range bm.Headers {
+		properties[k] = string(v)
+	}
+	if bm.ContentType != "" {
+		properties[brokers.ContentTypeHeaderKey] = bm.ContentType
+	}
+
+	msg := &pulsar.ProducerMessage{
+		Payload:    bm.Payload,
+		Properties: properties,
+	}
+	if c.messageKey != nil {
+		msg.Key = c.messageKey(channel, bm)
+	}
+
+	return msg
+}
+
+// consumerOptions returns the options of the consumer of the topic.
+func (c *Controller) consumerOptions(topic string) pulsar.ConsumerOptions {
+	opts := pulsar.ConsumerOptions{
+		Topic:               topic,
+		NackRedeliveryDelay: c.nackRedeliveryDelay,
+	}
+
+	if c.queueGroup != "" {
+		opts.SubscriptionName = c.queueGroup
+		opts.Type = pulsar.Shared
+	} else {
+		// Nothing should be kept on Pulsar once the controller is gone
+		opts.SubscriptionName = extensions.Prefix + uuid.NewString()
+		opts.SubscriptionMode = pulsar.NonDurable
+		opts.Type = pulsar.Exclusive
+	}
+
+	if c.subscriptionType != nil {
+		opts.Type = *c.subscriptionType
+	}
+
+	return opts
+}
+
+// Subscribe to messages from the broker.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	consumer, err := c.client.Subscribe(c.consumerOptions(c.topic(channel)))
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("failed to subscribe to %q: %w", channel, err)
+	}
+
+	// Create a new subscription
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	sub := extensions.NewBrokerChannelSubscription(messages, make(chan any, 1))
+
+	// Receive messages until the subscription is cancelled
+	receiveCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.receiveMessages(receiveCtx, consumer, messages)
+	}()
+
+	// Wait for cancellation and close the consumer
+	sub.WaitForCancellationAsync(func() {
+		cancel()
+		<-done
+		consumer.Close()
+	})
+
+	return sub, nil
+}
+
+func (c *Controller) receiveMessages(
+	ctx context.Context,
+	consumer pulsar.Consumer,
+	messages chan<- extensions.AcknowledgeableBrokerMessage,
+) {
+	for {
+		msg, err := consumer.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Receive only fails when the consumer is closed
+			c.logger.Error(ctx, "failed to receive message",
+				extensions.LogInfo{Key: "subscription", Value: consumer.Subscription()},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+			return
+		}
+
+		// Get headers
+		headers := make(map[string][]byte, len(msg.Properties()))
+		for k, v := range msg.Properties() {
+			headers[k] = []byte(v)
+		}
+
+		// Create and transmit message to user, unless the subscription is
+		// cancelled while waiting for the user: the message is not acknowledged
+		// and is redelivered once the consumer is closed
+		select {
+		case messages <- extensions.NewAcknowledgeableBrokerMessage(
+			extensions.BrokerMessage{
+				ContentType: brokers.ExtractContentType(headers),
+				Headers:     headers,
+				Payload:     msg.Payload(),
+			},
+			AcknowledgementHandler{
+				consumer: consumer,
+				msg:      msg,
+				logger:   c.logger,
+			},
+		).WithMetadata(messageMetadata(msg)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// messageMetadata returns the delivery metadata of the Pulsar message.
+func messageMetadata(msg pulsar.Message) extensions.MessageMetadata {
+	return extensions.MessageMetadata{
+		Redelivered:   msg.RedeliveryCount() > 0,
+		DeliveryCount: int(msg.RedeliveryCount()) + 1,
+		Timestamp:     msg.PublishTime(),
+		RoutingKey:    msg.Topic(),
+		ID:            msg.ID().String(),
+	}
+}
+
+// Close closes everything related to the broker.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	for topic, p := range c.producers {
+		p.Close()
+		delete(c.producers, topic)
+	}
+	c.mu.Unlock()
+
+	c.client.Close()
+}
+
+var _ extensions.BrokerAcknowledgment = (*AcknowledgementHandler)(nil)
+
+// AcknowledgementHandler for Pulsar broker.
+type AcknowledgementHandler struct {
+	consumer pulsar.Consumer
+	msg      pulsar.Message
+	logger   extensions.Logger
+}
+
+// AckMessage acknowledges the message.
+func (h AcknowledgementHandler) AckMessage() {
+	if err := h.consumer.Ack(h.msg); err != nil {
+		h.logger.Error(context.Background(), "failed to acknowledge message",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+}
+
+// NakMessage negatively acknowledges the message, so it is redelivered after
+// the nack redelivery delay.
+func (h AcknowledgementHandler) NakMessage() {
+	h.consumer.Nack(h.msg)
+}
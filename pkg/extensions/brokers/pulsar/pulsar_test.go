@@ -0,0 +1,130 @@
+package pulsar
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func brokerAddress() string {
+	return testutil.BrokerAddress(testutil.BrokerAddressParams{
+		Schema:         "pulsar",
+		DockerizedAddr: "pulsar",
+		Port:           "6650",
+	})
+}
+
+func TestOptionsValidation(t *testing.T) {
+	_, err := NewController(brokerAddress(), WithSubscriptionType(pulsar.SubscriptionType(42)))
+	assert.Error(t, err)
+
+	_, err = NewController(brokerAddress(), WithNackRedeliveryDelay(0))
+	assert.Error(t, err)
+}
+
+func TestConsumerOptions(t *testing.T) {
+	// Without queue group
+	c := &Controller{topicPrefix: "persistent://tenant/ns/"}
+	opts := c.consumerOptions(c.topic("orders"))
+	assert.Equal(t, "persistent://tenant/ns/orders", opts.Topic)
+	assert.True(t, strings.HasPrefix(opts.SubscriptionName, extensions.Prefix))
+	assert.NotEqual(t, opts.SubscriptionName, c.consumerOptions("orders").SubscriptionName)
+	assert.Equal(t, pulsar.NonDurable, opts.SubscriptionMode)
+	assert.Equal(t, pulsar.Exclusive, opts.Type)
+
+	// With queue group
+	c = &Controller{queueGroup: "billing", nackRedeliveryDelay: time.Second}
+	opts = c.consumerOptions("orders")
+	assert.Equal(t, "billing", opts.SubscriptionName)
+	assert.Equal(t, pulsar.Durable, opts.SubscriptionMode)
+	assert.Equal(t, pulsar.Shared, opts.Type)
+	assert.Equal(t, time.Second, opts.NackRedeliveryDelay)
+
+	// With subscription type
+	require.NoError(t, WithSubscriptionType(pulsar.KeyShared)(c))
+	assert.Equal(t, pulsar.KeyShared, c.consumerOptions("orders").Type)
+}
+
+func TestProducerMessage(t *testing.T) {
+	c := &Controller{messageKey: func(channel string, bm extensions.BrokerMessage) string {
+		return channel + "/" + string(bm.Headers["customer"])
+	}}
+
+	msg := c.producerMessage("orders", extensions.BrokerMessage{
+		Headers:     map[string][]byte{"customer": []byte("alice")},
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+	})
+	assert.Equal(t, "orders/alice", msg.Key)
+	assert.Equal(t, []byte(`{}`), msg.Payload)
+	assert.Equal(t, map[string]string{"customer": "alice", "content-type": "application/json"}, msg.Properties)
+}
+
+func receive(t *testing.T, sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("message not received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+func TestPulsarController_PublishSubscribe(t *testing.T) {
+	// The client retries to connect for about a minute without broker
+	testutil.SkipIfBrokerUnavailable(t, brokerAddress())
+
+	topic := "pulsar-publish-subscribe"
+	c, err := NewController(brokerAddress(), WithNackRedeliveryDelay(100*time.Millisecond))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), topic)
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	err = c.PublishBatch(context.Background(), topic, []extensions.BrokerMessage{
+		{Headers: map[string][]byte{"id": []byte("1")}, ContentType: "text/plain", Payload: []byte("first")},
+		{Headers: map[string][]byte{"id": []byte("2")}, Payload: []byte("second")},
+	})
+	require.NoError(t, err)
+
+	msg := receive(t, sub)
+	assert.Equal(t, "first", string(msg.Payload))
+	assert.Equal(t, "text/plain", msg.ContentType)
+	assert.Equal(t, map[string][]byte{"id": []byte("1")}, msg.Headers)
+	msg.Ack()
+
+	// Naked messages are redelivered
+	msg = receive(t, sub)
+	assert.Equal(t, "second", string(msg.Payload))
+	msg.Nak()
+
+	msg = receive(t, sub)
+	assert.Equal(t, "second", string(msg.Payload))
+	msg.Ack()
+}
+
+func TestConformance(t *testing.T) {
+	testutil.SkipIfBrokerUnavailable(t, brokerAddress())
+
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewController(brokerAddress(), WithNackRedeliveryDelay(100*time.Millisecond))
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		Timeout:    10 * time.Second,
+		Redelivery: true,
+	})
+}
@@ -0,0 +1,102 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DefaultChannelPoolSize is the default number of idle channels kept for publishing.
+const DefaultChannelPoolSize = 8
+
+// pooledChannel is an AMQP channel that can be reused across publications.
+type pooledChannel struct {
+	*amqp.Channel
+
+	// returns receives the unroutable messages, only set in confirm mode
+	returns chan amqp.Return
+}
+
+// channelPool keeps idle AMQP channels in order to avoid opening and closing
+// a channel on each publication. Channels in confirm mode are kept apart.
+type channelPool struct {
+	channels        chan *pooledChannel
+	confirmChannels chan *pooledChannel
+}
+
+func newChannelPool(size int) *channelPool {
+	return &channelPool{
+		channels:        make(chan *pooledChannel, size),
+		confirmChannels: make(chan *pooledChannel, size),
+	}
+}
+
+// idle returns the idle channels of the pool, in confirm mode or not.
+func (p *channelPool) idle(confirm bool) chan *pooledChannel {
+	if confirm {
+		return p.confirmChannels
+	}
+	return p.channels
+}
+
+// get returns an idle channel from the pool, or a new one from the connection
+// if there is none.
+func (p *channelPool) get(conn *amqp.Connection, confirm bool) (*pooledChannel, error) {
+	for {
+		select {
+		case pc := <-p.idle(confirm):
+			// Discard channels that have been closed while idle
+			if pc.IsClosed() {
+				continue
+			}
+			return pc, nil
+		default:
+			return newPooledChannel(conn, confirm)
+		}
+	}
+}
+
+// put gives back a channel to the pool, or closes it if the pool is full.
+func (p *channelPool) put(pc *pooledChannel) {
+	if pc.IsClosed() {
+		return
+	}
+
+	select {
+	case p.idle(pc.returns != nil) <- pc:
+	default:
+		_ = pc.Close()
+	}
+}
+
+// close closes all idle channels of the pool.
+func (p *channelPool) close() {
+	for _, channels := range []chan *pooledChannel{p.channels, p.confirmChannels} {
+		for done := false; !done; {
+			select {
+			case pc := <-channels:
+				_ = pc.Close()
+			default:
+				done = true
+			}
+		}
+	}
+}
+
+func newPooledChannel(conn *amqp.Connection, confirm bool) (*pooledChannel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	pc := &pooledChannel{Channel: ch}
+
+	if confirm {
+		if err := ch.Confirm(false); err != nil {
+			_ = ch.Close()
+			return nil, fmt.Errorf("failed to put channel in confirm mode: %w", err)
+		}
+		pc.returns = ch.NotifyReturn(make(chan amqp.Return, 1))
+	}
+
+	return pc, nil
+}
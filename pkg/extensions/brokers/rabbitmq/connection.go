@@ -0,0 +1,155 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Default reconnection constants.
+const (
+	DefaultReconnectInitialBackoff = 500 * time.Millisecond
+	DefaultReconnectMaxBackoff     = 30 * time.Second
+)
+
+// dial opens a new connection to RabbitMQ, with the connection options if any.
+func (c *Controller) dial() (*amqp.Connection, error) {
+	if c.config == nil && c.tlsConfig == nil && c.vhost == "" && c.credentials == nil && c.heartbeat == 0 {
+		return amqp.Dial(c.url)
+	}
+
+	return amqp.DialConfig(c.url, c.dialConfig())
+}
+
+// dialConfig returns the connection configuration, from the connection options
+// overridden by the more specific options.
+func (c *Controller) dialConfig() amqp.Config {
+	var config amqp.Config
+	if c.config != nil {
+		config = *c.config
+	}
+
+	if c.tlsConfig != nil {
+		// Clone as the server name can be set by the dial
+		config.TLSClientConfig = c.tlsConfig.Clone()
+	}
+	if c.vhost != "" {
+		config.Vhost = c.vhost
+	}
+	if c.credentials != nil {
+		config.SASL = []amqp.Authentication{c.credentials}
+	}
+	if c.heartbeat > 0 {
+		config.Heartbeat = c.heartbeat
+	}
+
+	return config
+}
+
+// connect establishes a connection to RabbitMQ and watches it in order to
+// reconnect if it drops.
+func (c *Controller) connect() error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.connection = conn
+
+	// Register right away to be sure to not miss the connection closure
+	go c.watchConnection(conn.NotifyClose(make(chan *amqp.Error, 1)))
+
+	return nil
+}
+
+// watchConnection waits for the connection to be closed and reconnects if the
+// closure was not requested by the user.
+func (c *Controller) watchConnection(notify <-chan *amqp.Error) {
+	amqpErr, ok := <-notify
+	if !ok || amqpErr == nil {
+		// Connection has been closed gracefully with Close()
+		return
+	}
+
+	c.logger.Warning(context.Background(), "connection to RabbitMQ lost",
+		extensions.LogInfo{Key: "error", Value: amqpErr.Error()})
+
+	c.reconnect()
+}
+
+// reconnect tries to establish a new connection with an exponential backoff
+// between attempts, then resumes the active subscriptions on it and notifies
+// the reconnection.
+func (c *Controller) reconnect() {
+	backoff := c.reconnectInitialBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		conn, err := c.dial()
+		if err != nil {
+			c.logger.Warning(context.Background(), "failed to reconnect to RabbitMQ",
+				extensions.LogInfo{Key: "attempt", Value: attempt},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+
+			backoff *= 2
+			if backoff > c.reconnectMaxBackoff {
+				backoff = c.reconnectMaxBackoff
+			}
+			continue
+		}
+
+		// Register right away to be sure to not miss the connection closure
+		notify := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		if !c.resume(conn) {
+			// Controller has been closed in the meantime
+			_ = conn.Close()
+			return
+		}
+
+		c.logger.Info(context.Background(), "reconnected to RabbitMQ",
+			extensions.LogInfo{Key: "attempt", Value: attempt})
+		c.reconnects.Notify()
+
+		go c.watchConnection(notify)
+		return
+	}
+}
+
+// resume replaces the controller connection and re-establishes the active
+// subscriptions on it. It returns false if the controller is closed.
+func (c *Controller) resume(conn *amqp.Connection) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	// Idle channels belong to the previous connection
+	c.channels.close()
+	c.connection = conn
+
+	for s := range c.subscriptions {
+		if s.stopped() {
+			continue
+		}
+
+		if err := c.consume(conn, s); err != nil {
+			c.logger.Error(context.Background(), "failed to resume subscription",
+				extensions.LogInfo{Key: "channel", Value: s.channel},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+			continue
+		}
+
+		c.logger.Info(context.Background(), "subscription resumed",
+			extensions.LogInfo{Key: "channel", Value: s.channel})
+	}
+
+	return true
+}
@@ -0,0 +1,129 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Check interface implementation at compile time.
+var _ extensions.DelayedPublisher = (*Controller)(nil)
+
+const (
+	// DelayedMessageExchangeType is the exchange type of the RabbitMQ delayed
+	// message exchange plugin.
+	DelayedMessageExchangeType = "x-delayed-message"
+
+	// delayQueueExpiration is the time a delay queue is kept once its last
+	// message has expired.
+	delayQueueExpiration = time.Minute
+)
+
+// WithDelayedMessageExchange uses an exchange of the RabbitMQ delayed message
+// exchange plugin to publish messages with a delay. The plugin has to be
+// enabled on the broker.
+//
+// Without this option, delayed messages are published on a delay queue per
+// destination and delay, from which they are dead-lettered to their
+// destination when their TTL expires.
+func WithDelayedMessageExchange(name string) ControllerOption {
+	return func(c *Controller) error {
+		if name == "" {
+			return fmt.Errorf("delayed message exchange name cannot be empty")
+		}
+		c.delayedExchange = name
+		return nil
+	}
+}
+
+// PublishWithDelay sends a message to the specified queue, that will only be
+// delivered after the delay.
+func (c *Controller) PublishWithDelay(
+	ctx context.Context,
+	queueName string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	return c.Publish(context.WithValue(ctx, extensions.ContextKeyIsDelay, delay), queueName, bm)
+}
+
+// messageDelay returns the delay set in the context, if any.
+func messageDelay(ctx context.Context) time.Duration {
+	var delay time.Duration
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsDelay, func(d time.Duration) {
+		delay = d
+	})
+	return delay
+}
+
+// delayDestination declares what is needed to deliver the messages to the
+// destination after the delay, and returns where they have to be published.
+func (c *Controller) delayDestination(ch *amqp.Channel, d destination, delay time.Duration) (destination, error) {
+	if c.delayedExchange != "" {
+		return c.delayedExchangeDestination(ch, d, delay)
+	}
+	return c.delayQueueDestination(ch, d, delay)
+}
+
+// delayedExchangeDestination routes the messages through the delayed message
+// exchange, which is bound to the destination.
+func (c *Controller) delayedExchangeDestination(
+	ch *amqp.Channel,
+	d destination,
+	delay time.Duration,
+) (destination, error) {
+	err := ch.ExchangeDeclare(c.delayedExchange, DelayedMessageExchangeType, true, false, false, false, amqp.Table{
+		"x-delayed-type": "direct",
+	})
+	if err != nil {
+		return destination{}, fmt.Errorf("failed to declare delayed message exchange: %w", err)
+	}
+
+	// The default exchange cannot be bound, so the queue is bound instead
+	if d.exchange == "" {
+		err = ch.QueueBind(d.queue, d.routingKey, c.delayedExchange, false, nil)
+	} else {
+		err = ch.ExchangeBind(d.exchange, d.routingKey, c.delayedExchange, false, nil)
+	}
+	if err != nil {
+		return destination{}, fmt.Errorf("failed to bind delayed message exchange: %w", err)
+	}
+
+	return destination{
+		exchange:   c.delayedExchange,
+		routingKey: d.routingKey,
+		headers:    amqp.Table{"x-delay": delay.Milliseconds()},
+
+		// Messages are only routed when the delay expires
+		notMandatory:             true,
+		delayedToHeadersExchange: d.isHeadersExchange(),
+	}, nil
+}
+
+// delayQueueDestination publishes the messages on a queue without consumers,
+// from which they are dead-lettered to the destination when they expire.
+func (c *Controller) delayQueueDestination(
+	ch *amqp.Channel,
+	d destination,
+	delay time.Duration,
+) (destination, error) {
+	name := fmt.Sprintf("%s.delay.%d", d.routingKey, delay.Milliseconds())
+	if d.exchange != "" {
+		name = d.exchange + "." + name
+	}
+
+	_, err := ch.QueueDeclare(name, d.queueOptions.Durable, false, false, false, amqp.Table{
+		"x-message-ttl":             delay.Milliseconds(),
+		"x-expires":                 (delay + delayQueueExpiration).Milliseconds(),
+		"x-dead-letter-exchange":    d.exchange,
+		"x-dead-letter-routing-key": d.routingKey,
+	})
+	if err != nil {
+		return destination{}, fmt.Errorf("failed to declare delay queue: %w", err)
+	}
+
+	return destination{routingKey: name, delayedToHeadersExchange: d.isHeadersExchange()}, nil
+}
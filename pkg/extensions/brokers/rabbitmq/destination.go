@@ -0,0 +1,238 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQP channel bindings types.
+const (
+	// BindingIsRoutingKey is used when the channel address is a routing key on an exchange.
+	BindingIsRoutingKey = "routingKey"
+	// BindingIsQueue is used when the channel address is a queue.
+	BindingIsQueue = "queue"
+)
+
+// destination is where messages of a channel are published to or consumed from.
+type destination struct {
+	exchange        string
+	exchangeOptions ExchangeDeclare
+	routingKey      string
+
+	queue        string
+	queueOptions QueueDeclare
+
+	// headersFilter is the headers the messages should have to be routed to
+	// the queue, when bound to a headers exchange.
+	headersFilter map[string]string
+
+	// declareQueueOnPublish is true if the queue should be declared when
+	// publishing, instead of relying on the exchange bindings.
+	declareQueueOnPublish bool
+
+	// headers are added to the headers of the published messages.
+	headers amqp.Table
+
+	// notMandatory is true if the published messages can't be routed right
+	// away, so the broker would always return them if published as mandatory.
+	notMandatory bool
+
+	// delayedToHeadersExchange is true if the messages are delivered to a
+	// headers exchange once their delay expires.
+	delayedToHeadersExchange bool
+
+	// replyQueue is true if the queue is a temporary reply queue, which is
+	// already declared and reached through the default exchange.
+	replyQueue bool
+}
+
+// destination returns the destination of the channel, based on the controller
+// configuration and the channel bindings that can be present in the context.
+func (c *Controller) destination(ctx context.Context, channel string) (destination, error) {
+	// The temporary reply queues are published to and consumed directly
+	if strings.HasPrefix(channel, replyQueuePrefix) {
+		return destination{routingKey: channel, queue: channel, replyQueue: true}, nil
+	}
+
+	d := destination{
+		exchange:        c.exchangeName(),
+		exchangeOptions: c.exchangeOptions,
+		routingKey:      channel,
+		queue:           channel,
+		queueOptions:    c.queueOptions,
+
+		// Only declare the queue when publishing directly to it, otherwise the
+		// exchange is in charge of routing the message to the bound queues
+		declareQueueOnPublish: c.exchange == "",
+	}
+
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsHeadersFilter, func(filter map[string]string) {
+		d.headersFilter = filter
+	})
+
+	var err error
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannelBindings, func(b extensions.ChannelBindings) {
+		if b.AMQP != nil {
+			err = c.applyBindings(&d, *b.AMQP)
+		}
+	})
+
+	return d, err
+}
+
+// applyBindings overrides the destination with the AMQP channel bindings.
+func (c *Controller) applyBindings(d *destination, b extensions.AMQPChannelBindings) error {
+	if err := c.checkVHost(b.Exchange.VHost, b.Queue.VHost); err != nil {
+		return err
+	}
+
+	if b.Queue != (extensions.AMQPQueueBindings{}) {
+		if b.Queue.Name != "" {
+			d.queue = b.Queue.Name
+		}
+		d.queueOptions.Durable = b.Queue.Durable
+		d.queueOptions.Exclusive = b.Queue.Exclusive
+		d.queueOptions.AutoDelete = b.Queue.AutoDelete
+	}
+
+	switch b.Is {
+	case BindingIsQueue:
+		// Publish directly to the queue through the default exchange
+		d.exchange = ""
+		d.routingKey = d.queue
+		d.declareQueueOnPublish = true
+	case BindingIsRoutingKey, "":
+		if err := applyExchangeBindings(d, b.Exchange); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid channel bindings type: %s", b.Is)
+	}
+
+	return validateQueueDeclare(d.queueOptions)
+}
+
+// applyExchangeBindings overrides the destination exchange with the AMQP
+// exchange bindings, if any.
+func applyExchangeBindings(d *destination, b extensions.AMQPExchangeBindings) error {
+	if b == (extensions.AMQPExchangeBindings{}) {
+		return nil
+	}
+
+	if b.Name != "" {
+		d.exchange = b.Name
+		d.declareQueueOnPublish = false
+	}
+	if b.Type != "" {
+		if !isValidExchangeType(b.Type) {
+			return fmt.Errorf("invalid exchange type in channel bindings: %s", b.Type)
+		}
+		d.exchangeOptions.Type = b.Type
+	}
+	d.exchangeOptions.Durable = b.Durable
+	d.exchangeOptions.AutoDelete = b.AutoDelete
+
+	return nil
+}
+
+// checkVHost checks that the virtual hosts required by the channel bindings
+// are the one the controller is connected to, as it can't switch between them.
+func (c *Controller) checkVHost(vhosts ...string) error {
+	current, err := c.currentVHost()
+	if err != nil {
+		return err
+	}
+
+	for _, vhost := range vhosts {
+		if vhost != "" && vhost != current {
+			return fmt.Errorf("channel bindings require vhost %q but controller is connected to %q", vhost, current)
+		}
+	}
+
+	return nil
+}
+
+// currentVHost returns the virtual host the controller is connected to.
+func (c *Controller) currentVHost() (string, error) {
+	switch {
+	case c.vhost != "":
+		return c.vhost, nil
+	case c.config != nil && c.config.Vhost != "":
+		return c.config.Vhost, nil
+	}
+
+	uri, err := amqp.ParseURI(c.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	return uri.Vhost, nil
+}
+
+func (c *Controller) declareExchange(ch *amqp.Channel, d destination) error {
+	// The default exchange cannot be declared
+	if d.exchange == "" {
+		return nil
+	}
+
+	return ch.ExchangeDeclare(
+		d.exchange,
+		d.exchangeOptions.Type,
+		d.exchangeOptions.Durable,
+		d.exchangeOptions.AutoDelete,
+		d.exchangeOptions.Internal,
+		d.exchangeOptions.NoWait,
+		d.exchangeOptions.Arguments,
+	)
+}
+
+func (c *Controller) declareQueue(ch *amqp.Channel, d destination) error {
+	_, err := ch.QueueDeclare(
+		d.queue,
+		d.queueOptions.Durable,
+		d.queueOptions.AutoDelete,
+		d.queueOptions.Exclusive,
+		d.queueOptions.NoWait,
+		c.queueArguments(d.queueOptions),
+	)
+	return err
+}
+
+func (c *Controller) bindQueue(ch *amqp.Channel, d destination) error {
+	// The queue is implicitly bound to the default exchange
+	if d.exchange == "" {
+		return nil
+	}
+
+	return ch.QueueBind(d.queue, d.routingKey, d.exchange, d.queueOptions.NoWait, d.bindingArguments())
+}
+
+// isHeadersExchange returns true if the messages are routed based on their
+// headers instead of the routing key.
+func (d destination) isHeadersExchange() bool {
+	return d.exchange != "" && d.exchangeOptions.Type == "headers"
+}
+
+// routesOnHeaders returns true if the messages are routed based on their
+// headers, by the destination or by the one they are delivered to after their
+// delay.
+func (d destination) routesOnHeaders() bool {
+	return d.isHeadersExchange() || d.delayedToHeadersExchange
+}
+
+// bindingArguments returns the arguments of the queue binding, which are the
+// headers that the messages should all have when bound to a headers exchange.
+func (d destination) bindingArguments() amqp.Table {
+	if !d.isHeadersExchange() || len(d.headersFilter) == 0 {
+		return nil
+	}
+
+	args := amqp.Table{"x-match": "all"}
+	for k, v := range d.headersFilter {
+		args[k] = v
+	}
+	return args
+}
@@ -2,17 +2,46 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"math"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 // Check interface implementation at compile time.
-var _ extensions.BrokerController = (*Controller)(nil)
+var (
+	_ extensions.BrokerController     = (*Controller)(nil)
+	_ extensions.ReplyAddressProvider = (*Controller)(nil)
+	_ extensions.PatternSubscriber    = (*Controller)(nil)
+	_ extensions.ReconnectNotifier    = (*Controller)(nil)
+)
+
+// replyQueuePrefix is the prefix of the queues named by the broker, which are
+// used as temporary reply queues.
+const replyQueuePrefix = "amq.gen-"
+
+var (
+	// ErrPublishNacked is returned when publisher confirms are enabled and the
+	// broker negatively acknowledged a published message.
+	ErrPublishNacked = fmt.Errorf("%w: message nacked by broker", extensions.ErrAsyncAPI)
+
+	// ErrPublishReturned is returned when publisher confirms are enabled and the
+	// broker returned a published message because it could not be routed.
+	ErrPublishReturned = fmt.Errorf("%w: message returned by broker", extensions.ErrAsyncAPI)
+
+	// ErrPublishConfirmTimeout is returned when publisher confirms are enabled and
+	// the broker did not confirm a published message in time.
+	ErrPublishConfirmTimeout = fmt.Errorf("%w: message confirmation timed out", extensions.ErrAsyncAPI)
+)
 
 // ExchangeDeclare represents RabbitMQ exchange configuration.
 type ExchangeDeclare struct {
@@ -27,23 +56,63 @@ type ExchangeDeclare struct {
 
 // QueueDeclare represents RabbitMQ queue configuration.
 type QueueDeclare struct {
-	Durable    bool       // Survives broker restart
-	Exclusive  bool       // Restricted to this connection
-	AutoDelete bool       // Deleted when last consumer unsubscribes
-	NoWait     bool       // If true, doesn't wait for server confirmation
-	Arguments  amqp.Table // Additional queue arguments
+	Type        string     // Queue type (classic, quorum), broker default if empty
+	Durable     bool       // Survives broker restart
+	Exclusive   bool       // Restricted to this connection
+	AutoDelete  bool       // Deleted when last consumer unsubscribes
+	NoWait      bool       // If true, doesn't wait for server confirmation
+	MaxPriority uint8      // Highest message priority supported, no priority if zero
+	Arguments   amqp.Table // Additional queue arguments
 }
 
+// DeadLetter represents the RabbitMQ dead-letter configuration of the queues.
+type DeadLetter struct {
+	Exchange   string // Exchange to which rejected messages are republished
+	RoutingKey string // Routing key of rejected messages, also used as dead-letter queue name
+}
+
+// NakPolicy defines what happens to a message when it is negatively acknowledged.
+type NakPolicy int
+
+const (
+	// NakPolicyRequeue puts back the message in its queue to be redelivered.
+	NakPolicyRequeue NakPolicy = iota
+	// NakPolicyDeadLetter rejects the message, which will be routed to the
+	// dead-letter exchange of the queue if any, or discarded.
+	NakPolicyDeadLetter
+)
+
 // Controller manages RabbitMQ connections and operations.
 type Controller struct {
 	url             string
+	config          *amqp.Config
+	tlsConfig       *tls.Config
+	vhost           string // URL virtual host if empty
+	credentials     amqp.Authentication
+	heartbeat       time.Duration
 	connection      *amqp.Connection
 	logger          extensions.Logger
 	queueGroup      string
+	exchange        string
 	exchangeOptions ExchangeDeclare
 	queueOptions    QueueDeclare
-	mu              sync.Mutex // Protects connection state
-	closed          bool
+	deadLetter      *DeadLetter
+	nakPolicy       *NakPolicy
+	prefetchCount   int           // No limit if zero
+	confirmTimeout  time.Duration // Only mandatory publications are confirmed if zero
+	mandatory       bool
+	channelPoolSize int
+	channels        *channelPool
+	delayedExchange string // Delay queues are used if empty
+
+	reconnectInitialBackoff time.Duration
+	reconnectMaxBackoff     time.Duration
+	reconnects              extensions.ReconnectListeners
+
+	mu            sync.Mutex // Protects connection state
+	subscriptions map[*subscription]struct{}
+	closed        bool
+	done          chan struct{}
 }
 
 // ControllerOption configures the Controller during creation.
@@ -52,7 +121,14 @@ type ControllerOption func(*Controller) error
 // Default configuration constants.
 const (
 	DefaultExchangeType = "direct"
+	DefaultContentType  = "application/octet-stream"
+	QueueTypeClassic    = "classic"
+	QueueTypeQuorum     = "quorum"
 	DefaultQueueGroup   = brokers.DefaultQueueGroupID
+
+	// DefaultConfirmTimeout is the publisher confirms timeout used for mandatory
+	// publications, when not set with WithPublisherConfirms.
+	DefaultConfirmTimeout = 5 * time.Second
 )
 
 // NewController creates and initializes a new RabbitMQ controller.
@@ -68,6 +144,11 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 		queueOptions: QueueDeclare{
 			Arguments: make(amqp.Table),
 		},
+		channelPoolSize:         DefaultChannelPoolSize,
+		reconnectInitialBackoff: DefaultReconnectInitialBackoff,
+		reconnectMaxBackoff:     DefaultReconnectMaxBackoff,
+		subscriptions:           make(map[*subscription]struct{}),
+		done:                    make(chan struct{}),
 	}
 
 	for _, opt := range options {
@@ -75,24 +156,26 @@ func NewController(url string, options ...ControllerOption) (*Controller, error)
 			return nil, fmt.Errorf("failed to apply option: %w", err)
 		}
 	}
+	c.channels = newChannelPool(c.channelPoolSize)
 
-	if c.connection == nil {
-		if err := c.connect(); err != nil {
-			return nil, fmt.Errorf("failed to establish initial connection: %w", err)
-		}
+	if err := c.validateTLS(); err != nil {
+		return nil, err
 	}
 
-	return c, nil
-}
+	// Dead-letter naked messages by default if there is a dead-letter exchange
+	if c.nakPolicy == nil {
+		policy := NakPolicyRequeue
+		if c.deadLetter != nil {
+			policy = NakPolicyDeadLetter
+		}
+		c.nakPolicy = &policy
+	}
 
-// connect establishes a connection to RabbitMQ.
-func (c *Controller) connect() error {
-	conn, err := amqp.Dial(c.url)
-	if err != nil {
-		return err
+	if err := c.connect(); err != nil {
+		return nil, fmt.Errorf("failed to establish initial connection: %w", err)
 	}
-	c.connection = conn
-	return nil
+
+	return c, nil
 }
 
 // WithLogger sets the logger for the controller.
@@ -106,11 +189,113 @@ func WithLogger(logger extensions.Logger) ControllerOption {
 // WithConnectionOpts sets the connection options for the controller.
 func WithConnectionOpts(config amqp.Config) ControllerOption {
 	return func(c *Controller) error {
-		conn, err := amqp.DialConfig(c.url, config)
+		c.config = &config
+		return nil
+	}
+}
+
+// WithTLS sets the TLS configuration used to connect to RabbitMQ. The URL of
+// the controller must use the amqps:// scheme.
+func WithTLS(config *tls.Config) ControllerOption {
+	return func(c *Controller) error {
+		if config == nil {
+			return fmt.Errorf("TLS configuration cannot be nil")
+		}
+		c.tlsConfig = config
+		return nil
+	}
+}
+
+// WithMutualTLS sets a TLS configuration authenticating the client with the
+// given certificate and key, and verifying the server with the given CA
+// certificate. The URL of the controller must use the amqps:// scheme.
+func WithMutualTLS(certFile, keyFile, caFile string) ControllerOption {
+	return func(c *Controller) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		ca, err := os.ReadFile(caFile)
 		if err != nil {
-			return fmt.Errorf("failed to connect with config: %w", err)
+			return fmt.Errorf("failed to read CA certificate: %w", err)
 		}
-		c.connection = conn
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no valid certificate found in %q", caFile)
+		}
+
+		c.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      pool,
+			MinVersion:   tls.VersionTLS12,
+		}
+		return nil
+	}
+}
+
+// validateTLS checks that the URL is compatible with the TLS configuration.
+func (c *Controller) validateTLS() error {
+	if c.tlsConfig == nil {
+		return nil
+	}
+
+	uri, err := amqp.ParseURI(c.url)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if uri.Scheme != "amqps" {
+		return fmt.Errorf("TLS requires an amqps:// URL, got %q scheme", uri.Scheme)
+	}
+
+	return nil
+}
+
+// WithVHost sets the virtual host to connect to, instead of the one of the URL.
+func WithVHost(vhost string) ControllerOption {
+	return func(c *Controller) error {
+		if vhost == "" {
+			return fmt.Errorf("virtual host cannot be empty")
+		}
+		c.vhost = vhost
+		return nil
+	}
+}
+
+// WithCredentials sets the credentials used to authenticate to RabbitMQ,
+// instead of the ones of the URL.
+func WithCredentials(username, password string) ControllerOption {
+	return func(c *Controller) error {
+		if username == "" {
+			return fmt.Errorf("username cannot be empty")
+		}
+		c.credentials = &amqp.PlainAuth{Username: username, Password: password}
+		return nil
+	}
+}
+
+// WithHeartbeat sets the interval of the heartbeats exchanged with RabbitMQ to
+// detect dead connections.
+func WithHeartbeat(interval time.Duration) ControllerOption {
+	return func(c *Controller) error {
+		if interval <= 0 {
+			return fmt.Errorf("heartbeat interval must be positive")
+		}
+		c.heartbeat = interval
+		return nil
+	}
+}
+
+// WithReconnectBackoff sets the delay before the first reconnection attempt
+// when the connection is lost. The delay is doubled after each failed attempt,
+// up to the maximum.
+func WithReconnectBackoff(initial, maximum time.Duration) ControllerOption {
+	return func(c *Controller) error {
+		if initial <= 0 || maximum < initial {
+			return fmt.Errorf("invalid reconnect backoff: initial %s, maximum %s", initial, maximum)
+		}
+		c.reconnectInitialBackoff = initial
+		c.reconnectMaxBackoff = maximum
 		return nil
 	}
 }
@@ -127,13 +312,57 @@ func WithQueueGroup(queueGroup string) ControllerOption {
 }
 
 // WithQueueOptions sets the queue options for the controller.
+//
+// Deprecated: use WithQueueDeclareOptions instead.
 func WithQueueOptions(options QueueDeclare) ControllerOption {
+	return WithQueueDeclareOptions(options)
+}
+
+// WithQueueDeclareOptions sets the options used to declare the queues of the
+// controller. Quorum queues have to be durable, non-exclusive and cannot be
+// auto-deleted.
+func WithQueueDeclareOptions(options QueueDeclare) ControllerOption {
 	return func(c *Controller) error {
+		if err := validateQueueDeclare(options); err != nil {
+			return err
+		}
 		c.queueOptions = options
 		return nil
 	}
 }
 
+// validateQueueDeclare validates queue configuration.
+func validateQueueDeclare(options QueueDeclare) error {
+	switch options.Type {
+	case "", QueueTypeClassic:
+		return nil
+	case QueueTypeQuorum:
+		if !options.Durable || options.Exclusive || options.AutoDelete {
+			return fmt.Errorf("quorum queues must be durable, non-exclusive and non auto-delete")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid queue type: %s", options.Type)
+	}
+}
+
+// WithExchange sets the exchange on which messages will be published, and to
+// which subscription queues will be bound, with the channel address as routing
+// key. Supported kinds are direct, topic, fanout and headers.
+func WithExchange(name, kind string) ControllerOption {
+	return func(c *Controller) error {
+		if name == "" {
+			return fmt.Errorf("exchange name cannot be empty")
+		}
+		if !isValidExchangeType(kind) {
+			return fmt.Errorf("invalid exchange type: %s", kind)
+		}
+		c.exchange = name
+		c.exchangeOptions.Type = kind
+		return nil
+	}
+}
+
 // WithExchangeOptions sets the exchange options for the controller.
 func WithExchangeOptions(options ExchangeDeclare) ControllerOption {
 	return func(c *Controller) error {
@@ -145,6 +374,91 @@ func WithExchangeOptions(options ExchangeDeclare) ControllerOption {
 	}
 }
 
+// WithDeadLetter declares the queues with a dead-letter exchange, to which the
+// rejected messages will be republished with the given routing key. The
+// exchange is declared along a dead-letter queue named after the routing key.
+// Unless specified otherwise with WithNakPolicy, naked messages are dead-lettered.
+func WithDeadLetter(exchange, routingKey string) ControllerOption {
+	return func(c *Controller) error {
+		if exchange == "" {
+			return fmt.Errorf("dead-letter exchange cannot be empty")
+		}
+		if routingKey == "" {
+			return fmt.Errorf("dead-letter routing key cannot be empty")
+		}
+		c.deadLetter = &DeadLetter{
+			Exchange:   exchange,
+			RoutingKey: routingKey,
+		}
+		return nil
+	}
+}
+
+// WithNakPolicy sets what happens to messages that are negatively acknowledged.
+func WithNakPolicy(policy NakPolicy) ControllerOption {
+	return func(c *Controller) error {
+		if policy != NakPolicyRequeue && policy != NakPolicyDeadLetter {
+			return fmt.Errorf("invalid nak policy: %d", policy)
+		}
+		c.nakPolicy = &policy
+		return nil
+	}
+}
+
+// WithPrefetchCount sets the maximum number of unacknowledged messages that
+// can be delivered to each subscription. Zero means no limit.
+func WithPrefetchCount(count int) ControllerOption {
+	return func(c *Controller) error {
+		if count < 0 {
+			return fmt.Errorf("prefetch count cannot be negative")
+		}
+		c.prefetchCount = count
+		return nil
+	}
+}
+
+// WithPublisherConfirms puts publishing channels in confirm mode: each Publish
+// will block until the broker acknowledges the message, or until the timeout
+// expires. Unroutable messages are returned by the broker and reported as an
+// error.
+func WithPublisherConfirms(timeout time.Duration) ControllerOption {
+	return func(c *Controller) error {
+		if timeout <= 0 {
+			return fmt.Errorf("publisher confirms timeout must be positive")
+		}
+		c.confirmTimeout = timeout
+		return nil
+	}
+}
+
+// WithMandatory publishes all messages as mandatory: the messages that can't be
+// routed to any queue are returned by the broker and Publish reports them as
+// ErrPublishReturned. Messages can also be published as mandatory on a given
+// operation with the AMQP operation bindings.
+//
+// Mandatory publications wait for the broker confirmation, in order to know
+// that the message has not been returned, with the timeout of
+// WithPublisherConfirms or DefaultConfirmTimeout.
+func WithMandatory() ControllerOption {
+	return func(c *Controller) error {
+		c.mandatory = true
+		return nil
+	}
+}
+
+// WithChannelPoolSize sets the maximum number of idle channels kept to be
+// reused across publications. A size of zero disables the pooling, so each
+// publication will open and close its own channel.
+func WithChannelPoolSize(size int) ControllerOption {
+	return func(c *Controller) error {
+		if size < 0 {
+			return fmt.Errorf("channel pool size cannot be negative")
+		}
+		c.channelPoolSize = size
+		return nil
+	}
+}
+
 // isValidExchangeType validates exchange type.
 func isValidExchangeType(exchangeType string) bool {
 	switch exchangeType {
@@ -157,77 +471,312 @@ func isValidExchangeType(exchangeType string) bool {
 
 // Publish sends a message to the specified queue.
 func (c *Controller) Publish(ctx context.Context, queueName string, bm extensions.BrokerMessage) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.PublishBatch(ctx, queueName, []extensions.BrokerMessage{bm})
+}
 
+// PublishBatch sends several messages to the specified queue on a single
+// channel. With publisher confirms, all messages are published before waiting
+// for their confirmation.
+func (c *Controller) PublishBatch(ctx context.Context, queueName string, bms []extensions.BrokerMessage) error {
+	c.mu.Lock()
 	if c.closed {
+		c.mu.Unlock()
 		return fmt.Errorf("controller is closed")
 	}
+	conn := c.connection
+	c.mu.Unlock()
 
-	ch, err := c.connection.Channel()
+	pc, err := c.channels.get(conn, c.publishConfirmTimeout(ctx) > 0)
 	if err != nil {
-		return fmt.Errorf("failed to open channel: %w", err)
+		return err
+	}
+
+	if err := c.publish(ctx, pc, queueName, bms); err != nil {
+		// The channel state is unknown after a failure, so it is not reused
+		_ = pc.Close()
+		return err
 	}
-	defer ch.Close()
 
-	if err := c.declareExchange(ch); err != nil {
+	c.channels.put(pc)
+	return nil
+}
+
+func (c *Controller) publish(
+	ctx context.Context,
+	pc *pooledChannel,
+	channel string,
+	bms []extensions.BrokerMessage,
+) error {
+	d, err := c.destination(ctx, channel)
+	if err != nil {
 		return err
 	}
 
-	if err := c.declareQueue(ch, queueName); err != nil {
+	if err := c.declareExchange(pc.Channel, d); err != nil {
 		return err
 	}
 
-	return c.publishMessage(ch, queueName, bm)
+	if d.declareQueueOnPublish {
+		if err := c.declareQueue(pc.Channel, d); err != nil {
+			return err
+		}
+	}
+
+	if delay := messageDelay(ctx); delay.Milliseconds() > 0 {
+		if d, err = c.delayDestination(pc.Channel, d, delay); err != nil {
+			return err
+		}
+	}
+
+	msgs := make([]amqp.Publishing, 0, len(bms))
+	for _, bm := range bms {
+		msgs = append(msgs, newPublishing(ctx, d, bm))
+	}
+
+	// Channels are only in confirm mode when confirmation is expected
+	if pc.returns == nil {
+		for _, msg := range msgs {
+			if err := pc.PublishWithContext(ctx, d.exchange, d.routingKey, false, false, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return c.publishWithConfirm(ctx, pc, d, msgs)
 }
 
-func (c *Controller) declareExchange(ch *amqp.Channel) error {
-	return ch.ExchangeDeclare(
-		c.queueGroup,
-		c.exchangeOptions.Type,
-		c.exchangeOptions.Durable,
-		c.exchangeOptions.AutoDelete,
-		c.exchangeOptions.Internal,
-		c.exchangeOptions.NoWait,
-		c.exchangeOptions.Arguments,
-	)
+// publishConfirmTimeout returns how long to wait for the broker confirmation of
+// a publication, or zero if it should not be waited for. Mandatory publications
+// are always confirmed, as it is the only way to know if the message has been
+// returned.
+func (c *Controller) publishConfirmTimeout(ctx context.Context) time.Duration {
+	if c.confirmTimeout > 0 {
+		return c.confirmTimeout
+	}
+
+	mandatory := c.mandatory
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		mandatory = mandatory || (b.AMQP != nil && b.AMQP.Mandatory)
+	})
+	if mandatory {
+		return DefaultConfirmTimeout
+	}
+
+	return 0
+}
+
+// exchangeName returns the name of the exchange used to publish messages.
+// For backward compatibility, the queue group is used when no exchange has
+// been explicitly set.
+func (c *Controller) exchangeName() string {
+	if c.exchange != "" {
+		return c.exchange
+	}
+	return c.queueGroup
 }
 
-func (c *Controller) declareQueue(ch *amqp.Channel, queueName string) error {
-	_, err := ch.QueueDeclare(
-		queueName,
-		c.queueOptions.Durable,
-		c.queueOptions.AutoDelete,
-		c.queueOptions.Exclusive,
-		c.queueOptions.NoWait,
-		c.queueOptions.Arguments,
-	)
-	return err
+// queueArguments returns the queue arguments, including the queue type, the
+// maximum priority and the dead-letter configuration if set.
+func (c *Controller) queueArguments(options QueueDeclare) amqp.Table {
+	if options.Type == "" && options.MaxPriority == 0 && c.deadLetter == nil {
+		return options.Arguments
+	}
+
+	args := make(amqp.Table, len(options.Arguments)+4)
+	for k, v := range options.Arguments {
+		args[k] = v
+	}
+	if options.Type != "" {
+		args["x-queue-type"] = options.Type
+	}
+	if options.MaxPriority > 0 {
+		args["x-max-priority"] = options.MaxPriority
+	}
+	if c.deadLetter != nil {
+		args["x-dead-letter-exchange"] = c.deadLetter.Exchange
+		args["x-dead-letter-routing-key"] = c.deadLetter.RoutingKey
+	}
+	return args
 }
 
-func (c *Controller) publishMessage(ch *amqp.Channel, queueName string, bm extensions.BrokerMessage) error {
+// declareDeadLetter declares the dead-letter exchange and queue, if set.
+func (c *Controller) declareDeadLetter(ch *amqp.Channel) error {
+	if c.deadLetter == nil {
+		return nil
+	}
+
+	if err := ch.ExchangeDeclare(c.deadLetter.Exchange, "direct", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+
+	if _, err := ch.QueueDeclare(c.deadLetter.RoutingKey, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
+	err := ch.QueueBind(c.deadLetter.RoutingKey, c.deadLetter.RoutingKey, c.deadLetter.Exchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+
+	return nil
+}
+
+// newPublishing converts the broker message to an AMQP message.
+func newPublishing(ctx context.Context, d destination, bm extensions.BrokerMessage) amqp.Publishing {
 	headers := amqp.Table{}
 	for k, v := range bm.Headers {
+		if d.routesOnHeaders() {
+			// Headers exchanges match on strings, as in the binding arguments
+			headers[k] = string(v)
+			continue
+		}
+		headers[k] = v
+	}
+	for k, v := range d.headers {
 		headers[k] = v
 	}
 
-	return ch.Publish(
-		c.queueGroup,
-		queueName,
-		false,
-		false,
-		amqp.Publishing{
-			Body:            bm.Payload,
-			Headers:         headers,
-			ContentType:     "application/octet-stream",
-			ContentEncoding: "binary",
-			Timestamp:       time.Now(),
-		},
-	)
+	msg := amqp.Publishing{
+		Body:            bm.Payload,
+		Headers:         headers,
+		ContentType:     bm.ContentType,
+		ContentEncoding: "binary",
+		Timestamp:       time.Now(),
+		Priority:        messagePriority(ctx),
+	}
+	if msg.ContentType == "" {
+		msg.ContentType = DefaultContentType
+	}
+
+	return msg
+}
+
+func (c *Controller) publishWithConfirm(
+	ctx context.Context,
+	pc *pooledChannel,
+	d destination,
+	msgs []amqp.Publishing,
+) error {
+	// Publish as mandatory in order to get the messages back if unroutable
+	mandatory := !d.notMandatory
+	confirms := make([]*amqp.DeferredConfirmation, 0, len(msgs))
+	for _, msg := range msgs {
+		dc, err := pc.PublishWithDeferredConfirmWithContext(ctx, d.exchange, d.routingKey, mandatory, false, msg)
+		if err != nil {
+			return err
+		}
+		confirms = append(confirms, dc)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.publishConfirmTimeout(ctx))
+	defer cancel()
+
+	// The broker sends the returns before the acknowledgements, so they have
+	// to be read while waiting in order to not block the channel
+	var returned []amqp.Return
+	for _, dc := range confirms {
+		for waiting := true; waiting; {
+			select {
+			case r := <-pc.returns:
+				returned = append(returned, r)
+			case <-dc.Done():
+				waiting = false
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return ErrPublishConfirmTimeout
+				}
+				return ctx.Err()
+			}
+		}
+
+		if !dc.Acked() {
+			return ErrPublishNacked
+		}
+	}
+
+	// Get the return of the last message, if any
+	select {
+	case r := <-pc.returns:
+		returned = append(returned, r)
+	default:
+	}
+
+	if len(returned) > 0 {
+		r := returned[0]
+		return fmt.Errorf("%w: %d/%d messages: %d %s", ErrPublishReturned, len(returned), len(msgs), r.ReplyCode, r.ReplyText)
+	}
+	return nil
+}
+
+// messagePriority returns the priority of the message to publish, either set
+// explicitly in the context or coming from the operation bindings.
+func messagePriority(ctx context.Context) uint8 {
+	var priority uint8
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		if b.AMQP != nil && b.AMQP.Priority > 0 {
+			priority = uint8(min(b.AMQP.Priority, math.MaxUint8))
+		}
+	})
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsPriority, func(p uint8) {
+		priority = p
+	})
+	return priority
+}
+
+// NewReplyAddress declares a new exclusive queue named by the broker, where the
+// reply of a request can be received. The queue is deleted once its consumer
+// is canceled, or when the connection is closed.
+func (c *Controller) NewReplyAddress(_ context.Context) (string, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return "", fmt.Errorf("controller is closed")
+	}
+	conn := c.connection
+	c.mu.Unlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return "", fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to declare reply queue: %w", err)
+	}
+
+	return q.Name, nil
 }
 
 // Subscribe creates a subscription to the specified queue.
 func (c *Controller) Subscribe(ctx context.Context, queueName string) (extensions.BrokerChannelSubscription, error) {
+	return c.subscribe(ctx, queueName, false)
+}
+
+// SubscribeToPattern subscribes to the messages of all the addresses of the
+// channel, with a queue bound to the topic exchange with the '*' wildcard in
+// place of the parameters.
+//
+// NOTE: the exchange should be a topic exchange, set with WithExchange or with
+// the channel bindings.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	bindingKey, err := extensions.ChannelAddressPattern(channelAddr, ".", "*")
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.subscribe(ctx, bindingKey, true)
+}
+
+func (c *Controller) subscribe(
+	ctx context.Context,
+	queueName string,
+	pattern bool,
+) (extensions.BrokerChannelSubscription, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -235,64 +784,215 @@ func (c *Controller) Subscribe(ctx context.Context, queueName string) (extension
 		return extensions.BrokerChannelSubscription{}, fmt.Errorf("controller is closed")
 	}
 
-	ch, err := c.connection.Channel()
+	// The destination is kept to resume the subscription after a reconnection,
+	// as it depends on the bindings of the context
+	d, err := c.destination(ctx, queueName)
 	if err != nil {
-		return extensions.BrokerChannelSubscription{}, fmt.Errorf("failed to open channel: %w", err)
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	if pattern && (d.exchange == "" || d.exchangeOptions.Type != "topic") {
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("%w: %q should be bound to a topic exchange",
+			extensions.ErrPatternSubscriptionNotSupported, queueName)
 	}
 
-	if err := c.declareQueue(ch, queueName); err != nil {
-		ch.Close()
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	s := &subscription{
+		channel:     queueName,
+		destination: d,
+		consumerTag: newConsumerTag(queueName),
+		pattern:     pattern,
+		messages:    messages,
+		sub:         extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		stop:        make(chan struct{}),
+	}
+
+	if err := c.consume(c.connection, s); err != nil {
 		return extensions.BrokerChannelSubscription{}, err
 	}
 
-	return c.setupConsumer(ctx, ch, queueName)
+	// Keep track of the subscription to resume it after a reconnection
+	c.subscriptions[s] = struct{}{}
+
+	// Stop consuming the messages once the context is done
+	stopWithContext := context.AfterFunc(ctx, s.cancel)
+
+	// Wait for cancellation and stop the consumer
+	s.sub.WaitForCancellationAsync(func() {
+		c.mu.Lock()
+		delete(c.subscriptions, s)
+		c.mu.Unlock()
+
+		stopWithContext()
+		s.cancel()
+		s.handlers.Wait()
+	})
+
+	return s.sub, nil
 }
 
-func (c *Controller) setupConsumer(
-	ctx context.Context,
-	ch *amqp.Channel,
-	queueName string,
-) (extensions.BrokerChannelSubscription, error) {
-	sub := extensions.NewBrokerChannelSubscription(
-		make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize),
-		make(chan any, 1),
-	)
+// consume declares the subscription queue on a new channel, then starts to
+// consume its messages.
+func (c *Controller) consume(conn *amqp.Connection, s *subscription) error {
+	d := s.destination
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	if err := c.declareDeadLetter(ch); err != nil {
+		ch.Close()
+		return err
+	}
+
+	if !d.replyQueue {
+		if err := c.declareQueue(ch, d); err != nil {
+			ch.Close()
+			return err
+		}
+	}
 
-	msgs, err := ch.Consume(queueName, "", false, false, false, false, nil)
+	if err := c.declareExchange(ch, d); err != nil {
+		ch.Close()
+		return err
+	}
+
+	if err := c.bindQueue(ch, d); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	if err := ch.Qos(c.prefetchCount, 0, false); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to set prefetch count: %w", err)
+	}
+
+	msgs, err := ch.Consume(d.queue, s.consumerTag, false, false, false, false, nil)
 	if err != nil {
 		ch.Close()
-		return extensions.BrokerChannelSubscription{}, fmt.Errorf("failed to start consumer: %w", err)
+		return fmt.Errorf("failed to start consumer: %w", err)
 	}
 
-	go c.handleMessages(ctx, ch, sub, msgs)
-	return sub, nil
+	s.handlers.Add(1)
+	go c.handleMessages(ch, s, msgs)
+	return nil
 }
 
-func (c *Controller) handleMessages(
-	ctx context.Context,
-	ch *amqp.Channel,
-	sub extensions.BrokerChannelSubscription,
-	msgs <-chan amqp.Delivery) {
+func (c *Controller) handleMessages(ch *amqp.Channel, s *subscription, msgs <-chan amqp.Delivery) {
+	defer s.handlers.Done()
 	defer ch.Close()
+
 	for {
 		select {
-		case <-ctx.Done():
+		case <-s.stop:
+			c.cancelConsumer(ch, s, msgs)
 			return
 		case d, ok := <-msgs:
 			if !ok {
+				// Channel or connection has been closed
+				return
+			}
+			bm := extensions.BrokerMessage{
+				ContentType: d.ContentType,
+				Headers:     convertHeaders(d.Headers),
+				Payload:     d.Body,
+			}
+			if s.pattern {
+				bm.Address = d.RoutingKey
+			}
+
+			// Give back the delivery if the subscription is stopped while
+			// waiting for the user to read the messages
+			select {
+			case s.messages <- extensions.NewAcknowledgeableBrokerMessage(
+				bm,
+				&AcknowledgementHandler{Delivery: &d, NakPolicy: *c.nakPolicy},
+			).WithMetadata(deliveryMetadata(d)):
+			case <-s.stop:
+				_ = d.Reject(true)
+				c.cancelConsumer(ch, s, msgs)
 				return
 			}
-			sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
-				extensions.BrokerMessage{
-					Headers: convertHeaders(d.Headers),
-					Payload: d.Body,
-				},
-				&AcknowledgementHandler{Delivery: &d},
-			))
 		}
 	}
 }
 
+// deliveryMetadata returns the delivery metadata of the RabbitMQ delivery. The
+// deliveries are only counted by the quorum queues, and the offset is only set
+// by the streams.
+func deliveryMetadata(d amqp.Delivery) extensions.MessageMetadata {
+	md := extensions.MessageMetadata{
+		Redelivered: d.Redelivered,
+		Timestamp:   d.Timestamp,
+		RoutingKey:  d.RoutingKey,
+		ID:          d.MessageId,
+	}
+	if count, ok := d.Headers["x-delivery-count"].(int64); ok {
+		// The header counts the previous deliveries
+		md.DeliveryCount = int(count) + 1
+		md.Redelivered = md.Redelivered || count > 0
+	}
+	if offset, ok := d.Headers["x-stream-offset"].(int64); ok {
+		md.Offset = offset
+	}
+	return md
+}
+
+// newConsumerTag returns a unique consumer tag for a subscription to the channel.
+func newConsumerTag(channel string) string {
+	return fmt.Sprintf("%s%s-%s", extensions.Prefix, channel, uuid.NewString())
+}
+
+// cancelConsumer stops the consumer on the broker, then gives back to the
+// queue the deliveries that have been received but not transmitted yet, so
+// they can be consumed by another subscription.
+func (c *Controller) cancelConsumer(ch *amqp.Channel, s *subscription, msgs <-chan amqp.Delivery) {
+	if err := ch.Cancel(s.consumerTag, false); err != nil {
+		// Channel is closed, its unacknowledged deliveries are already requeued
+		return
+	}
+
+	for d := range msgs {
+		_ = d.Reject(true)
+	}
+}
+
+// subscription is a consumer of a queue that is tracked by the controller
+// in order to be resumed after a reconnection.
+type subscription struct {
+	channel     string
+	destination destination
+	consumerTag string
+	messages    chan extensions.AcknowledgeableBrokerMessage
+	sub         extensions.BrokerChannelSubscription
+
+	// pattern is true if the channel is a binding key with wildcards, whose
+	// messages are transmitted with their routing key as address.
+	pattern bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	handlers sync.WaitGroup
+}
+
+// cancel stops the consumption of the subscription messages.
+func (s *subscription) cancel() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// stopped returns true once the consumption of the subscription messages is
+// stopped.
+func (s *subscription) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
 func convertHeaders(headers amqp.Table) map[string][]byte {
 	result := make(map[string][]byte)
 	for k, v := range headers {
@@ -308,26 +1008,65 @@ func convertHeaders(headers amqp.Table) map[string][]byte {
 	return result
 }
 
-// Close cleanly shuts down the controller.
-func (c *Controller) Close() {
+// NotifyReconnect registers a function called after each reconnection to
+// RabbitMQ, once the subscriptions are resumed, until the returned function is
+// called.
+func (c *Controller) NotifyReconnect(fn func()) (stop func()) {
+	return c.reconnects.NotifyReconnect(fn)
+}
+
+// Healthy returns an error if the controller is closed, or if the connection
+// to RabbitMQ is closed, for example while reconnecting.
+func (c *Controller) Healthy(_ context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	switch {
+	case c.closed:
+		return fmt.Errorf("controller is closed")
+	case c.connection == nil || c.connection.IsClosed():
+		return fmt.Errorf("rabbitmq connection is closed")
+	default:
+		return nil
+	}
+}
+
+// Close cleanly shuts down the controller. The consumers are canceled and
+// their pending deliveries requeued before closing the connection.
+func (c *Controller) Close() {
+	c.mu.Lock()
 	if c.closed {
+		c.mu.Unlock()
 		return
 	}
+	c.closed = true
+	close(c.done)
+	subscriptions := make([]*subscription, 0, len(c.subscriptions))
+	for s := range c.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	c.mu.Unlock()
 
+	// The connection can't be replaced anymore as the controller is closed
+	for _, s := range subscriptions {
+		s.cancel()
+	}
+	for _, s := range subscriptions {
+		s.handlers.Wait()
+	}
+
+	c.channels.close()
 	if c.connection != nil {
 		if err := c.connection.Close(); err != nil {
 			c.logger.Error(context.Background(), fmt.Sprintf("failed to close connection: %v", err))
 		}
 	}
-	c.closed = true
 }
 
 // AcknowledgementHandler implements message acknowledgment.
 type AcknowledgementHandler struct {
-	Delivery *amqp.Delivery
+	Delivery  *amqp.Delivery
+	NakPolicy NakPolicy
 }
 
 // AckMessage acknowledges the message.
@@ -337,9 +1076,9 @@ func (h *AcknowledgementHandler) AckMessage() {
 	}
 }
 
-// NakMessage requeues the message.
+// NakMessage requeues or dead-letters the message, depending on the policy.
 func (h *AcknowledgementHandler) NakMessage() {
 	if h.Delivery != nil {
-		_ = h.Delivery.Nack(false, true) // Requeue the message
+		_ = h.Delivery.Nack(false, h.NakPolicy == NakPolicyRequeue)
 	}
 }
@@ -0,0 +1,63 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// WithSecurity authenticates to RabbitMQ with the first of the security schemes
+// (userPassword, plain, X509 or oauth2) whose credentials are given. It fails
+// if there are security schemes but none of them can be used.
+//
+// With an X509 security scheme, the EXTERNAL mechanism is used (that requires
+// the rabbitmq_auth_mechanism_ssl plugin). With an oauth2 security scheme, a new access token is requested at each
+// connection and used as password, as expected by the OAuth 2.0 plugin of
+// RabbitMQ.
+func WithSecurity(schemes []extensions.SecurityScheme, credentials extensions.Credentials) ControllerOption {
+	return func(c *Controller) error {
+		scheme, err := extensions.SelectSecurityScheme(schemes, credentials,
+			extensions.SecuritySchemeUserPassword, extensions.SecuritySchemePlain,
+			extensions.SecuritySchemeX509, extensions.SecuritySchemeOAuth2)
+		if err != nil || scheme == nil {
+			return err
+		}
+
+		switch scheme.Type {
+		case extensions.SecuritySchemeUserPassword, extensions.SecuritySchemePlain:
+			return WithCredentials(credentials.UserPassword.Username, credentials.UserPassword.Password)(c)
+		case extensions.SecuritySchemeX509:
+			config, err := credentials.X509.TLSConfig()
+			if err != nil {
+				return err
+			}
+			c.tlsConfig = config
+			c.credentials = &amqp.ExternalAuth{}
+		case extensions.SecuritySchemeOAuth2:
+			c.credentials = oauth2Auth{controller: c, credentials: *credentials.OAuth2, scheme: *scheme}
+		}
+
+		return nil
+	}
+}
+
+// oauth2Auth is the PLAIN authentication with an OAuth2 access token as
+// password.
+type oauth2Auth struct {
+	controller  *Controller
+	credentials extensions.OAuth2ClientCredentials
+	scheme      extensions.SecurityScheme
+}
+
+func (a oauth2Auth) Mechanism() string {
+	return "PLAIN"
+}
+
+func (a oauth2Auth) Response() string {
+	token, err := a.credentials.Token(context.Background(), a.scheme)
+	if err != nil {
+		a.controller.logger.Error(context.Background(), err.Error())
+	}
+	return (&amqp.PlainAuth{Password: token}).Response()
+}
@@ -0,0 +1,405 @@
+package snssqs
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interface.
+var _ extensions.BrokerController = (*Controller)(nil)
+
+const (
+	// FIFOSuffix is the suffix of the names of the FIFO topics and queues.
+	FIFOSuffix = ".fifo"
+
+	// DefaultWaitTime is the default time a receive call waits for messages
+	// to arrive in the queue (long polling).
+	DefaultWaitTime = 20 * time.Second
+
+	// DefaultMaxMessages is the default maximum number of messages received
+	// from the queue at once.
+	DefaultMaxMessages = 10
+
+	// maxBatchSize is the maximum number of messages that can be published
+	// in one SNS batch.
+	maxBatchSize = 10
+
+	// maxMessageAttributes is the maximum number of message attributes of a
+	// SNS or SQS message.
+	maxMessageAttributes = 10
+
+	// maxVisibilityTimeout is the maximum visibility timeout of a SQS message.
+	maxVisibilityTimeout = 12 * time.Hour
+
+	// PayloadEncodingAttributeKey is the message attribute set on the messages
+	// whose payload is encoded in base64, as SNS only accepts UTF-8 messages.
+	PayloadEncodingAttributeKey = "payload-encoding"
+
+	// base64PayloadEncoding is the value of the payload encoding attribute of
+	// the messages whose payload is encoded in base64.
+	base64PayloadEncoding = "base64"
+)
+
+// snsAPI is the part of the SNS client used by the controller.
+type snsAPI interface {
+	CreateTopic(ctx context.Context, params *sns.CreateTopicInput, optFns ...func(*sns.Options)) (
+		*sns.CreateTopicOutput, error)
+	PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (
+		*sns.PublishBatchOutput, error)
+}
+
+// sqsAPI is the part of the SQS client used by the controller.
+type sqsAPI interface {
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (
+		*sqs.GetQueueUrlOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (
+		*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (
+		*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput,
+		optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// Controller is the Controller implementation for asyncapi-codegen, that
+// publishes messages on SNS topics and receives them from SQS queues.
+type Controller struct {
+	sns    snsAPI
+	sqs    sqsAPI
+	logger extensions.Logger
+
+	queueGroup     string
+	topicARNPrefix string
+	fifo           bool
+	messageGroupID func(channel string, bm extensions.BrokerMessage) string
+
+	waitTime          time.Duration
+	maxMessages       int
+	visibilityTimeout time.Duration
+
+	mu        sync.Mutex
+	topicARNs map[string]string
+	queueURLs map[string]string
+	polls     map[*poll]struct{}
+	closed    bool
+}
+
+// ControllerOption is a function that can be used to configure a SNS/SQS controller
+// Examples: WithQueueGroup(), WithLogger().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a new SNS/SQS controller from an AWS configuration.
+func NewController(cfg aws.Config, options ...ControllerOption) (*Controller, error) {
+	return newController(sns.NewFromConfig(cfg), sqs.NewFromConfig(cfg), options...)
+}
+
+func newController(snsClient snsAPI, sqsClient sqsAPI, options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		sns:         snsClient,
+		sqs:         sqsClient,
+		logger:      extensions.DummyLogger{},
+		queueGroup:  brokers.DefaultQueueGroupID,
+		waitTime:    DefaultWaitTime,
+		maxMessages: DefaultMaxMessages,
+		topicARNs:   make(map[string]string),
+		queueURLs:   make(map[string]string),
+		polls:       make(map[*poll]struct{}),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	return controller, nil
+}
+
+// WithQueueGroup set a custom queue group for channel subscription: the
+// messages are received from the '<channel>-<queue group>' queue instead of the
+// '<channel>' queue.
+func WithQueueGroup(name string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.queueGroup = name
+		return nil
+	}
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithTopicARNPrefix sets the prefix of the topics ARN (for example
+// 'arn:aws:sns:<region>:<account>:'), so they don't need to be resolved
+// with SNS. Without it, topics are created if they don't exist.
+func WithTopicARNPrefix(prefix string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.topicARNPrefix = prefix
+		return nil
+	}
+}
+
+// WithFIFO uses FIFO topics and queues, whose names end with '.fifo'.
+//
+// Messages are published with a random deduplication ID, and with the channel
+// as group ID unless WithMessageGroupID is used.
+func WithFIFO() ControllerOption {
+	return func(controller *Controller) error {
+		controller.fifo = true
+		return nil
+	}
+}
+
+// WithMessageGroupID sets the function that returns the group ID of the
+// messages published on FIFO topics. Messages of the same group are delivered
+// in order.
+func WithMessageGroupID(fn func(channel string, bm extensions.BrokerMessage) string) ControllerOption {
+	return func(controller *Controller) error {
+		controller.messageGroupID = fn
+		return nil
+	}
+}
+
+// WithWaitTime sets the time a receive call waits for messages to arrive in
+// the queue (long polling), up to 20 seconds. Zero means short polling.
+func WithWaitTime(d time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if d < 0 || d > DefaultWaitTime {
+			return fmt.Errorf("invalid wait time %s: should be between 0 and %s", d, DefaultWaitTime)
+		}
+		controller.waitTime = d
+		return nil
+	}
+}
+
+// WithMaxMessages sets the maximum number of messages received from the queue
+// at once, between 1 and 10.
+func WithMaxMessages(n int) ControllerOption {
+	return func(controller *Controller) error {
+		if n < 1 || n > DefaultMaxMessages {
+			return fmt.Errorf("invalid maximum number of messages %d: should be between 1 and %d",
+				n, DefaultMaxMessages)
+		}
+		controller.maxMessages = n
+		return nil
+	}
+}
+
+// WithVisibilityTimeout sets the time during which the received messages are
+// hidden from the other consumers of the queue, instead of the visibility
+// timeout of the queue.
+//
+// The visibility of the messages is extended until they are acknowledged, so
+// they are not delivered again while they are still processed.
+func WithVisibilityTimeout(d time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if d < time.Second || d > maxVisibilityTimeout {
+			return fmt.Errorf("invalid visibility timeout %s: should be between 1s and %s", d, maxVisibilityTimeout)
+		}
+		controller.visibilityTimeout = d
+		return nil
+	}
+}
+
+// resourceName returns the name of the topic or queue for the channel, as
+// their names can only contain alphanumeric characters, hyphens and underscores.
+func (c *Controller) resourceName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+
+	if c.fifo {
+		name += FIFOSuffix
+	}
+	return name
+}
+
+// topicARN returns the ARN of the topic of the channel, creating the topic if
+// there is no ARN prefix.
+func (c *Controller) topicARN(ctx context.Context, channel string) (string, error) {
+	name := c.resourceName(channel)
+	if c.topicARNPrefix != "" {
+		return c.topicARNPrefix + name, nil
+	}
+
+	c.mu.Lock()
+	arn, exists := c.topicARNs[name]
+	c.mu.Unlock()
+	if exists {
+		return arn, nil
+	}
+
+	// Create the topic, which returns the existing one if there is already one
+	input := &sns.CreateTopicInput{Name: aws.String(name)}
+	if c.fifo {
+		input.Attributes = map[string]string{"FifoTopic": "true"}
+	}
+	out, err := c.sns.CreateTopic(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to create topic %q: %w", name, err)
+	}
+
+	c.mu.Lock()
+	c.topicARNs[name] = aws.ToString(out.TopicArn)
+	c.mu.Unlock()
+
+	return aws.ToString(out.TopicArn), nil
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+// PublishBatch publishes several messages on the SNS topic of the channel, in
+// batches of 10 messages.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	arn, err := c.topicARN(ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(bms); start += maxBatchSize {
+		entries, err := c.batchEntries(channel, bms[start:min(start+maxBatchSize, len(bms))])
+		if err != nil {
+			return err
+		}
+
+		out, err := c.sns.PublishBatch(ctx, &sns.PublishBatchInput{
+			TopicArn:                   aws.String(arn),
+			PublishBatchRequestEntries: entries,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish messages: %w", err)
+		}
+
+		if len(out.Failed) > 0 {
+			f := out.Failed[0]
+			return fmt.Errorf("failed to publish %d/%d messages: %s %s",
+				len(out.Failed), len(entries), aws.ToString(f.Code), aws.ToString(f.Message))
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) batchEntries(
+	channel string,
+	bms []extensions.BrokerMessage,
+) ([]snstypes.PublishBatchRequestEntry, error) {
+	entries := make([]snstypes.PublishBatchRequestEntry, 0, len(bms))
+	for i, bm := range bms {
+		attributes, err := messageAttributes(bm)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := snstypes.PublishBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			Message:           aws.String(encodePayload(bm.Payload)),
+			MessageAttributes: attributes,
+		}
+
+		if c.fifo {
+			groupID := channel
+			if c.messageGroupID != nil {
+				groupID = c.messageGroupID(channel, bm)
+			}
+			entry.MessageGroupId = aws.String(groupID)
+			entry.MessageDeduplicationId = aws.String(uuid.NewString())
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// encodePayload returns the payload as a SNS message, encoded in base64 if it
+// is not valid UTF-8.
+func encodePayload(payload []byte) string {
+	if utf8.Valid(payload) {
+		return string(payload)
+	}
+	return base64.StdEncoding.EncodeToString(payload)
+}
+
+// messageAttributes returns the headers, content type and payload encoding of
+// the message as SNS message attributes.
+func messageAttributes(bm extensions.BrokerMessage) (map[string]snstypes.MessageAttributeValue, error) {
+	headers := bm.Headers
+	binary := !utf8.Valid(bm.Payload)
+	if bm.ContentType != "" || binary {
+		headers = make(map[string][]byte, len(bm.Headers)+2)
+		for k, v := range bm.Headers {
+			headers[k] = v
+		}
+		if bm.ContentType != "" {
+			headers[brokers.ContentTypeHeaderKey] = []byte(bm.ContentType)
+		}
+		if binary {
+			headers[PayloadEncodingAttributeKey] = []byte(base64PayloadEncoding)
+		}
+	}
+
+	if len(headers) > maxMessageAttributes {
+		return nil, fmt.Errorf("message has %d headers but SNS only supports %d message attributes",
+			len(headers), maxMessageAttributes)
+	}
+
+	attributes := make(map[string]snstypes.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		if utf8.Valid(v) {
+			attributes[k] = snstypes.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(string(v)),
+			}
+		} else {
+			attributes[k] = snstypes.MessageAttributeValue{
+				DataType:    aws.String("Binary"),
+				BinaryValue: v,
+			}
+		}
+	}
+
+	return attributes, nil
+}
+
+// Close closes everything related to the broker.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	c.closed = true
+	polls := make([]*poll, 0, len(c.polls))
+	for p := range c.polls {
+		polls = append(polls, p)
+	}
+	c.mu.Unlock()
+
+	for _, p := range polls {
+		p.stop()
+	}
+}
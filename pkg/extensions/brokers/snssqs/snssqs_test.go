@@ -0,0 +1,551 @@
+package snssqs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeSNS is a SNS client that records the topics created and the batches published.
+type fakeSNS struct {
+	mu      sync.Mutex
+	topics  []*sns.CreateTopicInput
+	batches []*sns.PublishBatchInput
+	failed  []snstypes.BatchResultErrorEntry
+}
+
+func (f *fakeSNS) CreateTopic(_ context.Context, params *sns.CreateTopicInput, _ ...func(*sns.Options)) (
+	*sns.CreateTopicOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.topics = append(f.topics, params)
+	return &sns.CreateTopicOutput{TopicArn: aws.String("arn:aws:sns:test:" + aws.ToString(params.Name))}, nil
+}
+
+func (f *fakeSNS) PublishBatch(_ context.Context, params *sns.PublishBatchInput, _ ...func(*sns.Options)) (
+	*sns.PublishBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.batches = append(f.batches, params)
+	return &sns.PublishBatchOutput{Failed: f.failed}, nil
+}
+
+// fakeSQS is a SQS client that delivers the messages of its channel and
+// records the operations on them.
+type fakeSQS struct {
+	messages chan sqstypes.Message
+
+	mu           sync.Mutex
+	receives     []*sqs.ReceiveMessageInput
+	deleted      []string
+	visibilities map[string][]int32
+}
+
+func newFakeSQS() *fakeSQS {
+	return &fakeSQS{
+		messages:     make(chan sqstypes.Message, 10),
+		visibilities: make(map[string][]int32),
+	}
+}
+
+func (f *fakeSQS) GetQueueUrl(_ context.Context, params *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (
+	*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.test/" + aws.ToString(params.QueueName))}, nil
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (
+	*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	f.receives = append(f.receives, params)
+	f.mu.Unlock()
+
+	select {
+	case msg := <-f.messages:
+		return &sqs.ReceiveMessageOutput{Messages: []sqstypes.Message{msg}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *fakeSQS) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (
+	*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(_ context.Context, params *sqs.ChangeMessageVisibilityInput,
+	_ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	handle := aws.ToString(params.ReceiptHandle)
+	f.visibilities[handle] = append(f.visibilities[handle], params.VisibilityTimeout)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) visibilityChanges(handle string) []int32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]int32(nil), f.visibilities[handle]...)
+}
+
+func (f *fakeSQS) deletedMessages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.deleted...)
+}
+
+// fakeAWS is a SNS and SQS client whose topics deliver their messages to the
+// queue of the same name, with raw message delivery.
+type fakeAWS struct {
+	mu       sync.Mutex
+	queues   map[string]chan sqstypes.Message
+	inFlight map[string]inFlightMessage
+	received int
+}
+
+// inFlightMessage is a received message that is not deleted yet.
+type inFlightMessage struct {
+	queue string
+	msg   sqstypes.Message
+}
+
+func newFakeAWS() *fakeAWS {
+	return &fakeAWS{
+		queues:   make(map[string]chan sqstypes.Message),
+		inFlight: make(map[string]inFlightMessage),
+	}
+}
+
+// queue returns the messages of the queue, that is created if it doesn't exist.
+func (f *fakeAWS) queue(name string) chan sqstypes.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	q, exists := f.queues[name]
+	if !exists {
+		q = make(chan sqstypes.Message, 100)
+		f.queues[name] = q
+	}
+	return q
+}
+
+func (f *fakeAWS) CreateTopic(_ context.Context, params *sns.CreateTopicInput, _ ...func(*sns.Options)) (
+	*sns.CreateTopicOutput, error) {
+	return &sns.CreateTopicOutput{TopicArn: aws.String("arn:aws:sns:test:" + aws.ToString(params.Name))}, nil
+}
+
+func (f *fakeAWS) PublishBatch(_ context.Context, params *sns.PublishBatchInput, _ ...func(*sns.Options)) (
+	*sns.PublishBatchOutput, error) {
+	q := f.queue(strings.TrimPrefix(aws.ToString(params.TopicArn), "arn:aws:sns:test:"))
+	for _, entry := range params.PublishBatchRequestEntries {
+		attributes := make(map[string]sqstypes.MessageAttributeValue, len(entry.MessageAttributes))
+		for k, v := range entry.MessageAttributes {
+			attributes[k] = sqstypes.MessageAttributeValue{
+				DataType:    v.DataType,
+				StringValue: v.StringValue,
+				BinaryValue: v.BinaryValue,
+			}
+		}
+		q <- sqstypes.Message{Body: entry.Message, MessageAttributes: attributes}
+	}
+	return &sns.PublishBatchOutput{}, nil
+}
+
+func (f *fakeAWS) GetQueueUrl(_ context.Context, params *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (
+	*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.test/" + aws.ToString(params.QueueName))}, nil
+}
+
+func (f *fakeAWS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (
+	*sqs.ReceiveMessageOutput, error) {
+	name := strings.TrimPrefix(aws.ToString(params.QueueUrl), "https://sqs.test/")
+
+	select {
+	case msg := <-f.queue(name):
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.received++
+		msg.ReceiptHandle = aws.String(fmt.Sprint(f.received))
+		f.inFlight[*msg.ReceiptHandle] = inFlightMessage{queue: name, msg: msg}
+		return &sqs.ReceiveMessageOutput{Messages: []sqstypes.Message{msg}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *fakeAWS) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (
+	*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.inFlight, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// ChangeMessageVisibility puts back the message in its queue if it is made
+// visible again right away.
+func (f *fakeAWS) ChangeMessageVisibility(_ context.Context, params *sqs.ChangeMessageVisibilityInput,
+	_ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	if params.VisibilityTimeout != 0 {
+		return &sqs.ChangeMessageVisibilityOutput{}, nil
+	}
+
+	f.mu.Lock()
+	m, exists := f.inFlight[aws.ToString(params.ReceiptHandle)]
+	delete(f.inFlight, aws.ToString(params.ReceiptHandle))
+	f.mu.Unlock()
+
+	if exists {
+		f.queue(m.queue) <- m.msg
+	}
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func TestOptionsValidation(t *testing.T) {
+	cases := []ControllerOption{
+		WithWaitTime(21 * time.Second),
+		WithMaxMessages(0),
+		WithMaxMessages(11),
+		WithVisibilityTimeout(time.Millisecond),
+		WithVisibilityTimeout(13 * time.Hour),
+	}
+
+	for i, option := range cases {
+		_, err := newController(&fakeSNS{}, newFakeSQS(), option)
+		assert.Error(t, err, "case %d", i)
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	c, err := newController(&fakeSNS{}, newFakeSQS())
+	require.NoError(t, err)
+	assert.Equal(t, "v3-issue_1--id-", c.resourceName("v3.issue_1.{id}"))
+
+	c, err = newController(&fakeSNS{}, newFakeSQS(), WithFIFO())
+	require.NoError(t, err)
+	assert.Equal(t, "orders-created.fifo", c.resourceName("orders.created"))
+}
+
+func TestPublishBatch(t *testing.T) {
+	fsns := &fakeSNS{}
+	c, err := newController(fsns, newFakeSQS())
+	require.NoError(t, err)
+
+	bms := make([]extensions.BrokerMessage, 12)
+	for i := range bms {
+		bms[i] = extensions.BrokerMessage{
+			Headers:     map[string][]byte{"id": []byte(fmt.Sprint(i)), "raw": {0xff}},
+			ContentType: "application/json",
+			Payload:     []byte(fmt.Sprintf(`{"n":%d}`, i)),
+		}
+	}
+
+	require.NoError(t, c.PublishBatch(context.Background(), "orders.created", bms))
+	require.NoError(t, c.Publish(context.Background(), "orders.created", bms[0]))
+
+	// The topic is only created once
+	require.Len(t, fsns.topics, 1)
+	assert.Equal(t, "orders-created", aws.ToString(fsns.topics[0].Name))
+	assert.Nil(t, fsns.topics[0].Attributes)
+
+	// Messages are published by batches of 10
+	require.Len(t, fsns.batches, 3)
+	assert.Len(t, fsns.batches[0].PublishBatchRequestEntries, 10)
+	assert.Len(t, fsns.batches[1].PublishBatchRequestEntries, 2)
+	assert.Len(t, fsns.batches[2].PublishBatchRequestEntries, 1)
+
+	b := fsns.batches[1]
+	assert.Equal(t, "arn:aws:sns:test:orders-created", aws.ToString(b.TopicArn))
+	e := b.PublishBatchRequestEntries[1]
+	assert.Equal(t, "1", aws.ToString(e.Id))
+	assert.Equal(t, `{"n":11}`, aws.ToString(e.Message))
+	assert.Equal(t, "11", aws.ToString(e.MessageAttributes["id"].StringValue))
+	assert.Equal(t, "Binary", aws.ToString(e.MessageAttributes["raw"].DataType))
+	assert.Equal(t, []byte{0xff}, e.MessageAttributes["raw"].BinaryValue)
+	assert.Equal(t, "application/json", aws.ToString(e.MessageAttributes["content-type"].StringValue))
+	assert.Nil(t, e.MessageGroupId)
+}
+
+func TestBinaryPayload(t *testing.T) {
+	fake := newFakeAWS()
+	c, err := newController(fake, fake)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	// The payloads that are not valid UTF-8 are encoded in base64
+	payload := []byte{0x00, 0xff, 0xfe}
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{Payload: payload}))
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{Payload: []byte("text")}))
+
+	msg := receive(t, sub)
+	assert.Equal(t, payload, msg.Payload)
+	assert.Empty(t, msg.Headers)
+	msg.Ack()
+
+	msg = receive(t, sub)
+	assert.Equal(t, "text", string(msg.Payload))
+	msg.Ack()
+}
+
+func TestPublishFIFO(t *testing.T) {
+	fsns := &fakeSNS{}
+	c, err := newController(fsns, newFakeSQS(), WithFIFO(), WithMessageGroupID(
+		func(_ string, bm extensions.BrokerMessage) string {
+			return string(bm.Headers["customer"])
+		}))
+	require.NoError(t, err)
+
+	err = c.PublishBatch(context.Background(), "orders", []extensions.BrokerMessage{
+		{Headers: map[string][]byte{"customer": []byte("alice")}},
+		{Headers: map[string][]byte{"customer": []byte("bob")}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, fsns.topics, 1)
+	assert.Equal(t, "orders.fifo", aws.ToString(fsns.topics[0].Name))
+	assert.Equal(t, map[string]string{"FifoTopic": "true"}, fsns.topics[0].Attributes)
+
+	entries := fsns.batches[0].PublishBatchRequestEntries
+	assert.Equal(t, "alice", aws.ToString(entries[0].MessageGroupId))
+	assert.Equal(t, "bob", aws.ToString(entries[1].MessageGroupId))
+	assert.NotEmpty(t, aws.ToString(entries[0].MessageDeduplicationId))
+	assert.NotEqual(t, aws.ToString(entries[0].MessageDeduplicationId), aws.ToString(entries[1].MessageDeduplicationId))
+}
+
+func TestPublishWithTopicARNPrefix(t *testing.T) {
+	fsns := &fakeSNS{}
+	c, err := newController(fsns, newFakeSQS(), WithTopicARNPrefix("arn:aws:sns:eu-west-1:123456789012:"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+	assert.Empty(t, fsns.topics)
+	assert.Equal(t, "arn:aws:sns:eu-west-1:123456789012:orders", aws.ToString(fsns.batches[0].TopicArn))
+}
+
+func TestPublishFailed(t *testing.T) {
+	fsns := &fakeSNS{failed: []snstypes.BatchResultErrorEntry{
+		{Id: aws.String("0"), Code: aws.String("InternalError"), Message: aws.String("boom")},
+	}}
+	c, err := newController(fsns, newFakeSQS())
+	require.NoError(t, err)
+
+	err = c.Publish(context.Background(), "orders", extensions.BrokerMessage{})
+	assert.ErrorContains(t, err, "InternalError boom")
+}
+
+func TestPublishTooManyHeaders(t *testing.T) {
+	c, err := newController(&fakeSNS{}, newFakeSQS())
+	require.NoError(t, err)
+
+	headers := make(map[string][]byte)
+	for i := 0; i < 10; i++ {
+		headers[fmt.Sprint(i)] = []byte("value")
+	}
+
+	err = c.Publish(context.Background(), "orders", extensions.BrokerMessage{Headers: headers, ContentType: "text/plain"})
+	assert.Error(t, err)
+}
+
+func receive(t *testing.T, sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	fsqs := newFakeSQS()
+	c, err := newController(&fakeSNS{}, fsqs, WithQueueGroup("billing"), WithWaitTime(5*time.Second))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders.created")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	// Raw message delivery
+	fsqs.messages <- sqstypes.Message{
+		ReceiptHandle: aws.String("raw"),
+		Body:          aws.String(`{"id":1}`),
+		MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+			"correlation-id": {DataType: aws.String("String"), StringValue: aws.String("1234")},
+			"content-type":   {DataType: aws.String("String"), StringValue: aws.String("application/json")},
+		},
+	}
+	msg := receive(t, sub)
+	assert.Equal(t, `{"id":1}`, string(msg.Payload))
+	assert.Equal(t, "application/json", msg.ContentType)
+	assert.Equal(t, map[string][]byte{"correlation-id": []byte("1234")}, msg.Headers)
+	msg.Ack()
+
+	// SNS envelope
+	fsqs.messages <- sqstypes.Message{
+		ReceiptHandle: aws.String("envelope"),
+		Body: aws.String(`{"Type":"Notification","TopicArn":"arn:aws:sns:test:orders-created",` +
+			`"Message":"{\"id\":2}","MessageAttributes":{"correlation-id":{"Type":"String","Value":"5678"},` +
+			`"raw":{"Type":"Binary","Value":"/w=="}}}`),
+	}
+	msg = receive(t, sub)
+	assert.Equal(t, `{"id":2}`, string(msg.Payload))
+	assert.Equal(t, map[string][]byte{"correlation-id": []byte("5678"), "raw": {0xff}}, msg.Headers)
+	msg.Nak()
+
+	assert.Equal(t, []string{"raw"}, fsqs.deletedMessages())
+	assert.Equal(t, []int32{0}, fsqs.visibilityChanges("envelope"))
+
+	fsqs.mu.Lock()
+	defer fsqs.mu.Unlock()
+	r := fsqs.receives[0]
+	assert.Equal(t, "https://sqs.test/orders-created-billing", aws.ToString(r.QueueUrl))
+	assert.Equal(t, int32(5), r.WaitTimeSeconds)
+	assert.Equal(t, int32(DefaultMaxMessages), r.MaxNumberOfMessages)
+	assert.Equal(t, []string{"All"}, r.MessageAttributeNames)
+}
+
+func TestSubscribeWithVisibilityTimeout(t *testing.T) {
+	fsqs := newFakeSQS()
+	c, err := newController(&fakeSNS{}, fsqs, WithVisibilityTimeout(time.Second))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	fsqs.messages <- sqstypes.Message{ReceiptHandle: aws.String("slow"), Body: aws.String("")}
+	msg := receive(t, sub)
+
+	// Visibility is extended while the message is processed
+	assert.Eventually(t, func() bool {
+		return len(fsqs.visibilityChanges("slow")) > 0
+	}, 2*time.Second, 50*time.Millisecond)
+	msg.Ack()
+
+	count := len(fsqs.visibilityChanges("slow"))
+	time.Sleep(700 * time.Millisecond)
+	assert.Len(t, fsqs.visibilityChanges("slow"), count, "visibility should not be extended once acknowledged")
+	assert.Equal(t, int32(1), fsqs.visibilityChanges("slow")[0])
+}
+
+func TestSubscribeMetadata(t *testing.T) {
+	fsqs := newFakeSQS()
+	c, err := newController(&fakeSNS{}, fsqs)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	fsqs.messages <- sqstypes.Message{
+		MessageId:     aws.String("1234"),
+		ReceiptHandle: aws.String("redelivered"),
+		Body:          aws.String(""),
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "3",
+			"SentTimestamp":           "1700000000000",
+		},
+	}
+	msg := receive(t, sub)
+	msg.Ack()
+
+	require.NotNil(t, msg.Metadata)
+	assert.Equal(t, extensions.MessageMetadata{
+		Redelivered:   true,
+		DeliveryCount: 3,
+		Timestamp:     time.UnixMilli(1700000000000),
+		ID:            "1234",
+	}, *msg.Metadata)
+
+	fsqs.mu.Lock()
+	defer fsqs.mu.Unlock()
+	assert.ElementsMatch(t, []sqstypes.MessageSystemAttributeName{
+		sqstypes.MessageSystemAttributeNameApproximateReceiveCount,
+		sqstypes.MessageSystemAttributeNameSentTimestamp,
+	}, fsqs.receives[0].MessageSystemAttributeNames)
+}
+
+func TestCancelFullSubscription(t *testing.T) {
+	fsqs := newFakeSQS()
+	c, err := newController(&fakeSNS{}, fsqs)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	// Fill the subscription, plus one message waiting for the user
+	go func() {
+		for i := 0; i <= brokers.BrokerMessagesQueueSize; i++ {
+			fsqs.messages <- sqstypes.Message{ReceiptHandle: aws.String(fmt.Sprint(i)), Body: aws.String("")}
+		}
+	}()
+	assert.Eventually(t, func() bool {
+		return len(sub.MessagesChannel()) == brokers.BrokerMessagesQueueSize && len(fsqs.messages) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// The cancellation makes the last message available again
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sub.Cancel(ctx)
+	require.NoError(t, ctx.Err())
+	assert.Equal(t, []int32{0}, fsqs.visibilityChanges(fmt.Sprint(brokers.BrokerMessagesQueueSize)))
+}
+
+func TestClose(t *testing.T) {
+	c, err := newController(&fakeSNS{}, newFakeSQS())
+	require.NoError(t, err)
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	c.Close()
+	sub.Cancel(context.Background())
+
+	_, err = c.Subscribe(context.Background(), "orders")
+	assert.Error(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			fake := newFakeAWS()
+			return newController(fake, fake)
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+		Redelivery: true,
+	})
+}
@@ -0,0 +1,337 @@
+package snssqs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// receiveRetryDelay is the time waited before receiving again messages from a
+// queue after a failure.
+const receiveRetryDelay = time.Second
+
+// queueURL returns the URL of the queue of the channel.
+func (c *Controller) queueURL(ctx context.Context, channel string) (string, error) {
+	name := channel
+	if c.queueGroup != "" {
+		name += "-" + c.queueGroup
+	}
+	name = c.resourceName(name)
+
+	c.mu.Lock()
+	url, exists := c.queueURLs[name]
+	c.mu.Unlock()
+	if exists {
+		return url, nil
+	}
+
+	out, err := c.sqs.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL of queue %q: %w", name, err)
+	}
+
+	c.mu.Lock()
+	c.queueURLs[name] = aws.ToString(out.QueueUrl)
+	c.mu.Unlock()
+
+	return aws.ToString(out.QueueUrl), nil
+}
+
+// poll receives the messages of a queue for a subscription.
+type poll struct {
+	queueURL string
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop stops receiving messages and waits for the last ones to be transmitted.
+func (p *poll) stop() {
+	p.cancel()
+	<-p.done
+}
+
+// Subscribe to messages from the SQS queue of the channel.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	url, err := c.queueURL(ctx, channel)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+
+	// Create a new subscription
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	p := &poll{
+		queueURL: url,
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		done:     make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		p.cancel()
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("controller is closed")
+	}
+	c.polls[p] = struct{}{}
+	c.mu.Unlock()
+
+	// Receive messages until the subscription is cancelled
+	go func() {
+		defer close(p.done)
+		c.receiveMessages(p)
+	}()
+
+	// Wait for cancellation and stop receiving messages
+	p.sub.WaitForCancellationAsync(func() {
+		p.stop()
+
+		c.mu.Lock()
+		delete(c.polls, p)
+		c.mu.Unlock()
+	})
+
+	return p.sub, nil
+}
+
+func (c *Controller) receiveMessages(p *poll) {
+	for {
+		out, err := c.sqs.ReceiveMessage(p.ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(p.queueURL),
+			MaxNumberOfMessages:   int32(c.maxMessages),
+			WaitTimeSeconds:       int32(c.waitTime / time.Second),
+			VisibilityTimeout:     int32(c.visibilityTimeout / time.Second),
+			MessageAttributeNames: []string{"All"},
+			MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{
+				sqstypes.MessageSystemAttributeNameApproximateReceiveCount,
+				sqstypes.MessageSystemAttributeNameSentTimestamp,
+			},
+		})
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			c.logger.Error(p.ctx, "failed to receive messages",
+				extensions.LogInfo{Key: "queue", Value: p.queueURL},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(receiveRetryDelay):
+			}
+			continue
+		}
+
+		for i, msg := range out.Messages {
+			// Make the remaining messages available again if the subscription
+			// is stopped, even while waiting for the user to read the messages
+			if p.ctx.Err() != nil {
+				c.release(p.queueURL, out.Messages[i:])
+				return
+			}
+
+			select {
+			case p.messages <- extensions.NewAcknowledgeableBrokerMessage(
+				brokerMessage(msg),
+				c.newAcknowledgementHandler(p, msg),
+			).WithMetadata(messageMetadata(msg)):
+			case <-p.ctx.Done():
+				c.release(p.queueURL, out.Messages[i:])
+				return
+			}
+		}
+	}
+}
+
+// release makes the messages visible again for the consumers of the queue.
+func (c *Controller) release(queueURL string, msgs []sqstypes.Message) {
+	for _, msg := range msgs {
+		c.changeVisibility(context.Background(), queueURL, msg, 0)
+	}
+}
+
+func (c *Controller) changeVisibility(ctx context.Context, queueURL string, msg sqstypes.Message, d time.Duration) {
+	_, err := c.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: int32(d / time.Second),
+	})
+	if err != nil && ctx.Err() == nil {
+		c.logger.Error(ctx, "failed to change message visibility",
+			extensions.LogInfo{Key: "message-id", Value: aws.ToString(msg.MessageId)},
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+}
+
+// snsNotification is the envelope of the messages delivered by SNS to SQS
+// when raw message delivery is disabled on the subscription.
+type snsNotification struct {
+	Type              string
+	TopicArn          string
+	Message           string
+	MessageAttributes map[string]struct {
+		Type  string
+		Value string
+	}
+}
+
+// brokerMessage converts the SQS message to a broker message, unwrapping it
+// from its SNS envelope if raw message delivery is disabled.
+func brokerMessage(msg sqstypes.Message) extensions.BrokerMessage {
+	body := aws.ToString(msg.Body)
+
+	var n snsNotification
+	if err := json.Unmarshal([]byte(body), &n); err == nil && n.Type == "Notification" && n.TopicArn != "" {
+		headers := make(map[string][]byte, len(n.MessageAttributes))
+		for k, v := range n.MessageAttributes {
+			headers[k] = []byte(v.Value)
+			if v.Type == "Binary" {
+				if b, err := base64.StdEncoding.DecodeString(v.Value); err == nil {
+					headers[k] = b
+				}
+			}
+		}
+
+		return extensions.BrokerMessage{
+			ContentType: brokers.ExtractContentType(headers),
+			Headers:     headers,
+			Payload:     decodePayload(headers, n.Message),
+		}
+	}
+
+	headers := make(map[string][]byte, len(msg.MessageAttributes))
+	for k, v := range msg.MessageAttributes {
+		if v.BinaryValue != nil {
+			headers[k] = v.BinaryValue
+		} else {
+			headers[k] = []byte(aws.ToString(v.StringValue))
+		}
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     decodePayload(headers, body),
+	}
+}
+
+// decodePayload returns the payload of the message, decoded from base64 if it
+// has been encoded on publication. The payload encoding attribute is removed
+// from the headers.
+func decodePayload(headers map[string][]byte, message string) []byte {
+	if string(headers[PayloadEncodingAttributeKey]) != base64PayloadEncoding {
+		return []byte(message)
+	}
+	delete(headers, PayloadEncodingAttributeKey)
+
+	payload, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return []byte(message)
+	}
+	return payload
+}
+
+// messageMetadata returns the delivery metadata of the SQS message, from its
+// system attributes.
+func messageMetadata(msg sqstypes.Message) extensions.MessageMetadata {
+	md := extensions.MessageMetadata{ID: aws.ToString(msg.MessageId)}
+
+	receiveCount := msg.Attributes[string(sqstypes.MessageSystemAttributeNameApproximateReceiveCount)]
+	if count, err := strconv.Atoi(receiveCount); err == nil {
+		md.DeliveryCount = count
+		md.Redelivered = count > 1
+	}
+	sentTimestamp := msg.Attributes[string(sqstypes.MessageSystemAttributeNameSentTimestamp)]
+	if ms, err := strconv.ParseInt(sentTimestamp, 10, 64); err == nil {
+		md.Timestamp = time.UnixMilli(ms)
+	}
+
+	return md
+}
+
+var _ extensions.BrokerAcknowledgment = (*AcknowledgementHandler)(nil)
+
+// AcknowledgementHandler for SQS broker.
+type AcknowledgementHandler struct {
+	controller *Controller
+	queueURL   string
+	msg        sqstypes.Message
+
+	once sync.Once
+	done chan struct{}
+}
+
+func (c *Controller) newAcknowledgementHandler(p *poll, msg sqstypes.Message) *AcknowledgementHandler {
+	h := &AcknowledgementHandler{
+		controller: c,
+		queueURL:   p.queueURL,
+		msg:        msg,
+		done:       make(chan struct{}),
+	}
+
+	if c.visibilityTimeout > 0 {
+		go h.extendVisibility(p.ctx)
+	}
+
+	return h
+}
+
+// extendVisibility extends the visibility timeout of the message until it is
+// acknowledged or the subscription is stopped.
+func (h *AcknowledgementHandler) extendVisibility(ctx context.Context) {
+	ticker := time.NewTicker(h.controller.visibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.controller.changeVisibility(ctx, h.queueURL, h.msg, h.controller.visibilityTimeout)
+		}
+	}
+}
+
+// AckMessage acknowledges the message by deleting it from the queue.
+func (h *AcknowledgementHandler) AckMessage() {
+	h.once.Do(func() {
+		close(h.done)
+
+		_, err := h.controller.sqs.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(h.queueURL),
+			ReceiptHandle: h.msg.ReceiptHandle,
+		})
+		if err != nil {
+			h.controller.logger.Error(context.Background(), "failed to delete message",
+				extensions.LogInfo{Key: "message-id", Value: aws.ToString(h.msg.MessageId)},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	})
+}
+
+// NakMessage negatively acknowledges the message by making it visible again
+// right away, so it can be received again.
+func (h *AcknowledgementHandler) NakMessage() {
+	h.once.Do(func() {
+		close(h.done)
+		h.controller.changeVisibility(context.Background(), h.queueURL, h.msg, 0)
+	})
+}
@@ -0,0 +1,467 @@
+// Package webhook is the HTTP implementation for asyncapi-codegen, for the
+// AsyncAPI documents using the 'http' protocol: the messages are published with
+// HTTP POST requests to an endpoint, and received as webhooks by an HTTP server.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interface.
+var _ extensions.BrokerController = (*Controller)(nil)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of the body of the
+	// received requests.
+	DefaultMaxBodySize = 1 << 20
+
+	// HeaderNamesHeaderKey is the HTTP header listing the names of the message
+	// headers sent as HTTP headers, so they keep their case on reception.
+	HeaderNamesHeaderKey = "Asyncapi-Headers"
+)
+
+// Controller is the HTTP implementation for asyncapi-codegen.
+//
+// The messages are published with HTTP POST requests to the endpoint, on the
+// path of their channel address, with the message headers as HTTP headers.
+// The controller is an http.Handler receiving these requests (it can also
+// serve them itself with WithServerAddress or WithListener), and transmitting
+// the messages to the subscriptions of the channel of their path.
+type Controller struct {
+	logger      extensions.Logger
+	endpoint    string
+	client      *http.Client
+	header      http.Header
+	maxBodySize int64
+	ackTimeout  time.Duration
+
+	server   *http.Server
+	listener net.Listener
+
+	mu            sync.Mutex
+	closed        bool
+	subscriptions map[string][]*subscription
+}
+
+// ControllerOption is a function that can be used to configure an HTTP
+// controller.
+// Examples: WithLogger(), WithEndpoint(), WithServerAddress().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a new HTTP controller.
+func NewController(options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		logger:        extensions.DummyLogger{},
+		client:        http.DefaultClient,
+		header:        make(http.Header),
+		maxBodySize:   DefaultMaxBodySize,
+		subscriptions: make(map[string][]*subscription),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	// Serve the received requests, if a listener is set
+	if controller.listener != nil {
+		controller.server = &http.Server{Handler: controller, ReadHeaderTimeout: 10 * time.Second}
+		go controller.serve()
+	}
+
+	return controller, nil
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithEndpoint set the URL where the messages are published (like
+// 'https://example.com/webhooks'), followed by the address of their channel.
+func WithEndpoint(endpoint string) ControllerOption {
+	return func(controller *Controller) error {
+		if _, err := url.Parse(endpoint); err != nil {
+			return fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+		controller.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithHTTPClient set the HTTP client used to publish the messages. If not
+// specified, http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) ControllerOption {
+	return func(controller *Controller) error {
+		controller.client = client
+		return nil
+	}
+}
+
+// WithHeader set the HTTP headers sent with each published message (for
+// example for authentication).
+func WithHeader(header http.Header) ControllerOption {
+	return func(controller *Controller) error {
+		controller.header = header
+		return nil
+	}
+}
+
+// WithServerAddress set the address where the controller serves the received
+// requests (like ':8080').
+func WithServerAddress(addr string) ControllerOption {
+	return func(controller *Controller) error {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("could not listen on %q: %w", addr, err)
+		}
+		controller.listener = l
+		return nil
+	}
+}
+
+// WithListener set the listener on which the controller serves the received
+// requests.
+func WithListener(l net.Listener) ControllerOption {
+	return func(controller *Controller) error {
+		controller.listener = l
+		return nil
+	}
+}
+
+// WithMaxBodySize set the maximum size of the body of the received requests. If
+// not specified, DefaultMaxBodySize is used.
+func WithMaxBodySize(size int64) ControllerOption {
+	return func(controller *Controller) error {
+		if size <= 0 {
+			return fmt.Errorf("max body size should be positive")
+		}
+		controller.maxBodySize = size
+		return nil
+	}
+}
+
+// WithWaitForAck makes the controller reply to the received requests once their
+// message is acknowledged by the subscriptions, or the timeout expires: with a
+// '204 No Content' status if it is acknowledged, and a '500 Internal Server
+// Error' status otherwise, so the sender can send it again. If not specified,
+// the controller replies with a '202 Accepted' status once the message is
+// transmitted to the subscriptions.
+func WithWaitForAck(timeout time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if timeout <= 0 {
+			return fmt.Errorf("ack timeout should be positive")
+		}
+		controller.ackTimeout = timeout
+		return nil
+	}
+}
+
+// Addr returns the address where the controller serves the received requests,
+// if it serves them itself.
+func (c *Controller) Addr() net.Addr {
+	if c.listener == nil {
+		return nil
+	}
+	return c.listener.Addr()
+}
+
+func (c *Controller) serve() {
+	if err := c.server.Serve(c.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.logger.Error(context.Background(), "failed to serve requests",
+			extensions.LogInfo{Key: "address", Value: c.listener.Addr().String()},
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	if c.endpoint == "" {
+		return fmt.Errorf("failed to publish on %q: no endpoint set", channel)
+	}
+
+	u, err := url.JoinPath(c.endpoint, channel)
+	if err != nil {
+		return fmt.Errorf("failed to publish on %q: %w", channel, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(bm.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to publish on %q: %w", channel, err)
+	}
+
+	// Set the headers, keeping the case of the message headers
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	names := make([]string, 0, len(bm.Headers))
+	for k, v := range bm.Headers {
+		req.Header[k] = []string{string(v)}
+		names = append(names, k)
+	}
+	if len(names) > 0 {
+		req.Header.Set(HeaderNamesHeaderKey, strings.Join(names, ","))
+	}
+	if bm.ContentType != "" {
+		req.Header.Set("Content-Type", bm.ContentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish on %q: %w", channel, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to publish on %q: unexpected status %q", channel, resp.Status)
+	}
+
+	return nil
+}
+
+// PublishBatch publishes several messages, with one request per message.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		if err := c.Publish(ctx, channel, bm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP receives a message published on the channel of the request path.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are accepted", http.StatusMethodNotAllowed)
+		return
+	}
+	channel := strings.TrimPrefix(r.URL.Path, "/")
+
+	// Get the subscriptions at the time of reception
+	c.mu.Lock()
+	closed := c.closed
+	subs := append([]*subscription(nil), c.subscriptions[channel]...)
+	c.mu.Unlock()
+	if closed {
+		http.Error(w, "controller is closed", http.StatusServiceUnavailable)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, c.maxBodySize))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Transmit the message to the subscriptions
+	acks := make(chan bool, len(subs))
+	transmitted := 0
+	for _, s := range subs {
+		bm := receivedMessage(r, payload)
+		if s.transmit(extensions.NewAcknowledgeableBrokerMessage(bm, AcknowledgementHandler{acks: acks})) {
+			transmitted++
+		}
+	}
+
+	// Without subscription, the message is dropped as with the other brokers
+	if c.ackTimeout <= 0 || transmitted == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	c.waitForAcks(w, r, acks, transmitted)
+}
+
+// waitForAcks replies once the message is acknowledged by all the subscriptions.
+func (c *Controller) waitForAcks(w http.ResponseWriter, r *http.Request, acks <-chan bool, count int) {
+	timeout := time.After(c.ackTimeout)
+	for i := 0; i < count; i++ {
+		select {
+		case acked := <-acks:
+			if !acked {
+				http.Error(w, "message not acknowledged", http.StatusInternalServerError)
+				return
+			}
+		case <-timeout:
+			http.Error(w, "message not acknowledged in time", http.StatusInternalServerError)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// receivedMessage returns the message of the received request. The message
+// headers are the ones listed in the HeaderNamesHeaderKey header, or all the
+// request headers if not set (e.g. when sent by another application).
+func receivedMessage(r *http.Request, payload []byte) extensions.BrokerMessage {
+	headers := make(map[string][]byte)
+	if names := r.Header.Get(HeaderNamesHeaderKey); names != "" {
+		for _, name := range strings.Split(names, ",") {
+			headers[name] = []byte(r.Header.Get(name))
+		}
+	} else {
+		for k, v := range r.Header {
+			if k != "Content-Type" && k != "Content-Length" {
+				headers[k] = []byte(strings.Join(v, ","))
+			}
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = brokers.ExtractContentType(headers)
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: contentType,
+		Headers:     headers,
+		Payload:     payload,
+	}
+}
+
+// Subscribe to messages from the broker.
+func (c *Controller) Subscribe(_ context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	// Create a new subscription
+	s := newSubscription()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return extensions.BrokerChannelSubscription{}, fmt.Errorf("controller is closed")
+	}
+	c.subscriptions[channel] = append(c.subscriptions[channel], s)
+	c.mu.Unlock()
+
+	// Wait for cancellation and remove the subscription from the channel
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+		c.removeSubscription(channel, s)
+	})
+
+	return s.sub, nil
+}
+
+// removeSubscription removes the subscription from the channel.
+func (c *Controller) removeSubscription(channel string, s *subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subs := c.subscriptions[channel]
+	for i, sub := range subs {
+		if sub == s {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(subs) > 0 {
+		c.subscriptions[channel] = subs
+		return
+	}
+	delete(c.subscriptions, channel)
+}
+
+// Close closes everything related to the broker.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	if c.server != nil {
+		_ = c.server.Close()
+	}
+}
+
+// subscription is a subscription of the user on a channel.
+type subscription struct {
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	mu      sync.Mutex
+	stopped bool
+	sending sync.WaitGroup
+	done    chan struct{}
+}
+
+func newSubscription() *subscription {
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	return &subscription{
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		done:     make(chan struct{}),
+	}
+}
+
+// transmit transmits the message to the user, unless the subscription is
+// stopped, even while waiting for the user to read the messages. It returns
+// true if the message has been transmitted.
+func (s *subscription) transmit(msg extensions.AcknowledgeableBrokerMessage) bool {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return false
+	}
+	s.sending.Add(1)
+	s.mu.Unlock()
+	defer s.sending.Done()
+
+	select {
+	case s.messages <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// stop prevents new messages from being transmitted and waits for the pending
+// transmissions, before the messages channel is closed.
+func (s *subscription) stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	s.sending.Wait()
+}
+
+var _ extensions.BrokerAcknowledgment = (*AcknowledgementHandler)(nil)
+
+// AcknowledgementHandler for HTTP broker, that gives the acknowledgement of the
+// message to the reply of its request, when the controller waits for it.
+type AcknowledgementHandler struct {
+	acks chan<- bool
+}
+
+// AckMessage acknowledges the message.
+func (h AcknowledgementHandler) AckMessage() {
+	h.acks <- true
+}
+
+// NakMessage negatively acknowledges the message.
+func (h AcknowledgementHandler) NakMessage() {
+	h.acks <- false
+}
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// newLoopbackController creates a controller publishing the messages to its own
+// server.
+func newLoopbackController(options ...ControllerOption) (*Controller, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	options = append([]ControllerOption{WithEndpoint("http://" + l.Addr().String()), WithListener(l)}, options...)
+	return NewController(options...)
+}
+
+func receive(t *testing.T, sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return newLoopbackController()
+		},
+		CloseController: func(c extensions.BrokerController) {
+			c.(*Controller).Close()
+		},
+	})
+}
+
+func TestOptionsValidation(t *testing.T) {
+	_, err := NewController(WithMaxBodySize(0))
+	assert.Error(t, err)
+	_, err = NewController(WithWaitForAck(0))
+	assert.Error(t, err)
+}
+
+func TestPublishRequest(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c, err := NewController(WithEndpoint(ts.URL+"/webhooks"),
+		WithHeader(http.Header{"Authorization": []string{"Bearer token"}}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.Publish(context.Background(), "v3/orders", extensions.BrokerMessage{
+		Headers:     map[string][]byte{"correlationId": []byte("1234")},
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+	}))
+
+	r := <-requests
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/webhooks/v3/orders", r.URL.Path)
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "1234", r.Header.Get("correlationId"))
+	assert.Equal(t, "correlationId", r.Header.Get(HeaderNamesHeaderKey))
+}
+
+func TestPublishFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	c, err := NewController(WithEndpoint(ts.URL))
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Error(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+
+	c, err = NewController()
+	require.NoError(t, err)
+	assert.Error(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+}
+
+func TestReceiveWebhook(t *testing.T) {
+	c, err := NewController()
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	// A webhook sent by another application keeps the canonical header names
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Signature", "abcd")
+	c.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	msg := receive(t, sub)
+	assert.Equal(t, `{"id":1}`, string(msg.Payload))
+	assert.Equal(t, "application/json", msg.ContentType)
+	assert.Equal(t, map[string][]byte{"X-Signature": []byte("abcd")}, msg.Headers)
+	msg.Ack()
+
+	// The requests with other methods are rejected
+	w = httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestCancelFullSubscription(t *testing.T) {
+	c, err := NewController()
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	// Fill the subscription, then send a webhook waiting for the user
+	for i := 0; i < brokers.BrokerMessagesQueueSize; i++ {
+		c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+	}
+	served := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		c.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+		served <- w.Code
+	}()
+
+	// The cancellation does not wait for the user to read the messages
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sub.Cancel(ctx)
+	require.NoError(t, ctx.Err())
+	assert.Equal(t, http.StatusAccepted, <-served)
+}
+
+func TestWaitForAck(t *testing.T) {
+	c, err := newLoopbackController(WithWaitForAck(time.Second))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	// The publication succeeds once the message is acknowledged
+	go func() {
+		msg := receive(t, sub)
+		msg.Ack()
+	}()
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+
+	// And fails if it is negatively acknowledged
+	go func() {
+		msg := receive(t, sub)
+		msg.Nak()
+	}()
+	require.Error(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{}))
+}
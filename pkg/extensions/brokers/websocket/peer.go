@@ -0,0 +1,199 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+const (
+	// actionPublish is the action of the frames carrying a message published on a channel.
+	actionPublish = "publish"
+	// actionSubscribe is the action of the frames sent by a client to subscribe to a channel.
+	actionSubscribe = "subscribe"
+	// actionSubscribed is the action of the frames sent by the server to confirm a subscription.
+	actionSubscribed = "subscribed"
+	// actionUnsubscribe is the action of the frames sent by a client to unsubscribe from a channel.
+	actionUnsubscribe = "unsubscribe"
+)
+
+// frame is the JSON text message exchanged between the clients and the server.
+type frame struct {
+	Action  string            `json:"action"`
+	Channel string            `json:"channel"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload []byte            `json:"payload,omitempty"`
+}
+
+// newPublishFrame creates the frame of a message published on a channel, with
+// its content type as header.
+func newPublishFrame(channel string, bm extensions.BrokerMessage) frame {
+	headers := make(map[string]string, len(bm.Headers)+1)
+	for k, v := range bm.Headers {
+		headers[k] = string(v)
+	}
+	if bm.ContentType != "" {
+		headers[brokers.ContentTypeHeaderKey] = bm.ContentType
+	}
+
+	return frame{
+		Action:  actionPublish,
+		Channel: channel,
+		Headers: headers,
+		Payload: bm.Payload,
+	}
+}
+
+// brokerMessage returns the message carried by the frame.
+func (f frame) brokerMessage() extensions.BrokerMessage {
+	headers := make(map[string][]byte, len(f.Headers))
+	for k, v := range f.Headers {
+		headers[k] = []byte(v)
+	}
+
+	return extensions.BrokerMessage{
+		ContentType: brokers.ExtractContentType(headers),
+		Headers:     headers,
+		Payload:     f.Payload,
+	}
+}
+
+// peer is a connection of the controller: to a client in server mode, or to
+// the server in client mode.
+type peer struct {
+	conn *gorilla.Conn
+	done chan struct{}
+
+	// channels are the channels the client is subscribed to, in server mode.
+	// It is protected by the controller mutex.
+	channels map[string]struct{}
+
+	writeMu sync.Mutex
+}
+
+// write sends the frame on the connection, as only one writer is allowed at a time.
+func (p *peer) write(f frame, timeout time.Duration) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if err := p.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	return p.conn.WriteJSON(f)
+}
+
+// isClosed returns true once the connection is closed and its frames are not
+// read anymore.
+func (p *peer) isClosed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the connection, after trying to notify the other side.
+func (p *peer) close(timeout time.Duration) {
+	msg := gorilla.FormatCloseMessage(gorilla.CloseNormalClosure, "")
+	_ = p.conn.WriteControl(gorilla.CloseMessage, msg, time.Now().Add(timeout))
+	_ = p.conn.Close()
+}
+
+// addPeer registers the connection, unless the controller is closed.
+func (c *Controller) addPeer(conn *gorilla.Conn) *peer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	p := &peer{
+		conn:     conn,
+		done:     make(chan struct{}),
+		channels: make(map[string]struct{}),
+	}
+	c.peers[p] = struct{}{}
+
+	return p
+}
+
+// serve reads the frames of the connection until it is closed.
+func (c *Controller) serve(p *peer) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.peers, p)
+		closed := c.closed
+		c.mu.Unlock()
+
+		close(p.done)
+		_ = p.conn.Close()
+
+		// The subscriptions of a client won't receive messages anymore
+		if !c.isServer && !closed {
+			c.logger.Error(context.Background(), "connection to websocket server lost")
+			c.endSubscriptions()
+		}
+	}()
+
+	for {
+		_, data, err := p.conn.ReadMessage()
+		if err != nil {
+			if !gorilla.IsCloseError(err, gorilla.CloseNormalClosure, gorilla.CloseGoingAway) {
+				c.logger.Warning(context.Background(), "websocket connection closed",
+					extensions.LogInfo{Key: "remote", Value: p.conn.RemoteAddr().String()},
+					extensions.LogInfo{Key: "error", Value: err.Error()})
+			}
+			return
+		}
+
+		var f frame
+		if err := json.Unmarshal(data, &f); err != nil {
+			c.logger.Error(context.Background(), "failed to decode frame",
+				extensions.LogInfo{Key: "remote", Value: p.conn.RemoteAddr().String()},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+			continue
+		}
+
+		c.handleFrame(p, f)
+	}
+}
+
+// handleFrame executes the action of the frame received on the connection.
+func (c *Controller) handleFrame(p *peer, f frame) {
+	switch {
+	case f.Action == actionPublish:
+		c.dispatch(f)
+	case f.Action == actionSubscribe && c.isServer:
+		c.mu.Lock()
+		p.channels[f.Channel] = struct{}{}
+		c.mu.Unlock()
+
+		if err := p.write(frame{Action: actionSubscribed, Channel: f.Channel}, c.writeTimeout); err != nil {
+			c.logger.Error(context.Background(), "failed to confirm subscription",
+				extensions.LogInfo{Key: "channel", Value: f.Channel},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	case f.Action == actionUnsubscribe && c.isServer:
+		c.mu.Lock()
+		delete(p.channels, f.Channel)
+		c.mu.Unlock()
+	case f.Action == actionSubscribed && !c.isServer:
+		c.mu.Lock()
+		if confirmed, exists := c.pending[f.Channel]; exists {
+			close(confirmed)
+			delete(c.pending, f.Channel)
+		}
+		c.mu.Unlock()
+	default:
+		c.logger.Warning(context.Background(), "unexpected frame action",
+			extensions.LogInfo{Key: "action", Value: f.Action},
+			extensions.LogInfo{Key: "channel", Value: f.Channel})
+	}
+}
@@ -0,0 +1,454 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+)
+
+// Check that it still fills the interface.
+var _ extensions.BrokerController = (*Controller)(nil)
+
+// DefaultWriteTimeout is the default time allowed to write a frame on a connection.
+const DefaultWriteTimeout = 10 * time.Second
+
+// Controller is the WebSocket implementation for asyncapi-codegen.
+//
+// In server mode, the controller is an http.Handler accepting connections from
+// the clients and routing the messages between them and its own subscriptions.
+// In client mode, the controller is connected to a server controller (or any
+// server implementing the same protocol, like a browser application).
+type Controller struct {
+	isServer     bool
+	logger       extensions.Logger
+	writeTimeout time.Duration
+
+	// Server mode
+	upgrader gorilla.Upgrader
+
+	// Client mode
+	dialer     gorilla.Dialer
+	header     http.Header
+	serverPeer *peer
+
+	mu            sync.Mutex
+	closed        bool
+	subscriptions map[string][]*subscription
+	peers         map[*peer]struct{}
+	pending       map[string]chan struct{}
+}
+
+// ControllerOption is a function that can be used to configure a WebSocket controller
+// Examples: WithLogger(), WithCheckOrigin(), WithHeader().
+type ControllerOption func(controller *Controller) error
+
+func newController(isServer bool, options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		isServer:      isServer,
+		logger:        extensions.DummyLogger{},
+		writeTimeout:  DefaultWriteTimeout,
+		dialer:        *gorilla.DefaultDialer,
+		subscriptions: make(map[string][]*subscription),
+		peers:         make(map[*peer]struct{}),
+		pending:       make(map[string]chan struct{}),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	return controller, nil
+}
+
+// NewServerController creates a new WebSocket controller in server mode. It
+// should be served on an HTTP server to accept the connections of the clients.
+func NewServerController(options ...ControllerOption) (*Controller, error) {
+	return newController(true, options...)
+}
+
+// NewClientController creates a new WebSocket controller in client mode,
+// connected to the server at the given URL ('ws://' or 'wss://').
+func NewClientController(url string, options ...ControllerOption) (*Controller, error) {
+	controller, err := newController(false, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, err := controller.dialer.Dial(url, controller.header)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to websocket server: %w", err)
+	}
+
+	controller.serverPeer = controller.addPeer(conn)
+	go controller.serve(controller.serverPeer)
+
+	return controller, nil
+}
+
+// WithLogger set a custom logger that will log operations on broker controller.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithWriteTimeout set the time allowed to write a frame on a connection. If
+// not specified, DefaultWriteTimeout is used.
+func WithWriteTimeout(d time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if d <= 0 {
+			return fmt.Errorf("write timeout should be positive")
+		}
+		controller.writeTimeout = d
+		return nil
+	}
+}
+
+// WithCheckOrigin set the function checking the origin of the connections, in
+// server mode. If not specified, only the connections from the same host are
+// accepted, which can be too restrictive for browser applications.
+func WithCheckOrigin(fn func(r *http.Request) bool) ControllerOption {
+	return func(controller *Controller) error {
+		controller.upgrader.CheckOrigin = fn
+		return nil
+	}
+}
+
+// WithUpgrader modifies the gorilla.Upgrader used to accept the connections,
+// in server mode.
+func WithUpgrader(fn func(upgrader *gorilla.Upgrader)) ControllerOption {
+	return func(controller *Controller) error {
+		fn(&controller.upgrader)
+		return nil
+	}
+}
+
+// WithDialer modifies the gorilla.Dialer used to connect to the server, in
+// client mode.
+func WithDialer(fn func(dialer *gorilla.Dialer)) ControllerOption {
+	return func(controller *Controller) error {
+		fn(&controller.dialer)
+		return nil
+	}
+}
+
+// WithHeader set the HTTP headers sent when connecting to the server, in
+// client mode (for example for authentication).
+func WithHeader(header http.Header) ControllerOption {
+	return func(controller *Controller) error {
+		controller.header = header
+		return nil
+	}
+}
+
+// ServeHTTP accepts a connection from a client, in server mode.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !c.isServer {
+		http.Error(w, "websocket controller is not in server mode", http.StatusNotImplemented)
+		return
+	}
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		http.Error(w, "websocket controller is closed", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Upgrade the connection, the upgrader replies to the client on failure
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		c.logger.Error(r.Context(), "failed to upgrade connection",
+			extensions.LogInfo{Key: "remote", Value: r.RemoteAddr},
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+		return
+	}
+
+	p := c.addPeer(conn)
+	if p == nil {
+		_ = conn.Close()
+		return
+	}
+	c.serve(p)
+}
+
+// Publish a message to the broker.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+// PublishBatch publishes several messages. In server mode, they are sent to the
+// clients subscribed to the channel and to the controller subscriptions. In
+// client mode, they are sent to the server.
+func (c *Controller) PublishBatch(_ context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		f := newPublishFrame(channel, bm)
+
+		if c.isServer {
+			c.dispatch(f)
+			continue
+		}
+
+		if err := c.serverPeer.write(f, c.writeTimeout); err != nil {
+			return fmt.Errorf("failed to publish on %q: %w", channel, err)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe to messages from the broker.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	// Create a new subscription
+	s := newSubscription()
+
+	// Subscribe on the server, if there is no subscription on the channel yet
+	first, err := c.addSubscription(channel, s)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	if first && !c.isServer {
+		if err := c.subscribeOnServer(ctx, channel); err != nil {
+			c.removeSubscription(channel, s)
+			return extensions.BrokerChannelSubscription{}, err
+		}
+	}
+
+	// Wait for cancellation and unsubscribe from the channel
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+		if !c.removeSubscription(channel, s) || c.isServer || c.serverPeer.isClosed() {
+			return
+		}
+
+		if err := c.serverPeer.write(frame{Action: actionUnsubscribe, Channel: channel}, c.writeTimeout); err != nil {
+			c.logger.Error(ctx, "failed to unsubscribe",
+				extensions.LogInfo{Key: "channel", Value: channel},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	})
+
+	return s.sub, nil
+}
+
+// subscribeOnServer subscribes to the channel on the server and waits for its
+// confirmation, so the messages published afterward are received.
+func (c *Controller) subscribeOnServer(ctx context.Context, channel string) error {
+	c.mu.Lock()
+	confirmed := make(chan struct{})
+	c.pending[channel] = confirmed
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, channel)
+		c.mu.Unlock()
+	}()
+
+	if err := c.serverPeer.write(frame{Action: actionSubscribe, Channel: channel}, c.writeTimeout); err != nil {
+		return fmt.Errorf("failed to subscribe to %q: %w", channel, err)
+	}
+
+	select {
+	case <-confirmed:
+		return nil
+	case <-c.serverPeer.done:
+		return fmt.Errorf("failed to subscribe to %q: connection closed", channel)
+	case <-ctx.Done():
+		return fmt.Errorf("failed to subscribe to %q: %w", channel, ctx.Err())
+	}
+}
+
+// addSubscription adds the subscription on the channel and returns true if it
+// is the first one.
+func (c *Controller) addSubscription(channel string, s *subscription) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false, fmt.Errorf("controller is closed")
+	}
+
+	first := len(c.subscriptions[channel]) == 0
+	c.subscriptions[channel] = append(c.subscriptions[channel], s)
+
+	return first, nil
+}
+
+// removeSubscription removes the subscription from the channel and returns
+// true if it was the last one.
+func (c *Controller) removeSubscription(channel string, s *subscription) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subs := c.subscriptions[channel]
+	for i, sub := range subs {
+		if sub == s {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(subs) > 0 {
+		c.subscriptions[channel] = subs
+		return false
+	}
+	delete(c.subscriptions, channel)
+	return true
+}
+
+// dispatch transmits the published message to the controller subscriptions
+// and, in server mode, to the clients subscribed to its channel.
+func (c *Controller) dispatch(f frame) {
+	// Get the subscriptions at the time of reception
+	c.mu.Lock()
+	subs := append([]*subscription(nil), c.subscriptions[f.Channel]...)
+	var peers []*peer
+	if c.isServer {
+		for p := range c.peers {
+			if _, subscribed := p.channels[f.Channel]; subscribed {
+				peers = append(peers, p)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for _, p := range peers {
+		if err := p.write(f, c.writeTimeout); err != nil {
+			c.logger.Error(context.Background(), "failed to send message to client",
+				extensions.LogInfo{Key: "channel", Value: f.Channel},
+				extensions.LogInfo{Key: "remote", Value: p.conn.RemoteAddr().String()},
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	}
+
+	if len(subs) == 0 {
+		return
+	}
+
+	// Create and transmit message to users
+	bm := f.brokerMessage()
+	for _, s := range subs {
+		s.transmit(extensions.NewAcknowledgeableBrokerMessage(bm, NoopAcknowledgementHandler{}))
+	}
+}
+
+// Healthy returns an error if the connection to the server is lost, in client
+// mode.
+func (c *Controller) Healthy(_ context.Context) error {
+	if !c.isServer && c.serverPeer.isClosed() {
+		return fmt.Errorf("connection to websocket server lost")
+	}
+	return nil
+}
+
+// endSubscriptions ends the subscriptions of the controller, as they won't
+// receive messages anymore.
+func (c *Controller) endSubscriptions() {
+	c.mu.Lock()
+	var subs []*subscription
+	for _, channelSubs := range c.subscriptions {
+		subs = append(subs, channelSubs...)
+	}
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		s.sub.Cancel(context.Background())
+	}
+}
+
+// Close closes everything related to the broker.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	c.closed = true
+	peers := make([]*peer, 0, len(c.peers))
+	for p := range c.peers {
+		peers = append(peers, p)
+	}
+	c.mu.Unlock()
+
+	for _, p := range peers {
+		p.close(c.writeTimeout)
+	}
+}
+
+// subscription is a subscription of the user on a channel.
+type subscription struct {
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	mu      sync.Mutex
+	stopped bool
+	sending sync.WaitGroup
+	done    chan struct{}
+}
+
+func newSubscription() *subscription {
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	return &subscription{
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		done:     make(chan struct{}),
+	}
+}
+
+// transmit transmits the message to the user, unless the subscription is
+// stopped, even while waiting for the user to read the messages. It returns
+// true if the message has been transmitted.
+func (s *subscription) transmit(msg extensions.AcknowledgeableBrokerMessage) bool {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return false
+	}
+	s.sending.Add(1)
+	s.mu.Unlock()
+	defer s.sending.Done()
+
+	select {
+	case s.messages <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// stop prevents new messages from being transmitted and waits for the pending
+// transmissions, before the messages channel is closed.
+func (s *subscription) stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	s.sending.Wait()
+}
+
+var _ extensions.BrokerAcknowledgment = (*NoopAcknowledgementHandler)(nil)
+
+// NoopAcknowledgementHandler for WebSocket broker, as messages are not kept
+// once they are delivered and can't be acknowledged.
+type NoopAcknowledgementHandler struct {
+}
+
+// AckMessage acknowledges the message.
+func (h NoopAcknowledgementHandler) AckMessage() {
+}
+
+// NakMessage negatively acknowledges the message.
+func (h NoopAcknowledgementHandler) NakMessage() {
+}
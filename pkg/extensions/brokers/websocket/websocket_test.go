@@ -0,0 +1,182 @@
+package websocket
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokerstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func newServer(t *testing.T) (*Controller, string) {
+	server, err := NewServerController()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	return server, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func newClient(t *testing.T, url string) *Controller {
+	client, err := NewClientController(url)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func receive(t *testing.T, sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	// The clients receive their own messages back from the server
+	_, url := newServer(t)
+
+	suite.Run(t, &brokerstest.Suite{
+		NewController: func() (extensions.BrokerController, error) {
+			return NewClientController(url)
+		},
+		CloseController: func(bc extensions.BrokerController) {
+			bc.(*Controller).Close()
+		},
+	})
+}
+
+func TestOptionsValidation(t *testing.T) {
+	_, err := NewServerController(WithWriteTimeout(0))
+	assert.Error(t, err)
+}
+
+func TestFrame(t *testing.T) {
+	bm := extensions.BrokerMessage{
+		Headers:     map[string][]byte{"id": []byte("1")},
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+	}
+
+	f := newPublishFrame("orders", bm)
+	assert.Equal(t, frame{
+		Action:  actionPublish,
+		Channel: "orders",
+		Headers: map[string]string{"id": "1", "content-type": "application/json"},
+		Payload: []byte(`{}`),
+	}, f)
+	assert.Equal(t, bm, f.brokerMessage())
+}
+
+func TestServerToClient(t *testing.T) {
+	server, url := newServer(t)
+	client := newClient(t, url)
+
+	sub, err := client.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer sub.Cancel(context.Background())
+
+	err = server.PublishBatch(context.Background(), "orders", []extensions.BrokerMessage{
+		{Headers: map[string][]byte{"id": []byte("1")}, ContentType: "text/plain", Payload: []byte("first")},
+		{Headers: map[string][]byte{}, Payload: []byte("second")},
+	})
+	require.NoError(t, err)
+
+	msg := receive(t, sub)
+	assert.Equal(t, "first", string(msg.Payload))
+	assert.Equal(t, "text/plain", msg.ContentType)
+	assert.Equal(t, map[string][]byte{"id": []byte("1")}, msg.Headers)
+	msg.Ack()
+
+	msg = receive(t, sub)
+	assert.Equal(t, "second", string(msg.Payload))
+	msg.Nak()
+}
+
+func TestClientToClientThroughServer(t *testing.T) {
+	server, url := newServer(t)
+	sender, receiver := newClient(t, url), newClient(t, url)
+
+	serverSub, err := server.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer serverSub.Cancel(context.Background())
+
+	receiverSub, err := receiver.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	defer receiverSub.Cancel(context.Background())
+
+	require.NoError(t, sender.Publish(context.Background(), "orders", extensions.BrokerMessage{
+		Payload: []byte("order"),
+	}))
+
+	assert.Equal(t, "order", string(receive(t, serverSub).Payload))
+	assert.Equal(t, "order", string(receive(t, receiverSub).Payload))
+}
+
+func TestUnsubscribe(t *testing.T) {
+	server, url := newServer(t)
+	client := newClient(t, url)
+
+	sub, err := client.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+
+	isSubscribed := func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		for p := range server.peers {
+			if _, ok := p.channels["orders"]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, isSubscribed())
+
+	// Let the subscription wait for its cancellation before cancelling it
+	time.Sleep(100 * time.Millisecond)
+	sub.Cancel(context.Background())
+	assert.Eventually(t, func() bool { return !isSubscribed() }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestClose(t *testing.T) {
+	server, url := newServer(t)
+	client := newClient(t, url)
+
+	server.Close()
+	<-client.serverPeer.done
+
+	_, err := client.Subscribe(context.Background(), "orders")
+	assert.Error(t, err)
+}
+
+func TestConnectionLost(t *testing.T) {
+	server, url := newServer(t)
+	client := newClient(t, url)
+
+	sub, err := client.Subscribe(context.Background(), "orders")
+	require.NoError(t, err)
+	require.NoError(t, client.Healthy(context.Background()))
+
+	// The subscriptions of the client end with the connection
+	server.Close()
+	select {
+	case _, open := <-sub.MessagesChannel():
+		assert.False(t, open)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not ended")
+	}
+	assert.Error(t, client.Healthy(context.Background()))
+	assert.NoError(t, server.Healthy(context.Background()))
+}
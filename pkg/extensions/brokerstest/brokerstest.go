@@ -0,0 +1,371 @@
+// Package brokerstest provides a conformance test suite for the broker
+// controllers, in order to check that a broker extension behaves as expected
+// by the generated code.
+//
+// It can be used in the tests of a broker controller:
+//
+//	func TestConformance(t *testing.T) {
+//		suite.Run(t, &brokerstest.Suite{
+//			NewController: func() (extensions.BrokerController, error) {
+//				return mybroker.NewController("localhost:1234")
+//			},
+//		})
+//	}
+package brokerstest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	// DefaultTimeout is the default maximum duration to receive a message.
+	DefaultTimeout = 5 * time.Second
+	// DefaultNoMessageDuration is the default duration during which no message
+	// should be received, when none is expected.
+	DefaultNoMessageDuration = 200 * time.Millisecond
+)
+
+// Suite is the conformance test suite of a broker controller, that checks the
+// publication, the subscription, the acknowledgement and the cancellation of
+// the subscriptions, and the pattern subscriptions if they are supported.
+type Suite struct {
+	suite.Suite
+
+	// NewController returns the broker controller tested by each test. It is
+	// mandatory.
+	NewController func() (extensions.BrokerController, error)
+	// CloseController closes the broker controller at the end of each test, if
+	// set.
+	CloseController func(extensions.BrokerController)
+
+	// ChannelPrefix is the prefix of the channels used by the tests, which are
+	// different for each test.
+	ChannelPrefix string
+	// SubscriptionDelay is the duration to wait after a subscription before
+	// publishing, for the brokers whose subscriptions are not effective
+	// immediately.
+	SubscriptionDelay time.Duration
+	// Timeout is the maximum duration to receive a message (DefaultTimeout if
+	// not set).
+	Timeout time.Duration
+	// NoMessageDuration is the duration during which no message should be
+	// received, when none is expected (DefaultNoMessageDuration if not set).
+	NoMessageDuration time.Duration
+
+	// WithoutHeaders states that the broker doesn't transmit the headers of
+	// the messages.
+	WithoutHeaders bool
+	// WithoutPatternSubscriptions states that the pattern subscriptions of the
+	// broker are not tested, as they only receive the messages of the channels
+	// existing when subscribing.
+	WithoutPatternSubscriptions bool
+	// Redelivery states that the broker delivers again the messages that are
+	// not acknowledged (with Nak).
+	Redelivery bool
+	// PatternSeparator is the separator of the tokens of the addresses, that
+	// delimits the parameters of the pattern subscriptions ('.' if not set).
+	PatternSeparator string
+
+	controller extensions.BrokerController
+}
+
+// SetupTest creates the tested broker controller.
+func (s *Suite) SetupTest() {
+	s.Require().NotNil(s.NewController, "NewController should be set")
+
+	controller, err := s.NewController()
+	s.Require().NoError(err)
+	s.controller = controller
+}
+
+// TearDownTest closes the tested broker controller.
+func (s *Suite) TearDownTest() {
+	if s.CloseController != nil && s.controller != nil {
+		s.CloseController(s.controller)
+	}
+	s.controller = nil
+}
+
+// TestPublishSubscribe checks that a published message is received by a
+// subscription, with its headers and payload.
+func (s *Suite) TestPublishSubscribe() {
+	channel := s.channel("pubsub")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	sent := extensions.BrokerMessage{
+		Headers:     map[string][]byte{"id": []byte("1"), "name": []byte("value")},
+		Payload:     []byte(`{"hello":"world"}`),
+		ContentType: "application/json",
+	}
+	s.Require().NoError(s.controller.Publish(context.Background(), channel, sent))
+
+	msg := s.receive(sub)
+	s.Require().Equal(sent.Payload, msg.Payload)
+	if !s.WithoutHeaders {
+		for k, v := range sent.Headers {
+			s.Require().Equal(v, msg.Headers[k], "header %q", k)
+		}
+	}
+	msg.Ack()
+}
+
+// TestPublishBatch checks that all the messages of a batch are received.
+func (s *Suite) TestPublishBatch() {
+	channel := s.channel("batch")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	batch := make([]extensions.BrokerMessage, 0, 3)
+	expected := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		payload := fmt.Sprintf("message %d", i)
+		batch = append(batch, extensions.BrokerMessage{
+			Headers: map[string][]byte{},
+			Payload: []byte(payload),
+		})
+		expected = append(expected, payload)
+	}
+	s.Require().NoError(s.controller.PublishBatch(context.Background(), channel, batch))
+
+	s.Require().ElementsMatch(expected, s.receivePayloads(sub, len(batch)))
+}
+
+// TestConcurrentPublish checks that the messages published concurrently are
+// all received.
+func (s *Suite) TestConcurrentPublish() {
+	channel := s.channel("concurrent")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	const count = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	expected := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		payload := fmt.Sprintf("message %d", i)
+		expected = append(expected, payload)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.controller.Publish(context.Background(), channel, extensions.BrokerMessage{
+				Headers: map[string][]byte{},
+				Payload: []byte(payload),
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		s.Require().NoError(err)
+	}
+
+	s.Require().ElementsMatch(expected, s.receivePayloads(sub, count))
+}
+
+// TestChannelsIsolation checks that a subscription only receives the messages
+// of its channel.
+func (s *Suite) TestChannelsIsolation() {
+	channel, other := s.channel("isolation"), s.channel("isolation.other")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	s.publish(other, "other")
+	s.publish(channel, "expected")
+
+	s.Require().Equal([]string{"expected"}, s.receivePayloads(sub, 1))
+	s.expectNoMessage(sub)
+}
+
+// TestAck checks that an acknowledged message is not delivered again.
+func (s *Suite) TestAck() {
+	channel := s.channel("ack")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	s.publish(channel, "message")
+	msg := s.receive(sub)
+	msg.Ack()
+	msg.Ack() // Only the first acknowledgement should be sent
+
+	s.expectNoMessage(sub)
+}
+
+// TestNak checks that a message that is not acknowledged is delivered again if
+// the broker supports it, and that it doesn't block the subscription
+// otherwise.
+func (s *Suite) TestNak() {
+	channel := s.channel("nak")
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	s.publish(channel, "message")
+	msg := s.receive(sub)
+	msg.Nak()
+
+	if s.Redelivery {
+		msg = s.receive(sub)
+		s.Require().Equal([]byte("message"), msg.Payload)
+		msg.Ack()
+		return
+	}
+
+	s.publish(channel, "next")
+	s.Require().Equal([]string{"next"}, s.receivePayloads(sub, 1))
+}
+
+// TestCancel checks that the cancellation of a subscription closes its
+// messages channel, and that publishing on the channel still works.
+func (s *Suite) TestCancel() {
+	channel := s.channel("cancel")
+	sub := s.subscribe(channel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+	sub.Cancel(ctx)
+	s.Require().NoError(ctx.Err(), "the cancellation should be effective before the timeout")
+
+	// The messages channel should be closed, once the pending messages are
+	// consumed
+	for closed := false; !closed; {
+		select {
+		case _, open := <-sub.MessagesChannel():
+			closed = !open
+		case <-time.After(s.timeout()):
+			s.FailNow("messages channel not closed")
+		}
+	}
+
+	s.publish(channel, "message")
+}
+
+// TestResubscribe checks that a channel can be subscribed again after the
+// cancellation of a subscription.
+func (s *Suite) TestResubscribe() {
+	channel := s.channel("resubscribe")
+	s.subscribe(channel).Cancel(context.Background())
+
+	sub := s.subscribe(channel)
+	defer sub.Cancel(context.Background())
+
+	s.publish(channel, "message")
+	s.Require().Equal([]string{"message"}, s.receivePayloads(sub, 1))
+}
+
+// TestSubscribeToPattern checks that a pattern subscription receives the
+// messages of all the matching addresses, with their address, if the broker
+// implements extensions.PatternSubscriber and WithoutPatternSubscriptions is
+// not set.
+func (s *Suite) TestSubscribeToPattern() {
+	if _, ok := s.controller.(extensions.PatternSubscriber); !ok {
+		s.T().Skip("pattern subscriptions not supported")
+	}
+	if s.WithoutPatternSubscriptions {
+		s.T().Skip("pattern subscriptions not tested")
+	}
+
+	sep := s.PatternSeparator
+	if sep == "" {
+		sep = "."
+	}
+	channel := s.channel("pattern")
+	address := func(id, event string) string { return channel + sep + id + sep + event }
+
+	sub, err := extensions.SubscribeToPattern(context.Background(), s.controller, address("{id}", "events"))
+	s.Require().NoError(err)
+	defer sub.Cancel(context.Background())
+	if s.SubscriptionDelay > 0 {
+		time.Sleep(s.SubscriptionDelay)
+	}
+
+	s.publish(address("1", "other"), "other")
+	s.publish(address("1", "events"), "first")
+	s.publish(address("2", "events"), "second")
+
+	received := make(map[string]string)
+	for len(received) < 2 {
+		msg := s.receive(sub)
+		msg.Ack()
+		received[string(msg.Payload)] = msg.Address
+	}
+	s.Require().Equal(map[string]string{
+		"first":  address("1", "events"),
+		"second": address("2", "events"),
+	}, received)
+	s.expectNoMessage(sub)
+}
+
+// channel returns a channel name specific to the test.
+func (s *Suite) channel(name string) string {
+	return fmt.Sprintf("%sbrokerstest.%s.%d", s.ChannelPrefix, name, time.Now().UnixNano())
+}
+
+func (s *Suite) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (s *Suite) subscribe(channel string) extensions.BrokerChannelSubscription {
+	sub, err := s.controller.Subscribe(context.Background(), channel)
+	s.Require().NoError(err)
+
+	if s.SubscriptionDelay > 0 {
+		time.Sleep(s.SubscriptionDelay)
+	}
+
+	return sub
+}
+
+func (s *Suite) publish(channel, payload string) {
+	s.Require().NoError(s.controller.Publish(context.Background(), channel, extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+		Payload: []byte(payload),
+	}))
+}
+
+func (s *Suite) receive(sub extensions.BrokerChannelSubscription) extensions.AcknowledgeableBrokerMessage {
+	select {
+	case msg, open := <-sub.MessagesChannel():
+		s.Require().True(open, "messages channel closed")
+		return msg
+	case <-time.After(s.timeout()):
+		s.FailNow("no message received")
+		return extensions.AcknowledgeableBrokerMessage{}
+	}
+}
+
+// receivePayloads receives and acknowledges the number of messages, returning
+// their payloads.
+func (s *Suite) receivePayloads(sub extensions.BrokerChannelSubscription, count int) []string {
+	payloads := make([]string, 0, count)
+	for len(payloads) < count {
+		msg := s.receive(sub)
+		msg.Ack()
+		payloads = append(payloads, string(msg.Payload))
+	}
+	return payloads
+}
+
+func (s *Suite) expectNoMessage(sub extensions.BrokerChannelSubscription) {
+	duration := s.NoMessageDuration
+	if duration <= 0 {
+		duration = DefaultNoMessageDuration
+	}
+
+	select {
+	case msg, open := <-sub.MessagesChannel():
+		if open {
+			s.FailNow("unexpected message received", "payload: %q", msg.Payload)
+		}
+	case <-time.After(duration):
+	}
+}
@@ -0,0 +1,94 @@
+package extensions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidChannelAddress is raised when a channel address doesn't match the
+// address of the channel, or has invalid parameters.
+var ErrInvalidChannelAddress = fmt.Errorf("%w: invalid channel address", ErrAsyncAPI)
+
+var (
+	channelParameterRegexp = regexp.MustCompile("{[^{}]*}")
+
+	// channelAddressParsers are the parsers of the channel addresses, by
+	// channel address.
+	channelAddressParsers sync.Map
+)
+
+// channelAddressParser parses the addresses of a channel.
+type channelAddressParser struct {
+	// regexp matches the addresses, with a group per parameter
+	regexp *regexp.Regexp
+	// names are the names of the parameters, in the groups order
+	names []string
+}
+
+// ParseChannelAddress parses the parameters values from an address of the
+// channel, based on the channel address with its parameters, like
+// 'user.{userId}.signedup'. The values are returned by parameter name.
+func ParseChannelAddress(channelAddr, addr string) (map[string]string, error) {
+	parser := getChannelAddressParser(channelAddr)
+
+	matches := parser.regexp.FindStringSubmatch(addr)
+	if matches == nil {
+		return nil, fmt.Errorf("%w: %q doesn't match %q", ErrInvalidChannelAddress, addr, channelAddr)
+	}
+
+	// Get the values, that should be the same for a parameter present twice
+	values := make(map[string]string, len(parser.names))
+	for i, name := range parser.names {
+		if v, exists := values[name]; exists && v != matches[i+1] {
+			return nil, fmt.Errorf("%w: %q has different values for parameter %q", ErrInvalidChannelAddress, addr, name)
+		}
+		values[name] = matches[i+1]
+	}
+
+	return values, nil
+}
+
+func getChannelAddressParser(channelAddr string) channelAddressParser {
+	if parser, ok := channelAddressParsers.Load(channelAddr); ok {
+		return parser.(channelAddressParser)
+	}
+
+	var parser channelAddressParser
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range channelParameterRegexp.FindAllStringIndex(channelAddr, -1) {
+		expr.WriteString(regexp.QuoteMeta(channelAddr[last:loc[0]]))
+		expr.WriteString("(.+?)")
+		parser.names = append(parser.names, channelAddr[loc[0]+1:loc[1]-1])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(channelAddr[last:]))
+	expr.WriteString("$")
+	parser.regexp = regexp.MustCompile(expr.String())
+
+	channelAddressParsers.Store(channelAddr, parser)
+	return parser
+}
+
+// ChannelAddressPattern returns the pattern matching all the addresses of the
+// channel, by replacing each parameter of the channel address by the wildcard,
+// like 'user.*.signedup' for 'user.{userId}.signedup'. The parameters have to
+// be whole tokens of the address, delimited by the separator, otherwise an
+// error wrapping ErrPatternSubscriptionNotSupported is returned.
+func ChannelAddressPattern(channelAddr, separator, wildcard string) (string, error) {
+	tokens := strings.Split(channelAddr, separator)
+	for i, token := range tokens {
+		switch params := channelParameterRegexp.FindAllString(token, -1); {
+		case len(params) == 0:
+		case len(params) == 1 && params[0] == token:
+			tokens[i] = wildcard
+		default:
+			return "", fmt.Errorf("%w: parameters of %q should be delimited by %q",
+				ErrPatternSubscriptionNotSupported, channelAddr, separator)
+		}
+	}
+	return strings.Join(tokens, separator), nil
+}
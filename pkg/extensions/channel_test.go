@@ -0,0 +1,75 @@
+package extensions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestChannelSuite(t *testing.T) {
+	suite.Run(t, new(ChannelSuite))
+}
+
+type ChannelSuite struct {
+	suite.Suite
+}
+
+func (suite *ChannelSuite) TestParseChannelAddress() {
+	cases := []struct {
+		Channel string
+		Addr    string
+		Values  map[string]string
+	}{
+		{Channel: "user.signedup", Addr: "user.signedup", Values: map[string]string{}},
+		{Channel: "user.{userId}.signedup", Addr: "user.1234.signedup", Values: map[string]string{"userId": "1234"}},
+		{Channel: "user/{userId}/{event}", Addr: "user/1234/signedup/v2", Values: map[string]string{
+			"userId": "1234", "event": "signedup/v2",
+		}},
+		{Channel: "{a}.{b}", Addr: "x.y.z", Values: map[string]string{"a": "x", "b": "y.z"}},
+		{Channel: "{id}.from.{id}", Addr: "1.from.1", Values: map[string]string{"id": "1"}},
+		{Channel: "price(+{currency})", Addr: "price(+EUR)", Values: map[string]string{"currency": "EUR"}},
+	}
+
+	for _, c := range cases {
+		values, err := ParseChannelAddress(c.Channel, c.Addr)
+		suite.Require().NoError(err, c.Addr)
+		suite.Require().Equal(c.Values, values, c.Addr)
+	}
+}
+
+func (suite *ChannelSuite) TestParseInvalidChannelAddress() {
+	cases := []struct {
+		Channel string
+		Addr    string
+	}{
+		{Channel: "user.signedup", Addr: "user.signedout"},
+		{Channel: "user.{userId}.signedup", Addr: "user..signedup"},
+		{Channel: "user.{userId}.signedup", Addr: "prefix.user.1234.signedup"},
+		{Channel: "{id}.from.{id}", Addr: "1.from.2"},
+	}
+
+	for _, c := range cases {
+		_, err := ParseChannelAddress(c.Channel, c.Addr)
+		suite.Require().ErrorIs(err, ErrInvalidChannelAddress, c.Addr)
+	}
+}
+
+func (suite *ChannelSuite) TestChannelAddressPattern() {
+	pattern, err := ChannelAddressPattern("user.{userId}.{event}", ".", "*")
+	suite.Require().NoError(err)
+	suite.Require().Equal("user.*.*", pattern)
+
+	pattern, err = ChannelAddressPattern("user/{userId}/signedup", "/", "+")
+	suite.Require().NoError(err)
+	suite.Require().Equal("user/+/signedup", pattern)
+
+	pattern, err = ChannelAddressPattern("user.signedup", ".", "*")
+	suite.Require().NoError(err)
+	suite.Require().Equal("user.signedup", pattern)
+
+	// Parameters that are not whole tokens
+	for _, addr := range []string{"user.id-{userId}.signedup", "user.{userId}{event}", "user/{userId}"} {
+		_, err = ChannelAddressPattern(addr, ".", "*")
+		suite.Require().ErrorIs(err, ErrPatternSubscriptionNotSupported, addr)
+	}
+}
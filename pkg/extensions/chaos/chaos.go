@@ -0,0 +1,277 @@
+// Package chaos injects failures in the messages published and received
+// through a broker controller, in order to test the idempotency and the
+// timeouts of the applications.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Controller)(nil)
+	_ extensions.DelayedPublisher  = (*Controller)(nil)
+	_ extensions.PatternSubscriber = (*Controller)(nil)
+)
+
+// Controller is a broker controller injecting failures in the messages of the
+// wrapped broker controller: latency on the publications and the deliveries,
+// dropped publications, and duplicated or reordered deliveries.
+type Controller struct {
+	broker        extensions.BrokerController
+	logger        extensions.Logger
+	minLatency    time.Duration
+	maxLatency    time.Duration
+	dropRate      float64
+	duplicateRate float64
+	reorderRate   float64
+	reorderWindow time.Duration
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// ControllerOption is a function that can be used to configure a chaos controller
+// Examples: WithLatency(), WithDropRate(), WithDuplicateRate().
+type ControllerOption func(controller *Controller) error
+
+// NewController creates a chaos controller around the broker controller.
+// Without options, the messages go through without failures.
+func NewController(broker extensions.BrokerController, options ...ControllerOption) (*Controller, error) {
+	// Creates default controller
+	controller := &Controller{
+		broker:        broker,
+		logger:        extensions.DummyLogger{},
+		reorderWindow: 100 * time.Millisecond,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	// Execute options
+	for _, option := range options {
+		if err := option(controller); err != nil {
+			return nil, fmt.Errorf("could not apply option to controller: %w", err)
+		}
+	}
+
+	return controller, nil
+}
+
+// WithLogger set a custom logger that will log the injected failures.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) error {
+		controller.logger = logger
+		return nil
+	}
+}
+
+// WithLatency set the latency added to the publications and to the deliveries,
+// picked randomly between minLatency and maxLatency for each message. As each
+// delivery has its own latency, the received messages can be reordered.
+func WithLatency(minLatency, maxLatency time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if minLatency < 0 || maxLatency < minLatency {
+			return fmt.Errorf("invalid latency: from %s to %s", minLatency, maxLatency)
+		}
+		controller.minLatency, controller.maxLatency = minLatency, maxLatency
+		return nil
+	}
+}
+
+// WithDropRate set the rate, between 0 and 1, of the published messages that
+// are dropped instead of being sent to the broker. The publication still
+// succeeds, as if the message has been lost by the broker.
+func WithDropRate(rate float64) ControllerOption {
+	return func(controller *Controller) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("drop rate should be between 0 and 1: %f", rate)
+		}
+		controller.dropRate = rate
+		return nil
+	}
+}
+
+// WithDuplicateRate set the rate, between 0 and 1, of the received messages that
+// are delivered twice. Only the first delivery acknowledges the message to
+// the broker.
+func WithDuplicateRate(rate float64) ControllerOption {
+	return func(controller *Controller) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("duplicate rate should be between 0 and 1: %f", rate)
+		}
+		controller.duplicateRate = rate
+		return nil
+	}
+}
+
+// WithReorderRate set the rate, between 0 and 1, of the received messages that
+// are delivered after the next message of the subscription. A message waits
+// at most for the window, before being delivered if no other message comes.
+func WithReorderRate(rate float64, window time.Duration) ControllerOption {
+	return func(controller *Controller) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("reorder rate should be between 0 and 1: %f", rate)
+		}
+		if window <= 0 {
+			return fmt.Errorf("reorder window should be positive: %s", window)
+		}
+		controller.reorderRate, controller.reorderWindow = rate, window
+		return nil
+	}
+}
+
+// WithSeed set the seed used to pick the latencies and the failures, in order
+// to get reproducible tests.
+func WithSeed(seed int64) ControllerOption {
+	return func(controller *Controller) error {
+		controller.rand = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// Publish a message to the broker, after the latency, unless it is dropped.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	if drop, err := c.beforePublication(ctx, channel, bm); drop || err != nil {
+		return err
+	}
+	return c.broker.Publish(ctx, channel, bm)
+}
+
+// PublishBatch publishes several messages to the broker at once, after the
+// latency, without the dropped ones.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	if err := c.wait(ctx, c.latency()); err != nil {
+		return err
+	}
+
+	kept := make([]extensions.BrokerMessage, 0, len(bms))
+	for _, bm := range bms {
+		if c.happens(c.dropRate) {
+			c.logFailure(ctx, channel, bm, "publication dropped")
+			continue
+		}
+		kept = append(kept, bm)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return c.broker.PublishBatch(ctx, channel, kept)
+}
+
+// PublishWithDelay publishes a message to the broker that will be delivered
+// after the delay, if the wrapped broker supports it, unless it is dropped.
+func (c *Controller) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	dp, ok := c.broker.(extensions.DelayedPublisher)
+	if !ok {
+		return extensions.ErrDelayedPublishNotSupported
+	}
+
+	if drop, err := c.beforePublication(ctx, channel, bm); drop || err != nil {
+		return err
+	}
+	return dp.PublishWithDelay(ctx, channel, bm, delay)
+}
+
+// Subscribe to messages from the broker, that are delivered with the latency,
+// duplicated or reordered.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := c.broker.Subscribe(ctx, channel)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.wrapSubscription(ctx, channel, sub), nil
+}
+
+// SubscribeToPattern subscribes to all the addresses matching the channel
+// address, if the wrapped broker supports it.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	sub, err := extensions.SubscribeToPattern(ctx, c.broker, channelAddr)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.wrapSubscription(ctx, channelAddr, sub), nil
+}
+
+// beforePublication waits for the latency, and states if the message should
+// be dropped.
+func (c *Controller) beforePublication(ctx context.Context, channel string, bm extensions.BrokerMessage) (bool, error) {
+	if err := c.wait(ctx, c.latency()); err != nil {
+		return false, err
+	}
+
+	if c.happens(c.dropRate) {
+		c.logFailure(ctx, channel, bm, "publication dropped")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (c *Controller) wrapSubscription(
+	ctx context.Context,
+	channel string,
+	sub extensions.BrokerChannelSubscription,
+) extensions.BrokerChannelSubscription {
+	d := &deliverer{controller: c, ctx: ctx, channel: channel}
+	return extensions.WrapBrokerChannelSubscription(sub, d.transmit)
+}
+
+// latency returns a random latency between the minimum and maximum latencies.
+func (c *Controller) latency() time.Duration {
+	if c.maxLatency == 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.minLatency + time.Duration(c.rand.Int63n(int64(c.maxLatency-c.minLatency)+1))
+}
+
+// happens states randomly if an event with this rate happens.
+func (c *Controller) happens(rate float64) bool {
+	if rate == 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rand.Float64() < rate
+}
+
+// wait waits for the duration, unless the context is done before.
+func (c *Controller) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", extensions.ErrContextCanceled, ctx.Err())
+	}
+}
+
+func (c *Controller) logFailure(ctx context.Context, channel string, bm extensions.BrokerMessage, failure string) {
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsChannel, channel)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, bm)
+	c.logger.Warning(ctx, "chaos: "+failure)
+}
@@ -0,0 +1,136 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestChaosSuite(t *testing.T) {
+	suite.Run(t, new(ChaosSuite))
+}
+
+type ChaosSuite struct {
+	suite.Suite
+	broker *inmemory.Controller
+}
+
+func (suite *ChaosSuite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+}
+
+// exchange publishes n messages through the chaos controller, and returns the
+// payloads of the messages received before the timeout.
+func (suite *ChaosSuite) exchange(c *Controller, n int, timeout time.Duration) []string {
+	ctx := context.Background()
+
+	sub, err := c.Subscribe(ctx, "chan")
+	suite.Require().NoError(err)
+	defer sub.Cancel(ctx)
+
+	for i := 1; i <= n; i++ {
+		suite.Require().NoError(c.Publish(ctx, "chan", extensions.BrokerMessage{
+			Headers: map[string][]byte{},
+			Payload: []byte(fmt.Sprint(i)),
+		}))
+	}
+
+	var received []string
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-sub.MessagesChannel():
+			received = append(received, string(msg.Payload))
+			msg.Ack()
+		case <-deadline:
+			return received
+		}
+	}
+}
+
+func (suite *ChaosSuite) TestWithoutFailures() {
+	c, err := NewController(suite.broker)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal([]string{"1", "2", "3"}, suite.exchange(c, 3, 50*time.Millisecond))
+}
+
+func (suite *ChaosSuite) TestDrop() {
+	c, err := NewController(suite.broker, WithDropRate(1))
+	suite.Require().NoError(err)
+
+	suite.Require().Empty(suite.exchange(c, 3, 50*time.Millisecond))
+	suite.Require().Empty(suite.broker.Published("chan"))
+}
+
+func (suite *ChaosSuite) TestDuplicate() {
+	c, err := NewController(suite.broker, WithDuplicateRate(1))
+	suite.Require().NoError(err)
+
+	suite.Require().Equal([]string{"1", "1", "2", "2"}, suite.exchange(c, 2, 50*time.Millisecond))
+
+	// The messages are only acknowledged once to the broker
+	deliveries := suite.broker.Deliveries()
+	suite.Require().Len(deliveries, 2)
+	for _, d := range deliveries {
+		suite.Require().Equal(inmemory.DeliveryAcked, d.Status)
+	}
+}
+
+func (suite *ChaosSuite) TestReorder() {
+	c, err := NewController(suite.broker, WithReorderRate(1, 20*time.Millisecond))
+	suite.Require().NoError(err)
+
+	// The held message is sent after the next one, and the last one after the window
+	suite.Require().Equal([]string{"2", "1", "3"}, suite.exchange(c, 3, 100*time.Millisecond))
+}
+
+func (suite *ChaosSuite) TestLatency() {
+	c, err := NewController(suite.broker, WithLatency(20*time.Millisecond, 30*time.Millisecond))
+	suite.Require().NoError(err)
+
+	start := time.Now()
+	suite.Require().NoError(c.Publish(context.Background(), "chan", extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+	}))
+	suite.Require().GreaterOrEqual(time.Since(start), 20*time.Millisecond)
+
+	// The latency is canceled with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.Publish(ctx, "chan", extensions.BrokerMessage{Headers: map[string][]byte{}})
+	suite.Require().ErrorIs(err, extensions.ErrContextCanceled)
+
+	suite.Require().Len(suite.exchange(c, 5, 200*time.Millisecond), 5)
+}
+
+func (suite *ChaosSuite) TestSeed() {
+	received := make([][]string, 2)
+	for i := range received {
+		c, err := NewController(suite.broker, WithSeed(42), WithDropRate(0.5), WithDuplicateRate(0.5))
+		suite.Require().NoError(err)
+		received[i] = suite.exchange(c, 20, 50*time.Millisecond)
+	}
+
+	suite.Require().Equal(received[0], received[1])
+}
+
+func (suite *ChaosSuite) TestInvalidOptions() {
+	for _, option := range []ControllerOption{
+		WithLatency(-time.Second, 0),
+		WithLatency(time.Second, time.Millisecond),
+		WithDropRate(1.5),
+		WithDuplicateRate(-1),
+		WithReorderRate(0.5, 0),
+	} {
+		_, err := NewController(suite.broker, option)
+		suite.Require().Error(err)
+	}
+}
@@ -0,0 +1,91 @@
+package chaos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// deliverer injects the failures in the deliveries of a subscription.
+type deliverer struct {
+	controller *Controller
+	ctx        context.Context
+	channel    string
+
+	mu   sync.Mutex
+	held *heldMessage
+}
+
+// heldMessage is a message delivered after the next one of the subscription.
+type heldMessage struct {
+	msg   extensions.AcknowledgeableBrokerMessage
+	timer *time.Timer
+}
+
+// transmit delivers the message received from the broker, and its duplicate.
+func (d *deliverer) transmit(
+	msg extensions.AcknowledgeableBrokerMessage,
+	send func(extensions.AcknowledgeableBrokerMessage),
+) {
+	deliveries := []extensions.AcknowledgeableBrokerMessage{msg}
+	if d.controller.happens(d.controller.duplicateRate) {
+		d.controller.logFailure(d.ctx, d.channel, msg.BrokerMessage, "delivery duplicated")
+
+		// The duplicate has no acknowledgment, to acknowledge the message only
+		// once to the broker
+		duplicate := extensions.NewAcknowledgeableBrokerMessage(msg.BrokerMessage, nil)
+		duplicate.Metadata = msg.Metadata
+		deliveries = append(deliveries, duplicate)
+	}
+
+	for _, m := range deliveries {
+		latency := d.controller.latency()
+		if latency <= 0 {
+			d.deliver(m, send)
+			continue
+		}
+
+		m := m
+		time.AfterFunc(latency, func() { d.deliver(m, send) })
+	}
+}
+
+// deliver sends the message, or holds it until the next one is sent.
+func (d *deliverer) deliver(
+	msg extensions.AcknowledgeableBrokerMessage,
+	send func(extensions.AcknowledgeableBrokerMessage),
+) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Send the held message after this one
+	if held := d.held; held != nil {
+		held.timer.Stop()
+		d.held = nil
+
+		send(msg)
+		send(held.msg)
+		return
+	}
+
+	if !d.controller.happens(d.controller.reorderRate) {
+		send(msg)
+		return
+	}
+
+	d.controller.logFailure(d.ctx, d.channel, msg.BrokerMessage, "delivery reordered")
+	held := &heldMessage{msg: msg}
+	held.timer = time.AfterFunc(d.controller.reorderWindow, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		// Send the message if no other message came during the window
+		if d.held == held {
+			d.held = nil
+			send(held.msg)
+		}
+	})
+	d.held = held
+}
@@ -0,0 +1,185 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Content types of the codecs registered by default.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeXML      = "application/xml"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeCBOR     = "application/cbor"
+	ContentTypeMsgPack  = "application/msgpack"
+)
+
+// ErrCodecUnsupportedValue is raised when a codec can't encode or decode a
+// payload of this type.
+var ErrCodecUnsupportedValue = fmt.Errorf("%w: value not supported by codec", ErrAsyncAPI)
+
+// Codec encodes and decodes the message payloads for a content type.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[string]Codec{
+		ContentTypeJSON:           JSONCodec{},
+		ContentTypeXML:            XMLCodec{},
+		"text/xml":                XMLCodec{},
+		ContentTypeProtobuf:       ProtobufCodec{},
+		"application/protobuf":    ProtobufCodec{},
+		ContentTypeCBOR:           CBORCodec{},
+		ContentTypeMsgPack:        MsgPackCodec{},
+		"application/x-msgpack":   MsgPackCodec{},
+		"application/vnd.msgpack": MsgPackCodec{},
+	}
+)
+
+// RegisterCodec registers the codec used for the payloads with the content
+// type, replacing the existing one if any.
+func RegisterCodec(contentType string, codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[NormalizeContentType(contentType)] = codec
+}
+
+// CodecFor returns the codec used for the payloads with the content type.
+//
+// Parameters of the content type are ignored, and content types with a
+// structured syntax suffix (like 'application/cloudevents+json') use the codec
+// of the suffix if they have none. Payloads without content type, or without
+// codec for it, are encoded in JSON.
+func CodecFor(contentType string) Codec {
+	contentType = NormalizeContentType(contentType)
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	if codec, exists := codecs[contentType]; exists {
+		return codec
+	}
+
+	if i := strings.LastIndex(contentType, "+"); i >= 0 {
+		if codec, exists := codecs["application/"+contentType[i+1:]]; exists {
+			return codec
+		}
+	}
+
+	return JSONCodec{}
+}
+
+// MarshalPayload encodes the payload with the codec of the content type.
+func MarshalPayload(contentType string, v any) ([]byte, error) {
+	return CodecFor(contentType).Marshal(v)
+}
+
+// UnmarshalPayload decodes the payload with the codec of the content type.
+func UnmarshalPayload(contentType string, data []byte, v any) error {
+	return CodecFor(contentType).Unmarshal(data, v)
+}
+
+// NormalizeContentType removes the parameters of the content type, and puts it
+// in lower case.
+func NormalizeContentType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// JSONCodec is the codec for JSON payloads.
+type JSONCodec struct{}
+
+// Marshal encodes the value in JSON.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the JSON data into the value.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// XMLCodec is the codec for XML payloads. As generated types have no XML tags,
+// the elements have the name of the Go types and fields.
+type XMLCodec struct{}
+
+// Marshal encodes the value in XML.
+func (XMLCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+// Unmarshal decodes the XML data into the value.
+func (XMLCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+// ProtobufCodec is the codec for Protocol Buffers payloads. It only supports
+// values implementing proto.Message.
+type ProtobufCodec struct{}
+
+// Marshal encodes the value in Protocol Buffers.
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not a protobuf message", ErrCodecUnsupportedValue, v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes the Protocol Buffers data into the value.
+func (ProtobufCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a protobuf message", ErrCodecUnsupportedValue, v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// CBORCodec is the codec for CBOR payloads, using the JSON tags of the types.
+type CBORCodec struct{}
+
+// Marshal encodes the value in CBOR.
+func (CBORCodec) Marshal(v any) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+// Unmarshal decodes the CBOR data into the value.
+func (CBORCodec) Unmarshal(data []byte, v any) error {
+	return cbor.Unmarshal(data, v)
+}
+
+// MsgPackCodec is the codec for MessagePack payloads, using the JSON tags of
+// the types.
+type MsgPackCodec struct{}
+
+// Marshal encodes the value in MessagePack.
+func (MsgPackCodec) Marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := msgpack.NewEncoder(&b)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal decodes the MessagePack data into the value.
+func (MsgPackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
@@ -0,0 +1,89 @@
+package extensions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecSuite(t *testing.T) {
+	suite.Run(t, new(CodecSuite))
+}
+
+type CodecSuite struct {
+	suite.Suite
+}
+
+type codecPayload struct {
+	Name  string `json:"name"`
+	Count *int   `json:"count,omitempty"`
+}
+
+func (suite *CodecSuite) TestRoundTrip() {
+	count := 3
+	for _, ct := range []string{ContentTypeJSON, ContentTypeXML, ContentTypeCBOR, ContentTypeMsgPack} {
+		data, err := MarshalPayload(ct, codecPayload{Name: "name", Count: &count})
+		suite.Require().NoError(err, ct)
+
+		var p codecPayload
+		suite.Require().NoError(UnmarshalPayload(ct, data, &p), ct)
+		suite.Require().Equal(codecPayload{Name: "name", Count: &count}, p, ct)
+	}
+}
+
+func (suite *CodecSuite) TestCodecFor() {
+	suite.Require().Equal(JSONCodec{}, CodecFor(""))
+	suite.Require().Equal(JSONCodec{}, CodecFor("application/json; charset=utf-8"))
+	suite.Require().Equal(JSONCodec{}, CodecFor("text/plain"))
+	suite.Require().Equal(XMLCodec{}, CodecFor("application/atom+xml"))
+	suite.Require().Equal(CBORCodec{}, CodecFor("Application/CBOR"))
+	suite.Require().Equal(MsgPackCodec{}, CodecFor("application/x-msgpack"))
+}
+
+func (suite *CodecSuite) TestJSONTagsWithBinaryFormats() {
+	for _, ct := range []string{ContentTypeCBOR, ContentTypeMsgPack} {
+		data, err := MarshalPayload(ct, codecPayload{Name: "name"})
+		suite.Require().NoError(err, ct)
+
+		var m map[string]any
+		suite.Require().NoError(UnmarshalPayload(ct, data, &m), ct)
+		suite.Require().Equal(map[string]any{"name": "name"}, m, ct)
+	}
+}
+
+func (suite *CodecSuite) TestProtobuf() {
+	data, err := MarshalPayload(ContentTypeProtobuf, wrapperspb.String("hello"))
+	suite.Require().NoError(err)
+
+	var s wrapperspb.StringValue
+	suite.Require().NoError(UnmarshalPayload(ContentTypeProtobuf, data, &s))
+	suite.Require().Equal("hello", s.GetValue())
+
+	_, err = MarshalPayload(ContentTypeProtobuf, codecPayload{})
+	suite.Require().ErrorIs(err, ErrCodecUnsupportedValue)
+}
+
+// upperCodec is a codec encoding strings in upper case.
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v any) ([]byte, error) {
+	return []byte("UPPER:" + v.(string)), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v any) error {
+	*v.(*string) = string(data[len("UPPER:"):])
+	return nil
+}
+
+func (suite *CodecSuite) TestRegisterCodec() {
+	RegisterCodec("application/vnd.upper; version=1", upperCodec{})
+
+	data, err := MarshalPayload("application/vnd.upper", "hello")
+	suite.Require().NoError(err)
+	suite.Require().Equal("UPPER:hello", string(data))
+
+	var s string
+	suite.Require().NoError(UnmarshalPayload("application/vnd.upper", data, &s))
+	suite.Require().Equal("hello", s)
+}
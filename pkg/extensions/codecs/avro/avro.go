@@ -0,0 +1,373 @@
+// Package avro provides the codec of the payloads with an Avro schema, and the
+// conversions between their JSON and Avro binary representations.
+package avro
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	hamba "github.com/hamba/avro/v2"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Content types of the Avro payloads.
+const (
+	// ContentType is the content type of the payloads encoded in Avro binary.
+	ContentType = "application/vnd.apache.avro"
+	// ContentTypeJSON is the content type of the payloads encoded in JSON.
+	ContentTypeJSON = "application/vnd.apache.avro+json"
+)
+
+// dateLayout is the layout of the dates in the JSON payloads.
+const dateLayout = "2006-01-02"
+
+// Codec encodes and decodes the payloads in Avro binary with a schema. Payloads
+// are converted from and to their JSON representation, so the generated types
+// can be used without Avro tags.
+type Codec struct {
+	Schema hamba.Schema
+}
+
+// NewCodec creates a codec from the Avro schema.
+func NewCodec(schema string) (Codec, error) {
+	s, err := hamba.Parse(schema)
+	if err != nil {
+		return Codec{}, fmt.Errorf("invalid Avro schema: %w", err)
+	}
+	return Codec{Schema: s}, nil
+}
+
+// MustNewCodec creates a codec from the Avro schema, and panics if the schema
+// is invalid.
+func MustNewCodec(schema string) Codec {
+	c, err := NewCodec(schema)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Marshal encodes the value in Avro binary.
+func (c Codec) Marshal(v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(c.Schema, payload)
+}
+
+// Unmarshal decodes the Avro binary data into the value.
+func (c Codec) Unmarshal(data []byte, v any) error {
+	payload, err := ToJSON(c.Schema, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// For returns the codec used for the payloads with the content type: the Avro
+// codec for the binary content types, or the codec registered for the others
+// (like ContentTypeJSON).
+func (c Codec) For(contentType string) extensions.Codec {
+	switch extensions.NormalizeContentType(contentType) {
+	case "", ContentType, ContentType + "+binary", "application/avro", "avro/binary":
+		return c
+	default:
+		return extensions.CodecFor(contentType)
+	}
+}
+
+// FromJSON encodes the JSON payload in Avro binary with the schema.
+func FromJSON(schema hamba.Schema, payload []byte) ([]byte, error) {
+	// Decode the JSON payload, keeping the numbers as they are
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON payload: %w", err)
+	}
+
+	native, err := toAvro(schema, v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := hamba.Marshal(schema, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Avro payload: %w", err)
+	}
+	return data, nil
+}
+
+// ToJSON decodes the Avro binary data with the schema, and returns it as JSON.
+func ToJSON(schema hamba.Schema, data []byte) ([]byte, error) {
+	var native any
+	if err := hamba.Unmarshal(schema, data, &native); err != nil {
+		return nil, fmt.Errorf("failed to decode Avro payload: %w", err)
+	}
+
+	return json.Marshal(fromAvro(schema, native))
+}
+
+// toAvro converts a value decoded from JSON to the type expected by the Avro
+// encoder for the schema.
+//
+//nolint:funlen,cyclop // this is a type switch on all Avro types
+func toAvro(schema hamba.Schema, v any) (any, error) {
+	switch s := schema.(type) {
+	case *hamba.RefSchema:
+		return toAvro(s.Schema(), v)
+	case *hamba.RecordSchema:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object for record %q, got %T", s.FullName(), v)
+		}
+
+		record := make(map[string]any, len(obj))
+		for _, f := range s.Fields() {
+			fv, exists := obj[f.Name()]
+			if !exists {
+				// The encoder will use the default value, if any
+				continue
+			}
+
+			native, err := toAvro(f.Type(), fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name(), err)
+			}
+			record[f.Name()] = native
+		}
+		return record, nil
+	case *hamba.ArraySchema:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+
+		items := make([]any, 0, len(arr))
+		for _, iv := range arr {
+			native, err := toAvro(s.Items(), iv)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, native)
+		}
+		return items, nil
+	case *hamba.MapSchema:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object for map, got %T", v)
+		}
+
+		values := make(map[string]any, len(obj))
+		for k, mv := range obj {
+			native, err := toAvro(s.Values(), mv)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+			values[k] = native
+		}
+		return values, nil
+	case *hamba.UnionSchema:
+		return unionToAvro(s, v)
+	case *hamba.EnumSchema:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string for enum %q, got %T", s.FullName(), v)
+		}
+		return str, nil
+	case *hamba.FixedSchema:
+		b, err := bytesToAvro(v)
+		if err != nil {
+			return nil, err
+		} else if len(b) != s.Size() {
+			return nil, fmt.Errorf("expected %d bytes for fixed %q, got %d", s.Size(), s.FullName(), len(b))
+		}
+
+		fixed := reflect.New(reflect.ArrayOf(s.Size(), reflect.TypeOf(byte(0)))).Elem()
+		reflect.Copy(fixed, reflect.ValueOf(b))
+		return fixed.Interface(), nil
+	case *hamba.PrimitiveSchema:
+		return primitiveToAvro(s, v)
+	default:
+		return nil, fmt.Errorf("unsupported Avro schema: %s", schema.Type())
+	}
+}
+
+// unionToAvro converts the value to the first type of the union it matches.
+func unionToAvro(s *hamba.UnionSchema, v any) (any, error) {
+	if v == nil {
+		if s.Nullable() || s.Types()[0].Type() == hamba.Null {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("null is not allowed in union")
+	}
+
+	for _, t := range s.Types() {
+		if t.Type() == hamba.Null {
+			continue
+		}
+
+		native, err := toAvro(t, v)
+		if err == nil {
+			return map[string]any{unionTypeName(t): native}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("value of type %T doesn't match any type of the union", v)
+}
+
+// unionTypeName returns the name of the type used as key by the Avro encoder
+// for the union values.
+func unionTypeName(schema hamba.Schema) string {
+	if ref, ok := schema.(*hamba.RefSchema); ok {
+		schema = ref.Schema()
+	}
+	if named, ok := schema.(hamba.NamedSchema); ok {
+		return named.FullName()
+	}
+	if p, ok := schema.(*hamba.PrimitiveSchema); ok && p.Logical() != nil {
+		return string(p.Type()) + "." + string(p.Logical().Type())
+	}
+	return string(schema.Type())
+}
+
+//nolint:cyclop // this is a type switch on all Avro primitive types
+func primitiveToAvro(s *hamba.PrimitiveSchema, v any) (any, error) {
+	// Logical types represented as strings in JSON
+	if s.Logical() != nil {
+		if str, ok := v.(string); ok {
+			switch s.Logical().Type() {
+			case hamba.Date:
+				return time.Parse(dateLayout, str)
+			case hamba.TimestampMillis, hamba.TimestampMicros:
+				return time.Parse(time.RFC3339Nano, str)
+			}
+		}
+	}
+
+	switch s.Type() {
+	case hamba.Null:
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return nil, nil
+	case hamba.Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case hamba.String:
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+	case hamba.Bytes:
+		return bytesToAvro(v)
+	case hamba.Int, hamba.Long:
+		if n, ok := v.(json.Number); ok {
+			i, err := n.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("expected an integer, got %s", n)
+			}
+			if s.Type() == hamba.Long {
+				return i, nil
+			} else if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, fmt.Errorf("integer %d overflows Avro int", i)
+			}
+			return int(i), nil
+		}
+	case hamba.Float, hamba.Double:
+		if n, ok := v.(json.Number); ok {
+			f, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("expected a number, got %s", n)
+			}
+			if s.Type() == hamba.Float {
+				return float32(f), nil
+			}
+			return f, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected %s, got %T", s.Type(), v)
+}
+
+// bytesToAvro decodes the bytes from their base64 representation in JSON.
+func bytesToAvro(v any) ([]byte, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a base64 string for bytes, got %T", v)
+	}
+	return base64.StdEncoding.DecodeString(str)
+}
+
+// fromAvro converts a value decoded with the Avro schema to its JSON representation.
+func fromAvro(schema hamba.Schema, v any) any {
+	switch s := schema.(type) {
+	case *hamba.RefSchema:
+		return fromAvro(s.Schema(), v)
+	case *hamba.RecordSchema:
+		record, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for _, f := range s.Fields() {
+			if fv, exists := record[f.Name()]; exists {
+				record[f.Name()] = fromAvro(f.Type(), fv)
+			}
+		}
+		return record
+	case *hamba.ArraySchema:
+		items, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i, iv := range items {
+			items[i] = fromAvro(s.Items(), iv)
+		}
+		return items
+	case *hamba.MapSchema:
+		values, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for k, mv := range values {
+			values[k] = fromAvro(s.Values(), mv)
+		}
+		return values
+	case *hamba.UnionSchema:
+		// Non-null union values are decoded as a map with the type name as key
+		union, ok := v.(map[string]any)
+		if !ok || len(union) != 1 {
+			return v
+		}
+		for _, t := range s.Types() {
+			if uv, exists := union[unionTypeName(t)]; exists {
+				return fromAvro(t, uv)
+			}
+		}
+		return v
+	case *hamba.FixedSchema:
+		fixed := reflect.ValueOf(v)
+		if fixed.Kind() != reflect.Array {
+			return v
+		}
+		b := make([]byte, fixed.Len())
+		reflect.Copy(reflect.ValueOf(b), fixed)
+		return b
+	case *hamba.PrimitiveSchema:
+		if t, ok := v.(time.Time); ok {
+			if s.Logical() != nil && s.Logical().Type() == hamba.Date {
+				return t.Format(dateLayout)
+			}
+			return t.Format(time.RFC3339Nano)
+		}
+		return v
+	default:
+		return v
+	}
+}
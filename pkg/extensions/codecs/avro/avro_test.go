@@ -0,0 +1,75 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "quantity", "type": "int", "default": 1},
+		{"name": "comment", "type": ["null", "string"], "default": null}
+	]
+}`
+
+type order struct {
+	ID       int64   `json:"id"`
+	Quantity *int32  `json:"quantity,omitempty"`
+	Comment  *string `json:"comment,omitempty"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c, err := NewCodec(orderSchema)
+	require.NoError(t, err)
+
+	comment := "fragile"
+	data, err := c.Marshal(order{ID: 42, Comment: &comment})
+	require.NoError(t, err)
+
+	var o order
+	require.NoError(t, c.Unmarshal(data, &o))
+	assert.Equal(t, int64(42), o.ID)
+	require.NotNil(t, o.Quantity)
+	assert.Equal(t, int32(1), *o.Quantity)
+	assert.Equal(t, &comment, o.Comment)
+}
+
+func TestCodecErrors(t *testing.T) {
+	_, err := NewCodec(`{"type": "unknown"}`)
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustNewCodec("{") })
+
+	c := MustNewCodec(orderSchema)
+	_, err = c.Marshal(map[string]any{"id": "42"})
+	assert.Error(t, err)
+
+	// Data with a truncated comment
+	assert.Error(t, c.Unmarshal([]byte{0x54, 0x02, 0x02, 0x14}, &order{}))
+}
+
+func TestCodecFor(t *testing.T) {
+	c := MustNewCodec(orderSchema)
+
+	for _, ct := range []string{"", ContentType, "application/vnd.apache.avro+binary", "avro/binary"} {
+		assert.Equal(t, c, c.For(ct), ct)
+	}
+	assert.Equal(t, extensions.JSONCodec{}, c.For(ContentTypeJSON))
+	assert.Equal(t, extensions.CBORCodec{}, c.For(extensions.ContentTypeCBOR))
+}
+
+func TestJSONConversion(t *testing.T) {
+	c := MustNewCodec(`{"type": "map", "values": {"type": "int", "logicalType": "date"}}`)
+
+	data, err := FromJSON(c.Schema, []byte(`{"start":"2024-05-01"}`))
+	require.NoError(t, err)
+
+	payload, err := ToJSON(c.Schema, data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"start":"2024-05-01"}`, string(payload))
+}
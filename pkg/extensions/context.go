@@ -20,10 +20,68 @@ const (
 	// ContextKeyIsDirection is the direction this data is coming from.
 	// It can be either "publication" or "reception".
 	ContextKeyIsDirection ContextKey = Prefix + "operation"
+	// ContextKeyIsOperation is the name of the operation this data is coming
+	// from, as named in the generated code (like "PingOperation").
+	ContextKeyIsOperation ContextKey = Prefix + "operation-name"
 	// ContextKeyIsBrokerMessage is the message that has been sent or received from/to the broker.
 	ContextKeyIsBrokerMessage ContextKey = Prefix + "broker-message"
 	// ContextKeyIsCorrelationID is the correlation ID of the message.
 	ContextKeyIsCorrelationID ContextKey = Prefix + "correlationID"
+	// ContextKeyIsChannelBindings is the protocol-specific information of the
+	// channel, as ChannelBindings.
+	ContextKeyIsChannelBindings ContextKey = Prefix + "channel-bindings"
+	// ContextKeyIsOperationBindings is the protocol-specific information of the
+	// operation, as OperationBindings.
+	ContextKeyIsOperationBindings ContextKey = Prefix + "operation-bindings"
+	// ContextKeyIsPriority is the priority of the message to publish, as an
+	// uint8. It is honored by the brokers supporting message priorities and
+	// takes precedence over the priority from the operation bindings.
+	ContextKeyIsPriority ContextKey = Prefix + "priority"
+	// ContextKeyIsDelay is the delay after which the message to publish should
+	// be delivered, as a time.Duration. It requires a broker controller
+	// implementing DelayedPublisher.
+	ContextKeyIsDelay ContextKey = Prefix + "delay"
+	// ContextKeyIsHeadersFilter is the headers, as a map[string]string, that the
+	// received messages should have. It is set from the message headers with a
+	// constant value, and honored by the brokers supporting content-based
+	// routing.
+	ContextKeyIsHeadersFilter ContextKey = Prefix + "headers-filter"
+	// ContextKeyIsLastValueCache is the last value cache of the channel, as
+	// LastValueCache. It is honored by the brokers able to keep the last
+	// messages of each channel address.
+	ContextKeyIsLastValueCache ContextKey = Prefix + "last-value-cache"
+	// ContextKeyIsReplyAddressHeader is the header, as a string, of the received
+	// messages that contains the address where to send the reply. It is set
+	// on the subscriptions with a dynamic reply address in the headers, so the
+	// brokers implementing BrokerRequester can set their own reply address.
+	ContextKeyIsReplyAddressHeader ContextKey = Prefix + "reply-address-header"
+	// ContextKeyIsPayloadSchema is the JSON Schema of the payload of the
+	// message, as a string, in order to validate the payload at runtime.
+	ContextKeyIsPayloadSchema ContextKey = Prefix + "payload-schema"
+	// ContextKeyIsAcknowledgment is the acknowledgment of the received message,
+	// as a *MessageAcknowledgment, used by AckFromContext and NakFromContext.
+	ContextKeyIsAcknowledgment ContextKey = Prefix + "acknowledgment"
+	// ContextKeyIsMessageMetadata is the delivery metadata of the received
+	// message, as a MessageMetadata, used by MessageMetadataFromContext.
+	ContextKeyIsMessageMetadata ContextKey = Prefix + "message-metadata"
+	// ContextKeyIsMessageID is the identifier of the sent message, as set in
+	// its 'messageId' header.
+	ContextKeyIsMessageID ContextKey = Prefix + "message-id"
+	// ContextKeyIsSentAt is the publication time of the sent message, as set in
+	// its 'sentAt' header.
+	ContextKeyIsSentAt ContextKey = Prefix + "sent-at"
+	// ContextKeyIsTenant is the tenant of the received message, as a string, as
+	// set in its 'tenantId' header.
+	ContextKeyIsTenant ContextKey = Prefix + "tenant"
+	// ContextKeyIsEndOfStream is set, as a bool, when sending the message
+	// ending a reply stream.
+	ContextKeyIsEndOfStream ContextKey = Prefix + "end-of-stream"
+	// ContextKeyIsOutboxTransaction is the database transaction in which the
+	// messages are stored by the outbox, as a *sql.Tx.
+	ContextKeyIsOutboxTransaction ContextKey = Prefix + "outbox-transaction"
+	// ContextKeyIsInboxTransaction is the database transaction in which a
+	// received message is recorded as processed by the inbox, as a *sql.Tx.
+	ContextKeyIsInboxTransaction ContextKey = Prefix + "inbox-transaction"
 )
 
 // String returns the string representation of the key.
@@ -0,0 +1,162 @@
+package extensions
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// SecuritySchemeUserPassword is the type of the security schemes with a
+	// username and a password.
+	SecuritySchemeUserPassword = "userPassword"
+	// SecuritySchemePlain is the type of the SASL PLAIN security schemes.
+	SecuritySchemePlain = "plain"
+	// SecuritySchemeScramSha256 is the type of the SASL SCRAM-SHA-256 security
+	// schemes.
+	SecuritySchemeScramSha256 = "scramSha256"
+	// SecuritySchemeScramSha512 is the type of the SASL SCRAM-SHA-512 security
+	// schemes.
+	SecuritySchemeScramSha512 = "scramSha512"
+	// SecuritySchemeX509 is the type of the mutual TLS security schemes.
+	SecuritySchemeX509 = "X509"
+	// SecuritySchemeOAuth2 is the type of the OAuth2 security schemes, where
+	// the client credentials flow is used.
+	SecuritySchemeOAuth2 = "oauth2"
+)
+
+// Credentials are the credentials used to connect to a server, depending on
+// the types of its security schemes: only the ones of the schemes to use have
+// to be set.
+type Credentials struct {
+	// UserPassword is used by the userPassword, plain, scramSha256 and
+	// scramSha512 security schemes.
+	UserPassword *UserPasswordCredentials
+	// X509 is used by the X509 security schemes.
+	X509 *X509Credentials
+	// OAuth2 is used by the oauth2 security schemes.
+	OAuth2 *OAuth2ClientCredentials
+}
+
+// UserPasswordCredentials are a username and a password.
+type UserPasswordCredentials struct {
+	Username string
+	Password string
+}
+
+// X509Credentials are the PEM files of a client certificate and key, and of
+// the CA certificate used to verify the server.
+type X509Credentials struct {
+	CertFile string
+	KeyFile  string
+	// CAFile is the CA certificate used to verify the server. If not set,
+	// the system certificates are used.
+	CAFile string
+}
+
+// TLSConfig creates the TLS configuration authenticating with the certificate.
+func (c X509Credentials) TLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificate found in %q", c.CAFile)
+		}
+	}
+
+	return config, nil
+}
+
+// OAuth2ClientCredentials are the credentials of an OAuth2 client, used to get
+// access tokens with the client credentials flow.
+type OAuth2ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+	// TokenURL is the URL of the token endpoint. If not set, the one of the
+	// security scheme is used.
+	TokenURL string
+	// Scopes are the requested scopes. If not set, the ones of the security
+	// scheme are used.
+	Scopes []string
+}
+
+// Token requests an access token for the security scheme.
+func (c OAuth2ClientCredentials) Token(ctx context.Context, scheme SecurityScheme) (string, error) {
+	config := clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		TokenURL:     c.TokenURL,
+		Scopes:       c.Scopes,
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = scheme.TokenURL
+	}
+	if config.Scopes == nil {
+		config.Scopes = scheme.Scopes
+	}
+
+	token, err := config.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OAuth2 token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// satisfy returns true if the credentials can be used for the security scheme.
+func (c Credentials) satisfy(scheme SecurityScheme) bool {
+	switch scheme.Type {
+	case SecuritySchemeUserPassword, SecuritySchemePlain, SecuritySchemeScramSha256, SecuritySchemeScramSha512:
+		return c.UserPassword != nil
+	case SecuritySchemeX509:
+		return c.X509 != nil
+	case SecuritySchemeOAuth2:
+		return c.OAuth2 != nil
+	default:
+		return false
+	}
+}
+
+// SelectSecurityScheme returns the first of the security schemes whose type is
+// one of the supported ones and that can be used with the credentials. It
+// returns nil if there is no security scheme, and ErrMissingCredentials if
+// none of them can be used.
+func SelectSecurityScheme(
+	schemes []SecurityScheme,
+	credentials Credentials,
+	supported ...string,
+) (*SecurityScheme, error) {
+	if len(schemes) == 0 {
+		return nil, nil
+	}
+
+	types := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		for _, t := range supported {
+			if scheme.Type == t && credentials.satisfy(scheme) {
+				return &scheme, nil
+			}
+		}
+		types = append(types, scheme.Type)
+	}
+
+	return nil, fmt.Errorf("%w: no supported credentials given for the security schemes %s",
+		ErrMissingCredentials, strings.Join(types, ", "))
+}
@@ -0,0 +1,80 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCredentialsSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsSuite))
+}
+
+type CredentialsSuite struct {
+	suite.Suite
+}
+
+func (suite *CredentialsSuite) TestSelectSecurityScheme() {
+	schemes := []SecurityScheme{
+		{Type: SecuritySchemeX509},
+		{Type: SecuritySchemeScramSha512},
+		{Type: SecuritySchemeUserPassword},
+	}
+
+	// No security scheme
+	scheme, err := SelectSecurityScheme(nil, Credentials{}, SecuritySchemeUserPassword)
+	suite.Require().NoError(err)
+	suite.Require().Nil(scheme)
+
+	// First scheme with credentials
+	creds := Credentials{UserPassword: &UserPasswordCredentials{Username: "user", Password: "password"}}
+	scheme, err = SelectSecurityScheme(schemes, creds, SecuritySchemeScramSha512, SecuritySchemeUserPassword)
+	suite.Require().NoError(err)
+	suite.Require().Equal(SecuritySchemeScramSha512, scheme.Type)
+
+	// First supported scheme with credentials
+	scheme, err = SelectSecurityScheme(schemes, creds, SecuritySchemeUserPassword, SecuritySchemeX509)
+	suite.Require().NoError(err)
+	suite.Require().Equal(SecuritySchemeUserPassword, scheme.Type)
+
+	// Missing credentials
+	_, err = SelectSecurityScheme(schemes, Credentials{}, SecuritySchemeUserPassword, SecuritySchemeX509)
+	suite.Require().ErrorIs(err, ErrMissingCredentials)
+	_, err = SelectSecurityScheme(schemes, creds, SecuritySchemeX509)
+	suite.Require().ErrorIs(err, ErrMissingCredentials)
+}
+
+func (suite *CredentialsSuite) TestOAuth2Token() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.NoError(r.ParseForm())
+		suite.Equal("client_credentials", r.Form.Get("grant_type"))
+		suite.Equal("events:read", r.Form.Get("scope"))
+
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		suite.NoError(json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "token",
+			"token_type":   "Bearer",
+		}))
+	}))
+	defer server.Close()
+
+	// URL and scopes from the security scheme
+	scheme := SecurityScheme{Type: SecuritySchemeOAuth2, TokenURL: server.URL, Scopes: []string{"events:read"}}
+	token, err := OAuth2ClientCredentials{ClientID: "client", ClientSecret: "secret"}.Token(context.Background(), scheme)
+	suite.Require().NoError(err)
+	suite.Require().Equal("token", token)
+
+	// Invalid credentials
+	_, err = OAuth2ClientCredentials{ClientID: "client", ClientSecret: "invalid"}.Token(context.Background(), scheme)
+	suite.Require().Error(err)
+}
@@ -0,0 +1,150 @@
+package extensions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDuration is raised when a duration is not a valid ISO 8601 duration.
+var ErrInvalidDuration = fmt.Errorf("%w: invalid ISO 8601 duration", ErrAsyncAPI)
+
+// Duration is a time.Duration encoded as an ISO 8601 duration, like 'PT1H30M'.
+// It is used for the strings with the 'duration' format.
+//
+// As there is no calendar, days are 24 hours and weeks are 7 days, while years
+// and months are not supported.
+type Duration time.Duration
+
+// ParseDuration parses an ISO 8601 duration, like 'P1DT12H' or 'PT0.5S'.
+//
+//nolint:cyclop // this is a parser of the designators
+func ParseDuration(s string) (Duration, error) {
+	str, negative := strings.CutPrefix(s, "-")
+	if !negative {
+		str = strings.TrimPrefix(str, "+")
+	}
+
+	str, ok := strings.CutPrefix(str, "P")
+	if !ok || str == "" || strings.HasSuffix(str, "T") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+	}
+
+	var total time.Duration
+	inTime := false
+	for str != "" {
+		if str[0] == 'T' && !inTime {
+			inTime, str = true, str[1:]
+			continue
+		}
+
+		// Get the number and its designator
+		i := strings.IndexFunc(str, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		number, designator := strings.ReplaceAll(str[:i], ",", "."), str[i]
+		str = str[i+1:]
+
+		var unit time.Duration
+		switch {
+		case !inTime && designator == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && designator == 'D':
+			unit = 24 * time.Hour
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("%w: unsupported designator %q in %q", ErrInvalidDuration, designator, s)
+		}
+
+		d, err := durationOf(number, unit)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		total += d
+	}
+
+	if negative {
+		total = -total
+	}
+	return Duration(total), nil
+}
+
+// durationOf returns the duration of the decimal number of units.
+func durationOf(number string, unit time.Duration) (time.Duration, error) {
+	integer, fraction, _ := strings.Cut(number, ".")
+	n, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	d := time.Duration(n) * unit
+
+	// Keep the nanoseconds of the fraction
+	scale := unit
+	for _, digit := range fraction {
+		if digit < '0' || digit > '9' {
+			return 0, strconv.ErrSyntax
+		}
+		scale /= 10
+		d += time.Duration(digit-'0') * scale
+	}
+
+	return d, nil
+}
+
+// String returns the ISO 8601 representation of the duration, with hours,
+// minutes and seconds (like 'PT36H0.5S').
+func (d Duration) String() string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	u := uint64(d)
+	if d < 0 {
+		b.WriteByte('-')
+		u = -u
+	}
+	b.WriteString("PT")
+
+	hours, u := u/uint64(time.Hour), u%uint64(time.Hour)
+	minutes, u := u/uint64(time.Minute), u%uint64(time.Minute)
+	seconds, nanoseconds := u/uint64(time.Second), u%uint64(time.Second)
+	if hours > 0 {
+		b.WriteString(strconv.FormatUint(hours, 10) + "H")
+	}
+	if minutes > 0 {
+		b.WriteString(strconv.FormatUint(minutes, 10) + "M")
+	}
+	if seconds > 0 || nanoseconds > 0 {
+		b.WriteString(strconv.FormatUint(seconds, 10))
+		if nanoseconds > 0 {
+			b.WriteString("." + strings.TrimRight(fmt.Sprintf("%09d", nanoseconds), "0"))
+		}
+		b.WriteString("S")
+	}
+
+	return b.String()
+}
+
+// MarshalText encodes the duration as an ISO 8601 duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes the ISO 8601 duration.
+func (d *Duration) UnmarshalText(data []byte) error {
+	parsed, err := ParseDuration(string(data))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,83 @@
+package extensions
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDurationSuite(t *testing.T) {
+	suite.Run(t, new(DurationSuite))
+}
+
+type DurationSuite struct {
+	suite.Suite
+}
+
+func (suite *DurationSuite) TestParseDuration() {
+	cases := []struct {
+		In  string
+		Out time.Duration
+	}{
+		{In: "PT0S", Out: 0},
+		{In: "PT1H30M", Out: 90 * time.Minute},
+		{In: "P1DT12H", Out: 36 * time.Hour},
+		{In: "P2W", Out: 14 * 24 * time.Hour},
+		{In: "PT0.5S", Out: 500 * time.Millisecond},
+		{In: "PT1,25M", Out: 75 * time.Second},
+		{In: "-PT10S", Out: -10 * time.Second},
+	}
+
+	for _, c := range cases {
+		d, err := ParseDuration(c.In)
+		suite.Require().NoError(err, c.In)
+		suite.Require().Equal(Duration(c.Out), d, c.In)
+	}
+}
+
+func (suite *DurationSuite) TestParseInvalidDuration() {
+	for _, in := range []string{"", "P", "PT", "P1DT", "1H", "PT1D", "P1H", "P1Y", "P1M", "PTH", "PT1.2.3S"} {
+		_, err := ParseDuration(in)
+		suite.Require().ErrorIs(err, ErrInvalidDuration, in)
+	}
+}
+
+func (suite *DurationSuite) TestString() {
+	cases := []struct {
+		In  time.Duration
+		Out string
+	}{
+		{In: 0, Out: "PT0S"},
+		{In: 90 * time.Minute, Out: "PT1H30M"},
+		{In: 36*time.Hour + 500*time.Millisecond, Out: "PT36H0.5S"},
+		{In: -10 * time.Second, Out: "-PT10S"},
+		{In: time.Nanosecond, Out: "PT0.000000001S"},
+	}
+
+	for _, c := range cases {
+		suite.Require().Equal(c.Out, Duration(c.In).String(), c.In)
+
+		// The representation can be parsed back
+		d, err := ParseDuration(c.Out)
+		suite.Require().NoError(err)
+		suite.Require().Equal(Duration(c.In), d)
+	}
+}
+
+func (suite *DurationSuite) TestJSON() {
+	type value struct {
+		Timeout Duration `json:"timeout"`
+	}
+
+	b, err := json.Marshal(value{Timeout: Duration(2 * time.Minute)})
+	suite.Require().NoError(err)
+	suite.Require().Equal(`{"timeout":"PT2M"}`, string(b))
+
+	var v value
+	suite.Require().NoError(json.Unmarshal([]byte(`{"timeout":"P1D"}`), &v))
+	suite.Require().Equal(Duration(24*time.Hour), v.Timeout)
+
+	suite.Require().ErrorIs(json.Unmarshal([]byte(`{"timeout":"1h"}`), &v), ErrInvalidDuration)
+}
@@ -6,6 +6,16 @@ import (
 
 // ErrorHandler is the signature of the function that needs to be implemented to use
 // errorhandler functionality.
+//
+// It is called by the controllers with the channel address and the message
+// when:
+//   - a received message can't be decoded (with an error wrapping
+//     ErrMessageDecoding), is rejected by a middleware or the subscription
+//     function returns an error: the message is not acknowledged yet, unless
+//     the subscription function has acknowledged it with AckFromContext;
+//   - a message can't be published, because of a middleware or the broker:
+//     the message can't be acknowledged, and the direction in the context
+//     is "publication".
 type ErrorHandler func(ctx context.Context, topic string, msg *AcknowledgeableBrokerMessage, err error)
 
 // DefaultErrorHandler returns the default error handler, which is a Noop errorhandler.
@@ -25,6 +25,10 @@ var (
 	// or more without unsubscribing.
 	ErrAlreadySubscribedChannel = fmt.Errorf("%w: the channel has already been subscribed", ErrAsyncAPI)
 
+	// ErrNotSubscribedChannel is raised when an action on a subscription is
+	// done on a channel that is not subscribed.
+	ErrNotSubscribedChannel = fmt.Errorf("%w: the channel is not subscribed", ErrAsyncAPI)
+
 	// ErrSubscriptionCanceled is raised when expecting something and the subscription has been canceled before it happens.
 	ErrSubscriptionCanceled = fmt.Errorf("%w: the subscription has been canceled", ErrAsyncAPI)
 
@@ -34,4 +38,100 @@ var (
 	// ErrChannelAddressEmpty is raised when a given channel address is empty,
 	// when dynamically set from message.
 	ErrChannelAddressEmpty = fmt.Errorf("%w: channel address empty", ErrAsyncAPI)
+
+	// ErrDelayedPublishNotSupported is raised when a message is published with
+	// a delay on a broker controller that doesn't support it.
+	ErrDelayedPublishNotSupported = fmt.Errorf("%w: delayed publish not supported by broker", ErrAsyncAPI)
+
+	// ErrPatternSubscriptionNotSupported is raised when subscribing to all the
+	// addresses of a channel on a broker controller that doesn't support it.
+	ErrPatternSubscriptionNotSupported = fmt.Errorf("%w: pattern subscription not supported by broker", ErrAsyncAPI)
+
+	// ErrMaxAttemptsReached is raised when a message is still not handled
+	// after the maximum number of attempts of a retry.
+	ErrMaxAttemptsReached = fmt.Errorf("%w: maximum number of attempts reached", ErrAsyncAPI)
+
+	// ErrSkipMessage can be returned by a middleware to stop the processing of
+	// a received message, that is then acknowledged without being handled.
+	ErrSkipMessage = fmt.Errorf("%w: message skipped", ErrAsyncAPI)
+
+	// ErrUnsupportedContentEncoding is raised when a payload is compressed or
+	// decompressed with an unsupported encoding.
+	ErrUnsupportedContentEncoding = fmt.Errorf("%w: unsupported content encoding", ErrAsyncAPI)
+
+	// ErrDecompressedPayloadTooLarge is raised when a received payload exceeds
+	// the maximum size once decompressed.
+	ErrDecompressedPayloadTooLarge = fmt.Errorf("%w: decompressed payload too large", ErrAsyncAPI)
+
+	// ErrUnknownKey is raised when a key used to encrypt or sign a message is
+	// not known by the key provider.
+	ErrUnknownKey = fmt.Errorf("%w: unknown key", ErrAsyncAPI)
+
+	// ErrDecryptionFailed is raised when a received message can't be
+	// decrypted.
+	ErrDecryptionFailed = fmt.Errorf("%w: decryption failed", ErrAsyncAPI)
+
+	// ErrInvalidSignature is raised when the signature of a received message
+	// is missing or invalid.
+	ErrInvalidSignature = fmt.Errorf("%w: invalid signature", ErrAsyncAPI)
+
+	// ErrUnknownVariant is raised when a payload can't be decoded as a union
+	// with a discriminator, as its discriminator value is unknown.
+	ErrUnknownVariant = fmt.Errorf("%w: unknown variant of union", ErrAsyncAPI)
+
+	// ErrUnknownServer is raised when a server is not one of the servers of
+	// the specification.
+	ErrUnknownServer = fmt.Errorf("%w: unknown server", ErrAsyncAPI)
+
+	// ErrUnsupportedProtocol is raised when a broker controller can't be
+	// created for the protocol of a server.
+	ErrUnsupportedProtocol = fmt.Errorf("%w: unsupported protocol", ErrAsyncAPI)
+
+	// ErrServerVariable is raised when a variable of a server has no value, or
+	// a value that is not allowed.
+	ErrServerVariable = fmt.Errorf("%w: invalid server variable", ErrAsyncAPI)
+
+	// ErrMissingCredentials is raised when a server requires one of its
+	// security schemes, but no credentials are given for them.
+	ErrMissingCredentials = fmt.Errorf("%w: missing credentials", ErrAsyncAPI)
+
+	// ErrInvalidHeader is raised when the value of a header of a received
+	// message can't be converted to its type.
+	ErrInvalidHeader = fmt.Errorf("%w: invalid header", ErrAsyncAPI)
+
+	// ErrUnknownEnumValue is raised when a value is parsed as an enum, but is
+	// not one of its values.
+	ErrUnknownEnumValue = fmt.Errorf("%w: unknown enum value", ErrAsyncAPI)
+
+	// ErrEndOfStream is raised when the message ending a reply stream is
+	// received instead of a reply.
+	ErrEndOfStream = fmt.Errorf("%w: end of stream", ErrAsyncAPI)
+
+	// ErrUnsupportedMessageVersion is raised when the payload of a received
+	// message has an unknown version, or a version without converter.
+	ErrUnsupportedMessageVersion = fmt.Errorf("%w: unsupported message version", ErrAsyncAPI)
+
+	// ErrMessageDecoding is raised when a received message can't be decoded
+	// into its generated type.
+	ErrMessageDecoding = fmt.Errorf("%w: message decoding failed", ErrAsyncAPI)
+
+	// ErrUnhealthy is raised when a controller can't receive or publish
+	// messages, because of its broker or its subscriptions.
+	ErrUnhealthy = fmt.Errorf("%w: unhealthy controller", ErrAsyncAPI)
+
+	// ErrSubscriptionEnded is raised when a subscription has ended without
+	// unsubscribing, for example when the broker stopped delivering messages.
+	ErrSubscriptionEnded = fmt.Errorf("%w: subscription ended", ErrAsyncAPI)
+
+	// ErrDraining is raised when a received message is not handled because
+	// the controller is draining before being closed.
+	ErrDraining = fmt.Errorf("%w: controller is draining", ErrAsyncAPI)
+
+	// ErrNoMessageAcknowledgment is raised when a message is acknowledged from
+	// a context that doesn't come from a received message.
+	ErrNoMessageAcknowledgment = fmt.Errorf("%w: no message to acknowledge", ErrAsyncAPI)
+
+	// ErrValidation is raised when a message doesn't respect the constraints of
+	// the specification, with the ValidationErrors of its fields.
+	ErrValidation = fmt.Errorf("%w: message validation failed", ErrAsyncAPI)
 )
@@ -0,0 +1,139 @@
+package extensions
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	// StandardHeaderMessageID is the name of the header with the identifier of
+	// the message, set on publication if it is not set.
+	StandardHeaderMessageID = "messageId"
+	// StandardHeaderSentAt is the name of the header with the time of the
+	// publication of the message, in RFC3339 with nanoseconds, set on
+	// publication if it is not set.
+	StandardHeaderSentAt = "sentAt"
+	// StandardHeaderTimestamp is the name of the header with the time of the
+	// publication of the message, set on publication if left empty in the
+	// typed headers.
+	StandardHeaderTimestamp = "timestamp"
+	// StandardHeaderContentType is the name of the header with the content
+	// type of the message, set on publication if left empty in the typed
+	// headers.
+	StandardHeaderContentType = "contentType"
+	// StandardHeaderEndOfStream is the default name of the header marking the
+	// message ending a reply stream.
+	StandardHeaderEndOfStream = "endOfStream"
+	// StandardHeaderMessageVersion is the default name of the header with the
+	// version of the payload of a versioned message.
+	StandardHeaderMessageVersion = "messageVersion"
+	// StandardHeaderTenant is the name of the header with the tenant of the
+	// message, set on publication by the controllers with a tenant resolver.
+	StandardHeaderTenant = "tenantId"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// MarshalHeader converts the value of a typed header into the value of a broker
+// message header: strings and bytes are kept as is, booleans and numbers are
+// formatted, times are in RFC3339, the values implementing
+// encoding.TextMarshaler use it, and the other ones are in JSON. The slices,
+// like the values of a multi-value header, are JSON arrays.
+func MarshalHeader(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		return nil, nil
+	case rv.Kind() == reflect.Struct && rv.Type().ConvertibleTo(timeType):
+		return []byte(rv.Convert(timeType).Interface().(time.Time).Format(time.RFC3339)), nil
+	}
+
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		return m.MarshalText()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return []byte(rv.String()), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte(nil), rv.Bytes()...), nil
+		}
+		return json.Marshal(v)
+	case reflect.Bool:
+		return []byte(strconv.FormatBool(rv.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []byte(strconv.FormatInt(rv.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []byte(strconv.FormatUint(rv.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return []byte(strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits())), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// UnmarshalHeader converts the value of a broker message header into the typed
+// header pointed by v, as the reverse of MarshalHeader.
+func UnmarshalHeader(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: non-nil pointer expected, got %T", ErrInvalidHeader, v)
+	}
+	elem := rv.Elem()
+
+	if elem.Kind() == reflect.Struct && elem.Type().ConvertibleTo(timeType) {
+		t, err := time.Parse(time.RFC3339, string(data))
+		if err != nil {
+			return err
+		}
+		elem.Set(reflect.ValueOf(t).Convert(elem.Type()))
+		return nil
+	}
+
+	if u, ok := v.(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText(data)
+	}
+
+	switch elem.Kind() {
+	case reflect.String:
+		elem.SetString(string(data))
+	case reflect.Slice:
+		if elem.Type().Elem().Kind() != reflect.Uint8 {
+			return json.Unmarshal(data, v)
+		}
+		elem.SetBytes(append([]byte(nil), data...))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(string(data))
+		if err != nil {
+			return err
+		}
+		elem.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(string(data), 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(string(data), 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(string(data), elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetFloat(f)
+	default:
+		return json.Unmarshal(data, v)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package extensions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestHeadersSuite(t *testing.T) {
+	suite.Run(t, new(HeadersSuite))
+}
+
+type HeadersSuite struct {
+	suite.Suite
+}
+
+// headerTime is a time type, as generated for the date-time schemas.
+type headerTime time.Time
+
+func (suite *HeadersSuite) TestRoundTrip() {
+	date := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, c := range []struct {
+		value    any
+		expected string
+		into     any
+	}{
+		{value: "value", expected: "value", into: new(string)},
+		{value: true, expected: "true", into: new(bool)},
+		{value: int64(-42), expected: "-42", into: new(int64)},
+		{value: uint8(42), expected: "42", into: new(uint8)},
+		{value: 1.5, expected: "1.5", into: new(float64)},
+		{value: date, expected: "2024-02-03T04:05:06Z", into: new(time.Time)},
+		{value: headerTime(date), expected: "2024-02-03T04:05:06Z", into: new(headerTime)},
+		{value: map[string]int{"a": 1}, expected: `{"a":1}`, into: new(map[string]int)},
+		{value: []byte{0x00, 0xff}, expected: "\x00\xff", into: new([]byte)},
+		{value: []string{"a", "b"}, expected: `["a","b"]`, into: new([]string)},
+	} {
+		b, err := MarshalHeader(c.value)
+		suite.Require().NoError(err)
+		suite.Require().Equal(c.expected, string(b))
+
+		suite.Require().NoError(UnmarshalHeader(b, c.into))
+		suite.Require().Equal(c.value, reflectElem(c.into))
+	}
+}
+
+func (suite *HeadersSuite) TestUnmarshalErrors() {
+	var i int8
+	suite.Require().Error(UnmarshalHeader([]byte("300"), &i))
+	suite.Require().Error(UnmarshalHeader([]byte("yes"), new(bool)))
+	suite.Require().Error(UnmarshalHeader([]byte("yesterday"), new(time.Time)))
+	suite.Require().ErrorIs(UnmarshalHeader([]byte("1"), i), ErrInvalidHeader)
+}
+
+// reflectElem returns the value pointed by the pointer.
+func reflectElem(ptr any) any {
+	return reflect.ValueOf(ptr).Elem().Interface()
+}
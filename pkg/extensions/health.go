@@ -0,0 +1,172 @@
+package extensions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthChecker can be implemented by the broker controllers to report the
+// health of their connection to the broker.
+type HealthChecker interface {
+	// Healthy returns an error if the broker can't be reached.
+	Healthy(ctx context.Context) error
+}
+
+// SubscriptionHealth is the health of a subscription of a controller.
+type SubscriptionHealth struct {
+	// Channel is the address of the subscribed channel.
+	Channel string
+	// Operation is the name of the operation of the subscription.
+	Operation string
+	// Active is false if the subscription has ended without unsubscribing,
+	// for example when the broker stopped delivering the messages.
+	Active bool
+	// Paused is true if the subscription is paused: its messages are not read
+	// until it is resumed.
+	Paused bool
+	// LastDelivery is the time of the last received message, if any.
+	LastDelivery time.Time
+	// LastError is the error of the last received message that couldn't be
+	// handled, if any.
+	LastError error
+}
+
+// Health is the health of a controller.
+type Health struct {
+	// Broker is the error of the broker health check, if it implements
+	// HealthChecker and is unhealthy.
+	Broker error
+	// Subscriptions are the subscriptions of the controller, by channel.
+	Subscriptions []SubscriptionHealth
+}
+
+// Err returns an error wrapping ErrUnhealthy if the broker or a subscription is
+// not healthy, or nil otherwise. The errors of the handled messages don't make
+// the controller unhealthy.
+func (h Health) Err() error {
+	errs := make([]error, 0)
+	if h.Broker != nil {
+		errs = append(errs, fmt.Errorf("broker: %w", h.Broker))
+	}
+	for _, s := range h.Subscriptions {
+		if !s.Active {
+			errs = append(errs, fmt.Errorf("subscription to %q has ended", s.Channel))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrUnhealthy, errors.Join(errs...))
+}
+
+// HealthTracker tracks the health of the subscriptions of a controller, and
+// whether it is ready.
+type HealthTracker struct {
+	mutex         sync.Mutex
+	subscriptions map[string]*SubscriptionHealth
+	ready         chan struct{}
+	readyOnce     sync.Once
+}
+
+// NewHealthTracker creates a new tracker of the health of a controller.
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		subscriptions: make(map[string]*SubscriptionHealth),
+		ready:         make(chan struct{}),
+	}
+}
+
+// Subscribed records a new subscription, and sets the controller as ready.
+func (t *HealthTracker) Subscribed(channel, operation string) {
+	t.mutex.Lock()
+	t.subscriptions[channel] = &SubscriptionHealth{
+		Channel:   channel,
+		Operation: operation,
+		Active:    true,
+	}
+	t.mutex.Unlock()
+
+	t.SetReady()
+}
+
+// Delivered records a received message of a subscription, with the error of
+// its handling if any.
+func (t *HealthTracker) Delivered(channel string, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s, exists := t.subscriptions[channel]; exists {
+		s.LastDelivery = time.Now()
+		if err != nil {
+			s.LastError = err
+		}
+	}
+}
+
+// Ended records the end of the messages of a subscription. If it has not been
+// unsubscribed before, the subscription is not active anymore and true is
+// returned.
+func (t *HealthTracker) Ended(channel string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	s, exists := t.subscriptions[channel]
+	if exists {
+		s.Active = false
+	}
+	return exists
+}
+
+// Paused records a subscription being paused or resumed.
+func (t *HealthTracker) Paused(channel string, paused bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s, exists := t.subscriptions[channel]; exists {
+		s.Paused = paused
+	}
+}
+
+// Unsubscribed removes a subscription.
+func (t *HealthTracker) Unsubscribed(channel string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.subscriptions, channel)
+}
+
+// SetReady sets the controller as ready.
+func (t *HealthTracker) SetReady() {
+	t.readyOnce.Do(func() { close(t.ready) })
+}
+
+// Ready returns a channel closed once the controller is ready.
+func (t *HealthTracker) Ready() <-chan struct{} {
+	return t.ready
+}
+
+// Health returns the health of the subscriptions, and the one of the broker
+// if it implements HealthChecker.
+func (t *HealthTracker) Health(ctx context.Context, broker BrokerController) Health {
+	var h Health
+	if checker, ok := broker.(HealthChecker); ok {
+		h.Broker = checker.Healthy(ctx)
+	}
+
+	t.mutex.Lock()
+	h.Subscriptions = make([]SubscriptionHealth, 0, len(t.subscriptions))
+	for _, s := range t.subscriptions {
+		h.Subscriptions = append(h.Subscriptions, *s)
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(h.Subscriptions, func(i, j int) bool {
+		return h.Subscriptions[i].Channel < h.Subscriptions[j].Channel
+	})
+	return h
+}
@@ -0,0 +1,97 @@
+package healthchecks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Controller is the part of the generated controllers used by the handlers.
+type Controller interface {
+	Health(ctx context.Context) extensions.Health
+	Ready() <-chan struct{}
+}
+
+type subscriptionStatus struct {
+	Channel      string     `json:"channel"`
+	Operation    string     `json:"operation"`
+	Active       bool       `json:"active"`
+	Paused       bool       `json:"paused,omitempty"`
+	LastDelivery *time.Time `json:"lastDelivery,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+}
+
+type status struct {
+	Status        string               `json:"status"`
+	Error         string               `json:"error,omitempty"`
+	Subscriptions []subscriptionStatus `json:"subscriptions,omitempty"`
+}
+
+// LivenessHandler returns an HTTP handler responding with the health of the
+// controllers, in JSON: with a 200 status code if they are all healthy, or
+// 503 otherwise.
+func LivenessHandler(controllers ...Controller) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, r, controllers)
+	})
+}
+
+// ReadinessHandler returns an HTTP handler responding as LivenessHandler, but
+// with a 503 status code until the controllers are ready.
+func ReadinessHandler(controllers ...Controller) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, ctrl := range controllers {
+			select {
+			case <-ctrl.Ready():
+			default:
+				writeStatus(w, http.StatusServiceUnavailable, status{Status: "not ready"})
+				return
+			}
+		}
+
+		writeHealth(w, r, controllers)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, r *http.Request, controllers []Controller) {
+	res := status{Status: "ok"}
+	code := http.StatusOK
+
+	for _, ctrl := range controllers {
+		h := ctrl.Health(r.Context())
+		if err := h.Err(); err != nil {
+			if res.Error != "" {
+				res.Error += "; "
+			}
+			res.Status, res.Error = "unhealthy", res.Error+err.Error()
+			code = http.StatusServiceUnavailable
+		}
+
+		for _, s := range h.Subscriptions {
+			sub := subscriptionStatus{
+				Channel:   s.Channel,
+				Operation: s.Operation,
+				Active:    s.Active,
+				Paused:    s.Paused,
+			}
+			if !s.LastDelivery.IsZero() {
+				sub.LastDelivery = &s.LastDelivery
+			}
+			if s.LastError != nil {
+				sub.LastError = s.LastError.Error()
+			}
+			res.Subscriptions = append(res.Subscriptions, sub)
+		}
+	}
+
+	writeStatus(w, code, res)
+}
+
+func writeStatus(w http.ResponseWriter, code int, s status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(s)
+}
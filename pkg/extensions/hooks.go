@@ -0,0 +1,102 @@
+package extensions
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriptionHooks are functions called on the lifecycle events of the
+// subscriptions of a controller, for example to emit metrics or to rebuild a
+// state when the subscriptions churn. The contexts contain the information of
+// the controller and of the subscription (channel, operation, etc). The hooks
+// that are not set are ignored.
+type SubscriptionHooks struct {
+	// OnSubscribed is called once the controller has subscribed to a channel.
+	OnSubscribed func(ctx context.Context)
+
+	// OnUnsubscribed is called once a subscription has stopped. The error is
+	// nil if it has been unsubscribed, or wraps ErrSubscriptionEnded if the
+	// broker stopped delivering the messages.
+	OnUnsubscribed func(ctx context.Context, err error)
+
+	// OnReconnected is called each time the broker has reconnected, if it
+	// implements ReconnectNotifier.
+	OnReconnected func(ctx context.Context)
+
+	// OnDeliveryDropped is called when a received message is not handled:
+	// the reason wraps ErrSkipMessage if a middleware skipped it, or
+	// ErrDraining if it has been left to the broker while draining.
+	OnDeliveryDropped func(ctx context.Context, msg BrokerMessage, reason error)
+}
+
+// Subscribed calls the OnSubscribed hook, if set.
+func (h SubscriptionHooks) Subscribed(ctx context.Context) {
+	if h.OnSubscribed != nil {
+		h.OnSubscribed(ctx)
+	}
+}
+
+// Unsubscribed calls the OnUnsubscribed hook, if set.
+func (h SubscriptionHooks) Unsubscribed(ctx context.Context, err error) {
+	if h.OnUnsubscribed != nil {
+		h.OnUnsubscribed(ctx, err)
+	}
+}
+
+// DeliveryDropped calls the OnDeliveryDropped hook, if set.
+func (h SubscriptionHooks) DeliveryDropped(ctx context.Context, msg BrokerMessage, reason error) {
+	if h.OnDeliveryDropped != nil {
+		h.OnDeliveryDropped(ctx, msg, reason)
+	}
+}
+
+// ReconnectNotifier can be implemented by the broker controllers reconnecting
+// automatically to the broker, to notify the reconnections.
+type ReconnectNotifier interface {
+	// NotifyReconnect registers a function called after each reconnection to
+	// the broker, until the returned function is called.
+	NotifyReconnect(fn func()) (stop func())
+}
+
+// ReconnectListeners are the functions to call on the reconnections of a
+// broker, that can be used to implement ReconnectNotifier. Its zero value is
+// ready to use.
+type ReconnectListeners struct {
+	mutex     sync.Mutex
+	next      int
+	listeners map[int]func()
+}
+
+// NotifyReconnect registers a function called by Notify, until the returned
+// function is called.
+func (l *ReconnectListeners) NotifyReconnect(fn func()) (stop func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.listeners == nil {
+		l.listeners = make(map[int]func())
+	}
+	id := l.next
+	l.next++
+	l.listeners[id] = fn
+
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		delete(l.listeners, id)
+	}
+}
+
+// Notify calls the registered functions, after a reconnection.
+func (l *ReconnectListeners) Notify() {
+	l.mutex.Lock()
+	listeners := make([]func(), 0, len(l.listeners))
+	for _, fn := range l.listeners {
+		listeners = append(listeners, fn)
+	}
+	l.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
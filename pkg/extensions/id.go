@@ -0,0 +1,39 @@
+package extensions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates the identifiers of the sent messages.
+type IDGenerator func() string
+
+// NewUUIDv7 returns a new UUIDv7, that is ordered by creation time. It is the
+// default IDGenerator.
+func NewUUIDv7() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// Only happens if the random source fails, as for a UUIDv4
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// StampMessage sets the 'messageId' header of the message with a new
+// identifier, and its 'sentAt' header with the time, if they are not already
+// set. It returns the values of both headers.
+func StampMessage(msg *BrokerMessage, generator IDGenerator, now time.Time) (id, sentAt string) {
+	if msg.Headers == nil {
+		msg.Headers = make(map[string][]byte, 2)
+	}
+
+	if len(msg.Headers[StandardHeaderMessageID]) == 0 && generator != nil {
+		msg.Headers[StandardHeaderMessageID] = []byte(generator())
+	}
+	if len(msg.Headers[StandardHeaderSentAt]) == 0 {
+		msg.Headers[StandardHeaderSentAt] = []byte(now.UTC().Format(time.RFC3339Nano))
+	}
+
+	return string(msg.Headers[StandardHeaderMessageID]), string(msg.Headers[StandardHeaderSentAt])
+}
@@ -0,0 +1,126 @@
+// Package inbox implements the inbox pattern, complementing the outbox: the
+// identifiers of the received messages are recorded as processed within the
+// transaction of the side effects of their handling. This way, a message
+// delivered several times by the broker is only processed once.
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+)
+
+var (
+	// ErrInbox is the generic error of the inbox.
+	ErrInbox = fmt.Errorf("%w: inbox", extensions.ErrAsyncAPI)
+
+	// ErrAlreadyProcessed is returned by the stores when a message has already
+	// been processed, or is being processed, by the consumer.
+	ErrAlreadyProcessed = fmt.Errorf("%w: message already processed", ErrInbox)
+)
+
+// Transaction is the processing of a received message, recorded by the store.
+type Transaction interface {
+	// Commit records the message as processed, with the side effects of its
+	// handling done within the transaction.
+	Commit(ctx context.Context) error
+	// Rollback cancels the processing of the message, so it can be processed
+	// again when delivered again.
+	Rollback(ctx context.Context) error
+}
+
+// Store records the received messages processed by the consumers.
+type Store interface {
+	// Begin starts the processing of the message with the id by the consumer.
+	// It returns the context given to the handler (e.g. with the database
+	// transaction), or an error wrapping ErrAlreadyProcessed if the message has
+	// already been processed or is being processed.
+	Begin(ctx context.Context, consumer, id string) (context.Context, Transaction, error)
+}
+
+// TxFromContext returns the database transaction in which the received message
+// is recorded as processed, in the context given to the subscription function,
+// or false if there is none. The side effects of the handling should be done
+// within it.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(extensions.ContextKeyIsInboxTransaction).(*sql.Tx)
+	return tx, ok && tx != nil
+}
+
+// MiddlewareOption is an option of the inbox middleware.
+type MiddlewareOption func(m *middleware)
+
+// WithMessageID sets the function returning the identifier of the received
+// messages (middlewares.KeyFromHeaders("messageId", "correlationId") if not
+// set).
+func WithMessageID(id middlewares.DeduplicationKey) MiddlewareOption {
+	return func(m *middleware) {
+		m.id = id
+	}
+}
+
+type middleware struct {
+	store Store
+	id    middlewares.DeduplicationKey
+}
+
+// Middleware is a middleware that processes the received messages within an
+// inbox transaction: the messages already processed are skipped (and
+// acknowledged), and the others are recorded as processed if their handling
+// succeeds. The consumers are the operations.
+//
+// The messages without identifier are handled without the inbox.
+func Middleware(store Store, options ...MiddlewareOption) extensions.Middleware {
+	m := middleware{
+		store: store,
+		id:    middlewares.KeyFromHeaders("messageId", "correlationId"),
+	}
+	for _, option := range options {
+		option(&m)
+	}
+
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only process the received messages
+		mc := extensions.MiddlewareContextFrom(ctx)
+		if mc.Direction != "reception" {
+			return next(ctx)
+		}
+
+		// Get the identifier of the message, if there is one
+		id := m.id(ctx, *msg)
+		if id == "" {
+			return next(ctx)
+		}
+
+		// Skip the message if it has already been processed
+		txCtx, tx, err := m.store.Begin(ctx, mc.Operation, id)
+		if errors.Is(err, ErrAlreadyProcessed) {
+			return fmt.Errorf("%w: message %q has already been processed", extensions.ErrSkipMessage, id)
+		} else if err != nil {
+			return fmt.Errorf("inbox of message %q: %w", id, err)
+		}
+
+		// Cancel the processing if the handling fails or panics
+		committed := false
+		defer func() {
+			if !committed {
+				_ = tx.Rollback(ctx)
+			}
+		}()
+
+		// Handle the message, and record it as processed if it succeeds
+		if err := next(txCtx); err != nil {
+			return err
+		}
+		committed = true
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("inbox of message %q: %w", id, err)
+		}
+
+		return nil
+	}
+}
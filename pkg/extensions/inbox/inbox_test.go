@@ -0,0 +1,136 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a Redis client keeping the keys in memory, without expiration.
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]string
+	ttls map[string]time.Duration
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{keys: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (f *fakeRedis) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.keys[key]; exists {
+		return false, nil
+	}
+	f.keys[key], f.ttls[key] = value, ttl
+	return true, nil
+}
+
+func (f *fakeRedis) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.keys[key], f.ttls[key] = value, ttl
+	return nil
+}
+
+func (f *fakeRedis) Del(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.keys, key)
+	delete(f.ttls, key)
+	return nil
+}
+
+// receive executes the middleware on a received message of the operation with
+// the id, and the handler.
+func receive(mw extensions.Middleware, operation, id string, handler extensions.NextMiddleware) error {
+	ctx := context.WithValue(context.Background(), extensions.ContextKeyIsDirection, "reception")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, operation)
+	msg := extensions.BrokerMessage{Headers: map[string][]byte{"messageId": []byte(id)}}
+	return mw(ctx, &msg, handler)
+}
+
+func TestMiddlewareWithRedis(t *testing.T) {
+	client := newFakeRedis()
+	mw := Middleware(NewRedisStore(client, "app:inbox:", WithRetention(time.Hour)))
+
+	var handled int
+	handler := func(_ context.Context) error {
+		handled++
+		return nil
+	}
+
+	// The message is processed once per operation
+	require.NoError(t, receive(mw, "ReceiveOrderOperation", "1", handler))
+	require.ErrorIs(t, receive(mw, "ReceiveOrderOperation", "1", handler), extensions.ErrSkipMessage)
+	require.NoError(t, receive(mw, "ReceiveShipmentOperation", "1", handler))
+	assert.Equal(t, 2, handled)
+
+	assert.Equal(t, "processed", client.keys["app:inbox:ReceiveOrderOperation:1"])
+	assert.Equal(t, time.Hour, client.ttls["app:inbox:ReceiveOrderOperation:1"])
+}
+
+func TestMiddlewareFailure(t *testing.T) {
+	client := newFakeRedis()
+	mw := Middleware(NewRedisStore(client, "app:inbox:"))
+
+	// The message whose handling failed can be processed again
+	failure := errors.New("failure")
+	require.ErrorIs(t, receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+		return failure
+	}), failure)
+	assert.Empty(t, client.keys)
+
+	// As well as the one whose handling panicked
+	assert.Panics(t, func() {
+		_ = receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+			panic("failure")
+		})
+	})
+	assert.Empty(t, client.keys)
+
+	require.NoError(t, receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+		return nil
+	}))
+}
+
+func TestMiddlewareBeingProcessed(t *testing.T) {
+	client := newFakeRedis()
+	mw := Middleware(NewRedisStore(client, "app:inbox:", WithProcessingTimeout(time.Minute)))
+
+	// A message being processed is skipped when delivered again
+	require.ErrorIs(t, receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+		assert.Equal(t, "processing", client.keys["app:inbox:ReceiveOrderOperation:1"])
+		assert.Equal(t, time.Minute, client.ttls["app:inbox:ReceiveOrderOperation:1"])
+		return receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+			t.Error("the message should not be handled twice")
+			return nil
+		})
+	}), extensions.ErrSkipMessage)
+}
+
+func TestMiddlewareWithoutID(t *testing.T) {
+	client := newFakeRedis()
+	mw := Middleware(NewRedisStore(client, "app:inbox:"),
+		WithMessageID(func(_ context.Context, _ extensions.BrokerMessage) string { return "" }))
+
+	var handled int
+	for i := 0; i < 2; i++ {
+		require.NoError(t, receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+			handled++
+			return nil
+		}))
+	}
+	assert.Equal(t, 2, handled)
+	assert.Empty(t, client.keys)
+}
@@ -0,0 +1,122 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/outbox"
+)
+
+// Check that it still fills the interface.
+var _ Store = (*PostgresStore)(nil)
+
+// DefaultTable is the default name of the table of the processed messages.
+const DefaultTable = "asyncapi_inbox"
+
+// PostgresStore records the processed messages in a PostgreSQL database,
+// within the transaction of the side effects of their handling. The database
+// driver should be registered by the application.
+//
+// The transaction is also set as outbox transaction, so the messages sent
+// while handling a message are stored in the outbox within it.
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+}
+
+// PostgresOption adds an option to the PostgresStore.
+type PostgresOption func(store *PostgresStore)
+
+// NewPostgresStore creates a store of the processed messages in a PostgreSQL
+// database.
+func NewPostgresStore(db *sql.DB, options ...PostgresOption) *PostgresStore {
+	// Create store
+	s := PostgresStore{
+		db:    db,
+		table: DefaultTable,
+	}
+
+	// Execute options
+	for _, option := range options {
+		option(&s)
+	}
+
+	return &s
+}
+
+// WithTable sets the name of the table of the processed messages.
+func WithTable(name string) PostgresOption {
+	return func(store *PostgresStore) {
+		store.table = name
+	}
+}
+
+// CreateTable creates the table of the processed messages, if it doesn't exist.
+func (s *PostgresStore) CreateTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		consumer TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		processed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (consumer, message_id)
+	)`, s.table)
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%w: creating table %q: %w", ErrInbox, s.table, err)
+	}
+	return nil
+}
+
+// Begin starts a transaction recording the message as processed by the
+// consumer. A concurrent processing of the same message waits for the end of
+// this one.
+func (s *PostgresStore) Begin(ctx context.Context, consumer, id string) (context.Context, Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("%w: beginning transaction: %w", ErrInbox, err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (consumer, message_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", s.table)
+	res, err := tx.ExecContext(ctx, query, consumer, id)
+	if err != nil {
+		_ = tx.Rollback()
+		return ctx, nil, fmt.Errorf("%w: recording message: %w", ErrInbox, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		_ = tx.Rollback()
+		return ctx, nil, fmt.Errorf("%w: recording message: %w", ErrInbox, err)
+	} else if n == 0 {
+		_ = tx.Rollback()
+		return ctx, nil, ErrAlreadyProcessed
+	}
+
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsInboxTransaction, tx)
+	return outbox.WithTx(ctx, tx), sqlTransaction{tx: tx}, nil
+}
+
+// Purge removes the records of the messages processed before the time, once
+// they can't be delivered again, and returns the number of records removed.
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE processed_at < $1", s.table)
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("%w: purging messages: %w", ErrInbox, err)
+	}
+	return res.RowsAffected()
+}
+
+// sqlTransaction is the processing of a message within a database transaction.
+type sqlTransaction struct {
+	tx *sql.Tx
+}
+
+func (t sqlTransaction) Commit(_ context.Context) error {
+	return t.tx.Commit()
+}
+
+func (t sqlTransaction) Rollback(_ context.Context) error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,123 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB is a database with a single inbox table, reached through a fake SQL
+// driver. The records inserted in a transaction are kept on commit.
+type fakeDB struct {
+	mu      sync.Mutex
+	records map[string]bool
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{records: make(map[string]bool)}
+	return f, sql.OpenDB(f)
+}
+
+func (f *fakeDB) Connect(_ context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                          { return nil }
+
+func (f *fakeDB) recorded(consumer, id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[consumer+":"+id]
+}
+
+type fakeConn struct {
+	db       *fakeDB
+	inserted []string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	for _, key := range c.inserted {
+		c.db.records[key] = true
+	}
+	c.inserted = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inserted = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+
+	s.conn.db.mu.Lock()
+	defer s.conn.db.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%s", args[0], args[1])
+	if s.conn.db.records[key] {
+		return driver.RowsAffected(0), nil
+	}
+	s.conn.inserted = append(s.conn.inserted, key)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestPostgresStore(t *testing.T) {
+	f, db := newFakeDB()
+	mw := Middleware(NewPostgresStore(db))
+
+	// The transaction is given to the handler, also for the outbox
+	require.NoError(t, receive(mw, "ReceiveOrderOperation", "1", func(ctx context.Context) error {
+		tx, ok := TxFromContext(ctx)
+		require.True(t, ok)
+		outboxTx, ok := outbox.TxFromContext(ctx)
+		require.True(t, ok)
+		assert.Same(t, tx, outboxTx)
+		assert.False(t, f.recorded("ReceiveOrderOperation", "1"))
+		return nil
+	}))
+	assert.True(t, f.recorded("ReceiveOrderOperation", "1"))
+
+	// The processed message is skipped
+	require.ErrorIs(t, receive(mw, "ReceiveOrderOperation", "1", func(_ context.Context) error {
+		t.Error("the message should not be handled twice")
+		return nil
+	}), extensions.ErrSkipMessage)
+
+	// The message whose handling failed is not recorded
+	require.Error(t, receive(mw, "ReceiveOrderOperation", "2", func(_ context.Context) error {
+		return errors.New("failure")
+	}))
+	assert.False(t, f.recorded("ReceiveOrderOperation", "2"))
+}
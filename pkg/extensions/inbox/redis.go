@@ -0,0 +1,110 @@
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+)
+
+// Check that it still fills the interface.
+var _ Store = (*RedisStore)(nil)
+
+const (
+	// DefaultRedisProcessingTimeout is the default duration after which a
+	// message being processed can be processed again, if its processing has
+	// not ended (e.g. because the application has crashed).
+	DefaultRedisProcessingTimeout = 5 * time.Minute
+
+	// DefaultRedisRetention is the default duration during which a processed
+	// message is recorded.
+	DefaultRedisRetention = 7 * 24 * time.Hour
+)
+
+// RedisClient is the subset of the commands of a Redis client used by the Redis
+// store, in order to use the client of your choice (see
+// middlewares.RedisClient for an example).
+type RedisClient interface {
+	middlewares.RedisClient
+	// Set sets the key with the value and the expiration.
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+}
+
+// RedisStore records the processed messages in Redis, that can be shared by
+// several instances of an application. As the records are not done within the
+// transaction of the side effects, a message is processed again if the
+// application fails between the end of its handling and its record.
+type RedisStore struct {
+	client            RedisClient
+	prefix            string
+	processingTimeout time.Duration
+	retention         time.Duration
+}
+
+// RedisOption adds an option to the RedisStore.
+type RedisOption func(store *RedisStore)
+
+// NewRedisStore creates a store of the processed messages in Redis, whose keys
+// are prefixed by the prefix (like 'myapp:inbox:').
+func NewRedisStore(client RedisClient, prefix string, options ...RedisOption) *RedisStore {
+	// Create store
+	s := RedisStore{
+		client:            client,
+		prefix:            prefix,
+		processingTimeout: DefaultRedisProcessingTimeout,
+		retention:         DefaultRedisRetention,
+	}
+
+	// Execute options
+	for _, option := range options {
+		option(&s)
+	}
+
+	return &s
+}
+
+// WithProcessingTimeout sets the duration after which a message being processed
+// can be processed again, if its processing has not ended.
+func WithProcessingTimeout(timeout time.Duration) RedisOption {
+	return func(store *RedisStore) {
+		store.processingTimeout = timeout
+	}
+}
+
+// WithRetention sets the duration during which a processed message is
+// recorded.
+func WithRetention(retention time.Duration) RedisOption {
+	return func(store *RedisStore) {
+		store.retention = retention
+	}
+}
+
+// Begin records the message as being processed by the consumer, until the
+// transaction ends or the processing timeout expires.
+func (s *RedisStore) Begin(ctx context.Context, consumer, id string) (context.Context, Transaction, error) {
+	key := s.prefix + consumer + ":" + id
+
+	set, err := s.client.SetNX(ctx, key, "processing", s.processingTimeout)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("%w: recording message: %w", ErrInbox, err)
+	} else if !set {
+		return ctx, nil, ErrAlreadyProcessed
+	}
+
+	return ctx, redisTransaction{store: s, key: key}, nil
+}
+
+// redisTransaction is the processing of a message recorded in Redis.
+type redisTransaction struct {
+	store *RedisStore
+	key   string
+}
+
+func (t redisTransaction) Commit(ctx context.Context) error {
+	return t.store.client.Set(ctx, t.key, "processed", t.store.retention)
+}
+
+func (t redisTransaction) Rollback(ctx context.Context) error {
+	return t.store.client.Del(ctx, t.key)
+}
@@ -0,0 +1,78 @@
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// InFlight tracks the messages being handled or published by a controller, in
+// order to wait for them before closing it.
+type InFlight struct {
+	mutex    sync.Mutex
+	count    int
+	draining bool
+	idle     chan struct{}
+}
+
+// NewInFlight creates a new tracker of the messages being handled or published.
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+// Add records a message being published. It is recorded even when draining,
+// as the handled messages can publish other messages (e.g. replies).
+func (f *InFlight) Add() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.count++
+}
+
+// TryAdd records a received message being handled, unless the tracker is
+// draining. It returns false if the message should not be handled.
+func (f *InFlight) TryAdd() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.draining {
+		return false
+	}
+	f.count++
+	return true
+}
+
+// Done records the end of the handling or publication of a message.
+func (f *InFlight) Done() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.count--
+	if f.count == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// Drain stops the handling of new received messages, and waits for the end of
+// the messages being handled or published. If the context is done before, an
+// error wrapping ErrContextCanceled is returned.
+func (f *InFlight) Drain(ctx context.Context) error {
+	f.mutex.Lock()
+	f.draining = true
+	if f.count == 0 {
+		f.mutex.Unlock()
+		return nil
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mutex.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrContextCanceled, ctx.Err())
+	}
+}
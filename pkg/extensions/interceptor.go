@@ -0,0 +1,23 @@
+package extensions
+
+import "context"
+
+// BrokerInterceptor intercepts the messages published and received through a
+// broker controller, below the encoding and the decoding of their payloads,
+// whatever the broker. Unlike the middlewares, it is set on the broker
+// controller, so it applies to all the controllers using it, like a bridge or
+// a recorder.
+//
+// The 'interceptors' package wraps a broker controller with interceptors.
+type BrokerInterceptor interface {
+	// BeforePublish is called with each message before its publication on the
+	// channel. It can modify the message, or return an error to not publish
+	// it: the error is then returned by the publication.
+	BeforePublish(ctx context.Context, channel string, msg *BrokerMessage) error
+
+	// AfterReceive is called with each received message before it is given to
+	// the subscriber. It can modify the message, or return an error to not
+	// give it to the subscriber: the message is then acknowledged if the error
+	// is ErrSkipMessage, and negatively acknowledged otherwise.
+	AfterReceive(ctx context.Context, channel string, msg *BrokerMessage) error
+}
@@ -0,0 +1,208 @@
+// Package interceptors applies broker interceptors to the raw messages of a
+// broker controller, like to wrap them in an envelope or to add headers to
+// all of them.
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController     = (*Controller)(nil)
+	_ extensions.DelayedPublisher     = (*Controller)(nil)
+	_ extensions.PatternSubscriber    = (*Controller)(nil)
+	_ extensions.ReplyAddressProvider = (*Controller)(nil)
+	_ extensions.HealthChecker        = (*Controller)(nil)
+	_ extensions.BrokerInterceptor    = Funcs{}
+)
+
+// Controller is a broker controller applying interceptors to the messages of
+// the wrapped broker controller. They are called in their order before the
+// publications, and in the reverse order after the receptions, so the first
+// interceptor is the closest to the application.
+type Controller struct {
+	broker       extensions.BrokerController
+	interceptors []extensions.BrokerInterceptor
+	logger       extensions.Logger
+}
+
+// ControllerOption is an option of the Controller.
+type ControllerOption func(controller *Controller)
+
+// NewController creates a broker controller applying the interceptors around
+// the broker controller.
+func NewController(
+	broker extensions.BrokerController,
+	interceptors []extensions.BrokerInterceptor,
+	options ...ControllerOption,
+) *Controller {
+	c := &Controller{
+		broker:       broker,
+		interceptors: interceptors,
+		logger:       extensions.DummyLogger{},
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// WithLogger sets the logger of the received messages rejected by an
+// interceptor.
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *Controller) {
+		controller.logger = logger
+	}
+}
+
+// Publish a message to the broker, once intercepted.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	if err := c.beforePublish(ctx, channel, &bm); err != nil {
+		return err
+	}
+	return c.broker.Publish(ctx, channel, bm)
+}
+
+// PublishBatch publishes several messages to the broker at once, once
+// intercepted. If a message can't be intercepted, none is published.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	intercepted := make([]extensions.BrokerMessage, len(bms))
+	for i, bm := range bms {
+		if err := c.beforePublish(ctx, channel, &bm); err != nil {
+			return err
+		}
+		intercepted[i] = bm
+	}
+	return c.broker.PublishBatch(ctx, channel, intercepted)
+}
+
+// PublishWithDelay publishes a message to the broker that will be delivered
+// after the delay, once intercepted, if the wrapped broker supports it.
+func (c *Controller) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	dp, ok := c.broker.(extensions.DelayedPublisher)
+	if !ok {
+		return extensions.ErrDelayedPublishNotSupported
+	}
+
+	if err := c.beforePublish(ctx, channel, &bm); err != nil {
+		return err
+	}
+	return dp.PublishWithDelay(ctx, channel, bm, delay)
+}
+
+// Subscribe to messages from the broker, that are intercepted before their
+// delivery.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := c.broker.Subscribe(ctx, channel)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.wrapSubscription(ctx, channel, sub), nil
+}
+
+// SubscribeToPattern subscribes to all the addresses matching the channel
+// address, if the wrapped broker supports it.
+func (c *Controller) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	sub, err := extensions.SubscribeToPattern(ctx, c.broker, channelAddr)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return c.wrapSubscription(ctx, channelAddr, sub), nil
+}
+
+// NewReplyAddress returns a reply address from the wrapped broker.
+func (c *Controller) NewReplyAddress(ctx context.Context) (string, error) {
+	return extensions.NewReplyAddress(ctx, c.broker)
+}
+
+// Healthy returns the health of the wrapped broker, if it implements
+// HealthChecker.
+func (c *Controller) Healthy(ctx context.Context) error {
+	if hc, ok := c.broker.(extensions.HealthChecker); ok {
+		return hc.Healthy(ctx)
+	}
+	return nil
+}
+
+// beforePublish calls the interceptors on the message, with a copy of its
+// headers so the message of the caller is not modified.
+func (c *Controller) beforePublish(ctx context.Context, channel string, bm *extensions.BrokerMessage) error {
+	bm.Headers = maps.Clone(bm.Headers)
+	for _, i := range c.interceptors {
+		if err := i.BeforePublish(ctx, channel, bm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) wrapSubscription(
+	ctx context.Context,
+	channel string,
+	sub extensions.BrokerChannelSubscription,
+) extensions.BrokerChannelSubscription {
+	return extensions.WrapBrokerChannelSubscription(sub,
+		func(msg extensions.AcknowledgeableBrokerMessage, send func(extensions.AcknowledgeableBrokerMessage)) {
+			// The messages of a pattern subscription have their own address
+			addr := channel
+			if msg.BrokerMessage.Address != "" {
+				addr = msg.BrokerMessage.Address
+			}
+
+			msg.BrokerMessage.Headers = maps.Clone(msg.BrokerMessage.Headers)
+			for i := len(c.interceptors) - 1; i >= 0; i-- {
+				err := c.interceptors[i].AfterReceive(ctx, addr, &msg.BrokerMessage)
+				switch {
+				case errors.Is(err, extensions.ErrSkipMessage):
+					msg.Ack()
+					return
+				case err != nil:
+					c.logger.Error(ctx, "received message rejected by interceptor",
+						extensions.LogInfo{Key: "channel", Value: addr},
+						extensions.LogInfo{Key: "error", Value: err.Error()})
+					msg.Nak()
+					return
+				}
+			}
+
+			send(msg)
+		})
+}
+
+// Funcs is a broker interceptor calling its functions, when they are set.
+type Funcs struct {
+	BeforePublishFunc func(ctx context.Context, channel string, msg *extensions.BrokerMessage) error
+	AfterReceiveFunc  func(ctx context.Context, channel string, msg *extensions.BrokerMessage) error
+}
+
+// BeforePublish calls BeforePublishFunc, if set.
+func (f Funcs) BeforePublish(ctx context.Context, channel string, msg *extensions.BrokerMessage) error {
+	if f.BeforePublishFunc == nil {
+		return nil
+	}
+	return f.BeforePublishFunc(ctx, channel, msg)
+}
+
+// AfterReceive calls AfterReceiveFunc, if set.
+func (f Funcs) AfterReceive(ctx context.Context, channel string, msg *extensions.BrokerMessage) error {
+	if f.AfterReceiveFunc == nil {
+		return nil
+	}
+	return f.AfterReceiveFunc(ctx, channel, msg)
+}
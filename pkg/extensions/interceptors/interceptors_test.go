@@ -0,0 +1,203 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestInterceptorsSuite(t *testing.T) {
+	suite.Run(t, new(InterceptorsSuite))
+}
+
+type InterceptorsSuite struct {
+	suite.Suite
+	broker *inmemory.Controller
+}
+
+func (suite *InterceptorsSuite) SetupTest() {
+	var err error
+	suite.broker, err = inmemory.NewController()
+	suite.Require().NoError(err)
+}
+
+// header returns an interceptor adding the header on publication, and
+// recording its value on reception.
+func header(key, value string, received *[]string) Funcs {
+	return Funcs{
+		BeforePublishFunc: func(_ context.Context, _ string, msg *extensions.BrokerMessage) error {
+			msg.Headers[key] = []byte(value)
+			return nil
+		},
+		AfterReceiveFunc: func(_ context.Context, _ string, msg *extensions.BrokerMessage) error {
+			*received = append(*received, string(msg.Headers[key]))
+			delete(msg.Headers, key)
+			return nil
+		},
+	}
+}
+
+func (suite *InterceptorsSuite) TestPublishAndReceive() {
+	var received []string
+	c := NewController(suite.broker, []extensions.BrokerInterceptor{
+		header("first", "1", &received), header("second", "2", &received),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	sub, err := c.Subscribe(ctx, "channel")
+	suite.Require().NoError(err)
+	defer sub.Cancel(ctx)
+
+	sent := extensions.BrokerMessage{Headers: map[string][]byte{"h": []byte("v")}, Payload: []byte("payload")}
+	suite.Require().NoError(c.Publish(ctx, "channel", sent))
+
+	// The message of the caller is not modified
+	suite.Require().Equal(map[string][]byte{"h": []byte("v")}, sent.Headers)
+	suite.Require().Equal(map[string][]byte{
+		"h":      []byte("v"),
+		"first":  []byte("1"),
+		"second": []byte("2"),
+	}, suite.broker.Published("channel")[0].Headers)
+
+	// The interceptors are called in the reverse order on reception
+	select {
+	case msg := <-sub.MessagesChannel():
+		suite.Require().Equal(sent, msg.BrokerMessage)
+		msg.Ack()
+	case <-ctx.Done():
+		suite.FailNow("message not received")
+	}
+	suite.Require().Equal([]string{"2", "1"}, received)
+}
+
+func (suite *InterceptorsSuite) TestPublishBatch() {
+	c := NewController(suite.broker, []extensions.BrokerInterceptor{Funcs{
+		BeforePublishFunc: func(_ context.Context, _ string, msg *extensions.BrokerMessage) error {
+			if string(msg.Payload) == "invalid" {
+				return errors.New("invalid message")
+			}
+			msg.Payload = append([]byte("envelope:"), msg.Payload...)
+			return nil
+		},
+	}})
+
+	suite.Require().NoError(c.PublishBatch(context.Background(), "channel", []extensions.BrokerMessage{
+		{Headers: map[string][]byte{}, Payload: []byte("a")},
+		{Headers: map[string][]byte{}, Payload: []byte("b")},
+	}))
+	suite.Require().Equal([]extensions.BrokerMessage{
+		{Headers: map[string][]byte{}, Payload: []byte("envelope:a")},
+		{Headers: map[string][]byte{}, Payload: []byte("envelope:b")},
+	}, suite.broker.Published("channel"))
+
+	// None of the messages is published if one is rejected
+	suite.Require().Error(c.PublishBatch(context.Background(), "other", []extensions.BrokerMessage{
+		{Headers: map[string][]byte{}, Payload: []byte("a")},
+		{Headers: map[string][]byte{}, Payload: []byte("invalid")},
+	}))
+	suite.Require().Empty(suite.broker.Published("other"))
+}
+
+// errorsLogger is a logger recording the logged errors.
+type errorsLogger struct {
+	extensions.DummyLogger
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *errorsLogger) Error(_ context.Context, msg string, _ ...extensions.LogInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errors = append(l.errors, msg)
+}
+
+func (l *errorsLogger) logged() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.errors...)
+}
+
+func (suite *InterceptorsSuite) TestRejectedReception() {
+	var logger errorsLogger
+	c := NewController(suite.broker, []extensions.BrokerInterceptor{Funcs{
+		AfterReceiveFunc: func(_ context.Context, _ string, msg *extensions.BrokerMessage) error {
+			switch string(msg.Payload) {
+			case "skip":
+				return extensions.ErrSkipMessage
+			case "fail":
+				return errors.New("failure")
+			}
+			return nil
+		},
+	}}, WithLogger(&logger))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	sub, err := c.Subscribe(ctx, "channel")
+	suite.Require().NoError(err)
+	defer sub.Cancel(ctx)
+
+	for _, p := range []string{"skip", "fail", "ok"} {
+		suite.Require().NoError(c.Publish(ctx, "channel", extensions.BrokerMessage{
+			Headers: map[string][]byte{},
+			Payload: []byte(p),
+		}))
+	}
+
+	// Only the accepted message is delivered
+	select {
+	case msg := <-sub.MessagesChannel():
+		suite.Require().Equal("ok", string(msg.BrokerMessage.Payload))
+		msg.Ack()
+	case <-ctx.Done():
+		suite.FailNow("message not received")
+	}
+
+	// The skipped message is acknowledged, and the failed one negatively acknowledged
+	suite.Require().NoError(suite.broker.WaitForAcknowledgements(ctx))
+	deliveries := suite.broker.Deliveries()
+	suite.Require().Len(deliveries, 3)
+	suite.Require().Equal(inmemory.DeliveryAcked, deliveries[0].Status)
+	suite.Require().Equal(inmemory.DeliveryNaked, deliveries[1].Status)
+	suite.Require().Equal(inmemory.DeliveryAcked, deliveries[2].Status)
+
+	// Only the failure is logged
+	suite.Require().Equal([]string{"received message rejected by interceptor"}, logger.logged())
+}
+
+func (suite *InterceptorsSuite) TestForwarding() {
+	c := NewController(suite.broker, nil)
+
+	// The inmemory broker provides no reply address and is always healthy
+	addr, err := c.NewReplyAddress(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().True(strings.HasPrefix(addr, extensions.DefaultReplyAddressPrefix))
+	suite.Require().NoError(c.Healthy(context.Background()))
+
+	suite.broker.Close()
+	suite.Require().Error(c.Healthy(context.Background()))
+}
+
+func (suite *InterceptorsSuite) TestPublishWithDelay() {
+	var received []string
+	c := NewController(suite.broker, []extensions.BrokerInterceptor{header("h", "v", &received)})
+
+	err := c.PublishWithDelay(context.Background(), "channel", extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+	}, time.Millisecond)
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[string][]byte{"h": []byte("v")}, suite.broker.Published("channel")[0].Headers)
+}
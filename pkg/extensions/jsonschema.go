@@ -0,0 +1,382 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	jsonSchemasMutex sync.Mutex
+	jsonSchemas      = make(map[string]*JSONSchema)
+)
+
+// JSONSchema is a compiled JSON Schema, that validates JSON values with the
+// keywords used by the specifications: type, enum, const, format, the string
+// lengths and pattern, the numeric limits, items, properties, required,
+// additionalProperties, allOf, anyOf, oneOf and not.
+type JSONSchema struct {
+	root *jsonSchemaNode
+}
+
+// CompileJSONSchema compiles the JSON Schema.
+func CompileJSONSchema(schema []byte) (*JSONSchema, error) {
+	var root jsonSchemaNode
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON Schema: %w", ErrAsyncAPI, err)
+	}
+	return &JSONSchema{root: &root}, nil
+}
+
+// ValidateJSON validates the JSON data against the JSON Schema, that is only
+// compiled the first time. The errors are returned as ValidationErrors, with
+// the paths of the fields in the data.
+func ValidateJSON(schema string, data []byte) error {
+	jsonSchemasMutex.Lock()
+	s, exists := jsonSchemas[schema]
+	if !exists {
+		var err error
+		if s, err = CompileJSONSchema([]byte(schema)); err != nil {
+			jsonSchemasMutex.Unlock()
+			return err
+		}
+		jsonSchemas[schema] = s
+	}
+	jsonSchemasMutex.Unlock()
+
+	return s.Validate(data)
+}
+
+// Validate validates the JSON data against the schema. The errors are returned
+// as ValidationErrors, with the paths of the fields in the data.
+func (s *JSONSchema) Validate(data []byte) error {
+	var errs ValidationErrors
+
+	value, err := decodeJSON(data)
+	if err != nil {
+		errs.Add("", "", "should be valid JSON: %s", err)
+		return errs
+	}
+
+	s.root.validate("", value, &errs)
+	return errs.Err()
+}
+
+// decodeJSON decodes the JSON data, keeping the numbers as json.Number.
+func decodeJSON(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var value any
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type jsonSchemaNode struct {
+	// boolean is set for the boolean schemas, 'true' accepting any value and
+	// 'false' accepting no value
+	boolean *bool
+	// constant is the decoded value of the 'const' keyword
+	constant any
+
+	Type   string          `json:"type"`
+	Format string          `json:"format"`
+	Enum   []any           `json:"enum"`
+	Const  json.RawMessage `json:"const"`
+
+	MinLength *int   `json:"minLength"`
+	MaxLength *int   `json:"maxLength"`
+	Pattern   string `json:"pattern"`
+
+	Minimum          *float64 `json:"minimum"`
+	Maximum          *float64 `json:"maximum"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum"`
+	MultipleOf       *float64 `json:"multipleOf"`
+
+	Items       *jsonSchemaNode `json:"items"`
+	MinItems    *int            `json:"minItems"`
+	MaxItems    *int            `json:"maxItems"`
+	UniqueItems bool            `json:"uniqueItems"`
+
+	Properties           map[string]*jsonSchemaNode `json:"properties"`
+	PatternProperties    map[string]*jsonSchemaNode `json:"patternProperties"`
+	AdditionalProperties *jsonSchemaNode            `json:"additionalProperties"`
+	Required             []string                   `json:"required"`
+	MinProperties        *int                       `json:"minProperties"`
+	MaxProperties        *int                       `json:"maxProperties"`
+
+	AllOf []*jsonSchemaNode `json:"allOf"`
+	AnyOf []*jsonSchemaNode `json:"anyOf"`
+	OneOf []*jsonSchemaNode `json:"oneOf"`
+	Not   *jsonSchemaNode   `json:"not"`
+}
+
+func (n *jsonSchemaNode) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", "false":
+		b := string(bytes.TrimSpace(data)) == "true"
+		*n = jsonSchemaNode{boolean: &b}
+		return nil
+	}
+
+	type node jsonSchemaNode
+	if err := json.Unmarshal(data, (*node)(n)); err != nil {
+		return err
+	}
+
+	// Decode the enum and const values as the validated values
+	for i, e := range n.Enum {
+		raw, _ := json.Marshal(e)
+		n.Enum[i], _ = decodeJSON(raw)
+	}
+	if n.Const != nil {
+		var err error
+		if n.constant, err = decodeJSON(n.Const); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate adds the errors of the value to the validation errors.
+//
+//nolint:cyclop // this is a switch on the value types
+func (n *jsonSchemaNode) validate(path string, value any, errs *ValidationErrors) {
+	if n.boolean != nil {
+		if !*n.boolean {
+			errs.Add(path, "false", "should not be present")
+		}
+		return
+	}
+
+	if n.Type != "" && !matchJSONType(n.Type, value) {
+		errs.Add(path, "type", "should be of type %q", n.Type)
+		return
+	}
+	if len(n.Enum) > 0 && !containsJSON(n.Enum, value) {
+		errs.Add(path, "enum", "should be one of the enum values")
+	}
+	if n.Const != nil && !equalJSON(n.constant, value) {
+		errs.Add(path, "const", "should be equal to %s", n.Const)
+	}
+
+	switch v := value.(type) {
+	case string:
+		n.validateString(path, v, errs)
+	case json.Number:
+		n.validateNumber(path, v, errs)
+	case []any:
+		n.validateArray(path, v, errs)
+	case map[string]any:
+		n.validateObject(path, v, errs)
+	}
+
+	n.validateCompositions(path, value, errs)
+}
+
+func (n *jsonSchemaNode) validateString(path, value string, errs *ValidationErrors) {
+	length := utf8.RuneCountInString(value)
+	if n.MinLength != nil && length < *n.MinLength {
+		errs.Add(path, "minLength", "should have at least %d characters", *n.MinLength)
+	}
+	if n.MaxLength != nil && length > *n.MaxLength {
+		errs.Add(path, "maxLength", "should have at most %d characters", *n.MaxLength)
+	}
+	if n.Pattern != "" && !MatchPattern(n.Pattern, value) {
+		errs.Add(path, "pattern", "should match %q", n.Pattern)
+	}
+	if n.Format != "" && !MatchFormat(n.Format, value) {
+		errs.Add(path, "format", "should be in the %q format", n.Format)
+	}
+}
+
+func (n *jsonSchemaNode) validateNumber(path string, value json.Number, errs *ValidationErrors) {
+	f, _ := value.Float64()
+
+	if n.Minimum != nil && f < *n.Minimum {
+		errs.Add(path, "minimum", "should be greater than or equal to %v", *n.Minimum)
+	}
+	if n.Maximum != nil && f > *n.Maximum {
+		errs.Add(path, "maximum", "should be less than or equal to %v", *n.Maximum)
+	}
+	if n.ExclusiveMinimum != nil && f <= *n.ExclusiveMinimum {
+		errs.Add(path, "exclusiveMinimum", "should be greater than %v", *n.ExclusiveMinimum)
+	}
+	if n.ExclusiveMaximum != nil && f >= *n.ExclusiveMaximum {
+		errs.Add(path, "exclusiveMaximum", "should be less than %v", *n.ExclusiveMaximum)
+	}
+	if n.MultipleOf != nil && *n.MultipleOf > 0 {
+		if q := f / *n.MultipleOf; q != math.Trunc(q) {
+			errs.Add(path, "multipleOf", "should be a multiple of %v", *n.MultipleOf)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateArray(path string, value []any, errs *ValidationErrors) {
+	if n.MinItems != nil && len(value) < *n.MinItems {
+		errs.Add(path, "minItems", "should have at least %d items", *n.MinItems)
+	}
+	if n.MaxItems != nil && len(value) > *n.MaxItems {
+		errs.Add(path, "maxItems", "should have at most %d items", *n.MaxItems)
+	}
+	if n.UniqueItems {
+		for i := range value {
+			if containsJSON(value[:i], value[i]) {
+				errs.Add(path, "uniqueItems", "should have unique items")
+				break
+			}
+		}
+	}
+
+	if n.Items != nil {
+		for i, item := range value {
+			n.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateObject(path string, value map[string]any, errs *ValidationErrors) {
+	for _, name := range n.Required {
+		if _, exists := value[name]; !exists {
+			errs.Add(jsonFieldPath(path, name), "required", "is required")
+		}
+	}
+	if n.MinProperties != nil && len(value) < *n.MinProperties {
+		errs.Add(path, "minProperties", "should have at least %d properties", *n.MinProperties)
+	}
+	if n.MaxProperties != nil && len(value) > *n.MaxProperties {
+		errs.Add(path, "maxProperties", "should have at most %d properties", *n.MaxProperties)
+	}
+
+	for name, v := range value {
+		field, matched := jsonFieldPath(path, name), false
+
+		if p, exists := n.Properties[name]; exists {
+			p.validate(field, v, errs)
+			matched = true
+		}
+		for pattern, p := range n.PatternProperties {
+			if MatchPattern(pattern, name) {
+				p.validate(field, v, errs)
+				matched = true
+			}
+		}
+
+		if !matched && n.AdditionalProperties != nil {
+			n.AdditionalProperties.validate(field, v, errs)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateCompositions(path string, value any, errs *ValidationErrors) {
+	for _, s := range n.AllOf {
+		s.validate(path, value, errs)
+	}
+
+	if len(n.AnyOf) > 0 && n.countValid(n.AnyOf, value) == 0 {
+		errs.Add(path, "anyOf", "should be valid against at least one of the schemas")
+	}
+	if len(n.OneOf) > 0 && n.countValid(n.OneOf, value) != 1 {
+		errs.Add(path, "oneOf", "should be valid against exactly one of the schemas")
+	}
+	if n.Not != nil && n.countValid([]*jsonSchemaNode{n.Not}, value) == 1 {
+		errs.Add(path, "not", "should not be valid against the schema")
+	}
+}
+
+// countValid returns the number of schemas the value is valid against.
+func (n *jsonSchemaNode) countValid(schemas []*jsonSchemaNode, value any) int {
+	count := 0
+	for _, s := range schemas {
+		var errs ValidationErrors
+		if s.validate("", value, &errs); len(errs) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func jsonFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func matchJSONType(typ string, value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case string:
+		return typ == "string"
+	case json.Number:
+		if typ == "integer" {
+			f, err := v.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+		return typ == "number"
+	case []any:
+		return typ == "array"
+	case map[string]any:
+		return typ == "object"
+	default:
+		return false
+	}
+}
+
+func containsJSON(values []any, value any) bool {
+	for _, v := range values {
+		if equalJSON(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON returns true if the decoded JSON values are equal, the numbers
+// being compared by value.
+func equalJSON(a, b any) bool {
+	switch va := a.(type) {
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, errA := va.Float64()
+		fb, errB := vb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equalJSON(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, v := range va {
+			if w, exists := vb[k]; !exists || !equalJSON(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
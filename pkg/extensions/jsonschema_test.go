@@ -0,0 +1,120 @@
+package extensions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestJSONSchemaSuite(t *testing.T) {
+	suite.Run(t, new(JSONSchemaSuite))
+}
+
+type JSONSchemaSuite struct {
+	suite.Suite
+}
+
+// fields returns the fields and constraints of the validation errors.
+func (suite *JSONSchemaSuite) fields(err error) []string {
+	var errs ValidationErrors
+	suite.Require().True(errors.As(err, &errs), "error should be validation errors: %v", err)
+
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field+" "+e.Constraint)
+	}
+	return fields
+}
+
+func (suite *JSONSchemaSuite) TestObject() {
+	schema := `{
+		"type": "object",
+		"required": ["id", "email"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"email": {"type": "string", "format": "email"},
+			"name": {"type": "string", "minLength": 2, "maxLength": 4, "pattern": "^[a-z]+$"},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+			"role": {"enum": ["admin", "user"]},
+			"version": {"const": 2}
+		},
+		"additionalProperties": false
+	}`
+
+	suite.Require().NoError(ValidateJSON(schema,
+		[]byte(`{"id": 1.0, "email": "a@b.c", "name": "abc", "tags": ["a"], "role": "user", "version": 2.0}`)))
+
+	err := ValidateJSON(schema, []byte(`{"id": 0, "name": "ABCDE", "tags": ["a", "a", "b", 1],
+		"role": "guest", "version": 3, "other": true}`))
+	suite.Require().ErrorIs(err, ErrValidation)
+	suite.Require().ElementsMatch([]string{
+		"email required",
+		"id minimum",
+		"name maxLength",
+		"name pattern",
+		"tags maxItems",
+		"tags uniqueItems",
+		"tags[3] type",
+		"role enum",
+		"version const",
+		"other false",
+	}, suite.fields(err))
+}
+
+func (suite *JSONSchemaSuite) TestTypes() {
+	cases := []struct {
+		typ, valid, invalid string
+	}{
+		{"string", `"a"`, `1`},
+		{"integer", `1`, `1.5`},
+		{"number", `1.5`, `"1"`},
+		{"boolean", `true`, `null`},
+		{"null", `null`, `false`},
+		{"array", `[]`, `{}`},
+		{"object", `{}`, `[]`},
+	}
+	for _, c := range cases {
+		schema := `{"type": "` + c.typ + `"}`
+		suite.Require().NoError(ValidateJSON(schema, []byte(c.valid)), c.typ)
+		suite.Require().Equal([]string{" type"}, suite.fields(ValidateJSON(schema, []byte(c.invalid))), c.typ)
+	}
+}
+
+func (suite *JSONSchemaSuite) TestCompositions() {
+	schema := `{
+		"oneOf": [
+			{"type": "object", "required": ["a"]},
+			{"type": "object", "required": ["b"]}
+		],
+		"not": {"type": "object", "required": ["c"]}
+	}`
+
+	suite.Require().NoError(ValidateJSON(schema, []byte(`{"a": 1}`)))
+	suite.Require().Equal([]string{" oneOf"}, suite.fields(ValidateJSON(schema, []byte(`{"a": 1, "b": 2}`))))
+	suite.Require().Equal([]string{" oneOf"}, suite.fields(ValidateJSON(schema, []byte(`{}`))))
+	suite.Require().Equal([]string{" not"}, suite.fields(ValidateJSON(schema, []byte(`{"a": 1, "c": 3}`))))
+
+	anyOf := `{"anyOf": [{"type": "string"}, {"type": "integer", "exclusiveMaximum": 10}]}`
+	suite.Require().NoError(ValidateJSON(anyOf, []byte(`9`)))
+	suite.Require().Equal([]string{" anyOf"}, suite.fields(ValidateJSON(anyOf, []byte(`10`))))
+}
+
+func (suite *JSONSchemaSuite) TestPatternProperties() {
+	schema := `{
+		"type": "object",
+		"patternProperties": {"^x-": {"type": "string"}},
+		"additionalProperties": {"type": "integer"}
+	}`
+
+	suite.Require().NoError(ValidateJSON(schema, []byte(`{"x-a": "a", "b": 1}`)))
+	suite.Require().ElementsMatch([]string{"x-a type", "b type"},
+		suite.fields(ValidateJSON(schema, []byte(`{"x-a": 1, "b": "b"}`))))
+}
+
+func (suite *JSONSchemaSuite) TestInvalid() {
+	suite.Require().Equal([]string{" "}, suite.fields(ValidateJSON(`true`, []byte(`{`))))
+
+	_, err := CompileJSONSchema([]byte(`{"type": 1}`))
+	suite.Require().ErrorIs(err, ErrAsyncAPI)
+}
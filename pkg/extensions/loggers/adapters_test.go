@@ -0,0 +1,129 @@
+package loggers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAdaptersSuite(t *testing.T) {
+	suite.Run(t, new(AdaptersSuite))
+}
+
+type AdaptersSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (suite *AdaptersSuite) SetupTest() {
+	suite.ctx = context.WithValue(context.Background(), extensions.ContextKeyIsChannel, "v3.orders")
+	suite.ctx = context.WithValue(suite.ctx, extensions.ContextKeyIsOperation, "receiveOrder")
+}
+
+func (suite *AdaptersSuite) TestSlog() {
+	var buf bytes.Buffer
+	logger := NewSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	logger.Info(suite.ctx, "ignored")
+	logger.Warning(suite.ctx, "warning", extensions.LogInfo{Key: "attempt", Value: 2})
+	logger.Error(suite.ctx, "error", extensions.LogInfo{Key: "error", Value: errors.New("failure")})
+
+	d := json.NewDecoder(&buf)
+	var warning, err map[string]any
+	suite.Require().NoError(d.Decode(&warning))
+	suite.Require().NoError(d.Decode(&err))
+	suite.Require().False(d.More())
+
+	suite.Require().Equal("WARN", warning["level"])
+	suite.Require().Equal("warning", warning["msg"])
+	suite.Require().Equal(2.0, warning["attempt"])
+	suite.Require().Equal("v3.orders", warning["asyncapi.channel"])
+	suite.Require().Equal("receiveOrder", warning["asyncapi.operation"])
+
+	suite.Require().Equal("ERROR", err["level"])
+	suite.Require().Equal("failure", err["error"])
+}
+
+type fakeZapLogger struct {
+	logs []string
+	kv   [][]any
+}
+
+func (l *fakeZapLogger) Infow(msg string, kv ...any)  { l.log("info", msg, kv) }
+func (l *fakeZapLogger) Warnw(msg string, kv ...any)  { l.log("warn", msg, kv) }
+func (l *fakeZapLogger) Errorw(msg string, kv ...any) { l.log("error", msg, kv) }
+
+func (l *fakeZapLogger) log(level, msg string, kv []any) {
+	l.logs = append(l.logs, level+": "+msg)
+	l.kv = append(l.kv, kv)
+}
+
+func (suite *AdaptersSuite) TestZap() {
+	zl := &fakeZapLogger{}
+	logger := NewZap(zl)
+
+	logger.Info(suite.ctx, "info", extensions.LogInfo{Key: "attempt", Value: 1})
+	logger.Warning(context.Background(), "warning")
+	logger.Error(context.Background(), "error")
+
+	suite.Require().Equal([]string{"info: info", "warn: warning", "error: error"}, zl.logs)
+	suite.Require().Equal([]any{
+		"attempt", 1,
+		"asyncapi.channel", "v3.orders",
+		"asyncapi.operation", "receiveOrder",
+	}, zl.kv[0])
+	suite.Require().Empty(zl.kv[1])
+}
+
+type fakeZerologEvent struct {
+	logger *fakeZerologLogger
+	level  string
+	fields map[string]any
+}
+
+func (e *fakeZerologEvent) Fields(fields any) *fakeZerologEvent {
+	e.fields, _ = fields.(map[string]any)
+	return e
+}
+
+func (e *fakeZerologEvent) Msg(msg string) {
+	e.logger.logs = append(e.logger.logs, e.level+": "+msg)
+	e.logger.fields = append(e.logger.fields, e.fields)
+}
+
+type fakeZerologLogger struct {
+	logs   []string
+	fields []map[string]any
+}
+
+func (l *fakeZerologLogger) Info() *fakeZerologEvent {
+	return &fakeZerologEvent{logger: l, level: "info"}
+}
+func (l *fakeZerologLogger) Warn() *fakeZerologEvent {
+	return &fakeZerologEvent{logger: l, level: "warn"}
+}
+func (l *fakeZerologLogger) Error() *fakeZerologEvent {
+	return &fakeZerologEvent{logger: l, level: "error"}
+}
+
+func (suite *AdaptersSuite) TestZerolog() {
+	zl := &fakeZerologLogger{}
+	logger := NewZerolog[*fakeZerologEvent](zl)
+
+	logger.Info(context.Background(), "info")
+	logger.Warning(context.Background(), "warning")
+	logger.Error(suite.ctx, "error", extensions.LogInfo{Key: "attempt", Value: 3})
+
+	suite.Require().Equal([]string{"info: info", "warn: warning", "error: error"}, zl.logs)
+	suite.Require().Equal(map[string]any{
+		"attempt":            3,
+		"asyncapi.channel":   "v3.orders",
+		"asyncapi.operation": "receiveOrder",
+	}, zl.fields[2])
+}
@@ -1,6 +1,7 @@
 package loggers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -45,3 +46,23 @@ func structureLogs(info []extensions.LogInfo) map[string]any {
 	}
 	return structuredLog
 }
+
+// withContextInfo adds the information set by the controllers in the context
+// to the logs information, for the adapters of structured loggers.
+func withContextInfo(ctx context.Context, info []extensions.LogInfo) []extensions.LogInfo {
+	for _, field := range []struct {
+		key    string
+		ctxKey extensions.ContextKey
+	}{
+		{"asyncapi.provider", extensions.ContextKeyIsProvider},
+		{"asyncapi.channel", extensions.ContextKeyIsChannel},
+		{"asyncapi.operation", extensions.ContextKeyIsOperation},
+		{"asyncapi.direction", extensions.ContextKeyIsDirection},
+		{"asyncapi.correlation_id", extensions.ContextKeyIsCorrelationID},
+	} {
+		if value := ctx.Value(field.ctxKey); value != nil {
+			info = append(info, extensions.LogInfo{Key: field.key, Value: value})
+		}
+	}
+	return info
+}
@@ -0,0 +1,53 @@
+package loggers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Slog is a logger that will write logs with a slog logger from the standard
+// library, with the additional info as attributes.
+type Slog struct {
+	logger *slog.Logger
+}
+
+// NewSlog creates a new Slog logger. If the slog logger is nil, the default
+// one is used.
+func NewSlog(logger *slog.Logger) Slog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return Slog{logger: logger}
+}
+
+func (sl Slog) log(ctx context.Context, level slog.Level, msg string, info ...extensions.LogInfo) {
+	// Skip the formatting of the disabled levels
+	if !sl.logger.Enabled(ctx, level) {
+		return
+	}
+
+	info = withContextInfo(ctx, info)
+	attrs := make([]slog.Attr, 0, len(info))
+	for _, i := range info {
+		attrs = append(attrs, slog.Any(i.Key, i.Value))
+	}
+
+	sl.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// Info logs a message at info level with context and additional info.
+func (sl Slog) Info(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	sl.log(ctx, slog.LevelInfo, msg, info...)
+}
+
+// Warning logs a message at warning level with context and additional info.
+func (sl Slog) Warning(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	sl.log(ctx, slog.LevelWarn, msg, info...)
+}
+
+// Error logs a message at error level with context and additional info.
+func (sl Slog) Error(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	sl.log(ctx, slog.LevelError, msg, info...)
+}
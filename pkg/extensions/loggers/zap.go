@@ -0,0 +1,52 @@
+package loggers
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// ZapLogger is the part of the uber-go/zap sugared logger (*zap.SugaredLogger)
+// used by the Zap logger, so the generated code doesn't depend on it.
+type ZapLogger interface {
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+}
+
+// Zap is a logger that will write logs with an uber-go/zap logger, with the
+// additional info as fields.
+type Zap struct {
+	logger ZapLogger
+}
+
+// NewZap creates a new Zap logger from a sugared zap logger:
+//
+//	logger := loggers.NewZap(zapLogger.Sugar())
+func NewZap(logger ZapLogger) Zap {
+	return Zap{logger: logger}
+}
+
+func (zl Zap) keysAndValues(ctx context.Context, info []extensions.LogInfo) []any {
+	info = withContextInfo(ctx, info)
+	kv := make([]any, 0, 2*len(info))
+	for _, i := range info {
+		kv = append(kv, i.Key, i.Value)
+	}
+	return kv
+}
+
+// Info logs a message at info level with context and additional info.
+func (zl Zap) Info(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Infow(msg, zl.keysAndValues(ctx, info)...)
+}
+
+// Warning logs a message at warning level with context and additional info.
+func (zl Zap) Warning(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Warnw(msg, zl.keysAndValues(ctx, info)...)
+}
+
+// Error logs a message at error level with context and additional info.
+func (zl Zap) Error(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Errorw(msg, zl.keysAndValues(ctx, info)...)
+}
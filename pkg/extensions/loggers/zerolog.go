@@ -0,0 +1,59 @@
+package loggers
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// ZerologEvent is the part of the rs/zerolog event (*zerolog.Event) used by the
+// Zerolog logger, so the generated code doesn't depend on it.
+type ZerologEvent[E any] interface {
+	Fields(fields any) E
+	Msg(msg string)
+}
+
+// ZerologLogger is the part of the rs/zerolog logger (*zerolog.Logger) used by
+// the Zerolog logger, so the generated code doesn't depend on it.
+type ZerologLogger[E ZerologEvent[E]] interface {
+	Info() E
+	Warn() E
+	Error() E
+}
+
+// Zerolog is a logger that will write logs with a rs/zerolog logger, with the
+// additional info as fields.
+type Zerolog[E ZerologEvent[E]] struct {
+	logger ZerologLogger[E]
+}
+
+// NewZerolog creates a new Zerolog logger from a zerolog logger:
+//
+//	logger := loggers.NewZerolog[*zerolog.Event](&zerologLogger)
+func NewZerolog[E ZerologEvent[E]](logger ZerologLogger[E]) Zerolog[E] {
+	return Zerolog[E]{logger: logger}
+}
+
+func (zl Zerolog[E]) fields(ctx context.Context, info []extensions.LogInfo) map[string]any {
+	info = withContextInfo(ctx, info)
+	fields := make(map[string]any, len(info))
+	for _, i := range info {
+		fields[i.Key] = i.Value
+	}
+	return fields
+}
+
+// Info logs a message at info level with context and additional info.
+func (zl Zerolog[E]) Info(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Info().Fields(zl.fields(ctx, info)).Msg(msg)
+}
+
+// Warning logs a message at warning level with context and additional info.
+func (zl Zerolog[E]) Warning(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Warn().Fields(zl.fields(ctx, info)).Msg(msg)
+}
+
+// Error logs a message at error level with context and additional info.
+func (zl Zerolog[E]) Error(ctx context.Context, msg string, info ...extensions.LogInfo) {
+	zl.logger.Error().Fields(zl.fields(ctx, info)).Msg(msg)
+}
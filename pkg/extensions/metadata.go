@@ -0,0 +1,40 @@
+package extensions
+
+import (
+	"context"
+	"time"
+)
+
+// MessageMetadata is the information of the delivery of a received message,
+// set by the brokers. The information that a broker doesn't provide is left
+// to its zero value.
+type MessageMetadata struct {
+	// Redelivered is true if the message has already been delivered before,
+	// for example because it has not been acknowledged.
+	Redelivered bool
+	// DeliveryCount is the number of deliveries of the message, including this
+	// one, or 0 if the broker doesn't count them.
+	DeliveryCount int
+	// Timestamp is the time the message has been published or stored by the
+	// broker.
+	Timestamp time.Time
+	// Partition is the partition of the message (e.g. the Kafka partition).
+	Partition int
+	// Offset is the position of the message in its partition or stream (e.g.
+	// the Kafka offset or the NATS JetStream sequence).
+	Offset int64
+	// RoutingKey is the routing key of the message (e.g. the RabbitMQ routing
+	// key, or the NATS subject and the Kafka or MQTT topic it has been
+	// published to).
+	RoutingKey string
+	// ID is the identifier of the message set by the broker, if any.
+	ID string
+}
+
+// MessageMetadataFromContext returns the delivery metadata of the received
+// message of the context given to the subscription functions, or false if the
+// broker has not set it.
+func MessageMetadataFromContext(ctx context.Context) (MessageMetadata, bool) {
+	md, ok := ctx.Value(ContextKeyIsMessageMetadata).(MessageMetadata)
+	return md, ok
+}
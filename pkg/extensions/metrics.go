@@ -0,0 +1,57 @@
+package extensions
+
+import "time"
+
+// MetricsLabels are the labels of the metrics of a message.
+type MetricsLabels struct {
+	// Channel is the address of the channel in the specification, with its
+	// parameters (like "user.{userId}.signedup"), or its name if the address
+	// is dynamic.
+	Channel string
+	// Operation is the name of the operation, as named in the generated code
+	// (like "PingOperation").
+	Operation string
+}
+
+// MetricsCollector collects the metrics of the messages sent and received by
+// the generated controllers.
+type MetricsCollector interface {
+	// MessagePublished is called once a message has been published, with the
+	// publication error if any.
+	MessagePublished(labels MetricsLabels, err error)
+	// MessageReceived is called when a message is received, before handling it.
+	MessageReceived(labels MetricsLabels)
+	// MessageHandled is called once a received message has been handled by the
+	// middlewares and the subscription function, with the handling error if any.
+	MessageHandled(labels MetricsLabels, duration time.Duration, err error)
+	// MessageAcked is called when a received message is acknowledged.
+	MessageAcked(labels MetricsLabels)
+	// MessageNaked is called when a received message is negatively acknowledged.
+	MessageNaked(labels MetricsLabels)
+}
+
+// DummyMetricsCollector is a metrics collector that does not collect anything.
+type DummyMetricsCollector struct{}
+
+// MessagePublished does nothing.
+func (DummyMetricsCollector) MessagePublished(MetricsLabels, error) {}
+
+// MessageReceived does nothing.
+func (DummyMetricsCollector) MessageReceived(MetricsLabels) {}
+
+// MessageHandled does nothing.
+func (DummyMetricsCollector) MessageHandled(MetricsLabels, time.Duration, error) {}
+
+// MessageAcked does nothing.
+func (DummyMetricsCollector) MessageAcked(MetricsLabels) {}
+
+// MessageNaked does nothing.
+func (DummyMetricsCollector) MessageNaked(MetricsLabels) {}
+
+// LagCollector collects the consumer lag of the subscriptions of the broker
+// controllers that can report it, like the Kafka controller.
+type LagCollector interface {
+	// ConsumerLag is called periodically with the number of messages of the
+	// partition of the channel that are not consumed yet.
+	ConsumerLag(channel string, partition int, lag int64)
+}
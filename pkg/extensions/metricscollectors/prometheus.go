@@ -0,0 +1,171 @@
+// Package metricscollectors provides implementations of extensions.MetricsCollector.
+package metricscollectors
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Check that it still fills the interface.
+var (
+	_ extensions.MetricsCollector = (*Prometheus)(nil)
+	_ extensions.LagCollector     = (*Prometheus)(nil)
+)
+
+const (
+	// DefaultNamespace is the default namespace of the Prometheus metrics.
+	DefaultNamespace = "asyncapi"
+
+	statusSuccess = "success"
+	statusError   = "error"
+)
+
+// Prometheus is a metrics collector recording the metrics of the controllers
+// as Prometheus metrics, labeled by channel and operation:
+//
+//   - <namespace>_messages_published_total, with a 'status' label;
+//   - <namespace>_messages_received_total;
+//   - <namespace>_messages_handling_duration_seconds, with a 'status' label;
+//   - <namespace>_messages_acknowledgements_total, with a 'type' label ('ack'
+//     or 'nak');
+//   - <namespace>_messages_in_flight, the received messages being handled.
+//
+// As a lag collector, it records the consumer lag reported by the broker
+// controllers, labeled by channel address and partition:
+//
+//   - <namespace>_consumer_lag, the messages not consumed yet.
+type Prometheus struct {
+	published        *prometheus.CounterVec
+	received         *prometheus.CounterVec
+	handlingDuration *prometheus.HistogramVec
+	acknowledgements *prometheus.CounterVec
+	inFlight         *prometheus.GaugeVec
+	consumerLag      *prometheus.GaugeVec
+}
+
+// PrometheusOption is an option of the Prometheus metrics collector.
+type PrometheusOption func(config *prometheusConfig)
+
+type prometheusConfig struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the namespace of the metrics (DefaultNamespace if not set).
+func WithNamespace(namespace string) PrometheusOption {
+	return func(config *prometheusConfig) {
+		config.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets, in seconds, of the handling duration histogram
+// (prometheus.DefBuckets if not set).
+func WithBuckets(buckets ...float64) PrometheusOption {
+	return func(config *prometheusConfig) {
+		config.buckets = buckets
+	}
+}
+
+// NewPrometheus creates a new Prometheus metrics collector, whose metrics are
+// registered with the registerer (prometheus.DefaultRegisterer if nil).
+func NewPrometheus(registerer prometheus.Registerer, options ...PrometheusOption) (*Prometheus, error) {
+	config := prometheusConfig{
+		namespace: DefaultNamespace,
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	labels := []string{"channel", "operation"}
+	p := &Prometheus{
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.namespace,
+			Name:      "messages_published_total",
+			Help:      "Number of published messages.",
+		}, []string{"channel", "operation", "status"}),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.namespace,
+			Name:      "messages_received_total",
+			Help:      "Number of received messages.",
+		}, labels),
+		handlingDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.namespace,
+			Name:      "messages_handling_duration_seconds",
+			Help:      "Duration of the handling of the received messages.",
+			Buckets:   config.buckets,
+		}, []string{"channel", "operation", "status"}),
+		acknowledgements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.namespace,
+			Name:      "messages_acknowledgements_total",
+			Help:      "Number of acknowledgements (ack or nak) of the received messages.",
+		}, []string{"channel", "operation", "type"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: config.namespace,
+			Name:      "messages_in_flight",
+			Help:      "Number of received messages being handled.",
+		}, labels),
+		consumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: config.namespace,
+			Name:      "consumer_lag",
+			Help:      "Number of messages of the partitions not consumed yet.",
+		}, []string{"channel", "partition"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		p.published, p.received, p.handlingDuration, p.acknowledgements, p.inFlight, p.consumerLag,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func status(err error) string {
+	if err != nil {
+		return statusError
+	}
+	return statusSuccess
+}
+
+// MessagePublished increments the published messages.
+func (p *Prometheus) MessagePublished(labels extensions.MetricsLabels, err error) {
+	p.published.WithLabelValues(labels.Channel, labels.Operation, status(err)).Inc()
+}
+
+// MessageReceived increments the received and in flight messages.
+func (p *Prometheus) MessageReceived(labels extensions.MetricsLabels) {
+	p.received.WithLabelValues(labels.Channel, labels.Operation).Inc()
+	p.inFlight.WithLabelValues(labels.Channel, labels.Operation).Inc()
+}
+
+// MessageHandled records the handling duration and decrements the in flight
+// messages.
+func (p *Prometheus) MessageHandled(labels extensions.MetricsLabels, duration time.Duration, err error) {
+	p.inFlight.WithLabelValues(labels.Channel, labels.Operation).Dec()
+	p.handlingDuration.WithLabelValues(labels.Channel, labels.Operation, status(err)).Observe(duration.Seconds())
+}
+
+// MessageAcked increments the acknowledgements.
+func (p *Prometheus) MessageAcked(labels extensions.MetricsLabels) {
+	p.acknowledgements.WithLabelValues(labels.Channel, labels.Operation, "ack").Inc()
+}
+
+// MessageNaked increments the negative acknowledgements.
+func (p *Prometheus) MessageNaked(labels extensions.MetricsLabels) {
+	p.acknowledgements.WithLabelValues(labels.Channel, labels.Operation, "nak").Inc()
+}
+
+// ConsumerLag sets the consumer lag of the partition.
+func (p *Prometheus) ConsumerLag(channel string, partition int, lag int64) {
+	p.consumerLag.WithLabelValues(channel, strconv.Itoa(partition)).Set(float64(lag))
+}
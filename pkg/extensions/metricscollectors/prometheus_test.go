@@ -0,0 +1,98 @@
+package metricscollectors
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPrometheusSuite(t *testing.T) {
+	suite.Run(t, new(PrometheusSuite))
+}
+
+type PrometheusSuite struct {
+	suite.Suite
+	registry  *prometheus.Registry
+	collector *Prometheus
+}
+
+var labels = extensions.MetricsLabels{Channel: "user.{userId}.signedup", Operation: "ReceiveUserSignedUpOperation"}
+
+func (suite *PrometheusSuite) SetupTest() {
+	suite.registry = prometheus.NewRegistry()
+
+	collector, err := NewPrometheus(suite.registry, WithNamespace("test"))
+	suite.Require().NoError(err)
+	suite.collector = collector
+}
+
+func (suite *PrometheusSuite) TestPublished() {
+	suite.collector.MessagePublished(labels, nil)
+	suite.collector.MessagePublished(labels, nil)
+	suite.collector.MessagePublished(labels, errors.New("error"))
+
+	suite.Require().Equal(2.0, testutil.ToFloat64(
+		suite.collector.published.WithLabelValues(labels.Channel, labels.Operation, "success")))
+	suite.Require().Equal(1.0, testutil.ToFloat64(
+		suite.collector.published.WithLabelValues(labels.Channel, labels.Operation, "error")))
+}
+
+func (suite *PrometheusSuite) TestReception() {
+	suite.collector.MessageReceived(labels)
+	suite.collector.MessageReceived(labels)
+	suite.Require().Equal(2.0, testutil.ToFloat64(suite.collector.inFlight.WithLabelValues(labels.Channel, labels.Operation)))
+
+	suite.collector.MessageHandled(labels, time.Millisecond, nil)
+	suite.collector.MessageAcked(labels)
+	suite.collector.MessageHandled(labels, time.Millisecond, errors.New("error"))
+	suite.collector.MessageNaked(labels)
+
+	suite.Require().Equal(2.0, testutil.ToFloat64(suite.collector.received.WithLabelValues(labels.Channel, labels.Operation)))
+	suite.Require().Equal(0.0, testutil.ToFloat64(suite.collector.inFlight.WithLabelValues(labels.Channel, labels.Operation)))
+	suite.Require().Equal(1.0, testutil.ToFloat64(
+		suite.collector.acknowledgements.WithLabelValues(labels.Channel, labels.Operation, "ack")))
+	suite.Require().Equal(1.0, testutil.ToFloat64(
+		suite.collector.acknowledgements.WithLabelValues(labels.Channel, labels.Operation, "nak")))
+	suite.Require().Equal(2, testutil.CollectAndCount(suite.collector.handlingDuration))
+}
+
+func (suite *PrometheusSuite) TestMetricsNames() {
+	suite.collector.MessagePublished(labels, nil)
+	suite.collector.MessageReceived(labels)
+	suite.collector.MessageHandled(labels, time.Millisecond, nil)
+	suite.collector.MessageAcked(labels)
+
+	families, err := suite.registry.Gather()
+	suite.Require().NoError(err)
+
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	suite.Require().ElementsMatch([]string{
+		"test_messages_published_total",
+		"test_messages_received_total",
+		"test_messages_handling_duration_seconds",
+		"test_messages_acknowledgements_total",
+		"test_messages_in_flight",
+	}, names)
+}
+
+func (suite *PrometheusSuite) TestConsumerLag() {
+	suite.collector.ConsumerLag("orders", 0, 12)
+	suite.collector.ConsumerLag("orders", 1, 3)
+	suite.collector.ConsumerLag("orders", 0, 5)
+
+	suite.Require().Equal(5.0, testutil.ToFloat64(suite.collector.consumerLag.WithLabelValues("orders", "0")))
+	suite.Require().Equal(3.0, testutil.ToFloat64(suite.collector.consumerLag.WithLabelValues("orders", "1")))
+}
+
+func (suite *PrometheusSuite) TestAlreadyRegistered() {
+	_, err := NewPrometheus(suite.registry, WithNamespace("test"))
+	suite.Require().Error(err)
+}
@@ -10,10 +10,33 @@ import "context"
 //
 // You can call the next middleware (by calling `next(ctx, msg)`) in the middleware
 // code in order to wrap next code execution (for example, to time execution, or
-// recover in case of panic).
+// recover in case of panic). It can be called several times, in order to execute
+// again the next code (for example, to retry a failing subscription function).
 type Middleware func(ctx context.Context, msg *BrokerMessage, next NextMiddleware) error
 
 // NextMiddleware represents the next middleware that can be executed during the
 // previous middleware. If this is already the last middleware, it will execute
 // the appropriate autogenerated code for reception/sending of messages.
 type NextMiddleware func(ctx context.Context) error
+
+// MiddlewareContext is the information on the operation of the message passed
+// through the middlewares, in order to scope them to some operations.
+type MiddlewareContext struct {
+	// Operation is the name of the operation, as named in the generated code
+	// (like "PingOperation").
+	Operation string
+	// Channel is the address of the channel of the message.
+	Channel string
+	// Direction is "publication", "reception" or "wait-for".
+	Direction string
+}
+
+// MiddlewareContextFrom returns the middleware context from the values set in
+// the context by the generated code.
+func MiddlewareContextFrom(ctx context.Context) MiddlewareContext {
+	var mc MiddlewareContext
+	IfContextSetWith(ctx, ContextKeyIsOperation, func(v string) { mc.Operation = v })
+	IfContextSetWith(ctx, ContextKeyIsChannel, func(v string) { mc.Channel = v })
+	IfContextSetWith(ctx, ContextKeyIsDirection, func(v string) { mc.Direction = v })
+	return mc
+}
@@ -0,0 +1,227 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+const (
+	// ContentEncodingHeader is the header containing the encoding of the
+	// compressed payloads.
+	ContentEncodingHeader = "content-encoding"
+
+	// EncodingGzip is the content encoding of the payloads compressed with gzip.
+	EncodingGzip = "gzip"
+	// EncodingZstd is the content encoding of the payloads compressed with
+	// zstandard.
+	EncodingZstd = "zstd"
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	errZstd     error
+)
+
+// initZstd creates the zstandard encoder and decoder, that can be used
+// concurrently.
+func initZstd() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, errZstd = zstd.NewWriter(nil); errZstd != nil {
+			return
+		}
+		zstdDecoder, errZstd = zstd.NewReader(nil)
+	})
+	return errZstd
+}
+
+// CompressionOption is an option of the Compress middleware.
+type CompressionOption func(c *compression)
+
+// WithCompressionMinSize sets the minimum size of the payloads to compress, as
+// compressing small payloads can increase their size (no minimum if not set).
+func WithCompressionMinSize(size int) CompressionOption {
+	return func(c *compression) {
+		c.minSize = size
+	}
+}
+
+type compression struct {
+	encoding string
+	minSize  int
+}
+
+// Compress is a middleware that compresses the payloads of the published
+// messages with the encoding (EncodingGzip or EncodingZstd), and sets it in
+// the ContentEncodingHeader header. It should be used with the Decompress
+// middleware on the receiving side.
+//
+// NOTE: it should be placed after the middlewares that need the uncompressed
+// payload. The brokers that don't transmit the headers are not supported.
+func Compress(encoding string, options ...CompressionOption) extensions.Middleware {
+	c := compression{encoding: encoding}
+	for _, option := range options {
+		option(&c)
+	}
+
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only compress the published messages, that are not already compressed
+		if extensions.MiddlewareContextFrom(ctx).Direction != "publication" ||
+			len(msg.Headers[ContentEncodingHeader]) > 0 || len(msg.Payload) < c.minSize {
+			return next(ctx)
+		}
+
+		payload, err := compress(c.encoding, msg.Payload)
+		if err != nil {
+			return err
+		}
+
+		msg.Payload = payload
+		if msg.Headers == nil {
+			msg.Headers = make(map[string][]byte)
+		}
+		msg.Headers[ContentEncodingHeader] = []byte(c.encoding)
+
+		return next(ctx)
+	}
+}
+
+// DecompressionOption is an option of the Decompress middleware.
+type DecompressionOption func(d *decompression)
+
+// WithDecompressionMaxSize sets the maximum size of the decompressed payloads,
+// so a small compressed payload can't exhaust the memory of the receiving side
+// (no maximum if not set).
+func WithDecompressionMaxSize(size int) DecompressionOption {
+	return func(d *decompression) {
+		d.maxSize = size
+	}
+}
+
+type decompression struct {
+	maxSize int
+
+	zstdOnce    sync.Once
+	zstdDecoder *zstd.Decoder
+	errZstd     error
+}
+
+// zstdReader returns the zstandard decoder, limited to the maximum size if set.
+func (d *decompression) zstdReader() (*zstd.Decoder, error) {
+	if d.maxSize <= 0 {
+		return zstdDecoder, initZstd()
+	}
+
+	// The decoder rejects the frames whose window is bigger than its maximum,
+	// and the window is at least of zstd.MinWindowSize
+	d.zstdOnce.Do(func() {
+		maxMemory := uint64(max(d.maxSize, zstd.MinWindowSize))
+		d.zstdDecoder, d.errZstd = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxMemory))
+	})
+	return d.zstdDecoder, d.errZstd
+}
+
+// Decompress is a middleware that decompresses the payloads of the received
+// messages, based on their ContentEncodingHeader header, and removes this
+// header. The messages without this header are not modified, and the ones
+// with an unsupported encoding or too large once decompressed are rejected.
+//
+// NOTE: it should be placed before the middlewares that need the uncompressed
+// payload.
+func Decompress(options ...DecompressionOption) extensions.Middleware {
+	d := &decompression{}
+	for _, option := range options {
+		option(d)
+	}
+
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only decompress the received messages, that are compressed
+		encoding := string(msg.Headers[ContentEncodingHeader])
+		if extensions.MiddlewareContextFrom(ctx).Direction != "reception" || encoding == "" {
+			return next(ctx)
+		}
+
+		payload, err := d.decompress(encoding, msg.Payload)
+		if err != nil {
+			return err
+		}
+
+		// Remove the header from a copy, as the headers can be shared with
+		// other subscriptions
+		msg.Payload, msg.Headers = payload, cloneMessage(*msg).Headers
+		delete(msg.Headers, ContentEncodingHeader)
+
+		return next(ctx)
+	}
+}
+
+func compress(encoding string, payload []byte) ([]byte, error) {
+	switch encoding {
+	case EncodingGzip:
+		buf := new(bytes.Buffer)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("compressing payload with gzip: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("compressing payload with gzip: %w", err)
+		}
+		return buf.Bytes(), nil
+	case EncodingZstd:
+		if err := initZstd(); err != nil {
+			return nil, fmt.Errorf("compressing payload with zstd: %w", err)
+		}
+		return zstdEncoder.EncodeAll(payload, nil), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", extensions.ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+func (d *decompression) decompress(encoding string, payload []byte) ([]byte, error) {
+	var decompressed []byte
+	switch encoding {
+	case EncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing payload with gzip: %w", err)
+		}
+		defer r.Close()
+
+		// Read one more byte than the maximum to know if it is exceeded
+		var reader io.Reader = r
+		if d.maxSize > 0 {
+			reader = io.LimitReader(r, int64(d.maxSize)+1)
+		}
+		if decompressed, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("decompressing payload with gzip: %w", err)
+		}
+	case EncodingZstd:
+		decoder, err := d.zstdReader()
+		if err != nil {
+			return nil, fmt.Errorf("decompressing payload with zstd: %w", err)
+		}
+
+		decompressed, err = decoder.DecodeAll(payload, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, fmt.Errorf("%w: more than %d bytes", extensions.ErrDecompressedPayloadTooLarge, d.maxSize)
+		} else if err != nil {
+			return nil, fmt.Errorf("decompressing payload with zstd: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", extensions.ErrUnsupportedContentEncoding, encoding)
+	}
+
+	if d.maxSize > 0 && len(decompressed) > d.maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", extensions.ErrDecompressedPayloadTooLarge, d.maxSize)
+	}
+	return decompressed, nil
+}
@@ -0,0 +1,255 @@
+package middlewares
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+const (
+	// DefaultDeduplicationTTL is the default duration during which a handled
+	// message is considered as a duplicate when received again.
+	DefaultDeduplicationTTL = time.Hour
+	// DefaultMemoryDeduplicationCapacity is the default maximum number of keys
+	// kept by the memory deduplication store.
+	DefaultMemoryDeduplicationCapacity = 10000
+)
+
+// DeduplicationStore stores the keys of the received messages, in order to
+// detect the duplicates.
+type DeduplicationStore interface {
+	// Reserve reserves the key for the duration, returning false if it is
+	// already reserved.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release releases the key, so the message can be handled again.
+	Release(ctx context.Context, key string) error
+}
+
+// DeduplicationKey returns the key identifying the received message, or an
+// empty string if the message can't be deduplicated.
+type DeduplicationKey func(ctx context.Context, msg extensions.BrokerMessage) string
+
+// KeyFromHeaders returns a deduplication key based on the value of the first
+// header that is set among the names (like 'messageId' or 'correlationId').
+func KeyFromHeaders(names ...string) DeduplicationKey {
+	return func(_ context.Context, msg extensions.BrokerMessage) string {
+		for _, name := range names {
+			if v := msg.Headers[name]; len(v) > 0 {
+				return string(v)
+			}
+		}
+		return ""
+	}
+}
+
+// DeduplicationOption is an option of the Deduplication middleware.
+type DeduplicationOption func(d *deduplication)
+
+// WithDeduplicationKey sets the function returning the key of the received
+// messages (KeyFromHeaders("messageId", "correlationId") if not set).
+func WithDeduplicationKey(key DeduplicationKey) DeduplicationOption {
+	return func(d *deduplication) {
+		d.key = key
+	}
+}
+
+// WithDeduplicationTTL sets the duration during which a handled message is
+// considered as a duplicate (DefaultDeduplicationTTL if not set).
+func WithDeduplicationTTL(ttl time.Duration) DeduplicationOption {
+	return func(d *deduplication) {
+		d.ttl = ttl
+	}
+}
+
+type deduplication struct {
+	store DeduplicationStore
+	key   DeduplicationKey
+	ttl   time.Duration
+}
+
+// Deduplication is a middleware that skips the received messages that have
+// already been handled, based on their key, so the subscription function is
+// only executed once per message with the brokers delivering messages at least
+// once. The skipped messages are acknowledged.
+//
+// The keys are scoped by operation, and released when the handling fails, so
+// the message can be handled again when delivered again.
+func Deduplication(store DeduplicationStore, options ...DeduplicationOption) extensions.Middleware {
+	d := deduplication{
+		store: store,
+		key:   KeyFromHeaders("messageId", "correlationId"),
+		ttl:   DefaultDeduplicationTTL,
+	}
+	for _, option := range options {
+		option(&d)
+	}
+
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only deduplicate the received messages
+		mc := extensions.MiddlewareContextFrom(ctx)
+		if mc.Direction != "reception" {
+			return next(ctx)
+		}
+
+		// Get the key of the message, if there is one
+		id := d.key(ctx, *msg)
+		if id == "" {
+			return next(ctx)
+		}
+		key := mc.Operation + ":" + id
+
+		// Skip the message if it has already been received
+		reserved, err := d.store.Reserve(ctx, key, d.ttl)
+		if err != nil {
+			return fmt.Errorf("deduplication of message %q: %w", id, err)
+		} else if !reserved {
+			return fmt.Errorf("%w: message %q has already been received", extensions.ErrSkipMessage, id)
+		}
+
+		// Handle the message, and release the key if it fails
+		if err := next(ctx); err != nil {
+			if releaseErr := d.store.Release(ctx, key); releaseErr != nil {
+				return fmt.Errorf("%w (and releasing deduplication key: %w)", err, releaseErr)
+			}
+			return err
+		}
+
+		return nil
+	}
+}
+
+// MemoryDeduplicationStore is an in-memory deduplication store, that keeps the
+// most recently reserved keys up to its capacity.
+type MemoryDeduplicationStore struct {
+	capacity int
+
+	mutex sync.Mutex
+	keys  map[string]*list.Element
+	order *list.List
+}
+
+type memoryDeduplicationEntry struct {
+	key     string
+	expires time.Time
+}
+
+var _ DeduplicationStore = (*MemoryDeduplicationStore)(nil)
+
+// NewMemoryDeduplicationStore creates a new in-memory deduplication store with
+// the maximum number of keys (DefaultMemoryDeduplicationCapacity if not
+// positive).
+func NewMemoryDeduplicationStore(capacity int) *MemoryDeduplicationStore {
+	if capacity <= 0 {
+		capacity = DefaultMemoryDeduplicationCapacity
+	}
+
+	return &MemoryDeduplicationStore{
+		capacity: capacity,
+		keys:     make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Reserve reserves the key for the duration, returning false if it is already
+// reserved. The least recently reserved key is removed if the capacity is
+// reached.
+func (s *MemoryDeduplicationStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if elem, exists := s.keys[key]; exists {
+		if now.Before(elem.Value.(*memoryDeduplicationEntry).expires) {
+			return false, nil
+		}
+		s.remove(elem)
+	}
+
+	s.keys[key] = s.order.PushFront(&memoryDeduplicationEntry{key: key, expires: now.Add(ttl)})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+
+	return true, nil
+}
+
+// Release releases the key.
+func (s *MemoryDeduplicationStore) Release(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if elem, exists := s.keys[key]; exists {
+		s.remove(elem)
+	}
+
+	return nil
+}
+
+// Len returns the number of keys in the store, including the expired ones
+// that have not been removed yet.
+func (s *MemoryDeduplicationStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.order.Len()
+}
+
+func (s *MemoryDeduplicationStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.keys, elem.Value.(*memoryDeduplicationEntry).key)
+}
+
+// RedisClient is the subset of the commands of a Redis client used by the Redis
+// deduplication store, in order to use the client of your choice.
+//
+// For example, with github.com/redis/go-redis:
+//
+//	type goRedisClient struct{ *redis.Client }
+//
+//	func (c goRedisClient) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+//		return c.Client.SetNX(ctx, key, value, ttl).Result()
+//	}
+//
+//	func (c goRedisClient) Del(ctx context.Context, key string) error {
+//		return c.Client.Del(ctx, key).Err()
+//	}
+type RedisClient interface {
+	// SetNX sets the key with the value and the expiration if it doesn't
+	// exist, returning true if it has been set.
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	// Del deletes the key.
+	Del(ctx context.Context, key string) error
+}
+
+// RedisDeduplicationStore is a deduplication store based on Redis, that can be
+// shared by several instances of an application.
+type RedisDeduplicationStore struct {
+	client RedisClient
+	prefix string
+}
+
+var _ DeduplicationStore = (*RedisDeduplicationStore)(nil)
+
+// NewRedisDeduplicationStore creates a new Redis deduplication store, whose keys
+// are prefixed by the prefix (like 'myapp:dedup:').
+func NewRedisDeduplicationStore(client RedisClient, prefix string) *RedisDeduplicationStore {
+	return &RedisDeduplicationStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Reserve reserves the key for the duration, returning false if it is already
+// reserved.
+func (s *RedisDeduplicationStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+key, "1", ttl)
+}
+
+// Release releases the key.
+func (s *RedisDeduplicationStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key)
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// EncryptionKeyIDHeader is the header containing the ID of the key used to
+// encrypt the payload.
+const EncryptionKeyIDHeader = "encryption-key-id"
+
+// Encrypt is a middleware that encrypts the payloads of the published messages
+// with AES-GCM, using the current key of the provider (of 16, 24 or 32 bytes
+// for AES-128, AES-192 or AES-256). The ID of the key is set in the
+// EncryptionKeyIDHeader header, and authenticated with the payload. It should
+// be used with the Decrypt middleware on the receiving side.
+//
+// NOTE: it should be placed after the middlewares that need the clear payload.
+// The headers are not encrypted.
+func Encrypt(provider KeyProvider) extensions.Middleware {
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only encrypt the published messages
+		if extensions.MiddlewareContextFrom(ctx).Direction != "publication" {
+			return next(ctx)
+		}
+
+		id, key, err := provider.CurrentKey(ctx)
+		if err != nil {
+			return fmt.Errorf("getting encryption key: %w", err)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return fmt.Errorf("encryption with key %q: %w", id, err)
+		}
+
+		// Prefix the encrypted payload with its random nonce
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(msg.Payload)+aead.Overhead())
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("generating encryption nonce: %w", err)
+		}
+		msg.Payload = aead.Seal(nonce, nonce, msg.Payload, []byte(id))
+
+		if msg.Headers == nil {
+			msg.Headers = make(map[string][]byte)
+		}
+		msg.Headers[EncryptionKeyIDHeader] = []byte(id)
+
+		return next(ctx)
+	}
+}
+
+// Decrypt is a middleware that decrypts the payloads of the received messages
+// encrypted by the Encrypt middleware, with the key of the provider whose ID
+// is in the EncryptionKeyIDHeader header. The messages that are not encrypted
+// or can't be decrypted are rejected with an error wrapping
+// extensions.ErrDecryptionFailed.
+//
+// NOTE: it should be placed before the middlewares that need the clear payload.
+func Decrypt(provider KeyProvider) extensions.Middleware {
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only decrypt the received messages
+		if extensions.MiddlewareContextFrom(ctx).Direction != "reception" {
+			return next(ctx)
+		}
+
+		id := string(msg.Headers[EncryptionKeyIDHeader])
+		if id == "" {
+			return fmt.Errorf("%w: no %q header", extensions.ErrDecryptionFailed, EncryptionKeyIDHeader)
+		}
+
+		key, err := provider.Key(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrDecryptionFailed, err)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return fmt.Errorf("%w: key %q: %w", extensions.ErrDecryptionFailed, id, err)
+		}
+
+		if len(msg.Payload) < aead.NonceSize() {
+			return fmt.Errorf("%w: payload too short", extensions.ErrDecryptionFailed)
+		}
+		nonce, encrypted := msg.Payload[:aead.NonceSize()], msg.Payload[aead.NonceSize():]
+		payload, err := aead.Open(nil, nonce, encrypted, []byte(id))
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrDecryptionFailed, err)
+		}
+		msg.Payload = payload
+
+		return next(ctx)
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
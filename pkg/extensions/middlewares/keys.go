@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// KeyProvider provides the keys used to encrypt or sign the messages. Each key
+// has an ID, that is set in the headers of the messages, so the keys can be
+// rotated while the messages protected with the previous keys are still
+// received.
+type KeyProvider interface {
+	// CurrentKey returns the ID and the key to protect the published
+	// messages.
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key with the ID, to decrypt or verify the received
+	// messages. It should return an error wrapping extensions.ErrUnknownKey if
+	// there is no key with this ID.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeyProvider is a key provider with a fixed set of keys.
+type StaticKeyProvider struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+var _ KeyProvider = StaticKeyProvider{}
+
+// NewStaticKeyProvider creates a new key provider with the keys by ID, using
+// the key with the current ID to protect the published messages.
+//
+// To rotate the keys, the new key can be added as the current key, while the
+// previous ones are kept until there is no more message protected with them.
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) (StaticKeyProvider, error) {
+	if _, exists := keys[currentID]; !exists {
+		return StaticKeyProvider{}, fmt.Errorf("%w: current key %q", extensions.ErrUnknownKey, currentID)
+	}
+
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		copied[id] = key
+	}
+
+	return StaticKeyProvider{currentID: currentID, keys: copied}, nil
+}
+
+// CurrentKey returns the ID and the current key.
+func (p StaticKeyProvider) CurrentKey(_ context.Context) (string, []byte, error) {
+	return p.currentID, p.keys[p.currentID], nil
+}
+
+// Key returns the key with the ID.
+func (p StaticKeyProvider) Key(_ context.Context, id string) ([]byte, error) {
+	key, exists := p.keys[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %q", extensions.ErrUnknownKey, id)
+	}
+	return key, nil
+}
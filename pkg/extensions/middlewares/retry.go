@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of attempts to
+	// handle a received message, including the first one.
+	DefaultRetryMaxAttempts = 3
+	// DefaultRetryInitialBackoff is the default duration to wait before the
+	// first retry.
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	// DefaultRetryMultiplier is the default factor applied to the backoff
+	// after each retry.
+	DefaultRetryMultiplier = 2.0
+)
+
+// DeadLetterPublisher forwards a message that has not been handled after the
+// maximum number of attempts, with the error of the last attempt. If it returns
+// nil, the message is acknowledged, otherwise it is not.
+type DeadLetterPublisher func(ctx context.Context, msg extensions.BrokerMessage, err error) error
+
+// RetryPolicy is the policy of the retries of the Retry middleware. The zero
+// values of its fields are replaced by their default values.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one
+	// (DefaultRetryMaxAttempts if not set).
+	MaxAttempts int
+	// InitialBackoff is the duration to wait before the first retry
+	// (DefaultRetryInitialBackoff if not set).
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum duration to wait between two attempts (no
+	// maximum if not set).
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the backoff after each retry
+	// (DefaultRetryMultiplier if not set).
+	Multiplier float64
+	// Jitter is the fraction of the backoff that is randomly added or removed,
+	// between 0 and 1 (no jitter if not set).
+	Jitter float64
+	// DeadLetter is called with the message after the maximum number of
+	// attempts. If not set, the message is not acknowledged.
+	DeadLetter DeadLetterPublisher
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff returns the duration to wait before the retry (starting at 1).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		//nolint:gosec // the jitter doesn't need a secure random
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// Retry is a middleware that executes again the middlewares coming after it and
+// the subscription function when they fail on a received message, waiting
+// between the attempts with a jittered exponential backoff. After the maximum
+// number of attempts, the message is forwarded to the dead letter publisher if
+// there is one, or not acknowledged otherwise.
+//
+// NOTE: it should be placed before the middlewares that should not be executed
+// again. The messages in publication are not retried.
+func Retry(policy RetryPolicy) extensions.Middleware {
+	policy = policy.withDefaults()
+
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only retry the received messages
+		if extensions.MiddlewareContextFrom(ctx).Direction != "reception" {
+			return next(ctx)
+		}
+
+		// Keep the message as received, to give it again at each attempt
+		original := cloneMessage(*msg)
+
+		var err error
+		for attempt := 1; ; attempt++ {
+			*msg = cloneMessage(original)
+			if err = next(ctx); err == nil {
+				return nil
+			} else if attempt >= policy.MaxAttempts {
+				break
+			}
+
+			// Wait before the next attempt, unless the context is canceled
+			timer := time.NewTimer(policy.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("%w: %w (after %d attempt(s))", extensions.ErrContextCanceled, err, attempt)
+			case <-timer.C:
+			}
+		}
+
+		// Forward the message to the dead letter publisher, if there is one
+		if policy.DeadLetter != nil {
+			return policy.DeadLetter(ctx, original, err)
+		}
+
+		return fmt.Errorf("%w: %w (after %d attempt(s))", extensions.ErrMaxAttemptsReached, err, policy.MaxAttempts)
+	}
+}
+
+// DeadLetterTo returns a dead letter publisher that publishes the message on a
+// channel of the broker, with the error in the 'x-error' header and the
+// original channel in the 'x-original-channel' header.
+func DeadLetterTo(broker extensions.BrokerController, channel string) DeadLetterPublisher {
+	return func(ctx context.Context, msg extensions.BrokerMessage, err error) error {
+		msg = cloneMessage(msg)
+		msg.Headers["x-error"] = []byte(err.Error())
+		extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannel, func(original string) {
+			msg.Headers["x-original-channel"] = []byte(original)
+		})
+
+		return broker.Publish(ctx, channel, msg)
+	}
+}
+
+// cloneMessage returns a copy of the message that doesn't share its headers.
+func cloneMessage(msg extensions.BrokerMessage) extensions.BrokerMessage {
+	headers := make(map[string][]byte, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+	return msg
+}
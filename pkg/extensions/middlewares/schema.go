@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// ValidateSchema is a middleware that validates the payloads of the published
+// and received messages against the JSON Schema of their message, embedded in
+// the generated code from the specification. This protects the application
+// from the messages of producers that don't use the generated code.
+//
+// The invalid messages are rejected with extensions.ValidationErrors, that
+// wraps extensions.ErrValidation: the received ones are not acknowledged and
+// given to the error handler, and the publication of the other ones fails.
+//
+// NOTE: it should be placed after the middlewares modifying the payload on
+// reception (like Decompress or Decrypt), and before them on publication. The
+// payloads whose content type is not JSON are not validated.
+func ValidateSchema() extensions.Middleware {
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		var schema string
+		extensions.IfContextSetWith(ctx, extensions.ContextKeyIsPayloadSchema, func(s string) {
+			schema = s
+		})
+
+		// Only validate the JSON payloads that have a schema
+		if schema == "" || (msg.ContentType != "" && !strings.Contains(msg.ContentType, "json")) {
+			return next(ctx)
+		}
+
+		var errs extensions.ValidationErrors
+		errs.AddNested("payload", extensions.ValidateJSON(schema, msg.Payload))
+		if err := errs.Err(); err != nil {
+			return err
+		}
+
+		return next(ctx)
+	}
+}
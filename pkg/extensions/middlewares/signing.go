@@ -0,0 +1,142 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+const (
+	// SignatureHeader is the header containing the signature of the payload,
+	// encoded in base64.
+	SignatureHeader = "signature"
+	// SignatureKeyIDHeader is the header containing the ID of the key used to
+	// sign the payload.
+	SignatureKeyIDHeader = "signature-key-id"
+)
+
+// SignatureAlgorithm is an algorithm to sign the payloads.
+type SignatureAlgorithm string
+
+const (
+	// SignatureHMACSHA256 signs the payloads with HMAC-SHA256, with the same
+	// secret key on the publishing and receiving sides.
+	SignatureHMACSHA256 SignatureAlgorithm = "hmac-sha256"
+	// SignatureEd25519 signs the payloads with Ed25519. The current key of the
+	// publishing side is an ed25519.PrivateKey, and the keys of the receiving
+	// side are the corresponding ed25519.PublicKey.
+	SignatureEd25519 SignatureAlgorithm = "ed25519"
+)
+
+// sign returns the signature of the payload.
+func (alg SignatureAlgorithm) sign(key, payload []byte) ([]byte, error) {
+	switch alg {
+	case SignatureHMACSHA256:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		return mac.Sum(nil), nil
+	case SignatureEd25519:
+		if len(key) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("ed25519 private key should be %d bytes, is %d", ed25519.PrivateKeySize, len(key))
+		}
+		return ed25519.Sign(ed25519.PrivateKey(key), payload), nil
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+}
+
+// verify checks the signature of the payload.
+func (alg SignatureAlgorithm) verify(key, payload, signature []byte) (bool, error) {
+	switch alg {
+	case SignatureHMACSHA256:
+		expected, _ := alg.sign(key, payload)
+		return hmac.Equal(expected, signature), nil
+	case SignatureEd25519:
+		switch len(key) {
+		case ed25519.PublicKeySize:
+		case ed25519.PrivateKeySize:
+			key = ed25519.PrivateKey(key).Public().(ed25519.PublicKey)
+		default:
+			return false, fmt.Errorf("ed25519 public key should be %d bytes, is %d", ed25519.PublicKeySize, len(key))
+		}
+		return ed25519.Verify(ed25519.PublicKey(key), payload, signature), nil
+	default:
+		return false, fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+}
+
+// Sign is a middleware that signs the payloads of the published messages with
+// the algorithm and the current key of the provider. The signature and the ID
+// of the key are set in the SignatureHeader and SignatureKeyIDHeader headers.
+// It should be used with the VerifySignature middleware on the receiving side.
+//
+// NOTE: it should be placed after the middlewares modifying the payload (like
+// Encrypt or Compress).
+func Sign(alg SignatureAlgorithm, provider KeyProvider) extensions.Middleware {
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only sign the published messages
+		if extensions.MiddlewareContextFrom(ctx).Direction != "publication" {
+			return next(ctx)
+		}
+
+		id, key, err := provider.CurrentKey(ctx)
+		if err != nil {
+			return fmt.Errorf("getting signature key: %w", err)
+		}
+
+		signature, err := alg.sign(key, msg.Payload)
+		if err != nil {
+			return fmt.Errorf("signature with key %q: %w", id, err)
+		}
+
+		if msg.Headers == nil {
+			msg.Headers = make(map[string][]byte)
+		}
+		msg.Headers[SignatureHeader] = []byte(base64.StdEncoding.EncodeToString(signature))
+		msg.Headers[SignatureKeyIDHeader] = []byte(id)
+
+		return next(ctx)
+	}
+}
+
+// VerifySignature is a middleware that verifies the signature of the payloads
+// of the received messages signed by the Sign middleware, with the algorithm
+// and the key of the provider whose ID is in the SignatureKeyIDHeader header.
+// The messages without a valid signature are rejected with an error wrapping
+// extensions.ErrInvalidSignature.
+//
+// NOTE: it should be placed before the middlewares modifying the payload (like
+// Decrypt or Decompress).
+func VerifySignature(alg SignatureAlgorithm, provider KeyProvider) extensions.Middleware {
+	return func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		// Only verify the received messages
+		if extensions.MiddlewareContextFrom(ctx).Direction != "reception" {
+			return next(ctx)
+		}
+
+		id := string(msg.Headers[SignatureKeyIDHeader])
+		signature, err := base64.StdEncoding.DecodeString(string(msg.Headers[SignatureHeader]))
+		if id == "" || err != nil || len(signature) == 0 {
+			return fmt.Errorf("%w: no valid %q and %q headers",
+				extensions.ErrInvalidSignature, SignatureHeader, SignatureKeyIDHeader)
+		}
+
+		key, err := provider.Key(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrInvalidSignature, err)
+		}
+
+		if valid, err := alg.verify(key, msg.Payload, signature); err != nil {
+			return fmt.Errorf("%w: key %q: %w", extensions.ErrInvalidSignature, id, err)
+		} else if !valid {
+			return fmt.Errorf("%w: signature doesn't match with key %q", extensions.ErrInvalidSignature, id)
+		}
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,87 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Option is an optional value, that is either unset or set to a value (that
+// can be its zero value). It is used for the optional fields generated with the
+// "option" strategy.
+//
+// Unset options are encoded in JSON as null, or omitted with the 'omitzero'
+// tag from Go 1.24. Null and absent JSON values are decoded as unset options.
+type Option[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an option set to the value.
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, set: true}
+}
+
+// None returns an unset option.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// Get returns the value of the option, and true if it is set.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// IsSet returns true if the option is set.
+func (o Option[T]) IsSet() bool {
+	return o.set
+}
+
+// IsZero returns true if the option is unset, in order to omit it with the
+// 'omitzero' JSON tag.
+func (o Option[T]) IsZero() bool {
+	return !o.set
+}
+
+// ValueOr returns the value of the option if it is set, or the default value.
+func (o Option[T]) ValueOr(defaultValue T) T {
+	if !o.set {
+		return defaultValue
+	}
+	return o.value
+}
+
+// Set sets the option to the value.
+func (o *Option[T]) Set(value T) {
+	o.value, o.set = value, true
+}
+
+// Unset unsets the option.
+func (o *Option[T]) Unset() {
+	var zero T
+	o.value, o.set = zero, false
+}
+
+// MarshalJSON encodes the value of the option in JSON, or null if it is unset.
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes the value of the option from JSON, and unsets it if the
+// value is null.
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Unset()
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Set(value)
+
+	return nil
+}
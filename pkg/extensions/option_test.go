@@ -0,0 +1,52 @@
+package extensions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOptionSuite(t *testing.T) {
+	suite.Run(t, new(OptionSuite))
+}
+
+type OptionSuite struct {
+	suite.Suite
+}
+
+func (suite *OptionSuite) TestSetAndUnset() {
+	o := None[int]()
+	suite.Require().False(o.IsSet())
+	suite.Require().True(o.IsZero())
+	suite.Require().Equal(42, o.ValueOr(42))
+
+	o.Set(0)
+	v, ok := o.Get()
+	suite.Require().True(ok)
+	suite.Require().Equal(0, v)
+	suite.Require().Equal(0, o.ValueOr(42))
+
+	o.Unset()
+	suite.Require().Equal(None[int](), o)
+}
+
+func (suite *OptionSuite) TestJSON() {
+	type object struct {
+		Name  Option[string] `json:"name"`
+		Count Option[int]    `json:"count"`
+	}
+
+	b, err := json.Marshal(object{Name: Some("")})
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{"name":"","count":null}`, string(b))
+
+	var o object
+	suite.Require().NoError(json.Unmarshal([]byte(`{"count":0}`), &o))
+	suite.Require().Equal(object{Count: Some(0)}, o)
+
+	suite.Require().NoError(json.Unmarshal([]byte(`{"name":null}`), &o))
+	suite.Require().Equal(object{Count: Some(0)}, o)
+
+	suite.Require().Error(json.Unmarshal([]byte(`{"count":"zero"}`), &o))
+}
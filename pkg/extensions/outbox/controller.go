@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Check that it still fills the interface.
+var _ extensions.BrokerController = (*Controller)(nil)
+
+// Controller is a broker controller storing the published messages in the
+// outbox, instead of publishing them directly to the broker. The subscriptions
+// are done on the underlying broker.
+//
+// It should be given to the controllers sending the messages, while a Relay
+// publishes them to the broker.
+type Controller struct {
+	store  Store
+	broker extensions.BrokerController
+}
+
+// NewController creates a broker controller storing the published messages in
+// the store, and subscribing on the broker.
+func NewController(store Store, broker extensions.BrokerController) *Controller {
+	return &Controller{
+		store:  store,
+		broker: broker,
+	}
+}
+
+// Publish stores the message in the outbox, within the transaction of the
+// context if any.
+func (c *Controller) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return c.store.Save(ctx, channel, bm)
+}
+
+// PublishBatch stores the messages in the outbox, within the transaction of the
+// context if any.
+func (c *Controller) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	return c.store.Save(ctx, channel, bms...)
+}
+
+// Subscribe subscribes to the channel on the underlying broker.
+func (c *Controller) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	return c.broker.Subscribe(ctx, channel)
+}
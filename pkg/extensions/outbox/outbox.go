@@ -0,0 +1,62 @@
+// Package outbox implements the transactional outbox pattern: the messages
+// sent by the controllers are stored in a database, within the transaction of
+// the application, then published to the broker by a relay. This way, the
+// messages are published if and only if the transaction is committed, at least
+// once.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrOutbox is the generic error of the outbox.
+	ErrOutbox = fmt.Errorf("%w: outbox", extensions.ErrAsyncAPI)
+)
+
+// Message is a message stored in the outbox, waiting to be published.
+type Message struct {
+	// ID is the identifier of the message in the store, increasing with the
+	// order in which the messages have been stored.
+	ID int64
+	// Channel is the address of the channel where the message is published.
+	Channel string
+	// Message is the message to publish.
+	Message extensions.BrokerMessage
+}
+
+// PublishFunc publishes a message of the outbox to the broker.
+type PublishFunc func(ctx context.Context, msg Message) error
+
+// Store is the persistence of the messages of the outbox.
+type Store interface {
+	// Save stores the messages to publish on the channel. If the context has a
+	// transaction (see WithTx), they should be stored within it.
+	Save(ctx context.Context, channel string, msgs ...extensions.BrokerMessage) error
+
+	// Process gives up to limit stored messages to publish, in the order they
+	// have been stored, and removes the ones published without error. It
+	// should stop at the first error, in order to keep the messages order, and
+	// return the number of messages published. The messages being processed
+	// should not be given to another concurrent call, even from another
+	// process.
+	Process(ctx context.Context, limit int, publish PublishFunc) (int, error)
+}
+
+// WithTx returns a context in which the messages sent by the controllers are
+// stored within the transaction. They will be published only once it is
+// committed.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, extensions.ContextKeyIsOutboxTransaction, tx)
+}
+
+// TxFromContext returns the transaction set in the context with WithTx, or
+// false if there is none.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(extensions.ContextKeyIsOutboxTransaction).(*sql.Tx)
+	return tx, ok && tx != nil
+}
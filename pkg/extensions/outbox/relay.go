@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+const (
+	// DefaultPollInterval is the default time waited by the relay between two
+	// reads of the outbox, once all the stored messages are published.
+	DefaultPollInterval = time.Second
+
+	// DefaultBatchSize is the default maximum number of messages read at once
+	// from the outbox by the relay.
+	DefaultBatchSize = 100
+)
+
+// Relay publishes the messages stored in the outbox to the broker.
+//
+// Several relays can run on the same outbox (e.g. one per instance of the
+// application), but the messages order is then only kept within a batch.
+type Relay struct {
+	store        Store
+	broker       extensions.BrokerController
+	logger       extensions.Logger
+	pollInterval time.Duration
+	batchSize    int
+}
+
+// RelayOption adds an option to the Relay.
+type RelayOption func(relay *Relay)
+
+// NewRelay creates a relay publishing the messages of the store to the broker.
+func NewRelay(store Store, broker extensions.BrokerController, options ...RelayOption) *Relay {
+	// Create relay
+	r := Relay{
+		store:        store,
+		broker:       broker,
+		logger:       extensions.DummyLogger{},
+		pollInterval: DefaultPollInterval,
+		batchSize:    DefaultBatchSize,
+	}
+
+	// Execute options
+	for _, option := range options {
+		option(&r)
+	}
+
+	return &r
+}
+
+// WithLogger lets add a logger to the Relay.
+func WithLogger(logger extensions.Logger) RelayOption {
+	return func(relay *Relay) {
+		relay.logger = logger
+	}
+}
+
+// WithPollInterval sets the time waited between two reads of the outbox, once
+// all the stored messages are published or after a failure.
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(relay *Relay) {
+		relay.pollInterval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of messages read at once from the
+// outbox. The sizes that are not positive are ignored, and DefaultBatchSize
+// is used instead.
+func WithBatchSize(size int) RelayOption {
+	return func(relay *Relay) {
+		if size > 0 {
+			relay.batchSize = size
+		}
+	}
+}
+
+// Run publishes the messages of the outbox until the context is canceled.
+// The failures are logged and the messages are published again after the poll
+// interval.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error(ctx, "failed to relay outbox messages",
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// Flush publishes the messages of the outbox until there is none left, and
+// returns the number of messages published.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	var total int
+	for {
+		n, err := r.store.Process(ctx, r.batchSize, r.publish)
+		total += n
+		if err != nil || n < r.batchSize {
+			return total, err
+		}
+	}
+}
+
+// publish publishes a message of the outbox to the broker.
+func (r *Relay) publish(ctx context.Context, msg Message) error {
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsChannel, msg.Channel)
+	return r.broker.Publish(ctx, msg.Channel, msg.Message)
+}
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerAndFlush(t *testing.T) {
+	_, db := newFakeDB()
+	store := NewPostgresStore(db)
+	broker, err := inmemory.NewController()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	// The published messages are stored, not published to the broker
+	c := NewController(store, broker)
+	require.NoError(t, c.Publish(context.Background(), "orders", extensions.BrokerMessage{Payload: []byte("1")}))
+	require.NoError(t, c.PublishBatch(context.Background(), "orders", []extensions.BrokerMessage{
+		{Payload: []byte("2")}, {Payload: []byte("3")},
+	}))
+	assert.Empty(t, broker.Published("orders"))
+
+	// Until they are published by the relay, in batches
+	relay := NewRelay(store, broker, WithBatchSize(2))
+	n, err := relay.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	published := broker.Published("orders")
+	require.Len(t, published, 3)
+	for i, msg := range published {
+		assert.Equal(t, fmt.Sprint(i+1), string(msg.Payload))
+	}
+}
+
+func TestWithBatchSize(t *testing.T) {
+	assert.Equal(t, 10, NewRelay(nil, nil, WithBatchSize(10)).batchSize)
+	assert.Equal(t, DefaultBatchSize, NewRelay(nil, nil, WithBatchSize(0)).batchSize)
+	assert.Equal(t, DefaultBatchSize, NewRelay(nil, nil, WithBatchSize(-1)).batchSize)
+}
+
+func TestRelayRun(t *testing.T) {
+	_, db := newFakeDB()
+	store := NewMySQLStore(db)
+	broker, err := inmemory.NewController()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewRelay(store, broker, WithPollInterval(10*time.Millisecond)).Run(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, NewController(store, broker).Publish(context.Background(), "orders",
+		extensions.BrokerMessage{Payload: []byte("1")}))
+	assert.Eventually(t, func() bool {
+		return len(broker.Published("orders")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the relay should stop once the context is canceled")
+	}
+}
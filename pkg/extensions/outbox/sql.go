@@ -0,0 +1,217 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Check that it still fills the interface.
+var _ Store = (*SQLStore)(nil)
+
+// DefaultTable is the default name of the table of the outbox messages.
+const DefaultTable = "asyncapi_outbox"
+
+// dialect is the SQL specific to a database.
+type dialect struct {
+	// placeholder returns the placeholder of the nth argument of a query,
+	// starting at 1.
+	placeholder func(n int) string
+	// createTable is the format of the statement creating the table, with its
+	// name as argument.
+	createTable string
+}
+
+var (
+	postgresDialect = dialect{
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		createTable: `CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			channel TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			partition_key TEXT NOT NULL,
+			headers BYTEA NOT NULL,
+			payload BYTEA NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+
+	mysqlDialect = dialect{
+		placeholder: func(_ int) string { return "?" },
+		createTable: `CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			channel VARCHAR(255) NOT NULL,
+			content_type VARCHAR(255) NOT NULL,
+			partition_key VARCHAR(255) NOT NULL,
+			headers BLOB NOT NULL,
+			payload LONGBLOB NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+)
+
+// SQLStore is the store of the outbox messages in a SQL database. The database
+// driver should be registered by the application.
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+	table   string
+}
+
+// StoreOption adds an option to the SQLStore.
+type StoreOption func(store *SQLStore)
+
+// NewPostgresStore creates a store of the outbox messages in a PostgreSQL
+// database.
+func NewPostgresStore(db *sql.DB, options ...StoreOption) *SQLStore {
+	return newSQLStore(db, postgresDialect, options...)
+}
+
+// NewMySQLStore creates a store of the outbox messages in a MySQL database,
+// from version 8.0.
+func NewMySQLStore(db *sql.DB, options ...StoreOption) *SQLStore {
+	return newSQLStore(db, mysqlDialect, options...)
+}
+
+func newSQLStore(db *sql.DB, d dialect, options ...StoreOption) *SQLStore {
+	// Create store
+	s := SQLStore{
+		db:      db,
+		dialect: d,
+		table:   DefaultTable,
+	}
+
+	// Execute options
+	for _, option := range options {
+		option(&s)
+	}
+
+	return &s
+}
+
+// WithTable sets the name of the table of the outbox messages.
+func WithTable(name string) StoreOption {
+	return func(store *SQLStore) {
+		store.table = name
+	}
+}
+
+// CreateTable creates the table of the outbox messages, if it doesn't exist.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(s.dialect.createTable, s.table)); err != nil {
+		return fmt.Errorf("%w: creating table %q: %w", ErrOutbox, s.table, err)
+	}
+	return nil
+}
+
+// Save stores the messages to publish on the channel, within the transaction
+// of the context if any. Otherwise, they are stored directly.
+func (s *SQLStore) Save(ctx context.Context, channel string, msgs ...extensions.BrokerMessage) error {
+	var exec interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	} = s.db
+	if tx, ok := TxFromContext(ctx); ok {
+		exec = tx
+	}
+
+	p := s.dialect.placeholder
+	query := fmt.Sprintf("INSERT INTO %s (channel, content_type, partition_key, headers, payload) "+
+		"VALUES (%s, %s, %s, %s, %s)", s.table, p(1), p(2), p(3), p(4), p(5))
+
+	for _, msg := range msgs {
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return fmt.Errorf("%w: encoding headers: %w", ErrOutbox, err)
+		}
+
+		payload := msg.Payload
+		if payload == nil {
+			payload = []byte{}
+		}
+
+		if _, err := exec.ExecContext(ctx, query, channel, msg.ContentType, msg.Key, headers, payload); err != nil {
+			return fmt.Errorf("%w: storing message: %w", ErrOutbox, err)
+		}
+	}
+
+	return nil
+}
+
+// Process gives up to limit stored messages to publish, in the order they have
+// been stored, and removes the ones published without error. The messages are
+// locked during their processing, so they are skipped by the other relays.
+func (s *SQLStore) Process(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: beginning transaction: %w", ErrOutbox, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	msgs, err := s.lock(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	// Publish the messages in order, until the first failure
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = %s", s.table, s.dialect.placeholder(1))
+	var published int
+	var publishErr error
+	for _, msg := range msgs {
+		if publishErr = publish(ctx, msg); publishErr != nil {
+			break
+		}
+
+		if _, err := tx.ExecContext(ctx, query, msg.ID); err != nil {
+			return 0, fmt.Errorf("%w: removing message %d: %w", ErrOutbox, msg.ID, err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: committing transaction: %w", ErrOutbox, err)
+	}
+
+	return published, publishErr
+}
+
+// lock reads and locks up to limit stored messages, skipping the ones already
+// locked.
+func (s *SQLStore) lock(ctx context.Context, tx *sql.Tx, limit int) ([]Message, error) {
+	query := fmt.Sprintf("SELECT id, channel, content_type, partition_key, headers, payload FROM %s "+
+		"ORDER BY id LIMIT %s FOR UPDATE SKIP LOCKED", s.table, s.dialect.placeholder(1))
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading messages: %w", ErrOutbox, err)
+	}
+	defer rows.Close()
+
+	msgs := make([]Message, 0, limit)
+	for rows.Next() {
+		var msg Message
+		var headers []byte
+		if err := rows.Scan(&msg.ID, &msg.Channel, &msg.Message.ContentType,
+			&msg.Message.Key, &headers, &msg.Message.Payload); err != nil {
+			return nil, fmt.Errorf("%w: reading message: %w", ErrOutbox, err)
+		}
+
+		msg.Message.Headers = make(map[string][]byte)
+		if err := json.Unmarshal(headers, &msg.Message.Headers); err != nil {
+			return nil, fmt.Errorf("%w: decoding headers of message %d: %w", ErrOutbox, msg.ID, err)
+		}
+		if msg.Message.Headers == nil {
+			msg.Message.Headers = make(map[string][]byte)
+		}
+
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: reading messages: %w", ErrOutbox, err)
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,278 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB is a database with a single outbox table, reached through a fake SQL
+// driver. The statements executed in a transaction are applied on commit.
+type fakeDB struct {
+	mu      sync.Mutex
+	rows    [][]driver.Value
+	nextID  int64
+	queries []string
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{}
+	return f, sql.OpenDB(f)
+}
+
+func (f *fakeDB) Connect(_ context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                          { return nil }
+
+func (f *fakeDB) executedQueries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+// exec applies a statement on the table.
+func (f *fakeDB) exec(query string, args []driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		f.nextID++
+		f.rows = append(f.rows, append([]driver.Value{f.nextID}, args...))
+	case strings.HasPrefix(query, "DELETE"):
+		for i, r := range f.rows {
+			if r[0] == args[0] {
+				f.rows = append(f.rows[:i], f.rows[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+type fakeConn struct {
+	db      *fakeDB
+	pending []func()
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, query)
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.pending = make([]func(), 0)
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	for _, fn := range c.pending {
+		fn()
+	}
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.conn.pending != nil {
+		s.conn.pending = append(s.conn.pending, func() { s.conn.db.exec(s.query, args) })
+	} else {
+		s.conn.db.exec(s.query, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.db.mu.Lock()
+	defer s.conn.db.mu.Unlock()
+
+	limit := int(args[0].(int64))
+	rows := s.conn.db.rows
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return &fakeRows{rows: append([][]driver.Value(nil), rows...)}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "channel", "content_type", "partition_key", "headers", "payload"}
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// collect returns a publish function recording the published messages, and
+// failing on the message with the failing payload, if any.
+func collect(published *[]Message, failing string) PublishFunc {
+	return func(_ context.Context, msg Message) error {
+		if failing != "" && string(msg.Message.Payload) == failing {
+			return errors.New("failure")
+		}
+		*published = append(*published, msg)
+		return nil
+	}
+}
+
+func TestSQLStoreSaveInTransaction(t *testing.T) {
+	_, db := newFakeDB()
+	store := NewPostgresStore(db)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, store.Save(WithTx(context.Background(), tx), "orders", extensions.BrokerMessage{
+		ContentType: "application/json",
+		Key:         "customer-1",
+		Headers:     map[string][]byte{"correlation-id": []byte("1234")},
+		Payload:     []byte(`{"id":1}`),
+	}))
+
+	// The message is not published before the transaction is committed
+	var published []Message
+	n, err := store.Process(context.Background(), 10, collect(&published, ""))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, tx.Commit())
+	n, err = store.Process(context.Background(), 10, collect(&published, ""))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []Message{{
+		ID:      1,
+		Channel: "orders",
+		Message: extensions.BrokerMessage{
+			ContentType: "application/json",
+			Key:         "customer-1",
+			Headers:     map[string][]byte{"correlation-id": []byte("1234")},
+			Payload:     []byte(`{"id":1}`),
+		},
+	}}, published)
+
+	// The published message is removed
+	n, err = store.Process(context.Background(), 10, collect(&published, ""))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestSQLStoreSaveRollback(t *testing.T) {
+	_, db := newFakeDB()
+	store := NewPostgresStore(db)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, store.Save(WithTx(context.Background(), tx), "orders",
+		extensions.BrokerMessage{Payload: []byte("1")}))
+	require.NoError(t, tx.Rollback())
+
+	var published []Message
+	n, err := store.Process(context.Background(), 10, collect(&published, ""))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestSQLStoreProcessFailure(t *testing.T) {
+	_, db := newFakeDB()
+	store := NewPostgresStore(db)
+	require.NoError(t, store.Save(context.Background(), "orders",
+		extensions.BrokerMessage{Payload: []byte("1")},
+		extensions.BrokerMessage{Payload: []byte("2")},
+		extensions.BrokerMessage{Payload: []byte("3")}))
+
+	// The processing stops at the first failure, to keep the order
+	var published []Message
+	n, err := store.Process(context.Background(), 10, collect(&published, "2"))
+	require.Error(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = store.Process(context.Background(), 10, collect(&published, ""))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	payloads := make([]string, 0, len(published))
+	for _, msg := range published {
+		payloads = append(payloads, string(msg.Message.Payload))
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, payloads)
+}
+
+func TestSQLStoreDialects(t *testing.T) {
+	cases := []struct {
+		name     string
+		newStore func(db *sql.DB, options ...StoreOption) *SQLStore
+		insert   string
+		sel      string
+		delete   string
+	}{
+		{
+			name:     "postgres",
+			newStore: NewPostgresStore,
+			insert: "INSERT INTO events_outbox (channel, content_type, partition_key, headers, payload) " +
+				"VALUES ($1, $2, $3, $4, $5)",
+			sel: "SELECT id, channel, content_type, partition_key, headers, payload FROM events_outbox " +
+				"ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED",
+			delete: "DELETE FROM events_outbox WHERE id = $1",
+		},
+		{
+			name:     "mysql",
+			newStore: NewMySQLStore,
+			insert: "INSERT INTO events_outbox (channel, content_type, partition_key, headers, payload) " +
+				"VALUES (?, ?, ?, ?, ?)",
+			sel: "SELECT id, channel, content_type, partition_key, headers, payload FROM events_outbox " +
+				"ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED",
+			delete: "DELETE FROM events_outbox WHERE id = ?",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, db := newFakeDB()
+			store := c.newStore(db, WithTable("events_outbox"))
+
+			require.NoError(t, store.CreateTable(context.Background()))
+			require.NoError(t, store.Save(context.Background(), "orders", extensions.BrokerMessage{}))
+			var published []Message
+			_, err := store.Process(context.Background(), 10, collect(&published, ""))
+			require.NoError(t, err)
+
+			queries := f.executedQueries()
+			require.Len(t, queries, 4)
+			assert.True(t, strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS events_outbox"))
+			assert.Equal(t, []string{c.insert, c.sel, c.delete}, queries[1:])
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package extensions
+
+import "sync"
+
+// PauseGate is used by the controllers to stop reading the messages of a
+// subscription while it is paused.
+type PauseGate struct {
+	mutex   sync.Mutex
+	paused  chan struct{} // Closed while paused
+	resumed chan struct{} // Closed while not paused
+}
+
+// NewPauseGate creates a new gate, that is not paused.
+func NewPauseGate() *PauseGate {
+	resumed := make(chan struct{})
+	close(resumed)
+
+	return &PauseGate{
+		paused:  make(chan struct{}),
+		resumed: resumed,
+	}
+}
+
+// Pause pauses the gate. It returns false if it was already paused.
+func (g *PauseGate) Pause() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	select {
+	case <-g.paused:
+		return false
+	default:
+	}
+
+	close(g.paused)
+	g.resumed = make(chan struct{})
+	return true
+}
+
+// Resume resumes the gate. It returns false if it was not paused.
+func (g *PauseGate) Resume() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	select {
+	case <-g.resumed:
+		return false
+	default:
+	}
+
+	close(g.resumed)
+	g.paused = make(chan struct{})
+	return true
+}
+
+// Paused returns a channel closed once the gate is paused.
+func (g *PauseGate) Paused() <-chan struct{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.paused
+}
+
+// Resumed returns a channel closed once the gate is not paused.
+func (g *PauseGate) Resumed() <-chan struct{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.resumed
+}
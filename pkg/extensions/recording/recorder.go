@@ -0,0 +1,143 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Recorder)(nil)
+	_ extensions.DelayedPublisher  = (*Recorder)(nil)
+	_ extensions.PatternSubscriber = (*Recorder)(nil)
+)
+
+// Recorder is a broker controller writing the messages published and received
+// through the broker to a recording, that can be replayed by a Replayer.
+type Recorder struct {
+	broker extensions.BrokerController
+	logger extensions.Logger
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// RecorderOption is an option of the Recorder.
+type RecorderOption func(recorder *Recorder)
+
+// NewRecorder creates a Recorder around a broker controller, writing the
+// records to w, one per line.
+func NewRecorder(broker extensions.BrokerController, w io.Writer, options ...RecorderOption) *Recorder {
+	r := &Recorder{
+		broker: broker,
+		logger: extensions.DummyLogger{},
+		w:      w,
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// WithLogger sets the logger of the errors when writing the records of the
+// received messages.
+func WithLogger(logger extensions.Logger) RecorderOption {
+	return func(recorder *Recorder) {
+		recorder.logger = logger
+	}
+}
+
+// Publish a message to the broker, and record it once published.
+func (r *Recorder) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	if err := r.broker.Publish(ctx, channel, bm); err != nil {
+		return err
+	}
+	return r.record(DirectionPublished, channel, bm)
+}
+
+// PublishBatch publishes several messages to the broker at once, and record
+// them once published.
+func (r *Recorder) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	if err := r.broker.PublishBatch(ctx, channel, bms); err != nil {
+		return err
+	}
+
+	for _, bm := range bms {
+		if err := r.record(DirectionPublished, channel, bm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishWithDelay publishes a message to the broker that will be delivered
+// after the delay, if the wrapped broker supports it. The delay is not
+// recorded.
+func (r *Recorder) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	dp, ok := r.broker.(extensions.DelayedPublisher)
+	if !ok {
+		return extensions.ErrDelayedPublishNotSupported
+	}
+
+	if err := dp.PublishWithDelay(ctx, channel, bm, delay); err != nil {
+		return err
+	}
+	return r.record(DirectionPublished, channel, bm)
+}
+
+// Subscribe to messages from the broker, that are recorded when received.
+func (r *Recorder) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := r.broker.Subscribe(ctx, channel)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return r.recordSubscription(ctx, channel, sub), nil
+}
+
+// SubscribeToPattern subscribes to all the addresses matching the channel
+// address, if the wrapped broker supports it. The messages are recorded with
+// the channel address as channel, and their address.
+func (r *Recorder) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	sub, err := extensions.SubscribeToPattern(ctx, r.broker, channelAddr)
+	if err != nil {
+		return extensions.BrokerChannelSubscription{}, err
+	}
+	return r.recordSubscription(ctx, channelAddr, sub), nil
+}
+
+func (r *Recorder) recordSubscription(
+	ctx context.Context,
+	channel string,
+	sub extensions.BrokerChannelSubscription,
+) extensions.BrokerChannelSubscription {
+	return extensions.WrapBrokerChannelSubscription(sub, func(
+		msg extensions.AcknowledgeableBrokerMessage,
+		send func(extensions.AcknowledgeableBrokerMessage),
+	) {
+		if err := r.record(DirectionReceived, channel, msg.BrokerMessage); err != nil {
+			r.logger.Error(context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, msg), err.Error())
+		}
+		send(msg)
+	})
+}
+
+func (r *Recorder) record(direction Direction, channel string, bm extensions.BrokerMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return WriteRecords(r.w, Record{Direction: direction, Channel: channel, Message: bm})
+}
@@ -0,0 +1,197 @@
+// Package recording records the messages published and received through a
+// broker controller, and replays them later without broker, in order to write
+// golden-file tests of the messages flows.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+var (
+	// ErrRecordingMismatch is raised when the messages published during a
+	// replay are not the recorded ones.
+	ErrRecordingMismatch = fmt.Errorf("%w: published messages differ from the recording", extensions.ErrAsyncAPI)
+
+	// ErrReplayIncomplete is raised when the replay ends before all the recorded
+	// messages have been received and published.
+	ErrReplayIncomplete = fmt.Errorf("%w: replay incomplete", extensions.ErrAsyncAPI)
+)
+
+// Direction is the direction of a recorded message.
+type Direction string
+
+const (
+	// DirectionPublished is the direction of the messages published on the broker.
+	DirectionPublished Direction = "published"
+	// DirectionReceived is the direction of the messages received from the broker.
+	DirectionReceived Direction = "received"
+)
+
+// Record is a message published or received on a channel.
+type Record struct {
+	Direction Direction
+	// Channel is the address of the publication, or the address subscribed
+	// when the message has been received, like 'user.{userId}' for a pattern
+	// subscription
+	Channel string
+	Message extensions.BrokerMessage
+}
+
+// jsonRecord is the JSON representation of a record, one per line in the
+// recordings. Values that are not valid UTF-8 are encoded in base64, and the
+// payloads already in compact JSON are kept as is, so the recordings can be
+// read and reviewed.
+type jsonRecord struct {
+	Direction     Direction         `json:"direction"`
+	Channel       string            `json:"channel"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	BinaryHeaders map[string][]byte `json:"binaryHeaders,omitempty"`
+	Payload       json.RawMessage   `json:"payload,omitempty"`
+	PayloadText   *string           `json:"payloadText,omitempty"`
+	PayloadBinary []byte            `json:"payloadBinary,omitempty"`
+	ContentType   string            `json:"contentType,omitempty"`
+	Key           string            `json:"key,omitempty"`
+	Address       string            `json:"address,omitempty"`
+}
+
+// MarshalJSON returns the record in JSON.
+func (r Record) MarshalJSON() ([]byte, error) {
+	jr := jsonRecord{
+		Direction:   r.Direction,
+		Channel:     r.Channel,
+		ContentType: r.Message.ContentType,
+		Key:         r.Message.Key,
+		Address:     r.Message.Address,
+	}
+
+	for k, v := range r.Message.Headers {
+		if utf8.Valid(v) {
+			if jr.Headers == nil {
+				jr.Headers = make(map[string]string)
+			}
+			jr.Headers[k] = string(v)
+		} else {
+			if jr.BinaryHeaders == nil {
+				jr.BinaryHeaders = make(map[string][]byte)
+			}
+			jr.BinaryHeaders[k] = v
+		}
+	}
+
+	var compact bytes.Buffer
+	switch p := r.Message.Payload; {
+	case p == nil:
+	case utf8.Valid(p) && json.Compact(&compact, p) == nil && bytes.Equal(compact.Bytes(), p):
+		jr.Payload = p
+	case utf8.Valid(p):
+		text := string(p)
+		jr.PayloadText = &text
+	default:
+		jr.PayloadBinary = p
+	}
+
+	// The HTML characters are not escaped, to keep the payloads as they are
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(jr); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalJSON sets the record from JSON.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var jr jsonRecord
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return err
+	}
+
+	switch jr.Direction {
+	case DirectionPublished, DirectionReceived:
+	default:
+		return fmt.Errorf("unknown direction %q", jr.Direction)
+	}
+
+	headers := make(map[string][]byte, len(jr.Headers)+len(jr.BinaryHeaders))
+	for k, v := range jr.Headers {
+		headers[k] = []byte(v)
+	}
+	for k, v := range jr.BinaryHeaders {
+		headers[k] = v
+	}
+
+	var payload []byte
+	switch {
+	case jr.Payload != nil:
+		payload = jr.Payload
+	case jr.PayloadText != nil:
+		payload = []byte(*jr.PayloadText)
+	case jr.PayloadBinary != nil:
+		payload = jr.PayloadBinary
+	}
+
+	*r = Record{
+		Direction: jr.Direction,
+		Channel:   jr.Channel,
+		Message: extensions.BrokerMessage{
+			Headers:     headers,
+			Payload:     payload,
+			ContentType: jr.ContentType,
+			Key:         jr.Key,
+			Address:     jr.Address,
+		},
+	}
+	return nil
+}
+
+// String returns the record in JSON.
+func (r Record) String() string {
+	b, err := r.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("%s on %q: %s", r.Direction, r.Channel, r.Message)
+	}
+	return string(b)
+}
+
+// ReadRecords reads the records of a recording, with one record in JSON per
+// line. Empty lines are ignored.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+// WriteRecords writes the records as a recording, with one record in JSON per
+// line.
+func WriteRecords(w io.Writer, records ...Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRecordingSuite(t *testing.T) {
+	suite.Run(t, new(RecordingSuite))
+}
+
+type RecordingSuite struct {
+	suite.Suite
+}
+
+// pingPong replies to each message received on 'ping' with the payload
+// transformed by the reply function, on 'pong'.
+func (suite *RecordingSuite) pingPong(broker extensions.BrokerController, reply func(string) string) func() {
+	ctx := context.Background()
+
+	sub, err := broker.Subscribe(ctx, "ping")
+	suite.Require().NoError(err)
+
+	go func() {
+		for msg := range sub.MessagesChannel() {
+			err := broker.Publish(ctx, "pong", extensions.BrokerMessage{
+				Headers: map[string][]byte{"id": []byte(time.Now().String())},
+				Payload: []byte(reply(string(msg.Payload))),
+			})
+			suite.Require().NoError(err)
+			msg.Ack()
+		}
+	}()
+
+	return func() { sub.Cancel(ctx) }
+}
+
+func (suite *RecordingSuite) record() *bytes.Buffer {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	var recording bytes.Buffer
+	stop := suite.pingPong(NewRecorder(broker, &recording), strings.ToUpper)
+	defer stop()
+
+	// Each message is replied before sending the next one
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, p := range []string{`{"msg":"hello"}`, "world"} {
+		suite.Require().NoError(broker.Publish(ctx, "ping", extensions.BrokerMessage{
+			Headers: map[string][]byte{},
+			Payload: []byte(p),
+		}))
+		suite.Require().NoError(broker.WaitForAcknowledgements(ctx))
+	}
+
+	return &recording
+}
+
+func (suite *RecordingSuite) TestRecord() {
+	records, err := ReadRecords(suite.record())
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 4)
+
+	suite.Require().Equal(DirectionReceived, records[0].Direction)
+	suite.Require().Equal("ping", records[0].Channel)
+	suite.Require().Equal(`{"msg":"hello"}`, string(records[0].Message.Payload))
+	suite.Require().Equal(DirectionPublished, records[1].Direction)
+	suite.Require().Equal("pong", records[1].Channel)
+	suite.Require().Equal(`{"MSG":"HELLO"}`, string(records[1].Message.Payload))
+	suite.Require().Equal("world", string(records[2].Message.Payload))
+	suite.Require().Equal("WORLD", string(records[3].Message.Payload))
+}
+
+func (suite *RecordingSuite) replay(recording *bytes.Buffer, reply func(string) string) *Replayer {
+	replayer, err := NewReplayer(recording, WithIgnoredHeaders("id"))
+	suite.Require().NoError(err)
+
+	stop := suite.pingPong(replayer, reply)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	suite.Require().NoError(replayer.Wait(ctx))
+
+	return replayer
+}
+
+func (suite *RecordingSuite) TestReplay() {
+	replayer := suite.replay(suite.record(), strings.ToUpper)
+	suite.Require().NoError(replayer.Verify())
+}
+
+func (suite *RecordingSuite) TestReplayMismatch() {
+	replayer := suite.replay(suite.record(), strings.ToLower)
+
+	err := replayer.Verify()
+	suite.Require().ErrorIs(err, ErrRecordingMismatch)
+	suite.Require().ErrorContains(err, `channel "pong": message 1 differs`)
+	suite.Require().ErrorContains(err, `channel "pong": message 2 differs`)
+}
+
+func (suite *RecordingSuite) TestReplayWithoutSubscription() {
+	replayer, err := NewReplayer(suite.record())
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	suite.Require().ErrorIs(replayer.Wait(ctx), ErrReplayIncomplete)
+
+	err = replayer.Verify()
+	suite.Require().ErrorIs(err, ErrRecordingMismatch)
+	suite.Require().ErrorContains(err, `channel "pong": message 1 not published`)
+}
+
+func (suite *RecordingSuite) TestReplayOrder() {
+	// The message received on 'b' is only transmitted after the publication on 'a'
+	var recording bytes.Buffer
+	suite.Require().NoError(WriteRecords(&recording,
+		Record{Direction: DirectionPublished, Channel: "a", Message: extensions.BrokerMessage{Payload: []byte("1")}},
+		Record{Direction: DirectionReceived, Channel: "b", Message: extensions.BrokerMessage{Payload: []byte("2")}},
+	))
+	replayer, err := NewReplayer(&recording)
+	suite.Require().NoError(err)
+
+	sub, err := replayer.Subscribe(context.Background(), "b")
+	suite.Require().NoError(err)
+	defer sub.Cancel(context.Background())
+
+	select {
+	case <-sub.MessagesChannel():
+		suite.FailNow("message transmitted before the publication")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	suite.Require().NoError(replayer.Publish(context.Background(), "a", extensions.BrokerMessage{
+		Payload: []byte("1"),
+	}))
+	select {
+	case msg := <-sub.MessagesChannel():
+		suite.Require().Equal("2", string(msg.Payload))
+	case <-time.After(time.Second):
+		suite.FailNow("message not transmitted")
+	}
+}
+
+func (suite *RecordingSuite) TestRecordEncoding() {
+	cases := []extensions.BrokerMessage{
+		{Headers: map[string][]byte{"a": []byte("text"), "b": {0xff, 0x00}}, Payload: []byte(`{"a":"<b>"}`)},
+		{Headers: map[string][]byte{}, Payload: []byte("{ \"indented\": true }")},
+		{Headers: map[string][]byte{}, Payload: []byte{0xde, 0xad, 0xbe, 0xef}},
+		{Headers: map[string][]byte{}, Payload: []byte{}, ContentType: "text/plain", Key: "k", Address: "a.b"},
+	}
+
+	for _, bm := range cases {
+		var buf bytes.Buffer
+		suite.Require().NoError(WriteRecords(&buf, Record{Direction: DirectionPublished, Channel: "c", Message: bm}))
+
+		records, err := ReadRecords(&buf)
+		suite.Require().NoError(err)
+		suite.Require().Len(records, 1)
+		suite.Require().Equal(bm, records[0].Message)
+	}
+
+	// The JSON payloads are kept as is
+	suite.Require().Contains(Record{Direction: DirectionPublished, Message: cases[0]}.String(),
+		`"payload":{"a":"<b>"}`)
+}
+
+func (suite *RecordingSuite) TestReadInvalidRecord() {
+	_, err := ReadRecords(strings.NewReader("{\"direction\":\"published\"}\n\n{\"direction\":\"sent\"}\n"))
+	suite.Require().ErrorContains(err, "line 3")
+}
@@ -0,0 +1,402 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+)
+
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController  = (*Replayer)(nil)
+	_ extensions.DelayedPublisher  = (*Replayer)(nil)
+	_ extensions.PatternSubscriber = (*Replayer)(nil)
+)
+
+// Replayer is a broker controller replaying a recording without broker: the
+// recorded received messages are transmitted to the subscriptions of their
+// channel, and the published messages are kept to be compared to the recorded
+// ones with Verify.
+//
+// A received message is only transmitted once the messages published before
+// it in the recording have been published, in order to keep the order of the
+// messages flows.
+type Replayer struct {
+	ignoredHeaders []string
+
+	mu            sync.Mutex
+	expected      map[string][]extensions.BrokerMessage
+	published     map[string][]extensions.BrokerMessage
+	publications  int
+	pending       map[string][]pendingMessage
+	received      int
+	settled       map[int]bool
+	subscriptions map[string][]*subscription
+	changed       chan struct{}
+}
+
+// pendingMessage is a recorded received message, waiting to be transmitted.
+type pendingMessage struct {
+	// id is the index of the message among the recorded received messages
+	id int
+	// after is the number of messages published before it in the recording
+	after   int
+	message extensions.BrokerMessage
+}
+
+// ReplayerOption is an option of the Replayer.
+type ReplayerOption func(replayer *Replayer)
+
+// NewReplayer creates a Replayer of the recording read from r.
+func NewReplayer(r io.Reader, options ...ReplayerOption) (*Replayer, error) {
+	records, err := ReadRecords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &Replayer{
+		expected:      make(map[string][]extensions.BrokerMessage),
+		published:     make(map[string][]extensions.BrokerMessage),
+		pending:       make(map[string][]pendingMessage),
+		settled:       make(map[int]bool),
+		subscriptions: make(map[string][]*subscription),
+		changed:       make(chan struct{}),
+	}
+
+	var publications int
+	for _, rec := range records {
+		switch rec.Direction {
+		case DirectionPublished:
+			rp.expected[rec.Channel] = append(rp.expected[rec.Channel], rec.Message)
+			publications++
+		case DirectionReceived:
+			rp.pending[rec.Channel] = append(rp.pending[rec.Channel], pendingMessage{
+				id:      rp.received,
+				after:   publications,
+				message: rec.Message,
+			})
+			rp.received++
+		}
+	}
+
+	for _, option := range options {
+		option(rp)
+	}
+
+	return rp, nil
+}
+
+// WithIgnoredHeaders sets headers that are not compared by Verify, like the
+// headers with identifiers or timestamps that change on each execution.
+func WithIgnoredHeaders(keys ...string) ReplayerOption {
+	return func(replayer *Replayer) {
+		replayer.ignoredHeaders = append(replayer.ignoredHeaders, keys...)
+	}
+}
+
+// Publish keeps the message, and transmits the recorded received messages
+// that were waiting for it.
+func (rp *Replayer) Publish(_ context.Context, channel string, bm extensions.BrokerMessage) error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	rp.published[channel] = append(rp.published[channel], bm)
+	rp.publications++
+	rp.transmitPending()
+	rp.notifyChange()
+
+	return nil
+}
+
+// PublishBatch keeps the messages, as for Publish.
+func (rp *Replayer) PublishBatch(ctx context.Context, channel string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		if err := rp.Publish(ctx, channel, bm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishWithDelay keeps the message, as for Publish. The delay is ignored.
+func (rp *Replayer) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm extensions.BrokerMessage,
+	_ time.Duration,
+) error {
+	return rp.Publish(ctx, channel, bm)
+}
+
+// Subscribe returns a subscription receiving the recorded messages of the
+// channel. The messages are transmitted to all the subscriptions of the
+// channel.
+func (rp *Replayer) Subscribe(_ context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	s := newSubscription(rp)
+
+	rp.mu.Lock()
+	rp.subscriptions[channel] = append(rp.subscriptions[channel], s)
+	rp.transmitPending()
+	rp.mu.Unlock()
+
+	s.sub.WaitForCancellationAsync(func() {
+		s.stop()
+
+		rp.mu.Lock()
+		defer rp.mu.Unlock()
+
+		subs := rp.subscriptions[channel]
+		for i, sub := range subs {
+			if sub == s {
+				rp.subscriptions[channel] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+	})
+
+	return s.sub, nil
+}
+
+// SubscribeToPattern returns a subscription receiving the recorded messages of
+// the pattern subscriptions to the channel address.
+func (rp *Replayer) SubscribeToPattern(
+	ctx context.Context,
+	channelAddr string,
+) (extensions.BrokerChannelSubscription, error) {
+	return rp.Subscribe(ctx, channelAddr)
+}
+
+// Wait waits for all the recorded received messages to be acknowledged, and
+// for as many messages as recorded to be published. It returns an error if the
+// context is done before.
+func (rp *Replayer) Wait(ctx context.Context) error {
+	for {
+		rp.mu.Lock()
+		var expected int
+		for _, bms := range rp.expected {
+			expected += len(bms)
+		}
+		settled, publications, changed := len(rp.settled), rp.publications, rp.changed
+		rp.mu.Unlock()
+
+		if settled >= rp.received && publications >= expected {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d of %d received messages acknowledged, %d of %d messages published: %w",
+				ErrReplayIncomplete, settled, rp.received, publications, expected, ctx.Err())
+		}
+	}
+}
+
+// Verify compares the published messages to the recorded ones, in the order
+// of the publications on each channel.
+func (rp *Replayer) Verify() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	channels := make(map[string]bool, len(rp.expected)+len(rp.published))
+	for ch := range rp.expected {
+		channels[ch] = true
+	}
+	for ch := range rp.published {
+		channels[ch] = true
+	}
+
+	var diffs []string
+	for _, ch := range utils.MapKeysToSortedList(channels) {
+		expected, published := rp.expected[ch], rp.published[ch]
+		for i := 0; i < max(len(expected), len(published)); i++ {
+			switch {
+			case i >= len(published):
+				diffs = append(diffs, fmt.Sprintf("channel %q: message %d not published: %s", ch, i+1,
+					Record{Direction: DirectionPublished, Channel: ch, Message: expected[i]}))
+			case i >= len(expected):
+				diffs = append(diffs, fmt.Sprintf("channel %q: message %d not recorded: %s", ch, i+1,
+					Record{Direction: DirectionPublished, Channel: ch, Message: published[i]}))
+			case !rp.equal(expected[i], published[i]):
+				diffs = append(diffs, fmt.Sprintf("channel %q: message %d differs:\n  expected %s\n  got      %s", ch, i+1,
+					Record{Direction: DirectionPublished, Channel: ch, Message: expected[i]},
+					Record{Direction: DirectionPublished, Channel: ch, Message: published[i]}))
+			}
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w:\n%s", ErrRecordingMismatch, strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// equal states if the messages are the same, without the ignored headers.
+func (rp *Replayer) equal(expected, published extensions.BrokerMessage) bool {
+	headers := func(bm extensions.BrokerMessage) map[string]string {
+		h := make(map[string]string, len(bm.Headers))
+		for k, v := range bm.Headers {
+			if !utils.IsInSlice(rp.ignoredHeaders, k) {
+				h[k] = string(v)
+			}
+		}
+		return h
+	}
+
+	return reflect.DeepEqual(headers(expected), headers(published)) &&
+		string(expected.Payload) == string(published.Payload) &&
+		expected.ContentType == published.ContentType &&
+		expected.Key == published.Key &&
+		expected.Address == published.Address
+}
+
+// transmitPending transmits the pending messages of the subscribed channels,
+// whose previous publications have been done. The mutex should be held.
+func (rp *Replayer) transmitPending() {
+	for ch, pending := range rp.pending {
+		subs := rp.subscriptions[ch]
+		if len(subs) == 0 {
+			continue
+		}
+
+		var n int
+		for n < len(pending) && pending[n].after <= rp.publications {
+			for _, s := range subs {
+				s.enqueue(pending[n])
+			}
+			n++
+		}
+
+		if n == len(pending) {
+			delete(rp.pending, ch)
+		} else {
+			rp.pending[ch] = pending[n:]
+		}
+	}
+}
+
+// settle records the acknowledgment of the recorded received message.
+func (rp *Replayer) settle(id int) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	rp.settled[id] = true
+	rp.notifyChange()
+}
+
+// notifyChange wakes up the waiting goroutines. The mutex should be held.
+func (rp *Replayer) notifyChange() {
+	close(rp.changed)
+	rp.changed = make(chan struct{})
+}
+
+// subscription is a subscription to the replayer, transmitting the enqueued
+// messages from its own goroutine so the publications are never blocked.
+type subscription struct {
+	replayer *Replayer
+	messages chan extensions.AcknowledgeableBrokerMessage
+	sub      extensions.BrokerChannelSubscription
+
+	mu     sync.Mutex
+	queue  []pendingMessage
+	notify chan struct{}
+	done   chan struct{}
+	ended  chan struct{}
+	once   sync.Once
+}
+
+func newSubscription(rp *Replayer) *subscription {
+	messages := make(chan extensions.AcknowledgeableBrokerMessage, brokers.BrokerMessagesQueueSize)
+	s := &subscription{
+		replayer: rp,
+		messages: messages,
+		sub:      extensions.NewBrokerChannelSubscription(messages, make(chan any, 1)),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		ended:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// enqueue adds the message to the messages to transmit.
+func (s *subscription) enqueue(pm pendingMessage) {
+	s.mu.Lock()
+	s.queue = append(s.queue, pm)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run transmits the enqueued messages until the subscription is stopped.
+func (s *subscription) run() {
+	defer close(s.ended)
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		pm := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		// Each transmission has its own copy of the headers, that can be
+		// modified by the subscriber
+		bm := pm.message
+		bm.Headers = make(map[string][]byte, len(pm.message.Headers))
+		for k, v := range pm.message.Headers {
+			bm.Headers[k] = v
+		}
+
+		msg := extensions.NewAcknowledgeableBrokerMessage(bm, acknowledgementHandler{replayer: s.replayer, id: pm.id})
+		select {
+		case s.messages <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// stop stops the transmission of the messages, before the messages channel is
+// closed.
+func (s *subscription) stop() {
+	s.once.Do(func() { close(s.done) })
+	<-s.ended
+}
+
+var _ extensions.BrokerAcknowledgment = acknowledgementHandler{}
+
+// acknowledgementHandler records the acknowledgment of the replayed messages,
+// positive or negative, in the replayer.
+type acknowledgementHandler struct {
+	replayer *Replayer
+	id       int
+}
+
+// AckMessage acknowledges the message.
+func (h acknowledgementHandler) AckMessage() {
+	h.replayer.settle(h.id)
+}
+
+// NakMessage negatively acknowledges the message. It is not transmitted again.
+func (h acknowledgementHandler) NakMessage() {
+	h.replayer.settle(h.id)
+}
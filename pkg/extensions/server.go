@@ -0,0 +1,82 @@
+package extensions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Server is a server of the AsyncAPI specification, as generated in the
+// AsyncAPIServers map.
+type Server struct {
+	Name            string
+	Host            string
+	Protocol        string
+	ProtocolVersion string
+	PathName        string
+	Title           string
+	Description     string
+
+	// Variables are the variables used in the host and the path name, by name.
+	Variables map[string]ServerVariable
+	// Security are the security schemes that can be used to connect to the
+	// server.
+	Security []SecurityScheme
+}
+
+// ServerVariable is a variable of the host or the path name of a server.
+type ServerVariable struct {
+	// Default is the value used if none is given.
+	Default string
+	// Enum is the set of the allowed values, if any.
+	Enum        []string
+	Description string
+}
+
+// SecurityScheme is a security scheme that can be used to connect to a server.
+type SecurityScheme struct {
+	Type         string
+	Description  string
+	In           string
+	Scheme       string
+	BearerFormat string
+
+	// TokenURL is the URL of the token endpoint of the OAuth2 client
+	// credentials flow.
+	TokenURL string
+	// Scopes are the OAuth2 scopes required to connect to the server.
+	Scopes []string
+}
+
+// Address returns the host and the path name of the server, with their
+// variables replaced by their values, or their default ones if not given.
+func (s Server) Address(values map[string]string) (string, error) {
+	addr := s.Host + s.PathName
+	for name, v := range s.Variables {
+		value, ok := values[name]
+		if !ok {
+			value = v.Default
+		}
+
+		if value == "" {
+			return "", fmt.Errorf("%w: no value for variable %q of server %q", ErrServerVariable, name, s.Name)
+		} else if len(v.Enum) > 0 && !slices.Contains(v.Enum, value) {
+			return "", fmt.Errorf("%w: value %q of variable %q of server %q is not one of %v",
+				ErrServerVariable, value, name, s.Name, v.Enum)
+		}
+
+		addr = strings.ReplaceAll(addr, "{"+name+"}", value)
+	}
+
+	return addr, nil
+}
+
+// URL returns the URL of the server, as its address prefixed by its protocol
+// (like 'nats://localhost:4222').
+func (s Server) URL(values map[string]string) (string, error) {
+	addr, err := s.Address(values)
+	if err != nil {
+		return "", err
+	}
+	return s.Protocol + "://" + addr, nil
+}
@@ -0,0 +1,92 @@
+package extensions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReplyStream is the stream of the replies to a request, received until the
+// message ending the stream, or until the request context is done.
+type ReplyStream[T any] struct {
+	replies chan T
+	cancel  context.CancelFunc
+	err     error
+}
+
+// NewReplyStream returns the stream of the replies returned by the next
+// function, until it returns an error (ErrEndOfStream ending the stream
+// without error) or the context is done. The release function is called at the
+// end of the stream.
+//
+// The next function can return no reply and no error to ignore a message.
+func NewReplyStream[T any](
+	ctx context.Context,
+	next func(ctx context.Context) (*T, error),
+	release func(),
+) *ReplyStream[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ReplyStream[T]{
+		replies: make(chan T),
+		cancel:  cancel,
+	}
+
+	go func() {
+		defer close(s.replies)
+		defer cancel()
+		defer release()
+
+		for {
+			reply, err := next(ctx)
+			if errors.Is(err, ErrEndOfStream) {
+				return
+			} else if err != nil {
+				s.err = err
+				return
+			} else if reply == nil {
+				continue
+			}
+
+			select {
+			case s.replies <- *reply:
+			case <-ctx.Done():
+				s.err = fmt.Errorf("%w: %w", ErrContextCanceled, ctx.Err())
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Replies returns the channel of the replies, closed at the end of the stream.
+func (s *ReplyStream[T]) Replies() <-chan T {
+	return s.replies
+}
+
+// Err returns the error that ended the stream, once the channel of the replies
+// is closed: nil if the message ending the stream has been received, or an
+// error wrapping ErrContextCanceled if the request has timed out.
+func (s *ReplyStream[T]) Err() error {
+	return s.err
+}
+
+// Close stops the reception of the replies before the end of the stream and
+// waits for the stream to be released.
+func (s *ReplyStream[T]) Close() {
+	s.cancel()
+	for range s.replies {
+		// Discard the remaining replies
+	}
+}
+
+// MarkEndOfStream sets the header marking the message ending a reply stream,
+// if the context says so (see ContextKeyIsEndOfStream).
+func MarkEndOfStream(ctx context.Context, msg *BrokerMessage, header string) {
+	IfContextValueEquals(ctx, ContextKeyIsEndOfStream, true, func() {
+		if msg.Headers == nil {
+			msg.Headers = make(map[string][]byte)
+		}
+		msg.Headers[header] = []byte("true")
+	})
+}
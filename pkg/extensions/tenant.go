@@ -0,0 +1,197 @@
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// ErrNoTenant is raised when no tenant is resolved for a publication or a
+// subscription of a controller with a tenant resolver.
+var ErrNoTenant = fmt.Errorf("%w: no tenant resolved", ErrAsyncAPI)
+
+// TenantResolver returns the tenant of a publication or a subscription from its
+// context, or an empty string if there is none.
+type TenantResolver func(ctx context.Context) string
+
+// TenantAddress returns the address of the channel for the tenant.
+func TenantAddress(tenant, addr string) string {
+	return tenant + "." + addr
+}
+
+// TenantFromContext returns the tenant of the received message, set in the
+// context of its handling by the controllers with a tenant resolver.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(ContextKeyIsTenant).(string)
+	return tenant, ok
+}
+
+// ContextWithTenantOf returns the context with the tenant of the message, from
+// its header, if it has one. The messages sent with this context are then for
+// the same tenant.
+func ContextWithTenantOf(ctx context.Context, bm BrokerMessage) context.Context {
+	tenant, exists := bm.Headers[StandardHeaderTenant]
+	if !exists {
+		return ctx
+	}
+	return context.WithValue(ctx, ContextKeyIsTenant, string(tenant))
+}
+
+// Check that it still fills the interfaces.
+var (
+	_ BrokerController     = (*TenantBroker)(nil)
+	_ DelayedPublisher     = (*TenantBroker)(nil)
+	_ PatternSubscriber    = (*TenantBroker)(nil)
+	_ ReplyAddressProvider = (*TenantBroker)(nil)
+	_ HealthChecker        = (*TenantBroker)(nil)
+)
+
+// TenantBroker is a broker controller isolating the tenants on the wrapped
+// broker controller: the channels addresses are prefixed by the tenant, and
+// the messages have the tenant in their header.
+//
+// The tenant is the one of the received message being handled, if it is in
+// the context, or the one given by the tenant resolver otherwise. The received
+// messages without the tenant of the subscription in their header are not
+// delivered, and acknowledged.
+type TenantBroker struct {
+	broker   BrokerController
+	resolver TenantResolver
+}
+
+// NewTenantBroker creates a broker controller isolating the tenants, given by
+// the resolver, on the broker controller.
+func NewTenantBroker(broker BrokerController, resolver TenantResolver) *TenantBroker {
+	return &TenantBroker{
+		broker:   broker,
+		resolver: resolver,
+	}
+}
+
+// tenant returns the tenant of the context.
+func (tb *TenantBroker) tenant(ctx context.Context) (string, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		tenant = tb.resolver(ctx)
+	}
+
+	if tenant == "" {
+		return "", ErrNoTenant
+	}
+	return tenant, nil
+}
+
+// withTenant returns the message with the tenant in its header.
+func withTenant(bm BrokerMessage, tenant string) BrokerMessage {
+	bm.Headers = maps.Clone(bm.Headers)
+	if bm.Headers == nil {
+		bm.Headers = make(map[string][]byte)
+	}
+	bm.Headers[StandardHeaderTenant] = []byte(tenant)
+	return bm
+}
+
+// Publish a message for the tenant of the context.
+func (tb *TenantBroker) Publish(ctx context.Context, channel string, bm BrokerMessage) error {
+	tenant, err := tb.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	return tb.broker.Publish(ctx, TenantAddress(tenant, channel), withTenant(bm, tenant))
+}
+
+// PublishBatch publishes several messages at once for the tenant of the context.
+func (tb *TenantBroker) PublishBatch(ctx context.Context, channel string, bms []BrokerMessage) error {
+	tenant, err := tb.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	scoped := make([]BrokerMessage, 0, len(bms))
+	for _, bm := range bms {
+		scoped = append(scoped, withTenant(bm, tenant))
+	}
+	return tb.broker.PublishBatch(ctx, TenantAddress(tenant, channel), scoped)
+}
+
+// PublishWithDelay publishes a message for the tenant of the context, that
+// will be delivered after the delay, if the wrapped broker supports it.
+func (tb *TenantBroker) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	bm BrokerMessage,
+	delay time.Duration,
+) error {
+	dp, ok := tb.broker.(DelayedPublisher)
+	if !ok {
+		return ErrDelayedPublishNotSupported
+	}
+
+	tenant, err := tb.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	return dp.PublishWithDelay(ctx, TenantAddress(tenant, channel), withTenant(bm, tenant), delay)
+}
+
+// Subscribe to the messages of the tenant of the context.
+func (tb *TenantBroker) Subscribe(ctx context.Context, channel string) (BrokerChannelSubscription, error) {
+	tenant, err := tb.tenant(ctx)
+	if err != nil {
+		return BrokerChannelSubscription{}, err
+	}
+
+	sub, err := tb.broker.Subscribe(ctx, TenantAddress(tenant, channel))
+	if err != nil {
+		return BrokerChannelSubscription{}, err
+	}
+	return tb.wrapSubscription(sub, tenant), nil
+}
+
+// SubscribeToPattern subscribes to the messages of the tenant of the context on
+// all the addresses matching the channel address, if the wrapped broker
+// supports it. The address of the received messages is without the tenant.
+func (tb *TenantBroker) SubscribeToPattern(ctx context.Context, channelAddr string) (BrokerChannelSubscription, error) {
+	tenant, err := tb.tenant(ctx)
+	if err != nil {
+		return BrokerChannelSubscription{}, err
+	}
+
+	sub, err := SubscribeToPattern(ctx, tb.broker, TenantAddress(tenant, channelAddr))
+	if err != nil {
+		return BrokerChannelSubscription{}, err
+	}
+	return tb.wrapSubscription(sub, tenant), nil
+}
+
+// NewReplyAddress returns a reply address from the wrapped broker, that is
+// prefixed by the tenant when used.
+func (tb *TenantBroker) NewReplyAddress(ctx context.Context) (string, error) {
+	return NewReplyAddress(ctx, tb.broker)
+}
+
+// Healthy returns the health of the wrapped broker, if it implements
+// HealthChecker.
+func (tb *TenantBroker) Healthy(ctx context.Context) error {
+	if hc, ok := tb.broker.(HealthChecker); ok {
+		return hc.Healthy(ctx)
+	}
+	return nil
+}
+
+// wrapSubscription filters the messages of the subscription that are not for
+// the tenant.
+func (tb *TenantBroker) wrapSubscription(sub BrokerChannelSubscription, tenant string) BrokerChannelSubscription {
+	return WrapBrokerChannelSubscription(sub,
+		func(msg AcknowledgeableBrokerMessage, send func(AcknowledgeableBrokerMessage)) {
+			if string(msg.BrokerMessage.Headers[StandardHeaderTenant]) != tenant {
+				msg.Ack()
+				return
+			}
+
+			msg.BrokerMessage.Address = strings.TrimPrefix(msg.BrokerMessage.Address, TenantAddress(tenant, ""))
+			send(msg)
+		})
+}
@@ -0,0 +1,147 @@
+package extensions
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationError is an error on a field of a message that doesn't respect a
+// constraint of the specification.
+type ValidationError struct {
+	// Field is the path of the field in the message, like 'payload.items[0].name'.
+	Field string
+	// Constraint is the JSON Schema keyword of the constraint, like 'minLength'.
+	Constraint string
+	// Message describes why the field doesn't respect the constraint.
+	Message string
+}
+
+// Error returns the field and the reason of the error.
+func (ve ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ve.Field, ve.Message)
+}
+
+// ValidationErrors are the errors of the fields of a message that don't respect
+// the constraints of the specification. It wraps ErrValidation.
+type ValidationErrors []ValidationError
+
+// Error returns all the validation errors.
+func (ves ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(ves))
+	for _, ve := range ves {
+		msgs = append(msgs, ve.Error())
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation.Error(), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns ErrValidation.
+func (ves ValidationErrors) Unwrap() error {
+	return ErrValidation
+}
+
+// Add adds an error on the field for the constraint.
+func (ves *ValidationErrors) Add(field, constraint, format string, args ...any) {
+	*ves = append(*ves, ValidationError{
+		Field:      field,
+		Constraint: constraint,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// AddNested adds the errors returned by the validation of a nested field, by
+// prefixing their path with the one of the field.
+func (ves *ValidationErrors) AddNested(field string, err error) {
+	if err == nil {
+		return
+	}
+
+	var nested ValidationErrors
+	if !errors.As(err, &nested) {
+		ves.Add(field, "", "%s", err.Error())
+		return
+	}
+
+	for _, ve := range nested {
+		switch {
+		case ve.Field == "":
+			ve.Field = field
+		case strings.HasPrefix(ve.Field, "["):
+			ve.Field = field + ve.Field
+		default:
+			ve.Field = field + "." + ve.Field
+		}
+		*ves = append(*ves, ve)
+	}
+}
+
+// Err returns the validation errors as an error, or nil if there is none.
+func (ves ValidationErrors) Err() error {
+	if len(ves) == 0 {
+		return nil
+	}
+	return ves
+}
+
+var (
+	patternsMutex sync.Mutex
+	patterns      = make(map[string]*regexp.Regexp)
+
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*` +
+		`[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+)
+
+// MatchPattern returns true if the value matches the regular expression of a
+// 'pattern' constraint. Invalid regular expressions never match.
+func MatchPattern(pattern, value string) bool {
+	patternsMutex.Lock()
+	re, exists := patterns[pattern]
+	if !exists {
+		re, _ = regexp.Compile(pattern)
+		patterns[pattern] = re
+	}
+	patternsMutex.Unlock()
+
+	return re != nil && re.MatchString(value)
+}
+
+// MatchFormat returns true if the value is in the format of a 'format'
+// constraint. Values are only checked for these formats: date, date-time,
+// email, hostname, ipv4, ipv6, uri, uuid and byte (base64).
+func MatchFormat(format, value string) bool {
+	var err error
+	switch format {
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "email":
+		var addr *mail.Address
+		addr, err = mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "hostname":
+		return len(value) <= 253 && hostnameRegexp.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	case "uri":
+		var u *url.URL
+		u, err = url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidRegexp.MatchString(value)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(value)
+	}
+	return err == nil
+}
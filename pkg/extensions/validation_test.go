@@ -0,0 +1,67 @@
+package extensions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestValidationSuite(t *testing.T) {
+	suite.Run(t, new(ValidationSuite))
+}
+
+type ValidationSuite struct {
+	suite.Suite
+}
+
+func (suite *ValidationSuite) TestValidationErrors() {
+	var nested ValidationErrors
+	nested.Add("name", "minLength", "should have at least %d characters", 2)
+	nested.Add("[0]", "enum", "should be one of %q", "a")
+
+	var errs ValidationErrors
+	suite.Require().NoError(errs.Err())
+	errs.AddNested("payload", nested.Err())
+	errs.AddNested("headers", errors.New("invalid"))
+	errs.AddNested("other", nil)
+
+	suite.Require().ErrorIs(errs.Err(), ErrValidation)
+	suite.Require().ErrorIs(errs.Err(), ErrAsyncAPI)
+	suite.Require().Equal(ValidationErrors{
+		{Field: "payload.name", Constraint: "minLength", Message: "should have at least 2 characters"},
+		{Field: "payload[0]", Constraint: "enum", Message: `should be one of "a"`},
+		{Field: "headers", Message: "invalid"},
+	}, errs)
+	suite.Require().Equal(ErrValidation.Error()+": payload.name: should have at least 2 characters; "+
+		`payload[0]: should be one of "a"; headers: invalid`, errs.Error())
+}
+
+func (suite *ValidationSuite) TestMatchPattern() {
+	suite.Require().True(MatchPattern("^[a-z]+$", "abc"))
+	suite.Require().False(MatchPattern("^[a-z]+$", "ABC"))
+	suite.Require().False(MatchPattern("[", "["))
+}
+
+func (suite *ValidationSuite) TestMatchFormat() {
+	cases := []struct {
+		format, valid, invalid string
+	}{
+		{"date", "2024-05-01", "2024-05-01T10:00:00Z"},
+		{"date-time", "2024-05-01T10:00:00+02:00", "2024-05-01"},
+		{"email", "alice@example.com", "Alice <alice@example.com>"},
+		{"hostname", "api.example.com", "-example.com"},
+		{"ipv4", "192.168.0.1", "::1"},
+		{"ipv6", "2001:db8::1", "192.168.0.1"},
+		{"uri", "https://example.com/path", "/path"},
+		{"uuid", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "6ba7b810"},
+		{"byte", "AQI=", "not base64"},
+	}
+	for _, c := range cases {
+		suite.Require().True(MatchFormat(c.format, c.valid), c.format)
+		suite.Require().False(MatchFormat(c.format, c.invalid), c.format)
+	}
+
+	// Unknown formats are not checked
+	suite.Require().True(MatchFormat("unknown", "value"))
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
 )
 
-// Check that it still fills the interface.
-var _ extensions.BrokerController = (*Wrapper)(nil)
+// Check that it still fills the interfaces.
+var (
+	_ extensions.BrokerController = (*Wrapper)(nil)
+	_ extensions.DelayedPublisher = (*Wrapper)(nil)
+)
 
 // DefaultVersionHeaderKey is the field that will be added to a message to get the version.
 const DefaultVersionHeaderKey = "application-version"
@@ -78,14 +82,49 @@ func WithVersionHeaderKey(headerKey string) WrapperOption {
 // Publish a message to the broker.
 func (w *Wrapper) Publish(ctx context.Context, channel string, mw extensions.BrokerMessage) error {
 	// Add version to message
-	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsVersion, func(version string) {
-		mw.Headers[w.versionHeaderKey] = []byte(version)
-	})
+	w.addVersion(ctx, mw)
 
 	// Send message
 	return w.broker.Publish(ctx, channel, mw)
 }
 
+// PublishBatch publishes several messages to the broker at once.
+func (w *Wrapper) PublishBatch(ctx context.Context, channel string, mws []extensions.BrokerMessage) error {
+	// Add version to messages
+	for _, mw := range mws {
+		w.addVersion(ctx, mw)
+	}
+
+	// Send messages
+	return w.broker.PublishBatch(ctx, channel, mws)
+}
+
+// PublishWithDelay publishes a message to the broker that will be delivered
+// after the delay, if the wrapped broker supports it.
+func (w *Wrapper) PublishWithDelay(
+	ctx context.Context,
+	channel string,
+	mw extensions.BrokerMessage,
+	delay time.Duration,
+) error {
+	// Add version to message
+	w.addVersion(ctx, mw)
+
+	// Send message
+	dp, ok := w.broker.(extensions.DelayedPublisher)
+	if !ok {
+		return extensions.ErrDelayedPublishNotSupported
+	}
+	return dp.PublishWithDelay(ctx, channel, mw, delay)
+}
+
+// addVersion adds the version from the context to the message headers.
+func (w *Wrapper) addVersion(ctx context.Context, mw extensions.BrokerMessage) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsVersion, func(version string) {
+		mw.Headers[w.versionHeaderKey] = []byte(version)
+	})
+}
+
 // Subscribe to messages from the broker.
 func (w *Wrapper) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
 	// Set context
@@ -0,0 +1,48 @@
+package extensions
+
+import "sync"
+
+// WorkerPool executes tasks concurrently, with a bounded number of tasks
+// executed at the same time. A nil WorkerPool executes the tasks sequentially.
+type WorkerPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewWorkerPool creates a new worker pool executing at most 'workers' tasks at
+// the same time. If there is at most one worker, the returned worker pool is
+// nil, to execute the tasks sequentially.
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers <= 1 {
+		return nil
+	}
+	return &WorkerPool{slots: make(chan struct{}, workers)}
+}
+
+// Run executes the task on a worker, waiting for one to be available if they
+// are all busy. If the worker pool is nil, the task is executed before
+// returning.
+func (wp *WorkerPool) Run(task func()) {
+	if wp == nil {
+		task()
+		return
+	}
+
+	wp.slots <- struct{}{}
+	wp.wg.Add(1)
+	go func() {
+		defer func() {
+			<-wp.slots
+			wp.wg.Done()
+		}()
+		task()
+	}()
+}
+
+// Wait waits for the end of the tasks being executed.
+func (wp *WorkerPool) Wait() {
+	if wp == nil {
+		return
+	}
+	wp.wg.Wait()
+}
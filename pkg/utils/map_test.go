@@ -22,3 +22,9 @@ func TestMapToListWithString(t *testing.T) {
 	less := func(a, b string) bool { return a < b }
 	assert.Equal(t, cmp.Diff(expectedOutput, MapToList(input), cmpopts.SortSlices(less)), "")
 }
+
+func TestMapKeysToSortedList(t *testing.T) {
+	input := map[string]int{"b": 2, "c": 3, "a": 1}
+	assert.Equal(t, []string{"a", "b", "c"}, MapKeysToSortedList(input))
+	assert.Empty(t, MapKeysToSortedList(map[string]int(nil)))
+}
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -28,6 +29,9 @@ var namifyPerScheme = map[string]namingSchemeFn{
 var convertKey = convertKeyFuncs["none"]
 var namify = namifyPerScheme["none"]
 
+// initialisms are the words written in upper case in golang names, like ID.
+var initialisms = map[string]bool{}
+
 // NamifyWithoutParams will convert a sentence to a golang conventional type name.
 // and will remove all parameters that can appear between '{' and '}'.
 func NamifyWithoutParams(sentence string) string {
@@ -35,7 +39,7 @@ func NamifyWithoutParams(sentence string) string {
 	re := regexp.MustCompile("{[^()]*}")
 	sentence = string(re.ReplaceAll([]byte(sentence), []byte("_")))
 
-	return namify(sentence)
+	return Namify(sentence)
 }
 
 // DefaultNamifier will convert a sentence to a golang conventional type name.
@@ -80,7 +84,50 @@ func ConvertKey(sentence string) string {
 // Namify is used in template to generated golang structs names
 // according to chosen strategy.
 func Namify(sentence string) string {
-	return namify(sentence)
+	return applyInitialisms(namify(sentence))
+}
+
+// applyInitialisms will put in upper case the words of the name that are
+// initialisms, like 'UserId' to 'UserID'.
+func applyInitialisms(name string) string {
+	if len(initialisms) == 0 {
+		return name
+	}
+
+	var b strings.Builder
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && !isWordStart(runes, i) {
+			continue
+		}
+
+		word := string(runes[start:i])
+		if initialisms[strings.ToUpper(word)] {
+			word = strings.ToUpper(word)
+		}
+		b.WriteString(word)
+		start = i
+	}
+
+	return b.String()
+}
+
+// isWordStart returns true if the rune at the index starts a new word of the
+// name, like the upper case letters after a lower case letter or the last
+// letter of an upper case sequence followed by a lower case letter.
+func isWordStart(runes []rune, i int) bool {
+	previous, current := runes[i-1], runes[i]
+	switch {
+	case unicode.IsDigit(previous) != unicode.IsDigit(current):
+		return true
+	case !unicode.IsUpper(current):
+		return false
+	case !unicode.IsUpper(previous):
+		return true
+	default:
+		return i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	}
 }
 
 // SetConvertKeyFn sets the function used to convert schema property key names.
@@ -107,6 +154,15 @@ func SetNamifyFn(name string) error {
 	return nil
 }
 
+// SetInitialisms sets the words, like ID or URL, that should be written in
+// upper case in the generated golang names.
+func SetInitialisms(words []string) {
+	initialisms = make(map[string]bool, len(words))
+	for _, w := range words {
+		initialisms[strings.ToUpper(w)] = true
+	}
+}
+
 // HasField will check if a struct has a field with the given name.
 func HasField(v any, name string) bool {
 	rv := reflect.ValueOf(v)
@@ -144,13 +200,46 @@ func CutSuffix(s, suffix string) string {
 	return s
 }
 
-var isDateOrDateTimeGenerated = func(format string) bool {
-	return format == "date" || format == "date-time"
+// stringFormats are the formats of the strings that can be generated with a
+// specific type ('uuid' and 'duration' are only supported by asyncapiv3).
+var stringFormats = []string{"date", "date-time", "uuid", "duration"}
+
+var generatedStringFormats = map[string]bool{
+	"date": true, "date-time": true, "uuid": true, "duration": true,
+}
+
+// SetGeneratedStringFormats sets the formats of the strings that are generated
+// with a specific type, the other ones being generated as strings.
+func SetGeneratedStringFormats(formats []string) error {
+	generated := make(map[string]bool, len(formats))
+	for _, f := range formats {
+		if !slices.Contains(stringFormats, f) {
+			return fmt.Errorf("unknown string format %s, supported values: date, date-time, uuid, duration", f)
+		}
+		generated[f] = true
+	}
+
+	generatedStringFormats = generated
+
+	return nil
+}
+
+// IsStringFormatGenerated returns true if the schemas with this format are
+// generated with a specific type instead of a string.
+func IsStringFormatGenerated(format string) bool {
+	return generatedStringFormats[format]
+}
+
+// IsDateOrDateTimeGenerated returns true if the schemas with this format are
+// generated as date or time types.
+func IsDateOrDateTimeGenerated(format string) bool {
+	return (format == "date" || format == "date-time") && generatedStringFormats[format]
 }
 
-// DisableDateOrTimeGeneration is used to disable the generation of date/date-time formats within types.
+// DisableDateOrTimeGeneration is used to disable the generation of all the
+// string formats within types.
 func DisableDateOrTimeGeneration() {
-	isDateOrDateTimeGenerated = func(_ string) bool { return false }
+	generatedStringFormats = map[string]bool{}
 }
 
 // HelpersFunctions returns the functions that can be used as helpers
@@ -159,7 +248,8 @@ func HelpersFunctions() template.FuncMap {
 	return template.FuncMap{
 		"namifyWithoutParam":        NamifyWithoutParams,
 		"namify":                    Namify,
-		"isDateOrDateTimeGenerated": isDateOrDateTimeGenerated,
+		"isDateOrDateTimeGenerated": IsDateOrDateTimeGenerated,
+		"isStringFormatGenerated":   IsStringFormatGenerated,
 		"convertKey":                ConvertKey,
 		"snakeCase":                 strcase.ToSnake,
 		"hasField":                  HasField,
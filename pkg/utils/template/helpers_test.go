@@ -67,3 +67,38 @@ func (suite *HelpersSuite) TestNamifyWithoutParams() {
 		suite.Require().Equal(c.Out, NamifyWithoutParams(c.In), i)
 	}
 }
+
+func (suite *HelpersSuite) TestSetInitialisms() {
+	defer SetInitialisms(nil)
+	SetInitialisms([]string{"id", "URL", "http"})
+
+	cases := []namifyCases{
+		{In: "user_id", Out: "UserID"},
+		{In: "userId", Out: "UserID"},
+		{In: "IdOfUser", Out: "IDOfUser"},
+		{In: "httpURLIds", Out: "HTTPURLIds"},
+		{In: "profile_url2", Out: "ProfileURL2"},
+		{In: "Identity", Out: "Identity"},
+		{In: "TotoIDLala", Out: "TotoIDLala"},
+	}
+
+	for i, c := range cases {
+		suite.Require().Equal(c.Out, Namify(c.In), i)
+	}
+}
+
+func (suite *HelpersSuite) TestSetGeneratedStringFormats() {
+	defer func() { suite.Require().NoError(SetGeneratedStringFormats(stringFormats)) }()
+
+	suite.Require().True(IsStringFormatGenerated("uuid"))
+	suite.Require().True(IsDateOrDateTimeGenerated("date-time"))
+	suite.Require().False(IsDateOrDateTimeGenerated("uuid"))
+
+	suite.Require().NoError(SetGeneratedStringFormats([]string{"date-time", "duration"}))
+	suite.Require().False(IsStringFormatGenerated("uuid"))
+	suite.Require().True(IsStringFormatGenerated("duration"))
+	suite.Require().True(IsDateOrDateTimeGenerated("date-time"))
+	suite.Require().False(IsDateOrDateTimeGenerated("date"))
+
+	suite.Require().Error(SetGeneratedStringFormats([]string{"email"}))
+}
@@ -1,9 +1,18 @@
 package test
 
 import (
+	"net"
+	"net/url"
 	"os"
+	"strings"
+	"testing"
+	"time"
 )
 
+// BrokerDialTimeout is the maximum duration to connect to a broker, when
+// checking that it is available.
+const BrokerDialTimeout = time.Second
+
 // BrokerAddressParams is the parameters for the BrokerAddress function.
 type BrokerAddressParams struct {
 	Schema string
@@ -19,6 +28,11 @@ type BrokerAddressParams struct {
 // BrokerAddress returns the broker address based on the environment.
 // If the environment variable ASYNCAPI_DOCKERIZED is set, it returns
 // the dockerized address.
+//
+// If the environment variable ASYNCAPI_<DOCKERIZED ADDR>_ADDRESS is set, like
+// ASYNCAPI_NATS_JETSTREAM_ADDRESS, it returns its address instead. It is set by
+// the 'testbrokers' command of 'pkg/utils/test/containers', when starting the
+// brokers in containers.
 func BrokerAddress(params BrokerAddressParams) string {
 	var url string
 
@@ -27,6 +41,11 @@ func BrokerAddress(params BrokerAddressParams) string {
 		url = params.Schema + "://"
 	}
 
+	// Use the address of the broker started in a container, if any
+	if addr := os.Getenv(addressEnv(params.DockerizedAddr)); params.DockerizedAddr != "" && addr != "" {
+		return url + addr
+	}
+
 	// Set address based on environment
 	dockerized := (os.Getenv("ASYNCAPI_DOCKERIZED") != "")
 	switch {
@@ -50,3 +69,28 @@ func BrokerAddress(params BrokerAddressParams) string {
 
 	return url
 }
+
+// addressEnv returns the environment variable with the address of the broker
+// started in a container, as set by the 'testbrokers' command.
+func addressEnv(dockerizedAddr string) string {
+	name := strings.ToUpper(strings.ReplaceAll(dockerizedAddr, "-", "_"))
+	return "ASYNCAPI_" + name + "_ADDRESS"
+}
+
+// SkipIfBrokerUnavailable skips the test if no broker listens on the address,
+// which is either an URL like 'nats://localhost:4222' or a 'host:port' address,
+// so the tests needing a broker don't wait for it when it isn't started.
+func SkipIfBrokerUnavailable(t testing.TB, address string) {
+	t.Helper()
+
+	host := address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	conn, err := net.DialTimeout("tcp", host, BrokerDialTimeout)
+	if err != nil {
+		t.Skipf("broker unavailable on %s: %s", address, err)
+	}
+	_ = conn.Close()
+}
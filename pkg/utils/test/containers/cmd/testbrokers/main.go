@@ -0,0 +1,75 @@
+// Command testbrokers starts the brokers in containers, runs a command with
+// their addresses in the environment, and removes the containers at its end.
+//
+// Example, from the root of the repository:
+//
+//	go -C pkg/utils/test/containers run ./cmd/testbrokers -dir ../../../.. \
+//		-brokers nats,kafka go test ./pkg/extensions/brokers/...
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/utils/test/containers"
+)
+
+func main() {
+	code, err := run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+func run() (int, error) {
+	brokers := flag.String("brokers", "nats,nats-jetstream,kafka,rabbitmq",
+		"comma-separated list of the brokers to start")
+	dir := flag.String("dir", ".", "directory in which the command is run")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		return 2, errors.New("usage: testbrokers [-brokers list] [-dir path] command [args...]")
+	}
+
+	// Start the brokers and set their address in the environment of the command
+	env := os.Environ()
+	for _, name := range strings.Split(*brokers, ",") {
+		broker := containers.Broker(strings.TrimSpace(name))
+		if broker == "" {
+			continue
+		}
+
+		bc, err := containers.StartBrokerContainer(context.Background(), broker)
+		if err != nil {
+			return 1, err
+		}
+		defer func() {
+			if err := bc.Terminate(context.Background()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+
+		env = append(env, containers.AddressEnv(broker)+"="+bc.Address)
+	}
+
+	// Run the command
+	cmd := exec.Command(flag.Arg(0), flag.Args()[1:]...)
+	cmd.Dir = *dir
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, err
+	}
+
+	return 0, nil
+}
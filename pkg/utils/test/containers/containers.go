@@ -0,0 +1,203 @@
+// Package containers starts the brokers used by the tests in containers with
+// testcontainers. It is a separate module, so that testcontainers and the
+// docker client are not dependencies of the asyncapi-codegen module.
+package containers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// TestcontainersEnv is the environment variable that enables the tests starting
+// brokers in containers.
+const TestcontainersEnv = "ASYNCAPI_TESTCONTAINERS"
+
+// Broker is a broker that can be started in a container, named as its service
+// in the docker-compose file.
+type Broker string
+
+const (
+	// BrokerNATS is a NATS broker.
+	BrokerNATS Broker = "nats"
+	// BrokerNATSJetStream is a NATS broker with JetStream enabled.
+	BrokerNATSJetStream Broker = "nats-jetstream"
+	// BrokerKafka is a Kafka broker.
+	BrokerKafka Broker = "kafka"
+	// BrokerRabbitMQ is a RabbitMQ broker.
+	BrokerRabbitMQ Broker = "rabbitmq"
+)
+
+// AddressEnv returns the environment variable with the address of the broker,
+// like 'ASYNCAPI_NATS_JETSTREAM_ADDRESS', from which BrokerAddress of
+// 'pkg/utils/test' returns it.
+func AddressEnv(broker Broker) string {
+	name := strings.ToUpper(strings.ReplaceAll(string(broker), "-", "_"))
+	return "ASYNCAPI_" + name + "_ADDRESS"
+}
+
+// brokerStartTimeout is the maximum duration to start a broker container.
+const brokerStartTimeout = 2 * time.Minute
+
+// BrokerContainer is a broker running in a container.
+type BrokerContainer struct {
+	container testcontainers.Container
+
+	// Address is the address of the broker from the host, like 'localhost:32768'
+	Address string
+}
+
+// Terminate stops and removes the container.
+func (bc *BrokerContainer) Terminate(ctx context.Context) error {
+	return bc.container.Terminate(ctx)
+}
+
+// StartBrokerContainer starts the broker in a new container, that should be
+// terminated once used.
+func StartBrokerContainer(ctx context.Context, broker Broker) (*BrokerContainer, error) {
+	req, port, err := brokerContainerRequest(broker)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, brokerStartTimeout)
+	defer cancel()
+
+	c, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("starting %s container: %w", broker, err)
+	}
+
+	host, err := c.Host(ctx)
+	if err != nil {
+		_ = c.Terminate(context.Background())
+		return nil, err
+	}
+	mapped, err := c.MappedPort(ctx, port)
+	if err != nil {
+		_ = c.Terminate(context.Background())
+		return nil, err
+	}
+
+	return &BrokerContainer{
+		container: c,
+		Address:   net.JoinHostPort(host, mapped.Port()),
+	}, nil
+}
+
+// StartBroker starts the broker in a new container for the test, terminated
+// at its end, and returns its address.
+func StartBroker(t testing.TB, broker Broker) string {
+	t.Helper()
+
+	bc, err := StartBrokerContainer(context.Background(), broker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := bc.Terminate(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return bc.Address
+}
+
+var (
+	sharedContainersMutex sync.Mutex
+	sharedContainers      = make(map[Broker]*BrokerContainer)
+)
+
+// SharedBrokerContainer returns the container of the broker shared by the tests
+// of the process, that is started on the first call. It is terminated by the
+// testcontainers reaper at the end of the process.
+func SharedBrokerContainer(ctx context.Context, broker Broker) (*BrokerContainer, error) {
+	sharedContainersMutex.Lock()
+	defer sharedContainersMutex.Unlock()
+
+	if bc, exists := sharedContainers[broker]; exists {
+		return bc, nil
+	}
+
+	bc, err := StartBrokerContainer(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	sharedContainers[broker] = bc
+
+	return bc, nil
+}
+
+// brokerContainerRequest returns the request of the broker container, with the
+// port of the broker in the container.
+func brokerContainerRequest(broker Broker) (testcontainers.ContainerRequest, nat.Port, error) {
+	switch broker {
+	case BrokerNATS, BrokerNATSJetStream:
+		req := testcontainers.ContainerRequest{
+			Image:        "nats:latest",
+			ExposedPorts: []string{"4222/tcp"},
+			WaitingFor:   wait.ForLog("Server is ready"),
+		}
+		if broker == BrokerNATSJetStream {
+			req.Cmd = []string{"-js"}
+		}
+		return req, "4222/tcp", nil
+	case BrokerKafka:
+		// The advertised listener should be the address from the host, so the
+		// port is bound to a free port of the host chosen beforehand
+		hostPort, err := freePort()
+		if err != nil {
+			return testcontainers.ContainerRequest{}, "", err
+		}
+		return testcontainers.ContainerRequest{
+			Image:        "bitnami/kafka:3.5.1",
+			ExposedPorts: []string{"9092/tcp"},
+			Env: map[string]string{
+				"KAFKA_CFG_NODE_ID":                        "0",
+				"KAFKA_CFG_PROCESS_ROLES":                  "controller,broker",
+				"KAFKA_CFG_LISTENERS":                      "INTERNAL://:9092,CONTROLLER://:9093",
+				"KAFKA_CFG_ADVERTISED_LISTENERS":           "INTERNAL://localhost:" + hostPort,
+				"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP": "CONTROLLER:PLAINTEXT,INTERNAL:PLAINTEXT",
+				"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS":       "0@localhost:9093",
+				"KAFKA_CFG_CONTROLLER_LISTENER_NAMES":      "CONTROLLER",
+				"KAFKA_CFG_INTER_BROKER_LISTENER_NAME":     "INTERNAL",
+			},
+			HostConfigModifier: func(hc *container.HostConfig) {
+				hc.PortBindings = nat.PortMap{"9092/tcp": {{HostIP: "0.0.0.0", HostPort: hostPort}}}
+			},
+			WaitingFor: wait.ForLog("Kafka Server started"),
+		}, "9092/tcp", nil
+	case BrokerRabbitMQ:
+		return testcontainers.ContainerRequest{
+			Image:        "rabbitmq:management",
+			ExposedPorts: []string{"5672/tcp"},
+			WaitingFor:   wait.ForLog("Server startup complete"),
+		}, "5672/tcp", nil
+	default:
+		return testcontainers.ContainerRequest{}, "", fmt.Errorf("no container for the broker %q", broker)
+	}
+}
+
+// freePort returns a free port of the host.
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port), nil
+}
@@ -0,0 +1,47 @@
+package containers
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressEnv(t *testing.T) {
+	assert.Equal(t, "ASYNCAPI_KAFKA_ADDRESS", AddressEnv(BrokerKafka))
+	assert.Equal(t, "ASYNCAPI_NATS_JETSTREAM_ADDRESS", AddressEnv(BrokerNATSJetStream))
+}
+
+func TestStartBrokerUnknown(t *testing.T) {
+	_, err := StartBrokerContainer(context.Background(), Broker("unknown"))
+	assert.Error(t, err)
+}
+
+func TestStartBrokerKafka(t *testing.T) {
+	if os.Getenv(TestcontainersEnv) == "" {
+		t.Skipf("%s is not set", TestcontainersEnv)
+	}
+
+	addr := StartBroker(t, BrokerKafka)
+
+	// The broker is reachable from the host through its advertised listener
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	conn, err := kafka.DialContext(ctx, "tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	brokers, err := conn.Brokers()
+	require.NoError(t, err)
+	require.Len(t, brokers, 1)
+
+	_, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	assert.Equal(t, port, strconv.Itoa(brokers[0].Port))
+}
@@ -76,5 +76,7 @@ func BrokerControllers(t *testing.T) ([]extensions.BrokerController, func()) {
 			rabbitmqController,
 		}, func() {
 			natsController.Close()
+			kafkaController.Close()
+			rabbitmqController.Close()
 		}
 }
@@ -4,7 +4,6 @@
 package issue190
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
@@ -47,7 +46,8 @@ func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
 	}
 }
 
-// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
 func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
 	return func(controller *controller) {
 		controller.errorHandler = handler
@@ -95,8 +95,10 @@ func NewV2Issue190Msg1Message() V2Issue190Msg1Message {
 func brokerMessageToV2Issue190Msg1Message(bMsg extensions.BrokerMessage) (V2Issue190Msg1Message, error) {
 	var msg V2Issue190Msg1Message
 
-	// Unmarshal payload to expected message payload format
-	err := json.Unmarshal(bMsg.Payload, &msg.Payload)
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
 	if err != nil {
 		return msg, err
 	}
@@ -110,8 +112,8 @@ func brokerMessageToV2Issue190Msg1Message(bMsg extensions.BrokerMessage) (V2Issu
 func (msg V2Issue190Msg1Message) toBrokerMessage() (extensions.BrokerMessage, error) {
 	// TODO: implement checks on message
 
-	// Marshal payload to JSON
-	payload, err := json.Marshal(msg.Payload)
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
 	if err != nil {
 		return extensions.BrokerMessage{}, err
 	}
@@ -152,8 +154,10 @@ func NewV2Issue190Msg2Message() V2Issue190Msg2Message {
 func brokerMessageToV2Issue190Msg2Message(bMsg extensions.BrokerMessage) (V2Issue190Msg2Message, error) {
 	var msg V2Issue190Msg2Message
 
-	// Unmarshal payload to expected message payload format
-	err := json.Unmarshal(bMsg.Payload, &msg.Payload)
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
 	if err != nil {
 		return msg, err
 	}
@@ -167,8 +171,8 @@ func brokerMessageToV2Issue190Msg2Message(bMsg extensions.BrokerMessage) (V2Issu
 func (msg V2Issue190Msg2Message) toBrokerMessage() (extensions.BrokerMessage, error) {
 	// TODO: implement checks on message
 
-	// Marshal payload to JSON
-	payload, err := json.Marshal(msg.Payload)
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
 	if err != nil {
 		return extensions.BrokerMessage{}, err
 	}
@@ -0,0 +1,188 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p ackpolicy -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package ackpolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// ackBroker is a broker whose received messages are transmitted by the test.
+type ackBroker struct {
+	*inmemory.Controller
+	subs chan extensions.BrokerChannelSubscription
+}
+
+func (ab ackBroker) Subscribe(_ context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage, 10),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	ab.subs <- sub
+	return sub, nil
+}
+
+// acknowledgment records the acknowledgments of a message.
+type acknowledgment struct {
+	id   string
+	acks chan string
+}
+
+func (a acknowledgment) AckMessage() { a.acks <- "ack " + a.id }
+func (a acknowledgment) NakMessage() { a.acks <- "nak " + a.id }
+
+type Suite struct {
+	suite.Suite
+	broker  ackBroker
+	app     *AppController
+	acks    chan string
+	handled chan context.Context
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = ackBroker{Controller: broker, subs: make(chan extensions.BrokerChannelSubscription, 1)}
+
+	suite.acks = make(chan string, 10)
+	suite.handled = make(chan context.Context, 10)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.broker.Close()
+}
+
+// newApp creates the app controller.
+func (suite *Suite) newApp(options ...ControllerOption) {
+	var err error
+	suite.app, err = NewAppController(suite.broker, options...)
+	suite.Require().NoError(err)
+}
+
+// listen subscribes with an handler executing the action if any, and failing on
+// the "fail" messages. It returns the broker subscription.
+func (suite *Suite) listen(action func(ctx context.Context)) extensions.BrokerChannelSubscription {
+	handled := suite.handled
+	suite.Require().NoError(suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(ctx context.Context, msg OrderMessage) error {
+			if action != nil {
+				action(ctx)
+			}
+			handled <- ctx
+			if *msg.Payload.Id == "fail" {
+				return errors.New("failure")
+			}
+			return nil
+		}))
+	return <-suite.broker.subs
+}
+
+// transmit transmits a received message with the id, and returns the context
+// of its handling.
+func (suite *Suite) transmit(sub extensions.BrokerChannelSubscription, id string) context.Context {
+	sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
+		extensions.BrokerMessage{Payload: []byte(`{"id":"` + id + `"}`)},
+		acknowledgment{id: id, acks: suite.acks},
+	))
+
+	select {
+	case ctx := <-suite.handled:
+		return ctx
+	case <-time.After(time.Second):
+		suite.FailNow("the message has not been handled")
+		return nil
+	}
+}
+
+// requireAcks checks the acknowledgments received since the last call.
+func (suite *Suite) requireAcks(expected ...string) {
+	// Let the controller acknowledge the handled messages
+	time.Sleep(50 * time.Millisecond)
+
+	acks := make([]string, 0)
+	for len(suite.acks) > 0 {
+		acks = append(acks, <-suite.acks)
+	}
+	suite.Require().Equal(expected, acks)
+}
+
+func (suite *Suite) TestDefaultPolicy() {
+	suite.newApp()
+	sub := suite.listen(nil)
+
+	suite.transmit(sub, "ok")
+	suite.transmit(sub, "fail")
+	suite.requireAcks("ack ok", "nak fail")
+}
+
+func (suite *Suite) TestManualAck() {
+	suite.newApp()
+	suite.app.SetAckPolicyForReceiveOrderOperation(extensions.AckPolicy{ManualAck: true})
+	sub := suite.listen(nil)
+
+	// The message handled without error is not acknowledged
+	ctx := suite.transmit(sub, "ok")
+	suite.transmit(sub, "fail")
+	suite.requireAcks("nak fail")
+
+	// Until it is acknowledged from the context, only once
+	suite.Require().NoError(extensions.AckFromContext(ctx))
+	suite.Require().NoError(extensions.NakFromContext(ctx))
+	suite.requireAcks("ack ok")
+}
+
+func (suite *Suite) TestManualNak() {
+	suite.newApp(WithAckPolicy(extensions.AckPolicy{ManualNak: true}))
+	sub := suite.listen(nil)
+
+	suite.transmit(sub, "ok")
+	ctx := suite.transmit(sub, "fail")
+	suite.requireAcks("ack ok")
+
+	suite.Require().NoError(extensions.NakFromContext(ctx))
+	suite.requireAcks("nak fail")
+}
+
+func (suite *Suite) TestAckFromHandler() {
+	suite.newApp()
+	sub := suite.listen(func(ctx context.Context) {
+		suite.Require().NoError(extensions.AckFromContext(ctx))
+	})
+
+	// The message acknowledged by the handler is not negatively acknowledged
+	suite.transmit(sub, "fail")
+	suite.requireAcks("ack fail")
+}
+
+func (suite *Suite) TestSkippedMessage() {
+	suite.newApp(WithAckPolicy(extensions.AckPolicy{ManualAck: true}),
+		WithMiddlewares(func(_ context.Context, _ *extensions.BrokerMessage, _ extensions.NextMiddleware) error {
+			return extensions.ErrSkipMessage
+		}))
+	sub := suite.listen(nil)
+
+	// The skipped messages are acknowledged, as they are not handled
+	sub.TransmitReceivedMessage(extensions.NewAcknowledgeableBrokerMessage(
+		extensions.BrokerMessage{Payload: []byte(`{"id":"skipped"}`)},
+		acknowledgment{id: "skipped", acks: suite.acks},
+	))
+	suite.requireAcks("ack skipped")
+}
+
+func (suite *Suite) TestNoMessage() {
+	suite.newApp()
+	suite.Require().ErrorIs(extensions.AckFromContext(context.Background()), extensions.ErrNoMessageAcknowledgment)
+	suite.Require().ErrorIs(extensions.NakFromContext(context.Background()), extensions.ErrNoMessageAcknowledgment)
+}
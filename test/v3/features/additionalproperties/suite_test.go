@@ -0,0 +1,123 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p additionalproperties -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package additionalproperties
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+const eventJSON = `{
+	"name": "created",
+	"score": 3,
+	"labels": {"x-env": "prod", "other": "dropped"},
+	"metadata": {"a": 1, "b": [true]},
+	"extras": {"s-a": "x", "n-b": 2}
+}`
+
+func (suite *Suite) TestUnmarshal() {
+	var e EventSchema
+	suite.Require().NoError(json.Unmarshal([]byte(eventJSON), &e))
+
+	// Additional properties of a different type than the properties
+	suite.Require().Equal("created", e.Name)
+	suite.Require().Equal(map[string]int64{"score": 3}, e.AdditionalProperties)
+
+	// Only the properties matching the pattern properties are kept
+	suite.Require().Equal(map[string]string{"x-env": "prod"}, e.Labels.AdditionalProperties)
+
+	// Values of any type
+	suite.Require().Equal(map[string]any{"a": float64(1), "b": []any{true}}, e.Metadata.AdditionalProperties)
+	suite.Require().Equal(map[string]any{"s-a": "x", "n-b": float64(2)}, e.Extras.AdditionalProperties)
+}
+
+func (suite *Suite) TestRoundTrip() {
+	var e EventSchema
+	suite.Require().NoError(json.Unmarshal([]byte(eventJSON), &e))
+
+	b, err := json.Marshal(e)
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{
+		"name": "created",
+		"score": 3,
+		"labels": {"x-env": "prod"},
+		"metadata": {"a": 1, "b": [true]},
+		"extras": {"s-a": "x", "n-b": 2}
+	}`, string(b))
+
+	// Additional properties are sorted, and the ones that are properties are skipped
+	e = EventSchema{Name: "created", AdditionalProperties: map[string]int64{"b": 2, "a": 1, "name": 3}}
+	b, err = json.Marshal(e)
+	suite.Require().NoError(err)
+	suite.Require().Equal(`{"name":"created","a":1,"b":2}`, string(b))
+}
+
+func (suite *Suite) TestValidation() {
+	e := EventSchema{
+		Name:                 "created",
+		AdditionalProperties: map[string]int64{"score": 0},
+		Labels:               &LabelsPropertyFromEventSchema{AdditionalProperties: map[string]string{"x-env": "production"}},
+	}
+
+	var errs extensions.ValidationErrors
+	suite.Require().ErrorAs(e.Validate(), &errs)
+	suite.Require().Len(errs, 2)
+	suite.Require().Equal("labels.x-env", errs[0].Field)
+	suite.Require().Equal("maxLength", errs[0].Constraint)
+	suite.Require().Equal("score", errs[1].Field)
+	suite.Require().Equal("minimum", errs[1].Constraint)
+}
+
+func (suite *Suite) TestSendReceive() {
+	received := make(chan EventMessageFromEventsChannel, 1)
+	err := suite.app.SubscribeToReceiveEventOperation(context.Background(),
+		func(_ context.Context, msg EventMessageFromEventsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveEventOperation(context.Background())
+
+	sent := NewEventMessageFromEventsChannel()
+	suite.Require().NoError(json.Unmarshal([]byte(eventJSON), &sent.Payload))
+	suite.Require().NoError(suite.user.SendToReceiveEventOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
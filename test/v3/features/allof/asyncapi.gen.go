@@ -0,0 +1,2595 @@
+// Package "allof" provides primitives to interact with the AsyncAPI specification.
+//
+// Code generated by github.com/lerenn/asyncapi-codegen version (devel) DO NOT EDIT.
+package allof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// AppSubscriber contains all handlers that are listening messages for App
+type AppSubscriber interface {
+	// ReceiveOrderCreatedOperationReceived receive all OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+	ReceiveOrderCreatedOperationReceived(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error
+
+	// ReceiveShipmentOperationReceived receive all ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+	ReceiveShipmentOperationReceived(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error
+}
+
+// AppController is the structure that provides sending capabilities to the
+// developer and and connect the broker with the App
+type AppController struct {
+	controller
+}
+
+// NewAppController links the App to the broker
+func NewAppController(bc extensions.BrokerController, options ...ControllerOption) (*AppController, error) {
+	// Check if broker controller has been provided
+	if bc == nil {
+		return nil, extensions.ErrNilBrokerController
+	}
+
+	// Create default controller
+	controller := controller{
+		broker:               bc,
+		subscriptions:        make(map[string]extensions.BrokerChannelSubscription),
+		pauseGates:           make(map[string]*extensions.PauseGate),
+		logger:               extensions.DummyLogger{},
+		middlewares:          make([]extensions.Middleware, 0),
+		operationMiddlewares: make(map[string][]extensions.Middleware),
+		operationConcurrency: make(map[string]int),
+		operationAckPolicies: make(map[string]extensions.AckPolicy),
+		errorHandler:         extensions.DefaultErrorHandler(),
+		inFlight:             extensions.NewInFlight(),
+		health:               extensions.NewHealthTracker(),
+		metrics:              extensions.DummyMetricsCollector{},
+		idGenerator:          extensions.NewUUIDv7,
+	}
+
+	// Apply options
+	for _, option := range options {
+		option(&controller)
+	}
+
+	// Isolate the tenants on the broker, if a tenant resolver is set
+	if controller.tenantResolver != nil {
+		controller.broker = extensions.NewTenantBroker(controller.broker, controller.tenantResolver)
+	}
+
+	// Call the reconnection hook on the reconnections of the broker
+	if notifier, ok := bc.(extensions.ReconnectNotifier); ok && controller.hooks.OnReconnected != nil {
+		ctx := context.WithValue(context.Background(), extensions.ContextKeyIsVersion, "1.0.0")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsProvider, "app")
+		onReconnected := controller.hooks.OnReconnected
+		controller.stopReconnectNotify = notifier.NotifyReconnect(func() { onReconnected(ctx) })
+	}
+
+	return &AppController{controller: controller}, nil
+}
+
+func (c AppController) wrapMiddlewares(
+	middlewares []extensions.Middleware,
+	callback extensions.NextMiddleware,
+) func(ctx context.Context, msg *extensions.BrokerMessage) error {
+	// If there is no more middleware
+	if len(middlewares) == 0 {
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
+				return callback(ctx)
+			}
+			return nil
+		}
+	}
+
+	// Get the next function to call from next middlewares or callback
+	next := c.wrapMiddlewares(middlewares[1:], callback)
+
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
+	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
+			called = true
+			return next(ctx, msg)
+		}
+
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
+		}
+
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
+	}
+}
+
+func (c AppController) executeMiddlewares(ctx context.Context, msg *extensions.BrokerMessage, callback extensions.NextMiddleware) error {
+	// Add the middlewares of the operation, after the controller ones
+	middlewares := c.middlewares
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperation, func(operation string) {
+		if opMiddlewares := c.operationMiddlewares[operation]; len(opMiddlewares) > 0 {
+			middlewares = append(middlewares[:len(middlewares):len(middlewares)], opMiddlewares...)
+		}
+	})
+
+	// Wrap middleware to have 'next' function when calling them
+	wrapped := c.wrapMiddlewares(middlewares, callback)
+
+	// Execute wrapped middlewares
+	return wrapped(ctx, msg)
+}
+
+func addAppContextValues(ctx context.Context, addr string) context.Context {
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsVersion, "1.0.0")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsProvider, "app")
+	return context.WithValue(ctx, extensions.ContextKeyIsChannel, addr)
+}
+
+// Health returns the health of the controller: the one of its broker, if it
+// implements extensions.HealthChecker, and the one of its subscriptions.
+func (c *AppController) Health(ctx context.Context) extensions.Health {
+	return c.health.Health(ctx, c.broker)
+}
+
+// Healthy returns an error wrapping extensions.ErrUnhealthy if the broker is
+// not reachable or if a subscription has ended without unsubscribing.
+func (c *AppController) Healthy(ctx context.Context) error {
+	return c.Health(ctx).Err()
+}
+
+// Ready returns a channel closed once the controller is ready: when it has
+// subscribed to a channel, or right away if it has no operation to receive.
+func (c *AppController) Ready() <-chan struct{} {
+	return c.health.Ready()
+}
+
+// Close will clean up any existing resources on the controller.
+//
+// If enabled with WithDrainOnClose, it stops handling the new received messages
+// and waits for the ones being handled or published before, as long as the
+// context is not done.
+func (c *AppController) Close(ctx context.Context) {
+	// Wait for the messages being handled or published
+	if c.drainOnClose {
+		if err := c.inFlight.Drain(ctx); err != nil {
+			c.logger.Warning(ctx, "Closing before the end of the messages being handled or published",
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	}
+
+	// Stop calling the reconnection hook
+	if c.stopReconnectNotify != nil {
+		c.stopReconnectNotify()
+	}
+
+	// Unsubscribing remaining channels
+	c.UnsubscribeFromAllChannels(ctx)
+
+	c.logger.Info(ctx, "Closed app controller")
+}
+
+// SubscribeToAllChannels will receive messages from channels where channel has
+// no parameter on which the app is expecting messages. For channels with parameters,
+// they should be subscribed independently.
+func (c *AppController) SubscribeToAllChannels(ctx context.Context, as AppSubscriber) error {
+	if as == nil {
+		return extensions.ErrNilAppSubscriber
+	}
+
+	if err := c.SubscribeToReceiveOrderCreatedOperation(ctx, as.ReceiveOrderCreatedOperationReceived); err != nil {
+		return err
+	}
+	if err := c.SubscribeToReceiveShipmentOperation(ctx, as.ReceiveShipmentOperationReceived); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnsubscribeFromAllChannels will stop the subscription of all remaining subscribed channels
+func (c *AppController) UnsubscribeFromAllChannels(ctx context.Context) {
+	c.UnsubscribeFromReceiveOrderCreatedOperation(ctx)
+	c.UnsubscribeFromReceiveShipmentOperation(ctx)
+}
+
+// UseForReceiveOrderCreatedOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of ReceiveOrderCreatedOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *AppController) UseForReceiveOrderCreatedOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["ReceiveOrderCreatedOperation"] = append(c.operationMiddlewares["ReceiveOrderCreatedOperation"], middlewares...)
+}
+
+// SetConcurrencyForReceiveOrderCreatedOperation sets the maximum number of OrderCreatedMessageFromOrdersChannel
+// messages handled concurrently by each subscription of ReceiveOrderCreatedOperation, overriding
+// the concurrency of the controller. A value of 1 keeps the messages handled one at
+// a time, in the order they are received.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *AppController) SetConcurrencyForReceiveOrderCreatedOperation(workers int) {
+	c.operationConcurrency["ReceiveOrderCreatedOperation"] = workers
+}
+
+// SetAckPolicyForReceiveOrderCreatedOperation sets the way the OrderCreatedMessageFromOrdersChannel messages
+// received by ReceiveOrderCreatedOperation are acknowledged once handled, overriding the
+// policy of the controller.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *AppController) SetAckPolicyForReceiveOrderCreatedOperation(policy extensions.AckPolicy) {
+	c.operationAckPolicies["ReceiveOrderCreatedOperation"] = policy
+}
+
+// SubscribeToReceiveOrderCreatedOperation will receive OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+//
+// Callback function 'fn' will be called each time a new message is received.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *AppController) SubscribeToReceiveOrderCreatedOperation(
+	ctx context.Context,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+
+	// Check if the controller is already subscribed
+	_, exists := c.subscriptions[addr]
+	if exists {
+		err := fmt.Errorf("%w: controller is already subscribed on channel %q", extensions.ErrAlreadySubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Subscribe to broker channel
+	sub, err := c.broker.Subscribe(ctx, addr)
+	if err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+	c.logger.Info(ctx, "Subscribed to channel")
+
+	// Record the subscription, to report its health
+	c.health.Subscribed(addr, "ReceiveOrderCreatedOperation")
+	c.hooks.Subscribed(ctx)
+
+	// Create the gate stopping the reading of the messages while paused
+	gate := extensions.NewPauseGate()
+
+	// Asynchronously listen to new messages and pass them to app receiver
+	go func() {
+		// Record the end of the subscription, unhealthy if not unsubscribed
+		defer func() {
+			if c.health.Ended(addr) {
+				c.hooks.Unsubscribed(ctx, extensions.ErrSubscriptionEnded)
+			}
+		}()
+
+		// Handle the messages on a worker pool if they are handled concurrently
+		workers := c.workerPool("ReceiveOrderCreatedOperation")
+		defer workers.Wait()
+
+		for {
+			// Listen to next message
+			stop, err := c.listenToReceiveOrderCreatedOperationNextMessage(addr, sub, fn, gate, workers)
+			if err != nil {
+				c.logger.Error(ctx, err.Error())
+			}
+
+			// Stop if required
+			if stop {
+				return
+			}
+		}
+	}()
+
+	// Add the cancel channel to the inside map
+	c.subscriptions[addr] = sub
+	c.pauseGates[addr] = gate
+
+	return nil
+}
+
+func (c *AppController) listenToReceiveOrderCreatedOperationNextMessage(
+	addr string,
+	sub extensions.BrokerChannelSubscription,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+	gate *extensions.PauseGate,
+	workers *extensions.WorkerPool,
+) (stop bool, err error) {
+	// Wait for the subscription to be resumed, if it is paused
+	<-gate.Resumed()
+
+	// Wait for next message, unless the subscription is paused in the meantime
+	var acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage
+	var open bool
+	select {
+	case acknowledgeableBrokerMessage, open = <-sub.MessagesChannel():
+	case <-gate.Paused():
+		return false, nil
+	}
+
+	// If subscription is closed and there is no more message
+	// (i.e. uninitialized message), then exit the function
+	if !open && acknowledgeableBrokerMessage.IsUninitialized() {
+		return true, nil
+	}
+
+	// Leave the message to the broker if the controller is draining
+	if !c.inFlight.TryAdd() {
+		acknowledgeableBrokerMessage.Nak()
+
+		ctx := addAppContextValues(context.Background(), addr)
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+		c.hooks.DeliveryDropped(ctx, acknowledgeableBrokerMessage.BrokerMessage, extensions.ErrDraining)
+		return false, nil
+	}
+
+	// Handle the message, on a worker if the messages are handled concurrently
+	workers.Run(func() {
+		defer c.inFlight.Done()
+		c.handleReceiveOrderCreatedOperationMessage(addr, acknowledgeableBrokerMessage, fn)
+	})
+
+	return false, nil
+}
+
+func (c *AppController) handleReceiveOrderCreatedOperationMessage(
+	addr string,
+	acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+) {
+	// Create a context for the received message
+	msgCtx, cancel := context.WithCancel(context.Background())
+	msgCtx = addAppContextValues(msgCtx, addr)
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsDirection, "reception")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+	defer cancel()
+
+	// Record the reception of the message
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "ReceiveOrderCreatedOperation"}
+	c.metrics.MessageReceived(metricsLabels)
+	start := time.Now()
+
+	// Set broker message to context
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, acknowledgeableBrokerMessage.String())
+
+	// Set the delivery metadata of the message to context, if set by the broker
+	if acknowledgeableBrokerMessage.Metadata != nil {
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsMessageMetadata, *acknowledgeableBrokerMessage.Metadata)
+	}
+
+	// Set the tenant of the message to context, if the tenants are isolated
+	if c.tenantResolver != nil {
+		msgCtx = extensions.ContextWithTenantOf(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+	}
+
+	// Set the baggage of the message to context
+	msgCtx = extensions.ExtractBaggage(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+
+	// Set the acknowledgment of the message to context, to acknowledge it
+	// from the subscription function
+	ack := extensions.NewMessageAcknowledgment(&acknowledgeableBrokerMessage,
+		func() { c.metrics.MessageAcked(metricsLabels) },
+		func() { c.metrics.MessageNaked(metricsLabels) })
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsAcknowledgment, ack)
+	policy := c.ackPolicyFor("ReceiveOrderCreatedOperation")
+
+	// Execute middlewares before handling the message
+	handleErr := c.executeMiddlewares(msgCtx, &acknowledgeableBrokerMessage.BrokerMessage, func(middlewareCtx context.Context) error {
+		// Process message
+		msg, err := brokerMessageToOrderCreatedMessageFromOrdersChannel(acknowledgeableBrokerMessage.BrokerMessage)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
+		}
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Execute the subscription function
+		if err := fn(middlewareCtx, msg); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// A message skipped by a middleware is acknowledged without being handled,
+	// whatever the acknowledgment policy
+	if errors.Is(handleErr, extensions.ErrSkipMessage) {
+		c.hooks.DeliveryDropped(msgCtx, acknowledgeableBrokerMessage.BrokerMessage, handleErr)
+		c.metrics.MessageHandled(metricsLabels, time.Since(start), nil)
+		c.health.Delivered(addr, nil)
+		ack.Ack()
+		return
+	}
+	c.metrics.MessageHandled(metricsLabels, time.Since(start), handleErr)
+	c.health.Delivered(addr, handleErr)
+
+	// Acknowledge the message once handled by the middlewares and the
+	// subscription function, unless it should be acknowledged manually
+	if handleErr == nil {
+		if !policy.ManualAck {
+			ack.Ack()
+		}
+		return
+	}
+
+	c.errorHandler(msgCtx, addr, &acknowledgeableBrokerMessage, handleErr)
+	// On error execute the acknowledgeableBrokerMessage nack() function and
+	// let the BrokerAcknowledgment decide what is the right nack behavior for
+	// the broker, unless it should be negatively acknowledged manually
+	if !policy.ManualNak {
+		ack.Nak()
+	}
+}
+
+// PauseReceiveOrderCreatedOperation will pause the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel,
+// without unsubscribing: the messages are not delivered by the broker if it supports
+// flow control, or buffered otherwise, until ResumeReceiveOrderCreatedOperation is called.
+func (c *AppController) PauseReceiveOrderCreatedOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+
+	return c.pause(ctx, addr)
+}
+
+// ResumeReceiveOrderCreatedOperation will resume the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel,
+// paused with PauseReceiveOrderCreatedOperation.
+func (c *AppController) ResumeReceiveOrderCreatedOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+
+	return c.resume(ctx, addr)
+}
+
+// UnsubscribeFromReceiveOrderCreatedOperation will stop the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+// A timeout can be set in context to avoid blocking operation, if needed.
+func (c *AppController) UnsubscribeFromReceiveOrderCreatedOperation(
+	ctx context.Context,
+) {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Check if there receivers for this channel
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		return
+	}
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+
+	// Stop the subscription, which is not reported as ended anymore, reading
+	// its last messages if it is paused
+	c.health.Unsubscribed(addr)
+	c.pauseGates[addr].Resume()
+	sub.Cancel(ctx)
+
+	// Remove if from the receivers
+	delete(c.subscriptions, addr)
+	delete(c.pauseGates, addr)
+	c.hooks.Unsubscribed(ctx, nil)
+
+	c.logger.Info(ctx, "Unsubscribed from channel")
+} // UseForReceiveShipmentOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of ReceiveShipmentOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *AppController) UseForReceiveShipmentOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["ReceiveShipmentOperation"] = append(c.operationMiddlewares["ReceiveShipmentOperation"], middlewares...)
+}
+
+// SetConcurrencyForReceiveShipmentOperation sets the maximum number of ShipmentMessageFromShipmentsChannel
+// messages handled concurrently by each subscription of ReceiveShipmentOperation, overriding
+// the concurrency of the controller. A value of 1 keeps the messages handled one at
+// a time, in the order they are received.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *AppController) SetConcurrencyForReceiveShipmentOperation(workers int) {
+	c.operationConcurrency["ReceiveShipmentOperation"] = workers
+}
+
+// SetAckPolicyForReceiveShipmentOperation sets the way the ShipmentMessageFromShipmentsChannel messages
+// received by ReceiveShipmentOperation are acknowledged once handled, overriding the
+// policy of the controller.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *AppController) SetAckPolicyForReceiveShipmentOperation(policy extensions.AckPolicy) {
+	c.operationAckPolicies["ReceiveShipmentOperation"] = policy
+}
+
+// SubscribeToReceiveShipmentOperation will receive ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+//
+// Callback function 'fn' will be called each time a new message is received.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *AppController) SubscribeToReceiveShipmentOperation(
+	ctx context.Context,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+
+	// Check if the controller is already subscribed
+	_, exists := c.subscriptions[addr]
+	if exists {
+		err := fmt.Errorf("%w: controller is already subscribed on channel %q", extensions.ErrAlreadySubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Subscribe to broker channel
+	sub, err := c.broker.Subscribe(ctx, addr)
+	if err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+	c.logger.Info(ctx, "Subscribed to channel")
+
+	// Record the subscription, to report its health
+	c.health.Subscribed(addr, "ReceiveShipmentOperation")
+	c.hooks.Subscribed(ctx)
+
+	// Create the gate stopping the reading of the messages while paused
+	gate := extensions.NewPauseGate()
+
+	// Asynchronously listen to new messages and pass them to app receiver
+	go func() {
+		// Record the end of the subscription, unhealthy if not unsubscribed
+		defer func() {
+			if c.health.Ended(addr) {
+				c.hooks.Unsubscribed(ctx, extensions.ErrSubscriptionEnded)
+			}
+		}()
+
+		// Handle the messages on a worker pool if they are handled concurrently
+		workers := c.workerPool("ReceiveShipmentOperation")
+		defer workers.Wait()
+
+		for {
+			// Listen to next message
+			stop, err := c.listenToReceiveShipmentOperationNextMessage(addr, sub, fn, gate, workers)
+			if err != nil {
+				c.logger.Error(ctx, err.Error())
+			}
+
+			// Stop if required
+			if stop {
+				return
+			}
+		}
+	}()
+
+	// Add the cancel channel to the inside map
+	c.subscriptions[addr] = sub
+	c.pauseGates[addr] = gate
+
+	return nil
+}
+
+func (c *AppController) listenToReceiveShipmentOperationNextMessage(
+	addr string,
+	sub extensions.BrokerChannelSubscription,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+	gate *extensions.PauseGate,
+	workers *extensions.WorkerPool,
+) (stop bool, err error) {
+	// Wait for the subscription to be resumed, if it is paused
+	<-gate.Resumed()
+
+	// Wait for next message, unless the subscription is paused in the meantime
+	var acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage
+	var open bool
+	select {
+	case acknowledgeableBrokerMessage, open = <-sub.MessagesChannel():
+	case <-gate.Paused():
+		return false, nil
+	}
+
+	// If subscription is closed and there is no more message
+	// (i.e. uninitialized message), then exit the function
+	if !open && acknowledgeableBrokerMessage.IsUninitialized() {
+		return true, nil
+	}
+
+	// Leave the message to the broker if the controller is draining
+	if !c.inFlight.TryAdd() {
+		acknowledgeableBrokerMessage.Nak()
+
+		ctx := addAppContextValues(context.Background(), addr)
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+		c.hooks.DeliveryDropped(ctx, acknowledgeableBrokerMessage.BrokerMessage, extensions.ErrDraining)
+		return false, nil
+	}
+
+	// Handle the message, on a worker if the messages are handled concurrently
+	workers.Run(func() {
+		defer c.inFlight.Done()
+		c.handleReceiveShipmentOperationMessage(addr, acknowledgeableBrokerMessage, fn)
+	})
+
+	return false, nil
+}
+
+func (c *AppController) handleReceiveShipmentOperationMessage(
+	addr string,
+	acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+) {
+	// Create a context for the received message
+	msgCtx, cancel := context.WithCancel(context.Background())
+	msgCtx = addAppContextValues(msgCtx, addr)
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsDirection, "reception")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+	defer cancel()
+
+	// Record the reception of the message
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "ReceiveShipmentOperation"}
+	c.metrics.MessageReceived(metricsLabels)
+	start := time.Now()
+
+	// Set broker message to context
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, acknowledgeableBrokerMessage.String())
+
+	// Set the delivery metadata of the message to context, if set by the broker
+	if acknowledgeableBrokerMessage.Metadata != nil {
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsMessageMetadata, *acknowledgeableBrokerMessage.Metadata)
+	}
+
+	// Set the tenant of the message to context, if the tenants are isolated
+	if c.tenantResolver != nil {
+		msgCtx = extensions.ContextWithTenantOf(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+	}
+
+	// Set the baggage of the message to context
+	msgCtx = extensions.ExtractBaggage(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+
+	// Set the acknowledgment of the message to context, to acknowledge it
+	// from the subscription function
+	ack := extensions.NewMessageAcknowledgment(&acknowledgeableBrokerMessage,
+		func() { c.metrics.MessageAcked(metricsLabels) },
+		func() { c.metrics.MessageNaked(metricsLabels) })
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsAcknowledgment, ack)
+	policy := c.ackPolicyFor("ReceiveShipmentOperation")
+
+	// Execute middlewares before handling the message
+	handleErr := c.executeMiddlewares(msgCtx, &acknowledgeableBrokerMessage.BrokerMessage, func(middlewareCtx context.Context) error {
+		// Process message
+		msg, err := brokerMessageToShipmentMessageFromShipmentsChannel(acknowledgeableBrokerMessage.BrokerMessage)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
+		}
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Execute the subscription function
+		if err := fn(middlewareCtx, msg); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// A message skipped by a middleware is acknowledged without being handled,
+	// whatever the acknowledgment policy
+	if errors.Is(handleErr, extensions.ErrSkipMessage) {
+		c.hooks.DeliveryDropped(msgCtx, acknowledgeableBrokerMessage.BrokerMessage, handleErr)
+		c.metrics.MessageHandled(metricsLabels, time.Since(start), nil)
+		c.health.Delivered(addr, nil)
+		ack.Ack()
+		return
+	}
+	c.metrics.MessageHandled(metricsLabels, time.Since(start), handleErr)
+	c.health.Delivered(addr, handleErr)
+
+	// Acknowledge the message once handled by the middlewares and the
+	// subscription function, unless it should be acknowledged manually
+	if handleErr == nil {
+		if !policy.ManualAck {
+			ack.Ack()
+		}
+		return
+	}
+
+	c.errorHandler(msgCtx, addr, &acknowledgeableBrokerMessage, handleErr)
+	// On error execute the acknowledgeableBrokerMessage nack() function and
+	// let the BrokerAcknowledgment decide what is the right nack behavior for
+	// the broker, unless it should be negatively acknowledged manually
+	if !policy.ManualNak {
+		ack.Nak()
+	}
+}
+
+// PauseReceiveShipmentOperation will pause the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel,
+// without unsubscribing: the messages are not delivered by the broker if it supports
+// flow control, or buffered otherwise, until ResumeReceiveShipmentOperation is called.
+func (c *AppController) PauseReceiveShipmentOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+
+	return c.pause(ctx, addr)
+}
+
+// ResumeReceiveShipmentOperation will resume the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel,
+// paused with PauseReceiveShipmentOperation.
+func (c *AppController) ResumeReceiveShipmentOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+
+	return c.resume(ctx, addr)
+}
+
+// UnsubscribeFromReceiveShipmentOperation will stop the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+// A timeout can be set in context to avoid blocking operation, if needed.
+func (c *AppController) UnsubscribeFromReceiveShipmentOperation(
+	ctx context.Context,
+) {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Check if there receivers for this channel
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		return
+	}
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+
+	// Stop the subscription, which is not reported as ended anymore, reading
+	// its last messages if it is paused
+	c.health.Unsubscribed(addr)
+	c.pauseGates[addr].Resume()
+	sub.Cancel(ctx)
+
+	// Remove if from the receivers
+	delete(c.subscriptions, addr)
+	delete(c.pauseGates, addr)
+	c.hooks.Unsubscribed(ctx, nil)
+
+	c.logger.Info(ctx, "Unsubscribed from channel")
+}
+
+// UseForSendOrderCreatedOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of SendOrderCreatedOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *AppController) UseForSendOrderCreatedOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["SendOrderCreatedOperation"] = append(c.operationMiddlewares["SendOrderCreatedOperation"], middlewares...)
+}
+
+// SendAsSendOrderCreatedOperation will send a OrderCreatedMessageFromOrdersChannel message on Orders channel.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *AppController) SendAsSendOrderCreatedOperation(
+	ctx context.Context,
+	msg OrderCreatedMessageFromOrdersChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+
+	// Validate message if enabled
+	if err := c.validate(msg); err != nil {
+		return err
+	}
+
+	// Convert to BrokerMessage
+	brokerMsg, err := msg.toBrokerMessage()
+	if err != nil {
+		return err
+	}
+
+	// Stamp the identifier and the publication time of the message
+	ctx = c.stamp(ctx, &brokerMsg)
+
+	// Set broker message to context
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+	// Send the message on event-broker through middlewares
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		err := extensions.Publish(ctx, c.broker, addr, brokerMsg)
+		c.metrics.MessagePublished(extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "SendOrderCreatedOperation"}, err)
+		return err
+	})
+	if err != nil {
+		c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// SendBatchAsSendOrderCreatedOperation will send several OrderCreatedMessageFromOrdersChannel messages at once on Orders channel.
+//
+// NOTE: the middlewares are executed on each message before the whole batch is sent.
+func (c *AppController) SendBatchAsSendOrderCreatedOperation(
+	ctx context.Context,
+	msgs []OrderCreatedMessageFromOrdersChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Stamp the identifier and the publication time of the message
+		msgCtx = c.stamp(msgCtx, &brokerMsg)
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Send the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, addr, brokerMsgs)
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "SendOrderCreatedOperation"}
+	for _, brokerMsg := range brokerMsgs {
+		c.metrics.MessagePublished(metricsLabels, err)
+		if err != nil {
+			c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
+}
+
+// UseForSendShipmentOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of SendShipmentOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *AppController) UseForSendShipmentOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["SendShipmentOperation"] = append(c.operationMiddlewares["SendShipmentOperation"], middlewares...)
+}
+
+// SendAsSendShipmentOperation will send a ShipmentMessageFromShipmentsChannel message on Shipments channel.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *AppController) SendAsSendShipmentOperation(
+	ctx context.Context,
+	msg ShipmentMessageFromShipmentsChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+
+	// Validate message if enabled
+	if err := c.validate(msg); err != nil {
+		return err
+	}
+
+	// Convert to BrokerMessage
+	brokerMsg, err := msg.toBrokerMessage()
+	if err != nil {
+		return err
+	}
+
+	// Stamp the identifier and the publication time of the message
+	ctx = c.stamp(ctx, &brokerMsg)
+
+	// Set broker message to context
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+	// Send the message on event-broker through middlewares
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		err := extensions.Publish(ctx, c.broker, addr, brokerMsg)
+		c.metrics.MessagePublished(extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "SendShipmentOperation"}, err)
+		return err
+	})
+	if err != nil {
+		c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// SendBatchAsSendShipmentOperation will send several ShipmentMessageFromShipmentsChannel messages at once on Shipments channel.
+//
+// NOTE: the middlewares are executed on each message before the whole batch is sent.
+func (c *AppController) SendBatchAsSendShipmentOperation(
+	ctx context.Context,
+	msgs []ShipmentMessageFromShipmentsChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addAppContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Stamp the identifier and the publication time of the message
+		msgCtx = c.stamp(msgCtx, &brokerMsg)
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Send the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, addr, brokerMsgs)
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "SendShipmentOperation"}
+	for _, brokerMsg := range brokerMsgs {
+		c.metrics.MessagePublished(metricsLabels, err)
+		if err != nil {
+			c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
+}
+
+// UserSubscriber contains all handlers that are listening messages for User
+type UserSubscriber interface {
+	// SendOrderCreatedOperationReceived receive all OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+	SendOrderCreatedOperationReceived(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error
+
+	// SendShipmentOperationReceived receive all ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+	SendShipmentOperationReceived(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error
+}
+
+// UserController is the structure that provides sending capabilities to the
+// developer and and connect the broker with the User
+type UserController struct {
+	controller
+}
+
+// NewUserController links the User to the broker
+func NewUserController(bc extensions.BrokerController, options ...ControllerOption) (*UserController, error) {
+	// Check if broker controller has been provided
+	if bc == nil {
+		return nil, extensions.ErrNilBrokerController
+	}
+
+	// Create default controller
+	controller := controller{
+		broker:               bc,
+		subscriptions:        make(map[string]extensions.BrokerChannelSubscription),
+		pauseGates:           make(map[string]*extensions.PauseGate),
+		logger:               extensions.DummyLogger{},
+		middlewares:          make([]extensions.Middleware, 0),
+		operationMiddlewares: make(map[string][]extensions.Middleware),
+		operationConcurrency: make(map[string]int),
+		operationAckPolicies: make(map[string]extensions.AckPolicy),
+		errorHandler:         extensions.DefaultErrorHandler(),
+		inFlight:             extensions.NewInFlight(),
+		health:               extensions.NewHealthTracker(),
+		metrics:              extensions.DummyMetricsCollector{},
+		idGenerator:          extensions.NewUUIDv7,
+	}
+
+	// Apply options
+	for _, option := range options {
+		option(&controller)
+	}
+
+	// Isolate the tenants on the broker, if a tenant resolver is set
+	if controller.tenantResolver != nil {
+		controller.broker = extensions.NewTenantBroker(controller.broker, controller.tenantResolver)
+	}
+
+	// Call the reconnection hook on the reconnections of the broker
+	if notifier, ok := bc.(extensions.ReconnectNotifier); ok && controller.hooks.OnReconnected != nil {
+		ctx := context.WithValue(context.Background(), extensions.ContextKeyIsVersion, "1.0.0")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsProvider, "user")
+		onReconnected := controller.hooks.OnReconnected
+		controller.stopReconnectNotify = notifier.NotifyReconnect(func() { onReconnected(ctx) })
+	}
+
+	return &UserController{controller: controller}, nil
+}
+
+func (c UserController) wrapMiddlewares(
+	middlewares []extensions.Middleware,
+	callback extensions.NextMiddleware,
+) func(ctx context.Context, msg *extensions.BrokerMessage) error {
+	// If there is no more middleware
+	if len(middlewares) == 0 {
+		return func(ctx context.Context, _ *extensions.BrokerMessage) error {
+			// Call the callback if it exists
+			if callback != nil {
+				return callback(ctx)
+			}
+			return nil
+		}
+	}
+
+	// Get the next function to call from next middlewares or callback
+	next := c.wrapMiddlewares(middlewares[1:], callback)
+
+	// Wrap middleware into a function that will execute the middleware and
+	// then the next wrapped middleware, if the middleware has not called it
+	return func(ctx context.Context, msg *extensions.BrokerMessage) error {
+		// Create the next call with the context and the message, which can be
+		// called several times by the middleware (for example to retry)
+		var called bool
+		nextWithArgs := func(ctx context.Context) error {
+			called = true
+			return next(ctx, msg)
+		}
+
+		// Call the middleware
+		if err := middlewares[0](ctx, msg, nextWithArgs); err != nil {
+			return err
+		}
+
+		// If next has already been called in middleware, it should not be executed again
+		if called {
+			return nil
+		}
+		return nextWithArgs(ctx)
+	}
+}
+
+func (c UserController) executeMiddlewares(ctx context.Context, msg *extensions.BrokerMessage, callback extensions.NextMiddleware) error {
+	// Add the middlewares of the operation, after the controller ones
+	middlewares := c.middlewares
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperation, func(operation string) {
+		if opMiddlewares := c.operationMiddlewares[operation]; len(opMiddlewares) > 0 {
+			middlewares = append(middlewares[:len(middlewares):len(middlewares)], opMiddlewares...)
+		}
+	})
+
+	// Wrap middleware to have 'next' function when calling them
+	wrapped := c.wrapMiddlewares(middlewares, callback)
+
+	// Execute wrapped middlewares
+	return wrapped(ctx, msg)
+}
+
+func addUserContextValues(ctx context.Context, addr string) context.Context {
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsVersion, "1.0.0")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsProvider, "user")
+	return context.WithValue(ctx, extensions.ContextKeyIsChannel, addr)
+}
+
+// Health returns the health of the controller: the one of its broker, if it
+// implements extensions.HealthChecker, and the one of its subscriptions.
+func (c *UserController) Health(ctx context.Context) extensions.Health {
+	return c.health.Health(ctx, c.broker)
+}
+
+// Healthy returns an error wrapping extensions.ErrUnhealthy if the broker is
+// not reachable or if a subscription has ended without unsubscribing.
+func (c *UserController) Healthy(ctx context.Context) error {
+	return c.Health(ctx).Err()
+}
+
+// Ready returns a channel closed once the controller is ready: when it has
+// subscribed to a channel, or right away if it has no operation to receive.
+func (c *UserController) Ready() <-chan struct{} {
+	return c.health.Ready()
+}
+
+// Close will clean up any existing resources on the controller.
+//
+// If enabled with WithDrainOnClose, it stops handling the new received messages
+// and waits for the ones being handled or published before, as long as the
+// context is not done.
+func (c *UserController) Close(ctx context.Context) {
+	// Wait for the messages being handled or published
+	if c.drainOnClose {
+		if err := c.inFlight.Drain(ctx); err != nil {
+			c.logger.Warning(ctx, "Closing before the end of the messages being handled or published",
+				extensions.LogInfo{Key: "error", Value: err.Error()})
+		}
+	}
+
+	// Stop calling the reconnection hook
+	if c.stopReconnectNotify != nil {
+		c.stopReconnectNotify()
+	}
+
+	// Unsubscribing remaining channels
+	c.UnsubscribeFromAllChannels(ctx)
+
+	c.logger.Info(ctx, "Closed user controller")
+}
+
+// SubscribeToAllChannels will receive messages from channels where channel has
+// no parameter on which the app is expecting messages. For channels with parameters,
+// they should be subscribed independently.
+func (c *UserController) SubscribeToAllChannels(ctx context.Context, as UserSubscriber) error {
+	if as == nil {
+		return extensions.ErrNilUserSubscriber
+	}
+
+	if err := c.SubscribeToSendOrderCreatedOperation(ctx, as.SendOrderCreatedOperationReceived); err != nil {
+		return err
+	}
+	if err := c.SubscribeToSendShipmentOperation(ctx, as.SendShipmentOperationReceived); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnsubscribeFromAllChannels will stop the subscription of all remaining subscribed channels
+func (c *UserController) UnsubscribeFromAllChannels(ctx context.Context) {
+	c.UnsubscribeFromSendOrderCreatedOperation(ctx)
+	c.UnsubscribeFromSendShipmentOperation(ctx)
+}
+
+// UseForSendOrderCreatedOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of SendOrderCreatedOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *UserController) UseForSendOrderCreatedOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["SendOrderCreatedOperation"] = append(c.operationMiddlewares["SendOrderCreatedOperation"], middlewares...)
+}
+
+// SetConcurrencyForSendOrderCreatedOperation sets the maximum number of OrderCreatedMessageFromOrdersChannel
+// messages handled concurrently by each subscription of SendOrderCreatedOperation, overriding
+// the concurrency of the controller. A value of 1 keeps the messages handled one at
+// a time, in the order they are received.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *UserController) SetConcurrencyForSendOrderCreatedOperation(workers int) {
+	c.operationConcurrency["SendOrderCreatedOperation"] = workers
+}
+
+// SetAckPolicyForSendOrderCreatedOperation sets the way the OrderCreatedMessageFromOrdersChannel messages
+// received by SendOrderCreatedOperation are acknowledged once handled, overriding the
+// policy of the controller.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *UserController) SetAckPolicyForSendOrderCreatedOperation(policy extensions.AckPolicy) {
+	c.operationAckPolicies["SendOrderCreatedOperation"] = policy
+}
+
+// SubscribeToSendOrderCreatedOperation will receive OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+//
+// Callback function 'fn' will be called each time a new message is received.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *UserController) SubscribeToSendOrderCreatedOperation(
+	ctx context.Context,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+
+	// Check if the controller is already subscribed
+	_, exists := c.subscriptions[addr]
+	if exists {
+		err := fmt.Errorf("%w: controller is already subscribed on channel %q", extensions.ErrAlreadySubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Subscribe to broker channel
+	sub, err := c.broker.Subscribe(ctx, addr)
+	if err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+	c.logger.Info(ctx, "Subscribed to channel")
+
+	// Record the subscription, to report its health
+	c.health.Subscribed(addr, "SendOrderCreatedOperation")
+	c.hooks.Subscribed(ctx)
+
+	// Create the gate stopping the reading of the messages while paused
+	gate := extensions.NewPauseGate()
+
+	// Asynchronously listen to new messages and pass them to app receiver
+	go func() {
+		// Record the end of the subscription, unhealthy if not unsubscribed
+		defer func() {
+			if c.health.Ended(addr) {
+				c.hooks.Unsubscribed(ctx, extensions.ErrSubscriptionEnded)
+			}
+		}()
+
+		// Handle the messages on a worker pool if they are handled concurrently
+		workers := c.workerPool("SendOrderCreatedOperation")
+		defer workers.Wait()
+
+		for {
+			// Listen to next message
+			stop, err := c.listenToSendOrderCreatedOperationNextMessage(addr, sub, fn, gate, workers)
+			if err != nil {
+				c.logger.Error(ctx, err.Error())
+			}
+
+			// Stop if required
+			if stop {
+				return
+			}
+		}
+	}()
+
+	// Add the cancel channel to the inside map
+	c.subscriptions[addr] = sub
+	c.pauseGates[addr] = gate
+
+	return nil
+}
+
+func (c *UserController) listenToSendOrderCreatedOperationNextMessage(
+	addr string,
+	sub extensions.BrokerChannelSubscription,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+	gate *extensions.PauseGate,
+	workers *extensions.WorkerPool,
+) (stop bool, err error) {
+	// Wait for the subscription to be resumed, if it is paused
+	<-gate.Resumed()
+
+	// Wait for next message, unless the subscription is paused in the meantime
+	var acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage
+	var open bool
+	select {
+	case acknowledgeableBrokerMessage, open = <-sub.MessagesChannel():
+	case <-gate.Paused():
+		return false, nil
+	}
+
+	// If subscription is closed and there is no more message
+	// (i.e. uninitialized message), then exit the function
+	if !open && acknowledgeableBrokerMessage.IsUninitialized() {
+		return true, nil
+	}
+
+	// Leave the message to the broker if the controller is draining
+	if !c.inFlight.TryAdd() {
+		acknowledgeableBrokerMessage.Nak()
+
+		ctx := addUserContextValues(context.Background(), addr)
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+		c.hooks.DeliveryDropped(ctx, acknowledgeableBrokerMessage.BrokerMessage, extensions.ErrDraining)
+		return false, nil
+	}
+
+	// Handle the message, on a worker if the messages are handled concurrently
+	workers.Run(func() {
+		defer c.inFlight.Done()
+		c.handleSendOrderCreatedOperationMessage(addr, acknowledgeableBrokerMessage, fn)
+	})
+
+	return false, nil
+}
+
+func (c *UserController) handleSendOrderCreatedOperationMessage(
+	addr string,
+	acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage,
+	fn func(ctx context.Context, msg OrderCreatedMessageFromOrdersChannel) error,
+) {
+	// Create a context for the received message
+	msgCtx, cancel := context.WithCancel(context.Background())
+	msgCtx = addUserContextValues(msgCtx, addr)
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsDirection, "reception")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+	defer cancel()
+
+	// Record the reception of the message
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "SendOrderCreatedOperation"}
+	c.metrics.MessageReceived(metricsLabels)
+	start := time.Now()
+
+	// Set broker message to context
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, acknowledgeableBrokerMessage.String())
+
+	// Set the delivery metadata of the message to context, if set by the broker
+	if acknowledgeableBrokerMessage.Metadata != nil {
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsMessageMetadata, *acknowledgeableBrokerMessage.Metadata)
+	}
+
+	// Set the tenant of the message to context, if the tenants are isolated
+	if c.tenantResolver != nil {
+		msgCtx = extensions.ContextWithTenantOf(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+	}
+
+	// Set the baggage of the message to context
+	msgCtx = extensions.ExtractBaggage(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+
+	// Set the acknowledgment of the message to context, to acknowledge it
+	// from the subscription function
+	ack := extensions.NewMessageAcknowledgment(&acknowledgeableBrokerMessage,
+		func() { c.metrics.MessageAcked(metricsLabels) },
+		func() { c.metrics.MessageNaked(metricsLabels) })
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsAcknowledgment, ack)
+	policy := c.ackPolicyFor("SendOrderCreatedOperation")
+
+	// Execute middlewares before handling the message
+	handleErr := c.executeMiddlewares(msgCtx, &acknowledgeableBrokerMessage.BrokerMessage, func(middlewareCtx context.Context) error {
+		// Process message
+		msg, err := brokerMessageToOrderCreatedMessageFromOrdersChannel(acknowledgeableBrokerMessage.BrokerMessage)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
+		}
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Execute the subscription function
+		if err := fn(middlewareCtx, msg); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// A message skipped by a middleware is acknowledged without being handled,
+	// whatever the acknowledgment policy
+	if errors.Is(handleErr, extensions.ErrSkipMessage) {
+		c.hooks.DeliveryDropped(msgCtx, acknowledgeableBrokerMessage.BrokerMessage, handleErr)
+		c.metrics.MessageHandled(metricsLabels, time.Since(start), nil)
+		c.health.Delivered(addr, nil)
+		ack.Ack()
+		return
+	}
+	c.metrics.MessageHandled(metricsLabels, time.Since(start), handleErr)
+	c.health.Delivered(addr, handleErr)
+
+	// Acknowledge the message once handled by the middlewares and the
+	// subscription function, unless it should be acknowledged manually
+	if handleErr == nil {
+		if !policy.ManualAck {
+			ack.Ack()
+		}
+		return
+	}
+
+	c.errorHandler(msgCtx, addr, &acknowledgeableBrokerMessage, handleErr)
+	// On error execute the acknowledgeableBrokerMessage nack() function and
+	// let the BrokerAcknowledgment decide what is the right nack behavior for
+	// the broker, unless it should be negatively acknowledged manually
+	if !policy.ManualNak {
+		ack.Nak()
+	}
+}
+
+// PauseSendOrderCreatedOperation will pause the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel,
+// without unsubscribing: the messages are not delivered by the broker if it supports
+// flow control, or buffered otherwise, until ResumeSendOrderCreatedOperation is called.
+func (c *UserController) PauseSendOrderCreatedOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+
+	return c.pause(ctx, addr)
+}
+
+// ResumeSendOrderCreatedOperation will resume the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel,
+// paused with PauseSendOrderCreatedOperation.
+func (c *UserController) ResumeSendOrderCreatedOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+
+	return c.resume(ctx, addr)
+}
+
+// UnsubscribeFromSendOrderCreatedOperation will stop the reception of OrderCreatedMessageFromOrdersChannel messages from Orders channel.
+// A timeout can be set in context to avoid blocking operation, if needed.
+func (c *UserController) UnsubscribeFromSendOrderCreatedOperation(
+	ctx context.Context,
+) {
+	// Get channel address
+	addr := "v3.features.allof.orders"
+
+	// Check if there receivers for this channel
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		return
+	}
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendOrderCreatedOperation")
+
+	// Stop the subscription, which is not reported as ended anymore, reading
+	// its last messages if it is paused
+	c.health.Unsubscribed(addr)
+	c.pauseGates[addr].Resume()
+	sub.Cancel(ctx)
+
+	// Remove if from the receivers
+	delete(c.subscriptions, addr)
+	delete(c.pauseGates, addr)
+	c.hooks.Unsubscribed(ctx, nil)
+
+	c.logger.Info(ctx, "Unsubscribed from channel")
+} // UseForSendShipmentOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of SendShipmentOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *UserController) UseForSendShipmentOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["SendShipmentOperation"] = append(c.operationMiddlewares["SendShipmentOperation"], middlewares...)
+}
+
+// SetConcurrencyForSendShipmentOperation sets the maximum number of ShipmentMessageFromShipmentsChannel
+// messages handled concurrently by each subscription of SendShipmentOperation, overriding
+// the concurrency of the controller. A value of 1 keeps the messages handled one at
+// a time, in the order they are received.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *UserController) SetConcurrencyForSendShipmentOperation(workers int) {
+	c.operationConcurrency["SendShipmentOperation"] = workers
+}
+
+// SetAckPolicyForSendShipmentOperation sets the way the ShipmentMessageFromShipmentsChannel messages
+// received by SendShipmentOperation are acknowledged once handled, overriding the
+// policy of the controller.
+//
+// NOTE: it should be called before subscribing, as it is not safe for concurrent use.
+func (c *UserController) SetAckPolicyForSendShipmentOperation(policy extensions.AckPolicy) {
+	c.operationAckPolicies["SendShipmentOperation"] = policy
+}
+
+// SubscribeToSendShipmentOperation will receive ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+//
+// Callback function 'fn' will be called each time a new message is received.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *UserController) SubscribeToSendShipmentOperation(
+	ctx context.Context,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+
+	// Check if the controller is already subscribed
+	_, exists := c.subscriptions[addr]
+	if exists {
+		err := fmt.Errorf("%w: controller is already subscribed on channel %q", extensions.ErrAlreadySubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Subscribe to broker channel
+	sub, err := c.broker.Subscribe(ctx, addr)
+	if err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+	c.logger.Info(ctx, "Subscribed to channel")
+
+	// Record the subscription, to report its health
+	c.health.Subscribed(addr, "SendShipmentOperation")
+	c.hooks.Subscribed(ctx)
+
+	// Create the gate stopping the reading of the messages while paused
+	gate := extensions.NewPauseGate()
+
+	// Asynchronously listen to new messages and pass them to app receiver
+	go func() {
+		// Record the end of the subscription, unhealthy if not unsubscribed
+		defer func() {
+			if c.health.Ended(addr) {
+				c.hooks.Unsubscribed(ctx, extensions.ErrSubscriptionEnded)
+			}
+		}()
+
+		// Handle the messages on a worker pool if they are handled concurrently
+		workers := c.workerPool("SendShipmentOperation")
+		defer workers.Wait()
+
+		for {
+			// Listen to next message
+			stop, err := c.listenToSendShipmentOperationNextMessage(addr, sub, fn, gate, workers)
+			if err != nil {
+				c.logger.Error(ctx, err.Error())
+			}
+
+			// Stop if required
+			if stop {
+				return
+			}
+		}
+	}()
+
+	// Add the cancel channel to the inside map
+	c.subscriptions[addr] = sub
+	c.pauseGates[addr] = gate
+
+	return nil
+}
+
+func (c *UserController) listenToSendShipmentOperationNextMessage(
+	addr string,
+	sub extensions.BrokerChannelSubscription,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+	gate *extensions.PauseGate,
+	workers *extensions.WorkerPool,
+) (stop bool, err error) {
+	// Wait for the subscription to be resumed, if it is paused
+	<-gate.Resumed()
+
+	// Wait for next message, unless the subscription is paused in the meantime
+	var acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage
+	var open bool
+	select {
+	case acknowledgeableBrokerMessage, open = <-sub.MessagesChannel():
+	case <-gate.Paused():
+		return false, nil
+	}
+
+	// If subscription is closed and there is no more message
+	// (i.e. uninitialized message), then exit the function
+	if !open && acknowledgeableBrokerMessage.IsUninitialized() {
+		return true, nil
+	}
+
+	// Leave the message to the broker if the controller is draining
+	if !c.inFlight.TryAdd() {
+		acknowledgeableBrokerMessage.Nak()
+
+		ctx := addUserContextValues(context.Background(), addr)
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "reception")
+		ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+		c.hooks.DeliveryDropped(ctx, acknowledgeableBrokerMessage.BrokerMessage, extensions.ErrDraining)
+		return false, nil
+	}
+
+	// Handle the message, on a worker if the messages are handled concurrently
+	workers.Run(func() {
+		defer c.inFlight.Done()
+		c.handleSendShipmentOperationMessage(addr, acknowledgeableBrokerMessage, fn)
+	})
+
+	return false, nil
+}
+
+func (c *UserController) handleSendShipmentOperationMessage(
+	addr string,
+	acknowledgeableBrokerMessage extensions.AcknowledgeableBrokerMessage,
+	fn func(ctx context.Context, msg ShipmentMessageFromShipmentsChannel) error,
+) {
+	// Create a context for the received message
+	msgCtx, cancel := context.WithCancel(context.Background())
+	msgCtx = addUserContextValues(msgCtx, addr)
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsDirection, "reception")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+	defer cancel()
+
+	// Record the reception of the message
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "SendShipmentOperation"}
+	c.metrics.MessageReceived(metricsLabels)
+	start := time.Now()
+
+	// Set broker message to context
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, acknowledgeableBrokerMessage.String())
+
+	// Set the delivery metadata of the message to context, if set by the broker
+	if acknowledgeableBrokerMessage.Metadata != nil {
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsMessageMetadata, *acknowledgeableBrokerMessage.Metadata)
+	}
+
+	// Set the tenant of the message to context, if the tenants are isolated
+	if c.tenantResolver != nil {
+		msgCtx = extensions.ContextWithTenantOf(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+	}
+
+	// Set the baggage of the message to context
+	msgCtx = extensions.ExtractBaggage(msgCtx, acknowledgeableBrokerMessage.BrokerMessage)
+
+	// Set the acknowledgment of the message to context, to acknowledge it
+	// from the subscription function
+	ack := extensions.NewMessageAcknowledgment(&acknowledgeableBrokerMessage,
+		func() { c.metrics.MessageAcked(metricsLabels) },
+		func() { c.metrics.MessageNaked(metricsLabels) })
+	msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsAcknowledgment, ack)
+	policy := c.ackPolicyFor("SendShipmentOperation")
+
+	// Execute middlewares before handling the message
+	handleErr := c.executeMiddlewares(msgCtx, &acknowledgeableBrokerMessage.BrokerMessage, func(middlewareCtx context.Context) error {
+		// Process message
+		msg, err := brokerMessageToShipmentMessageFromShipmentsChannel(acknowledgeableBrokerMessage.BrokerMessage)
+		if err != nil {
+			return fmt.Errorf("%w: %w", extensions.ErrMessageDecoding, err)
+		}
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Execute the subscription function
+		if err := fn(middlewareCtx, msg); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	// A message skipped by a middleware is acknowledged without being handled,
+	// whatever the acknowledgment policy
+	if errors.Is(handleErr, extensions.ErrSkipMessage) {
+		c.hooks.DeliveryDropped(msgCtx, acknowledgeableBrokerMessage.BrokerMessage, handleErr)
+		c.metrics.MessageHandled(metricsLabels, time.Since(start), nil)
+		c.health.Delivered(addr, nil)
+		ack.Ack()
+		return
+	}
+	c.metrics.MessageHandled(metricsLabels, time.Since(start), handleErr)
+	c.health.Delivered(addr, handleErr)
+
+	// Acknowledge the message once handled by the middlewares and the
+	// subscription function, unless it should be acknowledged manually
+	if handleErr == nil {
+		if !policy.ManualAck {
+			ack.Ack()
+		}
+		return
+	}
+
+	c.errorHandler(msgCtx, addr, &acknowledgeableBrokerMessage, handleErr)
+	// On error execute the acknowledgeableBrokerMessage nack() function and
+	// let the BrokerAcknowledgment decide what is the right nack behavior for
+	// the broker, unless it should be negatively acknowledged manually
+	if !policy.ManualNak {
+		ack.Nak()
+	}
+}
+
+// PauseSendShipmentOperation will pause the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel,
+// without unsubscribing: the messages are not delivered by the broker if it supports
+// flow control, or buffered otherwise, until ResumeSendShipmentOperation is called.
+func (c *UserController) PauseSendShipmentOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+
+	return c.pause(ctx, addr)
+}
+
+// ResumeSendShipmentOperation will resume the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel,
+// paused with PauseSendShipmentOperation.
+func (c *UserController) ResumeSendShipmentOperation(
+	ctx context.Context,
+) error {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+
+	return c.resume(ctx, addr)
+}
+
+// UnsubscribeFromSendShipmentOperation will stop the reception of ShipmentMessageFromShipmentsChannel messages from Shipments channel.
+// A timeout can be set in context to avoid blocking operation, if needed.
+func (c *UserController) UnsubscribeFromSendShipmentOperation(
+	ctx context.Context,
+) {
+	// Get channel address
+	addr := "v3.features.allof.shipments"
+
+	// Check if there receivers for this channel
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		return
+	}
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "SendShipmentOperation")
+
+	// Stop the subscription, which is not reported as ended anymore, reading
+	// its last messages if it is paused
+	c.health.Unsubscribed(addr)
+	c.pauseGates[addr].Resume()
+	sub.Cancel(ctx)
+
+	// Remove if from the receivers
+	delete(c.subscriptions, addr)
+	delete(c.pauseGates, addr)
+	c.hooks.Unsubscribed(ctx, nil)
+
+	c.logger.Info(ctx, "Unsubscribed from channel")
+}
+
+// UseForReceiveOrderCreatedOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of ReceiveOrderCreatedOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *UserController) UseForReceiveOrderCreatedOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["ReceiveOrderCreatedOperation"] = append(c.operationMiddlewares["ReceiveOrderCreatedOperation"], middlewares...)
+}
+
+// SendToReceiveOrderCreatedOperation will send a OrderCreatedMessageFromOrdersChannel message on Orders channel.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *UserController) SendToReceiveOrderCreatedOperation(
+	ctx context.Context,
+	msg OrderCreatedMessageFromOrdersChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+
+	// Validate message if enabled
+	if err := c.validate(msg); err != nil {
+		return err
+	}
+
+	// Convert to BrokerMessage
+	brokerMsg, err := msg.toBrokerMessage()
+	if err != nil {
+		return err
+	}
+
+	// Stamp the identifier and the publication time of the message
+	ctx = c.stamp(ctx, &brokerMsg)
+
+	// Set broker message to context
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+	// Send the message on event-broker through middlewares
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		err := extensions.Publish(ctx, c.broker, addr, brokerMsg)
+		c.metrics.MessagePublished(extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "ReceiveOrderCreatedOperation"}, err)
+		return err
+	})
+	if err != nil {
+		c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// SendBatchToReceiveOrderCreatedOperation will send several OrderCreatedMessageFromOrdersChannel messages at once on Orders channel.
+//
+// NOTE: the middlewares are executed on each message before the whole batch is sent.
+func (c *UserController) SendBatchToReceiveOrderCreatedOperation(
+	ctx context.Context,
+	msgs []OrderCreatedMessageFromOrdersChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.orders"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveOrderCreatedOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForOrderCreatedMessageFromOrdersChannel)
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Stamp the identifier and the publication time of the message
+		msgCtx = c.stamp(msgCtx, &brokerMsg)
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Send the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, addr, brokerMsgs)
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.orders", Operation: "ReceiveOrderCreatedOperation"}
+	for _, brokerMsg := range brokerMsgs {
+		c.metrics.MessagePublished(metricsLabels, err)
+		if err != nil {
+			c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
+}
+
+// UseForReceiveShipmentOperation adds middlewares that will be executed, after the
+// controller ones, only on the messages of ReceiveShipmentOperation.
+//
+// NOTE: it should be called before sending or receiving messages, as it is
+// not safe for concurrent use.
+func (c *UserController) UseForReceiveShipmentOperation(middlewares ...extensions.Middleware) {
+	c.operationMiddlewares["ReceiveShipmentOperation"] = append(c.operationMiddlewares["ReceiveShipmentOperation"], middlewares...)
+}
+
+// SendToReceiveShipmentOperation will send a ShipmentMessageFromShipmentsChannel message on Shipments channel.
+//
+// NOTE: for now, this only support the first message from AsyncAPI list.
+// If you need support for other messages, please raise an issue.
+func (c *UserController) SendToReceiveShipmentOperation(
+	ctx context.Context,
+	msg ShipmentMessageFromShipmentsChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+
+	// Validate message if enabled
+	if err := c.validate(msg); err != nil {
+		return err
+	}
+
+	// Convert to BrokerMessage
+	brokerMsg, err := msg.toBrokerMessage()
+	if err != nil {
+		return err
+	}
+
+	// Stamp the identifier and the publication time of the message
+	ctx = c.stamp(ctx, &brokerMsg)
+
+	// Set broker message to context
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+	// Send the message on event-broker through middlewares
+	err = c.executeMiddlewares(ctx, &brokerMsg, func(ctx context.Context) error {
+		err := extensions.Publish(ctx, c.broker, addr, brokerMsg)
+		c.metrics.MessagePublished(extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "ReceiveShipmentOperation"}, err)
+		return err
+	})
+	if err != nil {
+		c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+	}
+	return err
+}
+
+// SendBatchToReceiveShipmentOperation will send several ShipmentMessageFromShipmentsChannel messages at once on Shipments channel.
+//
+// NOTE: the middlewares are executed on each message before the whole batch is sent.
+func (c *UserController) SendBatchToReceiveShipmentOperation(
+	ctx context.Context,
+	msgs []ShipmentMessageFromShipmentsChannel,
+) error {
+	// Record the publication, to wait for it when draining
+	c.inFlight.Add()
+	defer c.inFlight.Done()
+
+	// Set channel address
+	addr := "v3.features.allof.shipments"
+
+	// Set context
+	ctx = addUserContextValues(ctx, addr)
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsDirection, "publication")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsOperation, "ReceiveShipmentOperation")
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsPayloadSchema, jsonSchemaForShipmentMessageFromShipmentsChannel)
+
+	brokerMsgs := make([]extensions.BrokerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		msgCtx := ctx
+
+		// Validate message if enabled
+		if err := c.validate(msg); err != nil {
+			return err
+		}
+
+		// Convert to BrokerMessage
+		brokerMsg, err := msg.toBrokerMessage()
+		if err != nil {
+			return err
+		}
+
+		// Stamp the identifier and the publication time of the message
+		msgCtx = c.stamp(msgCtx, &brokerMsg)
+
+		// Set broker message to context
+		msgCtx = context.WithValue(msgCtx, extensions.ContextKeyIsBrokerMessage, brokerMsg.String())
+
+		// Add the message to the batch through middlewares
+		if err := c.executeMiddlewares(msgCtx, &brokerMsg, func(_ context.Context) error {
+			brokerMsgs = append(brokerMsgs, brokerMsg)
+			return nil
+		}); err != nil {
+			c.errorHandler(msgCtx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+			return err
+		}
+	}
+
+	// Send the messages on event-broker
+	err := extensions.PublishBatch(ctx, c.broker, addr, brokerMsgs)
+	metricsLabels := extensions.MetricsLabels{Channel: "v3.features.allof.shipments", Operation: "ReceiveShipmentOperation"}
+	for _, brokerMsg := range brokerMsgs {
+		c.metrics.MessagePublished(metricsLabels, err)
+		if err != nil {
+			c.errorHandler(ctx, addr, &extensions.AcknowledgeableBrokerMessage{BrokerMessage: brokerMsg}, err)
+		}
+	}
+	return err
+}
+
+// AsyncAPIVersion is the version of the used AsyncAPI document
+const AsyncAPIVersion = "1.0.0"
+
+// controller is the controller that will be used to communicate with the broker
+// It will be used internally by AppController and UserController
+type controller struct {
+	// broker is the broker controller that will be used to communicate
+	broker extensions.BrokerController
+	// subscriptions is a map of all subscriptions
+	subscriptions map[string]extensions.BrokerChannelSubscription
+	// pauseGates stop reading the messages of the paused subscriptions
+	pauseGates map[string]*extensions.PauseGate
+	// logger is the logger that will be used² to log operations on controller
+	logger extensions.Logger
+	// middlewares are the middlewares that will be executed when sending or
+	// receiving messages
+	middlewares []extensions.Middleware
+	// operationMiddlewares are the middlewares that will be executed after the
+	// controller ones for the messages of an operation, by operation name
+	operationMiddlewares map[string][]extensions.Middleware
+	// metrics collects the metrics of the sent and received messages
+	metrics extensions.MetricsCollector
+	// handler to handle errors from consumers and middlewares
+	errorHandler extensions.ErrorHandler
+	// validation is true if the messages should be validated against the
+	// constraints of the specification
+	validation bool
+	// requestTimeout is the maximum duration of the requests, if not zero
+	requestTimeout time.Duration
+	// concurrency is the maximum number of received messages handled at the
+	// same time by each subscription
+	concurrency int
+	// operationConcurrency overrides the concurrency for the subscriptions of
+	// an operation, by operation name
+	operationConcurrency map[string]int
+	// ackPolicy is the way the received messages are acknowledged
+	ackPolicy extensions.AckPolicy
+	// operationAckPolicies override the acknowledgment policy for the
+	// messages of an operation, by operation name
+	operationAckPolicies map[string]extensions.AckPolicy
+	// inFlight tracks the messages being handled or published
+	inFlight *extensions.InFlight
+	// drainOnClose is true if the controller should wait for the messages
+	// being handled or published when closing
+	drainOnClose bool
+	// health tracks the health of the subscriptions
+	health *extensions.HealthTracker
+	// hooks are called on the lifecycle events of the subscriptions
+	hooks extensions.SubscriptionHooks
+	// stopReconnectNotify stops the notification of the broker reconnections
+	stopReconnectNotify func()
+	// idGenerator generates the identifiers of the sent messages
+	idGenerator extensions.IDGenerator
+	// tenantResolver resolves the tenant of the publications and subscriptions,
+	// if the tenants are isolated
+	tenantResolver extensions.TenantResolver
+}
+
+// ControllerOption is the type of the options that can be passed
+// when creating a new Controller
+type ControllerOption func(controller *controller)
+
+// WithLogger attaches a logger to the controller
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *controller) {
+		controller.logger = logger
+	}
+}
+
+// WithMiddlewares attaches middlewares that will be executed when sending or receiving messages
+func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
+	return func(controller *controller) {
+		controller.middlewares = middlewares
+	}
+}
+
+// WithMetrics attaches a metrics collector, that will record the metrics of
+// the sent and received messages
+func WithMetrics(collector extensions.MetricsCollector) ControllerOption {
+	return func(controller *controller) {
+		controller.metrics = collector
+	}
+}
+
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
+func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
+	return func(controller *controller) {
+		controller.errorHandler = handler
+	}
+}
+
+// WithValidation enables the validation of the messages against the constraints
+// of the specification, before sending them and after receiving them. Invalid
+// messages are rejected with extensions.ValidationErrors.
+func WithValidation() ControllerOption {
+	return func(controller *controller) {
+		controller.validation = true
+	}
+}
+
+// WithRequestTimeout sets the maximum duration of each request waiting for a
+// reply, in addition to the context deadline. The requests that time out return
+// an error wrapping extensions.ErrContextCanceled and context.DeadlineExceeded.
+func WithRequestTimeout(timeout time.Duration) ControllerOption {
+	return func(controller *controller) {
+		controller.requestTimeout = timeout
+	}
+}
+
+// WithConcurrency sets the maximum number of received messages handled
+// concurrently by each subscription, on a pool of workers. By default, the
+// messages are handled one at a time, in the order they are received: with a
+// concurrency above 1, this order is not guaranteed anymore, and the messages
+// can be acknowledged in another order.
+func WithConcurrency(workers int) ControllerOption {
+	return func(controller *controller) {
+		controller.concurrency = workers
+	}
+}
+
+// WithAckPolicy sets the way the received messages are acknowledged once
+// handled. By default, the messages handled without error are acknowledged, and
+// the others are negatively acknowledged.
+func WithAckPolicy(policy extensions.AckPolicy) ControllerOption {
+	return func(controller *controller) {
+		controller.ackPolicy = policy
+	}
+}
+
+// WithDrainOnClose makes the controller drain when closing: it stops handling
+// the new received messages, that are left to the broker, and waits for the
+// messages being handled or published (until the context given to Close is
+// done) before unsubscribing from the channels.
+func WithDrainOnClose() ControllerOption {
+	return func(controller *controller) {
+		controller.drainOnClose = true
+	}
+}
+
+// WithSubscriptionHooks sets the hooks called on the lifecycle events of the
+// subscriptions: subscription, unsubscription, reconnection of the broker (if
+// it implements extensions.ReconnectNotifier) and received messages dropped.
+func WithSubscriptionHooks(hooks extensions.SubscriptionHooks) ControllerOption {
+	return func(controller *controller) {
+		controller.hooks = hooks
+	}
+}
+
+// WithIDGenerator sets the generator of the identifiers of the sent messages,
+// set in their 'messageId' header if it is not already set. By default, the
+// identifiers are UUIDv7.
+func WithIDGenerator(generator extensions.IDGenerator) ControllerOption {
+	return func(controller *controller) {
+		controller.idGenerator = generator
+	}
+}
+
+// WithTenantResolver isolates the tenants given by the resolver: the channels
+// addresses are prefixed by the tenant, the sent messages have it in their
+// 'tenantId' header, and the received messages of another tenant are dropped.
+//
+// The messages sent while handling a received message are for the tenant of
+// the received message, that is also in the context under
+// extensions.ContextKeyIsTenant.
+func WithTenantResolver(resolver extensions.TenantResolver) ControllerOption {
+	return func(controller *controller) {
+		controller.tenantResolver = resolver
+	}
+}
+
+// ackPolicyFor returns the acknowledgment policy of the operation.
+func (c controller) ackPolicyFor(operation string) extensions.AckPolicy {
+	if policy, exists := c.operationAckPolicies[operation]; exists {
+		return policy
+	}
+	return c.ackPolicy
+}
+
+// pause pauses the subscription to the address: its messages are not read
+// anymore, and their delivery by the broker is stopped if it supports it.
+func (c controller) pause(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Stop the delivery by the broker, or buffer the messages if it can't
+	if err := sub.Pause(ctx); err != nil {
+		c.logger.Warning(ctx, "Buffering the messages, as their delivery can't be stopped",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+
+	if c.pauseGates[addr].Pause() {
+		c.health.Paused(addr, true)
+		c.logger.Info(ctx, "Paused subscription")
+	}
+	return nil
+}
+
+// resume resumes the paused subscription to the address.
+func (c controller) resume(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Restart the delivery by the broker
+	if err := sub.Resume(ctx); err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	if c.pauseGates[addr].Resume() {
+		c.health.Paused(addr, false)
+		c.logger.Info(ctx, "Resumed subscription")
+	}
+	return nil
+}
+
+// workerPool returns the worker pool handling the received messages of the
+// operation, or nil if they are handled sequentially.
+func (c controller) workerPool(operation string) *extensions.WorkerPool {
+	workers := c.concurrency
+	if w, exists := c.operationConcurrency[operation]; exists {
+		workers = w
+	}
+	return extensions.NewWorkerPool(workers)
+}
+
+// withRequestTimeout returns the context of a request, with the timeout of the
+// requests if there is one.
+func (c controller) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// stamp sets the 'messageId' and 'sentAt' headers of the sent message if they
+// are not already set, and adds them to the context. The baggage of the
+// context is also set in the headers.
+func (c controller) stamp(ctx context.Context, msg *extensions.BrokerMessage) context.Context {
+	extensions.InjectBaggage(ctx, msg)
+	id, sentAt := extensions.StampMessage(msg, c.idGenerator, time.Now())
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsMessageID, id)
+	return context.WithValue(ctx, extensions.ContextKeyIsSentAt, sentAt)
+}
+
+// validate checks the message against the constraints of the specification,
+// if the validation is enabled.
+func (c controller) validate(msg interface{ Validate() error }) error {
+	if !c.validation {
+		return nil
+	}
+	return msg.Validate()
+}
+
+type MessageWithCorrelationID interface {
+	CorrelationID() string
+	SetCorrelationID(id string)
+}
+
+type Error struct {
+	Channel string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("channel %q: err %v", e.Channel, e.Err)
+}
+
+// OrderCreatedMessageFromOrdersChannel is the message expected for 'OrderCreatedMessageFromOrdersChannel' channel.
+type OrderCreatedMessageFromOrdersChannel struct {
+	// Payload will be inserted in the message payload
+	Payload OrderCreatedSchema
+}
+
+// Validate checks that OrderCreatedMessageFromOrdersChannel respects the constraints of the specification.
+func (msg OrderCreatedMessageFromOrdersChannel) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("payload", msg.Payload.Validate())
+
+	return errs.Err()
+}
+
+// jsonSchemaForOrderCreatedMessageFromOrdersChannel is the JSON Schema of the payload of OrderCreatedMessageFromOrdersChannel.
+const jsonSchemaForOrderCreatedMessageFromOrdersChannel = "{\"properties\":{\"id\":{\"type\":\"string\"},\"orderId\":{\"type\":\"string\"},\"time\":{\"format\":\"date-time\",\"type\":\"string\"},\"traceId\":{\"type\":\"string\"}},\"required\":[\"id\",\"time\",\"traceId\",\"orderId\"],\"type\":\"object\"}"
+
+func NewOrderCreatedMessageFromOrdersChannel() OrderCreatedMessageFromOrdersChannel {
+	var msg OrderCreatedMessageFromOrdersChannel
+
+	return msg
+}
+
+// brokerMessageToOrderCreatedMessageFromOrdersChannel will fill a new OrderCreatedMessageFromOrdersChannel with data from generic broker message
+func brokerMessageToOrderCreatedMessageFromOrdersChannel(bMsg extensions.BrokerMessage) (OrderCreatedMessageFromOrdersChannel, error) {
+	var msg OrderCreatedMessageFromOrdersChannel
+
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+
+	// TODO: run checks on msg type
+
+	return msg, nil
+}
+
+// toBrokerMessage will generate a generic broker message from OrderCreatedMessageFromOrdersChannel data
+func (msg OrderCreatedMessageFromOrdersChannel) toBrokerMessage() (extensions.BrokerMessage, error) {
+	// TODO: implement checks on message
+
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	// There is no headers here
+	headers := make(map[string][]byte, 0)
+
+	return extensions.BrokerMessage{
+		Headers: headers,
+		Payload: payload,
+	}, nil
+}
+
+// ShipmentMessageFromShipmentsChannelPayload is a schema from the AsyncAPI specification required in messages
+type ShipmentMessageFromShipmentsChannelPayload struct {
+	Address *AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload `json:"address,omitempty"`
+	Id      string                                                                  `json:"id"`
+	OrderId string                                                                  `json:"orderId"`
+	Time    time.Time                                                               `json:"time"`
+	TraceId string                                                                  `json:"traceId"`
+}
+
+// Validate checks that ShipmentMessageFromShipmentsChannelPayload respects the constraints of the specification.
+func (t ShipmentMessageFromShipmentsChannelPayload) Validate() error {
+	var errs extensions.ValidationErrors
+	if t.Address != nil {
+		errs.AddNested("address", (*t.Address).Validate())
+	}
+
+	return errs.Err()
+}
+
+// AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload is a schema from the AsyncAPI specification required in messages
+type AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload struct {
+	City    string `json:"city"`
+	ZipCode string `json:"zipCode"`
+}
+
+// Validate checks that AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload respects the constraints of the specification.
+func (t AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// ShipmentMessageFromShipmentsChannel is the message expected for 'ShipmentMessageFromShipmentsChannel' channel.
+type ShipmentMessageFromShipmentsChannel struct {
+	// Payload will be inserted in the message payload
+	Payload ShipmentMessageFromShipmentsChannelPayload
+}
+
+// Validate checks that ShipmentMessageFromShipmentsChannel respects the constraints of the specification.
+func (msg ShipmentMessageFromShipmentsChannel) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("payload", msg.Payload.Validate())
+
+	return errs.Err()
+}
+
+// jsonSchemaForShipmentMessageFromShipmentsChannel is the JSON Schema of the payload of ShipmentMessageFromShipmentsChannel.
+const jsonSchemaForShipmentMessageFromShipmentsChannel = "{\"properties\":{\"address\":{\"properties\":{\"city\":{\"type\":\"string\"},\"zipCode\":{\"type\":\"string\"}},\"required\":[\"city\",\"zipCode\"],\"type\":\"object\"},\"id\":{\"type\":\"string\"},\"orderId\":{\"type\":\"string\"},\"time\":{\"format\":\"date-time\",\"type\":\"string\"},\"traceId\":{\"type\":\"string\"}},\"required\":[\"id\",\"orderId\",\"time\",\"traceId\"],\"type\":\"object\"}"
+
+func NewShipmentMessageFromShipmentsChannel() ShipmentMessageFromShipmentsChannel {
+	var msg ShipmentMessageFromShipmentsChannel
+
+	return msg
+}
+
+// brokerMessageToShipmentMessageFromShipmentsChannel will fill a new ShipmentMessageFromShipmentsChannel with data from generic broker message
+func brokerMessageToShipmentMessageFromShipmentsChannel(bMsg extensions.BrokerMessage) (ShipmentMessageFromShipmentsChannel, error) {
+	var msg ShipmentMessageFromShipmentsChannel
+
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+
+	// TODO: run checks on msg type
+
+	return msg, nil
+}
+
+// toBrokerMessage will generate a generic broker message from ShipmentMessageFromShipmentsChannel data
+func (msg ShipmentMessageFromShipmentsChannel) toBrokerMessage() (extensions.BrokerMessage, error) {
+	// TODO: implement checks on message
+
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("", msg.Payload)
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	// There is no headers here
+	headers := make(map[string][]byte, 0)
+
+	return extensions.BrokerMessage{
+		Headers: headers,
+		Payload: payload,
+	}, nil
+}
+
+// AddressSchema is a schema from the AsyncAPI specification required in messages
+type AddressSchema struct {
+	City string `json:"city"`
+}
+
+// Validate checks that AddressSchema respects the constraints of the specification.
+func (t AddressSchema) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// EventSchema is a schema from the AsyncAPI specification required in messages
+type EventSchema struct {
+	Id      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	TraceId string    `json:"traceId"`
+}
+
+// Validate checks that EventSchema respects the constraints of the specification.
+func (t EventSchema) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// OrderCreatedSchema is a schema from the AsyncAPI specification required in messages
+type OrderCreatedSchema struct {
+	Id      string    `json:"id"`
+	OrderId string    `json:"orderId"`
+	Time    time.Time `json:"time"`
+	TraceId string    `json:"traceId"`
+}
+
+// Validate checks that OrderCreatedSchema respects the constraints of the specification.
+func (t OrderCreatedSchema) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// TraceableSchema is a schema from the AsyncAPI specification required in messages
+type TraceableSchema struct {
+	TraceId string `json:"traceId"`
+}
+
+// Validate checks that TraceableSchema respects the constraints of the specification.
+func (t TraceableSchema) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+const (
+	// OrdersChannelPath is the constant representing the 'OrdersChannel' channel path.
+	OrdersChannelPath = "v3.features.allof.orders"
+	// ShipmentsChannelPath is the constant representing the 'ShipmentsChannel' channel path.
+	ShipmentsChannelPath = "v3.features.allof.shipments"
+)
+
+// ChannelsPaths is an array of all channels paths
+var ChannelsPaths = []string{
+	OrdersChannelPath,
+	ShipmentsChannelPath,
+}
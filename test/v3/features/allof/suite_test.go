@@ -0,0 +1,77 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p allof -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package allof
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestFlattenedFields() {
+	order := OrderCreatedSchema{
+		Id:      "1",
+		OrderId: "42",
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		TraceId: "abc",
+	}
+
+	b, err := json.Marshal(order)
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{"id":"1","orderId":"42","time":"2024-01-02T03:04:05Z","traceId":"abc"}`, string(b))
+
+	var decoded OrderCreatedSchema
+	suite.Require().NoError(json.Unmarshal(b, &decoded))
+	suite.Require().Equal(order, decoded)
+}
+
+func (suite *Suite) TestComposedPayload() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	received := make(chan ShipmentMessageFromShipmentsChannel, 1)
+	err = app.SubscribeToReceiveShipmentOperation(context.Background(),
+		func(_ context.Context, msg ShipmentMessageFromShipmentsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	msg := NewShipmentMessageFromShipmentsChannel()
+	msg.Payload.Id = "1"
+	msg.Payload.OrderId = "42"
+	msg.Payload.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg.Payload.TraceId = "abc"
+	msg.Payload.Address = &AddressPropertyFromAllOfFromShipmentMessageFromShipmentsChannelPayload{
+		City:    "Paris",
+		ZipCode: "75001",
+	}
+	suite.Require().NoError(user.SendToReceiveShipmentOperation(context.Background(), msg))
+
+	select {
+	case r := <-received:
+		suite.Require().Equal(msg, r)
+	case <-time.After(time.Second):
+		suite.FailNow("shipment not received")
+	}
+}
@@ -0,0 +1,114 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p amqpbindings -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package amqpbindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// bindingsRecorder is a broker that records the channel and operation bindings received.
+type bindingsRecorder struct {
+	bindings          []extensions.ChannelBindings
+	operationBindings []extensions.OperationBindings
+}
+
+func (br *bindingsRecorder) record(ctx context.Context) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannelBindings, func(b extensions.ChannelBindings) {
+		br.bindings = append(br.bindings, b)
+	})
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		br.operationBindings = append(br.operationBindings, b)
+	})
+}
+
+func (br *bindingsRecorder) Publish(ctx context.Context, _ string, _ extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) PublishBatch(ctx context.Context, _ string, _ []extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) Subscribe(ctx context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	br.record(ctx)
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestGeneratedBindings() {
+	suite.Require().Equal(extensions.ChannelBindings{
+		AMQP: &extensions.AMQPChannelBindings{
+			Is: "routingKey",
+			Exchange: extensions.AMQPExchangeBindings{
+				Name:    "v3.features.amqpbindings",
+				Type:    "topic",
+				Durable: true,
+				VHost:   "/",
+			},
+			Queue: extensions.AMQPQueueBindings{
+				Name:       "v3.features.amqpbindings.events.queue",
+				AutoDelete: true,
+			},
+		},
+	}, EventsChannelBindings)
+}
+
+func (suite *Suite) TestGeneratedOperationBindings() {
+	suite.Require().Equal(extensions.OperationBindings{
+		AMQP: &extensions.AMQPOperationBindings{
+			Expiration:   100000,
+			CC:           []string{"user.logs"},
+			Priority:     10,
+			DeliveryMode: 2,
+			Mandatory:    true,
+			Timestamp:    true,
+		},
+	}, ReceiveEventOperationBindings)
+}
+
+func (suite *Suite) TestBindingsInContext() {
+	broker := &bindingsRecorder{}
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = app.SubscribeToReceiveEventOperation(context.Background(), func(_ context.Context, _ EventMessageFromEventsChannel) error {
+		return nil
+	})
+	suite.Require().NoError(err)
+	defer app.UnsubscribeFromReceiveEventOperation(context.Background())
+
+	err = user.SendToReceiveEventOperation(context.Background(), EventMessageFromEventsChannel{})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(broker.bindings, 2)
+	suite.Require().Equal(EventsChannelBindings, broker.bindings[0])
+	suite.Require().Equal(EventsChannelBindings, broker.bindings[1])
+
+	suite.Require().Len(broker.operationBindings, 2)
+	suite.Require().Equal(ReceiveEventOperationBindings, broker.operationBindings[0])
+	suite.Require().Equal(ReceiveEventOperationBindings, broker.operationBindings[1])
+}
@@ -0,0 +1,85 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p avro -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package avro
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	hamba "github.com/hamba/avro/v2"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestAvroBinaryPayload() {
+	msg := NewOrderMessageFromOrdersChannel()
+	msg.Payload = OrderMessageFromOrdersChannelPayload{
+		Id:      42,
+		Comment: utils.ToPointer("fragile"),
+		Status:  "NEW",
+		Items: []ItemFromItemsPropertyFromOrderMessageFromOrdersChannelPayload{
+			{Sku: "book", Price: 9.5},
+		},
+		Attributes: AttributesPropertyFromOrderMessageFromOrdersChannelPayload{
+			AdditionalProperties: map[string]string{"color": "red"},
+		},
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/vnd.apache.avro", bMsg.ContentType)
+
+	// Payload is encoded in Avro binary, with the default values for the
+	// missing fields
+	var decoded map[string]any
+	suite.Require().NoError(hamba.Unmarshal(avroCodecForOrderMessageFromOrdersChannel.Schema, bMsg.Payload, &decoded))
+	suite.Require().Equal(int64(42), decoded["id"])
+	suite.Require().Equal(1, decoded["quantity"])
+
+	received, err := brokerMessageToOrderMessageFromOrdersChannel(bMsg)
+	suite.Require().NoError(err)
+	msg.Payload.Quantity = utils.ToPointer(int32(1))
+	suite.Require().Equal(msg.Payload.Id, received.Payload.Id)
+	suite.Require().Equal(msg.Payload.Quantity, received.Payload.Quantity)
+	suite.Require().Equal(msg.Payload.Comment, received.Payload.Comment)
+	suite.Require().Equal(msg.Payload.Items, received.Payload.Items)
+	suite.Require().Equal(msg.Payload.Attributes, received.Payload.Attributes)
+	suite.Require().True(msg.Payload.CreatedAt.Equal(received.Payload.CreatedAt))
+}
+
+func (suite *Suite) TestAvroJSONPayload() {
+	msg := NewPriceMessage()
+	msg.Payload = PriceSchema{
+		Sku:      "book",
+		Amount:   9.5,
+		Checksum: "AQI=",
+		Date:     civil.Date{Year: 2024, Month: time.May, Day: 1},
+	}
+
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/vnd.apache.avro+json", bMsg.ContentType)
+	suite.Require().JSONEq(`{"sku":"book","amount":9.5,"checksum":"AQI=","date":"2024-05-01"}`, string(bMsg.Payload))
+
+	received, err := brokerMessageToPriceMessage(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal(msg.Payload, received.Payload)
+
+	// Avro binary payloads are also accepted, based on their content type
+	bMsg.ContentType = "application/vnd.apache.avro"
+	bMsg.Payload, err = avroCodecForPriceMessage.Marshal(msg.Payload)
+	suite.Require().NoError(err)
+	received, err = brokerMessageToPriceMessage(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal(msg.Payload, received.Payload)
+}
@@ -0,0 +1,74 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p baggage -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package baggage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type userIDKey struct{}
+
+func init() {
+	extensions.RegisterBaggage(userIDKey{}, "userId")
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+func (suite *Suite) TestPropagation() {
+	// The replier gets the user ID of the request in its context
+	err := suite.app.SubscribeToPingOperation(context.Background(), func(ctx context.Context, msg PingMessage) error {
+		userID, _ := ctx.Value(userIDKey{}).(string)
+		return suite.app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+			replyMsg.Payload = "pong to " + userID
+		})
+	})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromPingOperation(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), userIDKey{}, "alice"), time.Second)
+	defer cancel()
+	reply, err := suite.user.RequestToPingOperation(ctx, NewPingMessage())
+	suite.Require().NoError(err)
+	suite.Require().Equal("pong to alice", reply.Payload)
+
+	// The user ID is in the headers of the request, and of the reply sent with
+	// the context of the request
+	for _, addr := range []string{"v3.baggage.ping", "v3.baggage.pong"} {
+		published := suite.broker.Published(addr)
+		suite.Require().Len(published, 1, addr)
+		suite.Require().Equal("alice", string(published[0].Headers["userId"]), addr)
+	}
+}
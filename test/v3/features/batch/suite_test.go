@@ -0,0 +1,65 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p batch -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package batch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// batchRecorder is a broker that records the batches published.
+type batchRecorder struct {
+	batches [][]extensions.BrokerMessage
+}
+
+func (br *batchRecorder) Publish(ctx context.Context, channel string, bm extensions.BrokerMessage) error {
+	return br.PublishBatch(ctx, channel, []extensions.BrokerMessage{bm})
+}
+
+func (br *batchRecorder) PublishBatch(_ context.Context, _ string, bms []extensions.BrokerMessage) error {
+	br.batches = append(br.batches, bms)
+	return nil
+}
+
+func (br *batchRecorder) Subscribe(_ context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	return extensions.BrokerChannelSubscription{}, nil
+}
+
+func (suite *Suite) TestSendBatch() {
+	broker := &batchRecorder{}
+
+	// Add a middleware that should be executed on each message
+	middlewareCalls := 0
+	user, err := NewUserController(broker, WithMiddlewares(
+		func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+			middlewareCalls++
+			msg.Headers["middleware"] = []byte("called")
+			return next(ctx)
+		}))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = user.SendBatchToReceiveEventOperation(context.Background(), []EventMessageFromEventsChannel{
+		{Payload: "first"},
+		{Payload: "second"},
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(2, middlewareCalls)
+	suite.Require().Len(broker.batches, 1)
+	suite.Require().Len(broker.batches[0], 2)
+	suite.Require().Equal([]byte("first"), broker.batches[0][0].Payload)
+	suite.Require().Equal([]byte("second"), broker.batches[0][1].Payload)
+	suite.Require().Equal([]byte("called"), broker.batches[0][1].Headers["middleware"])
+}
@@ -0,0 +1,62 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p codecs -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package codecs
+
+import (
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestPayloadEncodedWithContentTypeCodec() {
+	measure := MeasureSchema{Sensor: "temperature", Value: utils.ToPointer(21.5)}
+
+	var msg MeasureMessageFromCborChannel
+	msg.Payload = measure
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/cbor", bMsg.ContentType)
+
+	var payload map[string]any
+	suite.Require().NoError(extensions.CBORCodec{}.Unmarshal(bMsg.Payload, &payload))
+	suite.Require().Equal(map[string]any{"sensor": "temperature", "value": 21.5}, payload)
+
+	received, err := brokerMessageToMeasureMessageFromCborChannel(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal(measure, received.Payload)
+}
+
+func (suite *Suite) TestPayloadDecodedWithReceivedContentType() {
+	measure := MeasureSchema{Sensor: "humidity"}
+
+	var msg MeasureMessageFromMsgpackChannel
+	msg.Payload = measure
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+
+	// Content type of the received message is used over the one of the spec
+	received, err := brokerMessageToMeasureMessageFromXmlChannel(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal(measure, received.Payload)
+
+	// Content type of the spec is used when there is none
+	var xmlMsg MeasureMessageFromXmlChannel
+	xmlMsg.Payload = measure
+	bMsg, err = xmlMsg.toBrokerMessage()
+	suite.Require().NoError(err)
+	bMsg.ContentType = ""
+
+	received, err = brokerMessageToMeasureMessageFromXmlChannel(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal(measure, received.Payload)
+}
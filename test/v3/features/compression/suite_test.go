@@ -0,0 +1,187 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p compression -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package compression
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+const address = "v3.compression.report"
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *Suite) newUser(mws ...extensions.Middleware) *UserController {
+	user, err := NewUserController(suite.broker, WithMiddlewares(mws...))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { user.Close(context.Background()) })
+	return user
+}
+
+// subscribeApp subscribes with the Decompress middleware, sending the payloads
+// of the handled messages and the handling errors to the returned channels.
+func (suite *Suite) subscribeApp(options ...middlewares.DecompressionOption) (chan string, chan error) {
+	errs := make(chan error, 1)
+	app, err := NewAppController(suite.broker,
+		WithMiddlewares(middlewares.Decompress(options...)),
+		WithErrorHandler(func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			errs <- err
+		}))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+
+	received := make(chan string, 1)
+	suite.Require().NoError(app.SubscribeToReceiveReportOperation(context.Background(),
+		func(_ context.Context, msg ReportMessage) error {
+			received <- msg.Payload
+			return nil
+		}))
+	return received, errs
+}
+
+// subscribeBroker subscribes directly to the broker, to get the messages as
+// published.
+func (suite *Suite) subscribeBroker() extensions.BrokerChannelSubscription {
+	sub, err := suite.broker.Subscribe(context.Background(), address)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { sub.Cancel(context.Background()) })
+	return sub
+}
+
+func (suite *Suite) send(user *UserController, payload string) {
+	msg := NewReportMessage()
+	msg.Payload = payload
+	suite.Require().NoError(user.SendToReceiveReportOperation(context.Background(), msg))
+}
+
+func (suite *Suite) receiveRaw(sub extensions.BrokerChannelSubscription) extensions.BrokerMessage {
+	select {
+	case msg := <-sub.MessagesChannel():
+		msg.Ack()
+		return msg.BrokerMessage
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+		return extensions.BrokerMessage{}
+	}
+}
+
+func (suite *Suite) TestGzip() {
+	suite.testRoundTrip(middlewares.EncodingGzip)
+}
+
+func (suite *Suite) TestZstd() {
+	suite.testRoundTrip(middlewares.EncodingZstd)
+}
+
+func (suite *Suite) testRoundTrip(encoding string) {
+	user := suite.newUser(middlewares.Compress(encoding))
+	received, _ := suite.subscribeApp()
+	raw := suite.subscribeBroker()
+
+	payload := strings.Repeat("report ", 100)
+	suite.send(user, payload)
+
+	// The published payload is compressed
+	msg := suite.receiveRaw(raw)
+	suite.Require().Equal(encoding, string(msg.Headers[middlewares.ContentEncodingHeader]))
+	suite.Require().Less(len(msg.Payload), len(payload))
+
+	// The received payload is decompressed
+	select {
+	case p := <-received:
+		suite.Require().Equal(payload, p)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestMinSize() {
+	user := suite.newUser(middlewares.Compress(middlewares.EncodingGzip, middlewares.WithCompressionMinSize(100)))
+	received, _ := suite.subscribeApp()
+	raw := suite.subscribeBroker()
+
+	suite.send(user, "small")
+
+	msg := suite.receiveRaw(raw)
+	suite.Require().NotContains(msg.Headers, middlewares.ContentEncodingHeader)
+	suite.Require().Equal("small", <-received)
+}
+
+func (suite *Suite) TestMaxSize() {
+	for _, encoding := range []string{middlewares.EncodingGzip, middlewares.EncodingZstd} {
+		suite.Run(encoding, func() {
+			user := suite.newUser(middlewares.Compress(encoding))
+			received, errs := suite.subscribeApp(middlewares.WithDecompressionMaxSize(2000))
+
+			// The payloads up to the maximum size are decompressed
+			payload := strings.Repeat("report ", 200)
+			suite.send(user, payload)
+			suite.Require().Equal(payload, <-received)
+
+			// The bigger ones are rejected
+			suite.send(user, strings.Repeat("report ", 1000))
+			select {
+			case err := <-errs:
+				suite.Require().ErrorIs(err, extensions.ErrDecompressedPayloadTooLarge)
+			case <-time.After(time.Second):
+				suite.FailNow("no error received")
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestUncompressed() {
+	user := suite.newUser()
+	received, _ := suite.subscribeApp()
+
+	suite.send(user, "uncompressed")
+	suite.Require().Equal("uncompressed", <-received)
+}
+
+func (suite *Suite) TestUnsupportedEncoding() {
+	_, errs := suite.subscribeApp()
+
+	suite.Require().NoError(suite.broker.Publish(context.Background(), address, extensions.BrokerMessage{
+		Headers: map[string][]byte{middlewares.ContentEncodingHeader: []byte("br")},
+		Payload: []byte("payload"),
+	}))
+
+	select {
+	case err := <-errs:
+		suite.Require().ErrorIs(err, extensions.ErrUnsupportedContentEncoding)
+	case <-time.After(time.Second):
+		suite.FailNow("no error received")
+	}
+}
+
+func (suite *Suite) TestUnsupportedCompressionEncoding() {
+	user := suite.newUser(middlewares.Compress("br"))
+
+	msg := NewReportMessage()
+	err := user.SendToReceiveReportOperation(context.Background(), msg)
+	suite.Require().ErrorIs(err, extensions.ErrUnsupportedContentEncoding)
+}
@@ -0,0 +1,113 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p concurrency -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+const messages = 6
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	user   *UserController
+
+	mutex      sync.Mutex
+	running    int
+	maxRunning int
+	order      []int64
+	handled    sync.WaitGroup
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+
+	suite.running, suite.maxRunning, suite.order = 0, 0, nil
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// handle records the number of messages handled at the same time, and the
+// order in which they are handled.
+func (suite *Suite) handle(_ context.Context, msg TaskMessage) error {
+	defer suite.handled.Done()
+
+	suite.mutex.Lock()
+	suite.running++
+	suite.maxRunning = max(suite.maxRunning, suite.running)
+	suite.order = append(suite.order, msg.Payload.Id)
+	suite.mutex.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	suite.mutex.Lock()
+	suite.running--
+	suite.mutex.Unlock()
+	return nil
+}
+
+// run subscribes the app to the messages and sends them, returning the
+// maximum number of messages handled at the same time.
+func (suite *Suite) run(app *AppController) int {
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+	suite.Require().NoError(app.SubscribeToReceiveTaskOperation(context.Background(), suite.handle))
+
+	suite.handled.Add(messages)
+	for i := 0; i < messages; i++ {
+		suite.Require().NoError(suite.user.SendToReceiveTaskOperation(context.Background(), TaskMessage{
+			Payload: TaskMessagePayload{Id: int64(i)},
+		}))
+	}
+	suite.handled.Wait()
+
+	suite.mutex.Lock()
+	defer suite.mutex.Unlock()
+	suite.Require().Len(suite.order, messages)
+	return suite.maxRunning
+}
+
+func (suite *Suite) TestSequentialByDefault() {
+	app, err := NewAppController(suite.broker)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(1, suite.run(app))
+	suite.Require().Equal([]int64{0, 1, 2, 3, 4, 5}, suite.order)
+}
+
+func (suite *Suite) TestConcurrency() {
+	app, err := NewAppController(suite.broker, WithConcurrency(3))
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(3, suite.run(app))
+	suite.Require().ElementsMatch([]int64{0, 1, 2, 3, 4, 5}, suite.order)
+}
+
+func (suite *Suite) TestOperationConcurrency() {
+	app, err := NewAppController(suite.broker, WithConcurrency(3))
+	suite.Require().NoError(err)
+
+	// Keep the tasks handled in order
+	app.SetConcurrencyForReceiveTaskOperation(1)
+
+	suite.Require().Equal(1, suite.run(app))
+	suite.Require().Equal([]int64{0, 1, 2, 3, 4, 5}, suite.order)
+}
@@ -0,0 +1,32 @@
+// Package codec contains a codec registered with the configuration file.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Prefix is the prefix of the encoded payloads.
+var Prefix = []byte("prefixed:")
+
+// Prefixed encodes the payloads in JSON, with a prefix.
+type Prefixed struct{}
+
+// Marshal encodes the value in JSON, with the prefix.
+func (Prefixed) Marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(Prefix), b...), nil
+}
+
+// Unmarshal decodes the prefixed JSON data into the value.
+func (Prefixed) Unmarshal(data []byte, v any) error {
+	b, ok := bytes.CutPrefix(data, Prefix)
+	if !ok {
+		return errors.New("missing prefix")
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,76 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/test/v3/features/config/codec"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestCodec() {
+	// The codec of the configuration file is registered
+	suite.Require().Equal(codec.Prefixed{}, extensions.CodecFor("application/x-prefixed"))
+
+	msg := NewItemMessageFromItemsChannel()
+	msg.Payload.ItemID = "item-1"
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().True(bytes.HasPrefix(bMsg.Payload, codec.Prefix))
+}
+
+func (suite *Suite) TestItem() {
+	received := make(chan ItemMessageFromItemsChannel, 1)
+	err := suite.app.SubscribeToReceiveItemOperation(context.Background(),
+		func(_ context.Context, msg ItemMessageFromItemsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveItemOperation(context.Background())
+
+	// The names and optional fields follow the configuration file
+	sent := NewItemMessageFromItemsChannel()
+	sent.Payload.ItemID = "item-1"
+	sent.Payload.Label = "label"
+	suite.Require().NoError(suite.user.SendToReceiveItemOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,93 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p contenttype -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package contenttype
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	testutil "github.com/lerenn/asyncapi-codegen/test"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	brokers, cleanup := testutil.BrokerControllers(t)
+	defer cleanup()
+
+	for _, b := range brokers {
+		suite.Run(t, NewSuite(b))
+	}
+}
+
+type Suite struct {
+	broker extensions.BrokerController
+	app    *AppController
+	user   *UserController
+	suite.Suite
+}
+
+func NewSuite(broker extensions.BrokerController) *Suite {
+	return &Suite{
+		broker: broker,
+	}
+}
+
+func (suite *Suite) SetupTest() {
+	// Create app
+	app, err := NewAppController(suite.broker)
+	suite.Require().NoError(err)
+	suite.app = app
+
+	// Create user
+	user, err := NewUserController(suite.broker)
+	suite.Require().NoError(err)
+	suite.user = user
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestGeneratedContentType() {
+	bMsg, err := JsonMessageFromJsonChannel{}.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/json", bMsg.ContentType)
+
+	bMsg, err = TextMessageFromTextChannel{}.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("text/plain", bMsg.ContentType)
+}
+
+func (suite *Suite) TestContentTypeTransmission() {
+	var wg sync.WaitGroup
+
+	// Check the content type on reception with a middleware
+	suite.app.middlewares = append(suite.app.middlewares,
+		func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+			extensions.IfContextValueEquals(ctx, extensions.ContextKeyIsDirection, "reception", func() {
+				suite.Require().Equal("application/json", msg.ContentType)
+			})
+			return next(ctx)
+		})
+
+	err := suite.app.SubscribeToReceiveJsonOperation(context.Background(),
+		func(_ context.Context, msg JsonMessageFromJsonChannel) error {
+			suite.Require().Equal("hello", *msg.Payload.Text)
+			wg.Done()
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveJsonOperation(context.Background())
+
+	wg.Add(1)
+	var msg JsonMessageFromJsonChannel
+	msg.Payload.Text = utils.ToPointer("hello")
+	err = suite.user.SendToReceiveJsonOperation(context.Background(), msg)
+	suite.Require().NoError(err)
+
+	wg.Wait()
+}
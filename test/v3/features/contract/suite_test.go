@@ -0,0 +1,170 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p contract -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package contract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/contract"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// handler handles the pings received by the application.
+type handler func(ctx context.Context, app *AppController, broker extensions.BrokerController, msg PingMessage) error
+
+// run runs the contract tests on an application handling the pings with the handler.
+func (suite *Suite) run(handle handler) contract.Report {
+	report, err := contract.Run(context.Background(), contract.Params{
+		Specification: "asyncapi.yaml",
+		Start: func(ctx context.Context, broker extensions.BrokerController) (func(), error) {
+			app, err := NewAppController(broker)
+			if err != nil {
+				return nil, err
+			}
+
+			err = app.SubscribeToAllPingOperation(ctx,
+				func(ctx context.Context, _ PingChannelParameters, msg PingMessage) error {
+					return handle(ctx, app, broker, msg)
+				})
+			if err != nil {
+				return nil, err
+			}
+
+			return func() { app.Close(context.Background()) }, nil
+		},
+	})
+	suite.Require().NoError(err)
+	return report
+}
+
+// respect handles the pings as described by the specification.
+func respect(ctx context.Context, app *AppController, _ extensions.BrokerController, msg PingMessage) error {
+	if msg.Payload.Count != nil {
+		if err := app.SendAsPingedOperation(ctx, PingedMessage{
+			Payload: PingedMessagePayload{Count: *msg.Payload.Count},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+		replyMsg.Payload.Message = "pong to " + msg.Payload.Message
+	})
+}
+
+func (suite *Suite) TestRespected() {
+	report := suite.run(respect)
+
+	// Each example should be replayed
+	suite.Require().Len(report.Cases, 2)
+	suite.Require().Equal("ping/PingMessage/hello", report.Cases[0].Name)
+	suite.Require().Equal("ping/PingMessage/many", report.Cases[1].Name)
+	suite.Require().Zero(report.Failures())
+	suite.Require().NoError(report.Err())
+
+	// The report should be written in JUnit format
+	var buf bytes.Buffer
+	suite.Require().NoError(report.WriteJUnit(&buf))
+	suite.Require().Contains(buf.String(), `<testsuite name="Contract App" tests="2" failures="0"`)
+	suite.Require().Contains(buf.String(), `<testcase name="ping/PingMessage/hello" classname="ping"`)
+}
+
+func (suite *Suite) TestInvalidReply() {
+	report := suite.run(func(
+		ctx context.Context, app *AppController, _ extensions.BrokerController, msg PingMessage,
+	) error {
+		return app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {})
+	})
+
+	// The reply should match its schema
+	suite.Require().Equal(2, report.Failures())
+	suite.Require().ErrorIs(report.Err(), contract.ErrContractBroken)
+	suite.Require().Len(report.Cases[0].Failures, 1)
+	suite.Require().Contains(report.Cases[0].Failures[0], "the reply doesn't match the specification")
+
+	// And be reported in JUnit format
+	var buf bytes.Buffer
+	suite.Require().NoError(report.WriteJUnit(&buf))
+	suite.Require().Contains(buf.String(), `<testsuite name="Contract App" tests="2" failures="2"`)
+	suite.Require().Contains(buf.String(), `<failure message="the reply doesn&#39;t match the specification`)
+}
+
+func (suite *Suite) TestWrongCorrelationID() {
+	report := suite.run(func(
+		ctx context.Context, app *AppController, _ extensions.BrokerController, msg PingMessage,
+	) error {
+		return app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+			replyMsg.Payload.Message = "pong"
+			replyMsg.SetCorrelationID("another")
+		})
+	})
+
+	// The reply should have the correlation ID of the example
+	suite.Require().Equal([]string{`the reply should have the correlation ID "1234" of the message, got "another"`},
+		report.Cases[0].Failures)
+}
+
+func (suite *Suite) TestNoReply() {
+	report := suite.run(func(context.Context, *AppController, extensions.BrokerController, PingMessage) error {
+		return nil
+	})
+
+	// The application should reply
+	suite.Require().Equal([]string{"the application has not replied"}, report.Cases[0].Failures)
+}
+
+func (suite *Suite) TestError() {
+	report := suite.run(func(context.Context, *AppController, extensions.BrokerController, PingMessage) error {
+		return errors.New("some error")
+	})
+
+	// The application should acknowledge the examples
+	suite.Require().Equal([]string{
+		"the message has been negatively acknowledged by the application",
+		"the application has not replied",
+	}, report.Cases[0].Failures)
+}
+
+func (suite *Suite) TestInvalidPublication() {
+	report := suite.run(func(
+		ctx context.Context, app *AppController, _ extensions.BrokerController, msg PingMessage,
+	) error {
+		if err := app.SendAsPingedOperation(ctx, PingedMessage{}); err != nil {
+			return err
+		}
+		return respect(ctx, app, nil, msg)
+	})
+
+	// The other messages should match their schema
+	suite.Require().Len(report.Cases[0].Failures, 1)
+	suite.Require().Contains(report.Cases[0].Failures[0],
+		`the message published on "v3.contract.pinged" doesn't match the specification`)
+}
+
+func (suite *Suite) TestUnknownAddress() {
+	report := suite.run(func(
+		ctx context.Context, app *AppController, broker extensions.BrokerController, msg PingMessage,
+	) error {
+		if err := broker.Publish(ctx, "v3.contract.unknown", extensions.BrokerMessage{}); err != nil {
+			return err
+		}
+		return respect(ctx, app, broker, msg)
+	})
+
+	// The messages should be published on the channels of the specification
+	suite.Require().Equal([]string{
+		`a message has been published on "v3.contract.unknown", that is not the address of a sent channel`,
+	}, report.Cases[0].Failures)
+}
@@ -0,0 +1,108 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p conversion -i ./asyncapi.yaml -o ./asyncapi.gen.go --convert-to-v3
+//go:generate go run ../../../../cmd/asyncapi-codegen convert -i ./asyncapi.yaml -o ./asyncapi.v3.yaml
+
+package conversion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/asyncapi/parser"
+	asyncapiv3 "github.com/lerenn/asyncapi-codegen/pkg/asyncapi/v3"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestPublishOperation() {
+	params := V3ConversionUserUserIdSignedupChannelParameters{UserId: "user-1"}
+
+	// Publish operations are received by the application
+	received := make(chan UserSignedUpMessage, 1)
+	err := suite.app.SubscribeToUserSignedUpOperation(context.Background(), params,
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromUserSignedUpOperation(context.Background(), params)
+
+	requestID, name := "request-1", "John"
+	sent := NewUserSignedUpMessage()
+	sent.Headers.RequestId = &requestID
+	sent.Payload.Name = &name
+	suite.Require().NoError(suite.user.SendToUserSignedUpOperation(context.Background(), params, sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestSubscribeOperation() {
+	params := V3ConversionUserUserIdSignedupChannelParameters{UserId: "user-1"}
+
+	// Subscribe operations are sent by the application
+	received := make(chan WelcomeMessageFromV3ConversionUserUserIdSignedupChannel, 1)
+	err := suite.user.SubscribeToWelcomeUserOperation(context.Background(), params,
+		func(_ context.Context, msg WelcomeMessageFromV3ConversionUserUserIdSignedupChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.user.UnsubscribeFromWelcomeUserOperation(context.Background(), params)
+
+	text := "Welcome!"
+	sent := NewWelcomeMessageFromV3ConversionUserUserIdSignedupChannel()
+	sent.Payload.Text = &text
+	suite.Require().NoError(suite.app.SendAsWelcomeUserOperation(context.Background(), params, sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestConvertedFile() {
+	spec, err := parser.FromFile(parser.FromFileParams{Path: "./asyncapi.v3.yaml"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(asyncapiv3.MajorVersion, spec.MajorVersion())
+	suite.Require().NoError(spec.Process())
+
+	specV3, ok := spec.(*asyncapiv3.Specification)
+	suite.Require().True(ok)
+	suite.Require().Equal("v3.conversion.user/{userId}/signedup",
+		specV3.Channels["v3_conversion_user_userId_signedup"].Address)
+	suite.Require().Equal(asyncapiv3.OperationActionIsReceive, specV3.Operations["userSignedUp"].Action)
+	suite.Require().Equal(asyncapiv3.OperationActionIsSend, specV3.Operations["welcomeUser"].Action)
+}
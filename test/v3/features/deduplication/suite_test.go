@@ -0,0 +1,217 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p deduplication -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package deduplication
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	user   *UserController
+
+	errors chan error
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	suite.user = user
+
+	suite.errors = make(chan error, 10)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// subscribe subscribes with the deduplication middleware and the function,
+// sending the payloads of the handled messages to the returned channel.
+func (suite *Suite) subscribe(
+	store middlewares.DeduplicationStore,
+	fn func(msg PaymentMessage) error,
+) chan string {
+	app, err := NewAppController(suite.broker,
+		WithMiddlewares(middlewares.Deduplication(store)),
+		WithErrorHandler(func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			suite.errors <- err
+		}))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+
+	handled := make(chan string, 10)
+	suite.Require().NoError(app.SubscribeToReceivePaymentOperation(context.Background(),
+		func(_ context.Context, msg PaymentMessage) error {
+			if err := fn(msg); err != nil {
+				return err
+			}
+			handled <- msg.Payload
+			return nil
+		}))
+	return handled
+}
+
+func (suite *Suite) send(id, payload string) {
+	msg := NewPaymentMessage()
+	if id != "" {
+		msg.Headers.MessageId = &id
+	}
+	msg.Payload = payload
+	suite.Require().NoError(suite.user.SendToReceivePaymentOperation(context.Background(), msg))
+}
+
+// handled returns the payloads of the messages handled until no message is
+// received for a short time.
+func (suite *Suite) handled(handled chan string) []string {
+	payloads := make([]string, 0)
+	for {
+		select {
+		case payload := <-handled:
+			payloads = append(payloads, payload)
+		case <-time.After(100 * time.Millisecond):
+			return payloads
+		}
+	}
+}
+
+func (suite *Suite) TestDuplicateSkipped() {
+	store := middlewares.NewMemoryDeduplicationStore(0)
+	handled := suite.subscribe(store, func(PaymentMessage) error { return nil })
+
+	suite.send("1", "first")
+	suite.send("1", "duplicate")
+	suite.send("2", "second")
+
+	suite.Require().Equal([]string{"first", "second"}, suite.handled(handled))
+	suite.Require().Empty(suite.errors, "skipped messages should not be errors")
+	suite.Require().Equal(2, store.Len())
+}
+
+func (suite *Suite) TestFailureReleasesKey() {
+	errFailure := errors.New("failure")
+	var once sync.Once
+	handled := suite.subscribe(middlewares.NewMemoryDeduplicationStore(0), func(PaymentMessage) error {
+		var err error
+		once.Do(func() { err = errFailure })
+		return err
+	})
+
+	suite.send("1", "failed")
+	suite.send("1", "redelivered")
+
+	suite.Require().Equal([]string{"redelivered"}, suite.handled(handled))
+	suite.Require().ErrorIs(<-suite.errors, errFailure)
+}
+
+func (suite *Suite) TestWithoutKey() {
+	handled := suite.subscribe(middlewares.NewMemoryDeduplicationStore(0), func(PaymentMessage) error { return nil })
+
+	suite.send("", "first")
+	suite.send("", "second")
+
+	suite.Require().Equal([]string{"first", "second"}, suite.handled(handled))
+}
+
+func (suite *Suite) TestMemoryStoreCapacity() {
+	ctx := context.Background()
+	store := middlewares.NewMemoryDeduplicationStore(2)
+
+	for _, key := range []string{"1", "2", "3"} {
+		reserved, err := store.Reserve(ctx, key, time.Hour)
+		suite.Require().NoError(err)
+		suite.Require().True(reserved)
+	}
+	suite.Require().Equal(2, store.Len())
+
+	// The least recently reserved key has been removed
+	reserved, err := store.Reserve(ctx, "1", time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().True(reserved)
+	reserved, err = store.Reserve(ctx, "3", time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().False(reserved)
+}
+
+func (suite *Suite) TestMemoryStoreTTL() {
+	ctx := context.Background()
+	store := middlewares.NewMemoryDeduplicationStore(0)
+
+	reserved, err := store.Reserve(ctx, "1", time.Millisecond)
+	suite.Require().NoError(err)
+	suite.Require().True(reserved)
+
+	time.Sleep(5 * time.Millisecond)
+	reserved, err = store.Reserve(ctx, "1", time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().True(reserved, "expired key should be reserved again")
+}
+
+func (suite *Suite) TestRedisStore() {
+	client := &fakeRedisClient{values: make(map[string]string)}
+	handled := suite.subscribe(middlewares.NewRedisDeduplicationStore(client, "app:"), func(PaymentMessage) error {
+		return nil
+	})
+
+	suite.send("1", "first")
+	suite.send("1", "duplicate")
+
+	suite.Require().Equal([]string{"first"}, suite.handled(handled))
+	suite.Require().Equal(map[string]string{"app:ReceivePaymentOperation:1": "1"}, client.get())
+	suite.Require().Equal(middlewares.DefaultDeduplicationTTL, client.ttl)
+}
+
+// fakeRedisClient is a Redis client keeping the values in memory.
+type fakeRedisClient struct {
+	mutex  sync.Mutex
+	values map[string]string
+	ttl    time.Duration
+}
+
+func (c *fakeRedisClient) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, exists := c.values[key]; exists {
+		return false, nil
+	}
+	c.values[key], c.ttl = value, ttl
+	return true, nil
+}
+
+func (c *fakeRedisClient) Del(_ context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.values, key)
+	return nil
+}
+
+func (c *fakeRedisClient) get() map[string]string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	values := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+	return values
+}
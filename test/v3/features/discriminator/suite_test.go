@@ -0,0 +1,102 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p discriminator -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package discriminator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestPayloadUnion() {
+	received := make(chan PetSchema, 3)
+	err := suite.app.SubscribeToReceivePetOperation(context.Background(),
+		func(_ context.Context, msg PetMessageFromPetsChannel) error {
+			received <- msg.Payload
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	pets := []PetSchema{
+		CatSchema{PetType: "cat", Name: "Felix", Indoor: utils.ToPointer(true)},
+		DogSchema{PetType: "dog", Name: "Rex"},
+		OneOf2FromPetSchema{PetType: "fish", Water: utils.ToPointer(WaterPropertyFromOneOf2FromPetSchemaSalt)},
+	}
+	for _, p := range pets {
+		msg := NewPetMessageFromPetsChannel()
+		msg.Payload = p
+		suite.Require().NoError(suite.user.SendToReceivePetOperation(context.Background(), msg))
+	}
+
+	for _, expected := range pets {
+		select {
+		case p := <-received:
+			suite.Require().Equal(expected, p)
+		case <-time.After(time.Second):
+			suite.FailNow("pet not received")
+		}
+	}
+}
+
+func (suite *Suite) TestPropertyUnion() {
+	var owner OwnerMessageFromOwnersChannelPayload
+	err := json.Unmarshal([]byte(`{"name":"Alice","pet":{"petType":"dog","name":"Rex","breed":"Beagle"}}`), &owner)
+	suite.Require().NoError(err)
+	suite.Require().Equal(OwnerMessageFromOwnersChannelPayload{
+		Name: "Alice",
+		Pet:  DogSchema{PetType: "dog", Name: "Rex", Breed: utils.ToPointer("Beagle")},
+	}, owner)
+}
+
+func (suite *Suite) TestUnknownVariant() {
+	var owner OwnerMessageFromOwnersChannelPayload
+	err := json.Unmarshal([]byte(`{"name":"Alice","pet":{"petType":"bird"}}`), &owner)
+	suite.Require().ErrorIs(err, extensions.ErrUnknownVariant)
+
+	_, err = brokerMessageToPetMessageFromPetsChannel(extensions.BrokerMessage{Payload: []byte(`{"name":"Tweety"}`)})
+	suite.Require().ErrorIs(err, extensions.ErrUnknownVariant)
+}
+
+func (suite *Suite) TestValidation() {
+	msg := NewOwnerMessageFromOwnersChannel()
+	msg.Payload.Name = "Alice"
+	suite.Require().ErrorContains(msg.Validate(), "payload.pet: is required")
+
+	msg.Payload.Pet = CatSchema{PetType: "cat"}
+	suite.Require().ErrorContains(msg.Validate(), "payload.pet.name: should have at least 1 characters")
+
+	msg.Payload.Pet = CatSchema{PetType: "cat", Name: "Felix"}
+	suite.Require().NoError(msg.Validate())
+}
@@ -0,0 +1,802 @@
+// Package "docs" provides primitives to interact with the AsyncAPI specification.
+//
+// Code generated by github.com/lerenn/asyncapi-codegen version (devel) DO NOT EDIT.
+package docs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/nats"
+
+	"github.com/google/uuid"
+)
+
+// AsyncAPIVersion is the version of the used AsyncAPI document
+const AsyncAPIVersion = "1.2.3"
+
+// controller is the controller that will be used to communicate with the broker
+// It will be used internally by AppController and UserController
+type controller struct {
+	// broker is the broker controller that will be used to communicate
+	broker extensions.BrokerController
+	// subscriptions is a map of all subscriptions
+	subscriptions map[string]extensions.BrokerChannelSubscription
+	// pauseGates stop reading the messages of the paused subscriptions
+	pauseGates map[string]*extensions.PauseGate
+	// logger is the logger that will be used² to log operations on controller
+	logger extensions.Logger
+	// middlewares are the middlewares that will be executed when sending or
+	// receiving messages
+	middlewares []extensions.Middleware
+	// operationMiddlewares are the middlewares that will be executed after the
+	// controller ones for the messages of an operation, by operation name
+	operationMiddlewares map[string][]extensions.Middleware
+	// metrics collects the metrics of the sent and received messages
+	metrics extensions.MetricsCollector
+	// handler to handle errors from consumers and middlewares
+	errorHandler extensions.ErrorHandler
+	// validation is true if the messages should be validated against the
+	// constraints of the specification
+	validation bool
+	// requestTimeout is the maximum duration of the requests, if not zero
+	requestTimeout time.Duration
+	// concurrency is the maximum number of received messages handled at the
+	// same time by each subscription
+	concurrency int
+	// operationConcurrency overrides the concurrency for the subscriptions of
+	// an operation, by operation name
+	operationConcurrency map[string]int
+	// ackPolicy is the way the received messages are acknowledged
+	ackPolicy extensions.AckPolicy
+	// operationAckPolicies override the acknowledgment policy for the
+	// messages of an operation, by operation name
+	operationAckPolicies map[string]extensions.AckPolicy
+	// inFlight tracks the messages being handled or published
+	inFlight *extensions.InFlight
+	// drainOnClose is true if the controller should wait for the messages
+	// being handled or published when closing
+	drainOnClose bool
+	// health tracks the health of the subscriptions
+	health *extensions.HealthTracker
+	// hooks are called on the lifecycle events of the subscriptions
+	hooks extensions.SubscriptionHooks
+	// stopReconnectNotify stops the notification of the broker reconnections
+	stopReconnectNotify func()
+	// idGenerator generates the identifiers of the sent messages
+	idGenerator extensions.IDGenerator
+	// tenantResolver resolves the tenant of the publications and subscriptions,
+	// if the tenants are isolated
+	tenantResolver extensions.TenantResolver
+}
+
+// ControllerOption is the type of the options that can be passed
+// when creating a new Controller
+type ControllerOption func(controller *controller)
+
+// WithLogger attaches a logger to the controller
+func WithLogger(logger extensions.Logger) ControllerOption {
+	return func(controller *controller) {
+		controller.logger = logger
+	}
+}
+
+// WithMiddlewares attaches middlewares that will be executed when sending or receiving messages
+func WithMiddlewares(middlewares ...extensions.Middleware) ControllerOption {
+	return func(controller *controller) {
+		controller.middlewares = middlewares
+	}
+}
+
+// WithMetrics attaches a metrics collector, that will record the metrics of
+// the sent and received messages
+func WithMetrics(collector extensions.MetricsCollector) ControllerOption {
+	return func(controller *controller) {
+		controller.metrics = collector
+	}
+}
+
+// WithErrorHandler attaches a errorhandler to handle errors from subscriber functions,
+// received messages decoding and messages publication
+func WithErrorHandler(handler extensions.ErrorHandler) ControllerOption {
+	return func(controller *controller) {
+		controller.errorHandler = handler
+	}
+}
+
+// WithValidation enables the validation of the messages against the constraints
+// of the specification, before sending them and after receiving them. Invalid
+// messages are rejected with extensions.ValidationErrors.
+func WithValidation() ControllerOption {
+	return func(controller *controller) {
+		controller.validation = true
+	}
+}
+
+// WithRequestTimeout sets the maximum duration of each request waiting for a
+// reply, in addition to the context deadline. The requests that time out return
+// an error wrapping extensions.ErrContextCanceled and context.DeadlineExceeded.
+func WithRequestTimeout(timeout time.Duration) ControllerOption {
+	return func(controller *controller) {
+		controller.requestTimeout = timeout
+	}
+}
+
+// WithConcurrency sets the maximum number of received messages handled
+// concurrently by each subscription, on a pool of workers. By default, the
+// messages are handled one at a time, in the order they are received: with a
+// concurrency above 1, this order is not guaranteed anymore, and the messages
+// can be acknowledged in another order.
+func WithConcurrency(workers int) ControllerOption {
+	return func(controller *controller) {
+		controller.concurrency = workers
+	}
+}
+
+// WithAckPolicy sets the way the received messages are acknowledged once
+// handled. By default, the messages handled without error are acknowledged, and
+// the others are negatively acknowledged.
+func WithAckPolicy(policy extensions.AckPolicy) ControllerOption {
+	return func(controller *controller) {
+		controller.ackPolicy = policy
+	}
+}
+
+// WithDrainOnClose makes the controller drain when closing: it stops handling
+// the new received messages, that are left to the broker, and waits for the
+// messages being handled or published (until the context given to Close is
+// done) before unsubscribing from the channels.
+func WithDrainOnClose() ControllerOption {
+	return func(controller *controller) {
+		controller.drainOnClose = true
+	}
+}
+
+// WithSubscriptionHooks sets the hooks called on the lifecycle events of the
+// subscriptions: subscription, unsubscription, reconnection of the broker (if
+// it implements extensions.ReconnectNotifier) and received messages dropped.
+func WithSubscriptionHooks(hooks extensions.SubscriptionHooks) ControllerOption {
+	return func(controller *controller) {
+		controller.hooks = hooks
+	}
+}
+
+// WithIDGenerator sets the generator of the identifiers of the sent messages,
+// set in their 'messageId' header if it is not already set. By default, the
+// identifiers are UUIDv7.
+func WithIDGenerator(generator extensions.IDGenerator) ControllerOption {
+	return func(controller *controller) {
+		controller.idGenerator = generator
+	}
+}
+
+// WithTenantResolver isolates the tenants given by the resolver: the channels
+// addresses are prefixed by the tenant, the sent messages have it in their
+// 'tenantId' header, and the received messages of another tenant are dropped.
+//
+// The messages sent while handling a received message are for the tenant of
+// the received message, that is also in the context under
+// extensions.ContextKeyIsTenant.
+func WithTenantResolver(resolver extensions.TenantResolver) ControllerOption {
+	return func(controller *controller) {
+		controller.tenantResolver = resolver
+	}
+}
+
+// ackPolicyFor returns the acknowledgment policy of the operation.
+func (c controller) ackPolicyFor(operation string) extensions.AckPolicy {
+	if policy, exists := c.operationAckPolicies[operation]; exists {
+		return policy
+	}
+	return c.ackPolicy
+}
+
+// pause pauses the subscription to the address: its messages are not read
+// anymore, and their delivery by the broker is stopped if it supports it.
+func (c controller) pause(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Stop the delivery by the broker, or buffer the messages if it can't
+	if err := sub.Pause(ctx); err != nil {
+		c.logger.Warning(ctx, "Buffering the messages, as their delivery can't be stopped",
+			extensions.LogInfo{Key: "error", Value: err.Error()})
+	}
+
+	if c.pauseGates[addr].Pause() {
+		c.health.Paused(addr, true)
+		c.logger.Info(ctx, "Paused subscription")
+	}
+	return nil
+}
+
+// resume resumes the paused subscription to the address.
+func (c controller) resume(ctx context.Context, addr string) error {
+	sub, exists := c.subscriptions[addr]
+	if !exists {
+		err := fmt.Errorf("%w: controller is not subscribed on channel %q", extensions.ErrNotSubscribedChannel, addr)
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	// Restart the delivery by the broker
+	if err := sub.Resume(ctx); err != nil {
+		c.logger.Error(ctx, err.Error())
+		return err
+	}
+
+	if c.pauseGates[addr].Resume() {
+		c.health.Paused(addr, false)
+		c.logger.Info(ctx, "Resumed subscription")
+	}
+	return nil
+}
+
+// workerPool returns the worker pool handling the received messages of the
+// operation, or nil if they are handled sequentially.
+func (c controller) workerPool(operation string) *extensions.WorkerPool {
+	workers := c.concurrency
+	if w, exists := c.operationConcurrency[operation]; exists {
+		workers = w
+	}
+	return extensions.NewWorkerPool(workers)
+}
+
+// withRequestTimeout returns the context of a request, with the timeout of the
+// requests if there is one.
+func (c controller) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
+
+// stamp sets the 'messageId' and 'sentAt' headers of the sent message if they
+// are not already set, and adds them to the context. The baggage of the
+// context is also set in the headers.
+func (c controller) stamp(ctx context.Context, msg *extensions.BrokerMessage) context.Context {
+	extensions.InjectBaggage(ctx, msg)
+	id, sentAt := extensions.StampMessage(msg, c.idGenerator, time.Now())
+	ctx = context.WithValue(ctx, extensions.ContextKeyIsMessageID, id)
+	return context.WithValue(ctx, extensions.ContextKeyIsSentAt, sentAt)
+}
+
+// validate checks the message against the constraints of the specification,
+// if the validation is enabled.
+func (c controller) validate(msg interface{ Validate() error }) error {
+	if !c.validation {
+		return nil
+	}
+	return msg.Validate()
+}
+
+type MessageWithCorrelationID interface {
+	CorrelationID() string
+	SetCorrelationID(id string)
+}
+
+type Error struct {
+	Channel string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("channel %q: err %v", e.Channel, e.Err)
+}
+
+// AsyncAPIServers are the servers of the AsyncAPI document, by name
+var AsyncAPIServers = map[string]extensions.Server{
+	"production": {
+		Name:        "production",
+		Host:        "nats.example.com:4222",
+		Protocol:    "nats",
+		Description: "Production broker",
+	},
+}
+
+// BrokerFromServerOption is an option of NewBrokerFromServer
+type BrokerFromServerOption func(opts *brokerFromServerOptions)
+
+type brokerFromServerOptions struct {
+	variables   map[string]string
+	credentials extensions.Credentials
+	nats        []nats.ControllerOption
+}
+
+// WithServerVariables sets the values of the variables of the server, instead
+// of their default values
+func WithServerVariables(values map[string]string) BrokerFromServerOption {
+	return func(opts *brokerFromServerOptions) {
+		opts.variables = values
+	}
+}
+
+// WithServerCredentials sets the credentials used with the security schemes of
+// the server, that are required if the server has security schemes
+func WithServerCredentials(credentials extensions.Credentials) BrokerFromServerOption {
+	return func(opts *brokerFromServerOptions) {
+		opts.credentials = credentials
+	}
+}
+
+// WithNATSOptions adds options to the NATS broker controllers created with
+// NewBrokerFromServer
+func WithNATSOptions(options ...nats.ControllerOption) BrokerFromServerOption {
+	return func(opts *brokerFromServerOptions) {
+		opts.nats = append(opts.nats, options...)
+	}
+}
+
+// NewBrokerFromServer creates the broker controller of the server of the AsyncAPI
+// document with the name, depending on its protocol
+func NewBrokerFromServer(name string, options ...BrokerFromServerOption) (extensions.BrokerController, error) {
+	server, ok := AsyncAPIServers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", extensions.ErrUnknownServer, name)
+	}
+
+	// Execute options
+	var opts brokerFromServerOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	// Get the address of the server
+	addr, err := server.Address(opts.variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the broker controller of the protocol
+	var broker extensions.BrokerController
+	switch server.Protocol {
+	case "nats":
+		broker, err = nats.NewController("nats://"+addr,
+			append([]nats.ControllerOption{nats.WithSecurity(server.Security, opts.credentials)}, opts.nats...)...)
+	default:
+		return nil, fmt.Errorf("%w: %q for server %q", extensions.ErrUnsupportedProtocol, server.Protocol, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
+
+// PingChannelParameters represents PingChannel channel parameters
+type PingChannelParameters struct {
+	// Region is a channel parameter: Region of the users
+	Region string
+}
+
+// Address returns the address of PingChannel channel with the parameters.
+func (params PingChannelParameters) Address() string {
+	return fmt.Sprintf("v3.docs.ping.%s", params.Region)
+}
+
+// ParsePingChannelParameters parses the parameters of PingChannel
+// channel from one of its addresses, like the address of a received message.
+func ParsePingChannelParameters(addr string) (PingChannelParameters, error) {
+	values, err := extensions.ParseChannelAddress("v3.docs.ping.{region}", addr)
+	if err != nil {
+		return PingChannelParameters{}, err
+	}
+
+	// Check that the parameter 'region' is one of its values
+	switch values["region"] {
+	case "eu", "us":
+	default:
+		return PingChannelParameters{}, fmt.Errorf("%w: %q is not a value of parameter %q",
+			extensions.ErrInvalidChannelAddress, values["region"], "region")
+	}
+
+	return PingChannelParameters{
+		Region: values["region"],
+	}, nil
+}
+
+// Message 'PingMessageFromPingChannel' reference another one at '#/components/messages/ping'.
+// This should be fixed in a future version to allow message override.
+// If you encounter this message, feel free to open an issue on this subject
+// to let know that you need this functionnality.
+
+// Message 'PongMessageFromPongChannel' reference another one at '#/components/messages/pong'.
+// This should be fixed in a future version to allow message override.
+// If you encounter this message, feel free to open an issue on this subject
+// to let know that you need this functionnality.
+
+// HeadersFromPingMessage is a schema from the AsyncAPI specification required in messages
+type HeadersFromPingMessage struct {
+	// Description: Identifier of the ping | its pong
+	CorrelationId *string `json:"correlationId,omitempty"`
+}
+
+// Validate checks that HeadersFromPingMessage respects the constraints of the specification.
+func (t HeadersFromPingMessage) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// MarshalBrokerHeaders converts the headers into the headers of a broker message.
+func (t HeadersFromPingMessage) MarshalBrokerHeaders() (map[string][]byte, error) {
+	headers := make(map[string][]byte, 1)
+
+	// Adding CorrelationId header
+	if h := t.CorrelationId; h != nil {
+		b, err := extensions.MarshalHeader(*h)
+		if err != nil {
+			return nil, fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+		}
+		headers["correlationId"] = b
+	}
+
+	return headers, nil
+}
+
+// UnmarshalBrokerHeaders fills the headers with the headers of a broker message,
+// ignoring the unknown ones.
+func (t *HeadersFromPingMessage) UnmarshalBrokerHeaders(headers map[string][]byte) error {
+	for k, v := range headers {
+		switch k {
+		case "correlationId": // Retrieving CorrelationId header
+			var h string
+			if err := extensions.UnmarshalHeader(v, &h); err != nil {
+				return fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+			}
+			t.CorrelationId = &h
+		}
+	}
+
+	return nil
+}
+
+// PingMessagePayload is a schema from the AsyncAPI specification required in messages
+type PingMessagePayload struct {
+	SentAt *time.Time `json:"sentAt,omitempty"`
+	Tags   []string   `json:"tags,omitempty"`
+
+	// Description: User of the application.
+	User UserSchema `json:"user"`
+}
+
+// Validate checks that PingMessagePayload respects the constraints of the specification.
+func (t PingMessagePayload) Validate() error {
+	var errs extensions.ValidationErrors
+
+	errs.AddNested("user", t.User.Validate())
+
+	return errs.Err()
+}
+
+// PingMessage is the message expected for 'PingMessage' channel.
+// NOTE: Ping sent to a user.
+type PingMessage struct {
+	// Headers will be used to fill the message headers
+	Headers HeadersFromPingMessage
+
+	// Payload will be inserted in the message payload
+	Payload PingMessagePayload
+}
+
+// Validate checks that PingMessage respects the constraints of the specification.
+func (msg PingMessage) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("headers", msg.Headers.Validate())
+
+	errs.AddNested("payload", msg.Payload.Validate())
+
+	return errs.Err()
+}
+
+// jsonSchemaForPingMessage is the JSON Schema of the payload of PingMessage.
+const jsonSchemaForPingMessage = "{\"properties\":{\"sentAt\":{\"format\":\"date-time\",\"type\":\"string\"},\"tags\":{\"items\":{\"type\":\"string\"},\"type\":\"array\"},\"user\":{\"properties\":{\"address\":{\"properties\":{\"city\":{\"type\":\"string\"}},\"type\":\"object\"},\"id\":{\"format\":\"uuid\",\"type\":\"string\"}},\"required\":[\"id\"],\"type\":\"object\"}},\"required\":[\"user\"],\"type\":\"object\"}"
+
+func NewPingMessage() PingMessage {
+	var msg PingMessage
+
+	// Set correlation ID
+	u := uuid.New().String()
+	msg.Headers.CorrelationId = &u
+
+	return msg
+}
+
+// brokerMessageToPingMessage will fill a new PingMessage with data from generic broker message
+func brokerMessageToPingMessage(bMsg extensions.BrokerMessage) (PingMessage, error) {
+	var msg PingMessage
+
+	// Unmarshal payload to expected message payload format, with the codec
+	// of its content type
+	contentType := bMsg.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	err := extensions.UnmarshalPayload(contentType, bMsg.Payload, &msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+
+	// Get the headers from broker message
+	if err := msg.Headers.UnmarshalBrokerHeaders(bMsg.Headers); err != nil {
+		return msg, err
+	}
+
+	// TODO: run checks on msg type
+
+	return msg, nil
+}
+
+// toBrokerMessage will generate a generic broker message from PingMessage data
+func (msg PingMessage) toBrokerMessage() (extensions.BrokerMessage, error) {
+	// TODO: implement checks on message
+
+	// Marshal payload with the codec of the message content type
+	payload, err := extensions.MarshalPayload("application/json", msg.Payload)
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	// Convert the headers to broker message headers
+	headers, err := msg.Headers.MarshalBrokerHeaders()
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	return extensions.BrokerMessage{
+		Headers:     headers,
+		Payload:     payload,
+		ContentType: "application/json",
+	}, nil
+}
+
+// CorrelationID will give the correlation ID of the message, based on AsyncAPI spec
+func (msg PingMessage) CorrelationID() string {
+	if msg.Headers.CorrelationId != nil {
+		return *msg.Headers.CorrelationId
+	}
+
+	return ""
+}
+
+// SetCorrelationID will set the correlation ID of the message, based on AsyncAPI spec
+func (msg *PingMessage) SetCorrelationID(id string) {
+	msg.Headers.CorrelationId = &id
+}
+
+// SetAsResponseFrom will correlate the message with the one passed in parameter.
+// It will assign the 'req' message correlation ID to the message correlation ID,
+// both specified in AsyncAPI spec.
+func (msg *PingMessage) SetAsResponseFrom(req MessageWithCorrelationID) {
+	id := req.CorrelationID()
+	msg.Headers.CorrelationId = &id
+}
+
+// HeadersFromPongMessage is a schema from the AsyncAPI specification required in messages
+type HeadersFromPongMessage struct {
+	CorrelationId *string `json:"correlationId,omitempty"`
+}
+
+// Validate checks that HeadersFromPongMessage respects the constraints of the specification.
+func (t HeadersFromPongMessage) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+// MarshalBrokerHeaders converts the headers into the headers of a broker message.
+func (t HeadersFromPongMessage) MarshalBrokerHeaders() (map[string][]byte, error) {
+	headers := make(map[string][]byte, 1)
+
+	// Adding CorrelationId header
+	if h := t.CorrelationId; h != nil {
+		b, err := extensions.MarshalHeader(*h)
+		if err != nil {
+			return nil, fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+		}
+		headers["correlationId"] = b
+	}
+
+	return headers, nil
+}
+
+// UnmarshalBrokerHeaders fills the headers with the headers of a broker message,
+// ignoring the unknown ones.
+func (t *HeadersFromPongMessage) UnmarshalBrokerHeaders(headers map[string][]byte) error {
+	for k, v := range headers {
+		switch k {
+		case "correlationId": // Retrieving CorrelationId header
+			var h string
+			if err := extensions.UnmarshalHeader(v, &h); err != nil {
+				return fmt.Errorf("%w: header correlationId: %w", extensions.ErrInvalidHeader, err)
+			}
+			t.CorrelationId = &h
+		}
+	}
+
+	return nil
+}
+
+// PongMessage is the message expected for 'PongMessage' channel.
+type PongMessage struct {
+	// Headers will be used to fill the message headers
+	Headers HeadersFromPongMessage
+
+	// Payload will be inserted in the message payload
+	Payload StatusSchema
+}
+
+// Validate checks that PongMessage respects the constraints of the specification.
+func (msg PongMessage) Validate() error {
+	var errs extensions.ValidationErrors
+	errs.AddNested("headers", msg.Headers.Validate())
+
+	switch string(msg.Payload) {
+	case "available", "away":
+	default:
+		errs.Add("payload", "enum", "should be one of \"available\", \"away\"")
+	}
+
+	return errs.Err()
+}
+
+// jsonSchemaForPongMessage is the JSON Schema of the payload of PongMessage.
+const jsonSchemaForPongMessage = "{\"enum\":[\"available\",\"away\"],\"type\":\"string\"}"
+
+func NewPongMessage() PongMessage {
+	var msg PongMessage
+
+	// Set correlation ID
+	u := uuid.New().String()
+	msg.Headers.CorrelationId = &u
+
+	return msg
+}
+
+// brokerMessageToPongMessage will fill a new PongMessage with data from generic broker message
+func brokerMessageToPongMessage(bMsg extensions.BrokerMessage) (PongMessage, error) {
+	var msg PongMessage
+
+	// Convert to string
+	payload := string(bMsg.Payload)
+	msg.Payload = StatusSchema(payload)
+
+	// Get the headers from broker message
+	if err := msg.Headers.UnmarshalBrokerHeaders(bMsg.Headers); err != nil {
+		return msg, err
+	}
+
+	// TODO: run checks on msg type
+
+	return msg, nil
+}
+
+// toBrokerMessage will generate a generic broker message from PongMessage data
+func (msg PongMessage) toBrokerMessage() (extensions.BrokerMessage, error) {
+	// TODO: implement checks on message
+
+	// Convert to []byte
+	payload := []byte(msg.Payload)
+
+	// Convert the headers to broker message headers
+	headers, err := msg.Headers.MarshalBrokerHeaders()
+	if err != nil {
+		return extensions.BrokerMessage{}, err
+	}
+
+	return extensions.BrokerMessage{
+		Headers: headers,
+		Payload: payload,
+	}, nil
+}
+
+// CorrelationID will give the correlation ID of the message, based on AsyncAPI spec
+func (msg PongMessage) CorrelationID() string {
+	if msg.Headers.CorrelationId != nil {
+		return *msg.Headers.CorrelationId
+	}
+
+	return ""
+}
+
+// SetCorrelationID will set the correlation ID of the message, based on AsyncAPI spec
+func (msg *PongMessage) SetCorrelationID(id string) {
+	msg.Headers.CorrelationId = &id
+}
+
+// SetAsResponseFrom will correlate the message with the one passed in parameter.
+// It will assign the 'req' message correlation ID to the message correlation ID,
+// both specified in AsyncAPI spec.
+func (msg *PongMessage) SetAsResponseFrom(req MessageWithCorrelationID) {
+	id := req.CorrelationID()
+	msg.Headers.CorrelationId = &id
+}
+
+// StatusSchema is a schema from the AsyncAPI specification required in messages
+type StatusSchema string
+
+const (
+	// StatusSchemaAvailable is the "available" value of StatusSchema.
+	StatusSchemaAvailable StatusSchema = "available"
+	// StatusSchemaAway is the "away" value of StatusSchema.
+	StatusSchemaAway StatusSchema = "away"
+)
+
+// StatusSchemaValues are all the values of StatusSchema.
+var StatusSchemaValues = []StatusSchema{
+	StatusSchemaAvailable,
+	StatusSchemaAway,
+}
+
+// String returns the string representation of the StatusSchema value.
+func (e StatusSchema) String() string {
+	return string(e)
+}
+
+// IsValid returns true if the value is one of the values of StatusSchema.
+func (e StatusSchema) IsValid() bool {
+	switch e {
+	case StatusSchemaAvailable, StatusSchemaAway:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseStatusSchema returns the StatusSchema value represented by the string,
+// or an error wrapping extensions.ErrUnknownEnumValue if there is none.
+func ParseStatusSchema(s string) (StatusSchema, error) {
+	e := StatusSchema(s)
+	if !e.IsValid() {
+		return "", fmt.Errorf("%w: %q is not a value of StatusSchema", extensions.ErrUnknownEnumValue, s)
+	}
+	return e, nil
+}
+
+// UserSchema is a schema from the AsyncAPI specification required in messages
+// Description: User of the application.
+type UserSchema struct {
+	Address *AddressPropertyFromUserSchema `json:"address,omitempty"`
+	Id      uuid.UUID                      `json:"id"`
+}
+
+// Validate checks that UserSchema respects the constraints of the specification.
+func (t UserSchema) Validate() error {
+	var errs extensions.ValidationErrors
+	if t.Address != nil {
+		errs.AddNested("address", (*t.Address).Validate())
+	}
+
+	return errs.Err()
+}
+
+// AddressPropertyFromUserSchema is a schema from the AsyncAPI specification required in messages
+type AddressPropertyFromUserSchema struct {
+	City *string `json:"city,omitempty"`
+}
+
+// Validate checks that AddressPropertyFromUserSchema respects the constraints of the specification.
+func (t AddressPropertyFromUserSchema) Validate() error {
+	var errs extensions.ValidationErrors
+
+	return errs.Err()
+}
+
+const (
+	// PingChannelPath is the constant representing the 'PingChannel' channel path.
+	PingChannelPath = "v3.docs.ping.{region}"
+	// PongChannelPath is the constant representing the 'PongChannel' channel path.
+	PongChannelPath = "v3.docs.pong"
+)
+
+// ChannelsPaths is an array of all channels paths
+var ChannelsPaths = []string{
+	PingChannelPath,
+	PongChannelPath,
+}
@@ -0,0 +1,67 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p docs -g types,markdown,html -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package docs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) read(path string) string {
+	content, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	return string(content)
+}
+
+func (suite *Suite) TestMarkdown() {
+	doc := suite.read("asyncapi.gen.md")
+
+	// Specification information and servers
+	suite.Require().Contains(doc, "# Sample App (1.2.3)")
+	suite.Require().Contains(doc, "| production | `nats://nats.example.com:4222` | Production broker |")
+
+	// Channels with their parameters and messages
+	suite.Require().Contains(doc, "Address: `v3.docs.ping.{region}`")
+	suite.Require().Contains(doc, "| `region` | string (one of eu, us) | yes | Region of the users |")
+	suite.Require().Contains(doc, "- [PingMessage](#pingmessage)")
+
+	// Operations with their reply
+	suite.Require().Contains(doc, "### PingUserOperation")
+	suite.Require().Contains(doc, "Action: `send` on [PingChannel](#pingchannel)")
+	suite.Require().Contains(doc, "Reply on [PongChannel](#pongchannel) with:")
+
+	// Messages with their headers and payload
+	suite.Require().Contains(doc, "Correlation ID: `$message.header#/correlationId`")
+	suite.Require().Contains(doc, "| `correlationId` | string | no | Identifier of the ping \\| its pong |")
+	suite.Require().Contains(doc, "| `user` | [UserSchema](#userschema) | yes | User of the application. |")
+	suite.Require().Contains(doc, "| `sentAt` | string (date-time) | no |  |")
+	suite.Require().Contains(doc, "| `tags` | array of string | no |  |")
+	suite.Require().Contains(doc, "Payload: [StatusSchema](#statusschema)")
+
+	// Schemas of the components, with their inline properties
+	suite.Require().Contains(doc, "### UserSchema")
+	suite.Require().Contains(doc, "| `address.city` | string | no |  |")
+	suite.Require().Contains(doc, "Type: string (one of available, away)")
+}
+
+func (suite *Suite) TestHTML() {
+	doc := suite.read("asyncapi.gen.html")
+
+	suite.Require().Contains(doc, "<h1>Sample App (1.2.3)</h1>")
+	suite.Require().Contains(doc, `<h3 id="pingmessage">PingMessage</h3>`)
+	suite.Require().Contains(doc, `<td><code>user</code></td><td><a href="#userschema">UserSchema</a></td>`)
+	suite.Require().Contains(doc, `<p>Reply on <a href="#pongchannel">PongChannel</a> with:</p>`)
+
+	// The texts should be escaped
+	suite.Require().Contains(doc, "<p>Application checking the availability of the users &amp; their status.</p>")
+}
@@ -0,0 +1,171 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p drain -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// slowBroker is a broker waiting to be released before publishing.
+type slowBroker struct {
+	*inmemory.Controller
+	publishing chan struct{}
+	release    chan struct{}
+}
+
+func (sb slowBroker) Publish(ctx context.Context, channel string, msg extensions.BrokerMessage) error {
+	sb.publishing <- struct{}{}
+	<-sb.release
+	return sb.Controller.Publish(ctx, channel, msg)
+}
+
+type Suite struct {
+	suite.Suite
+	broker  *inmemory.Controller
+	user    *UserController
+	started chan struct{}
+	release chan struct{}
+	handled chan struct{}
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+
+	suite.started = make(chan struct{}, 2)
+	suite.release = make(chan struct{})
+	suite.handled = make(chan struct{}, 2)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// subscribe creates an app controller whose handler waits to be released.
+func (suite *Suite) subscribe(options ...ControllerOption) *AppController {
+	app, err := NewAppController(suite.broker, options...)
+	suite.Require().NoError(err)
+
+	// Use the channels of the test, as the handler can end after it
+	started, release, handled := suite.started, suite.release, suite.handled
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, _ OrderMessage) error {
+			started <- struct{}{}
+			<-release
+			handled <- struct{}{}
+			return nil
+		}))
+	return app
+}
+
+// closeAsync closes the app controller and returns a channel closed once it
+// is closed.
+func (suite *Suite) closeAsync(ctx context.Context, app *AppController) <-chan struct{} {
+	closed := make(chan struct{})
+	go func() {
+		app.Close(ctx)
+		close(closed)
+	}()
+	return closed
+}
+
+func (suite *Suite) send() {
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage()))
+}
+
+func (suite *Suite) TestDrain() {
+	app := suite.subscribe(WithDrainOnClose())
+	suite.send()
+	<-suite.started
+
+	// The controller waits for the message being handled
+	closed := suite.closeAsync(context.Background(), app)
+	select {
+	case <-closed:
+		suite.FailNow("the controller has been closed before the end of the handler")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A message received while draining is not handled
+	suite.send()
+
+	close(suite.release)
+	<-closed
+	suite.Require().Len(suite.handled, 1)
+	suite.Require().Empty(suite.started)
+}
+
+func (suite *Suite) TestDrainTimeout() {
+	app := suite.subscribe(WithDrainOnClose())
+	defer close(suite.release)
+	suite.send()
+	<-suite.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-suite.closeAsync(ctx, app):
+	case <-time.After(time.Second):
+		suite.FailNow("the controller has not been closed when the context is done")
+	}
+	suite.Require().Empty(suite.handled)
+}
+
+func (suite *Suite) TestPendingPublication() {
+	broker := slowBroker{
+		Controller: suite.broker,
+		publishing: make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	user, err := NewUserController(broker, WithDrainOnClose())
+	suite.Require().NoError(err)
+
+	sent := make(chan error)
+	go func() { sent <- user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage()) }()
+	<-broker.publishing
+
+	// The controller waits for the message being published
+	closed := make(chan struct{})
+	go func() {
+		user.Close(context.Background())
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		suite.FailNow("the controller has been closed before the end of the publication")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(broker.release)
+	suite.Require().NoError(<-sent)
+	<-closed
+}
+
+func (suite *Suite) TestWithoutDrain() {
+	app := suite.subscribe()
+	defer close(suite.release)
+	suite.send()
+	<-suite.started
+
+	select {
+	case <-suite.closeAsync(context.Background(), app):
+	case <-time.After(time.Second):
+		suite.FailNow("the controller should not wait for the handler")
+	}
+}
@@ -0,0 +1,123 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p enums -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package enums
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestStringEnum() {
+	suite.Require().Equal([]StatusSchema{StatusSchemaPending, StatusSchemaInProgress, StatusSchemaDone},
+		StatusSchemaValues)
+	suite.Require().Equal("in-progress", StatusSchemaInProgress.String())
+	suite.Require().True(StatusSchemaDone.IsValid())
+	suite.Require().False(StatusSchema("unknown").IsValid())
+
+	s, err := ParseStatusSchema("done")
+	suite.Require().NoError(err)
+	suite.Require().Equal(StatusSchemaDone, s)
+
+	_, err = ParseStatusSchema("unknown")
+	suite.Require().ErrorIs(err, extensions.ErrUnknownEnumValue)
+}
+
+func (suite *Suite) TestIntegerEnum() {
+	p := PriorityPropertyFromOrderMessageFromOrdersChannelPayload2
+	suite.Require().Equal("2", p.String())
+	suite.Require().True(p.IsValid())
+	suite.Require().False(PriorityPropertyFromOrderMessageFromOrdersChannelPayload(4).IsValid())
+
+	p, err := ParsePriorityPropertyFromOrderMessageFromOrdersChannelPayload("3")
+	suite.Require().NoError(err)
+	suite.Require().Equal(PriorityPropertyFromOrderMessageFromOrdersChannelPayload3, p)
+
+	for _, s := range []string{"4", "high"} {
+		_, err = ParsePriorityPropertyFromOrderMessageFromOrdersChannelPayload(s)
+		suite.Require().ErrorIs(err, extensions.ErrUnknownEnumValue, s)
+	}
+}
+
+func (suite *Suite) TestEnumsInMessage() {
+	received := make(chan OrderMessageFromOrdersChannel, 1)
+	err := suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessageFromOrdersChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	msg := NewOrderMessageFromOrdersChannel()
+	msg.Headers.Source = utils.ToPointer(SourcePropertyFromHeadersFromOrderMessageFromOrdersChannelMobile)
+	msg.Payload.Status = StatusSchemaInProgress
+	msg.Payload.Priority = utils.ToPointer(PriorityPropertyFromOrderMessageFromOrdersChannelPayload1)
+	msg.Payload.Tags = []ItemFromTagsPropertyFromOrderMessageFromOrdersChannelPayload{
+		ItemFromTagsPropertyFromOrderMessageFromOrdersChannelPayloadGift,
+	}
+	suite.Require().NoError(msg.Validate())
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), msg))
+
+	select {
+	case r := <-received:
+		suite.Require().Equal(msg, r)
+	case <-time.After(time.Second):
+		suite.FailNow("order not received")
+	}
+
+	// Values that are not in the enum are still sent, but are not valid
+	msg.Payload.Status = "unknown"
+	suite.Require().ErrorIs(msg.Validate(), extensions.ErrValidation)
+}
+
+func (suite *Suite) TestEnumPayload() {
+	received := make(chan StatusMessageFromStatusesChannelPayload, 1)
+	err := suite.app.SubscribeToReceiveStatusOperation(context.Background(),
+		func(_ context.Context, msg StatusMessageFromStatusesChannel) error {
+			received <- msg.Payload
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	msg := NewStatusMessageFromStatusesChannel()
+	msg.Payload = StatusMessageFromStatusesChannelPayloadClosed
+	suite.Require().NoError(suite.user.SendToReceiveStatusOperation(context.Background(), msg))
+
+	select {
+	case p := <-received:
+		suite.Require().Equal(StatusMessageFromStatusesChannelPayloadClosed, p)
+	case <-time.After(time.Second):
+		suite.FailNow("status not received")
+	}
+}
@@ -0,0 +1,174 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p errorhandler -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package errorhandler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+const address = "v3.errorhandler.order"
+
+var errPublish = errors.New("publish failed")
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// handledError is an error given to the error handler.
+type handledError struct {
+	direction string
+	channel   string
+	msg       *extensions.AcknowledgeableBrokerMessage
+	err       error
+}
+
+// failingBroker is a broker failing to publish the messages.
+type failingBroker struct {
+	*inmemory.Controller
+}
+
+func (fb failingBroker) Publish(_ context.Context, _ string, _ extensions.BrokerMessage) error {
+	return errPublish
+}
+
+func (fb failingBroker) PublishBatch(_ context.Context, _ string, _ []extensions.BrokerMessage) error {
+	return errPublish
+}
+
+type Suite struct {
+	suite.Suite
+	broker  *inmemory.Controller
+	handled chan handledError
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+	suite.handled = make(chan handledError, 2)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *Suite) errorHandler(ctx context.Context, channel string, msg *extensions.AcknowledgeableBrokerMessage, err error) {
+	var direction string
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsDirection, func(d string) {
+		direction = d
+	})
+	suite.handled <- handledError{direction: direction, channel: channel, msg: msg, err: err}
+}
+
+func (suite *Suite) subscribe(fn func(ctx context.Context, msg OrderMessage) error) {
+	app, err := NewAppController(suite.broker, WithErrorHandler(suite.errorHandler))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(), fn))
+}
+
+func (suite *Suite) expectError() handledError {
+	select {
+	case h := <-suite.handled:
+		suite.Require().Equal(address, h.channel)
+		return h
+	case <-time.After(time.Second):
+		suite.FailNow("the error handler has not been called")
+	}
+	return handledError{}
+}
+
+func (suite *Suite) TestHandlerError() {
+	errHandler := errors.New("handler failed")
+	suite.subscribe(func(_ context.Context, _ OrderMessage) error {
+		return errHandler
+	})
+
+	user, err := NewUserController(suite.broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	id := "1234"
+	msg := NewOrderMessage()
+	msg.Payload.Id = &id
+	suite.Require().NoError(user.SendToReceiveOrderOperation(context.Background(), msg))
+
+	h := suite.expectError()
+	suite.Require().Equal("reception", h.direction)
+	suite.Require().ErrorIs(h.err, errHandler)
+	suite.Require().JSONEq(`{"id": "1234"}`, string(h.msg.Payload))
+}
+
+func (suite *Suite) TestDecodingError() {
+	suite.subscribe(func(_ context.Context, _ OrderMessage) error {
+		suite.FailNow("the subscription function should not be called")
+		return nil
+	})
+
+	suite.Require().NoError(suite.broker.Publish(context.Background(), address, extensions.BrokerMessage{
+		Headers: map[string][]byte{},
+		Payload: []byte(`{"id": 1234}`),
+	}))
+
+	h := suite.expectError()
+	suite.Require().Equal("reception", h.direction)
+	suite.Require().ErrorIs(h.err, extensions.ErrMessageDecoding)
+}
+
+func (suite *Suite) TestPublicationError() {
+	user, err := NewUserController(failingBroker{suite.broker}, WithErrorHandler(suite.errorHandler))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage())
+	suite.Require().ErrorIs(err, errPublish)
+
+	h := suite.expectError()
+	suite.Require().Equal("publication", h.direction)
+	suite.Require().ErrorIs(h.err, errPublish)
+	suite.Require().JSONEq(`{}`, string(h.msg.Payload))
+
+	// The message can't be acknowledged, but this is not a failure
+	h.msg.Ack()
+	h.msg.Nak()
+}
+
+func (suite *Suite) TestBatchPublicationError() {
+	user, err := NewUserController(failingBroker{suite.broker}, WithErrorHandler(suite.errorHandler))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	msgs := []OrderMessage{NewOrderMessage(), NewOrderMessage()}
+	suite.Require().ErrorIs(user.SendBatchToReceiveOrderOperation(context.Background(), msgs), errPublish)
+
+	for range msgs {
+		h := suite.expectError()
+		suite.Require().Equal("publication", h.direction)
+		suite.Require().ErrorIs(h.err, errPublish)
+	}
+}
+
+func (suite *Suite) TestMiddlewareError() {
+	errMiddleware := errors.New("middleware failed")
+	user, err := NewUserController(suite.broker,
+		WithErrorHandler(suite.errorHandler),
+		WithMiddlewares(func(_ context.Context, _ *extensions.BrokerMessage, _ extensions.NextMiddleware) error {
+			return errMiddleware
+		}))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	suite.Require().ErrorIs(user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage()), errMiddleware)
+
+	h := suite.expectError()
+	suite.Require().Equal("publication", h.direction)
+	suite.Require().ErrorIs(h.err, errMiddleware)
+}
@@ -0,0 +1,88 @@
+// Package "fixtures" provides primitives to interact with the AsyncAPI specification.
+//
+// Code generated by github.com/lerenn/asyncapi-codegen version (devel) DO NOT EDIT.
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// NotificationMessageExamples returns the NotificationMessage messages built from the
+// examples of the specification, by name.
+func NotificationMessageExamples() map[string]NotificationMessage {
+	examples := make(map[string]NotificationMessage, 2)
+	{
+		msg := NewNotificationMessage()
+		decodeExample("0", "\"Welcome!\"", &msg.Payload)
+		examples["0"] = msg
+	}
+	{
+		msg := NewNotificationMessage()
+		decodeExample("1", "\"See you soon\"", &msg.Payload)
+		examples["1"] = msg
+	}
+
+	return examples
+}
+
+// UserSignedUpMessageExamples returns the UserSignedUpMessage messages built from the
+// examples of the specification, by name.
+func UserSignedUpMessageExamples() map[string]UserSignedUpMessage {
+	examples := make(map[string]UserSignedUpMessage, 2)
+	// An adult user
+	{
+		msg := NewUserSignedUpMessage()
+		decodeExample("john", "{\"tenant\":\"acme\"}", &msg.Headers)
+		decodeExample("john", "{\"age\":42,\"createdAt\":\"2024-01-02T03:04:05Z\",\"name\":\"John\"}", &msg.Payload)
+		examples["john"] = msg
+	}
+	{
+		msg := NewUserSignedUpMessage()
+		decodeExample("jane", "{\"name\":\"Jane\"}", &msg.Payload)
+		examples["jane"] = msg
+	}
+
+	return examples
+}
+
+// SendExamplesAsSendNotificationOperation sends the examples of the
+// NotificationMessage message with SendAsSendNotificationOperation, in the order of the specification.
+func (c *AppController) SendExamplesAsSendNotificationOperation(
+	ctx context.Context,
+	params NotificationChannelParameters,
+) error {
+	examples := NotificationMessageExamples()
+	for _, name := range []string{"0", "1"} {
+		if err := c.SendAsSendNotificationOperation(ctx, params, examples[name]); err != nil {
+			return fmt.Errorf("example %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// SendExamplesToReceiveSignedUpOperation sends the examples of the
+// UserSignedUpMessage message with SendToReceiveSignedUpOperation, in the order of the specification.
+func (c *UserController) SendExamplesToReceiveSignedUpOperation(
+	ctx context.Context,
+) error {
+	examples := UserSignedUpMessageExamples()
+	for _, name := range []string{"john", "jane"} {
+		if err := c.SendToReceiveSignedUpOperation(ctx, examples[name]); err != nil {
+			return fmt.Errorf("example %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeExample decodes the JSON of an example of the specification. It panics
+// if it doesn't match the generated type, as the example is part of the
+// specification.
+func decodeExample(name, data string, v any) {
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		panic(fmt.Sprintf("invalid example %q of the specification: %s", name, err))
+	}
+}
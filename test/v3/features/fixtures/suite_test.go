@@ -0,0 +1,88 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p fixtures -i ./asyncapi.yaml -o ./asyncapi.gen.go
+//go:generate go run ../../../../cmd/asyncapi-codegen -p fixtures -i ./asyncapi.yaml -o ./fixtures.gen.go -g fixtures
+
+package fixtures
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestExamples() {
+	examples := UserSignedUpMessageExamples()
+	suite.Require().Len(examples, 2)
+
+	// The examples should be decoded in the message types
+	john := examples["john"]
+	suite.Require().Equal(utils.ToPointer("acme"), john.Headers.Tenant)
+	suite.Require().Equal("John", john.Payload.Name)
+	suite.Require().Equal(utils.ToPointer(int64(42)), john.Payload.Age)
+	suite.Require().Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), john.Payload.CreatedAt.UTC())
+
+	jane := examples["jane"]
+	suite.Require().Nil(jane.Headers.Tenant)
+	suite.Require().Equal("Jane", jane.Payload.Name)
+
+	// Including the ones without name, by position
+	suite.Require().Equal(map[string]string{"0": "Welcome!", "1": "See you soon"}, map[string]string{
+		"0": NotificationMessageExamples()["0"].Payload,
+		"1": NotificationMessageExamples()["1"].Payload,
+	})
+}
+
+func (suite *Suite) TestSendExamples() {
+	received := make(chan UserSignedUpMessage, 2)
+	suite.Require().NoError(suite.app.SubscribeToReceiveSignedUpOperation(context.Background(),
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			received <- msg
+			return nil
+		}))
+
+	// The examples should be sent in the order of the specification
+	suite.Require().NoError(suite.user.SendExamplesToReceiveSignedUpOperation(context.Background()))
+	suite.Require().Equal("John", (<-received).Payload.Name)
+	suite.Require().Equal("Jane", (<-received).Payload.Name)
+}
+
+func (suite *Suite) TestSendExamplesWithParameters() {
+	params := NotificationChannelParameters{Region: "eu"}
+	suite.Require().NoError(suite.app.SendExamplesAsSendNotificationOperation(context.Background(), params))
+
+	published := suite.broker.Published("v3.fixtures.notification.eu")
+	suite.Require().Len(published, 2)
+	suite.Require().Equal("Welcome!", string(published[0].Payload))
+	suite.Require().Equal("See you soon", string(published[1].Payload))
+}
@@ -0,0 +1,120 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p formats -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package formats
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestJSON() {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	payload := JobMessageFromJobsChannelPayload{
+		Id:          JobIdSchema(id),
+		Timeout:     extensions.Duration(90 * time.Minute),
+		ScheduledAt: utils.ToPointer(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+		Day:         &civil.Date{Year: 2024, Month: time.March, Day: 1},
+		Parents:     []uuid.UUID{id},
+	}
+
+	b, err := json.Marshal(payload)
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{
+		"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"timeout": "PT1H30M",
+		"scheduledAt": "2024-03-01T12:00:00Z",
+		"day": "2024-03-01",
+		"parents": ["6ba7b810-9dad-11d1-80b4-00c04fd430c8"]
+	}`, string(b))
+
+	var decoded JobMessageFromJobsChannelPayload
+	suite.Require().NoError(json.Unmarshal(b, &decoded))
+	suite.Require().Equal(payload, decoded)
+
+	// Invalid values are rejected when decoding
+	suite.Require().Error(json.Unmarshal([]byte(`{"id": "not-a-uuid", "timeout": "PT1S"}`), &decoded))
+	suite.Require().ErrorIs(json.Unmarshal([]byte(`{"id": "`+id.String()+`", "timeout": "1s"}`), &decoded),
+		extensions.ErrInvalidDuration)
+}
+
+func (suite *Suite) TestHeaders() {
+	received := make(chan JobMessageFromJobsChannel, 1)
+	err := suite.app.SubscribeToReceiveJobOperation(context.Background(),
+		func(_ context.Context, msg JobMessageFromJobsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveJobOperation(context.Background())
+
+	sent := NewJobMessageFromJobsChannel()
+	sent.Headers.RequestId = uuid.New()
+	sent.Headers.RetryAfter = utils.ToPointer(extensions.Duration(30 * time.Second))
+	sent.Payload.Id = JobIdSchema(uuid.New())
+	sent.Payload.Timeout = extensions.Duration(time.Minute)
+	suite.Require().NoError(suite.user.SendToReceiveJobOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestPayload() {
+	received := make(chan CancellationMessageFromCancellationsChannel, 1)
+	err := suite.app.SubscribeToReceiveCancellationOperation(context.Background(),
+		func(_ context.Context, msg CancellationMessageFromCancellationsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveCancellationOperation(context.Background())
+
+	sent := NewCancellationMessageFromCancellationsChannel()
+	sent.Payload = JobIdSchema(uuid.New())
+	suite.Require().NoError(suite.user.SendToReceiveCancellationOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
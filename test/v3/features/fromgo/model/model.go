@@ -0,0 +1,70 @@
+// Package model contains the annotated messages and interfaces from which the
+// specification of the fromgo feature is generated.
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// Status is the status of a user.
+type Status string
+
+const (
+	// StatusActive is the status of the active users.
+	StatusActive Status = "active"
+	// StatusBanned is the status of the banned users.
+	StatusBanned Status = "banned"
+)
+
+// UserSignedUp is sent when a user signs up.
+//
+//asyncapi:message channel=v3.fromgo.user.signedup
+type UserSignedUp struct {
+	// User is the user that signed up.
+	User User `json:"user"`
+	// At is the time of the sign up.
+	At time.Time `json:"at"`
+	// Referrer is the user that invited the user, if any.
+	Referrer *string `json:"referrer,omitempty"`
+}
+
+// User is a user of the application.
+type User struct {
+	Name   string            `json:"name"`
+	Age    int64             `json:"age,omitempty"`
+	Status Status            `json:"status"`
+	Tags   []string          `json:"tags,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// password is not exported, so it is not in the message
+	password string //nolint:unused
+}
+
+// UserBanned is sent when a user is banned.
+//
+//asyncapi:message name=banned
+type UserBanned struct {
+	Name string `json:"name"`
+	// Reason is the reason of the ban.
+	Reason string `json:"reason"`
+}
+
+// Publisher sends the messages about the users.
+//
+//asyncapi:publisher
+type Publisher interface {
+	// SignUp notifies that a user signed up.
+	SignUp(ctx context.Context, msg UserSignedUp) error
+
+	//asyncapi:operation channel=v3.fromgo.user.{region}.banned
+	Ban(ctx context.Context, msg *UserBanned) error
+}
+
+// Subscriber receives the messages about the users.
+//
+//asyncapi:subscriber
+type Subscriber interface {
+	//asyncapi:operation name=welcome
+	UserSignedUp(ctx context.Context, msg UserSignedUp) error
+}
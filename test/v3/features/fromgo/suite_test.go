@@ -0,0 +1,88 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen from-go ./model --title "Sample App" --version 1.2.3 -o ./asyncapi.yaml
+//go:generate go run ../../../../cmd/asyncapi-codegen -p fromgo -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package fromgo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/test/v3/features/fromgo/model"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker, WithValidation())
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker, WithValidation())
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestReceiveAnnotatedMessage() {
+	received := make(chan UserSignedUpMessage, 1)
+	suite.Require().NoError(suite.user.SubscribeToSignUpOperation(context.Background(),
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			received <- msg
+			return nil
+		}))
+
+	// The messages of the annotated code should be received by the generated one
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload, err := json.Marshal(model.UserSignedUp{
+		User: model.User{Name: "john", Status: model.StatusActive, Tags: []string{"admin"}},
+		At:   at,
+	})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.broker.Publish(context.Background(), "v3.fromgo.user.signedup",
+		extensions.BrokerMessage{Payload: payload}))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(UserSignedUpSchema{
+			User: UserSchema{Name: "john", Status: StatusPropertyFromUserSchema(model.StatusActive), Tags: []string{"admin"}},
+			At:   at,
+		}, msg.Payload)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestSendToAnnotatedCode() {
+	msg := NewBannedMessage()
+	msg.Payload = UserBannedSchema{Name: "john", Reason: "spam"}
+	suite.Require().NoError(suite.app.SendAsBanOperation(context.Background(),
+		V3FromgoUserRegionBannedChannelParameters{Region: "eu"}, msg))
+
+	// The messages of the generated code should be read by the annotated one
+	published := suite.broker.Published("v3.fromgo.user.eu.banned")
+	suite.Require().Len(published, 1)
+
+	var banned model.UserBanned
+	suite.Require().NoError(json.Unmarshal(published[0].Payload, &banned))
+	suite.Require().Equal(model.UserBanned{Name: "john", Reason: "spam"}, banned)
+}
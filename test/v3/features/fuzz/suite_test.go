@@ -0,0 +1,70 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p fuzz -i ./asyncapi.yaml -o ./asyncapi.gen.go
+//go:generate go run ../../../../cmd/asyncapi-codegen -p fuzz -i ./asyncapi.yaml -o ./fuzz.gen_test.go -g fuzz
+
+package fuzz
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// panickingEnv is the environment variable enabling FuzzPanicking.
+const panickingEnv = "ASYNCAPI_CODEGEN_FUZZ_PANICKING"
+
+func FuzzUserSignedUp(f *testing.F) {
+	AppFuzzUserSignedUpOperation(f, func(_ context.Context, msg UserSignedUpMessage) error {
+		if msg.Payload.Address != nil && msg.Payload.Address.City == nil {
+			return errors.New("no city")
+		}
+		return nil
+	})
+}
+
+func FuzzWelcome(f *testing.F) {
+	UserFuzzWelcomeOperation(f, func(_ context.Context, msg WelcomeMessageFromWelcomeChannel) error {
+		if !strings.HasPrefix(msg.Payload, "fuzz") {
+			return errors.New("unexpected payload")
+		}
+		return nil
+	})
+}
+
+// FuzzPanicking is a fuzz target whose function panics on some payloads of the
+// corpus, run by TestPanic.
+func FuzzPanicking(f *testing.F) {
+	if os.Getenv(panickingEnv) == "" {
+		f.Skip("run by TestPanic")
+	}
+
+	AppFuzzUserSignedUpOperation(f, func(_ context.Context, msg UserSignedUpMessage) error {
+		if *msg.Payload.Address.City == "" {
+			return errors.New("no city")
+		}
+		return nil
+	})
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestPanic() {
+	cmd := exec.Command("go", "test", "-run", "^FuzzPanicking$", ".")
+	cmd.Env = append(os.Environ(), panickingEnv+"=1")
+	out, err := cmd.CombinedOutput()
+
+	// The panic of the function should fail the fuzz target with the payload
+	suite.Require().Error(err, string(out))
+	suite.Require().Contains(string(out), `the subscription function panicked on the payload "{}": `+
+		"runtime error: invalid memory address or nil pointer dereference")
+}
@@ -0,0 +1,11 @@
+// Package custom contains the existing Go types used by the gotype test.
+package custom
+
+// OrderID is the identifier of an order.
+type OrderID string
+
+// Money is an amount of money, in cents.
+type Money struct {
+	Cents    int64  `json:"cents"`
+	Currency string `json:"currency"`
+}
@@ -0,0 +1,92 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p gotype -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package gotype
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/test/v3/features/gotype/custom"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestOrder() {
+	received := make(chan OrderMessageFromOrdersChannel, 1)
+	err := suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessageFromOrdersChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveOrderOperation(context.Background())
+
+	// The custom types are used in place of the generated ones
+	details := json.RawMessage(`{"gift":true}`)
+	sent := NewOrderMessageFromOrdersChannel()
+	sent.Headers.OrderId = custom.OrderID("order-1")
+	sent.Payload = OrderSchema{
+		Id:      custom.OrderID("order-1"),
+		Total:   custom.Money{Cents: 1250, Currency: "EUR"},
+		Refunds: []custom.Money{{Cents: 250, Currency: "EUR"}},
+		Details: &details,
+	}
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestCancellation() {
+	received := make(chan CancellationMessageFromCancellationsChannel, 1)
+	err := suite.app.SubscribeToReceiveCancellationOperation(context.Background(),
+		func(_ context.Context, msg CancellationMessageFromCancellationsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveCancellationOperation(context.Background())
+
+	sent := NewCancellationMessageFromCancellationsChannel()
+	sent.Payload = custom.OrderID("order-1")
+	suite.Require().NoError(suite.user.SendToReceiveCancellationOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
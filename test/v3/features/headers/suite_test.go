@@ -0,0 +1,147 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p headers -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package headers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// send sends the message and returns it as received, in the broker message
+// and in the typed message.
+func (suite *Suite) send(msg OrderMessage) (extensions.BrokerMessage, OrderMessage) {
+	sub, err := suite.broker.Subscribe(context.Background(), "v3.headers.order")
+	suite.Require().NoError(err)
+	defer sub.Cancel(context.Background())
+
+	received := make(chan OrderMessage, 1)
+	suite.Require().NoError(suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessage) error {
+			received <- msg
+			return nil
+		}))
+	defer suite.app.UnsubscribeFromReceiveOrderOperation(context.Background())
+
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), msg))
+
+	var bMsg extensions.AcknowledgeableBrokerMessage
+	select {
+	case bMsg = <-sub.MessagesChannel():
+	case <-time.After(time.Second):
+		suite.FailNow("broker message not received")
+	}
+
+	select {
+	case msg = <-received:
+	case <-time.After(time.Second):
+		suite.FailNow("message not received")
+	}
+
+	return bMsg.BrokerMessage, msg
+}
+
+func (suite *Suite) TestTypedHeaders() {
+	messageID, contentType := "1234", "application/vnd.order+json"
+	timestamp := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	urgent, ratio, traceID := true, 0.5, uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	service := "billing"
+
+	msg := NewOrderMessage()
+	msg.Headers = HeadersFromOrderMessage{
+		MessageId:   &messageID,
+		Timestamp:   &timestamp,
+		ContentType: &contentType,
+		Priority:    3,
+		Urgent:      &urgent,
+		Ratio:       &ratio,
+		TraceId:     &traceID,
+		Origin:      &OriginPropertyFromHeadersFromOrderMessage{Service: &service},
+	}
+
+	bMsg, received := suite.send(msg)
+	suite.Require().Contains(bMsg.Headers, "sentAt")
+	delete(bMsg.Headers, "sentAt")
+	suite.Require().Equal(map[string][]byte{
+		"messageId":   []byte("1234"),
+		"timestamp":   []byte("2024-02-03T04:05:06Z"),
+		"contentType": []byte("application/vnd.order+json"),
+		"priority":    []byte("3"),
+		"urgent":      []byte("true"),
+		"ratio":       []byte("0.5"),
+		"traceId":     []byte("f47ac10b-58cc-4372-a567-0e02b2c3d479"),
+		"origin":      []byte(`{"service":"billing"}`),
+	}, bMsg.Headers)
+	suite.Require().Equal(msg.Headers, received.Headers)
+}
+
+func (suite *Suite) TestStandardHeaders() {
+	msg := NewOrderMessage()
+	msg.Headers.Priority = 1
+
+	before := time.Now().Truncate(time.Second)
+	bMsg, received := suite.send(msg)
+
+	// The standard headers are set on publication
+	suite.Require().NotNil(received.Headers.MessageId)
+	_, err := uuid.Parse(*received.Headers.MessageId)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(bMsg.Headers["messageId"]), *received.Headers.MessageId)
+	suite.Require().NotNil(received.Headers.Timestamp)
+	suite.Require().False(received.Headers.Timestamp.Before(before))
+	suite.Require().Equal("application/json", *received.Headers.ContentType)
+
+	// Without modifying the sent message
+	suite.Require().Nil(msg.Headers.MessageId)
+
+	// And the other optional headers are not set
+	suite.Require().NotContains(bMsg.Headers, "urgent")
+	suite.Require().Nil(received.Headers.Urgent)
+}
+
+func (suite *Suite) TestInvalidHeader() {
+	var h HeadersFromOrderMessage
+	suite.Require().ErrorIs(h.UnmarshalBrokerHeaders(map[string][]byte{
+		"priority": []byte("high"),
+	}), extensions.ErrInvalidHeader)
+
+	// Unknown headers are ignored
+	suite.Require().NoError(h.UnmarshalBrokerHeaders(map[string][]byte{
+		"priority": []byte("2"),
+		"unknown":  []byte("value"),
+	}))
+	suite.Require().Equal(int64(2), h.Priority)
+}
@@ -0,0 +1,62 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p headersfilter -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package headersfilter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// filterRecorder is a broker that records the headers filters received.
+type filterRecorder struct {
+	filters []map[string]string
+}
+
+func (fr *filterRecorder) Publish(_ context.Context, _ string, _ extensions.BrokerMessage) error {
+	return nil
+}
+
+func (fr *filterRecorder) PublishBatch(_ context.Context, _ string, _ []extensions.BrokerMessage) error {
+	return nil
+}
+
+func (fr *filterRecorder) Subscribe(ctx context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsHeadersFilter, func(filter map[string]string) {
+		fr.filters = append(fr.filters, filter)
+	})
+
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestHeadersFilterInContext() {
+	broker := &filterRecorder{}
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	err = app.SubscribeToReceiveOrderOperation(context.Background(), func(_ context.Context, _ OrderMessageFromOrdersChannel) error {
+		return nil
+	})
+	suite.Require().NoError(err)
+	defer app.UnsubscribeFromReceiveOrderOperation(context.Background())
+
+	// Only headers with a constant value are used as filter
+	suite.Require().Equal([]map[string]string{{"region": "eu"}}, broker.filters)
+}
@@ -0,0 +1,177 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p health -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/healthchecks"
+	"github.com/stretchr/testify/suite"
+)
+
+const address = "v3.health.order"
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// endingBroker is a broker whose subscriptions can be ended without
+// unsubscribing, as when the broker stops delivering the messages.
+type endingBroker struct {
+	*inmemory.Controller
+	subs chan extensions.BrokerChannelSubscription
+}
+
+func (eb endingBroker) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := eb.Controller.Subscribe(ctx, channel)
+	eb.subs <- sub
+	return sub, err
+}
+
+type Suite struct {
+	suite.Suite
+	broker  endingBroker
+	app     *AppController
+	user    *UserController
+	handled chan error
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = endingBroker{Controller: broker, subs: make(chan extensions.BrokerChannelSubscription, 1)}
+
+	suite.app, err = NewAppController(suite.broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(suite.broker)
+	suite.Require().NoError(err)
+
+	suite.handled = make(chan error, 1)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+func (suite *Suite) subscribe() {
+	handled := suite.handled
+	suite.Require().NoError(suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessage) error {
+			var err error
+			if msg.Payload.Id == nil {
+				err = errors.New("no id")
+			}
+			handled <- err
+			return err
+		}))
+}
+
+// isReady returns true if the controller is ready.
+func isReady(ctrl interface{ Ready() <-chan struct{} }) bool {
+	select {
+	case <-ctrl.Ready():
+		return true
+	default:
+		return false
+	}
+}
+
+func (suite *Suite) TestReady() {
+	// The user controller has no subscription, so it is ready right away
+	suite.Require().True(isReady(suite.user))
+
+	suite.Require().False(isReady(suite.app))
+	suite.subscribe()
+	suite.Require().True(isReady(suite.app))
+}
+
+func (suite *Suite) TestSubscriptions() {
+	suite.subscribe()
+	suite.Require().NoError(suite.app.Healthy(context.Background()))
+
+	h := suite.app.Health(context.Background())
+	suite.Require().Len(h.Subscriptions, 1)
+	suite.Require().Equal(address, h.Subscriptions[0].Channel)
+	suite.Require().Equal("ReceiveOrderOperation", h.Subscriptions[0].Operation)
+	suite.Require().True(h.Subscriptions[0].Active)
+	suite.Require().True(h.Subscriptions[0].LastDelivery.IsZero())
+
+	// A message that can't be handled doesn't make the controller unhealthy
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage()))
+	suite.Require().Error(<-suite.handled)
+	suite.Require().Eventually(func() bool {
+		return suite.app.Health(context.Background()).Subscriptions[0].LastError != nil
+	}, time.Second, 10*time.Millisecond)
+	suite.Require().NoError(suite.app.Healthy(context.Background()))
+	suite.Require().False(suite.app.Health(context.Background()).Subscriptions[0].LastDelivery.IsZero())
+
+	// Unsubscribed channels are not reported anymore
+	suite.app.UnsubscribeFromReceiveOrderOperation(context.Background())
+	suite.Require().Empty(suite.app.Health(context.Background()).Subscriptions)
+	suite.Require().NoError(suite.app.Healthy(context.Background()))
+}
+
+func (suite *Suite) TestSubscriptionEnded() {
+	suite.subscribe()
+	sub := <-suite.broker.subs
+
+	sub.Cancel(context.Background())
+	suite.Require().Eventually(func() bool {
+		return errors.Is(suite.app.Healthy(context.Background()), extensions.ErrUnhealthy)
+	}, time.Second, 10*time.Millisecond)
+	suite.Require().False(suite.app.Health(context.Background()).Subscriptions[0].Active)
+}
+
+func (suite *Suite) TestBrokerUnhealthy() {
+	suite.broker.Close()
+
+	err := suite.user.Healthy(context.Background())
+	suite.Require().ErrorIs(err, extensions.ErrUnhealthy)
+	suite.Require().ErrorContains(err, "controller is closed")
+}
+
+func (suite *Suite) TestHTTPHandlers() {
+	get := func(h http.Handler) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var body map[string]any
+		suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	liveness := healthchecks.LivenessHandler(suite.app, suite.user)
+	readiness := healthchecks.ReadinessHandler(suite.app, suite.user)
+
+	// Not subscribed yet
+	code, body := get(readiness)
+	suite.Require().Equal(http.StatusServiceUnavailable, code)
+	suite.Require().Equal("not ready", body["status"])
+	code, _ = get(liveness)
+	suite.Require().Equal(http.StatusOK, code)
+
+	// Ready
+	suite.subscribe()
+	code, body = get(readiness)
+	suite.Require().Equal(http.StatusOK, code)
+	suite.Require().Equal("ok", body["status"])
+	suite.Require().Len(body["subscriptions"], 1)
+
+	// Unhealthy broker
+	suite.broker.Close()
+	code, body = get(liveness)
+	suite.Require().Equal(http.StatusServiceUnavailable, code)
+	suite.Require().Equal("unhealthy", body["status"])
+	suite.Require().Contains(body["error"], "controller is closed")
+}
@@ -0,0 +1,208 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p hooks -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package hooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+const address = "v3.hooks.order"
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// testBroker is a broker whose reconnections can be simulated, and whose
+// subscriptions can be ended without unsubscribing.
+type testBroker struct {
+	*inmemory.Controller
+	*extensions.ReconnectListeners
+	subs chan extensions.BrokerChannelSubscription
+}
+
+func (tb testBroker) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := tb.Controller.Subscribe(ctx, channel)
+	tb.subs <- sub
+	return sub, err
+}
+
+// event is a call of a hook.
+type event struct {
+	hook      string
+	channel   string
+	operation string
+	err       error
+}
+
+type Suite struct {
+	suite.Suite
+	broker testBroker
+	user   *UserController
+	events chan event
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = testBroker{
+		Controller:         broker,
+		ReconnectListeners: &extensions.ReconnectListeners{},
+		subs:               make(chan extensions.BrokerChannelSubscription, 1),
+	}
+
+	suite.user, err = NewUserController(suite.broker)
+	suite.Require().NoError(err)
+
+	suite.events = make(chan event, 10)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// newApp creates an app controller recording the calls of the hooks.
+func (suite *Suite) newApp(options ...ControllerOption) *AppController {
+	events := suite.events
+	record := func(hook string) func(ctx context.Context, err error) {
+		return func(ctx context.Context, err error) {
+			e := event{hook: hook, err: err}
+			e.channel, _ = ctx.Value(extensions.ContextKeyIsChannel).(string)
+			e.operation, _ = ctx.Value(extensions.ContextKeyIsOperation).(string)
+			events <- e
+		}
+	}
+
+	app, err := NewAppController(suite.broker, append(options, WithSubscriptionHooks(extensions.SubscriptionHooks{
+		OnSubscribed:   func(ctx context.Context) { record("subscribed")(ctx, nil) },
+		OnUnsubscribed: record("unsubscribed"),
+		OnReconnected:  func(ctx context.Context) { record("reconnected")(ctx, nil) },
+		OnDeliveryDropped: func(ctx context.Context, _ extensions.BrokerMessage, reason error) {
+			record("dropped")(ctx, reason)
+		},
+	}))...)
+	suite.Require().NoError(err)
+	return app
+}
+
+// nextEvent returns the next call of a hook.
+func (suite *Suite) nextEvent() event {
+	select {
+	case e := <-suite.events:
+		return e
+	case <-time.After(time.Second):
+		suite.FailNow("no hook has been called")
+		return event{}
+	}
+}
+
+func (suite *Suite) send() {
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), NewOrderMessage()))
+}
+
+func (suite *Suite) TestSubscription() {
+	app := suite.newApp()
+	defer app.Close(context.Background())
+
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, _ OrderMessage) error { return nil }))
+	suite.Require().Equal(event{hook: "subscribed", channel: address, operation: "ReceiveOrderOperation"}, suite.nextEvent())
+
+	app.UnsubscribeFromReceiveOrderOperation(context.Background())
+	suite.Require().Equal(event{hook: "unsubscribed", channel: address, operation: "ReceiveOrderOperation"}, suite.nextEvent())
+
+	// The end of the unsubscribed subscription is not reported
+	select {
+	case e := <-suite.events:
+		suite.FailNow("unexpected hook call", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (suite *Suite) TestSubscriptionEnded() {
+	app := suite.newApp()
+	defer app.Close(context.Background())
+
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, _ OrderMessage) error { return nil }))
+	suite.Require().Equal("subscribed", suite.nextEvent().hook)
+
+	(<-suite.broker.subs).Cancel(context.Background())
+	e := suite.nextEvent()
+	suite.Require().Equal("unsubscribed", e.hook)
+	suite.Require().Equal("ReceiveOrderOperation", e.operation)
+	suite.Require().ErrorIs(e.err, extensions.ErrSubscriptionEnded)
+}
+
+func (suite *Suite) TestReconnected() {
+	app := suite.newApp()
+
+	suite.broker.Notify()
+	suite.Require().Equal("reconnected", suite.nextEvent().hook)
+
+	// The hook is not called anymore once the controller is closed
+	app.Close(context.Background())
+	suite.broker.Notify()
+	suite.Require().Empty(suite.events)
+}
+
+func (suite *Suite) TestDroppedBySkip() {
+	app := suite.newApp(WithMiddlewares(func(_ context.Context, _ *extensions.BrokerMessage, _ extensions.NextMiddleware) error {
+		return extensions.ErrSkipMessage
+	}))
+	defer app.Close(context.Background())
+
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, _ OrderMessage) error {
+			suite.Fail("the message should not be handled")
+			return nil
+		}))
+	suite.Require().Equal("subscribed", suite.nextEvent().hook)
+
+	suite.send()
+	e := suite.nextEvent()
+	suite.Require().Equal("dropped", e.hook)
+	suite.Require().Equal("ReceiveOrderOperation", e.operation)
+	suite.Require().ErrorIs(e.err, extensions.ErrSkipMessage)
+}
+
+func (suite *Suite) TestDroppedWhileDraining() {
+	app := suite.newApp(WithDrainOnClose())
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, _ OrderMessage) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}))
+	suite.Require().Equal("subscribed", suite.nextEvent().hook)
+
+	suite.send()
+	<-started
+
+	// Drain while the first message is handled
+	closed := make(chan struct{})
+	go func() {
+		app.Close(context.Background())
+		close(closed)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// The message received while draining is dropped
+	suite.send()
+	close(release)
+	e := suite.nextEvent()
+	suite.Require().Equal("dropped", e.hook)
+	suite.Require().Equal("ReceiveOrderOperation", e.operation)
+	suite.Require().ErrorIs(e.err, extensions.ErrDraining)
+
+	<-closed
+	suite.Require().Equal("unsubscribed", suite.nextEvent().hook)
+}
@@ -0,0 +1,102 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p idgenerator -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	sub    extensions.BrokerChannelSubscription
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.sub, err = broker.Subscribe(context.Background(), "v3.idgenerator.order")
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.sub.Cancel(context.Background())
+	suite.broker.Close()
+}
+
+// send sends the message with a user controller created with the options, and
+// returns the sent message.
+func (suite *Suite) send(msg OrderMessage, options ...ControllerOption) extensions.BrokerMessage {
+	user, err := NewUserController(suite.broker, options...)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	suite.Require().NoError(user.SendToReceiveOrderOperation(context.Background(), msg))
+
+	select {
+	case bMsg := <-suite.sub.MessagesChannel():
+		return bMsg.BrokerMessage
+	case <-time.After(time.Second):
+		suite.FailNow("message not received")
+		return extensions.BrokerMessage{}
+	}
+}
+
+func (suite *Suite) TestDefaultGenerator() {
+	before := time.Now()
+	bMsg := suite.send(NewOrderMessage())
+
+	id, err := uuid.Parse(string(bMsg.Headers["messageId"]))
+	suite.Require().NoError(err)
+	suite.Require().Equal(uuid.Version(7), id.Version())
+
+	sentAt, err := time.Parse(time.RFC3339Nano, string(bMsg.Headers["sentAt"]))
+	suite.Require().NoError(err)
+	suite.Require().False(sentAt.Before(before))
+}
+
+func (suite *Suite) TestCustomGenerator() {
+	var count int
+	generator := func() string {
+		count++
+		return fmt.Sprintf("order-%d", count)
+	}
+
+	// The identifier and the publication time are given to the middlewares
+	var fromContext, fromMessage, sentAt string
+	mw := func(ctx context.Context, msg *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		fromContext, _ = ctx.Value(extensions.ContextKeyIsMessageID).(string)
+		sentAt, _ = ctx.Value(extensions.ContextKeyIsSentAt).(string)
+		fromMessage = string(msg.Headers["messageId"])
+		return next(ctx)
+	}
+
+	bMsg := suite.send(NewOrderMessage(), WithIDGenerator(generator), WithMiddlewares(mw))
+	suite.Require().Equal("order-1", string(bMsg.Headers["messageId"]))
+	suite.Require().Equal("order-1", fromContext)
+	suite.Require().Equal("order-1", fromMessage)
+	suite.Require().Equal(string(bMsg.Headers["sentAt"]), sentAt)
+}
+
+func (suite *Suite) TestExistingID() {
+	id := "existing"
+	msg := NewOrderMessage()
+	msg.Headers.MessageId = &id
+
+	bMsg := suite.send(msg, WithIDGenerator(func() string { return "generated" }))
+	suite.Require().Equal("existing", string(bMsg.Headers["messageId"]))
+}
@@ -0,0 +1,92 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p jetstreamconsumer -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package jetstreamconsumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// bindingsRecorder is a broker that records the operation bindings received.
+type bindingsRecorder struct {
+	operationBindings []extensions.OperationBindings
+}
+
+func (br *bindingsRecorder) record(ctx context.Context) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsOperationBindings, func(b extensions.OperationBindings) {
+		br.operationBindings = append(br.operationBindings, b)
+	})
+}
+
+func (br *bindingsRecorder) Publish(ctx context.Context, _ string, _ extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) PublishBatch(ctx context.Context, _ string, _ []extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) Subscribe(ctx context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	br.record(ctx)
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestGeneratedOperationBindings() {
+	suite.Require().Equal(extensions.OperationBindings{
+		NATS: &extensions.NATSOperationBindings{
+			JetStreamConsumer: &extensions.JetStreamConsumerBindings{
+				Durable:       "orders-processor",
+				AckWait:       30 * time.Second,
+				MaxDeliver:    5,
+				DeliverPolicy: extensions.JetStreamDeliverNew,
+				BackOff:       []time.Duration{time.Second, 5 * time.Second},
+			},
+		},
+	}, ReceiveOrderOperationBindings)
+}
+
+func (suite *Suite) TestBindingsInContext() {
+	broker := &bindingsRecorder{}
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = app.SubscribeToReceiveOrderOperation(
+		context.Background(),
+		func(_ context.Context, _ OrderMessageFromOrdersChannel) error {
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer app.UnsubscribeFromReceiveOrderOperation(context.Background())
+
+	err = user.SendToReceiveOrderOperation(context.Background(), OrderMessageFromOrdersChannel{})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(broker.operationBindings, 2)
+	suite.Require().Equal(ReceiveOrderOperationBindings, broker.operationBindings[0])
+	suite.Require().Equal(ReceiveOrderOperationBindings, broker.operationBindings[1])
+}
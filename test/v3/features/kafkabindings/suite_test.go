@@ -0,0 +1,85 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p kafkabindings -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package kafkabindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// bindingsRecorder is a broker that records the channel bindings received.
+type bindingsRecorder struct {
+	bindings []extensions.ChannelBindings
+}
+
+func (br *bindingsRecorder) record(ctx context.Context) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannelBindings, func(b extensions.ChannelBindings) {
+		br.bindings = append(br.bindings, b)
+	})
+}
+
+func (br *bindingsRecorder) Publish(ctx context.Context, _ string, _ extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) PublishBatch(ctx context.Context, _ string, _ []extensions.BrokerMessage) error {
+	br.record(ctx)
+	return nil
+}
+
+func (br *bindingsRecorder) Subscribe(ctx context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	br.record(ctx)
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestGeneratedBindings() {
+	suite.Require().Equal(extensions.ChannelBindings{
+		Kafka: &extensions.KafkaChannelBindings{
+			Topic:      "v3.features.kafkabindings.orders",
+			Partitions: 3,
+			Replicas:   1,
+		},
+	}, OrdersChannelBindings)
+}
+
+func (suite *Suite) TestBindingsInContext() {
+	broker := &bindingsRecorder{}
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = app.SubscribeToReceiveOrderOperation(context.Background(), func(_ context.Context, _ OrderMessageFromOrdersChannel) error {
+		return nil
+	})
+	suite.Require().NoError(err)
+	defer app.UnsubscribeFromReceiveOrderOperation(context.Background())
+
+	err = user.SendToReceiveOrderOperation(context.Background(), OrderMessageFromOrdersChannel{})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(broker.bindings, 2)
+	suite.Require().Equal(OrdersChannelBindings, broker.bindings[0])
+	suite.Require().Equal(OrdersChannelBindings, broker.bindings[1])
+}
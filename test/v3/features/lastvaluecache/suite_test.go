@@ -0,0 +1,83 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p lastvaluecache -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package lastvaluecache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// cacheRecorder is a broker that records the last value caches received.
+type cacheRecorder struct {
+	caches    []extensions.LastValueCache
+	addresses []string
+}
+
+func (cr *cacheRecorder) record(ctx context.Context, channel string) {
+	extensions.IfContextSetWith(ctx, extensions.ContextKeyIsLastValueCache, func(c extensions.LastValueCache) {
+		cr.caches = append(cr.caches, c)
+		cr.addresses = append(cr.addresses, channel)
+	})
+}
+
+func (cr *cacheRecorder) Publish(ctx context.Context, channel string, _ extensions.BrokerMessage) error {
+	cr.record(ctx, channel)
+	return nil
+}
+
+func (cr *cacheRecorder) PublishBatch(ctx context.Context, channel string, _ []extensions.BrokerMessage) error {
+	cr.record(ctx, channel)
+	return nil
+}
+
+func (cr *cacheRecorder) Subscribe(_ context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestGeneratedLastValueCache() {
+	suite.Require().Equal(extensions.LastValueCache{
+		Store:   extensions.LastValueCacheStoreKV,
+		Bucket:  "devices-status",
+		History: 5,
+	}, StatusChannelLastValueCache)
+}
+
+func (suite *Suite) TestLastValueCacheInContext() {
+	broker := &cacheRecorder{}
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	params := StatusChannelParameters{DeviceId: "42"}
+	err = app.SendAsPublishStatusOperation(context.Background(), params, StatusMessageFromStatusChannel{})
+	suite.Require().NoError(err)
+
+	err = app.SendBatchAsPublishStatusOperation(context.Background(), params, []StatusMessageFromStatusChannel{{}, {}})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal([]extensions.LastValueCache{
+		StatusChannelLastValueCache,
+		StatusChannelLastValueCache,
+	}, broker.caches)
+	suite.Require().Equal([]string{
+		"v3.features.lastvaluecache.status.42",
+		"v3.features.lastvaluecache.status.42",
+	}, broker.addresses)
+}
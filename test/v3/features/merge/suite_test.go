@@ -0,0 +1,84 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p merge -i ./users.yaml -i ./orders.yaml --merge -o ./asyncapi.gen.go
+
+package merge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestMergedDocuments() {
+	users := make(chan UserSignedUpMessage, 1)
+	err := suite.app.SubscribeToReceiveUserSignedUpOperation(context.Background(),
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			users <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveUserSignedUpOperation(context.Background())
+
+	orders := make(chan OrderPlacedMessage, 1)
+	err = suite.app.SubscribeToReceiveOrderPlacedOperation(context.Background(),
+		func(_ context.Context, msg OrderPlacedMessage) error {
+			orders <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveOrderPlacedOperation(context.Background())
+
+	// Both documents use the schema of the same referenced file
+	id := CustomerIdSchema(uuid.New())
+	user := NewUserSignedUpMessage()
+	user.Payload.Id = &id
+	suite.Require().NoError(suite.user.SendToReceiveUserSignedUpOperation(context.Background(), user))
+
+	amount := int64(42)
+	order := NewOrderPlacedMessage()
+	order.Payload.CustomerId, order.Payload.Amount = &id, &amount
+	suite.Require().NoError(suite.user.SendToReceiveOrderPlacedOperation(context.Background(), order))
+
+	select {
+	case msg := <-users:
+		suite.Require().Equal(user, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no user message received")
+	}
+
+	select {
+	case msg := <-orders:
+		suite.Require().Equal(order, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no order message received")
+	}
+}
@@ -0,0 +1,126 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p messageversions -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package messageversions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+
+	received chan UserSignedUpMessage
+	errors   chan error
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.received = make(chan UserSignedUpMessage, 1)
+	suite.errors = make(chan error, 1)
+	suite.app, err = NewAppController(broker, WithErrorHandler(
+		func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			suite.errors <- err
+		}))
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+
+	// Convert the previous versions
+	UserSignedUpMessageUpConverters.FromV1 = func(payload LegacyUserSchema) (UserSignedUpMessagePayload, error) {
+		return UserSignedUpMessagePayload{FullName: *payload.Name}, nil
+	}
+	UserSignedUpMessageUpConverters.FromV2 = func(
+		payload UserSignedUpMessagePayloadV2,
+	) (UserSignedUpMessagePayload, error) {
+		return UserSignedUpMessagePayload{FullName: *payload.FirstName + " " + *payload.LastName}, nil
+	}
+
+	suite.Require().NoError(suite.app.SubscribeToWelcomeUserOperation(context.Background(),
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			suite.received <- msg
+			return nil
+		}))
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	UserSignedUpMessageUpConverters.FromV1 = nil
+	UserSignedUpMessageUpConverters.FromV2 = nil
+}
+
+// publish publishes a payload with the version header, if there is one.
+func (suite *Suite) publish(version, payload string) {
+	headers := map[string][]byte{}
+	if version != "" {
+		headers[extensions.StandardHeaderMessageVersion] = []byte(version)
+	}
+	suite.Require().NoError(suite.broker.Publish(context.Background(), "v3.messageversions.user.signedup",
+		extensions.BrokerMessage{Headers: headers, Payload: []byte(payload)}))
+}
+
+func (suite *Suite) receive() UserSignedUpMessage {
+	select {
+	case msg := <-suite.received:
+		return msg
+	case err := <-suite.errors:
+		suite.FailNow("unexpected error", err.Error())
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+	return UserSignedUpMessage{}
+}
+
+func (suite *Suite) TestCurrentVersion() {
+	sent := NewUserSignedUpMessage()
+	sent.Payload.FullName = "John Doe"
+	sent.Payload.Email = utils.ToPointer("john@doe.com")
+	suite.Require().NoError(suite.user.SendToWelcomeUserOperation(context.Background(), sent))
+
+	// The version should be sent in a header
+	published := suite.broker.Published("v3.messageversions.user.signedup")
+	suite.Require().Len(published, 1)
+	suite.Require().Equal("3", string(published[0].Headers[extensions.StandardHeaderMessageVersion]))
+
+	suite.Require().Equal(sent.Payload, suite.receive().Payload)
+}
+
+func (suite *Suite) TestPreviousVersion() {
+	suite.publish("2", `{"firstName":"John","lastName":"Doe"}`)
+	suite.Require().Equal("John Doe", suite.receive().Payload.FullName)
+}
+
+func (suite *Suite) TestDefaultVersion() {
+	// The messages without version header are in the default version
+	suite.publish("", `{"name":"John Doe"}`)
+	suite.Require().Equal("John Doe", suite.receive().Payload.FullName)
+}
+
+func (suite *Suite) TestUnsupportedVersion() {
+	// A message with an unknown version is rejected
+	suite.publish("4", `{"fullName":"John Doe"}`)
+	suite.Require().ErrorIs(<-suite.errors, extensions.ErrUnsupportedMessageVersion)
+
+	// As well as a message whose version has no converter
+	UserSignedUpMessageUpConverters.FromV2 = nil
+	suite.publish("2", `{"firstName":"John","lastName":"Doe"}`)
+	suite.Require().ErrorIs(<-suite.errors, extensions.ErrUnsupportedMessageVersion)
+}
@@ -0,0 +1,108 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p metadata -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// metadataBroker is a broker whose received messages are transmitted by the
+// test.
+type metadataBroker struct {
+	*inmemory.Controller
+	subs chan extensions.BrokerChannelSubscription
+}
+
+func (mb metadataBroker) Subscribe(_ context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage, 10),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	mb.subs <- sub
+	return sub, nil
+}
+
+type Suite struct {
+	suite.Suite
+	broker  metadataBroker
+	app     *AppController
+	sub     extensions.BrokerChannelSubscription
+	handled chan context.Context
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = metadataBroker{Controller: broker, subs: make(chan extensions.BrokerChannelSubscription, 1)}
+
+	suite.app, err = NewAppController(suite.broker)
+	suite.Require().NoError(err)
+
+	handled := make(chan context.Context, 10)
+	suite.Require().NoError(suite.app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(ctx context.Context, _ OrderMessage) error {
+			handled <- ctx
+			return nil
+		}))
+	suite.handled = handled
+	suite.sub = <-suite.broker.subs
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.broker.Close()
+}
+
+// transmit transmits the received message, and returns the context of its
+// handling.
+func (suite *Suite) transmit(msg extensions.AcknowledgeableBrokerMessage) context.Context {
+	suite.sub.TransmitReceivedMessage(msg)
+
+	select {
+	case ctx := <-suite.handled:
+		return ctx
+	case <-time.After(time.Second):
+		suite.FailNow("the message has not been handled")
+		return nil
+	}
+}
+
+func (suite *Suite) TestMetadata() {
+	expected := extensions.MessageMetadata{
+		Redelivered:   true,
+		DeliveryCount: 2,
+		Timestamp:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Partition:     1,
+		Offset:        42,
+		RoutingKey:    "v3.metadata.order",
+		ID:            "1234",
+	}
+
+	ctx := suite.transmit(extensions.NewAcknowledgeableBrokerMessage(
+		extensions.BrokerMessage{Payload: []byte(`{"id":"1"}`)}, nil,
+	).WithMetadata(expected))
+
+	md, ok := extensions.MessageMetadataFromContext(ctx)
+	suite.Require().True(ok)
+	suite.Require().Equal(expected, md)
+}
+
+func (suite *Suite) TestNoMetadata() {
+	ctx := suite.transmit(extensions.NewAcknowledgeableBrokerMessage(
+		extensions.BrokerMessage{Payload: []byte(`{"id":"1"}`)}, nil,
+	))
+
+	_, ok := extensions.MessageMetadataFromContext(ctx)
+	suite.Require().False(ok)
+}
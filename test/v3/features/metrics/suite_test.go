@@ -0,0 +1,196 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p metrics -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/metricscollectors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	channel   = "v3.metrics.user.{userId}.signedup"
+	operation = "ReceiveUserSignedUpOperation"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	registry *prometheus.Registry
+	broker   *inmemory.Controller
+	app      *AppController
+	user     *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	suite.registry = prometheus.NewRegistry()
+	collector, err := metricscollectors.NewPrometheus(suite.registry)
+	suite.Require().NoError(err)
+
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	app, err := NewAppController(broker, WithMetrics(collector))
+	suite.Require().NoError(err)
+	suite.app = app
+
+	user, err := NewUserController(broker, WithMetrics(collector))
+	suite.Require().NoError(err)
+	suite.user = user
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// value returns the value of the metric with the labels, or the number of
+// observations for a histogram.
+func (suite *Suite) value(name string, labels map[string]string) float64 {
+	families, err := suite.registry.Gather()
+	suite.Require().NoError(err)
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, exists := labels[l.GetName()]; exists && v != l.GetValue() {
+					continue metrics
+				}
+			}
+
+			switch {
+			case m.GetCounter() != nil:
+				return m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				return m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
+
+func (suite *Suite) labels(kv ...string) map[string]string {
+	labels := map[string]string{"channel": channel, "operation": operation}
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels[kv[i]] = kv[i+1]
+	}
+	return labels
+}
+
+// subscribe subscribes with the function, returning a channel closed once the
+// function has been called the number of times.
+func (suite *Suite) subscribe(count int, fn func(msg UserSignedUpMessage) error) chan struct{} {
+	done := make(chan struct{})
+	err := suite.app.SubscribeToAllReceiveUserSignedUpOperation(context.Background(),
+		func(_ context.Context, _ UserSignedUpChannelParameters, msg UserSignedUpMessage) error {
+			err := fn(msg)
+			if count--; count == 0 {
+				close(done)
+			}
+			return err
+		})
+	suite.Require().NoError(err)
+	return done
+}
+
+func (suite *Suite) send(userID string, payload string) {
+	msg := NewUserSignedUpMessage()
+	msg.Payload = payload
+	err := suite.user.SendToReceiveUserSignedUpOperation(context.Background(),
+		UserSignedUpChannelParameters{UserId: userID}, msg)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) wait(done chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.FailNow("messages not received")
+	}
+
+	// Let the metrics be recorded after the subscription function
+	suite.Require().Eventually(func() bool {
+		return suite.value("asyncapi_messages_in_flight", suite.labels()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *Suite) TestSuccess() {
+	done := suite.subscribe(2, func(UserSignedUpMessage) error { return nil })
+	suite.send("1", "first")
+	suite.send("2", "second")
+	suite.wait(done)
+
+	// The metrics of all the addresses are labeled by the channel address
+	suite.Require().Equal(2.0, suite.value("asyncapi_messages_published_total", suite.labels("status", "success")))
+	suite.Require().Equal(2.0, suite.value("asyncapi_messages_received_total", suite.labels()))
+	suite.Require().Equal(2.0, suite.value("asyncapi_messages_handling_duration_seconds", suite.labels("status", "success")))
+	suite.Require().Equal(2.0, suite.value("asyncapi_messages_acknowledgements_total", suite.labels("type", "ack")))
+	suite.Require().Equal(0.0, suite.value("asyncapi_messages_acknowledgements_total", suite.labels("type", "nak")))
+}
+
+func (suite *Suite) TestHandlingError() {
+	done := suite.subscribe(2, func(msg UserSignedUpMessage) error {
+		if msg.Payload == "invalid" {
+			return errors.New("invalid message")
+		}
+		return nil
+	})
+	suite.send("1", "valid")
+	suite.send("1", "invalid")
+	suite.wait(done)
+
+	suite.Require().Equal(1.0, suite.value("asyncapi_messages_handling_duration_seconds", suite.labels("status", "success")))
+	suite.Require().Equal(1.0, suite.value("asyncapi_messages_handling_duration_seconds", suite.labels("status", "error")))
+	suite.Require().Equal(1.0, suite.value("asyncapi_messages_acknowledgements_total", suite.labels("type", "ack")))
+	suite.Require().Equal(1.0, suite.value("asyncapi_messages_acknowledgements_total", suite.labels("type", "nak")))
+}
+
+func (suite *Suite) TestBatch() {
+	msgs := []UserSignedUpMessage{NewUserSignedUpMessage(), NewUserSignedUpMessage(), NewUserSignedUpMessage()}
+	err := suite.user.SendBatchToReceiveUserSignedUpOperation(context.Background(),
+		UserSignedUpChannelParameters{UserId: "1"}, msgs)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(3.0, suite.value("asyncapi_messages_published_total", suite.labels("status", "success")))
+}
+
+func (suite *Suite) TestDefaultWithoutMetrics() {
+	// Controllers without metrics collector should still work
+	app, err := NewAppController(suite.broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	received := make(chan struct{}, 1)
+	suite.Require().NoError(app.SubscribeToReceiveUserSignedUpOperation(context.Background(),
+		UserSignedUpChannelParameters{UserId: "1"},
+		func(context.Context, UserSignedUpMessage) error {
+			received <- struct{}{}
+			return nil
+		}))
+
+	suite.send("1", "message")
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,433 @@
+// Package "mocks" provides primitives to interact with the AsyncAPI specification.
+//
+// Code generated by github.com/lerenn/asyncapi-codegen version (devel) DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAppSubscriber is a mock of AppSubscriber, based on testify mocks:
+// the expected calls are set with 'On' and checked with 'AssertExpectations'.
+type MockAppSubscriber struct {
+	mock.Mock
+}
+
+var _ AppSubscriber = (*MockAppSubscriber)(nil)
+
+// NewMockAppSubscriber creates a new MockAppSubscriber, whose expected calls are
+// asserted at the end of the test.
+func NewMockAppSubscriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAppSubscriber {
+	m := &MockAppSubscriber{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
+
+// ReceivePingOperationReceived mocks the method 'ReceivePingOperationReceived' of AppSubscriber.
+func (_m *MockAppSubscriber) ReceivePingOperationReceived(ctx context.Context, msg PingMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ReceiveUserSignedUpOperationReceived mocks the method 'ReceiveUserSignedUpOperationReceived' of AppSubscriber.
+func (_m *MockAppSubscriber) ReceiveUserSignedUpOperationReceived(ctx context.Context, msg UserSignedUpMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// MockAppController is a mock of AppController, based on testify mocks:
+// the expected calls are set with 'On' and checked with 'AssertExpectations'.
+type MockAppController struct {
+	mock.Mock
+}
+
+// NewMockAppController creates a new MockAppController, whose expected calls are
+// asserted at the end of the test.
+func NewMockAppController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAppController {
+	m := &MockAppController{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
+
+// Health mocks the method 'Health' of AppController.
+func (_m *MockAppController) Health(ctx context.Context) extensions.Health {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(extensions.Health)
+	return r0
+}
+
+// Healthy mocks the method 'Healthy' of AppController.
+func (_m *MockAppController) Healthy(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// Ready mocks the method 'Ready' of AppController.
+func (_m *MockAppController) Ready() <-chan struct{} {
+	_ret := _m.Called()
+	r0, _ := _ret.Get(0).(<-chan struct{})
+	return r0
+}
+
+// Close mocks the method 'Close' of AppController.
+func (_m *MockAppController) Close(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// SubscribeToAllChannels mocks the method 'SubscribeToAllChannels' of AppController.
+func (_m *MockAppController) SubscribeToAllChannels(ctx context.Context, as AppSubscriber) error {
+	_ret := _m.Called(ctx, as)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UnsubscribeFromAllChannels mocks the method 'UnsubscribeFromAllChannels' of AppController.
+func (_m *MockAppController) UnsubscribeFromAllChannels(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// UseForReceivePingOperation mocks the method 'UseForReceivePingOperation' of AppController.
+func (_m *MockAppController) UseForReceivePingOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SetConcurrencyForReceivePingOperation mocks the method 'SetConcurrencyForReceivePingOperation' of AppController.
+func (_m *MockAppController) SetConcurrencyForReceivePingOperation(workers int) {
+	_m.Called(workers)
+}
+
+// SetAckPolicyForReceivePingOperation mocks the method 'SetAckPolicyForReceivePingOperation' of AppController.
+func (_m *MockAppController) SetAckPolicyForReceivePingOperation(policy extensions.AckPolicy) {
+	_m.Called(policy)
+}
+
+// SubscribeToReceivePingOperation mocks the method 'SubscribeToReceivePingOperation' of AppController.
+func (_m *MockAppController) SubscribeToReceivePingOperation(ctx context.Context, fn func(ctx context.Context, msg PingMessage) error) error {
+	_ret := _m.Called(ctx, fn)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ReplyToReceivePingOperation mocks the method 'ReplyToReceivePingOperation' of AppController.
+func (_m *MockAppController) ReplyToReceivePingOperation(ctx context.Context, recvMsg PingMessage, fn func(replyMsg *PongMessage)) error {
+	_ret := _m.Called(ctx, recvMsg, fn)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// PauseReceivePingOperation mocks the method 'PauseReceivePingOperation' of AppController.
+func (_m *MockAppController) PauseReceivePingOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ResumeReceivePingOperation mocks the method 'ResumeReceivePingOperation' of AppController.
+func (_m *MockAppController) ResumeReceivePingOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UnsubscribeFromReceivePingOperation mocks the method 'UnsubscribeFromReceivePingOperation' of AppController.
+func (_m *MockAppController) UnsubscribeFromReceivePingOperation(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// UseForReceiveUserSignedUpOperation mocks the method 'UseForReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) UseForReceiveUserSignedUpOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SetConcurrencyForReceiveUserSignedUpOperation mocks the method 'SetConcurrencyForReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) SetConcurrencyForReceiveUserSignedUpOperation(workers int) {
+	_m.Called(workers)
+}
+
+// SetAckPolicyForReceiveUserSignedUpOperation mocks the method 'SetAckPolicyForReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) SetAckPolicyForReceiveUserSignedUpOperation(policy extensions.AckPolicy) {
+	_m.Called(policy)
+}
+
+// SubscribeToReceiveUserSignedUpOperation mocks the method 'SubscribeToReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) SubscribeToReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters, fn func(ctx context.Context, msg UserSignedUpMessage) error) error {
+	_ret := _m.Called(ctx, params, fn)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// SubscribeToAllReceiveUserSignedUpOperation mocks the method 'SubscribeToAllReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) SubscribeToAllReceiveUserSignedUpOperation(ctx context.Context, fn func(ctx context.Context, params UserSignedUpChannelParameters, msg UserSignedUpMessage) error) error {
+	_ret := _m.Called(ctx, fn)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// PauseReceiveUserSignedUpOperation mocks the method 'PauseReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) PauseReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters) error {
+	_ret := _m.Called(ctx, params)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ResumeReceiveUserSignedUpOperation mocks the method 'ResumeReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) ResumeReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters) error {
+	_ret := _m.Called(ctx, params)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// PauseAllReceiveUserSignedUpOperation mocks the method 'PauseAllReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) PauseAllReceiveUserSignedUpOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ResumeAllReceiveUserSignedUpOperation mocks the method 'ResumeAllReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) ResumeAllReceiveUserSignedUpOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UnsubscribeFromReceiveUserSignedUpOperation mocks the method 'UnsubscribeFromReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) UnsubscribeFromReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters) {
+	_m.Called(ctx, params)
+}
+
+// UnsubscribeFromAllReceiveUserSignedUpOperation mocks the method 'UnsubscribeFromAllReceiveUserSignedUpOperation' of AppController.
+func (_m *MockAppController) UnsubscribeFromAllReceiveUserSignedUpOperation(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// UseForReplyToReceivePingOperation mocks the method 'UseForReplyToReceivePingOperation' of AppController.
+func (_m *MockAppController) UseForReplyToReceivePingOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SendAsReplyToReceivePingOperation mocks the method 'SendAsReplyToReceivePingOperation' of AppController.
+func (_m *MockAppController) SendAsReplyToReceivePingOperation(ctx context.Context, msg PongMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// SendBatchAsReplyToReceivePingOperation mocks the method 'SendBatchAsReplyToReceivePingOperation' of AppController.
+func (_m *MockAppController) SendBatchAsReplyToReceivePingOperation(ctx context.Context, msgs []PongMessage) error {
+	_ret := _m.Called(ctx, msgs)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UseForSendWelcomeOperation mocks the method 'UseForSendWelcomeOperation' of AppController.
+func (_m *MockAppController) UseForSendWelcomeOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SendAsSendWelcomeOperation mocks the method 'SendAsSendWelcomeOperation' of AppController.
+func (_m *MockAppController) SendAsSendWelcomeOperation(ctx context.Context, msg WelcomeMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// SendBatchAsSendWelcomeOperation mocks the method 'SendBatchAsSendWelcomeOperation' of AppController.
+func (_m *MockAppController) SendBatchAsSendWelcomeOperation(ctx context.Context, msgs []WelcomeMessage) error {
+	_ret := _m.Called(ctx, msgs)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// MockUserSubscriber is a mock of UserSubscriber, based on testify mocks:
+// the expected calls are set with 'On' and checked with 'AssertExpectations'.
+type MockUserSubscriber struct {
+	mock.Mock
+}
+
+var _ UserSubscriber = (*MockUserSubscriber)(nil)
+
+// NewMockUserSubscriber creates a new MockUserSubscriber, whose expected calls are
+// asserted at the end of the test.
+func NewMockUserSubscriber(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockUserSubscriber {
+	m := &MockUserSubscriber{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
+
+// SendWelcomeOperationReceived mocks the method 'SendWelcomeOperationReceived' of UserSubscriber.
+func (_m *MockUserSubscriber) SendWelcomeOperationReceived(ctx context.Context, msg WelcomeMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// MockUserController is a mock of UserController, based on testify mocks:
+// the expected calls are set with 'On' and checked with 'AssertExpectations'.
+type MockUserController struct {
+	mock.Mock
+}
+
+// NewMockUserController creates a new MockUserController, whose expected calls are
+// asserted at the end of the test.
+func NewMockUserController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockUserController {
+	m := &MockUserController{}
+	m.Mock.Test(t)
+	t.Cleanup(func() { m.AssertExpectations(t) })
+	return m
+}
+
+// Health mocks the method 'Health' of UserController.
+func (_m *MockUserController) Health(ctx context.Context) extensions.Health {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(extensions.Health)
+	return r0
+}
+
+// Healthy mocks the method 'Healthy' of UserController.
+func (_m *MockUserController) Healthy(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// Ready mocks the method 'Ready' of UserController.
+func (_m *MockUserController) Ready() <-chan struct{} {
+	_ret := _m.Called()
+	r0, _ := _ret.Get(0).(<-chan struct{})
+	return r0
+}
+
+// Close mocks the method 'Close' of UserController.
+func (_m *MockUserController) Close(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// SubscribeToAllChannels mocks the method 'SubscribeToAllChannels' of UserController.
+func (_m *MockUserController) SubscribeToAllChannels(ctx context.Context, as UserSubscriber) error {
+	_ret := _m.Called(ctx, as)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UnsubscribeFromAllChannels mocks the method 'UnsubscribeFromAllChannels' of UserController.
+func (_m *MockUserController) UnsubscribeFromAllChannels(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// UseForSendWelcomeOperation mocks the method 'UseForSendWelcomeOperation' of UserController.
+func (_m *MockUserController) UseForSendWelcomeOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SetConcurrencyForSendWelcomeOperation mocks the method 'SetConcurrencyForSendWelcomeOperation' of UserController.
+func (_m *MockUserController) SetConcurrencyForSendWelcomeOperation(workers int) {
+	_m.Called(workers)
+}
+
+// SetAckPolicyForSendWelcomeOperation mocks the method 'SetAckPolicyForSendWelcomeOperation' of UserController.
+func (_m *MockUserController) SetAckPolicyForSendWelcomeOperation(policy extensions.AckPolicy) {
+	_m.Called(policy)
+}
+
+// SubscribeToSendWelcomeOperation mocks the method 'SubscribeToSendWelcomeOperation' of UserController.
+func (_m *MockUserController) SubscribeToSendWelcomeOperation(ctx context.Context, fn func(ctx context.Context, msg WelcomeMessage) error) error {
+	_ret := _m.Called(ctx, fn)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// PauseSendWelcomeOperation mocks the method 'PauseSendWelcomeOperation' of UserController.
+func (_m *MockUserController) PauseSendWelcomeOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// ResumeSendWelcomeOperation mocks the method 'ResumeSendWelcomeOperation' of UserController.
+func (_m *MockUserController) ResumeSendWelcomeOperation(ctx context.Context) error {
+	_ret := _m.Called(ctx)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// UnsubscribeFromSendWelcomeOperation mocks the method 'UnsubscribeFromSendWelcomeOperation' of UserController.
+func (_m *MockUserController) UnsubscribeFromSendWelcomeOperation(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// UseForReceivePingOperation mocks the method 'UseForReceivePingOperation' of UserController.
+func (_m *MockUserController) UseForReceivePingOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SendToReceivePingOperation mocks the method 'SendToReceivePingOperation' of UserController.
+func (_m *MockUserController) SendToReceivePingOperation(ctx context.Context, msg PingMessage) error {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// SendBatchToReceivePingOperation mocks the method 'SendBatchToReceivePingOperation' of UserController.
+func (_m *MockUserController) SendBatchToReceivePingOperation(ctx context.Context, msgs []PingMessage) error {
+	_ret := _m.Called(ctx, msgs)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// RequestToReceivePingOperation mocks the method 'RequestToReceivePingOperation' of UserController.
+func (_m *MockUserController) RequestToReceivePingOperation(ctx context.Context, msg PingMessage) (PongMessage, error) {
+	_ret := _m.Called(ctx, msg)
+	r0, _ := _ret.Get(0).(PongMessage)
+	r1, _ := _ret.Get(1).(error)
+	return r0, r1
+}
+
+// WaitForReplyToReceivePingOperation mocks the method 'WaitForReplyToReceivePingOperation' of UserController.
+func (_m *MockUserController) WaitForReplyToReceivePingOperation(ctx context.Context, msg PingMessage, pub func(ctx context.Context, msg PingMessage) error) (PongMessage, error) {
+	_ret := _m.Called(ctx, msg, pub)
+	r0, _ := _ret.Get(0).(PongMessage)
+	r1, _ := _ret.Get(1).(error)
+	return r0, r1
+}
+
+// UseForReceiveUserSignedUpOperation mocks the method 'UseForReceiveUserSignedUpOperation' of UserController.
+func (_m *MockUserController) UseForReceiveUserSignedUpOperation(middlewares ...extensions.Middleware) {
+	_m.Called(middlewares)
+}
+
+// SendToReceiveUserSignedUpOperation mocks the method 'SendToReceiveUserSignedUpOperation' of UserController.
+func (_m *MockUserController) SendToReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters, msg UserSignedUpMessage) error {
+	_ret := _m.Called(ctx, params, msg)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
+
+// SendBatchToReceiveUserSignedUpOperation mocks the method 'SendBatchToReceiveUserSignedUpOperation' of UserController.
+func (_m *MockUserController) SendBatchToReceiveUserSignedUpOperation(ctx context.Context, params UserSignedUpChannelParameters, msgs []UserSignedUpMessage) error {
+	_ret := _m.Called(ctx, params, msgs)
+	r0, _ := _ret.Get(0).(error)
+	return r0
+}
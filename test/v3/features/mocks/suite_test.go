@@ -0,0 +1,104 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p mocks -i ./asyncapi.yaml -o ./asyncapi.gen.go
+//go:generate go run ../../../../cmd/asyncapi-codegen -p mocks -i ./asyncapi.yaml -o ./mocks.gen.go -g mocks
+
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestSubscriberMock() {
+	sent := NewPingMessage()
+	sent.Payload = "ping"
+
+	received := make(chan struct{})
+	subscriber := NewMockAppSubscriber(suite.T())
+	subscriber.On("ReceivePingOperationReceived", mock.Anything, sent).
+		Return(nil).
+		Run(func(mock.Arguments) { close(received) }).
+		Once()
+
+	suite.Require().NoError(suite.app.SubscribeToAllChannels(context.Background(), subscriber))
+	defer suite.app.UnsubscribeFromAllChannels(context.Background())
+	suite.Require().NoError(suite.user.SendToReceivePingOperation(context.Background(), sent))
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+// welcomeSender is the part of the application controller used by welcome,
+// like an interface that would be defined by the package using the
+// controller.
+type welcomeSender interface {
+	SendAsSendWelcomeOperation(ctx context.Context, msg WelcomeMessage) error
+}
+
+func welcome(ctx context.Context, sender welcomeSender, text string) error {
+	msg := NewWelcomeMessage()
+	msg.Payload.Text = &text
+	return sender.SendAsSendWelcomeOperation(ctx, msg)
+}
+
+func (suite *Suite) TestControllerMock() {
+	var _ welcomeSender = suite.app
+
+	text := "Welcome!"
+	expected := NewWelcomeMessage()
+	expected.Payload.Text = &text
+
+	ctrl := NewMockAppController(suite.T())
+	ctrl.On("SendAsSendWelcomeOperation", mock.Anything, expected).Return(nil).Once()
+	suite.Require().NoError(welcome(context.Background(), ctrl, text))
+
+	errSend := errors.New("send error")
+	ctrl.On("SendAsSendWelcomeOperation", mock.Anything, mock.Anything).Return(errSend).Once()
+	suite.Require().ErrorIs(welcome(context.Background(), ctrl, text), errSend)
+}
+
+func (suite *Suite) TestControllerMockResults() {
+	pong := NewPongMessage()
+	pong.Payload = "pong"
+
+	ctrl := NewMockUserController(suite.T())
+	ctrl.On("RequestToReceivePingOperation", mock.Anything, mock.Anything).Return(pong, nil).Once()
+
+	resp, err := ctrl.RequestToReceivePingOperation(context.Background(), NewPingMessage())
+	suite.Require().NoError(err)
+	suite.Require().Equal(pong, resp)
+}
@@ -0,0 +1,105 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p naming -i ./asyncapi.yaml -o ./asyncapi.gen.go --initialisms id,url --operation-suffix ""
+
+package naming
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestNames() {
+	received := make(chan CustomerCreated, 1)
+	err := suite.app.SubscribeToReceiveUser(context.Background(),
+		func(_ context.Context, msg CustomerCreated) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveUser(context.Background())
+
+	// The names follow the initialisms and the x-go-name extensions
+	requestID, trace, email := "request-1", "trace-1", "john@example.com"
+	sent := NewCustomerCreated()
+	sent.Headers.RequestID = &requestID
+	sent.Headers.Trace = &trace
+	sent.Payload = Customer{UserID: "user-1", Email: &email}
+	suite.Require().NoError(suite.user.SendToReceiveUser(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestKeys() {
+	// The JSON keys are not changed by the names
+	email, url := "john@example.com", "https://example.com/john.png"
+	b, err := json.Marshal(Customer{UserID: "user-1", AvatarURL: &url, Email: &email})
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{"user_id":"user-1","avatar_url":"https://example.com/john.png","e-mail":"john@example.com"}`,
+		string(b))
+
+	// The validation uses the renamed field
+	email = "john.doe.with.a.long.name@example.com"
+	err = Customer{UserID: "user-1", Email: &email}.Validate()
+	var errs extensions.ValidationErrors
+	suite.Require().ErrorAs(err, &errs)
+	suite.Require().Equal("e-mail", errs[0].Field)
+}
+
+func (suite *Suite) TestOperationName() {
+	received := make(chan NotificationMessageFromNotificationsChannel, 1)
+	err := suite.app.SubscribeToNotify(context.Background(),
+		func(_ context.Context, msg NotificationMessageFromNotificationsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromNotify(context.Background())
+
+	text := "hello"
+	sent := NewNotificationMessageFromNotificationsChannel()
+	sent.Payload.Text = &text
+	suite.Require().NoError(suite.user.SendToNotify(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,162 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p operationmiddlewares -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package operationmiddlewares
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	user   *UserController
+
+	mu       sync.Mutex
+	recorded []string
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	suite.user = user
+
+	suite.recorded = nil
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// record returns a middleware recording its name and the middleware context.
+func (suite *Suite) record(name string) extensions.Middleware {
+	return func(ctx context.Context, _ *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+		mc := extensions.MiddlewareContextFrom(ctx)
+
+		suite.mu.Lock()
+		suite.recorded = append(suite.recorded, name+" "+mc.Operation+" "+mc.Channel+" "+mc.Direction)
+		suite.mu.Unlock()
+
+		return next(ctx)
+	}
+}
+
+func (suite *Suite) getRecorded() []string {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	return append([]string(nil), suite.recorded...)
+}
+
+// subscribe subscribes to both operations, sending the payloads of the
+// received messages to the returned channel.
+func (suite *Suite) subscribe(app *AppController) chan string {
+	received := make(chan string, 2)
+	fn := func(_ context.Context, msg MessageMessage) error {
+		received <- msg.Payload
+		return nil
+	}
+	suite.Require().NoError(app.SubscribeToReceivePingOperation(context.Background(), fn))
+	suite.Require().NoError(app.SubscribeToReceiveStatusOperation(context.Background(), fn))
+	return received
+}
+
+func (suite *Suite) send() {
+	ping, status := NewMessageMessage(), NewMessageMessage()
+	ping.Payload, status.Payload = "ping", "status"
+	suite.Require().NoError(suite.user.SendToReceivePingOperation(context.Background(), ping))
+	suite.Require().NoError(suite.user.SendToReceiveStatusOperation(context.Background(), status))
+}
+
+func (suite *Suite) receive(received chan string) string {
+	select {
+	case payload := <-received:
+		return payload
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+		return ""
+	}
+}
+
+func (suite *Suite) TestReceptionScope() {
+	app, err := NewAppController(suite.broker, WithMiddlewares(suite.record("global")))
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+	app.UseForReceivePingOperation(suite.record("first"), suite.record("second"))
+
+	received := suite.subscribe(app)
+	suite.send()
+	suite.Require().ElementsMatch([]string{"ping", "status"}, []string{suite.receive(received), suite.receive(received)})
+
+	// The operation middlewares are executed after the global ones, only on
+	// the messages of the operation
+	recorded := suite.getRecorded()
+	suite.Require().Len(recorded, 4)
+	suite.Require().Contains(recorded, "global ReceiveStatusOperation v3.operationmiddlewares.status reception")
+
+	var ping []string
+	for _, r := range recorded {
+		if r != "global ReceiveStatusOperation v3.operationmiddlewares.status reception" {
+			ping = append(ping, r)
+		}
+	}
+	suite.Require().Equal([]string{
+		"global ReceivePingOperation v3.operationmiddlewares.ping reception",
+		"first ReceivePingOperation v3.operationmiddlewares.ping reception",
+		"second ReceivePingOperation v3.operationmiddlewares.ping reception",
+	}, ping)
+}
+
+func (suite *Suite) TestPublicationScope() {
+	suite.user.UseForReceiveStatusOperation(suite.record("user"))
+
+	app, err := NewAppController(suite.broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	received := suite.subscribe(app)
+	suite.send()
+	suite.receive(received)
+	suite.receive(received)
+
+	suite.Require().Equal([]string{
+		"user ReceiveStatusOperation v3.operationmiddlewares.status publication",
+	}, suite.getRecorded())
+}
+
+func (suite *Suite) TestRejection() {
+	app, err := NewAppController(suite.broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	// Reject the messages of the ping operation only
+	errUnauthorized := errors.New("unauthorized")
+	app.UseForReceivePingOperation(func(context.Context, *extensions.BrokerMessage, extensions.NextMiddleware) error {
+		return errUnauthorized
+	})
+
+	received := suite.subscribe(app)
+	suite.send()
+	suite.Require().Equal("status", suite.receive(received))
+
+	select {
+	case payload := <-received:
+		suite.FailNow("rejected message received", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,112 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p optionalfields -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package optionalfields
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) receive(sent UserMessageFromUsersChannel) UserMessageFromUsersChannel {
+	received := make(chan UserMessageFromUsersChannel, 1)
+	err := suite.app.SubscribeToReceiveUserOperation(context.Background(),
+		func(_ context.Context, msg UserMessageFromUsersChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveUserOperation(context.Background())
+
+	suite.Require().NoError(suite.user.SendToReceiveUserOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+		return UserMessageFromUsersChannel{}
+	}
+}
+
+func (suite *Suite) TestSetValues() {
+	sent := NewUserMessageFromUsersChannel()
+	sent.Headers.Tenant = extensions.Some("acme")
+	sent.Payload.Id = "1"
+	sent.Payload.Nickname = extensions.Some("")
+	sent.Payload.Age = 42
+	sent.Payload.Email = utils.ToPointer("john@example.com")
+	sent.Payload.Address = &AddressSchema{
+		City:   "Paris",
+		Street: extensions.Some("Rue de Rivoli"),
+		Floor:  utils.ToPointer(int64(0)),
+	}
+
+	msg := suite.receive(sent)
+	suite.Require().Equal(sent.Headers, msg.Headers)
+	suite.Require().Equal(sent.Payload, msg.Payload)
+
+	// Options keep the zero values that are set
+	nickname, ok := msg.Payload.Nickname.Get()
+	suite.Require().True(ok)
+	suite.Require().Equal("", nickname)
+}
+
+func (suite *Suite) TestUnsetValues() {
+	sent := NewUserMessageFromUsersChannel()
+	sent.Payload.Id = "1"
+	sent.Payload.Address = &AddressSchema{City: "Paris"}
+
+	msg := suite.receive(sent)
+	suite.Require().False(msg.Headers.Tenant.IsSet())
+	suite.Require().False(msg.Payload.Nickname.IsSet())
+	suite.Require().Zero(msg.Payload.Age)
+	suite.Require().Nil(msg.Payload.Email)
+	suite.Require().False(msg.Payload.Address.Street.IsSet())
+	suite.Require().Nil(msg.Payload.Address.Floor)
+}
+
+func (suite *Suite) TestValidation() {
+	// Unset values are not checked
+	payload := UserMessageFromUsersChannelPayload{Id: "1"}
+	suite.Require().NoError(payload.Validate())
+
+	payload.Nickname = extensions.Some("a very long nickname")
+	payload.Age = 12
+	var errs extensions.ValidationErrors
+	suite.Require().ErrorAs(payload.Validate(), &errs)
+	suite.Require().Len(errs, 2)
+	suite.Require().Equal("age", errs[0].Field)
+	suite.Require().Equal("nickname", errs[1].Field)
+}
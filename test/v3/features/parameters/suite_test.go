@@ -0,0 +1,84 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p parameters -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package parameters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestAddress() {
+	params := UserEventsChannelParameters{Region: "eu", UserId: "1234", Event: "signedup"}
+	suite.Require().Equal("v3.parameters.eu.user.1234.signedup", params.Address())
+}
+
+func (suite *Suite) TestParse() {
+	params, err := ParseUserEventsChannelParameters("v3.parameters.us.user.1234.signedout")
+	suite.Require().NoError(err)
+	suite.Require().Equal(UserEventsChannelParameters{Region: "us", UserId: "1234", Event: "signedout"}, params)
+}
+
+func (suite *Suite) TestParseInvalid() {
+	for _, addr := range []string{
+		"v3.parameters.eu.user.1234",               // Missing parameter
+		"v3.other.eu.user.1234.signedup",           // Other channel
+		"v3.parameters.eu.user.1234.deleted",       // Not a value of the enum
+		"v3.parameters.asia.user.1234.signedup",    // Not a value of the referenced parameter
+		"prefix.v3.parameters.eu.user.1234.signup", // Not at the start of the address
+	} {
+		_, err := ParseUserEventsChannelParameters(addr)
+		suite.Require().ErrorIs(err, extensions.ErrInvalidChannelAddress, addr)
+	}
+}
+
+func (suite *Suite) TestParseReceivedAddress() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	// Get the parameters from the address of the received message
+	sent := UserEventsChannelParameters{Region: "eu", UserId: "1234", Event: "signedup"}
+	received := make(chan UserEventsChannelParameters, 1)
+	err = app.SubscribeToReceiveUserEventsOperation(context.Background(), sent,
+		func(ctx context.Context, _ UserEventMessage) error {
+			extensions.IfContextSetWith(ctx, extensions.ContextKeyIsChannel, func(addr string) {
+				params, err := ParseUserEventsChannelParameters(addr)
+				suite.Require().NoError(err)
+				received <- params
+			})
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	msg := NewUserEventMessage()
+	msg.Payload.Name = utils.ToPointer("john")
+	suite.Require().NoError(user.SendToReceiveUserEventsOperation(context.Background(), sent, msg))
+
+	select {
+	case params := <-received:
+		suite.Require().Equal(sent, params)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,84 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p partitionkey -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package partitionkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+// keyRecorder is a broker that records the keys of the published messages.
+type keyRecorder struct {
+	keys []string
+}
+
+func (kr *keyRecorder) Publish(_ context.Context, _ string, bm extensions.BrokerMessage) error {
+	kr.keys = append(kr.keys, bm.Key)
+	return nil
+}
+
+func (kr *keyRecorder) PublishBatch(_ context.Context, _ string, bms []extensions.BrokerMessage) error {
+	for _, bm := range bms {
+		kr.keys = append(kr.keys, bm.Key)
+	}
+	return nil
+}
+
+func (kr *keyRecorder) Subscribe(_ context.Context, _ string) (extensions.BrokerChannelSubscription, error) {
+	sub := extensions.NewBrokerChannelSubscription(
+		make(chan extensions.AcknowledgeableBrokerMessage),
+		make(chan any, 1),
+	)
+	sub.WaitForCancellationAsync(func() {})
+	return sub, nil
+}
+
+func (suite *Suite) TestPartitionKeyFromPayload() {
+	msg := OrderMessageFromOrdersChannel{}
+	msg.Payload.CustomerId = "customer-1"
+	suite.Require().Equal("customer-1", msg.PartitionKey())
+
+	broker := &keyRecorder{}
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	suite.Require().NoError(app.SendAsPublishOrderOperation(context.Background(), msg))
+
+	other := OrderMessageFromOrdersChannel{}
+	other.Payload.CustomerId = "customer-2"
+	err = app.SendBatchAsPublishOrderOperation(context.Background(), []OrderMessageFromOrdersChannel{msg, other})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal([]string{"customer-1", "customer-1", "customer-2"}, broker.keys)
+}
+
+func (suite *Suite) TestPartitionKeyFromHeader() {
+	// Optional header is not set
+	msg := InvoiceMessageFromInvoicesChannel{}
+	suite.Require().Equal("", msg.PartitionKey())
+
+	broker := &keyRecorder{}
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	suite.Require().NoError(app.SendAsPublishInvoiceOperation(context.Background(), msg))
+
+	msg.Headers.AccountId = utils.ToPointer("account-1")
+	suite.Require().NoError(app.SendAsPublishInvoiceOperation(context.Background(), msg))
+
+	suite.Require().Equal([]string{"", "account-1"}, broker.keys)
+}
@@ -0,0 +1,151 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p patternsubscription -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package patternsubscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	suite.app = app
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	suite.user = user
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+func (suite *Suite) send(params UserEventsChannelParameters, name string) {
+	msg := NewUserEventMessage()
+	msg.Payload.Name = utils.ToPointer(name)
+	suite.Require().NoError(suite.user.SendToReceiveUserEventsOperation(context.Background(), params, msg))
+}
+
+func (suite *Suite) TestSubscribeToAll() {
+	received := make(chan UserEventsChannelParameters, 2)
+	err := suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(),
+		func(_ context.Context, params UserEventsChannelParameters, msg UserEventMessage) error {
+			suite.Require().Equal(params.UserId, *msg.Payload.Name)
+			received <- params
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	sent := []UserEventsChannelParameters{
+		{UserId: "1234", Event: "signedup"},
+		{UserId: "5678", Event: "signedout"},
+	}
+	for _, params := range sent {
+		suite.send(params, params.UserId)
+	}
+
+	all := make([]UserEventsChannelParameters, 0, len(sent))
+	for len(all) < len(sent) {
+		select {
+		case params := <-received:
+			all = append(all, params)
+		case <-time.After(time.Second):
+			suite.FailNow("no message received")
+		}
+	}
+	suite.Require().ElementsMatch(sent, all)
+}
+
+func (suite *Suite) TestSubscribeToAllAlongsideAddress() {
+	params := UserEventsChannelParameters{UserId: "1234", Event: "signedup"}
+
+	all, one := make(chan struct{}, 1), make(chan struct{}, 1)
+	suite.Require().NoError(suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(),
+		func(context.Context, UserEventsChannelParameters, UserEventMessage) error {
+			all <- struct{}{}
+			return nil
+		}))
+	suite.Require().NoError(suite.app.SubscribeToReceiveUserEventsOperation(context.Background(), params,
+		func(context.Context, UserEventMessage) error {
+			one <- struct{}{}
+			return nil
+		}))
+
+	// Both subscriptions should receive the message
+	suite.send(params, "john")
+	for _, ch := range []chan struct{}{all, one} {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			suite.FailNow("no message received")
+		}
+	}
+}
+
+func (suite *Suite) TestSubscribeToAllTwice() {
+	fn := func(context.Context, UserEventsChannelParameters, UserEventMessage) error { return nil }
+	suite.Require().NoError(suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(), fn))
+
+	err := suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(), fn)
+	suite.Require().ErrorIs(err, extensions.ErrAlreadySubscribedChannel)
+}
+
+func (suite *Suite) TestUnsubscribeFromAll() {
+	received := make(chan struct{}, 1)
+	suite.Require().NoError(suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(),
+		func(context.Context, UserEventsChannelParameters, UserEventMessage) error {
+			received <- struct{}{}
+			return nil
+		}))
+	suite.app.UnsubscribeFromAllReceiveUserEventsOperation(context.Background())
+
+	suite.send(UserEventsChannelParameters{UserId: "1234", Event: "signedup"}, "john")
+	select {
+	case <-received:
+		suite.FailNow("message received after unsubscription")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// It should be possible to subscribe again
+	fn := func(context.Context, UserEventsChannelParameters, UserEventMessage) error { return nil }
+	suite.Require().NoError(suite.app.SubscribeToAllReceiveUserEventsOperation(context.Background(), fn))
+}
+
+// brokerWithoutPattern is a broker that doesn't support pattern subscriptions.
+type brokerWithoutPattern struct {
+	extensions.BrokerController
+}
+
+func (suite *Suite) TestPatternSubscriptionNotSupported() {
+	app, err := NewAppController(brokerWithoutPattern{suite.broker})
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	err = app.SubscribeToAllReceiveUserEventsOperation(context.Background(),
+		func(context.Context, UserEventsChannelParameters, UserEventMessage) error { return nil })
+	suite.Require().ErrorIs(err, extensions.ErrPatternSubscriptionNotSupported)
+}
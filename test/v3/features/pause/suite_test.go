@@ -0,0 +1,190 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p pause -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package pause
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+// flowBroker is a broker setting a flow controller on its subscriptions.
+type flowBroker struct {
+	*inmemory.Controller
+	flow *flowController
+}
+
+func (fb flowBroker) Subscribe(ctx context.Context, channel string) (extensions.BrokerChannelSubscription, error) {
+	sub, err := fb.Controller.Subscribe(ctx, channel)
+	return sub.WithFlowController(fb.flow), err
+}
+
+// flowController records the calls of the subscription flow controller.
+type flowController struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (fc *flowController) Pause(_ context.Context) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.calls = append(fc.calls, "pause")
+	return nil
+}
+
+func (fc *flowController) Resume(_ context.Context) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.calls = append(fc.calls, "resume")
+	return nil
+}
+
+type Suite struct {
+	suite.Suite
+	broker  *inmemory.Controller
+	app     *AppController
+	user    *UserController
+	handled chan OrderMessage
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+
+	suite.handled = make(chan OrderMessage, 10)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+func (suite *Suite) subscribe(app *AppController) {
+	handled := suite.handled
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessage) error {
+			handled <- msg
+			return nil
+		}))
+}
+
+func (suite *Suite) send(id string) {
+	msg := NewOrderMessage()
+	msg.Payload.Id = &id
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), msg))
+}
+
+// requireNotHandled checks that no message is handled for a while.
+func (suite *Suite) requireNotHandled() {
+	select {
+	case <-suite.handled:
+		suite.FailNow("the message should not be handled while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// nextHandled returns the id of the next handled message.
+func (suite *Suite) nextHandled() string {
+	select {
+	case msg := <-suite.handled:
+		return *msg.Payload.Id
+	case <-time.After(time.Second):
+		suite.FailNow("no message has been handled")
+		return ""
+	}
+}
+
+func (suite *Suite) TestPauseAndResume() {
+	suite.subscribe(suite.app)
+	suite.send("1")
+	suite.Require().Equal("1", suite.nextHandled())
+
+	// The messages are buffered while paused
+	suite.Require().NoError(suite.app.PauseReceiveOrderOperation(context.Background()))
+	suite.Require().NoError(suite.app.PauseReceiveOrderOperation(context.Background()))
+	suite.send("2")
+	suite.send("3")
+	suite.requireNotHandled()
+	suite.Require().True(suite.app.Health(context.Background()).Subscriptions[0].Paused)
+
+	// They are handled in order once resumed
+	suite.Require().NoError(suite.app.ResumeReceiveOrderOperation(context.Background()))
+	suite.Require().NoError(suite.app.ResumeReceiveOrderOperation(context.Background()))
+	suite.Require().Equal("2", suite.nextHandled())
+	suite.Require().Equal("3", suite.nextHandled())
+	suite.Require().False(suite.app.Health(context.Background()).Subscriptions[0].Paused)
+}
+
+func (suite *Suite) TestNotSubscribed() {
+	suite.Require().ErrorIs(suite.app.PauseReceiveOrderOperation(context.Background()), extensions.ErrNotSubscribedChannel)
+	suite.Require().ErrorIs(suite.app.ResumeReceiveOrderOperation(context.Background()), extensions.ErrNotSubscribedChannel)
+}
+
+func (suite *Suite) TestUnsubscribeWhilePaused() {
+	suite.subscribe(suite.app)
+	suite.Require().NoError(suite.app.PauseReceiveOrderOperation(context.Background()))
+
+	unsubscribed := make(chan struct{})
+	go func() {
+		suite.app.UnsubscribeFromReceiveOrderOperation(context.Background())
+		close(unsubscribed)
+	}()
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		suite.FailNow("the paused subscription should be unsubscribed")
+	}
+
+	// It can be subscribed again, without being paused
+	suite.subscribe(suite.app)
+	suite.send("1")
+	suite.Require().Equal("1", suite.nextHandled())
+}
+
+func (suite *Suite) TestFlowController() {
+	broker := flowBroker{Controller: suite.broker, flow: &flowController{}}
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	suite.subscribe(app)
+	suite.Require().NoError(app.PauseReceiveOrderOperation(context.Background()))
+	suite.Require().NoError(app.ResumeReceiveOrderOperation(context.Background()))
+	suite.Require().Equal([]string{"pause", "resume"}, broker.flow.calls)
+}
+
+func (suite *Suite) TestPauseAll() {
+	handled := suite.handled
+	suite.Require().NoError(suite.app.SubscribeToAllReceiveShipmentOperation(context.Background(),
+		func(_ context.Context, _ ShipmentChannelParameters, msg OrderMessage) error {
+			handled <- msg
+			return nil
+		}))
+
+	suite.Require().NoError(suite.app.PauseAllReceiveShipmentOperation(context.Background()))
+	id := "1"
+	msg := NewOrderMessage()
+	msg.Payload.Id = &id
+	suite.Require().NoError(suite.user.SendToReceiveShipmentOperation(context.Background(), ShipmentChannelParameters{Id: "42"}, msg))
+	suite.requireNotHandled()
+
+	suite.Require().NoError(suite.app.ResumeAllReceiveShipmentOperation(context.Background()))
+	suite.Require().Equal("1", suite.nextHandled())
+}
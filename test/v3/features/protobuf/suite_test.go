@@ -0,0 +1,65 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p protobuf -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestProtobufPayload() {
+	msg := NewTimestampMessageFromTimestampsChannel()
+	suite.Require().NotNil(msg.Payload)
+	msg.Payload = timestamppb.New(time.Unix(1700000000, 0))
+
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/x-protobuf", bMsg.ContentType)
+
+	expected, err := proto.Marshal(msg.Payload)
+	suite.Require().NoError(err)
+	suite.Require().Equal(expected, bMsg.Payload)
+
+	received, err := brokerMessageToTimestampMessageFromTimestampsChannel(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().True(proto.Equal(msg.Payload, received.Payload))
+}
+
+func (suite *Suite) TestProtobufPayloadWithHeaders() {
+	msg := NewLabelMessage()
+	msg.Headers.Source = utils.ToPointer("test")
+	msg.Payload = wrapperspb.String("hello")
+
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]byte("test"), bMsg.Headers["source"])
+
+	received, err := brokerMessageToLabelMessage(bMsg)
+	suite.Require().NoError(err)
+	suite.Require().Equal("hello", received.Payload.GetValue())
+	suite.Require().Equal("test", *received.Headers.Source)
+}
+
+func (suite *Suite) TestJSONPayloadAlongProtobuf() {
+	var msg CommentMessageFromCommentsChannel
+	msg.Payload.Text = utils.ToPointer("hello")
+
+	bMsg, err := msg.toBrokerMessage()
+	suite.Require().NoError(err)
+	suite.Require().Equal("application/json", bMsg.ContentType)
+	suite.Require().JSONEq(`{"text":"hello"}`, string(bMsg.Payload))
+}
@@ -0,0 +1,62 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p refs -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package refs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestReferencedFiles() {
+	received := make(chan UserSignedUpMessage, 1)
+	err := suite.app.SubscribeToReceiveUserSignedUpOperation(context.Background(),
+		func(_ context.Context, msg UserSignedUpMessage) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveUserSignedUpOperation(context.Background())
+
+	// The message and its schemas come from the referenced files
+	name, city := "John", "Paris"
+	sent := NewUserSignedUpMessage()
+	sent.Payload = UserSchema{Name: &name, Address: &AddressSchema{City: &city}}
+	suite.Require().NoError(suite.user.SendToReceiveUserSignedUpOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,87 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p replyaddress -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package replyaddress
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+
+	// replyAddresses are the reply addresses of the received requests
+	replyAddresses chan string
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+
+	// Reply to the requests
+	suite.replyAddresses = make(chan string, 10)
+	suite.Require().NoError(suite.app.SubscribeToPingOperation(context.Background(),
+		func(ctx context.Context, msg PingMessage) error {
+			suite.replyAddresses <- *msg.Headers.ReplyTo
+			return suite.app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+				replyMsg.Payload = "pong to " + msg.Payload
+			})
+		}))
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) request(msg PingMessage) PongMessage {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := suite.user.RequestToPingOperation(ctx, msg)
+	suite.Require().NoError(err)
+	return reply
+}
+
+func (suite *Suite) TestTemporaryReplyAddress() {
+	msg := NewPingMessage()
+	msg.Payload = "ping"
+
+	// The reply should be received on a temporary reply address
+	suite.Require().Equal("pong to ping", suite.request(msg).Payload)
+	first := <-suite.replyAddresses
+	suite.Require().True(strings.HasPrefix(first, extensions.DefaultReplyAddressPrefix), first)
+
+	// Each request should have its own reply address
+	suite.Require().Equal("pong to ping", suite.request(msg).Payload)
+	suite.Require().NotEqual(first, <-suite.replyAddresses)
+}
+
+func (suite *Suite) TestSetReplyAddress() {
+	msg := NewPingMessage()
+	msg.Payload = "ping"
+	msg.Headers.ReplyTo = utils.ToPointer("v3.replyaddress.pong.1234")
+
+	// The reply should be received on the reply address of the request
+	suite.Require().Equal("pong to ping", suite.request(msg).Payload)
+	suite.Require().Equal("v3.replyaddress.pong.1234", <-suite.replyAddresses)
+}
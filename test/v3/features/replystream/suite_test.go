@@ -0,0 +1,186 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p replystream -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package replystream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	// Validate the messages, as the message ending the stream is an empty one
+	suite.app, err = NewAppController(broker, WithValidation())
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker, WithValidation())
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+// replyResults replies to the search queries with a result per word of the
+// query, and ends the stream if asked to.
+func (suite *Suite) replyResults(end bool) {
+	suite.Require().NoError(suite.app.SubscribeToSearchOperation(context.Background(),
+		func(ctx context.Context, msg QueryMessage) error {
+			for _, word := range strings.Fields(msg.Payload) {
+				if err := suite.app.ReplyToSearchOperation(ctx, msg, func(replyMsg *ResultMessage) {
+					replyMsg.Payload.Name = word
+				}); err != nil {
+					return err
+				}
+			}
+
+			if !end {
+				return nil
+			}
+			return suite.app.EndReplyStreamToSearchOperation(ctx, msg)
+		}))
+}
+
+// collect returns the names of the results of the stream, until its end.
+func collect(stream *extensions.ReplyStream[ResultMessage]) []string {
+	names := make([]string, 0)
+	for reply := range stream.Replies() {
+		names = append(names, reply.Payload.Name)
+	}
+	return names
+}
+
+func (suite *Suite) TestStream() {
+	suite.replyResults(true)
+
+	query := NewQueryMessage()
+	query.Payload = "first second third"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := suite.user.RequestToSearchOperationStream(ctx, query)
+	suite.Require().NoError(err)
+
+	// All the replies should be received, without the message ending the stream
+	suite.Require().Equal([]string{"first", "second", "third"}, collect(stream))
+	suite.Require().NoError(stream.Err())
+}
+
+func (suite *Suite) TestEmptyStream() {
+	suite.replyResults(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := suite.user.RequestToSearchOperationStream(ctx, NewQueryMessage())
+	suite.Require().NoError(err)
+
+	suite.Require().Empty(collect(stream))
+	suite.Require().NoError(stream.Err())
+}
+
+func (suite *Suite) TestStreamTimeout() {
+	suite.replyResults(false)
+
+	user, err := NewUserController(suite.broker, WithRequestTimeout(100*time.Millisecond))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	query := NewQueryMessage()
+	query.Payload = "first second"
+
+	// The stream is not ended, so it should stop at the timeout
+	start := time.Now()
+	stream, err := user.RequestToSearchOperationStream(context.Background(), query)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"first", "second"}, collect(stream))
+	suite.Require().ErrorIs(stream.Err(), extensions.ErrContextCanceled)
+	suite.Require().ErrorIs(stream.Err(), context.DeadlineExceeded)
+	suite.Require().Less(time.Since(start), time.Second)
+}
+
+func (suite *Suite) TestCloseStream() {
+	suite.replyResults(false)
+
+	query := NewQueryMessage()
+	query.Payload = "first second"
+
+	stream, err := suite.user.RequestToSearchOperationStream(context.Background(), query)
+	suite.Require().NoError(err)
+
+	// Stop after the first reply
+	reply := <-stream.Replies()
+	suite.Require().Equal("first", reply.Payload.Name)
+	stream.Close()
+
+	_, open := <-stream.Replies()
+	suite.Require().False(open)
+	suite.Require().ErrorIs(stream.Err(), context.Canceled)
+}
+
+func (suite *Suite) TestRequestEndOfStream() {
+	suite.replyResults(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The message ending the stream is not a reply
+	_, err := suite.user.RequestToSearchOperation(ctx, NewQueryMessage())
+	suite.Require().ErrorIs(err, extensions.ErrEndOfStream)
+}
+
+func (suite *Suite) TestStreamWithReplyAddress() {
+	suite.Require().NoError(suite.app.SubscribeToListOperation(context.Background(),
+		func(ctx context.Context, msg ListingMessage) error {
+			for _, item := range []string{"a", "b"} {
+				if err := suite.app.ReplyToListOperation(ctx, msg, func(replyMsg *ItemMessage) {
+					replyMsg.Payload = item
+				}); err != nil {
+					return err
+				}
+			}
+			return suite.app.EndReplyStreamToListOperation(ctx, msg)
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := suite.user.RequestToListOperationStream(ctx, NewListingMessage())
+	suite.Require().NoError(err)
+
+	items := make([]string, 0)
+	for reply := range stream.Replies() {
+		items = append(items, reply.Payload)
+	}
+	suite.Require().Equal([]string{"a", "b"}, items)
+	suite.Require().NoError(stream.Err())
+
+	// The stream should have been ended with the custom header
+	var ended bool
+	for _, d := range suite.broker.Deliveries() {
+		if _, ok := d.Message.Headers["last"]; ok {
+			suite.Require().True(strings.HasPrefix(d.Channel, extensions.DefaultReplyAddressPrefix), d.Channel)
+			ended = true
+		}
+	}
+	suite.Require().True(ended)
+}
@@ -0,0 +1,181 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p requestreply -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package requestreply
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker extensions.BrokerController
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+type subscriber struct {
+	app *AppController
+}
+
+func (s subscriber) PingOperationReceived(ctx context.Context, msg PingMessage) error {
+	return s.app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+		replyMsg.Payload = "pong to " + msg.Payload
+	})
+}
+
+func (suite *Suite) TestRequestReply() {
+	suite.Require().NoError(suite.app.SubscribeToPingOperation(context.Background(),
+		subscriber{app: suite.app}.PingOperationReceived))
+	defer suite.app.UnsubscribeFromPingOperation(context.Background())
+
+	// Send a request without correlation ID
+	req := NewPingMessage()
+	req.Headers.RequestId = nil
+	req.Payload = "ping"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := suite.user.RequestToPingOperation(ctx, req)
+	suite.Require().NoError(err)
+
+	// The correlation ID should have been generated and copied onto the reply
+	suite.Require().Equal("pong to ping", reply.Payload)
+	suite.Require().NotNil(reply.Headers.RequestId)
+	suite.Require().NotEqual(uuid.Nil, *reply.Headers.RequestId)
+}
+
+func (suite *Suite) TestConcurrentRequests() {
+	suite.Require().NoError(suite.app.SubscribeToPingOperation(context.Background(),
+		subscriber{app: suite.app}.PingOperationReceived))
+	defer suite.app.UnsubscribeFromPingOperation(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Each request should receive its own reply, even if they share the reply channel
+	var wg sync.WaitGroup
+	replies := make([]string, 10)
+	errs := make([]error, len(replies))
+	for i := range replies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := NewPingMessage()
+			req.Payload = fmt.Sprint("ping ", i)
+			reply, err := suite.user.RequestToPingOperation(ctx, req)
+			replies[i], errs[i] = reply.Payload, err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range replies {
+		suite.Require().NoError(errs[i])
+		suite.Require().Equal(fmt.Sprint("pong to ping ", i), replies[i])
+	}
+}
+
+func (suite *Suite) TestRequestReleasesSubscription() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The request times out as there is no reply
+	_, err := suite.user.RequestToPingOperation(ctx, NewPingMessage())
+	suite.Require().ErrorIs(err, context.DeadlineExceeded)
+
+	// Its subscription to the reply channel should have been canceled
+	suite.Require().NoError(suite.app.SendAsReplyToPingOperation(context.Background(), NewPongMessage()))
+	for _, d := range suite.broker.(*inmemory.Controller).Deliveries() {
+		suite.Require().NotEqual("v3.requestreply.pong", d.Channel)
+	}
+}
+
+func (suite *Suite) TestWaitForReplyIgnoresOtherCorrelationIDs() {
+	req := NewPingMessage()
+	req.Payload = "ping"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := suite.user.WaitForReplyToPingOperation(ctx, req, func(ctx context.Context, msg PingMessage) error {
+		// Reply with another correlation ID first
+		other := NewPongMessage()
+		other.Payload = "other"
+		if err := suite.app.SendAsReplyToPingOperation(ctx, other); err != nil {
+			return err
+		}
+
+		// Then with the correlation ID of the request
+		expected := NewPongMessage()
+		expected.SetAsResponseFrom(&msg)
+		expected.Payload = "expected"
+		return suite.app.SendAsReplyToPingOperation(ctx, expected)
+	})
+	suite.Require().NoError(err)
+
+	suite.Require().Equal("expected", reply.Payload)
+	suite.Require().Equal(req.CorrelationID(), reply.CorrelationID())
+}
+
+func (suite *Suite) TestRequestTimeout() {
+	user, err := NewUserController(suite.broker, WithRequestTimeout(100*time.Millisecond))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	// There is no reply, so the request should time out
+	start := time.Now()
+	_, err = user.RequestToPingOperation(context.Background(), NewPingMessage())
+	suite.Require().ErrorIs(err, extensions.ErrContextCanceled)
+	suite.Require().ErrorIs(err, context.DeadlineExceeded)
+	suite.Require().Less(time.Since(start), time.Second)
+}
+
+func (suite *Suite) TestRequestContextTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// There is no reply, so the request should time out
+	start := time.Now()
+	_, err := suite.user.RequestToPingOperation(ctx, NewPingMessage())
+	suite.Require().ErrorIs(err, extensions.ErrContextCanceled)
+	suite.Require().ErrorIs(err, context.DeadlineExceeded)
+	suite.Require().Less(time.Since(start), time.Second)
+}
+
+func (suite *Suite) TestSetInvalidCorrelationID() {
+	msg := NewPingMessage()
+	id := msg.CorrelationID()
+
+	// The correlation ID is a UUID, so an invalid one should be ignored
+	msg.SetCorrelationID("not-a-uuid")
+	suite.Require().Equal(id, msg.CorrelationID())
+}
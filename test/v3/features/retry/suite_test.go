@@ -0,0 +1,185 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p retry -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+const deadLetterAddress = "v3.retry.order.deadletter"
+
+var errHandling = errors.New("handling error")
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	user   *UserController
+
+	errors chan error
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	suite.user = user
+
+	suite.errors = make(chan error, 1)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// newApp returns an app controller with the middlewares, whose handling errors
+// are sent to the errors channel.
+func (suite *Suite) newApp(mws ...extensions.Middleware) *AppController {
+	app, err := NewAppController(suite.broker,
+		WithMiddlewares(mws...),
+		WithErrorHandler(func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			suite.errors <- err
+		}))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+	return app
+}
+
+// subscribe subscribes with a function failing until the number of calls, and
+// returns the calls count and a channel closed on success.
+func (suite *Suite) subscribe(app *AppController, failures int32) (*atomic.Int32, chan struct{}) {
+	calls, done := &atomic.Int32{}, make(chan struct{})
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessage) error {
+			suite.Require().Equal("order", msg.Payload)
+			if calls.Add(1) <= failures {
+				return errHandling
+			}
+			close(done)
+			return nil
+		}))
+	return calls, done
+}
+
+func (suite *Suite) send() {
+	msg := NewOrderMessage()
+	msg.Payload = "order"
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), msg))
+}
+
+func (suite *Suite) receiveError() error {
+	select {
+	case err := <-suite.errors:
+		return err
+	case <-time.After(time.Second):
+		suite.FailNow("no error received")
+		return nil
+	}
+}
+
+func (suite *Suite) TestSuccessAfterRetries() {
+	// Modify the message after the retry, to check that it is given again as
+	// received at each attempt
+	app := suite.newApp(
+		middlewares.Retry(middlewares.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		func(_ context.Context, msg *extensions.BrokerMessage, _ extensions.NextMiddleware) error {
+			suite.Require().NotContains(msg.Headers, "modified")
+			msg.Headers["modified"] = []byte("true")
+			return nil
+		})
+	calls, done := suite.subscribe(app, 2)
+	suite.send()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.FailNow("message not handled")
+	}
+	suite.Require().Equal(int32(3), calls.Load())
+
+	select {
+	case err := <-suite.errors:
+		suite.FailNow("unexpected error", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (suite *Suite) TestMaxAttemptsReached() {
+	app := suite.newApp(middlewares.Retry(middlewares.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Jitter:         0.5,
+	}))
+	calls, _ := suite.subscribe(app, 10)
+	suite.send()
+
+	err := suite.receiveError()
+	suite.Require().ErrorIs(err, extensions.ErrMaxAttemptsReached)
+	suite.Require().ErrorIs(err, errHandling)
+	suite.Require().Equal(int32(3), calls.Load())
+}
+
+func (suite *Suite) TestDeadLetter() {
+	sub, err := suite.broker.Subscribe(context.Background(), deadLetterAddress)
+	suite.Require().NoError(err)
+	defer sub.Cancel(context.Background())
+
+	app := suite.newApp(middlewares.Retry(middlewares.RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		DeadLetter:     middlewares.DeadLetterTo(suite.broker, deadLetterAddress),
+	}))
+	calls, _ := suite.subscribe(app, 10)
+	suite.send()
+
+	select {
+	case msg := <-sub.MessagesChannel():
+		msg.Ack()
+		suite.Require().Equal([]byte("order"), msg.Payload)
+		suite.Require().Equal(errHandling.Error(), string(msg.Headers["x-error"]))
+		suite.Require().Equal("v3.retry.order", string(msg.Headers["x-original-channel"]))
+	case <-time.After(time.Second):
+		suite.FailNow("no dead letter received")
+	}
+	suite.Require().Equal(int32(2), calls.Load())
+
+	// The message forwarded to the dead letter is not an error
+	select {
+	case err := <-suite.errors:
+		suite.FailNow("unexpected error", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (suite *Suite) TestContextCanceled() {
+	app := suite.newApp(
+		func(ctx context.Context, _ *extensions.BrokerMessage, next extensions.NextMiddleware) error {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			return next(ctx)
+		},
+		middlewares.Retry(middlewares.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute}))
+	calls, _ := suite.subscribe(app, 10)
+	suite.send()
+
+	err := suite.receiveError()
+	suite.Require().ErrorIs(err, extensions.ErrContextCanceled)
+	suite.Require().ErrorIs(err, errHandling)
+	suite.Require().Equal(int32(1), calls.Load())
+}
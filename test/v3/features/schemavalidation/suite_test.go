@@ -0,0 +1,155 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p schemavalidation -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package schemavalidation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+const address = "v3.schemavalidation.user"
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker   *inmemory.Controller
+	received chan UserMessage
+	errors   chan error
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.errors = make(chan error, 1)
+	app, err := NewAppController(broker,
+		WithMiddlewares(middlewares.ValidateSchema()),
+		WithErrorHandler(func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			suite.errors <- err
+		}))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+
+	suite.received = make(chan UserMessage, 1)
+	suite.Require().NoError(app.SubscribeToReceiveUserOperation(context.Background(),
+		func(_ context.Context, msg UserMessage) error {
+			suite.received <- msg
+			return nil
+		}))
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *Suite) publish(payload string) {
+	suite.Require().NoError(suite.broker.Publish(context.Background(), address, extensions.BrokerMessage{
+		Headers:     map[string][]byte{},
+		Payload:     []byte(payload),
+		ContentType: "application/json",
+	}))
+}
+
+// expectErrors expects the error of the reception and returns the fields with
+// the constraints of its validation errors.
+func (suite *Suite) expectErrors() []string {
+	select {
+	case err := <-suite.errors:
+		suite.Require().ErrorIs(err, extensions.ErrValidation)
+
+		var errs extensions.ValidationErrors
+		suite.Require().True(errors.As(err, &errs))
+		fields := make([]string, 0, len(errs))
+		for _, e := range errs {
+			fields = append(fields, e.Field+" "+e.Constraint)
+		}
+		return fields
+	case msg := <-suite.received:
+		suite.FailNow("invalid message received", msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no error received")
+	}
+	return nil
+}
+
+func (suite *Suite) TestValidReception() {
+	suite.publish(`{"name": "john", "age": 42, "email": "john@example.com", "roles": ["admin"]}`)
+
+	select {
+	case msg := <-suite.received:
+		suite.Require().Equal("john", msg.Payload.Name)
+	case err := <-suite.errors:
+		suite.FailNow("unexpected error", err)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestInvalidReception() {
+	// Producers that don't use the generated code can send invalid payloads
+	suite.publish(`{"name": "", "age": 200.5, "email": "john", "roles": ["guest"]}`)
+
+	suite.Require().ElementsMatch([]string{
+		"payload.name minLength",
+		"payload.age type",
+		"payload.email format",
+		"payload.roles[0] enum",
+	}, suite.expectErrors())
+}
+
+func (suite *Suite) TestMissingFields() {
+	suite.publish(`{"email": "john@example.com"}`)
+
+	suite.Require().ElementsMatch([]string{
+		"payload.name required",
+		"payload.age required",
+	}, suite.expectErrors())
+}
+
+func (suite *Suite) TestInvalidJSON() {
+	suite.publish(`{"name":`)
+
+	suite.Require().Equal([]string{"payload "}, suite.expectErrors())
+}
+
+func (suite *Suite) TestOtherContentType() {
+	suite.Require().NoError(suite.broker.Publish(context.Background(), address, extensions.BrokerMessage{
+		Headers:     map[string][]byte{},
+		Payload:     []byte(`{"name": "", "age": 1}`),
+		ContentType: "application/x-custom",
+	}))
+
+	select {
+	case err := <-suite.errors:
+		// The message can't be decoded with this content type, but it is not
+		// rejected by the middleware
+		suite.Require().NotErrorIs(err, extensions.ErrValidation)
+	case <-suite.received:
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) TestInvalidPublication() {
+	user, err := NewUserController(suite.broker, WithMiddlewares(middlewares.ValidateSchema()))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	msg := NewUserMessage()
+	msg.Payload.Age = 151
+	err = user.SendToReceiveUserOperation(context.Background(), msg)
+	suite.Require().ErrorIs(err, extensions.ErrValidation)
+	suite.Require().ErrorContains(err, "payload.name")
+	suite.Require().ErrorContains(err, "payload.age")
+}
@@ -0,0 +1,211 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p security -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	address = "v3.security.customer"
+	email   = "john@example.com"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.broker.Close()
+}
+
+func (suite *Suite) keys(currentID string, keys map[string][]byte) middlewares.KeyProvider {
+	provider, err := middlewares.NewStaticKeyProvider(currentID, keys)
+	suite.Require().NoError(err)
+	return provider
+}
+
+func (suite *Suite) send(mws ...extensions.Middleware) {
+	user, err := NewUserController(suite.broker, WithMiddlewares(mws...))
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	msg := NewCustomerMessage()
+	msg.Payload.Email = ptr(email)
+	suite.Require().NoError(user.SendToReceiveCustomerOperation(context.Background(), msg))
+}
+
+// subscribe subscribes with the middlewares, sending the handled messages and
+// the handling errors to the returned channels.
+func (suite *Suite) subscribe(mws ...extensions.Middleware) (chan CustomerMessage, chan error) {
+	errs := make(chan error, 1)
+	app, err := NewAppController(suite.broker,
+		WithMiddlewares(mws...),
+		WithErrorHandler(func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			errs <- err
+		}))
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { app.Close(context.Background()) })
+
+	received := make(chan CustomerMessage, 1)
+	suite.Require().NoError(app.SubscribeToReceiveCustomerOperation(context.Background(),
+		func(_ context.Context, msg CustomerMessage) error {
+			received <- msg
+			return nil
+		}))
+	return received, errs
+}
+
+// subscribeBroker subscribes directly to the broker, to get the messages as
+// published.
+func (suite *Suite) subscribeBroker() extensions.BrokerChannelSubscription {
+	sub, err := suite.broker.Subscribe(context.Background(), address)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { sub.Cancel(context.Background()) })
+	return sub
+}
+
+func (suite *Suite) expectReceived(received chan CustomerMessage) {
+	select {
+	case msg := <-received:
+		suite.Require().Equal(email, *msg.Payload.Email)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
+
+func (suite *Suite) expectError(errs chan error, expected error) {
+	select {
+	case err := <-errs:
+		suite.Require().ErrorIs(err, expected)
+	case <-time.After(time.Second):
+		suite.FailNow("no error received")
+	}
+}
+
+func (suite *Suite) TestEncryption() {
+	keys := suite.keys("v1", map[string][]byte{"v1": bytes.Repeat([]byte("k"), 32)})
+	received, _ := suite.subscribe(middlewares.Decrypt(keys))
+	raw := suite.subscribeBroker()
+
+	suite.send(middlewares.Encrypt(keys))
+
+	// The published payload is encrypted, with the key ID in the headers
+	select {
+	case msg := <-raw.MessagesChannel():
+		msg.Ack()
+		suite.Require().NotContains(string(msg.Payload), email)
+		suite.Require().Equal("v1", string(msg.Headers[middlewares.EncryptionKeyIDHeader]))
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+
+	suite.expectReceived(received)
+}
+
+func (suite *Suite) TestEncryptionKeyRotation() {
+	previous, current := bytes.Repeat([]byte("p"), 32), bytes.Repeat([]byte("c"), 16)
+	received, _ := suite.subscribe(middlewares.Decrypt(suite.keys("v2", map[string][]byte{
+		"v1": previous,
+		"v2": current,
+	})))
+
+	// Messages encrypted with the previous and current keys are decrypted
+	suite.send(middlewares.Encrypt(suite.keys("v1", map[string][]byte{"v1": previous})))
+	suite.expectReceived(received)
+	suite.send(middlewares.Encrypt(suite.keys("v2", map[string][]byte{"v2": current})))
+	suite.expectReceived(received)
+}
+
+func (suite *Suite) TestDecryptionFailure() {
+	_, errs := suite.subscribe(middlewares.Decrypt(suite.keys("v1", map[string][]byte{
+		"v1": bytes.Repeat([]byte("k"), 32),
+	})))
+
+	// Not encrypted
+	suite.send()
+	suite.expectError(errs, extensions.ErrDecryptionFailed)
+
+	// Unknown key
+	suite.send(middlewares.Encrypt(suite.keys("v2", map[string][]byte{"v2": bytes.Repeat([]byte("k"), 32)})))
+	suite.expectError(errs, extensions.ErrUnknownKey)
+
+	// Wrong key
+	suite.send(middlewares.Encrypt(suite.keys("v1", map[string][]byte{"v1": bytes.Repeat([]byte("w"), 32)})))
+	suite.expectError(errs, extensions.ErrDecryptionFailed)
+}
+
+func (suite *Suite) TestHMACSignature() {
+	keys := suite.keys("v1", map[string][]byte{"v1": []byte("secret")})
+	received, errs := suite.subscribe(middlewares.VerifySignature(middlewares.SignatureHMACSHA256, keys))
+
+	suite.send(middlewares.Sign(middlewares.SignatureHMACSHA256, keys))
+	suite.expectReceived(received)
+
+	// Signed with another secret
+	suite.send(middlewares.Sign(middlewares.SignatureHMACSHA256, suite.keys("v1", map[string][]byte{
+		"v1": []byte("other"),
+	})))
+	suite.expectError(errs, extensions.ErrInvalidSignature)
+
+	// Not signed
+	suite.send()
+	suite.expectError(errs, extensions.ErrInvalidSignature)
+}
+
+func (suite *Suite) TestEd25519Signature() {
+	public, private, err := ed25519.GenerateKey(nil)
+	suite.Require().NoError(err)
+	received, errs := suite.subscribe(middlewares.VerifySignature(middlewares.SignatureEd25519,
+		suite.keys("v1", map[string][]byte{"v1": public})))
+
+	suite.send(middlewares.Sign(middlewares.SignatureEd25519, suite.keys("v1", map[string][]byte{"v1": private})))
+	suite.expectReceived(received)
+
+	// Payload modified after the signature
+	suite.send(
+		middlewares.Sign(middlewares.SignatureEd25519, suite.keys("v1", map[string][]byte{"v1": private})),
+		func(_ context.Context, msg *extensions.BrokerMessage, _ extensions.NextMiddleware) error {
+			msg.Payload = []byte(`{"email":"mallory@example.com"}`)
+			return nil
+		})
+	suite.expectError(errs, extensions.ErrInvalidSignature)
+}
+
+func (suite *Suite) TestEncryptionAndSignature() {
+	encryptionKeys := suite.keys("v1", map[string][]byte{"v1": bytes.Repeat([]byte("k"), 32)})
+	signatureKeys := suite.keys("v1", map[string][]byte{"v1": []byte("secret")})
+
+	received, _ := suite.subscribe(
+		middlewares.VerifySignature(middlewares.SignatureHMACSHA256, signatureKeys),
+		middlewares.Decrypt(encryptionKeys))
+	suite.send(
+		middlewares.Encrypt(encryptionKeys),
+		middlewares.Sign(middlewares.SignatureHMACSHA256, signatureKeys))
+
+	suite.expectReceived(received)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
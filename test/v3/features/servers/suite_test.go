@@ -0,0 +1,127 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p servers -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package servers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/nats"
+	testutil "github.com/lerenn/asyncapi-codegen/pkg/utils/test"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func (suite *Suite) TestServers() {
+	suite.Require().Len(AsyncAPIServers, 6)
+
+	production := AsyncAPIServers["production"]
+	suite.Require().Equal("kafka-secure", production.Protocol)
+	suite.Require().Equal(map[string]extensions.ServerVariable{
+		"region": {Default: "eu", Enum: []string{"eu", "us"}},
+		"port":   {Default: "9093"},
+	}, production.Variables)
+	suite.Require().Equal([]extensions.SecurityScheme{
+		{Type: "scramSha512", Description: "SASL/SCRAM authentication"},
+	}, production.Security)
+
+	suite.Require().Equal([]extensions.SecurityScheme{
+		{Type: "oauth2", TokenURL: "https://auth.example.com/token", Scopes: []string{"events:read"}},
+		{Type: "userPassword"},
+	}, AsyncAPIServers["secured"].Security)
+
+	// Referenced server
+	suite.Require().Equal(extensions.Server{
+		Name:     "mqtt",
+		Host:     "mqtt.example.com:8883",
+		Protocol: "secure-mqtt",
+	}, AsyncAPIServers["mqtt"])
+}
+
+func (suite *Suite) TestURL() {
+	production := AsyncAPIServers["production"]
+
+	url, err := production.URL(nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal("kafka-secure://events.eu.example.com:9093", url)
+
+	url, err = production.URL(map[string]string{"region": "us"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("kafka-secure://events.us.example.com:9093", url)
+
+	_, err = production.URL(map[string]string{"region": "asia"})
+	suite.Require().ErrorIs(err, extensions.ErrServerVariable)
+
+	url, err = AsyncAPIServers["rabbitmq"].URL(nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal("amqps://rabbitmq.example.com/vhost", url)
+}
+
+func (suite *Suite) TestNewBrokerFromServer() {
+	host := testutil.BrokerAddress(testutil.BrokerAddressParams{DockerizedAddr: "nats"})
+	broker, err := NewBrokerFromServer("local",
+		WithServerVariables(map[string]string{"host": host}),
+		WithNATSOptions(nats.WithQueueGroup("servers-"+suite.T().Name())))
+	suite.Require().NoError(err)
+	suite.Require().IsType(&nats.Controller{}, broker)
+	defer broker.(*nats.Controller).Close()
+
+	// The broker can be used by the application
+	app, err := NewAppController(broker)
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	received := make(chan OrderMessage, 1)
+	suite.Require().NoError(app.SubscribeToReceiveOrderOperation(context.Background(),
+		func(_ context.Context, msg OrderMessage) error {
+			received <- msg
+			return nil
+		}))
+
+	id := "1234"
+	suite.Require().NoError(broker.Publish(context.Background(), "v3.servers.order", extensions.BrokerMessage{
+		Payload: []byte(`{"id":"1234"}`),
+	}))
+	select {
+	case msg := <-received:
+		suite.Require().Equal(&id, msg.Payload.Id)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("message not received")
+	}
+}
+
+func (suite *Suite) TestNewBrokerFromServerErrors() {
+	_, err := NewBrokerFromServer("unknown")
+	suite.Require().ErrorIs(err, extensions.ErrUnknownServer)
+
+	_, err = NewBrokerFromServer("websocket")
+	suite.Require().ErrorIs(err, extensions.ErrUnsupportedProtocol)
+
+	_, err = NewBrokerFromServer("production", WithServerVariables(map[string]string{"region": "asia"}))
+	suite.Require().ErrorIs(err, extensions.ErrServerVariable)
+
+	_, err = NewBrokerFromServer("secured")
+	suite.Require().ErrorIs(err, extensions.ErrMissingCredentials)
+}
+
+func (suite *Suite) TestNewBrokerFromServerWithCredentials() {
+	host := testutil.BrokerAddress(testutil.BrokerAddressParams{DockerizedAddr: "nats"})
+	broker, err := NewBrokerFromServer("secured",
+		WithServerVariables(map[string]string{"host": host}),
+		WithServerCredentials(extensions.Credentials{
+			UserPassword: &extensions.UserPasswordCredentials{Username: "user", Password: "password"},
+		}))
+	suite.Require().NoError(err)
+	nb := broker.(*nats.Controller)
+	defer nb.Close()
+	suite.Require().NoError(nb.Healthy(context.Background()))
+}
@@ -0,0 +1,48 @@
+// Code generated by github.com/lerenn/asyncapi-codegen version (devel) DO NOT EDIT.
+
+package split
+
+import (
+	"fmt"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+)
+
+// OrdersChannelParameters represents OrdersChannel channel parameters
+type OrdersChannelParameters struct {
+	// Region is a channel parameter: Region of the orders.
+	Region string
+}
+
+// Address returns the address of OrdersChannel channel with the parameters.
+func (params OrdersChannelParameters) Address() string {
+	return fmt.Sprintf("v3.features.split.orders.%s", params.Region)
+}
+
+// ParseOrdersChannelParameters parses the parameters of OrdersChannel
+// channel from one of its addresses, like the address of a received message.
+func ParseOrdersChannelParameters(addr string) (OrdersChannelParameters, error) {
+	values, err := extensions.ParseChannelAddress("v3.features.split.orders.{region}", addr)
+	if err != nil {
+		return OrdersChannelParameters{}, err
+	}
+
+	return OrdersChannelParameters{
+		Region: values["region"],
+	}, nil
+}
+
+// Message 'OrderMessageFromOrdersChannel' reference another one at '#/components/messages/order'.
+// This should be fixed in a future version to allow message override.
+// If you encounter this message, feel free to open an issue on this subject
+// to let know that you need this functionnality.
+
+const (
+	// OrdersChannelPath is the constant representing the 'OrdersChannel' channel path.
+	OrdersChannelPath = "v3.features.split.orders.{region}"
+)
+
+// ChannelsPaths is an array of all channels paths
+var ChannelsPaths = []string{
+	OrdersChannelPath,
+}
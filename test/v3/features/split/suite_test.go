@@ -0,0 +1,76 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p split -i ./asyncapi.yaml -o . --split
+
+package split
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestFiles() {
+	for file, content := range map[string]string{
+		"types.gen.go":    "type OrderSchema struct",
+		"channels.gen.go": "type OrdersChannelParameters struct",
+		"app.gen.go":      "type AppController struct",
+		"user.gen.go":     "type UserController struct",
+	} {
+		b, err := os.ReadFile(file)
+		suite.Require().NoError(err)
+		suite.Require().Contains(string(b), content, file)
+	}
+}
+
+func (suite *Suite) TestOrder() {
+	params := OrdersChannelParameters{Region: "eu"}
+	received := make(chan OrderMessage, 1)
+	err := suite.app.SubscribeToReceiveOrderOperation(context.Background(), params,
+		func(_ context.Context, msg OrderMessage) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveOrderOperation(context.Background(), params)
+
+	quantity := int64(2)
+	sent := NewOrderMessage()
+	sent.Payload = OrderSchema{Id: "order-1", Quantity: &quantity}
+	suite.Require().NoError(suite.user.SendToReceiveOrderOperation(context.Background(), params, sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,72 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p templates -i ./asyncapi.yaml -o ./asyncapi.gen.go --template-dir ./overrides
+
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	app  *AppController
+	user *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	suite.app, err = NewAppController(broker)
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestOverriddenTemplate() {
+	// The subscriber template is replaced
+	suite.Require().Equal([]string{"ReceiveEventOperation"}, AppSubscriberOperations)
+}
+
+func (suite *Suite) TestOverriddenDefinition() {
+	// The time marshaling is replaced, with Unix timestamps
+	at := TimestampSchema(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	b, err := json.Marshal(EventSchema{At: at})
+	suite.Require().NoError(err)
+	suite.Require().JSONEq(`{"at":1709294400}`, string(b))
+
+	received := make(chan EventMessageFromEventsChannel, 1)
+	err = suite.app.SubscribeToReceiveEventOperation(context.Background(),
+		func(_ context.Context, msg EventMessageFromEventsChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromReceiveEventOperation(context.Background())
+
+	sent := NewEventMessageFromEventsChannel()
+	sent.Payload = EventSchema{At: at}
+	suite.Require().NoError(suite.user.SendToReceiveEventOperation(context.Background(), sent))
+
+	select {
+	case msg := <-received:
+		suite.Require().Equal(sent, msg)
+	case <-time.After(time.Second):
+		suite.FailNow("no message received")
+	}
+}
@@ -0,0 +1,128 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p tenant -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package tenant
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type tenantKey struct{}
+
+// withTenant returns the context with the tenant given to the resolver.
+func withTenant(tenant string) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, tenant)
+}
+
+func resolver(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	app    *AppController
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.app, err = NewAppController(broker, WithTenantResolver(resolver))
+	suite.Require().NoError(err)
+
+	suite.user, err = NewUserController(broker, WithTenantResolver(resolver))
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.app.Close(context.Background())
+	suite.user.Close(context.Background())
+	suite.broker.Close()
+}
+
+// subscribe subscribes the app for the tenant, replying with the tenant of
+// the received messages.
+func (suite *Suite) subscribe(tenant string) {
+	err := suite.app.SubscribeToPingOperation(withTenant(tenant), func(ctx context.Context, msg PingMessage) error {
+		from, _ := extensions.TenantFromContext(ctx)
+		return suite.app.ReplyToPingOperation(ctx, msg, func(replyMsg *PongMessage) {
+			replyMsg.Payload = "pong from " + from
+		})
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TestIsolation() {
+	suite.subscribe("acme")
+	defer suite.app.UnsubscribeFromPingOperation(withTenant("acme"))
+
+	ctx, cancel := context.WithTimeout(withTenant("acme"), time.Second)
+	defer cancel()
+	reply, err := suite.user.RequestToPingOperation(ctx, NewPingMessage())
+	suite.Require().NoError(err)
+	suite.Require().Equal("pong from acme", reply.Payload)
+
+	// The request and its reply are on the channels of the tenant
+	for _, addr := range []string{"acme.v3.tenant.ping", "acme.v3.tenant.pong"} {
+		published := suite.broker.Published(addr)
+		suite.Require().Len(published, 1, addr)
+		suite.Require().Equal("acme", string(published[0].Headers[extensions.StandardHeaderTenant]), addr)
+	}
+
+	// The requests of another tenant are not received
+	ctx, cancel = context.WithTimeout(withTenant("globex"), 100*time.Millisecond)
+	defer cancel()
+	_, err = suite.user.RequestToPingOperation(ctx, NewPingMessage())
+	suite.Require().ErrorIs(err, context.DeadlineExceeded)
+	suite.Require().Len(suite.broker.Published("globex.v3.tenant.ping"), 1)
+}
+
+func (suite *Suite) TestOtherTenantHeader() {
+	var received atomic.Int32
+	err := suite.app.SubscribeToPingOperation(withTenant("acme"), func(_ context.Context, _ PingMessage) error {
+		received.Add(1)
+		return nil
+	})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromPingOperation(withTenant("acme"))
+
+	// The messages on the channels of the tenant but for another tenant, or
+	// without tenant, are acknowledged without being received
+	for _, headers := range []map[string][]byte{{extensions.StandardHeaderTenant: []byte("globex")}, {}} {
+		suite.Require().NoError(suite.broker.Publish(context.Background(), "acme.v3.tenant.ping",
+			extensions.BrokerMessage{Headers: headers, Payload: []byte(`"ping"`)}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	suite.Require().NoError(suite.broker.WaitForAcknowledgements(ctx))
+
+	suite.Require().Zero(received.Load())
+	for _, d := range suite.broker.Deliveries() {
+		suite.Require().Equal(inmemory.DeliveryAcked, d.Status)
+	}
+}
+
+func (suite *Suite) TestNoTenant() {
+	err := suite.app.SubscribeToPingOperation(context.Background(), func(_ context.Context, _ PingMessage) error {
+		return nil
+	})
+	suite.Require().ErrorIs(err, extensions.ErrNoTenant)
+
+	_, err = suite.user.RequestToPingOperation(context.Background(), NewPingMessage())
+	suite.Require().ErrorIs(err, extensions.ErrNoTenant)
+}
@@ -0,0 +1,82 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p traits -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package traits
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+	broker *inmemory.Controller
+	user   *UserController
+}
+
+func (suite *Suite) SetupTest() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+	suite.broker = broker
+
+	suite.user, err = NewUserController(broker)
+	suite.Require().NoError(err)
+}
+
+func (suite *Suite) TearDownTest() {
+	suite.user.Close(context.Background())
+}
+
+func (suite *Suite) TestHeadersFromTraits() {
+	msg := NewUserSignedUpMessage()
+	msg.Headers.Origin = utils.ToPointer("web")
+	msg.Headers.Tenant = "acme"
+	msg.Headers.TraceId = utils.ToPointer("abc")
+	suite.Require().NoError(suite.user.SendToReceiveSignedUpOperation(context.Background(), msg))
+
+	// The headers of the message and of all the traits should be sent
+	published := suite.broker.Published("v3.traits.user.signedup")
+	suite.Require().Len(published, 1)
+	suite.Require().Equal("web", string(published[0].Headers["origin"]))
+	suite.Require().Equal("acme", string(published[0].Headers["tenant"]))
+	suite.Require().Equal("abc", string(published[0].Headers["traceId"]))
+
+	// And the referenced headers schema should not be changed by the traits
+	suite.Require().Equal(1, reflect.TypeOf(SignUpHeadersSchema{}).NumField())
+}
+
+func (suite *Suite) TestValuesFromLastTrait() {
+	msg := NewUserDeletedMessage()
+	msg.Headers.Tenant = "acme"
+	suite.Require().NoError(suite.user.SendToReceiveDeletedOperation(context.Background(), msg))
+
+	// The content type of the last trait takes precedence over the first one
+	published := suite.broker.Published("v3.traits.user.deleted")
+	suite.Require().Len(published, 1)
+	suite.Require().Equal("application/json", published[0].ContentType)
+}
+
+func (suite *Suite) TestCorrelationIDFromTrait() {
+	msg := NewUserDeletedMessage()
+	msg.SetCorrelationID("abc")
+	suite.Require().Equal("abc", *msg.Headers.TraceId)
+	suite.Require().Equal("abc", msg.CorrelationID())
+}
+
+func (suite *Suite) TestOperationBindingsFromTraits() {
+	// The bindings of all the traits should be merged
+	suite.Require().Equal(5, ReceiveSignedUpOperationBindings.AMQP.Priority)
+	suite.Require().True(ReceiveSignedUpOperationBindings.AMQP.Ack)
+
+	// But the ones of the operation take precedence
+	suite.Require().Equal(1, ReceiveDeletedOperationBindings.AMQP.Priority)
+	suite.Require().False(ReceiveDeletedOperationBindings.AMQP.Ack)
+}
@@ -0,0 +1,159 @@
+//go:generate go run ../../../../cmd/asyncapi-codegen -p validation --string-formats date,date-time -i ./asyncapi.yaml -o ./asyncapi.gen.go
+
+package validation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions"
+	"github.com/lerenn/asyncapi-codegen/pkg/extensions/brokers/inmemory"
+	"github.com/lerenn/asyncapi-codegen/pkg/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+type Suite struct {
+	suite.Suite
+}
+
+func validUser() UserMessageFromUsersChannel {
+	msg := NewUserMessageFromUsersChannel()
+	msg.Headers.RequestId = utils.ToPointer("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	msg.Payload = UserMessageFromUsersChannelPayload{
+		Name:     "Alice",
+		Email:    "alice@example.com",
+		Age:      utils.ToPointer(int64(30)),
+		Score:    utils.ToPointer(9.5),
+		Role:     utils.ToPointer(RolePropertyFromUserMessageFromUsersChannelPayloadAdmin),
+		Level:    utils.ToPointer(LevelPropertyFromUserMessageFromUsersChannelPayload2),
+		Tags:     []string{"a", "b"},
+		Address:  &AddressSchema{City: "Paris", ZipCode: utils.ToPointer(ZipCodeSchema("75001"))},
+		Contacts: []AddressSchema{{City: "Lyon"}},
+	}
+	return msg
+}
+
+func (suite *Suite) TestValidMessage() {
+	suite.Require().NoError(validUser().Validate())
+}
+
+func (suite *Suite) TestInvalidMessage() {
+	msg := validUser()
+	msg.Headers.RequestId = utils.ToPointer("not-a-uuid")
+	msg.Payload.Name = "a"
+	msg.Payload.Email = "alice"
+	msg.Payload.Age = utils.ToPointer(int64(150))
+	msg.Payload.Score = utils.ToPointer(10.0)
+	msg.Payload.Role = utils.ToPointer(RolePropertyFromUserMessageFromUsersChannelPayload("owner"))
+	msg.Payload.Level = utils.ToPointer(LevelPropertyFromUserMessageFromUsersChannelPayload(4))
+	msg.Payload.Tags = []string{"a", "toolong"}
+	msg.Payload.Address.ZipCode = utils.ToPointer(ZipCodeSchema("7500"))
+	msg.Payload.Contacts = []AddressSchema{{}}
+
+	err := msg.Validate()
+	suite.Require().ErrorIs(err, extensions.ErrValidation)
+
+	var errs extensions.ValidationErrors
+	suite.Require().ErrorAs(err, &errs)
+	constraints := make(map[string]string, len(errs))
+	for _, e := range errs {
+		constraints[e.Field] += e.Constraint + " "
+	}
+	suite.Require().Equal(map[string]string{
+		"headers.requestId":        "format ",
+		"payload.name":             "minLength pattern ",
+		"payload.email":            "format ",
+		"payload.age":              "exclusiveMaximum ",
+		"payload.score":            "maximum ",
+		"payload.role":             "enum ",
+		"payload.level":            "enum ",
+		"payload.tags[1]":          "maxLength ",
+		"payload.address.zipCode":  "pattern ",
+		"payload.contacts[0].city": "minLength ",
+	}, constraints)
+}
+
+func (suite *Suite) TestRequiredFields() {
+	msg := validUser()
+	msg.Payload.Tags = nil
+
+	err := msg.Validate()
+	suite.Require().ErrorIs(err, extensions.ErrValidation)
+	suite.Require().ErrorContains(err, "payload.tags: is required")
+	suite.Require().ErrorContains(err, "payload.tags: should have at least 1 items")
+}
+
+func (suite *Suite) TestValidationOnSend() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	// Invalid messages are sent without validation
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	msg := validUser()
+	msg.Payload.Name = "a"
+	suite.Require().NoError(user.SendToReceiveUserOperation(context.Background(), msg))
+
+	// And rejected with validation
+	user, err = NewUserController(broker, WithValidation())
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	err = user.SendToReceiveUserOperation(context.Background(), msg)
+	suite.Require().ErrorIs(err, extensions.ErrValidation)
+	suite.Require().NoError(user.SendToReceiveUserOperation(context.Background(), validUser()))
+}
+
+func (suite *Suite) TestValidationOnReception() {
+	broker, err := inmemory.NewController()
+	suite.Require().NoError(err)
+
+	errs := make(chan error, 1)
+	app, err := NewAppController(broker, WithValidation(), WithErrorHandler(
+		func(_ context.Context, _ string, _ *extensions.AcknowledgeableBrokerMessage, err error) {
+			errs <- err
+		}))
+	suite.Require().NoError(err)
+	defer app.Close(context.Background())
+
+	received := make(chan UserMessageFromUsersChannel, 1)
+	err = app.SubscribeToReceiveUserOperation(context.Background(),
+		func(_ context.Context, msg UserMessageFromUsersChannel) error {
+			received <- msg
+			return nil
+		})
+	suite.Require().NoError(err)
+
+	user, err := NewUserController(broker)
+	suite.Require().NoError(err)
+	defer user.Close(context.Background())
+
+	// Invalid message is given to the error handler
+	msg := validUser()
+	msg.Payload.Email = "alice"
+	suite.Require().NoError(user.SendToReceiveUserOperation(context.Background(), msg))
+	select {
+	case err := <-errs:
+		suite.Require().ErrorIs(err, extensions.ErrValidation)
+	case <-received:
+		suite.FailNow("invalid message should not be received")
+	case <-time.After(time.Second):
+		suite.FailNow("no error on invalid message")
+	}
+
+	// Valid message is received
+	suite.Require().NoError(user.SendToReceiveUserOperation(context.Background(), validUser()))
+	select {
+	case msg := <-received:
+		suite.Require().Equal("Alice", msg.Payload.Name)
+	case <-time.After(time.Second):
+		suite.FailNow("valid message not received")
+	}
+}
@@ -79,6 +79,32 @@ func (suite *Suite) TestRequestReplyWithReplyHelper() {
 	suite.Require().Equal(*msg.Payload.Event, *resp.Payload.Event)
 }
 
+func (suite *Suite) TestRequestReplyWithTemporaryReplyAddress() {
+	// Listen for pings on the application
+	err := suite.app.SubscribeToPingRequestOperation(
+		context.Background(),
+		func(ctx context.Context, ping PingMessage) error {
+			callbackErr := suite.app.ReplyToPingRequestOperation(ctx, ping, func(pong *PongMessage) {
+				pong.Payload.Event = ping.Payload.Event
+			})
+			suite.Require().NoError(callbackErr)
+			return nil
+		})
+	suite.Require().NoError(err)
+	defer suite.app.UnsubscribeFromPingRequestOperation(context.Background())
+
+	// Set a new ping, without reply address
+	var msg PingMessage
+	msg.Payload.Event = utils.ToPointer("testing.temporary")
+
+	// Send a request
+	resp, err := suite.user.RequestToPingRequestOperation(context.Background(), msg)
+	suite.Require().NoError(err)
+
+	// Check response
+	suite.Require().Equal(*msg.Payload.Event, *resp.Payload.Event)
+}
+
 func (suite *Suite) TestRequestReplyOnRawChannel() {
 	// Listen for pings on the application
 	err := suite.app.SubscribeToPingRequestOperation(